@@ -0,0 +1,153 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package annotations keeps notes on periods of time, such as planned
+// maintenance, which apply to the series matching a predicate. Annotations
+// of type "maintenance" provide the maintenance windows of select queries.
+package annotations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/query/predicate"
+	"gopkg.in/yaml.v2"
+)
+
+// Maintenance is the type of the annotations which describe planned downtime.
+const Maintenance = "maintenance"
+
+// Annotation notes a period of time for the series matching its predicate.
+type Annotation struct {
+	Type  string    `yaml:"type" json:"type"`             // such as maintenance
+	Where string    `yaml:"where" json:"where,omitempty"` // a predicate, written as in the where clause of a select; empty for every series
+	Start time.Time `yaml:"start" json:"start"`           // inclusive
+	End   time.Time `yaml:"end" json:"end"`               // exclusive
+	Text  string    `yaml:"text" json:"text,omitempty"`
+}
+
+// Store holds the current annotations. It is safe for concurrent use.
+type Store struct {
+	path        string
+	mutex       sync.RWMutex
+	annotations []Annotation
+	predicates  []predicate.Predicate // of each annotation; nil for every series
+}
+
+var _ command.MaintenanceAPI = (*Store)(nil)
+
+// NewStore creates a store holding the given annotations.
+func NewStore(annotations []Annotation) (*Store, error) {
+	store := &Store{}
+	if err := store.Replace(annotations); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// LoadStore creates a store from the given YAML file, which lists the
+// annotations. An empty path results in an empty store.
+func LoadStore(path string) (*Store, error) {
+	store, err := NewStore(nil)
+	if err != nil {
+		return nil, err
+	}
+	store.path = path
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload re-reads the file that the store was loaded from.
+// The current annotations are kept if the file cannot be loaded.
+func (store *Store) Reload() error {
+	if store.path == "" {
+		return nil
+	}
+	bytes, err := ioutil.ReadFile(store.path)
+	if err != nil {
+		return err
+	}
+	annotations := []Annotation{}
+	if err := yaml.Unmarshal(bytes, &annotations); err != nil {
+		return fmt.Errorf("unable to parse annotations `%s`: %s", store.path, err.Error())
+	}
+	return store.Replace(annotations)
+}
+
+// Replace validates the given annotations and swaps them in for the current ones.
+func (store *Store) Replace(annotations []Annotation) error {
+	predicates := make([]predicate.Predicate, len(annotations))
+	for i, annotation := range annotations {
+		if annotation.Type == "" {
+			return fmt.Errorf("annotation %d has no type", i)
+		}
+		if !annotation.Start.Before(annotation.End) {
+			return fmt.Errorf("annotation %d must start before it ends", i)
+		}
+		if annotation.Where == "" {
+			continue
+		}
+		condition, err := parsePredicate(annotation.Where)
+		if err != nil {
+			return fmt.Errorf("annotation %d has an invalid predicate: %s", i, err.Error())
+		}
+		predicates[i] = condition
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.annotations = annotations
+	store.predicates = predicates
+	return nil
+}
+
+// parsePredicate parses a predicate as it's written in the where clause of a select.
+func parsePredicate(where string) (predicate.Predicate, error) {
+	cmd, err := parser.Parse(fmt.Sprintf("select x where %s from 0 to 0", where))
+	if err != nil {
+		return nil, err
+	}
+	return cmd.(*command.SelectCommand).Predicate, nil
+}
+
+// Annotations returns a copy of the current annotations.
+func (store *Store) Annotations() []Annotation {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return append([]Annotation{}, store.annotations...)
+}
+
+// GetMaintenanceWindows returns the maintenance annotations which overlap the timerange.
+func (store *Store) GetMaintenanceWindows(timerange api.Timerange) ([]command.MaintenanceWindow, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	windows := []command.MaintenanceWindow{}
+	for i, annotation := range store.annotations {
+		if annotation.Type != Maintenance || annotation.Start.After(timerange.End()) || !annotation.End.After(timerange.Start()) {
+			continue
+		}
+		windows = append(windows, command.MaintenanceWindow{
+			Predicate: store.predicates[i],
+			Start:     annotation.Start,
+			End:       annotation.End,
+		})
+	}
+	return windows, nil
+}
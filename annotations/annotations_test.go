@@ -0,0 +1,90 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotations
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestStore_Load(t *testing.T) {
+	a := assert.New(t)
+	file, err := ioutil.TempFile("", "annotations")
+	a.CheckError(err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`
+- type: maintenance
+  where: dc = 'east'
+  start: 2016-10-01T00:00:00Z
+  end: 2016-10-01T02:00:00Z
+  text: rack move
+- type: deploy
+  start: 2016-10-01T01:00:00Z
+  end: 2016-10-01T01:10:00Z
+- type: maintenance
+  start: 2016-10-02T00:00:00Z
+  end: 2016-10-02T01:00:00Z
+`)
+	a.CheckError(err)
+	a.CheckError(file.Close())
+
+	store, err := LoadStore(file.Name())
+	a.CheckError(err)
+	a.EqInt(len(store.Annotations()), 3)
+
+	start := time.Date(2016, 10, 1, 1, 0, 0, 0, time.UTC)
+	timerange, err := api.NewSnappedTimerange(start.UnixNano()/1e6, start.Add(2*time.Hour).UnixNano()/1e6, 60*1000)
+	a.CheckError(err)
+	windows, err := store.GetMaintenanceWindows(timerange)
+	a.CheckError(err)
+	a.EqInt(len(windows), 1) // neither the deploy nor the later window
+	a.EqString(windows[0].Predicate.Query(), `dc = "east"`)
+	a.EqBool(windows[0].Predicate.Apply(api.TagSet{"dc": "east"}), true)
+	a.EqBool(windows[0].Predicate.Apply(api.TagSet{"dc": "west"}), false)
+	a.Eq(windows[0].End, time.Date(2016, 10, 1, 2, 0, 0, 0, time.UTC))
+
+	// A window which ends as the timerange starts doesn't overlap it.
+	windows, err = store.GetMaintenanceWindows(timerange.Shift(time.Hour))
+	a.CheckError(err)
+	a.EqInt(len(windows), 0)
+
+	// A broken file keeps the previous annotations.
+	a.CheckError(ioutil.WriteFile(file.Name(), []byte("- type: maintenance\n  where: dc =\n  start: 2016-10-01T00:00:00Z\n  end: 2016-10-01T02:00:00Z\n"), 0644))
+	if err := store.Reload(); err == nil {
+		a.Errorf("expected an error reloading an annotation with an invalid predicate")
+	}
+	a.EqInt(len(store.Annotations()), 3)
+
+	empty, err := LoadStore("")
+	a.CheckError(err)
+	a.EqInt(len(empty.Annotations()), 0)
+}
+
+func TestStore_Replace(t *testing.T) {
+	start := time.Unix(0, 0)
+	for _, invalid := range [][]Annotation{
+		{{Start: start, End: start.Add(time.Hour)}},
+		{{Type: Maintenance, Start: start, End: start}},
+	} {
+		if _, err := NewStore(invalid); err == nil {
+			t.Errorf("expected an error creating a store of %+v", invalid)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package anomaly scores how unusual each point of a series is, with
+// detectors chosen by name. Scores are comparable to z-scores: the number of
+// (robust) standard deviations between a point and what was expected of it.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Options tune a detector.
+type Options struct {
+	Period    int     // the number of points in a season, such as a day; zero if the series isn't seasonal
+	Threshold float64 // the score above which a point is anomalous, for detectors without a test of their own
+}
+
+// Result holds the score of each point of a series, and the indices of the
+// points found to be anomalous. The scores of missing points are NaN.
+type Result struct {
+	Scores    []float64
+	Anomalies []int // in increasing order
+}
+
+// A Detector scores the points of a series.
+type Detector interface {
+	Detect(values []float64, options Options) (Result, error)
+}
+
+var (
+	detectorsMutex sync.RWMutex
+	detectors      = map[string]Detector{
+		"zscore":       ZScore{},
+		"seasonal_esd": SeasonalESD{Alpha: 0.05, MaxAnomalies: 0.1},
+	}
+)
+
+// Register adds a detector, which may then be chosen by name.
+func Register(name string, detector Detector) {
+	detectorsMutex.Lock()
+	defer detectorsMutex.Unlock()
+	detectors[name] = detector
+}
+
+// Lookup finds the detector with the name.
+func Lookup(name string) (Detector, error) {
+	detectorsMutex.RLock()
+	defer detectorsMutex.RUnlock()
+	detector, ok := detectors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown anomaly detector %q; expected one of %s", name, strings.Join(namesLocked(), ", "))
+	}
+	return detector, nil
+}
+
+// Names lists the registered detectors.
+func Names() []string {
+	detectorsMutex.RLock()
+	defer detectorsMutex.RUnlock()
+	return namesLocked()
+}
+
+func namesLocked() []string {
+	names := make([]string, 0, len(detectors))
+	for name := range detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ZScore scores each point by its distance from the mean of the series, in
+// standard deviations. It suits series without trends or seasons.
+type ZScore struct{}
+
+// Detect scores the points, marking those scoring above the threshold (3 if
+// it isn't set) as anomalous.
+func (ZScore) Detect(values []float64, options Options) (Result, error) {
+	threshold := options.Threshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+	present := presentValues(values)
+	mean, deviation := meanAndDeviation(present)
+	result := Result{Scores: make([]float64, len(values)), Anomalies: []int{}}
+	for i, value := range values {
+		switch {
+		case !isPresent(value):
+			result.Scores[i] = math.NaN()
+			continue
+		case deviation == 0:
+			result.Scores[i] = 0
+		default:
+			result.Scores[i] = math.Abs(value-mean) / deviation
+		}
+		if result.Scores[i] > threshold {
+			result.Anomalies = append(result.Anomalies, i)
+		}
+	}
+	return result, nil
+}
+
+// SeasonalESD is the seasonal hybrid ESD detector. It removes the median of
+// each phase of the season (if there is one) and of the series, and then
+// applies the generalized extreme Studentized deviate test to what remains,
+// with the median and the median absolute deviation standing in for the mean
+// and standard deviation so that the anomalies themselves don't mask others.
+type SeasonalESD struct {
+	Alpha        float64 // the significance level of the test
+	MaxAnomalies float64 // the largest fraction of the points which may be anomalous
+}
+
+// Detect scores each point by its robust deviation from the season, and marks
+// those which the test finds anomalous.
+func (d SeasonalESD) Detect(values []float64, options Options) (Result, error) {
+	if options.Period < 0 || options.Period > 1 && 2*options.Period > len(values) {
+		return Result{}, fmt.Errorf("the period of %d points needs at least two seasons, but the series has %d points", options.Period, len(values))
+	}
+	residuals := deseasonalize(values, options.Period)
+	present := presentValues(residuals)
+	center := median(present)
+	spread := medianAbsoluteDeviation(present, center)
+
+	result := Result{Scores: make([]float64, len(values)), Anomalies: []int{}}
+	for i, residual := range residuals {
+		switch {
+		case !isPresent(residual):
+			result.Scores[i] = math.NaN()
+		case spread == 0:
+			result.Scores[i] = 0
+		default:
+			result.Scores[i] = math.Abs(residual-center) / spread
+		}
+	}
+	if spread == 0 {
+		return result, nil
+	}
+
+	// The generalized ESD test: remove the most extreme point repeatedly, and
+	// keep as anomalies those removed before the last one which exceeded its
+	// critical value.
+	remaining := []int{}
+	for i := range residuals {
+		if isPresent(residuals[i]) {
+			remaining = append(remaining, i)
+		}
+	}
+	n := len(remaining)
+	maxAnomalies := int(d.MaxAnomalies * float64(n))
+	removed := []int{}
+	anomalies := 0
+	for k := 1; k <= maxAnomalies && len(remaining) > 2; k++ {
+		remainingValues := make([]float64, len(remaining))
+		for i, index := range remaining {
+			remainingValues[i] = residuals[index]
+		}
+		center := median(remainingValues)
+		spread := medianAbsoluteDeviation(remainingValues, center)
+		if spread == 0 {
+			break
+		}
+		extreme := 0
+		for i := range remainingValues {
+			if math.Abs(remainingValues[i]-center) > math.Abs(remainingValues[extreme]-center) {
+				extreme = i
+			}
+		}
+		statistic := math.Abs(remainingValues[extreme]-center) / spread
+		removed = append(removed, remaining[extreme])
+		remaining = append(remaining[:extreme], remaining[extreme+1:]...)
+		if statistic > esdCriticalValue(n, k, d.Alpha) {
+			anomalies = k
+		}
+	}
+	result.Anomalies = append(result.Anomalies, removed[:anomalies]...)
+	sort.Ints(result.Anomalies)
+	return result, nil
+}
+
+// deseasonalize subtracts the median of each phase of the season, and then
+// the median of the series.
+func deseasonalize(values []float64, period int) []float64 {
+	residuals := make([]float64, len(values))
+	copy(residuals, values)
+	if period > 1 {
+		for phase := 0; phase < period; phase++ {
+			points := []float64{}
+			for i := phase; i < len(values); i += period {
+				if isPresent(values[i]) {
+					points = append(points, values[i])
+				}
+			}
+			seasonal := median(points)
+			for i := phase; i < len(values); i += period {
+				residuals[i] -= seasonal
+			}
+		}
+	}
+	center := median(presentValues(residuals))
+	for i := range residuals {
+		residuals[i] -= center
+	}
+	return residuals
+}
+
+// esdCriticalValue is the critical value of the kth test of the generalized
+// ESD test over n points.
+func esdCriticalValue(n int, k int, alpha float64) float64 {
+	remaining := float64(n - k + 1)
+	p := 1 - alpha/(2*remaining)
+	t := studentTQuantile(p, remaining-2)
+	return (remaining - 1) * t / math.Sqrt((remaining-2+t*t)*remaining)
+}
+
+// studentTQuantile approximates the quantile of Student's t-distribution with
+// the given degrees of freedom, by the Cornish-Fisher expansion about the
+// normal quantile.
+func studentTQuantile(p float64, freedom float64) float64 {
+	z := math.Sqrt2 * math.Erfinv(2*p-1)
+	z3 := z * z * z
+	z5 := z3 * z * z
+	z7 := z5 * z * z
+	return z +
+		(z3+z)/(4*freedom) +
+		(5*z5+16*z3+3*z)/(96*freedom*freedom) +
+		(3*z7+19*z5+17*z3-15*z)/(384*freedom*freedom*freedom)
+}
+
+func isPresent(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+func presentValues(values []float64) []float64 {
+	present := make([]float64, 0, len(values))
+	for _, value := range values {
+		if isPresent(value) {
+			present = append(present, value)
+		}
+	}
+	return present
+}
+
+func meanAndDeviation(values []float64) (float64, float64) {
+	if len(values) < 2 {
+		return 0, 0
+	}
+	mean := 0.0
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+	variance /= float64(len(values) - 1)
+	return mean, math.Sqrt(variance)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return (sorted[middle-1] + sorted[middle]) / 2
+}
+
+// medianAbsoluteDeviation is scaled to estimate the standard deviation of
+// normally distributed values.
+func medianAbsoluteDeviation(values []float64, center float64) float64 {
+	deviations := make([]float64, len(values))
+	for i, value := range values {
+		deviations[i] = math.Abs(value - center)
+	}
+	return 1.4826 * median(deviations)
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomaly
+
+import (
+	"math"
+	"testing"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestZScore(t *testing.T) {
+	a := assert.New(t)
+	values := []float64{1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 20, math.NaN()}
+	result, err := ZScore{}.Detect(values, Options{})
+	a.CheckError(err)
+	a.Eq(result.Anomalies, []int{14})
+	a.EqBool(math.IsNaN(result.Scores[15]), true)
+	a.EqBool(result.Scores[14] > 3, true)
+
+	result, err = ZScore{}.Detect([]float64{5, 5, 5}, Options{})
+	a.CheckError(err)
+	a.EqFloatArray(result.Scores, []float64{0, 0, 0}, 1e-9)
+	a.Eq(result.Anomalies, []int{})
+}
+
+func TestSeasonalESD(t *testing.T) {
+	a := assert.New(t)
+	detector, err := Lookup("seasonal_esd")
+	a.CheckError(err)
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = 10*math.Sin(2*math.Pi*float64(i)/10) + 0.1*float64(i%3)
+	}
+	// A spike which is within the range of the season, but not of its phase.
+	values[52] = 12
+	values[81] = math.NaN()
+
+	result, err := detector.Detect(values, Options{Period: 10})
+	a.CheckError(err)
+	a.Eq(result.Anomalies, []int{52})
+	a.EqBool(math.IsNaN(result.Scores[81]), true)
+
+	// Without the season, the spike is lost among the peaks.
+	result, err = detector.Detect(values, Options{})
+	a.CheckError(err)
+	a.Eq(result.Anomalies, []int{})
+
+	if _, err := detector.Detect(values, Options{Period: 60}); err == nil {
+		t.Errorf("expected an error for a period longer than half the series")
+	}
+}
+
+func TestStudentTQuantile(t *testing.T) {
+	a := assert.New(t)
+	a.EqFloat(studentTQuantile(0.975, 10), 2.228, 0.01)
+	a.EqFloat(studentTQuantile(0.995, 30), 2.750, 0.01)
+}
+
+func TestLookup(t *testing.T) {
+	a := assert.New(t)
+	a.Eq(Names(), []string{"seasonal_esd", "zscore"})
+	if _, err := Lookup("magic"); err == nil {
+		t.Errorf("expected an error for an unknown detector")
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// DefaultInternLimit is the number of distinct strings (and, separately,
+// tagsets) held by the default interner before it starts afresh.
+const DefaultInternLimit = 1 << 20
+
+// internShards is the number of independently locked parts of an
+// interner, so that concurrent queries rarely wait for one another.
+const internShards = 16
+
+var defaultInterner = NewInterner(DefaultInternLimit)
+
+// Interner shares the storage of equal strings and tagsets. The tag keys and
+// values of hundreds of thousands of series are mostly repeats (every series
+// of a host has the same host tag, and often the same tagset under many
+// metrics), so holding one copy of each cuts the memory of large queries
+// and metadata caches by a large factor.
+//
+// Interned tagsets are shared, so they must not be modified (see TagSet).
+// It's safe for concurrent use.
+type Interner struct {
+	shards [internShards]*internShard
+}
+
+type internShard struct {
+	limit int
+
+	mutex   sync.Mutex
+	strings map[string]string
+	tagSets map[uint64][]TagSet // by hash
+	held    int                 // the number of tagsets
+	stats   InternStats
+}
+
+// InternStats counts the work of an interner.
+type InternStats struct {
+	Strings int   `json:"strings"`  // the number of distinct strings held
+	TagSets int   `json:"tag_sets"` // the number of distinct tagsets held
+	Hits    int64 `json:"hits"`     // tagsets which were already held
+	Misses  int64 `json:"misses"`
+	Resets  int64 `json:"resets"` // the number of times part of the interner reached its limit and started afresh
+}
+
+// NewInterner creates an interner which holds at most about limit strings
+// and limit tagsets. When it is full it forgets what it holds (which remains
+// valid for those who were given it), so that it can't grow without bound.
+func NewInterner(limit int) *Interner {
+	interner := &Interner{}
+	for i := range interner.shards {
+		interner.shards[i] = &internShard{
+			limit:   (limit + internShards - 1) / internShards,
+			strings: map[string]string{},
+			tagSets: map[uint64][]TagSet{},
+		}
+	}
+	return interner
+}
+
+// String returns the held string equal to the given one, holding it if
+// there is none.
+func (i *Interner) String(s string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(s))
+	shard := i.shards[hash.Sum64()%internShards]
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	return shard.internString(s)
+}
+
+// TagSet returns the held tagset equal to the given one, holding a copy
+// (with interned keys and values) if there is none.
+func (i *Interner) TagSet(tagSet TagSet) TagSet {
+	if tagSet == nil {
+		return nil
+	}
+	hash := hashTagSet(tagSet)
+	shard := i.shards[hash%internShards]
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	for _, held := range shard.tagSets[hash] {
+		if held.Equals(tagSet) {
+			shard.stats.Hits++
+			return held
+		}
+	}
+	shard.stats.Misses++
+	if shard.held >= shard.limit {
+		shard.tagSets = map[uint64][]TagSet{}
+		shard.held = 0
+		shard.stats.Resets++
+	}
+	// The keys and values are interned within the shard, so each may be held
+	// by several shards; they are few, next to the tagsets which use them.
+	held := make(TagSet, len(tagSet))
+	for key, value := range tagSet {
+		held[shard.internString(key)] = shard.internString(value)
+	}
+	shard.tagSets[hash] = append(shard.tagSets[hash], held)
+	shard.held++
+	return held
+}
+
+func (s *internShard) internString(value string) string {
+	if held, ok := s.strings[value]; ok {
+		return held
+	}
+	if len(s.strings) >= s.limit {
+		s.strings = map[string]string{}
+		s.stats.Resets++
+	}
+	s.strings[value] = value
+	return value
+}
+
+// hashTagSet hashes the pairs of the tagset in the order of their keys.
+func hashTagSet(tagSet TagSet) uint64 {
+	keys := make([]string, 0, len(tagSet))
+	for key := range tagSet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := fnv.New64a()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write([]byte(tagSet[key]))
+		hash.Write([]byte{0})
+	}
+	return hash.Sum64()
+}
+
+// Stats describes the interner.
+func (i *Interner) Stats() InternStats {
+	total := InternStats{}
+	for _, shard := range i.shards {
+		shard.mutex.Lock()
+		total.Strings += len(shard.strings)
+		total.TagSets += shard.held
+		total.Hits += shard.stats.Hits
+		total.Misses += shard.stats.Misses
+		total.Resets += shard.stats.Resets
+		shard.mutex.Unlock()
+	}
+	return total
+}
+
+// Intern returns the tagset held by the default interner which is equal
+// to this one. The result is shared, and must not be modified.
+func (tagSet TagSet) Intern() TagSet {
+	return defaultInterner.TagSet(tagSet)
+}
+
+// InternString returns the string held by the default interner which is
+// equal to the given one.
+func InternString(s string) string {
+	return defaultInterner.String(s)
+}
+
+// DefaultInternStats describes the default interner.
+func DefaultInternStats() InternStats {
+	return defaultInterner.Stats()
+}
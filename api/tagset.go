@@ -22,7 +22,8 @@ import (
 
 // TagSet is the set of key-value pairs associated with a given metric.
 // Instances of tag set should generally be treated immutably to avoid
-// accidentally modifying instances belonging to other metrics.
+// accidentally modifying instances belonging to other metrics; interned
+// tag sets (see Intern) are shared by every series with the same tags.
 type TagSet map[string]string
 
 // NewTagSet creates a new instance of TagSet.
@@ -64,7 +65,7 @@ func (tagSet TagSet) Merge(other TagSet) TagSet {
 	return result
 }
 
-// ParseTagSet parses a given string to a tagset, nil
+// ParseTagSet parses a given string to an interned tagset, nil
 // if parsing failed.
 func ParseTagSet(raw string) TagSet {
 	result := NewTagSet()
@@ -82,7 +83,7 @@ func ParseTagSet(raw string) TagSet {
 		byteSlice = byteSlice[matcher[1]:]
 		if len(byteSlice) == 0 {
 			// end of input
-			return result
+			return result.Intern()
 		} else if byteSlice[0] == ',' {
 			// progress to the next key-value pair.
 			byteSlice = byteSlice[1:]
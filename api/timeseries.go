@@ -19,12 +19,22 @@ import (
 	"encoding/json"
 	"math"
 	"strconv"
+
+	"github.com/square/metrics/tdigest"
 )
 
 // Timeseries is a single time series, identified with the associated tagset.
 type Timeseries struct {
 	Values []float64 `json:"values"`
 	TagSet TagSet    `json:"tagset"`
+	// Samples optionally holds the number of raw samples behind each value,
+	// for storage which reports them. Functions computing new values drop it.
+	Samples []int `json:"-"`
+	// Sketches optionally holds a t-digest of the raw values behind each
+	// value (nil where there are none), for storage which keeps them. They
+	// may be shared between series, so they're never changed. Functions
+	// computing new values drop them.
+	Sketches []*tdigest.Digest `json:"-"`
 }
 
 // MarshalJSON exists to manually encode floats.
@@ -108,6 +108,34 @@ func TestTagSet_ParseTagSet(t *testing.T) {
 	a.EqString(ParseTagSet("a\\=b=1").Serialize(), "a\\=b=1")
 }
 
+func TestInterner(t *testing.T) {
+	a := assert.New(t)
+	interner := NewInterner(64)
+	first := interner.TagSet(TagSet{"host": "a", "dc": "east"})
+	second := interner.TagSet(TagSet{"dc": "east", "host": "a"})
+	other := interner.TagSet(TagSet{"host": "b", "dc": "east"})
+	a.EqBool(first.Equals(TagSet{"host": "a", "dc": "east"}), true)
+	// Equal tagsets share a map; others don't.
+	first["shared"] = "yes"
+	a.EqString(second["shared"], "yes")
+	a.EqBool(other.HasKey("shared"), false)
+	a.EqString(interner.String("east"), "east")
+	a.Eq(interner.TagSet(nil), TagSet(nil))
+
+	stats := interner.Stats()
+	a.EqInt(stats.TagSets, 2)
+	a.EqInt(int(stats.Hits), 1)
+	a.EqInt(int(stats.Misses), 2)
+
+	// A full interner starts afresh.
+	for i := 0; i < 200; i++ {
+		interner.TagSet(TagSet{"host": string(rune('a' + i))})
+	}
+	stats = interner.Stats()
+	a.EqBool(stats.Resets > 0, true)
+	a.EqBool(stats.TagSets <= 64, true)
+}
+
 func TestTimeseries_MarshalJSON(t *testing.T) {
 	for _, suite := range []struct {
 		input    Timeseries
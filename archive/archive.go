@@ -0,0 +1,139 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// manifestKey is the key of the manifest, which lists every archived result.
+// Object stores can't always list their keys cheaply, so the manifest is the
+// index of the archive.
+const manifestKey = "manifest.json"
+
+// An Entry describes an archived result.
+type Entry struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`  // the name given when the query was flagged for archival
+	Query      string    `json:"query"` // the query which produced the result
+	ArchivedAt time.Time `json:"archived_at"`
+	Key        string    `json:"key"` // the object holding the result
+	Size       int       `json:"size"`
+}
+
+type manifest struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Archiver writes results to an ObjectStore and keeps the manifest up to
+// date. An Archiver should be the only writer of its store's manifest.
+type Archiver struct {
+	store ObjectStore
+	now   func() time.Time
+	mutex sync.Mutex // guards updates to the manifest
+}
+
+// NewArchiver creates an Archiver for the store.
+func NewArchiver(store ObjectStore) *Archiver {
+	return &Archiver{store: store, now: time.Now}
+}
+
+func (a *Archiver) readManifest() (manifest, error) {
+	data, err := a.store.Get(manifestKey)
+	if err == ErrNotFound {
+		return manifest{Entries: []Entry{}}, nil
+	}
+	if err != nil {
+		return manifest{}, err
+	}
+	result := manifest{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return manifest{}, fmt.Errorf("archive: cannot read manifest: %s", err.Error())
+	}
+	return result, nil
+}
+
+// Archive stores the result of the query under the name, and adds it to the
+// manifest. The result is stored before the manifest is updated, so the
+// manifest never lists a result which is missing.
+func (a *Archiver) Archive(name string, query string, result interface{}) (Entry, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return Entry{}, err
+	}
+	archivedAt := a.now().UTC()
+	hash := sha1.Sum(append([]byte(archivedAt.Format(time.RFC3339Nano)+"\n"+name+"\n"+query+"\n"), data...))
+	id := archivedAt.Format("20060102T150405Z") + "-" + hex.EncodeToString(hash[:])[:8]
+	entry := Entry{
+		ID:         id,
+		Name:       name,
+		Query:      query,
+		ArchivedAt: archivedAt,
+		Key:        "results/" + id + ".json",
+		Size:       len(data),
+	}
+	if err := a.store.Put(entry.Key, data); err != nil {
+		return Entry{}, err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	current, err := a.readManifest()
+	if err != nil {
+		return Entry{}, err
+	}
+	current.Entries = append(current.Entries, entry)
+	encoded, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := a.store.Put(manifestKey, encoded); err != nil {
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+// List returns the archived results, oldest first.
+func (a *Archiver) List() ([]Entry, error) {
+	current, err := a.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	return current.Entries, nil
+}
+
+// Get returns the entry with the ID and its stored result, which is JSON.
+func (a *Archiver) Get(id string) (Entry, []byte, error) {
+	entries, err := a.List()
+	if err != nil {
+		return Entry{}, nil, err
+	}
+	for _, entry := range entries {
+		if entry.ID != id {
+			continue
+		}
+		data, err := a.store.Get(entry.Key)
+		if err != nil {
+			return Entry{}, nil, err
+		}
+		return entry, data, nil
+	}
+	return Entry{}, nil, ErrNotFound
+}
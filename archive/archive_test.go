@@ -0,0 +1,110 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+// bucket is a fake object store served over HTTP.
+type bucket struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (b *bucket) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if request.Header.Get("Authorization") != "Bearer secret" {
+		writer.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(request.URL.Path, "/reports/")
+	switch request.Method {
+	case "PUT":
+		data, _ := ioutil.ReadAll(request.Body)
+		b.objects[key] = data
+	case "GET":
+		data, ok := b.objects[key]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writer.Write(data)
+	}
+}
+
+func TestArchiver(t *testing.T) {
+	directory, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(directory)
+	fake := &bucket{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	for name, store := range map[string]ObjectStore{
+		"directory": DirectoryStore{Directory: directory},
+		"bucket":    BucketStore{URL: server.URL + "/reports/", Headers: map[string]string{"Authorization": "Bearer secret"}},
+	} {
+		a := assert.New(t).Contextf("%s", name)
+		archiver := NewArchiver(store)
+		archiver.now = func() time.Time { return time.Date(2016, 10, 1, 0, 0, 0, 0, time.UTC) }
+
+		entries, err := archiver.List()
+		a.CheckError(err)
+		a.EqInt(len(entries), 0)
+
+		first, err := archiver.Archive("september", "select cpu from -30d to now", map[string]int{"total": 1})
+		a.CheckError(err)
+		second, err := archiver.Archive("october", "select cpu from -30d to now", map[string]int{"total": 2})
+		a.CheckError(err)
+		a.EqBool(first.ID != second.ID, true)
+		a.EqString(first.Key, "results/"+first.ID+".json")
+
+		entries, err = archiver.List()
+		a.CheckError(err)
+		a.Eq(entries, []Entry{first, second})
+
+		entry, data, err := archiver.Get(second.ID)
+		a.CheckError(err)
+		a.EqString(entry.Name, "october")
+		a.EqString(string(data), `{"total":2}`)
+
+		if _, _, err := archiver.Get("missing"); err != ErrNotFound {
+			t.Errorf("%s: expected ErrNotFound, got %v", name, err)
+		}
+	}
+
+	// Requests which the bucket refuses are errors.
+	refused := NewArchiver(BucketStore{URL: server.URL + "/reports"})
+	if _, err := refused.Archive("refused", "select cpu from -1h to now", nil); err == nil {
+		t.Errorf("expected an error when the bucket refuses the upload")
+	}
+	// Keys can't escape the directory.
+	if _, err := (DirectoryStore{Directory: directory}).Get("../../etc/passwd"); err != ErrNotFound {
+		t.Errorf("expected a key outside the directory not to be found, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/log"
+)
+
+// replicaKeysKey is the key of the object in a replica which lists the keys
+// replicated to it, so that the replica can be restored without listing it.
+const replicaKeysKey = "replication/keys.json"
+
+// ReplicatedStore keeps objects in a primary store (such as a directory on
+// the node's disk) and copies each one to a replica (such as a bucket) in the
+// background, so that the objects outlive the node. Writes succeed once the
+// primary has them; only the latest version of an object is replicated if it
+// changes faster than it can be copied, and failed copies are retried.
+type ReplicatedStore struct {
+	primary    ObjectStore
+	replica    ObjectStore
+	retryDelay time.Duration
+
+	mutex      sync.Mutex
+	pending    map[string]bool // the keys which have yet to be copied
+	copying    bool            // whether the keys are being copied
+	replicated map[string]bool // the keys which the replica lists, once they've been read
+	idle       *sync.Cond      // signalled when no keys are pending
+}
+
+// NewReplicatedStore creates a store replicating the primary to the replica.
+func NewReplicatedStore(primary ObjectStore, replica ObjectStore) *ReplicatedStore {
+	store := &ReplicatedStore{
+		primary:    primary,
+		replica:    replica,
+		retryDelay: 10 * time.Second,
+		pending:    map[string]bool{},
+	}
+	store.idle = sync.NewCond(&store.mutex)
+	return store
+}
+
+// Put writes the object to the primary, and queues it to be replicated.
+func (store *ReplicatedStore) Put(key string, data []byte) error {
+	if err := store.primary.Put(key, data); err != nil {
+		return err
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.pending[key] = true
+	if !store.copying {
+		store.copying = true
+		go store.copyPending()
+	}
+	return nil
+}
+
+// Get reads the object from the primary.
+func (store *ReplicatedStore) Get(key string) ([]byte, error) {
+	return store.primary.Get(key)
+}
+
+// Wait blocks until every object written so far has been replicated.
+func (store *ReplicatedStore) Wait() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for store.copying {
+		store.idle.Wait()
+	}
+}
+
+// copyPending copies the pending keys until there are none left, waiting
+// between attempts if the replica fails.
+func (store *ReplicatedStore) copyPending() {
+	for {
+		store.mutex.Lock()
+		if len(store.pending) == 0 {
+			store.copying = false
+			store.idle.Broadcast()
+			store.mutex.Unlock()
+			return
+		}
+		keys := store.pending
+		store.pending = map[string]bool{}
+		store.mutex.Unlock()
+
+		if err := store.copy(keys); err != nil {
+			log.Errorf("Cannot replicate %d objects; retrying in %s: %s", len(keys), store.retryDelay, err.Error())
+			store.mutex.Lock()
+			for key := range keys {
+				store.pending[key] = true
+			}
+			store.mutex.Unlock()
+			time.Sleep(store.retryDelay)
+		}
+	}
+}
+
+// copy copies the objects to the replica, then adds their keys to its list.
+func (store *ReplicatedStore) copy(keys map[string]bool) error {
+	for key := range keys {
+		data, err := store.primary.Get(key)
+		if err != nil {
+			return err
+		}
+		if err := store.replica.Put(key, data); err != nil {
+			return err
+		}
+	}
+	if store.replicated == nil {
+		replicated, err := replicaKeys(store.replica)
+		if err != nil {
+			return err
+		}
+		store.replicated = map[string]bool{}
+		for _, key := range replicated {
+			store.replicated[key] = true
+		}
+	}
+	added := false
+	for key := range keys {
+		if !store.replicated[key] {
+			store.replicated[key] = true
+			added = true
+		}
+	}
+	if !added {
+		return nil
+	}
+	list := make([]string, 0, len(store.replicated))
+	for key := range store.replicated {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	encoded, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return store.replica.Put(replicaKeysKey, encoded)
+}
+
+// replicaKeys reads the keys which have been replicated to the replica.
+func replicaKeys(replica ObjectStore) ([]string, error) {
+	data, err := replica.Get(replicaKeysKey)
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Restore copies the objects of the replica to the primary, as when a node
+// which lost its disk is replaced. Objects which the primary already has are
+// kept unless overwrite is set. It returns the number of objects copied.
+func Restore(replica ObjectStore, primary ObjectStore, overwrite bool) (int, error) {
+	keys, err := replicaKeys(replica)
+	if err != nil {
+		return 0, err
+	}
+	copied := 0
+	for _, key := range keys {
+		if !overwrite {
+			if _, err := primary.Get(key); err == nil {
+				continue
+			} else if err != ErrNotFound {
+				return copied, err
+			}
+		}
+		data, err := replica.Get(key)
+		if err != nil {
+			return copied, err
+		}
+		if err := primary.Put(key, data); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+	return copied, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+// flakyStore is an in-memory object store whose writes can be made to fail.
+type flakyStore struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+	failing bool
+}
+
+func (s *flakyStore) Put(key string, data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failing {
+		return errors.New("unavailable")
+	}
+	s.objects[key] = data
+	return nil
+}
+
+func (s *flakyStore) Get(key string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *flakyStore) setFailing(failing bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failing = failing
+}
+
+func TestReplicatedStore(t *testing.T) {
+	a := assert.New(t)
+	directory, err := ioutil.TempDir("", "replica")
+	a.CheckError(err)
+	defer os.RemoveAll(directory)
+
+	replica := &flakyStore{objects: map[string][]byte{}}
+	store := NewReplicatedStore(DirectoryStore{Directory: directory}, replica)
+	store.retryDelay = time.Millisecond
+
+	a.CheckError(store.Put("tenants/a/macros.json", []byte("a")))
+	a.CheckError(store.Put("manifest.json", []byte("1")))
+	store.Wait()
+	a.Eq(replica.objects, map[string][]byte{
+		"tenants/a/macros.json": []byte("a"),
+		"manifest.json":         []byte("1"),
+		replicaKeysKey:          []byte(`["manifest.json","tenants/a/macros.json"]`),
+	})
+
+	// Writes succeed while the replica is down, and are copied once it's back.
+	replica.setFailing(true)
+	a.CheckError(store.Put("manifest.json", []byte("2")))
+	a.CheckError(store.Put("results/b.json", []byte("b")))
+	data, err := store.Get("results/b.json")
+	a.CheckError(err)
+	a.EqString(string(data), "b")
+	time.Sleep(5 * time.Millisecond)
+	replica.setFailing(false)
+	store.Wait()
+	a.EqString(string(replica.objects["manifest.json"]), "2")
+	a.EqString(string(replica.objects[replicaKeysKey]), `["manifest.json","results/b.json","tenants/a/macros.json"]`)
+
+	// A new node restores everything from the replica, keeping what it has
+	// unless told to overwrite it.
+	restored := &flakyStore{objects: map[string][]byte{"manifest.json": []byte("local")}}
+	copied, err := Restore(replica, restored, false)
+	a.CheckError(err)
+	a.EqInt(copied, 2)
+	a.EqString(string(restored.objects["manifest.json"]), "local")
+	a.EqString(string(restored.objects["results/b.json"]), "b")
+	copied, err = Restore(replica, restored, true)
+	a.CheckError(err)
+	a.EqInt(copied, 3)
+	a.EqString(string(restored.objects["manifest.json"]), "2")
+}
@@ -0,0 +1,145 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archive keeps the results of queries in object storage, such as
+// S3 or GCS, so that expensive historical computations can be served again
+// after their data has expired from the backends.
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by an ObjectStore for a key which has no object.
+var ErrNotFound = errors.New("archive: object not found")
+
+// ObjectStore holds objects by key. Keys are slash-separated paths.
+type ObjectStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error) // ErrNotFound if there is no such object
+}
+
+// DirectoryStore keeps objects as files beneath a directory, which may be a
+// mounted bucket.
+type DirectoryStore struct {
+	Directory string
+}
+
+func (store DirectoryStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("archive: invalid key %q", key)
+	}
+	return filepath.Join(store.Directory, filepath.FromSlash(clean)), nil
+}
+
+// Put writes the object, replacing it atomically if it already exists.
+func (store DirectoryStore) Put(key string, data []byte) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	temporary := path + ".tmp"
+	if err := ioutil.WriteFile(temporary, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+// Get reads the object.
+func (store DirectoryStore) Get(key string) ([]byte, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// BucketStore keeps objects in a bucket which is reached over HTTP, with a
+// PUT or GET of the bucket's URL followed by the key. Both S3 and GCS serve
+// buckets this way (for example, https://storage.googleapis.com/bucket).
+// Requests are signed if Credentials are given; otherwise they must be
+// authorized by the headers (such as a bearer token), by the bucket's policy,
+// or by a signing proxy in front of the bucket.
+type BucketStore struct {
+	URL         string            // the URL of the bucket, optionally with a prefix
+	Headers     map[string]string // sent with each request
+	Credentials *Credentials      // optional; signs each request
+	Client      *http.Client      // optional; defaults to http.DefaultClient
+}
+
+func (store BucketStore) do(method string, key string, body []byte) (*http.Response, error) {
+	request, err := http.NewRequest(method, strings.TrimSuffix(store.URL, "/")+"/"+strings.TrimPrefix(key, "/"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range store.Headers {
+		request.Header.Set(name, value)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if store.Credentials != nil {
+		store.Credentials.sign(request, body, time.Now())
+	}
+	client := store.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(request)
+}
+
+// Put uploads the object.
+func (store BucketStore) Put(key string, data []byte) error {
+	response, err := store.do("PUT", key, data)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("archive: PUT of %q failed with status %s", key, response.Status)
+	}
+	return nil
+}
+
+// Get downloads the object.
+func (store BucketStore) Get(key string) ([]byte, error) {
+	response, err := store.do("GET", key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if response.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("archive: GET of %q failed with status %s", key, response.Status)
+	}
+	return ioutil.ReadAll(response.Body)
+}
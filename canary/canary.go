@@ -0,0 +1,262 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package canary checks the whole pipeline from end to end: it periodically
+// writes a synthetic point through the ingestion path of the storage, and
+// queries it back through the engine, measuring how long the point took to
+// become queryable and whether it was read back unchanged.
+package canary
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/log"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/util"
+)
+
+// Config enables the canary.
+type Config struct {
+	IntervalSeconds int    `yaml:"interval_seconds"` // how often a point is written; if zero, the canary is disabled. It should be at least the storage's finest resolution
+	TimeoutSeconds  int    `yaml:"timeout_seconds"`  // how long a point may take to be read back before its check fails; defaults to 300
+	PollSeconds     int    `yaml:"poll_seconds"`     // how often a point is queried until it's read back; defaults to 5
+	Metric          string `yaml:"metric"`           // the metric written; defaults to mqe.canary
+	Instance        string `yaml:"instance"`         // the value of the canary tag of the series written; defaults to the hostname, so that each server checks its own points
+}
+
+// CanaryTag is the tag which distinguishes the series of each server.
+const CanaryTag = "canary"
+
+// A Writer writes points through the ingestion path of a storage backend.
+type Writer interface {
+	WritePoint(metric api.TaggedMetric, t time.Time, value float64) error
+}
+
+// SketchWriter writes points as t-digests of a single value, as sent to
+// /ingest/sketch, whose mean the storage returns.
+type SketchWriter struct {
+	Storage timeseries.SketchStorageAPI
+}
+
+// WritePoint adds the value to the series at the given time.
+func (w SketchWriter) WritePoint(metric api.TaggedMetric, t time.Time, value float64) error {
+	sketch := tdigest.New(0)
+	sketch.Add(value)
+	return w.Storage.AddSketch(metric, t, sketch)
+}
+
+// Status describes the checks of the canary.
+type Status struct {
+	Metric           api.TaggedMetric `json:"metric"`
+	Healthy          bool             `json:"healthy"`    // whether the last check succeeded
+	Checks           int              `json:"checks"`     // the number of completed checks
+	Failures         int              `json:"failures"`   // the checks whose point couldn't be written, or wasn't read back unchanged in time
+	Mismatches       int              `json:"mismatches"` // of the failures, those whose point was read back with another value
+	LastWrite        time.Time        `json:"last_write,omitempty"`
+	LastSuccess      time.Time        `json:"last_success,omitempty"`
+	FreshnessSeconds float64          `json:"freshness_seconds"` // how long the point of the last successful check took to become queryable
+	LastError        string           `json:"last_error,omitempty"`
+}
+
+// Canary writes and reads back its points.
+type Canary struct {
+	interval time.Duration
+	timeout  time.Duration
+	poll     time.Duration
+	metric   api.TaggedMetric
+	writer   Writer
+	updates  metadata.MetricUpdateAPI // optional
+	context  command.ExecutionContext
+
+	mutex      sync.Mutex
+	registered bool
+	status     Status
+}
+
+// New returns nil if the canary is disabled. If the update API is given, the
+// canary's series is added to the metadata before its first point is written.
+func New(config Config, writer Writer, updates metadata.MetricUpdateAPI, context command.ExecutionContext) *Canary {
+	if config.IntervalSeconds <= 0 || writer == nil {
+		return nil
+	}
+	if config.TimeoutSeconds <= 0 {
+		config.TimeoutSeconds = 300
+	}
+	if config.PollSeconds <= 0 {
+		config.PollSeconds = 5
+	}
+	if config.Metric == "" {
+		config.Metric = "mqe.canary"
+	}
+	if config.Instance == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			hostname = "mqe"
+		}
+		config.Instance = hostname
+	}
+	metric := api.TaggedMetric{MetricKey: api.MetricKey(config.Metric), TagSet: api.TagSet{CanaryTag: config.Instance}}
+	return &Canary{
+		interval: time.Duration(config.IntervalSeconds) * time.Second,
+		timeout:  time.Duration(config.TimeoutSeconds) * time.Second,
+		poll:     time.Duration(config.PollSeconds) * time.Second,
+		metric:   metric,
+		writer:   writer,
+		updates:  updates,
+		context:  context,
+		status:   Status{Metric: metric},
+	}
+}
+
+// Run checks the pipeline periodically, until stopped. Checks are at least
+// the interval apart (rather than on a ticker), so that no two points share
+// a slot of the interval's resolution.
+func (c *Canary) Run(ctx context.Context) error {
+	for {
+		c.check(ctx)
+		select {
+		case <-time.After(c.interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Status describes the checks so far.
+func (c *Canary) Status() Status {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.status
+}
+
+// check writes a point and queries it until it's read back, or until the
+// timeout. Each point's value is the time it was written at, in seconds, so
+// that it can't be mistaken for an earlier point.
+func (c *Canary) check(ctx context.Context) {
+	written := time.Now()
+	value := float64(written.Unix())
+	err := c.write(ctx, written, value)
+	c.mutex.Lock()
+	c.status.LastWrite = written
+	c.mutex.Unlock()
+	if err != nil {
+		c.record(fmt.Errorf("cannot write the canary's point: %s", err.Error()), false, 0)
+		return
+	}
+	deadline := written.Add(c.timeout)
+	for {
+		read, err := c.read(ctx, written)
+		if err == nil && read == value {
+			c.record(nil, false, time.Since(written))
+			return
+		}
+		if ctx.Err() != nil {
+			return // stopped, which says nothing of the pipeline
+		}
+		if !time.Now().Add(c.poll).Before(deadline) {
+			switch {
+			case err != nil:
+				c.record(fmt.Errorf("cannot read the canary's point: %s", err.Error()), false, 0)
+			case math.IsNaN(read):
+				c.record(fmt.Errorf("the canary's point written at %s wasn't read back within %s", written.UTC().Format(time.RFC3339), c.timeout), false, 0)
+			default:
+				c.record(fmt.Errorf("the canary's point written at %s was read back as %g instead of %g", written.UTC().Format(time.RFC3339), read, value), true, 0)
+			}
+			return
+		}
+		select {
+		case <-time.After(c.poll):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// write adds the series to the metadata (once) and writes the point.
+func (c *Canary) write(ctx context.Context, t time.Time, value float64) error {
+	c.mutex.Lock()
+	registered := c.registered
+	c.mutex.Unlock()
+	if !registered && c.updates != nil {
+		if err := c.updates.AddMetric(ctx, c.metric, metadata.Context{}); err != nil {
+			return err
+		}
+		c.mutex.Lock()
+		c.registered = true
+		c.mutex.Unlock()
+	}
+	return c.writer.WritePoint(c.metric, t, value)
+}
+
+// read selects the canary's series around the time of the point, returning
+// the value of the slot holding it (NaN if it's missing).
+func (c *Canary) read(ctx context.Context, t time.Time) (float64, error) {
+	millis := t.UnixNano() / 1e6
+	query := fmt.Sprintf("select %s where %s = %s from %d to %d resolution %dms",
+		util.EscapeIdentifier(string(c.metric.MetricKey)),
+		util.EscapeIdentifier(CanaryTag),
+		util.EscapeString(c.metric.TagSet[CanaryTag]),
+		millis-c.interval.Nanoseconds()/1e6,
+		millis+c.interval.Nanoseconds()/1e6,
+		c.interval.Nanoseconds()/1e6,
+	)
+	cmd, err := parser.Parse(query)
+	if err != nil {
+		return 0, err
+	}
+	context := c.context
+	context.Ctx = ctx
+	result, err := cmd.Execute(context)
+	if err != nil {
+		return 0, err
+	}
+	for _, queryResult := range result.Body.([]command.QueryResult) {
+		for _, series := range queryResult.Series {
+			index := queryResult.Timerange.IndexOfTime(t)
+			if index >= 0 && index < len(series.Values) {
+				return series.Values[index], nil
+			}
+		}
+	}
+	return math.NaN(), nil
+}
+
+// record completes a check.
+func (c *Canary) record(err error, mismatch bool, freshness time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.status.Checks++
+	c.status.Healthy = err == nil
+	if err != nil {
+		log.Errorf("Canary check failed: %s", err.Error())
+		c.status.Failures++
+		if mismatch {
+			c.status.Mismatches++
+		}
+		c.status.LastError = err.Error()
+		return
+	}
+	c.status.LastSuccess = time.Now()
+	c.status.FreshnessSeconds = freshness.Seconds()
+}
@@ -0,0 +1,97 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+// skewedWriter writes the points it's given shifted by an offset, or drops
+// them.
+type skewedWriter struct {
+	store  *memory.Store
+	offset float64
+	drop   bool
+}
+
+func (w skewedWriter) WritePoint(metric api.TaggedMetric, t time.Time, value float64) error {
+	if w.drop {
+		return nil
+	}
+	return SketchWriter{Storage: w.store}.WritePoint(metric, t, value+w.offset)
+}
+
+func newTestCanary(writer func(*memory.Store) Writer) *Canary {
+	store := memory.NewStore(time.Second)
+	c := New(Config{IntervalSeconds: 1, Instance: "test"}, writer(store), store, command.ExecutionContext{
+		TimeseriesStorageAPI: store,
+		MetricMetadataAPI:    store,
+		FetchLimit:           10,
+		Ctx:                  context.Background(),
+	})
+	c.timeout = 50 * time.Millisecond
+	c.poll = 5 * time.Millisecond
+	return c
+}
+
+func TestCanaryDisabled(t *testing.T) {
+	if c := New(Config{}, SketchWriter{}, nil, command.ExecutionContext{}); c != nil {
+		t.Errorf("expected the canary to be disabled")
+	}
+}
+
+func TestCanaryReadsBackItsPoint(t *testing.T) {
+	c := newTestCanary(func(store *memory.Store) Writer { return SketchWriter{Storage: store} })
+	c.check(context.Background())
+	status := c.Status()
+	if !status.Healthy || status.Checks != 1 || status.Failures != 0 || status.LastError != "" {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.LastSuccess.IsZero() || status.FreshnessSeconds < 0 || status.FreshnessSeconds > 1 {
+		t.Errorf("unexpected freshness in status %+v", status)
+	}
+	if status.Metric.MetricKey != "mqe.canary" || status.Metric.TagSet[CanaryTag] != "test" {
+		t.Errorf("unexpected metric %+v", status.Metric)
+	}
+}
+
+func TestCanaryFailures(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		writer     func(*memory.Store) Writer
+		mismatches int
+		message    string
+	}{
+		{"dropped", func(store *memory.Store) Writer { return skewedWriter{store: store, drop: true} }, 0, "wasn't read back within 50ms"},
+		{"changed", func(store *memory.Store) Writer { return skewedWriter{store: store, offset: 1} }, 1, "was read back as"},
+	} {
+		c := newTestCanary(test.writer)
+		c.check(context.Background())
+		status := c.Status()
+		if status.Healthy || status.Checks != 1 || status.Failures != 1 || status.Mismatches != test.mismatches {
+			t.Errorf("%s: unexpected status %+v", test.name, status)
+		}
+		if !strings.Contains(status.LastError, test.message) {
+			t.Errorf("%s: expected the error %q to mention %q", test.name, status.LastError, test.message)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// ArrowContentType is the media type of Arrow IPC streams.
+const ArrowContentType = "application/vnd.apache.arrow.stream"
+
+// Identifiers from Arrow's Schema.fbs and Message.fbs.
+const (
+	arrowMetadataV5 = 4
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	arrowTypeFloatingPoint = 3
+	arrowTypeUtf8          = 5
+	arrowTypeTimestamp     = 10
+
+	arrowPrecisionDouble = 2
+	arrowUnitMillisecond = 1
+)
+
+// arrowContinuation begins each message of an IPC stream.
+const arrowContinuation = 0xFFFFFFFF
+
+// WriteArrow writes the frame as an Arrow IPC stream: its schema, followed by
+// a single record batch holding all of its rows.
+func (frame Frame) WriteArrow(writer io.Writer) error {
+	if err := writeArrowMessage(writer, frame.arrowSchema(), nil); err != nil {
+		return err
+	}
+	metadata, body := frame.arrowRecordBatch()
+	if err := writeArrowMessage(writer, metadata, body); err != nil {
+		return err
+	}
+	// The end of the stream is marked by a message of length zero.
+	return binary.Write(writer, binary.LittleEndian, []uint32{arrowContinuation, 0})
+}
+
+// writeArrowMessage writes an encapsulated message: its length and metadata,
+// padded to a multiple of eight bytes, and then its body.
+func writeArrowMessage(writer io.Writer, metadata []byte, body []byte) error {
+	padding := padding8(len(metadata))
+	prefix := []uint32{arrowContinuation, uint32(len(metadata) + padding)}
+	if err := binary.Write(writer, binary.LittleEndian, prefix); err != nil {
+		return err
+	}
+	if _, err := writer.Write(append(metadata, make([]byte, padding)...)); err != nil {
+		return err
+	}
+	_, err := writer.Write(body)
+	return err
+}
+
+// padding8 is the padding needed after the given number of bytes to reach a
+// multiple of eight.
+func padding8(length int) int {
+	return (8 - length%8) % 8
+}
+
+// arrowMessage finishes a message with the given header table.
+func arrowMessage(b *builder, headerType uint8, header uint32, bodyLength int) []byte {
+	b.startObject(5)
+	b.addInt64(3, int64(bodyLength))
+	b.addOffset(2, header)
+	b.addInt16(0, arrowMetadataV5)
+	b.addUint8(1, headerType)
+	return b.finish(b.endObject())
+}
+
+func (frame Frame) arrowSchema() []byte {
+	b := &builder{}
+	fields := make([]uint32, len(frame.Columns))
+	for i, column := range frame.Columns {
+		name := b.createString(column.Name)
+		var typeType uint8
+		var typeTable uint32
+		switch column.Kind {
+		case Timestamp:
+			timezone := b.createString("UTC")
+			b.startObject(2)
+			b.addOffset(1, timezone)
+			b.addInt16(0, arrowUnitMillisecond)
+			typeType, typeTable = arrowTypeTimestamp, b.endObject()
+		case Float:
+			b.startObject(1)
+			b.addInt16(0, arrowPrecisionDouble)
+			typeType, typeTable = arrowTypeFloatingPoint, b.endObject()
+		case String:
+			b.startObject(0)
+			typeType, typeTable = arrowTypeUtf8, b.endObject()
+		}
+		children := b.createOffsetVector(nil)
+		b.startObject(7)
+		b.addOffset(0, name)
+		b.addOffset(3, typeTable)
+		b.addOffset(5, children)
+		b.addBool(1, column.Nullable)
+		b.addUint8(2, typeType)
+		fields[i] = b.endObject()
+	}
+	fieldVector := b.createOffsetVector(fields)
+	b.startObject(4)
+	b.addOffset(1, fieldVector)
+	return arrowMessage(b, arrowHeaderSchema, b.endObject(), 0)
+}
+
+// arrowRecordBatch lays out the buffers of each column in the body: a
+// validity bitmap (empty if no values are null), then offsets for strings,
+// then the values themselves.
+func (frame Frame) arrowRecordBatch() ([]byte, []byte) {
+	var body bytes.Buffer
+	nodes := make([][2]int64, len(frame.Columns))
+	buffers := [][2]int64{}
+	addBuffer := func(data []byte) {
+		buffers = append(buffers, [2]int64{int64(body.Len()), int64(len(data))})
+		body.Write(data)
+		body.Write(make([]byte, padding8(len(data))))
+	}
+	for i, column := range frame.Columns {
+		nulls := column.nulls()
+		nodes[i] = [2]int64{int64(frame.Rows), int64(nulls)}
+		if nulls == 0 {
+			addBuffer(nil)
+		} else {
+			bitmap := make([]byte, (frame.Rows+7)/8)
+			for row := 0; row < frame.Rows; row++ {
+				if column.valid(row) {
+					bitmap[row/8] |= 1 << uint(row%8)
+				}
+			}
+			addBuffer(bitmap)
+		}
+		switch column.Kind {
+		case Timestamp:
+			data := make([]byte, 8*frame.Rows)
+			for row, timestamp := range column.Timestamps {
+				binary.LittleEndian.PutUint64(data[8*row:], uint64(timestamp))
+			}
+			addBuffer(data)
+		case Float:
+			data := make([]byte, 8*frame.Rows)
+			for row, value := range column.Floats {
+				binary.LittleEndian.PutUint64(data[8*row:], math.Float64bits(value))
+			}
+			addBuffer(data)
+		case String:
+			offsets := make([]byte, 4*(frame.Rows+1))
+			var data bytes.Buffer
+			for row, value := range column.Strings {
+				if column.valid(row) {
+					data.WriteString(value)
+				}
+				binary.LittleEndian.PutUint32(offsets[4*(row+1):], uint32(data.Len()))
+			}
+			addBuffer(offsets)
+			addBuffer(data.Bytes())
+		}
+	}
+	b := &builder{}
+	nodeVector := b.createPairVector(nodes)
+	bufferVector := b.createPairVector(buffers)
+	b.startObject(4)
+	b.addInt64(0, int64(frame.Rows))
+	b.addOffset(1, nodeVector)
+	b.addOffset(2, bufferVector)
+	return arrowMessage(b, arrowHeaderRecordBatch, b.endObject(), body.Len()), body.Bytes()
+}
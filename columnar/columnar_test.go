@@ -0,0 +1,256 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+// The golden files hold the encodings of testResults. After changing an
+// encoder, regenerate them with
+//
+//	go test ./columnar -run TestGolden -update
+//
+// CI reads them with pyarrow through testing_support/script/check-columnar,
+// which holds the table they should decode to; run it before committing them.
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func testResults(t *testing.T) []command.QueryResult {
+	timerange, err := api.NewSnappedTimerange(0, 20, 10)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	return []command.QueryResult{
+		{Name: "cpu", Type: "series", Timerange: timerange, Series: []api.Timeseries{
+			{Values: []float64{1, math.NaN(), 3}, TagSet: api.TagSet{"host": "a", "value": "v"}},
+		}},
+		{Name: "disk", Type: "series", Timerange: timerange, Series: []api.Timeseries{
+			{Values: []float64{4, 5, 6}, TagSet: api.TagSet{"dc": "west"}},
+		}},
+	}
+}
+
+func TestFromResults(t *testing.T) {
+	a := assert.New(t)
+	frame, err := FromResults(testResults(t))
+	a.CheckError(err)
+	a.EqInt(frame.Rows, 6)
+	names := []string{}
+	for _, column := range frame.Columns {
+		names = append(names, column.Name)
+	}
+	a.Eq(names, []string{"expression", "timestamp", "value", "dc", "host", "tag_value"})
+	a.Eq(frame.Columns[0].Strings, []string{"cpu", "cpu", "cpu", "disk", "disk", "disk"})
+	a.Eq(frame.Columns[1].Timestamps, []int64{0, 10, 20, 0, 10, 20})
+	a.Eq(frame.Columns[2].Valid, []bool{true, false, true, true, true, true})
+	a.Eq(frame.Columns[3].Valid, []bool{false, false, false, true, true, true})
+	a.EqInt(frame.Columns[3].nulls(), 3)
+
+	single, err := FromResults(testResults(t)[:1])
+	a.CheckError(err)
+	a.EqString(single.Columns[0].Name, "timestamp")
+
+	if _, err := FromResults([]command.QueryResult{{Query: "select 1", Type: "scalars"}}); err == nil {
+		a.Errorf("expected scalars to be rejected")
+	}
+}
+
+func TestWriteArrow(t *testing.T) {
+	a := assert.New(t)
+	frame, err := FromResults(testResults(t))
+	a.CheckError(err)
+	var buffer bytes.Buffer
+	a.CheckError(frame.WriteArrow(&buffer))
+	stream := buffer.Bytes()
+
+	// The schema, the record batch and the end-of-stream marker follow each other.
+	messages := 0
+	for {
+		a.EqInt(int(binary.LittleEndian.Uint32(stream)), arrowContinuation)
+		length := int(binary.LittleEndian.Uint32(stream[4:]))
+		if length == 0 {
+			a.EqInt(len(stream), 8)
+			break
+		}
+		a.EqInt(length%8, 0)
+		metadata := stream[8 : 8+length]
+		message := table(metadata, binary.LittleEndian.Uint32(metadata))
+		a.EqInt(int(message.uint16(0)), arrowMetadataV5)
+		bodyLength := int(message.int64(3))
+		a.EqInt(bodyLength%8, 0)
+		header := message.table(2)
+		switch message.uint8(1) {
+		case arrowHeaderSchema:
+			a.EqInt(messages, 0)
+			fields := header.vector(1)
+			a.EqInt(len(fields), 6)
+			a.EqString(fields[1].string(0), "timestamp")
+			a.EqInt(int(fields[1].uint8(2)), arrowTypeTimestamp)
+			a.EqBool(fields[2].uint8(1) == 1, true)
+		case arrowHeaderRecordBatch:
+			a.EqInt(messages, 1)
+			a.EqInt(int(header.int64(0)), 6)
+		default:
+			a.Errorf("unexpected message type %d", message.uint8(1))
+		}
+		stream = stream[8+length+bodyLength:]
+		messages++
+	}
+	a.EqInt(messages, 2)
+}
+
+func TestWriteParquet(t *testing.T) {
+	a := assert.New(t)
+	frame, err := FromResults(testResults(t))
+	a.CheckError(err)
+	var buffer bytes.Buffer
+	a.CheckError(frame.WriteParquet(&buffer))
+	file := buffer.Bytes()
+	a.EqString(string(file[:4]), parquetMagic)
+	a.EqString(string(file[len(file)-4:]), parquetMagic)
+	length := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	metadata := len(file) - 8 - length
+
+	// Each column is a single page, in order, and the metadata follows the last.
+	offset := len(parquetMagic)
+	for _, column := range frame.Columns {
+		page := column.parquetPage(frame.Rows)
+		index := bytes.Index(file[offset:], page)
+		if index < 0 {
+			t.Fatalf("the page of %s is missing", column.Name)
+		}
+		offset += index + len(page)
+	}
+	a.EqInt(offset, metadata)
+	for _, column := range frame.Columns {
+		if !bytes.Contains(file[metadata:], []byte(column.Name)) {
+			a.Errorf("the schema is missing %s", column.Name)
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	frame, err := FromResults(testResults(t))
+	if err != nil {
+		t.Fatalf("Error creating frame for test: %s", err.Error())
+	}
+	for name, write := range map[string]func(*bytes.Buffer) error{
+		"results.arrow":   func(buffer *bytes.Buffer) error { return frame.WriteArrow(buffer) },
+		"results.parquet": func(buffer *bytes.Buffer) error { return frame.WriteParquet(buffer) },
+	} {
+		a := assert.New(t).Contextf("%s", name)
+		var buffer bytes.Buffer
+		a.CheckError(write(&buffer))
+		filename := filepath.Join("testdata", name)
+		if *updateGolden {
+			if err := ioutil.WriteFile(filename, buffer.Bytes(), 0644); err != nil {
+				t.Fatalf("cannot write golden file %s: %s", filename, err.Error())
+			}
+			continue
+		}
+		expected, err := ioutil.ReadFile(filename)
+		if err != nil {
+			a.Errorf("cannot read golden file %s (run with -update to create it): %s", filename, err.Error())
+			continue
+		}
+		if !bytes.Equal(buffer.Bytes(), expected) {
+			a.Errorf("the encoding differs from %s", filename)
+		}
+	}
+}
+
+func TestEncodeLevels(t *testing.T) {
+	a := assert.New(t)
+	a.Eq(encodeLevels([]bool{true, true, false, true}), []byte{4, 1, 2, 0, 2, 1})
+	a.Eq(encodeLevels(nil), []byte(nil))
+}
+
+// flatTable reads the fields of a FlatBuffers table.
+type flatTable struct {
+	bytes    []byte
+	position uint32
+}
+
+func table(bytes []byte, position uint32) flatTable {
+	return flatTable{bytes: bytes, position: position}
+}
+
+// field finds the position of the given field, or zero if it's absent.
+func (t flatTable) field(field int) uint32 {
+	vtable := uint32(int32(t.position) - int32(binary.LittleEndian.Uint32(t.bytes[t.position:])))
+	if 4+2*uint32(field) >= uint32(binary.LittleEndian.Uint16(t.bytes[vtable:])) {
+		return 0
+	}
+	offset := uint32(binary.LittleEndian.Uint16(t.bytes[vtable+4+2*uint32(field):]))
+	if offset == 0 {
+		return 0
+	}
+	return t.position + offset
+}
+
+func (t flatTable) uint8(field int) uint8 {
+	if position := t.field(field); position != 0 {
+		return t.bytes[position]
+	}
+	return 0
+}
+
+func (t flatTable) uint16(field int) uint16 {
+	if position := t.field(field); position != 0 {
+		return binary.LittleEndian.Uint16(t.bytes[position:])
+	}
+	return 0
+}
+
+func (t flatTable) int64(field int) int64 {
+	if position := t.field(field); position != 0 {
+		return int64(binary.LittleEndian.Uint64(t.bytes[position:]))
+	}
+	return 0
+}
+
+func (t flatTable) indirect(position uint32) uint32 {
+	return position + binary.LittleEndian.Uint32(t.bytes[position:])
+}
+
+func (t flatTable) table(field int) flatTable {
+	return table(t.bytes, t.indirect(t.field(field)))
+}
+
+func (t flatTable) string(field int) string {
+	position := t.indirect(t.field(field))
+	length := binary.LittleEndian.Uint32(t.bytes[position:])
+	return string(t.bytes[position+4 : position+4+length])
+}
+
+func (t flatTable) vector(field int) []flatTable {
+	position := t.indirect(t.field(field))
+	length := binary.LittleEndian.Uint32(t.bytes[position:])
+	tables := make([]flatTable, length)
+	for i := range tables {
+		tables[i] = table(t.bytes, t.indirect(position+4+4*uint32(i)))
+	}
+	return tables
+}
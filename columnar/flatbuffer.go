@@ -0,0 +1,180 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package columnar
+
+import (
+	"encoding/binary"
+)
+
+// builder writes FlatBuffers, the serialization used by Arrow's metadata.
+// As in the reference implementation, the buffer is built backwards, from
+// its end: the children of each table are written before the table, so that
+// the (unsigned) offsets to them point forwards. Offsets are measured from
+// the end of the buffer until it is finished.
+type builder struct {
+	bytes     []byte   // the end of the finished buffer
+	minAlign  int      // the largest alignment required so far
+	fields    []uint32 // the offsets of the fields of the table being built; zero if absent
+	objectEnd uint32   // the offset at which the table being built began
+}
+
+// offset is the offset of the most recently written object.
+func (b *builder) offset() uint32 {
+	return uint32(len(b.bytes))
+}
+
+func (b *builder) prepend(data []byte) {
+	bytes := make([]byte, len(data)+len(b.bytes))
+	copy(bytes, data)
+	copy(bytes[len(data):], b.bytes)
+	b.bytes = bytes
+}
+
+// prep pads the buffer so that, once the given number of additional bytes
+// have been written, it is aligned to the given size.
+func (b *builder) prep(size int, additional int) {
+	if size > b.minAlign {
+		b.minAlign = size
+	}
+	padding := (size - (len(b.bytes)+additional)%size) % size
+	b.prepend(make([]byte, padding))
+}
+
+func (b *builder) prependUint8(value uint8) {
+	b.prepend([]byte{value})
+}
+
+func (b *builder) prependUint16(value uint16) {
+	b.prep(2, 0)
+	var data [2]byte
+	binary.LittleEndian.PutUint16(data[:], value)
+	b.prepend(data[:])
+}
+
+func (b *builder) prependUint32(value uint32) {
+	b.prep(4, 0)
+	var data [4]byte
+	binary.LittleEndian.PutUint32(data[:], value)
+	b.prepend(data[:])
+}
+
+func (b *builder) prependInt64(value int64) {
+	b.prep(8, 0)
+	var data [8]byte
+	binary.LittleEndian.PutUint64(data[:], uint64(value))
+	b.prepend(data[:])
+}
+
+// prependOffset writes an offset to an object written earlier.
+func (b *builder) prependOffset(object uint32) {
+	b.prep(4, 0)
+	b.prependUint32(b.offset() - object + 4)
+}
+
+// createString writes a length-prefixed, null-terminated string.
+func (b *builder) createString(value string) uint32 {
+	b.prep(4, len(value)+1)
+	b.prepend(append([]byte(value), 0))
+	b.prependUint32(uint32(len(value)))
+	return b.offset()
+}
+
+// createOffsetVector writes a vector of offsets to objects written earlier.
+func (b *builder) createOffsetVector(objects []uint32) uint32 {
+	b.prep(4, 4*len(objects))
+	for i := len(objects) - 1; i >= 0; i-- {
+		b.prependOffset(objects[i])
+	}
+	b.prependUint32(uint32(len(objects)))
+	return b.offset()
+}
+
+// createPairVector writes a vector of structs of two 64-bit integers, such as
+// Arrow's Buffer and FieldNode.
+func (b *builder) createPairVector(pairs [][2]int64) uint32 {
+	b.prep(4, 16*len(pairs))
+	b.prep(8, 16*len(pairs))
+	for i := len(pairs) - 1; i >= 0; i-- {
+		b.prependInt64(pairs[i][1])
+		b.prependInt64(pairs[i][0])
+	}
+	b.prependUint32(uint32(len(pairs)))
+	return b.offset()
+}
+
+// startObject begins a table with the given number of fields. Its fields are
+// added with the add methods, and it's completed by endObject.
+func (b *builder) startObject(fields int) {
+	b.fields = make([]uint32, fields)
+	b.objectEnd = b.offset()
+}
+
+func (b *builder) addBool(field int, value bool) {
+	if value {
+		b.addUint8(field, 1)
+	} else {
+		b.addUint8(field, 0)
+	}
+}
+
+func (b *builder) addUint8(field int, value uint8) {
+	b.prependUint8(value)
+	b.fields[field] = b.offset()
+}
+
+func (b *builder) addInt16(field int, value int16) {
+	b.prependUint16(uint16(value))
+	b.fields[field] = b.offset()
+}
+
+func (b *builder) addInt64(field int, value int64) {
+	b.prependInt64(value)
+	b.fields[field] = b.offset()
+}
+
+func (b *builder) addOffset(field int, object uint32) {
+	b.prependOffset(object)
+	b.fields[field] = b.offset()
+}
+
+// endObject completes the table, writing its vtable (which locates each of
+// its fields) just before it.
+func (b *builder) endObject() uint32 {
+	b.prependUint32(0) // the offset of the vtable, filled in below
+	object := b.offset()
+	count := len(b.fields)
+	for count > 0 && b.fields[count-1] == 0 {
+		count--
+	}
+	for i := count - 1; i >= 0; i-- {
+		var field uint16
+		if b.fields[i] != 0 {
+			field = uint16(object - b.fields[i])
+		}
+		b.prependUint16(field)
+	}
+	b.prependUint16(uint16(object - b.objectEnd))
+	b.prependUint16(uint16(2*count + 4))
+	binary.LittleEndian.PutUint32(b.bytes[len(b.bytes)-int(object):], b.offset()-object)
+	b.fields = nil
+	return object
+}
+
+// finish writes the offset of the root table, returning the finished buffer.
+func (b *builder) finish(root uint32) []byte {
+	b.prep(b.minAlign, 4)
+	b.prependOffset(root)
+	return b.bytes
+}
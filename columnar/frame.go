@@ -0,0 +1,151 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package columnar writes the results of select queries in the columnar
+// formats of data-science tools: Arrow IPC streams and Parquet files. Each
+// series becomes a run of rows with a timestamp, a value and one column for
+// each tag, so consumers such as pandas or Spark can load results directly.
+package columnar
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+)
+
+// Kind is the type of the values of a column.
+type Kind int
+
+const (
+	// Timestamp columns hold milliseconds since the epoch, in UTC.
+	Timestamp Kind = iota
+	// Float columns hold 64-bit floating-point numbers.
+	Float
+	// String columns hold UTF-8 strings.
+	String
+)
+
+// A Column is a named sequence of values of a single kind. Only one of
+// Timestamps, Floats and Strings is used, according to its kind.
+type Column struct {
+	Name       string
+	Kind       Kind
+	Nullable   bool
+	Timestamps []int64
+	Floats     []float64
+	Strings    []string
+	Valid      []bool // for nullable columns, whether each row has a value
+}
+
+// nulls counts the rows of the column without a value.
+func (column Column) nulls() int {
+	count := 0
+	if column.Nullable {
+		for _, valid := range column.Valid {
+			if !valid {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// valid reports whether the given row of the column has a value.
+func (column Column) valid(row int) bool {
+	return !column.Nullable || column.Valid[row]
+}
+
+// A Frame is a table of columns of equal length.
+type Frame struct {
+	Rows    int
+	Columns []Column
+}
+
+// The names of the columns which aren't tags.
+const (
+	expressionColumn = "expression"
+	timestampColumn  = "timestamp"
+	valueColumn      = "value"
+)
+
+// FromResults converts the results of a select query into a frame in long
+// form, with one row for each point of each series. When there are several
+// results, an expression column holds the name of the result of each row.
+// NaN values and tags missing from a series are null. A tag whose key is
+// also the name of one of the other columns is prefixed with "tag_".
+func FromResults(results []command.QueryResult) (Frame, error) {
+	rows := 0
+	keySet := map[string]bool{}
+	for _, result := range results {
+		if result.Type != "series" {
+			return Frame{}, fmt.Errorf("only series can be written in columnar formats, but %s is of type %s", result.Query, result.Type)
+		}
+		for _, series := range result.Series {
+			rows += len(series.Values)
+			for key := range series.TagSet {
+				keySet[key] = true
+			}
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	withExpression := len(results) > 1
+	expressions := Column{Name: expressionColumn, Kind: String, Strings: make([]string, 0, rows)}
+	timestamps := Column{Name: timestampColumn, Kind: Timestamp, Timestamps: make([]int64, 0, rows)}
+	values := Column{Name: valueColumn, Kind: Float, Nullable: true, Floats: make([]float64, 0, rows), Valid: make([]bool, 0, rows)}
+	tags := make([]Column, len(keys))
+	for i, key := range keys {
+		name := key
+		if key == timestampColumn || key == valueColumn || (withExpression && key == expressionColumn) {
+			name = "tag_" + key
+		}
+		tags[i] = Column{Name: name, Kind: String, Nullable: true, Strings: make([]string, 0, rows), Valid: make([]bool, 0, rows)}
+	}
+	for _, result := range results {
+		for _, series := range result.Series {
+			for i, value := range series.Values {
+				if withExpression {
+					expressions.Strings = append(expressions.Strings, result.Name)
+				}
+				timestamps.Timestamps = append(timestamps.Timestamps, timestampOf(result.Timerange, i))
+				values.Floats = append(values.Floats, value)
+				values.Valid = append(values.Valid, !math.IsNaN(value))
+				for j, key := range keys {
+					tag, ok := series.TagSet[key]
+					tags[j].Strings = append(tags[j].Strings, tag)
+					tags[j].Valid = append(tags[j].Valid, ok)
+				}
+			}
+		}
+	}
+	frame := Frame{Rows: rows}
+	if withExpression {
+		frame.Columns = append(frame.Columns, expressions)
+	}
+	frame.Columns = append(frame.Columns, timestamps, values)
+	frame.Columns = append(frame.Columns, tags...)
+	return frame, nil
+}
+
+// timestampOf is the time of the given point of a timerange, in milliseconds.
+func timestampOf(timerange api.Timerange, index int) int64 {
+	return timerange.StartMillis() + int64(index)*timerange.ResolutionMillis()
+}
@@ -0,0 +1,225 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// ParquetContentType is the media type of Parquet files.
+const ParquetContentType = "application/vnd.apache.parquet"
+
+// parquetMagic begins and ends each Parquet file.
+const parquetMagic = "PAR1"
+
+// Identifiers from parquet.thrift.
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetUTF8            = 0
+	parquetTimestampMillis = 9
+
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetDataPage = 0
+)
+
+// parquetChunk locates the single page of a column in the file.
+type parquetChunk struct {
+	offset int64
+	size   int64
+}
+
+// WriteParquet writes the frame as a Parquet file holding a single row
+// group. Each column is a single data page, plainly encoded and uncompressed.
+func (frame Frame) WriteParquet(writer io.Writer) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+	chunks := make([]parquetChunk, len(frame.Columns))
+	for i, column := range frame.Columns {
+		page := column.parquetPage(frame.Rows)
+		header := thriftWriter{}
+		header.writeI32(1, parquetDataPage)
+		header.writeI32(2, int32(len(page)))
+		header.writeI32(3, int32(len(page)))
+		header.beginStruct(5)
+		header.writeI32(1, int32(frame.Rows))
+		header.writeI32(2, parquetPlain)
+		header.writeI32(3, parquetRLE)
+		header.writeI32(4, parquetRLE)
+		header.endStruct()
+		header.endStruct()
+		offset := file.Len()
+		file.Write(header.Bytes())
+		file.Write(page)
+		chunks[i] = parquetChunk{offset: int64(offset), size: int64(file.Len() - offset)}
+	}
+	metadata := frame.parquetMetadata(chunks)
+	file.Write(metadata)
+	binary.Write(&file, binary.LittleEndian, uint32(len(metadata)))
+	file.WriteString(parquetMagic)
+	_, err := writer.Write(file.Bytes())
+	return err
+}
+
+// parquetPage encodes the column's values. Nullable columns are preceded by
+// their definition levels: 1 for each row with a value, 0 for each null.
+func (column Column) parquetPage(rows int) []byte {
+	var page bytes.Buffer
+	if column.Nullable {
+		levels := encodeLevels(column.Valid)
+		binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+	var data [8]byte
+	for row := 0; row < rows; row++ {
+		if !column.valid(row) {
+			continue
+		}
+		switch column.Kind {
+		case Timestamp:
+			binary.LittleEndian.PutUint64(data[:], uint64(column.Timestamps[row]))
+			page.Write(data[:])
+		case Float:
+			binary.LittleEndian.PutUint64(data[:], math.Float64bits(column.Floats[row]))
+			page.Write(data[:])
+		case String:
+			binary.LittleEndian.PutUint32(data[:], uint32(len(column.Strings[row])))
+			page.Write(data[:4])
+			page.WriteString(column.Strings[row])
+		}
+	}
+	return page.Bytes()
+}
+
+// encodeLevels encodes definition levels of bit-width one in the RLE hybrid
+// encoding, as a run for each sequence of equal levels.
+func encodeLevels(valid []bool) []byte {
+	var levels bytes.Buffer
+	var header [binary.MaxVarintLen64]byte
+	for start := 0; start < len(valid); {
+		end := start + 1
+		for end < len(valid) && valid[end] == valid[start] {
+			end++
+		}
+		levels.Write(header[:binary.PutUvarint(header[:], uint64(end-start)<<1)])
+		if valid[start] {
+			levels.WriteByte(1)
+		} else {
+			levels.WriteByte(0)
+		}
+		start = end
+	}
+	return levels.Bytes()
+}
+
+// parquetMetadata encodes the FileMetaData footer: the schema, with a root
+// holding each of the columns, and the row group locating their pages.
+func (frame Frame) parquetMetadata(chunks []parquetChunk) []byte {
+	w := thriftWriter{}
+	w.writeI32(1, 1)
+	w.beginList(2, thriftStruct, len(frame.Columns)+1)
+	w.beginElement()
+	w.writeString(4, "schema")
+	w.writeI32(5, int32(len(frame.Columns)))
+	w.endStruct()
+	for _, column := range frame.Columns {
+		w.beginElement()
+		w.writeI32(1, column.parquetType())
+		if column.Nullable {
+			w.writeI32(3, parquetOptional)
+		} else {
+			w.writeI32(3, parquetRequired)
+		}
+		w.writeString(4, column.Name)
+		switch column.Kind {
+		case Timestamp:
+			w.writeI32(6, parquetTimestampMillis)
+			w.beginStruct(10)
+			w.beginStruct(8) // TIMESTAMP
+			w.writeBool(1, true)
+			w.beginStruct(2)
+			w.beginStruct(1) // MILLIS
+			w.endStruct()
+			w.endStruct()
+			w.endStruct()
+			w.endStruct()
+		case String:
+			w.writeI32(6, parquetUTF8)
+			w.beginStruct(10)
+			w.beginStruct(1) // STRING
+			w.endStruct()
+			w.endStruct()
+		}
+		w.endStruct()
+	}
+	w.writeI64(3, int64(frame.Rows))
+	w.beginList(4, thriftStruct, 1)
+	w.beginElement()
+	w.beginList(1, thriftStruct, len(frame.Columns))
+	total := int64(0)
+	for i, column := range frame.Columns {
+		chunk := chunks[i]
+		total += chunk.size
+		w.beginElement()
+		w.writeI64(2, chunk.offset)
+		w.beginStruct(3)
+		w.writeI32(1, column.parquetType())
+		if column.Nullable {
+			w.beginList(2, thriftI32, 2)
+			w.writeI32Element(parquetPlain)
+			w.writeI32Element(parquetRLE)
+		} else {
+			w.beginList(2, thriftI32, 1)
+			w.writeI32Element(parquetPlain)
+		}
+		w.beginList(3, thriftBinary, 1)
+		w.writeStringElement(column.Name)
+		w.writeI32(4, 0) // uncompressed
+		w.writeI64(5, int64(frame.Rows))
+		w.writeI64(6, chunk.size)
+		w.writeI64(7, chunk.size)
+		w.writeI64(9, chunk.offset)
+		w.endStruct()
+		w.endStruct()
+	}
+	w.writeI64(2, total)
+	w.writeI64(3, int64(frame.Rows))
+	w.endStruct()
+	w.writeString(6, "mqe")
+	w.endStruct()
+	return w.Bytes()
+}
+
+// parquetType is the physical type of the column.
+func (column Column) parquetType() int32 {
+	switch column.Kind {
+	case Timestamp:
+		return parquetInt64
+	case Float:
+		return parquetDouble
+	default:
+		return parquetByteArray
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Types of Thrift's compact protocol.
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter writes structs in Thrift's compact protocol, in which Parquet
+// encodes its metadata. Field headers hold the difference from the previous
+// field's identifier, so the identifiers of enclosing structs are stacked.
+type thriftWriter struct {
+	bytes.Buffer
+	last  int16   // the identifier of the previous field of the current struct
+	stack []int16 // the identifiers of the previous fields of the enclosing structs
+}
+
+func (w *thriftWriter) varint(value uint64) {
+	var data [binary.MaxVarintLen64]byte
+	w.Write(data[:binary.PutUvarint(data[:], value)])
+}
+
+func (w *thriftWriter) zigzag(value int64) {
+	w.varint(uint64((value << 1) ^ (value >> 63)))
+}
+
+func (w *thriftWriter) field(id int16, kind byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		w.WriteByte(kind)
+		w.zigzag(int64(id))
+	}
+	w.last = id
+}
+
+func (w *thriftWriter) writeBool(id int16, value bool) {
+	if value {
+		w.field(id, thriftTrue)
+	} else {
+		w.field(id, thriftFalse)
+	}
+}
+
+func (w *thriftWriter) writeI32(id int16, value int32) {
+	w.field(id, thriftI32)
+	w.zigzag(int64(value))
+}
+
+func (w *thriftWriter) writeI64(id int16, value int64) {
+	w.field(id, thriftI64)
+	w.zigzag(value)
+}
+
+func (w *thriftWriter) writeString(id int16, value string) {
+	w.field(id, thriftBinary)
+	w.writeStringElement(value)
+}
+
+// beginStruct begins a struct-valued field, which is ended by endStruct.
+func (w *thriftWriter) beginStruct(id int16) {
+	w.field(id, thriftStruct)
+	w.beginElement()
+}
+
+// beginList begins a list-valued field. Its elements follow, written by the
+// element methods.
+func (w *thriftWriter) beginList(id int16, kind byte, size int) {
+	w.field(id, thriftList)
+	if size < 15 {
+		w.WriteByte(byte(size)<<4 | kind)
+	} else {
+		w.WriteByte(0xF0 | kind)
+		w.varint(uint64(size))
+	}
+}
+
+// beginElement begins a struct in a list, which is ended by endStruct.
+func (w *thriftWriter) beginElement() {
+	w.stack = append(w.stack, w.last)
+	w.last = 0
+}
+
+func (w *thriftWriter) writeI32Element(value int32) {
+	w.zigzag(int64(value))
+}
+
+func (w *thriftWriter) writeStringElement(value string) {
+	w.varint(uint64(len(value)))
+	w.WriteString(value)
+}
+
+// endStruct ends the current struct, which may be the outermost one.
+func (w *thriftWriter) endStruct() {
+	w.WriteByte(0)
+	if len(w.stack) > 0 {
+		w.last = w.stack[len(w.stack)-1]
+		w.stack = w.stack[:len(w.stack)-1]
+	}
+}
@@ -117,7 +117,7 @@ func main() {
 		}
 
 		// All of the metrics that were successfully converted will be placed into the Cassandra store by MQE.
-		err = cassandra.AddMetrics(converted, metadata.Context{})
+		err = cassandra.AddMetrics(req.Context(), converted, metadata.Context{})
 		if err != nil {
 			log.Printf("Error sending metrics to Cassandra: %s", err.Error())
 			w.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,243 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package endpoints spreads the requests for an HTTP backend across a pool
+// of its servers, so that no load balancer is needed in front of them.
+// Servers are chosen by weighted round-robin; those which fail repeatedly,
+// whether in requests or in active health checks, are ejected until they
+// recover.
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/log"
+)
+
+// ErrNoEndpoints is returned by Next when the pool is empty.
+var ErrNoEndpoints = errors.New("the pool has no endpoints")
+
+// Config describes a pool.
+type Config struct {
+	Endpoints    []Endpoint        `yaml:"endpoints"`
+	HealthCheck  HealthCheckConfig `yaml:"health_check"`
+	MaxFailures  int               `yaml:"max_failures"`  // consecutive failures before an endpoint is ejected; defaults to 3
+	EjectionTime time.Duration     `yaml:"ejection_time"` // how long an ejected endpoint is skipped when there are no health checks; defaults to 30s
+}
+
+// Enabled determines whether any endpoints have been configured.
+func (c Config) Enabled() bool {
+	return len(c.Endpoints) != 0
+}
+
+// Endpoint is a server of the backend.
+type Endpoint struct {
+	URL    string `yaml:"url"`    // the base URL of the server
+	Weight int    `yaml:"weight"` // its share of the requests, relative to the others; defaults to 1
+}
+
+// HealthCheckConfig describes the active health checks of the endpoints. An
+// ejected endpoint is returned to the pool once it passes a check.
+type HealthCheckConfig struct {
+	Path     string        `yaml:"path"`     // requested from each endpoint; it's healthy if the status is 2xx
+	Interval time.Duration `yaml:"interval"` // if zero, endpoints aren't checked
+}
+
+// Getter makes the requests of the health checks.
+type Getter interface {
+	Get(url string) (*http.Response, error)
+}
+
+// Pool chooses among the endpoints of a backend. It's safe for concurrent use.
+type Pool struct {
+	config Config
+	client Getter
+	now    func() time.Time
+
+	mutex   sync.Mutex
+	members []*member
+	onEject func(url string, failures int)
+}
+
+type member struct {
+	Endpoint
+	current      int // the smooth weighted round-robin counter
+	failures     int // consecutive failures
+	ejected      bool
+	ejectedUntil time.Time
+}
+
+// NewPool creates a pool of the configured endpoints.
+func NewPool(config Config, client Getter) *Pool {
+	if config.MaxFailures == 0 {
+		config.MaxFailures = 3
+	}
+	if config.EjectionTime == 0 {
+		config.EjectionTime = 30 * time.Second
+	}
+	pool := &Pool{config: config, client: client, now: time.Now}
+	pool.SetEndpoints(config.Endpoints)
+	return pool
+}
+
+// SetEndpoints replaces the endpoints, such as when they're discovered anew.
+// Endpoints which remain keep their health.
+func (p *Pool) SetEndpoints(endpoints []Endpoint) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	previous := map[string]*member{}
+	for _, m := range p.members {
+		previous[m.URL] = m
+	}
+	members := make([]*member, len(endpoints))
+	for i, endpoint := range endpoints {
+		if endpoint.Weight <= 0 {
+			endpoint.Weight = 1
+		}
+		if m, ok := previous[endpoint.URL]; ok {
+			m.Weight = endpoint.Weight
+			members[i] = m
+			continue
+		}
+		members[i] = &member{Endpoint: endpoint}
+	}
+	p.members = members
+}
+
+// Next chooses the endpoint for a request, returning its URL. If every
+// endpoint has been ejected, they're all used, since the backend may be
+// reachable after all.
+func (p *Pool) Next() (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.members) == 0 {
+		return "", ErrNoEndpoints
+	}
+	now := p.now()
+	candidates := []*member{}
+	for _, m := range p.members {
+		if m.ejected && p.config.HealthCheck.Interval == 0 && !now.Before(m.ejectedUntil) {
+			// On probation: one more failure ejects it again.
+			m.ejected = false
+			m.failures = p.config.MaxFailures - 1
+		}
+		if !m.ejected {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = p.members
+	}
+	total := 0
+	var chosen *member
+	for _, m := range candidates {
+		m.current += m.Weight
+		total += m.Weight
+		if chosen == nil || m.current > chosen.current {
+			chosen = m
+		}
+	}
+	chosen.current -= total
+	return chosen.URL, nil
+}
+
+// OnEject sets a function which is called whenever an endpoint is ejected,
+// such as to notify operators. It's called without the pool locked.
+func (p *Pool) OnEject(callback func(url string, failures int)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.onEject = callback
+}
+
+// Report records the outcome of a request to the given URL, which is
+// attributed to the endpoint whose URL it starts with.
+func (p *Pool) Report(url string, healthy bool) {
+	p.mutex.Lock()
+	ejected, failures := "", 0
+	for _, m := range p.members {
+		if within(url, m.URL) {
+			if p.record(m, healthy) {
+				ejected, failures = m.URL, m.failures
+			}
+			break
+		}
+	}
+	callback := p.onEject
+	p.mutex.Unlock()
+	if ejected != "" && callback != nil {
+		callback(ejected, failures)
+	}
+}
+
+// within determines whether the URL is beneath the base URL.
+func within(url string, base string) bool {
+	if !strings.HasPrefix(url, base) {
+		return false
+	}
+	rest := url[len(base):]
+	return rest == "" || strings.HasSuffix(base, "/") || rest[0] == '/' || rest[0] == '?'
+}
+
+// record updates the health of the endpoint, returning whether it was ejected.
+func (p *Pool) record(m *member, healthy bool) bool {
+	if healthy {
+		if m.ejected {
+			log.Infof("Returning %s to the pool", m.URL)
+		}
+		m.failures = 0
+		m.ejected = false
+		return false
+	}
+	m.failures++
+	if !m.ejected && m.failures >= p.config.MaxFailures {
+		log.Warningf("Ejecting %s from the pool after %d consecutive failures", m.URL, m.failures)
+		m.ejected = true
+		m.ejectedUntil = p.now().Add(p.config.EjectionTime)
+		return true
+	}
+	return false
+}
+
+// Check checks the health of each endpoint once.
+func (p *Pool) Check() {
+	p.mutex.Lock()
+	urls := make([]string, len(p.members))
+	for i, m := range p.members {
+		urls[i] = m.URL
+	}
+	p.mutex.Unlock()
+	for _, url := range urls {
+		response, err := p.client.Get(url + p.config.HealthCheck.Path)
+		healthy := err == nil && response.StatusCode/100 == 2
+		if response != nil {
+			response.Body.Close()
+		}
+		p.Report(url, healthy)
+	}
+}
+
+// Run checks the health of the endpoints periodically, forever. It returns
+// at once if health checks aren't configured.
+func (p *Pool) Run() {
+	if p.config.HealthCheck.Interval == 0 {
+		return
+	}
+	for range time.Tick(p.config.HealthCheck.Interval) {
+		p.Check()
+	}
+}
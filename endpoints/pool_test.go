@@ -0,0 +1,129 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoints
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+// statusGetter responds to health checks with the status of each URL.
+type statusGetter map[string]int
+
+func (g statusGetter) Get(url string) (*http.Response, error) {
+	status, ok := g[url]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	return &http.Response{StatusCode: status, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+}
+
+func count(pool *Pool, n int) map[string]int {
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		url, err := pool.Next()
+		if err != nil {
+			panic(err)
+		}
+		counts[url]++
+	}
+	return counts
+}
+
+func TestPool_WeightedRoundRobin(t *testing.T) {
+	a := assert.New(t)
+	pool := NewPool(Config{Endpoints: []Endpoint{{URL: "http://a", Weight: 3}, {URL: "http://b"}}}, nil)
+	a.Eq(count(pool, 8), map[string]int{"http://a": 6, "http://b": 2})
+
+	// The requests are interleaved, not sent in bursts.
+	sequence := ""
+	for i := 0; i < 4; i++ {
+		url, _ := pool.Next()
+		sequence += url[len(url)-1:]
+	}
+	a.EqString(sequence, "aaba")
+
+	if _, err := NewPool(Config{}, nil).Next(); err != ErrNoEndpoints {
+		t.Errorf("expected ErrNoEndpoints from an empty pool, but got %v", err)
+	}
+}
+
+func TestPool_Ejection(t *testing.T) {
+	a := assert.New(t)
+	now := time.Unix(0, 0)
+	pool := NewPool(Config{Endpoints: []Endpoint{{URL: "http://a"}, {URL: "http://b"}}, MaxFailures: 2, EjectionTime: time.Minute}, nil)
+	pool.now = func() time.Time { return now }
+	ejections := []string{}
+	pool.OnEject(func(url string, failures int) {
+		ejections = append(ejections, fmt.Sprintf("%s:%d", url, failures))
+	})
+
+	pool.Report("http://a/v2.0/tenant/views/cpu?from=0", false)
+	a.Eq(count(pool, 4), map[string]int{"http://a": 2, "http://b": 2})
+	pool.Report("http://a/v2.0/tenant/views/cpu?from=0", false)
+	a.Eq(count(pool, 4), map[string]int{"http://b": 4})
+	a.Eq(ejections, []string{"http://a:2"})
+
+	// Endpoints whose URLs merely share a prefix are unaffected.
+	pool.Report("http://bb/v2.0", false)
+	pool.Report("http://bb/v2.0", false)
+	a.Eq(count(pool, 4), map[string]int{"http://b": 4})
+
+	// With every endpoint ejected, all of them are used.
+	pool.Report("http://b", false)
+	pool.Report("http://b", false)
+	a.Eq(count(pool, 4), map[string]int{"http://a": 2, "http://b": 2})
+
+	// After the ejection time, an endpoint is on probation: one failure
+	// ejects it again, but a success restores it.
+	now = now.Add(time.Minute)
+	a.Eq(count(pool, 4), map[string]int{"http://a": 2, "http://b": 2})
+	pool.Report("http://a", false)
+	pool.Report("http://b", true)
+	a.Eq(count(pool, 4), map[string]int{"http://b": 4})
+	pool.Report("http://b", false)
+	a.Eq(count(pool, 4), map[string]int{"http://b": 4})
+	a.Eq(ejections, []string{"http://a:2", "http://b:2", "http://a:2"})
+
+	// Replacing the endpoints keeps the health of those which remain.
+	pool.SetEndpoints([]Endpoint{{URL: "http://a"}, {URL: "http://c"}})
+	a.Eq(count(pool, 4), map[string]int{"http://c": 4})
+}
+
+func TestPool_HealthCheck(t *testing.T) {
+	a := assert.New(t)
+	getter := statusGetter{"http://a/health": 200, "http://b/health": 503}
+	pool := NewPool(Config{
+		Endpoints:   []Endpoint{{URL: "http://a"}, {URL: "http://b"}, {URL: "http://c"}},
+		HealthCheck: HealthCheckConfig{Path: "/health", Interval: time.Second},
+		MaxFailures: 1,
+	}, getter)
+	pool.Check()
+	a.Eq(count(pool, 4), map[string]int{"http://a": 4})
+
+	// Ejected endpoints return only once they pass a check.
+	pool.now = func() time.Time { return time.Now().Add(time.Hour) }
+	a.Eq(count(pool, 4), map[string]int{"http://a": 4})
+	getter["http://c/health"] = 204
+	pool.Check()
+	a.Eq(count(pool, 4), map[string]int{"http://a": 2, "http://c": 2})
+}
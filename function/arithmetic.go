@@ -0,0 +1,73 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"fmt"
+	"time"
+)
+
+// scalarOperators holds the arithmetic operators which may be applied to literals.
+var scalarOperators = map[string]func(float64, float64) float64{
+	"+": func(x float64, y float64) float64 { return x + y },
+	"-": func(x float64, y float64) float64 { return x - y },
+	"*": func(x float64, y float64) float64 { return x * y },
+	"/": func(x float64, y float64) float64 { return x / y },
+}
+
+// Arithmetic applies the binary operator `op` to a pair of scalar or duration values.
+// The supported combinations are:
+//
+//	scalar op scalar        => scalar
+//	duration +/- duration   => duration
+//	duration / duration     => scalar
+//	duration * scalar       => duration (and scalar * duration)
+//	duration / scalar       => duration
+//
+// If either value is neither a scalar nor a duration, ok is false and the
+// caller should apply the operator to series lists instead.
+func Arithmetic(op string, left Value, right Value) (result Value, ok bool, err error) {
+	operator, ok := scalarOperators[op]
+	if !ok {
+		return nil, false, nil
+	}
+	leftScalar, leftIsScalar := left.(ScalarValue)
+	rightScalar, rightIsScalar := right.(ScalarValue)
+	leftDuration, leftIsDuration := left.(DurationValue)
+	rightDuration, rightIsDuration := right.(DurationValue)
+	if !(leftIsScalar || leftIsDuration) || !(rightIsScalar || rightIsDuration) {
+		return nil, false, nil
+	}
+	switch {
+	case leftIsScalar && rightIsScalar:
+		return ScalarValue(operator(float64(leftScalar), float64(rightScalar))), true, nil
+	case leftIsDuration && rightIsDuration && (op == "+" || op == "-"):
+		return NewDurationValue("", time.Duration(operator(float64(leftDuration.duration), float64(rightDuration.duration)))), true, nil
+	case leftIsDuration && rightIsDuration && op == "/":
+		return ScalarValue(operator(float64(leftDuration.duration), float64(rightDuration.duration))), true, nil
+	case leftIsDuration && rightIsScalar && (op == "*" || op == "/"):
+		return NewDurationValue("", time.Duration(operator(float64(leftDuration.duration), float64(rightScalar)))), true, nil
+	case leftIsScalar && rightIsDuration && op == "*":
+		return NewDurationValue("", time.Duration(operator(float64(leftScalar), float64(rightDuration.duration)))), true, nil
+	}
+	return nil, true, fmt.Errorf("operator %s cannot be applied to a %s and a %s", op, arithmeticTypeName(leftIsDuration), arithmeticTypeName(rightIsDuration))
+}
+
+func arithmeticTypeName(isDuration bool) string {
+	if isDuration {
+		return "duration"
+	}
+	return "scalar"
+}
@@ -0,0 +1,59 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestArithmetic(t *testing.T) {
+	minute := NewDurationValue("1m", time.Minute)
+	second := NewDurationValue("1s", time.Second)
+	for _, test := range []struct {
+		op          string
+		left        Value
+		right       Value
+		expectOk    bool
+		expectError bool
+		expected    Value
+	}{
+		{"*", ScalarValue(1024), ScalarValue(1024), true, false, ScalarValue(1024 * 1024)},
+		{"-", ScalarValue(3), ScalarValue(5), true, false, ScalarValue(-2)},
+		{"*", ScalarValue(30), minute, true, false, NewDurationValue("", 30*time.Minute)},
+		{"*", minute, ScalarValue(2), true, false, NewDurationValue("", 2*time.Minute)},
+		{"/", minute, ScalarValue(4), true, false, NewDurationValue("", 15*time.Second)},
+		{"+", minute, second, true, false, NewDurationValue("", 61*time.Second)},
+		{"-", minute, second, true, false, NewDurationValue("", 59*time.Second)},
+		{"/", minute, second, true, false, ScalarValue(60)},
+		{"*", minute, second, true, true, nil},
+		{"+", minute, ScalarValue(1), true, true, nil},
+		{"/", ScalarValue(1), minute, true, true, nil},
+		{"+", ScalarValue(1), SeriesListValue(api.SeriesList{}), false, false, nil},
+		{"+", StringValue("a"), ScalarValue(1), false, false, nil},
+		{"%", ScalarValue(1), ScalarValue(1), false, false, nil},
+	} {
+		a := assert.New(t).Contextf("%+v %s %+v", test.left, test.op, test.right)
+		result, ok, err := Arithmetic(test.op, test.left, test.right)
+		a.EqBool(ok, test.expectOk)
+		a.EqBool(err != nil, test.expectError)
+		if test.expected != nil {
+			a.Eq(result, test.expected)
+		}
+	}
+}
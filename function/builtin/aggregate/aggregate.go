@@ -220,3 +220,55 @@ func By(list api.SeriesList, aggregator func([]float64) float64, tags []string,
 	}
 	return result
 }
+
+// WeightedMeanBy groups pairs of series by the tags, and finds the mean of
+// the values of each group, weighted by the matching weights. The values and
+// weights must already be joined, so that `values.Series[i]` is weighted by
+// `weights.Series[i]`, and both have the tagset of their joined row. A value
+// or weight which is NaN leaves out the pair, and a group with no weight at
+// a point has a NaN mean there.
+func WeightedMeanBy(values api.SeriesList, weights api.SeriesList, tags []string, collapses bool) api.SeriesList {
+	products := api.SeriesList{Series: make([]api.Timeseries, len(values.Series))}
+	counted := api.SeriesList{Series: make([]api.Timeseries, len(values.Series))}
+	for i := range values.Series {
+		value := values.Series[i]
+		weight := weights.Series[i]
+		products.Series[i] = api.Timeseries{Values: make([]float64, len(value.Values)), TagSet: value.TagSet}
+		counted.Series[i] = api.Timeseries{Values: make([]float64, len(value.Values)), TagSet: value.TagSet}
+		for j := range value.Values {
+			if math.IsNaN(value.Values[j]) || math.IsNaN(weight.Values[j]) {
+				products.Series[i].Values[j] = math.NaN()
+				counted.Series[i].Values[j] = math.NaN()
+				continue
+			}
+			products.Series[i].Values[j] = value.Values[j] * weight.Values[j]
+			counted.Series[i].Values[j] = weight.Values[j]
+		}
+	}
+	// Both lists have the same tagsets in the same order, so they're grouped
+	// into the same rows.
+	sums := By(products, Sum, tags, collapses)
+	totals := By(counted, Sum, tags, collapses)
+	for i := range sums.Series {
+		for j, total := range totals.Series[i].Values {
+			if total == 0 {
+				sums.Series[i].Values[j] = math.NaN()
+				continue
+			}
+			sums.Series[i].Values[j] /= total
+		}
+	}
+	return sums
+}
+
+// GroupBy breaks the list into groups of series which agree on each of the
+// tags (or, if collapses, on every other tag), like By. Each series of a
+// group has the tagset of the group.
+func GroupBy(list api.SeriesList, tags []string, collapses bool) []api.SeriesList {
+	groups := groupBy(list, tags, collapses)
+	result := make([]api.SeriesList, len(groups))
+	for i, group := range groups {
+		result[i] = api.SeriesList{Series: group.List}
+	}
+	return result
+}
@@ -640,3 +640,33 @@ func Test_AggregateBy(t *testing.T) {
 		}
 	}
 }
+
+func Test_WeightedMeanBy(t *testing.T) {
+	a := assert.New(t)
+	nan := math.NaN()
+	values := api.SeriesList{
+		Series: []api.Timeseries{
+			{Values: []float64{10, 10, nan, 10}, TagSet: api.TagSet{"dc": "A", "host": "#1"}},
+			{Values: []float64{20, 40, 20, 20}, TagSet: api.TagSet{"dc": "A", "host": "#2"}},
+			{Values: []float64{5, 5, 5, 5}, TagSet: api.TagSet{"dc": "B", "host": "#3"}},
+		},
+	}
+	weights := api.SeriesList{
+		Series: []api.Timeseries{
+			{Values: []float64{1, 3, 1, 0}, TagSet: api.TagSet{"dc": "A", "host": "#1"}},
+			{Values: []float64{3, 1, 1, 0}, TagSet: api.TagSet{"dc": "A", "host": "#2"}},
+			{Values: []float64{2, nan, 0, 2}, TagSet: api.TagSet{"dc": "B", "host": "#3"}},
+		},
+	}
+	result := WeightedMeanBy(values, weights, []string{"dc"}, false)
+	a.EqInt(len(result.Series), 2)
+	expected := map[string][]float64{
+		"A": {17.5, 17.5, 20, nan},
+		"B": {5, nan, nan, 5},
+	}
+	for _, series := range result.Series {
+		a := a.Contextf("dc %s", series.TagSet["dc"])
+		a.EqInt(len(series.TagSet), 1)
+		a.EqFloatArray(series.Values, expected[series.TagSet["dc"]], epsilon)
+	}
+}
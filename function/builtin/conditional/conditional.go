@@ -0,0 +1,94 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditional
+
+import (
+	"math"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/function/builtin/join"
+)
+
+// hasData returns true if the series contains at least one value which is not NaN.
+func hasData(series api.Timeseries) bool {
+	for _, value := range series.Values {
+		if !math.IsNaN(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Coalesce returns every series of `primary` which contains data, along with
+// each series of `fallback` whose tagset has no such series in `primary`.
+// This allows a query to fall back to a legacy metric while a migration is in
+// progress, on a tagset-by-tagset basis.
+func Coalesce(primary api.SeriesList, fallback api.SeriesList) api.SeriesList {
+	result := []api.Timeseries{}
+	for _, series := range primary.Series {
+		if hasData(series) {
+			result = append(result, series)
+		}
+	}
+	covered := len(result)
+	for _, series := range fallback.Series {
+		found := false
+		for _, existing := range result[:covered] {
+			if existing.TagSet.Equals(series.TagSet) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, series)
+		}
+	}
+	return api.SeriesList{
+		Series: result,
+	}
+}
+
+// If selects point-wise between `then` and `otherwise` according to `condition`.
+// The three lists are joined on their tags; a non-zero condition selects `then`,
+// a zero condition selects `otherwise` and a NaN condition results in NaN.
+func If(condition api.SeriesList, then api.SeriesList, otherwise api.SeriesList) api.SeriesList {
+	joined := join.Join([]api.SeriesList{condition, then, otherwise})
+	result := make([]api.Timeseries, len(joined.Rows))
+	for i, row := range joined.Rows {
+		conditionValues := row.Row[0].Values
+		values := make([]float64, len(conditionValues))
+		for j, value := range conditionValues {
+			switch {
+			case math.IsNaN(value):
+				values[j] = math.NaN()
+			case value != 0:
+				values[j] = row.Row[1].Values[j]
+			default:
+				values[j] = row.Row[2].Values[j]
+			}
+		}
+		result[i] = api.Timeseries{Values: values, TagSet: row.TagSet}
+	}
+	return api.SeriesList{
+		Series: result,
+	}
+}
+
+// CoalesceFunction wraps up Coalesce into a Function called "coalesce"
+var CoalesceFunction = function.MakeFunction("coalesce", Coalesce)
+
+// IfFunction wraps up If into a Function called "if"
+var IfFunction = function.MakeFunction("if", If)
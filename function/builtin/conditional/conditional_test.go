@@ -0,0 +1,72 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditional
+
+import (
+	"math"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+var nan = math.NaN()
+
+func TestCoalesce(t *testing.T) {
+	primary := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{1, nan, 3}, TagSet: api.TagSet{"host": "a"}},
+		{Values: []float64{nan, nan, nan}, TagSet: api.TagSet{"host": "b"}},
+	}}
+	fallback := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{7, 8, 9}, TagSet: api.TagSet{"host": "a"}},
+		{Values: []float64{4, 5, 6}, TagSet: api.TagSet{"host": "b"}},
+		{Values: []float64{0, 0, 0}, TagSet: api.TagSet{"host": "c"}},
+	}}
+	result := Coalesce(primary, fallback)
+	a := assert.New(t)
+	a.EqInt(len(result.Series), 3)
+	expected := map[string][]float64{
+		"a": {1, nan, 3},
+		"b": {4, 5, 6},
+		"c": {0, 0, 0},
+	}
+	for _, series := range result.Series {
+		a.Contextf("host=%s", series.TagSet["host"]).EqFloatArray(series.Values, expected[series.TagSet["host"]], 1e-9)
+	}
+}
+
+func TestIf(t *testing.T) {
+	condition := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{1, 0, nan, -2}, TagSet: api.TagSet{"host": "a"}},
+		{Values: []float64{0, 0, 1, 1}, TagSet: api.TagSet{"host": "b"}},
+	}}
+	then := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{10, 20, 30, 40}, TagSet: api.TagSet{"host": "a"}},
+		{Values: []float64{50, 60, 70, 80}, TagSet: api.TagSet{"host": "b"}},
+	}}
+	otherwise := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{-1, -1, -1, -1}, TagSet: api.TagSet{}},
+	}}
+	result := If(condition, then, otherwise)
+	a := assert.New(t)
+	a.EqInt(len(result.Series), 2)
+	expected := map[string][]float64{
+		"a": {10, -1, nan, 40},
+		"b": {-1, -1, 70, 80},
+	}
+	for _, series := range result.Series {
+		a.Contextf("host=%s", series.TagSet["host"]).EqFloatArray(series.Values, expected[series.TagSet["host"]], 1e-9)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package find
+
+import (
+	"math"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+)
+
+// crossing makes a function which gives, for each series, the time (in
+// milliseconds since the epoch) of the first or last point satisfying the
+// predicate, or NaN if no point does. Missing points never satisfy it.
+func crossing(name string, last bool, predicate func(value float64, threshold float64) bool) function.MetricFunction {
+	return function.MakeFunction(
+		name,
+		func(list api.SeriesList, threshold float64, timerange api.Timerange) function.ScalarSet {
+			result := make(function.ScalarSet, len(list.Series))
+			for i, series := range list.Series {
+				result[i] = function.TaggedScalar{
+					TagSet: series.TagSet,
+					Value:  math.NaN(),
+				}
+				for j := range series.Values {
+					index := j
+					if last {
+						index = len(series.Values) - 1 - j
+					}
+					value := series.Values[index]
+					if !math.IsNaN(value) && predicate(value, threshold) {
+						result[i].Value = float64(timerange.StartMillis() + int64(index)*timerange.ResolutionMillis())
+						break
+					}
+				}
+			}
+			return result
+		},
+	)
+}
+
+// FirstAbove gives the time at which each series was first above the threshold.
+var FirstAbove = crossing("find.first_above", false, func(value float64, threshold float64) bool { return value > threshold })
+
+// LastBelow gives the time at which each series was last below the threshold.
+var LastBelow = crossing("find.last_below", true, func(value float64, threshold float64) bool { return value < threshold })
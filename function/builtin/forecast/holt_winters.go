@@ -0,0 +1,233 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+)
+
+// The learning rates of forecast.holt_winters, "per period" as for the
+// rolling models: the weight of a period's values relative to the period
+// before it. The seasonal rate applies to each phase once a period.
+const (
+	holtWintersLevelRate    = 0.5
+	holtWintersTrendRate    = 0.1
+	holtWintersSeasonalRate = 0.3
+)
+
+// defaultSeasonality is the period of the seasonal term of forecast.holt_winters,
+// unless another is given.
+const defaultSeasonality = 24 * time.Hour
+
+// HoltWinters forecasts each point from the points before it with the
+// additive Holt-Winters model (triple exponential smoothing). The first
+// period of the values initializes the model, so its forecasts are NaN, and
+// the trend begins as the change from the first period to the second.
+// Missing (NaN) values aren't observed, and the model continues its trend
+// through them, so that points past the end of the data are forecast too.
+func HoltWinters(ys []float64, period int, levelLearningRate float64, trendLearningRate float64, seasonalLearningRate float64) []float64 {
+	// As in RollingMultiplicativeHoltWinters, the level and trend rates are per period.
+	levelLearningRate = 1 - math.Pow(1-levelLearningRate, 1/float64(period))
+	trendLearningRate = 1 - math.Pow(1-trendLearningRate, 1/float64(period))
+	estimate := make([]float64, len(ys))
+	if len(ys) < period {
+		for i := range estimate {
+			estimate[i] = math.NaN()
+		}
+		return estimate
+	}
+
+	// The level begins as the mean of the first period, and the seasonal
+	// terms as the differences from it.
+	level := 0.0
+	count := 0
+	for _, y := range ys[:period] {
+		if !math.IsNaN(y) {
+			level += y
+			count++
+		}
+	}
+	level /= float64(count) // NaN if the first period is missing
+	// The trend begins as the mean change between the first two periods.
+	trend := 0.0
+	if len(ys) >= 2*period {
+		changes := 0
+		for i := 0; i < period; i++ {
+			if !math.IsNaN(ys[i]) && !math.IsNaN(ys[i+period]) {
+				trend += (ys[i+period] - ys[i]) / float64(period)
+				changes++
+			}
+		}
+		if changes > 0 {
+			trend /= float64(changes)
+		}
+	}
+	season := make([]float64, period)
+	for i, y := range ys[:period] {
+		estimate[i] = math.NaN()
+		if !math.IsNaN(y) {
+			season[i] = y - level
+		}
+	}
+
+	for i := period; i < len(ys); i++ {
+		phase := i % period
+		estimate[i] = level + trend + season[phase]
+		y := ys[i]
+		if math.IsNaN(y) {
+			level += trend
+			continue
+		}
+		if math.IsNaN(level) {
+			// Nothing was observed before this point.
+			level = y - season[phase]
+			continue
+		}
+		newLevel := levelLearningRate*(y-season[phase]) + (1-levelLearningRate)*(level+trend)
+		trend = trendLearningRate*(newLevel-level) + (1-trendLearningRate)*trend
+		season[phase] = seasonalLearningRate*(y-newLevel) + (1-seasonalLearningRate)*season[phase]
+		level = newLevel
+	}
+	return estimate
+}
+
+// residualDeviation is the standard deviation of the differences between the
+// values and their forecasts, among the first `training` points where both
+// are known. If there are fewer than two such points, every point is used.
+func residualDeviation(ys []float64, estimate []float64, training int) float64 {
+	residuals := func(limit int) []float64 {
+		result := []float64{}
+		for i := 0; i < limit; i++ {
+			if !math.IsNaN(ys[i]) && !math.IsNaN(estimate[i]) {
+				result = append(result, ys[i]-estimate[i])
+			}
+		}
+		return result
+	}
+	differences := residuals(training)
+	if len(differences) < 2 {
+		differences = residuals(len(ys))
+	}
+	if len(differences) < 2 {
+		return math.NaN()
+	}
+	mean := 0.0
+	for _, difference := range differences {
+		mean += difference
+	}
+	mean /= float64(len(differences))
+	variance := 0.0
+	for _, difference := range differences {
+		variance += (difference - mean) * (difference - mean)
+	}
+	return math.Sqrt(variance / float64(len(differences)-1))
+}
+
+// holtWintersForecasts fetches the series over the training period as well as
+// the timerange, and forecasts them. It returns the fetched series, their
+// forecasts, and the number of slots fetched for training.
+func holtWintersForecasts(name string, context function.EvaluationContext, seriesExpression function.Expression, training time.Duration, optionalSeasonality *time.Duration) (api.SeriesList, [][]float64, int, error) {
+	seasonality := defaultSeasonality
+	if optionalSeasonality != nil {
+		seasonality = *optionalSeasonality
+	}
+	samples := int(seasonality / context.Timerange().Resolution())
+	if samples <= 0 {
+		return api.SeriesList{}, nil, 0, fmt.Errorf("%s expects the seasonality to be at least one slot", name)
+	}
+	newContext := context.WithTimerange(context.Timerange().ExtendBefore(training))
+	extraSlots := newContext.Timerange().Slots() - context.Timerange().Slots()
+	seriesList, err := function.EvaluateToSeriesList(seriesExpression, newContext)
+	if err != nil {
+		return api.SeriesList{}, nil, 0, err
+	}
+	forecasts := make([][]float64, len(seriesList.Series))
+	for i, series := range seriesList.Series {
+		forecasts[i] = HoltWinters(series.Values, samples, holtWintersLevelRate, holtWintersTrendRate, holtWintersSeasonalRate)
+	}
+	return seriesList, forecasts, extraSlots, nil
+}
+
+// FunctionHoltWinters forecasts series with seasonal cycles, such as daily or
+// weekly traffic, with the additive Holt-Winters model. The model is trained
+// on the given duration of data before the timerange, and forecasts each point
+// from those before it. The seasonality is 1d unless given.
+var FunctionHoltWinters = function.MakeFunction(
+	"forecast.holt_winters",
+	func(context function.EvaluationContext, seriesExpression function.Expression, training time.Duration, optionalSeasonality *time.Duration) (api.SeriesList, error) {
+		seriesList, forecasts, extraSlots, err := holtWintersForecasts("forecast.holt_winters", context, seriesExpression, training, optionalSeasonality)
+		if err != nil {
+			return api.SeriesList{}, err
+		}
+		result := api.SeriesList{
+			Series: make([]api.Timeseries, len(seriesList.Series)),
+		}
+		for i, series := range seriesList.Series {
+			result.Series[i] = api.Timeseries{
+				TagSet: series.TagSet,
+				Values: forecasts[i][extraSlots:], // Slice to drop the training slots from the result
+			}
+		}
+		return result, nil
+	},
+	function.Option{Name: function.WidenBy, Value: function.Argument(1)},
+	function.Option{Name: function.NonNegative, Value: function.Argument(1)},
+	function.Option{Name: function.Positive, Value: function.Argument(2)},
+)
+
+// FunctionDeviationBand bounds the values expected by forecast.holt_winters,
+// for alerting on seasonal metrics: the forecast plus and minus a number of
+// standard deviations (3 unless given) of its errors over the training
+// period. Each series gives two, with the tag band=upper and band=lower.
+var FunctionDeviationBand = function.MakeFunction(
+	"forecast.deviation_band",
+	func(context function.EvaluationContext, seriesExpression function.Expression, training time.Duration, optionalDeviations *float64, optionalSeasonality *time.Duration) (api.SeriesList, error) {
+		deviations := 3.0
+		if optionalDeviations != nil {
+			deviations = *optionalDeviations
+		}
+		seriesList, forecasts, extraSlots, err := holtWintersForecasts("forecast.deviation_band", context, seriesExpression, training, optionalSeasonality)
+		if err != nil {
+			return api.SeriesList{}, err
+		}
+		result := api.SeriesList{
+			Series: make([]api.Timeseries, 0, 2*len(seriesList.Series)),
+		}
+		for i, series := range seriesList.Series {
+			width := deviations * residualDeviation(series.Values, forecasts[i], extraSlots)
+			for _, band := range []struct {
+				name string
+				sign float64
+			}{{"upper", 1}, {"lower", -1}} {
+				values := make([]float64, len(forecasts[i])-extraSlots)
+				for j := range values {
+					values[j] = forecasts[i][extraSlots+j] + band.sign*width
+				}
+				tagSet := api.TagSet{"band": band.name}.Merge(series.TagSet)
+				result.Series = append(result.Series, api.Timeseries{TagSet: tagSet, Values: values})
+			}
+		}
+		return result, nil
+	},
+	function.Option{Name: function.WidenBy, Value: function.Argument(1)},
+	function.Option{Name: function.NonNegative, Value: function.Argument(1)},
+	function.Option{Name: function.NonNegative, Value: function.Argument(2)},
+	function.Option{Name: function.Positive, Value: function.Argument(3)},
+)
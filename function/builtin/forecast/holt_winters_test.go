@@ -0,0 +1,67 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forecast
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHoltWinters(t *testing.T) {
+	// A seasonal series with a trend, missing its last period.
+	period := 4
+	pattern := []float64{5, 1, -2, 3}
+	ys := make([]float64, 10*period)
+	for i := range ys {
+		ys[i] = 100 + 0.5*float64(i) + pattern[i%period]
+		if i >= 9*period {
+			ys[i] = math.NaN()
+		}
+	}
+	estimate := HoltWinters(ys, period, 0.5, 0.1, 0.3)
+	for i := 0; i < period; i++ {
+		if !math.IsNaN(estimate[i]) {
+			t.Errorf("expected no forecast for the first period at %d, but got %f", i, estimate[i])
+		}
+	}
+	for i := 8 * period; i < len(ys); i++ {
+		correct := 100 + 0.5*float64(i) + pattern[i%period]
+		if math.Abs(estimate[i]-correct) > 1 {
+			t.Errorf("expected a forecast near %f at %d, but got %f", correct, i, estimate[i])
+		}
+	}
+}
+
+func TestHoltWintersShortSeries(t *testing.T) {
+	estimate := HoltWinters([]float64{1, 2, 3}, 4, 0.5, 0.1, 0.3)
+	for i := range estimate {
+		if !math.IsNaN(estimate[i]) {
+			t.Errorf("expected no forecast at %d, but got %f", i, estimate[i])
+		}
+	}
+}
+
+func TestResidualDeviation(t *testing.T) {
+	ys := []float64{1, 2, 3, 4, 5, 6}
+	estimate := []float64{math.NaN(), 1, 4, 3, 5, 6}
+	// Over the first 4 points, the residuals are 1, -1, 1.
+	if deviation := residualDeviation(ys, estimate, 4); math.Abs(deviation-math.Sqrt(4.0/3)) > 1e-9 {
+		t.Errorf("expected a deviation of %f, but got %f", math.Sqrt(4.0/3), deviation)
+	}
+	// Without enough training points, every point is used: 1, -1, 1, 0, 0.
+	if deviation := residualDeviation(ys, estimate, 2); math.Abs(deviation-math.Sqrt(2.8/4)) > 1e-9 {
+		t.Errorf("expected a deviation of %f, but got %f", math.Sqrt(2.8/4), deviation)
+	}
+}
@@ -34,9 +34,6 @@ var FunctionRollingMultiplicativeHoltWinters = function.MakeFunction(
 		if optionalExtraTrainingTime != nil {
 			extraTrainingTime = *optionalExtraTrainingTime
 		}
-		if extraTrainingTime < 0 {
-			return api.SeriesList{}, fmt.Errorf("extra training time must be non-negative, but got %s", extraTrainingTime.String()) // TODO: use structured error
-		}
 
 		samples := int(period / context.Timerange().Resolution())
 		if samples <= 0 {
@@ -64,6 +61,8 @@ var FunctionRollingMultiplicativeHoltWinters = function.MakeFunction(
 		return result, nil
 	},
 	function.Option{Name: function.WidenBy, Value: function.Argument(5)},
+	function.Option{Name: function.Positive, Value: function.Argument(1)},
+	function.Option{Name: function.NonNegative, Value: function.Argument(5)},
 )
 
 // FunctionRollingSeasonal is a forecasting MetricFunction that performs the rolling seasonal estimation.
@@ -76,9 +75,6 @@ var FunctionRollingSeasonal = function.MakeFunction(
 		if optionalExtraTrainingTime != nil {
 			extraTrainingTime = *optionalExtraTrainingTime
 		}
-		if extraTrainingTime < 0 {
-			return api.SeriesList{}, fmt.Errorf("extra training time must be non-negative, but got %s", extraTrainingTime.String()) // TODO: use structured error
-		}
 
 		samples := int(period / context.Timerange().Resolution())
 		if samples <= 0 {
@@ -106,6 +102,8 @@ var FunctionRollingSeasonal = function.MakeFunction(
 		return result, nil
 	},
 	function.Option{Name: function.WidenBy, Value: function.Argument(3)},
+	function.Option{Name: function.Positive, Value: function.Argument(1)},
+	function.Option{Name: function.NonNegative, Value: function.Argument(3)},
 )
 
 // FunctionLinear forecasts with a simple linear regression.
@@ -118,9 +116,6 @@ var FunctionLinear = function.MakeFunction(
 		if optionalTrainingTime != nil {
 			extraTrainingTime = *optionalTrainingTime
 		}
-		if extraTrainingTime < 0 {
-			return api.SeriesList{}, fmt.Errorf("extra training time must be non-negative, but got %s", extraTrainingTime.String()) // TODO: use structured error
-		}
 
 		newContext := context.WithTimerange(context.Timerange().ExtendBefore(extraTrainingTime))
 		extraSlots := newContext.Timerange().Slots() - context.Timerange().Slots()
@@ -143,4 +138,5 @@ var FunctionLinear = function.MakeFunction(
 		return result, nil
 	},
 	function.Option{Name: function.WidenBy, Value: function.Argument(1)},
+	function.Option{Name: function.NonNegative, Value: function.Argument(1)},
 )
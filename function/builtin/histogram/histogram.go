@@ -0,0 +1,102 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package histogram holds the functions of bucketed histogram metrics,
+// whose series count the values in each bucket.
+package histogram
+
+import (
+	"strconv"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+)
+
+// bucketTags identify the bucket of a series within its histogram.
+var bucketTags = []string{function.HistogramBoundTag, "upper", "lower"}
+
+// Quantile estimates a quantile (from 0 to 1) of the values counted by
+// histogram buckets, as Prometheus's histogram_quantile does. The buckets
+// of the series of each group are merged into one histogram, so that (for
+// example) the quantile is over all hosts. Every series is in one group
+// unless they're grouped, and their bucket tags are never part of it.
+var Quantile = function.MakeFunction(
+	"histogram_quantile",
+	func(context function.EvaluationContext, q float64, list api.SeriesList, groups function.Groups) (api.SeriesList, error) {
+		if !(q >= 0 && q <= 1) {
+			return api.SeriesList{}, function.ArgumentError{
+				Name:     "histogram_quantile",
+				Index:    0,
+				Expected: "a quantile from 0 to 1",
+				Actual:   strconv.FormatFloat(q, 'g', -1, 64),
+			}
+		}
+		if err := function.CheckGroups(context, "histogram_quantile", list, groups); err != nil {
+			return api.SeriesList{}, err
+		}
+		result := api.SeriesList{}
+		for _, group := range groupBuckets(list, groups) {
+			histogram, err := function.NewHistogram(group.Series)
+			if err != nil {
+				return api.SeriesList{}, err
+			}
+			values := make([]float64, len(group.Series[0].Values))
+			for t := range values {
+				values[t] = histogram.Quantile(q, t)
+			}
+			result.Series = append(result.Series, api.Timeseries{Values: values, TagSet: group.TagSet})
+		}
+		return result, nil
+	},
+)
+
+type bucketGroup struct {
+	TagSet api.TagSet
+	Series []api.Timeseries
+}
+
+// groupBuckets groups the series by the tags of the group-by clause (or all
+// but those of the collapse-by clause), leaving out their bucket tags. The
+// series keep their own tags, and the groups are in order of appearance.
+func groupBuckets(list api.SeriesList, groups function.Groups) []bucketGroup {
+	result := []bucketGroup{}
+	index := map[string]int{}
+	for _, series := range list.Series {
+		tagSet := api.TagSet{}
+		if groups.Collapses {
+			tagSet = series.TagSet.Clone()
+			for _, tag := range groups.List {
+				delete(tagSet, tag)
+			}
+		} else {
+			for _, tag := range groups.List {
+				if value, ok := series.TagSet[tag]; ok {
+					tagSet[tag] = value
+				}
+			}
+		}
+		for _, tag := range bucketTags {
+			delete(tagSet, tag)
+		}
+		key := tagSet.Serialize()
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			result = append(result, bucketGroup{TagSet: tagSet})
+		}
+		result[i].Series = append(result[i].Series, series)
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package join
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/square/metrics/api"
+)
+
+// Unmatched describes a series which joined with no series from the other list.
+type Unmatched struct {
+	TagSet    api.TagSet // The tagset of the unmatched series
+	Closest   api.TagSet // The tagset of the series in the other list with the fewest conflicts, if there is one
+	Conflicts []string   // The sorted tag keys whose values differ between TagSet and Closest
+}
+
+// Diagnosis explains why a join of two series lists has the rows it does.
+type Diagnosis struct {
+	LeftOnly       []string // Tag keys which appear in the left list but not the right one
+	RightOnly      []string // Tag keys which appear in the right list but not the left one
+	UnmatchedLeft  []Unmatched
+	UnmatchedRight []Unmatched
+}
+
+// Diagnose explains the join of two series lists: which tag keys only one side
+// has (so that they never prevent a match, and each series on the other side
+// matches all of them) and which series joined with nothing, along with the
+// conflicting tags of the nearest series they could have joined with.
+func Diagnose(left api.SeriesList, right api.SeriesList) Diagnosis {
+	leftKeys := tagKeys(left)
+	rightKeys := tagKeys(right)
+	return Diagnosis{
+		LeftOnly:       difference(leftKeys, rightKeys),
+		RightOnly:      difference(rightKeys, leftKeys),
+		UnmatchedLeft:  unmatched(left, right),
+		UnmatchedRight: unmatched(right, left),
+	}
+}
+
+// maxUnmatchedNotes is the number of unmatched series described on each side
+// of a diagnosed join; the rest are only counted.
+const maxUnmatchedNotes = 5
+
+// Notes describes the diagnosis for the evaluation notes of the function with
+// the given name. The names describe the two sides of the join.
+func (diagnosis Diagnosis) Notes(name string, leftName string, rightName string) []string {
+	notes := []string{}
+	if len(diagnosis.LeftOnly) != 0 {
+		notes = append(notes, fmt.Sprintf("%s: only the %s has the tags %s, so each %s series is matched with every %s series which agrees on the other tags", name, leftName, strings.Join(diagnosis.LeftOnly, ", "), rightName, leftName))
+	}
+	if len(diagnosis.RightOnly) != 0 {
+		notes = append(notes, fmt.Sprintf("%s: only the %s has the tags %s, so each %s series is matched with every %s series which agrees on the other tags", name, rightName, strings.Join(diagnosis.RightOnly, ", "), leftName, rightName))
+	}
+	describe := func(side string, other string, list []Unmatched) {
+		for i, unmatched := range list {
+			if i == maxUnmatchedNotes {
+				notes = append(notes, fmt.Sprintf("%s: %d more %s series matched no %s series", name, len(list)-i, side, other))
+				return
+			}
+			if unmatched.Closest == nil {
+				notes = append(notes, fmt.Sprintf("%s: %s series {%s} was dropped because the %s is empty", name, side, unmatched.TagSet.Serialize(), other))
+				continue
+			}
+			differences := make([]string, len(unmatched.Conflicts))
+			for j, key := range unmatched.Conflicts {
+				differences[j] = fmt.Sprintf("%s (%q vs %q)", key, unmatched.TagSet[key], unmatched.Closest[key])
+			}
+			notes = append(notes, fmt.Sprintf("%s: %s series {%s} matched no %s series; the closest, {%s}, differs on %s", name, side, unmatched.TagSet.Serialize(), other, unmatched.Closest.Serialize(), strings.Join(differences, ", ")))
+		}
+	}
+	describe(leftName, rightName, diagnosis.UnmatchedLeft)
+	describe(rightName, leftName, diagnosis.UnmatchedRight)
+	return notes
+}
+
+// conflicts returns the sorted keys which both tagsets have, but with different values.
+func conflicts(a api.TagSet, b api.TagSet) []string {
+	result := []string{}
+	for key, value := range a {
+		if other, ok := b[key]; ok && other != value {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// unmatched finds the series in `list` which join with no series in `other`.
+func unmatched(list api.SeriesList, other api.SeriesList) []Unmatched {
+	result := []Unmatched{}
+	for _, series := range list.Series {
+		var closest *Unmatched
+		for _, candidate := range other.Series {
+			keys := conflicts(series.TagSet, candidate.TagSet)
+			if closest == nil || len(keys) < len(closest.Conflicts) {
+				closest = &Unmatched{TagSet: series.TagSet, Closest: candidate.TagSet, Conflicts: keys}
+			}
+		}
+		if closest == nil {
+			result = append(result, Unmatched{TagSet: series.TagSet})
+			continue
+		}
+		if len(closest.Conflicts) != 0 {
+			result = append(result, *closest)
+		}
+	}
+	return result
+}
+
+func tagKeys(list api.SeriesList) map[string]bool {
+	keys := map[string]bool{}
+	for _, series := range list.Series {
+		for key := range series.TagSet {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// difference returns the sorted keys of `a` which aren't in `b`.
+func difference(a map[string]bool, b map[string]bool) []string {
+	result := []string{}
+	for key := range a {
+		if !b[key] {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
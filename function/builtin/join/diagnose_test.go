@@ -0,0 +1,115 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package join
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/square/metrics/api"
+)
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		name     string
+		left     api.SeriesList
+		right    api.SeriesList
+		expected Diagnosis
+	}{
+		{
+			name:  "everything joins",
+			left:  basicList,
+			right: dcList,
+			expected: Diagnosis{
+				LeftOnly:       []string{"host"},
+				RightOnly:      []string{},
+				UnmatchedLeft:  []Unmatched{},
+				UnmatchedRight: []Unmatched{},
+			},
+		},
+		{
+			name:  "disjoint tags join everything with everything",
+			left:  dcList,
+			right: envList,
+			expected: Diagnosis{
+				LeftOnly:       []string{"dc"},
+				RightOnly:      []string{"env"},
+				UnmatchedLeft:  []Unmatched{},
+				UnmatchedRight: []Unmatched{},
+			},
+		},
+		{
+			name:  "empty side",
+			left:  dcList,
+			right: emptyList,
+			expected: Diagnosis{
+				LeftOnly:  []string{"dc"},
+				RightOnly: []string{},
+				UnmatchedLeft: []Unmatched{
+					{TagSet: seriesDCOfA.TagSet},
+					{TagSet: seriesDCOfB.TagSet},
+					{TagSet: seriesDCOfC.TagSet},
+				},
+				UnmatchedRight: []Unmatched{},
+			},
+		},
+		{
+			name: "conflicting values",
+			left: basicList,
+			right: api.SeriesList{Series: []api.Timeseries{
+				{TagSet: api.TagSet{"dc": "A"}},
+				{TagSet: api.TagSet{"dc": "D", "host": "#5"}},
+			}},
+			expected: Diagnosis{
+				LeftOnly:  []string{},
+				RightOnly: []string{},
+				UnmatchedLeft: []Unmatched{
+					{TagSet: seriesDCOfBHost3.TagSet, Closest: api.TagSet{"dc": "A"}, Conflicts: []string{"dc"}},
+					{TagSet: seriesDCOfBHost4.TagSet, Closest: api.TagSet{"dc": "A"}, Conflicts: []string{"dc"}},
+					{TagSet: seriesDCOfCHost5.TagSet, Closest: api.TagSet{"dc": "A"}, Conflicts: []string{"dc"}},
+				},
+				UnmatchedRight: []Unmatched{
+					{TagSet: api.TagSet{"dc": "D", "host": "#5"}, Closest: seriesDCOfCHost5.TagSet, Conflicts: []string{"dc"}},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		actual := Diagnose(test.left, test.right)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: expected %+v but got %+v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestRatioNotes(t *testing.T) {
+	numerator := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{4, 6}, TagSet: api.TagSet{"host": "a", "dc": "east"}},
+		{Values: []float64{1, 1}, TagSet: api.TagSet{"host": "b", "dc": "west"}},
+	}}
+	denominator := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{2, 3}, TagSet: api.TagSet{"dc": "east"}},
+		{Values: []float64{5, 5}, TagSet: api.TagSet{"dc": "West"}},
+	}}
+	notes := Diagnose(numerator, denominator).Notes("ratio", "numerator", "denominator")
+	expected := []string{
+		"ratio: only the numerator has the tags host, so each denominator series is matched with every numerator series which agrees on the other tags",
+		`ratio: numerator series {dc=west,host=b} matched no denominator series; the closest, {dc=east}, differs on dc ("west" vs "east")`,
+		`ratio: denominator series {dc=West} matched no numerator series; the closest, {dc=east,host=a}, differs on dc ("West" vs "east")`,
+	}
+	if !reflect.DeepEqual(notes, expected) {
+		t.Errorf("expected notes %#v but got %#v", expected, notes)
+	}
+}
@@ -85,6 +85,70 @@ func Join(lists []api.SeriesList) Result {
 	}
 	// at this stage, iteration has continued over the entire set of lists,
 	// so `results` contains the join of all of the lists.
+	for i := range results {
+		results[i].TagSet = results[i].TagSet.Intern()
+	}
+
+	return Result{Rows: results}
+}
+
+// Matching restricts the tags on which the series of a binary operation are
+// matched: only the listed tags (as in "a / on (host) b"), or all the tags
+// both series have except the listed ones (as in "a / ignoring (code) b").
+type Matching struct {
+	Tags     []string
+	Ignoring bool
+}
 
+// matches reports whether the two tagsets agree on the tags of the matching.
+// A tag which only one of them has never prevents a match, except for the
+// listed tags of an "on" matching, which both or neither must have.
+func (m Matching) matches(left api.TagSet, right api.TagSet) bool {
+	if !m.Ignoring {
+		for _, key := range m.Tags {
+			leftValue, leftOK := left[key]
+			rightValue, rightOK := right[key]
+			if leftOK != rightOK || leftValue != rightValue {
+				return false
+			}
+		}
+		return true
+	}
+	ignored := map[string]bool{}
+	for _, key := range m.Tags {
+		ignored[key] = true
+	}
+	for key, leftValue := range left {
+		if rightValue, ok := right[key]; ok && !ignored[key] && leftValue != rightValue {
+			return false
+		}
+	}
+	return true
+}
+
+// JoinMatching pairs each series of the left list with every series of the
+// right list which agrees with it on the tags of the matching. Each row has
+// the tags of either series, except those on which they differ.
+func JoinMatching(left api.SeriesList, right api.SeriesList, matching Matching) Result {
+	results := []Row{}
+	for _, leftSeries := range left.Series {
+		for _, rightSeries := range right.Series {
+			if !matching.matches(leftSeries.TagSet, rightSeries.TagSet) {
+				continue
+			}
+			tagset := api.NewTagSet()
+			for key, value := range leftSeries.TagSet {
+				if other, ok := rightSeries.TagSet[key]; !ok || other == value {
+					tagset[key] = value
+				}
+			}
+			for key, value := range rightSeries.TagSet {
+				if _, ok := leftSeries.TagSet[key]; !ok {
+					tagset[key] = value
+				}
+			}
+			results = append(results, Row{TagSet: tagset.Intern(), Row: []api.Timeseries{leftSeries, rightSeries}})
+		}
+	}
 	return Result{Rows: results}
 }
@@ -15,6 +15,7 @@
 package join
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/square/metrics/api"
@@ -82,6 +83,38 @@ func Test_join_ResultSizes(t *testing.T) {
 	}
 }
 
+func Test_join_Matching(t *testing.T) {
+	errors := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{1}, TagSet: api.TagSet{"host": "a", "code": "500", "kind": "errors"}},
+		{Values: []float64{2}, TagSet: api.TagSet{"host": "a", "code": "503", "kind": "errors"}},
+		{Values: []float64{3}, TagSet: api.TagSet{"host": "b", "code": "500", "kind": "errors"}},
+	}}
+	requests := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{10}, TagSet: api.TagSet{"host": "a", "kind": "requests"}},
+		{Values: []float64{20}, TagSet: api.TagSet{"host": "b", "kind": "requests"}},
+		{Values: []float64{30}, TagSet: api.TagSet{"host": "c", "kind": "requests"}},
+	}}
+	for i, testCase := range []struct {
+		matching Matching
+		expected []string // the serialized tagsets of the rows
+	}{
+		{Matching{Tags: []string{"host"}}, []string{"code=500,host=a", "code=503,host=a", "code=500,host=b"}},
+		{Matching{Tags: []string{"kind"}}, []string{}},
+		{Matching{Tags: []string{"kind"}, Ignoring: true}, []string{"code=500,host=a", "code=503,host=a", "code=500,host=b"}},
+		{Matching{Ignoring: true}, []string{}}, // the natural join
+		{Matching{Tags: []string{"missing"}}, []string{"code=500,host=a", "code=500", "code=500", "code=503,host=a", "code=503", "code=503", "code=500", "code=500,host=b", "code=500"}}, // every pair matches
+	} {
+		result := JoinMatching(errors, requests, testCase.matching)
+		actual := []string{}
+		for _, row := range result.Rows {
+			actual = append(actual, row.TagSet.Serialize())
+		}
+		if strings.Join(actual, " ") != strings.Join(testCase.expected, " ") {
+			t.Errorf("matching testcase %d results in %v; expected %v", i, actual, testCase.expected)
+		}
+	}
+}
+
 func max(x, y int) int {
 	if x < y {
 		return y
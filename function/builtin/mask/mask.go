@@ -0,0 +1,99 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mask
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/tdigest"
+)
+
+// loadSchedule parses the schedule in the optional time zone, which defaults to UTC.
+func loadSchedule(text string, zone *string) (Schedule, error) {
+	location := time.UTC
+	if zone != nil {
+		var err error
+		location, err = time.LoadLocation(*zone)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("unknown time zone '%s'", *zone)
+		}
+	}
+	return ParseSchedule(text, location)
+}
+
+// Apply returns a copy of the series list where each point is replaced by NaN
+// unless its membership in the schedule matches `keep`. The samples and
+// sketches of the series are kept, except at the points which are replaced.
+func Apply(list api.SeriesList, schedule Schedule, keep bool, timerange api.Timerange) api.SeriesList {
+	inside := make([]bool, timerange.Slots())
+	for i := range inside {
+		inside[i] = schedule.Contains(timerange.TimeOfIndex(i))
+	}
+	series := make([]api.Timeseries, len(list.Series))
+	for i, original := range list.Series {
+		result := original
+		result.Values = append([]float64(nil), original.Values...)
+		if original.Samples != nil {
+			result.Samples = append([]int(nil), original.Samples...)
+		}
+		if original.Sketches != nil {
+			result.Sketches = append([]*tdigest.Digest(nil), original.Sketches...)
+		}
+		for j := range result.Values {
+			if j < len(inside) && inside[j] == keep {
+				continue
+			}
+			result.Values[j] = math.NaN()
+			if j < len(result.Samples) {
+				result.Samples[j] = 0
+			}
+			if j < len(result.Sketches) {
+				result.Sketches[j] = nil
+			}
+		}
+		series[i] = result
+	}
+	return api.SeriesList{
+		Series: series,
+	}
+}
+
+// BusinessHours keeps only the points which fall inside the schedule.
+var BusinessHours = function.MakeFunction(
+	"mask.business_hours",
+	func(list api.SeriesList, text string, zone *string, timerange api.Timerange) (api.SeriesList, error) {
+		schedule, err := loadSchedule(text, zone)
+		if err != nil {
+			return api.SeriesList{}, err
+		}
+		return Apply(list, schedule, true, timerange), nil
+	},
+)
+
+// Exclude removes the points which fall inside the schedule.
+var Exclude = function.MakeFunction(
+	"mask.exclude",
+	func(list api.SeriesList, text string, zone *string, timerange api.Timerange) (api.SeriesList, error) {
+		schedule, err := loadSchedule(text, zone)
+		if err != nil {
+			return api.SeriesList{}, err
+		}
+		return Apply(list, schedule, false, timerange), nil
+	},
+)
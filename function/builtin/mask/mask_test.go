@@ -0,0 +1,120 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mask
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	for _, test := range []struct {
+		schedule string
+		valid    bool
+	}{
+		{"Mon-Fri 09:00-17:00", true},
+		{"mon,wed-fri 09:00-17:00", true},
+		{"Fri-Mon", true},
+		{"22:00-06:00", true},
+		{"00:00-24:00", true},
+		{"Mon-Fri 09:00-17:00; Sat 10:00-14:00", true},
+		{"", false},
+		{"Mon-Fri 09:00-17:00 UTC", false},
+		{"Funday 09:00-17:00", false},
+		{"Mon-Tue-Wed", false},
+		{"Mon 9-17", false},
+		{"Mon 09:00", false},
+		{"Mon 09:00-09:00", false},
+		{"Mon 09:00-25:00", false},
+		{"Mon 09:0-17:00", false},
+		{"Mon-Fri;", false},
+	} {
+		a := assert.New(t).Contextf("%s", test.schedule)
+		_, err := ParseSchedule(test.schedule, time.UTC)
+		a.EqBool(err == nil, test.valid)
+	}
+}
+
+func TestSchedule_Contains(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data is unavailable: %s", err.Error())
+	}
+	// 2016-03-04 is a Friday.
+	friday := func(hour, minute int) time.Time {
+		return time.Date(2016, 3, 4, hour, minute, 0, 0, time.UTC)
+	}
+	for _, test := range []struct {
+		schedule string
+		location *time.Location
+		time     time.Time
+		expected bool
+	}{
+		{"Mon-Fri 09:00-17:00", time.UTC, friday(9, 0), true},
+		{"Mon-Fri 09:00-17:00", time.UTC, friday(16, 59), true},
+		{"Mon-Fri 09:00-17:00", time.UTC, friday(17, 0), false},
+		{"Mon-Fri 09:00-17:00", time.UTC, friday(8, 59), false},
+		{"Mon-Thu 09:00-17:00", time.UTC, friday(12, 0), false},
+		{"Mon-Fri 09:00-17:00", newYork, friday(12, 0), false},
+		{"Mon-Fri 09:00-17:00", newYork, friday(15, 0), true},
+		{"Thu 22:00-06:00", time.UTC, friday(5, 0), true},
+		{"Thu 22:00-06:00", time.UTC, friday(23, 0), false},
+		{"Fri 22:00-06:00", time.UTC, friday(23, 0), true},
+		{"Fri-Mon", time.UTC, friday(0, 0), true},
+		{"Sat,Sun; 12:00-13:00", time.UTC, friday(12, 30), true},
+		{"Sat,Sun; 12:00-13:00", time.UTC, friday(13, 30), false},
+	} {
+		a := assert.New(t).Contextf("%s at %s", test.schedule, test.time)
+		schedule, err := ParseSchedule(test.schedule, test.location)
+		a.CheckError(err)
+		a.EqBool(schedule.Contains(test.time), test.expected)
+	}
+}
+
+func TestApply(t *testing.T) {
+	// Six-hour slots starting at midnight on Friday, 2016-03-04.
+	start := time.Date(2016, 3, 4, 0, 0, 0, 0, time.UTC).UnixNano() / 1e6
+	timerange, err := api.NewTimerange(start, start+5*6*3600*1000, 6*3600*1000)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	schedule, err := ParseSchedule("Mon-Fri 06:00-18:00", time.UTC)
+	if err != nil {
+		t.Fatalf("Error parsing schedule for test: %s", err.Error())
+	}
+	list := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{1, 2, 3, 4, 5, 6}, TagSet: api.TagSet{"host": "a"}},
+	}}
+	nan := math.NaN()
+	a := assert.New(t)
+	a.EqFloatArray(Apply(list, schedule, true, timerange).Series[0].Values, []float64{nan, 2, 3, nan, nan, nan}, 1e-9)
+	a.EqFloatArray(Apply(list, schedule, false, timerange).Series[0].Values, []float64{1, nan, nan, 4, 5, 6}, 1e-9)
+	a.EqFloatArray(list.Series[0].Values, []float64{1, 2, 3, 4, 5, 6}, 1e-9)
+
+	// The samples and sketches of the kept points are kept too.
+	sketch := tdigest.New(100)
+	sketch.Add(2)
+	list.Series[0].Samples = []int{1, 1, 1, 1, 1, 1}
+	list.Series[0].Sketches = []*tdigest.Digest{sketch, sketch, nil, nil, nil, nil}
+	masked := Apply(list, schedule, true, timerange).Series[0]
+	a.Eq(masked.Samples, []int{0, 1, 1, 0, 0, 0})
+	a.Eq(masked.Sketches, []*tdigest.Digest{nil, sketch, nil, nil, nil, nil})
+	a.Eq(list.Series[0].Samples, []int{1, 1, 1, 1, 1, 1})
+}
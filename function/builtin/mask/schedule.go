@@ -0,0 +1,173 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mask
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// weekdays maps the abbreviated day names accepted in schedules to their days.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// window is a recurring period of time on some days of the week.
+// If end comes before start, the window runs past midnight into the next day.
+type window struct {
+	days  [7]bool
+	start int // minutes since midnight, inclusive
+	end   int // minutes since midnight, exclusive
+}
+
+// Schedule is a set of weekly windows, such as 'Mon-Fri 09:00-17:00'.
+type Schedule struct {
+	windows  []window
+	location *time.Location
+}
+
+// ParseSchedule parses a schedule in the given time zone.
+// A schedule is a list of windows separated by semicolons. Each window has a
+// list of days (like 'Mon-Fri' or 'Sat,Sun'), a time range (like '09:00-17:00'),
+// or both. A missing day list means every day and a missing time range means
+// the whole day.
+func ParseSchedule(text string, location *time.Location) (Schedule, error) {
+	schedule := Schedule{location: location}
+	for _, part := range strings.Split(text, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 || len(fields) > 2 {
+			return Schedule{}, fmt.Errorf("invalid schedule window '%s'; expected something like 'Mon-Fri 09:00-17:00'", strings.TrimSpace(part))
+		}
+		w := window{start: 0, end: minutesPerDay}
+		for i := range w.days {
+			w.days[i] = true
+		}
+		timeField := fields[len(fields)-1]
+		if len(fields) == 2 || !strings.Contains(timeField, ":") {
+			days, err := parseDays(fields[0])
+			if err != nil {
+				return Schedule{}, err
+			}
+			w.days = days
+		}
+		if strings.Contains(timeField, ":") {
+			start, end, err := parseTimes(timeField)
+			if err != nil {
+				return Schedule{}, err
+			}
+			w.start, w.end = start, end
+		} else if len(fields) == 2 {
+			return Schedule{}, fmt.Errorf("invalid time range '%s'; expected something like '09:00-17:00'", timeField)
+		}
+		schedule.windows = append(schedule.windows, w)
+	}
+	return schedule, nil
+}
+
+// parseDays parses a comma-separated list of days and day ranges.
+func parseDays(text string) ([7]bool, error) {
+	result := [7]bool{}
+	for _, item := range strings.Split(text, ",") {
+		bounds := strings.Split(item, "-")
+		if len(bounds) > 2 {
+			return result, fmt.Errorf("invalid day range '%s'", item)
+		}
+		first, ok := weekdays[strings.ToLower(bounds[0])]
+		if !ok {
+			return result, fmt.Errorf("invalid day '%s'; expected one of Sun, Mon, Tue, Wed, Thu, Fri, Sat", bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, ok = weekdays[strings.ToLower(bounds[1])]
+			if !ok {
+				return result, fmt.Errorf("invalid day '%s'; expected one of Sun, Mon, Tue, Wed, Thu, Fri, Sat", bounds[1])
+			}
+		}
+		// Ranges may wrap around the end of the week, as in 'Fri-Mon'.
+		for day := first; ; day = (day + 1) % 7 {
+			result[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// parseTimes parses a time range such as '09:00-17:00'.
+func parseTimes(text string) (int, int, error) {
+	bounds := strings.Split(text, "-")
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("invalid time range '%s'; expected something like '09:00-17:00'", text)
+	}
+	start, err := parseTime(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseTime(bounds[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end || start == minutesPerDay {
+		return 0, 0, fmt.Errorf("invalid time range '%s'; use '00:00-24:00' for the whole day", text)
+	}
+	return start, end, nil
+}
+
+// parseTime parses a time of day such as '09:30' into minutes since midnight.
+func parseTime(text string) (int, error) {
+	parts := strings.Split(text, ":")
+	if len(parts) == 2 {
+		hours, hourErr := strconv.Atoi(parts[0])
+		minutes, minuteErr := strconv.Atoi(parts[1])
+		if hourErr == nil && minuteErr == nil && len(parts[1]) == 2 && hours >= 0 && minutes >= 0 && minutes < 60 {
+			if result := hours*60 + minutes; result <= minutesPerDay {
+				return result, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid time of day '%s'; expected something like '09:00'", text)
+}
+
+// Contains returns true if the given time falls within one of the schedule's windows.
+func (schedule Schedule) Contains(t time.Time) bool {
+	local := t.In(schedule.location)
+	day := local.Weekday()
+	yesterday := (day + 6) % 7
+	minute := local.Hour()*60 + local.Minute()
+	for _, w := range schedule.windows {
+		if w.start < w.end {
+			if w.days[day] && w.start <= minute && minute < w.end {
+				return true
+			}
+			continue
+		}
+		// The window wraps past midnight.
+		if (w.days[day] && minute >= w.start) || (w.days[yesterday] && minute < w.end) {
+			return true
+		}
+	}
+	return false
+}
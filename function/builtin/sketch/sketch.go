@@ -0,0 +1,88 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sketch holds the functions of series whose storage keeps
+// t-digests of their raw values.
+package sketch
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/function/builtin/aggregate"
+	"github.com/square/metrics/tdigest"
+)
+
+// Percentile estimates a percentile (from 0 to 100) of the raw values
+// behind each point, by merging the t-digests of the series of each group.
+// Unlike aggregating percentiles computed ahead of time, this is correct
+// across hosts and time. Like the aggregates, every series is in one group
+// unless they're grouped. Points with no digests are NaN, and a note says
+// how many series had none.
+var Percentile = function.MakeFunction(
+	"sketch.percentile",
+	func(context function.EvaluationContext, list api.SeriesList, percentile float64, groups function.Groups) (api.SeriesList, error) {
+		if !(percentile >= 0 && percentile <= 100) {
+			return api.SeriesList{}, function.ArgumentError{
+				Name:     "sketch.percentile",
+				Index:    1,
+				Expected: "a percentile from 0 to 100",
+				Actual:   strconv.FormatFloat(percentile, 'g', -1, 64),
+			}
+		}
+		if err := function.CheckGroups(context, "sketch.percentile", list, groups); err != nil {
+			return api.SeriesList{}, err
+		}
+		unsketched := 0
+		for _, series := range list.Series {
+			if series.Sketches == nil {
+				unsketched++
+			}
+		}
+		if unsketched != 0 {
+			context.AddNote(fmt.Sprintf("sketch.percentile: %d of %d series have no t-digests, since the storage doesn't keep them or a function computed their values", unsketched, len(list.Series)))
+		}
+		grouped := aggregate.GroupBy(list, groups.List, groups.Collapses)
+		result := api.SeriesList{Series: make([]api.Timeseries, len(grouped))}
+		for i, group := range grouped {
+			values := make([]float64, len(group.Series[0].Values))
+			for t := range values {
+				values[t] = merge(group.Series, t).Quantile(percentile / 100)
+			}
+			result.Series[i] = api.Timeseries{Values: values, TagSet: group.Series[0].TagSet}
+		}
+		return result, nil
+	},
+)
+
+// merge merges the digests of the series at the t-th point. The digests of
+// series are shared, so they're merged into a new one.
+func merge(list []api.Timeseries, t int) *tdigest.Digest {
+	var merged *tdigest.Digest
+	for _, series := range list {
+		if t >= len(series.Sketches) || series.Sketches[t] == nil {
+			continue
+		}
+		if merged == nil {
+			merged = tdigest.New(series.Sketches[t].Compression())
+		}
+		merged.Merge(series.Sketches[t])
+	}
+	if merged == nil {
+		return tdigest.New(0) // its quantiles are NaN
+	}
+	return merged
+}
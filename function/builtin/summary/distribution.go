@@ -0,0 +1,71 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+)
+
+// maxDistributionBuckets bounds the size of the matrix of a distribution.
+const maxDistributionBuckets = 1000
+
+// Distribution counts the series with a value in each of a number of
+// equal-width buckets at each time, for rendering as a heatmap, as in
+// `distribution(latency, 20)`. The buckets span the values of the series,
+// unless lower and upper bounds are given: `distribution(latency, 20, 0, 500)`.
+// Values beyond the bounds are counted in the first or last bucket.
+var Distribution = function.MakeFunction(
+	"distribution",
+	func(list api.SeriesList, buckets float64, optionalLower *float64, optionalUpper *float64, timerange api.Timerange) (function.Distribution, error) {
+		if buckets != math.Floor(buckets) || buckets > maxDistributionBuckets {
+			return function.Distribution{}, fmt.Errorf("distribution: the number of buckets must be a whole number no greater than %d, not %g", maxDistributionBuckets, buckets)
+		}
+		lower, upper := math.Inf(1), math.Inf(-1)
+		for _, series := range list.Series {
+			for _, value := range series.Values {
+				if !math.IsNaN(value) && !math.IsInf(value, 0) {
+					lower = math.Min(lower, value)
+					upper = math.Max(upper, value)
+				}
+			}
+		}
+		if optionalLower != nil {
+			lower = *optionalLower
+		}
+		if optionalUpper != nil {
+			upper = *optionalUpper
+		}
+		if optionalLower != nil && optionalUpper != nil && lower >= upper {
+			return function.Distribution{}, fmt.Errorf("distribution: the lower bound %g must be less than the upper bound %g", lower, upper)
+		}
+		if math.IsInf(lower, 1) {
+			// There are no values to span.
+			lower, upper = 0, 1
+		}
+		if upper <= lower {
+			upper = lower + 1
+		}
+		bounds := make([]float64, int(buckets)+1)
+		for i := range bounds {
+			bounds[i] = lower + (upper-lower)*float64(i)/buckets
+		}
+		return function.NewDistribution(list, bounds, timerange), nil
+	},
+	function.Option{Name: function.Positive, Value: function.Argument(1)},
+)
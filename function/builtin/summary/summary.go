@@ -15,6 +15,7 @@
 package summary
 
 import (
+	"fmt"
 	"math"
 	"time"
 
@@ -45,6 +46,7 @@ var recentScaled = func(name string, summarizer func([]float64, api.Timerange) f
 			}
 			return result
 		},
+		function.Option{Name: function.NonNegative, Value: function.Argument(1)},
 	)
 }
 
@@ -201,3 +203,83 @@ var Current = function.MakeFunction(
 		return result
 	},
 )
+
+// availabilityComparisons are the ways in which a point may meet the availability threshold.
+var availabilityComparisons = map[string]func(float64, float64) bool{
+	">=": func(x float64, threshold float64) bool { return x >= threshold },
+	">":  func(x float64, threshold float64) bool { return x > threshold },
+	"<=": func(x float64, threshold float64) bool { return x <= threshold },
+	"<":  func(x float64, threshold float64) bool { return x < threshold },
+}
+
+// Availability computes, for each time series line, the fraction of points
+// which meet the threshold and the longest streak of violations in seconds.
+// The two are returned as separate scalars, with the additional tag
+// `measure=fraction` and `measure=longest_violation` respectively.
+// Missing points count neither towards the fraction nor as violations, and do
+// not interrupt a streak: a streak lasts from its first violating point to its
+// last, so missing points within it are part of its duration.
+var Availability = function.MakeFunction(
+	"availability",
+	func(list api.SeriesList, threshold float64, optionalComparison *string, timerange api.Timerange) (function.ScalarSet, error) {
+		comparison := ">="
+		if optionalComparison != nil {
+			comparison = *optionalComparison
+		}
+		meets, ok := availabilityComparisons[comparison]
+		if !ok {
+			return nil, fmt.Errorf("availability comparison must be one of '>=', '>', '<=' or '<', got '%s'", comparison)
+		}
+		result := function.ScalarSet{}
+		for _, series := range list.Series {
+			present := 0
+			met := 0
+			start := -1 // the first violating slot of the current streak
+			longest := 0
+			for i, value := range series.Values {
+				if math.IsNaN(value) {
+					continue
+				}
+				present++
+				if meets(value, threshold) {
+					met++
+					start = -1
+					continue
+				}
+				if start < 0 {
+					start = i
+				}
+				if i-start+1 > longest {
+					longest = i - start + 1
+				}
+			}
+			fraction := series.TagSet.Clone()
+			fraction["measure"] = "fraction"
+			violation := series.TagSet.Clone()
+			violation["measure"] = "longest_violation"
+			result = append(result,
+				function.TaggedScalar{TagSet: fraction, Value: float64(met) / float64(present)},
+				function.TaggedScalar{TagSet: violation, Value: float64(longest) * timerange.Resolution().Seconds()},
+			)
+		}
+		return result, nil
+	},
+)
+
+// Freshness computes, for each time series line, the number of seconds
+// between its last datapoint and the end of the timerange. Series without
+// any datapoints are NaN.
+var Freshness = function.MakeFunction(
+	"freshness",
+	func(list api.SeriesList, timerange api.Timerange) function.ScalarSet {
+		result := function.ScalarSet{}
+		for _, series := range list.Series {
+			value := math.NaN()
+			if lag, ok := function.SeriesLag(series, timerange); ok {
+				value = lag.Seconds()
+			}
+			result = append(result, function.TaggedScalar{TagSet: series.TagSet, Value: value})
+		}
+		return result
+	},
+)
@@ -0,0 +1,137 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/function/builtin/aggregate"
+)
+
+// tableColumns are the named statistics which may be used as columns of summarize_table.
+var tableColumns = map[string]func([]float64) float64{
+	"mean":  aggregate.Mean,
+	"min":   aggregate.Min,
+	"max":   aggregate.Max,
+	"sum":   aggregate.Sum,
+	"count": aggregate.Count,
+	"total": aggregate.Total,
+	"oldest": func(values []float64) float64 {
+		return values[0]
+	},
+	"current": func(values []float64) float64 {
+		return values[len(values)-1]
+	},
+}
+
+// percentile computes the p-th percentile (0 <= p <= 100) of the values which
+// are not NaN, interpolating linearly between the closest ranks.
+func percentile(values []float64, p float64) float64 {
+	sorted := []float64{}
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			sorted = append(sorted, value)
+		}
+	}
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// tableColumn finds the statistic for the named column. Besides the names in
+// tableColumns, percentiles are written as 'p' followed by a number, as in 'p99'.
+func tableColumn(name string) (func([]float64) float64, error) {
+	if column, ok := tableColumns[name]; ok {
+		return column, nil
+	}
+	if strings.HasPrefix(name, "p") {
+		p, err := strconv.ParseFloat(name[1:], 64)
+		if err == nil && p >= 0 && p <= 100 {
+			return func(values []float64) float64 { return percentile(values, p) }, nil
+		}
+	}
+	names := []string{}
+	for known := range tableColumns {
+		names = append(names, known)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("summarize_table: unknown column '%s'; expected one of %s, or a percentile such as 'p99'", name, strings.Join(names, ", "))
+}
+
+// Table computes a table with a row for each series in the list and a column
+// for each of the named statistics, such as `summarize_table(series, 'mean', 'p99', 'max')`.
+var Table = function.MetricFunction{
+	FunctionName: "summarize_table",
+	MinArguments: 2,
+	MaxArguments: -1,
+	Compute: func(context function.EvaluationContext, arguments []function.Expression, groups function.Groups) (function.Value, error) {
+		values, err := function.EvaluateMany(context, arguments)
+		if err != nil {
+			return nil, err
+		}
+		list, convErr := values[0].ToSeriesList(context.Timerange())
+		if convErr != nil {
+			return nil, convErr.WithContext(fmt.Sprintf("the first argument to summarize_table (%s)", arguments[0].ExpressionDescription(function.StringQuery())))
+		}
+		columns := make([]string, len(values)-1)
+		statistics := make([]func([]float64) float64, len(columns))
+		for i, value := range values[1:] {
+			name, convErr := value.ToString()
+			if convErr != nil {
+				return nil, function.ArgumentError{
+					Name:     "summarize_table",
+					Index:    i + 1,
+					Expected: "a string",
+					Actual:   arguments[i+1].ExpressionDescription(function.StringQuery()),
+				}
+			}
+			statistic, err := tableColumn(name)
+			if err != nil {
+				return nil, err
+			}
+			columns[i] = name
+			statistics[i] = statistic
+		}
+		table := function.Table{
+			Columns: columns,
+			Rows:    make([]function.TableRow, len(list.Series)),
+		}
+		for i, series := range list.Series {
+			row := function.TableRow{
+				TagSet: series.TagSet,
+				Values: make([]float64, len(statistics)),
+			}
+			for j, statistic := range statistics {
+				if len(series.Values) == 0 {
+					row.Values[j] = math.NaN()
+					continue
+				}
+				row.Values[j] = statistic(series.Values)
+			}
+			table.Rows[i] = row
+		}
+		return table, nil
+	},
+}
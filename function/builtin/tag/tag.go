@@ -25,7 +25,7 @@ import (
 func dropTagSeries(series api.Timeseries, dropTag string) api.Timeseries {
 	tagSet := series.TagSet.Clone()
 	delete(tagSet, dropTag)
-	series.TagSet = tagSet
+	series.TagSet = tagSet.Intern() // many series may now have the same tags
 	return series
 }
 
@@ -50,7 +50,7 @@ func setTagSeries(series api.Timeseries, newTag string, newValue string) api.Tim
 		tagSet[tag] = val
 	}
 	tagSet[newTag] = newValue
-	series.TagSet = tagSet
+	series.TagSet = tagSet.Intern()
 	return series
 }
 
@@ -80,7 +80,7 @@ func copyTagSeries(series api.Timeseries, target string, source string) api.Time
 	} else {
 		delete(tagSet, target)
 	}
-	series.TagSet = tagSet
+	series.TagSet = tagSet.Intern()
 	return series
 }
 
@@ -0,0 +1,81 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+)
+
+// undefinedNote describes the values for which a transform is undefined,
+// which become NaN.
+func undefinedNote(name string, count int, reason string) string {
+	if count == 1 {
+		return fmt.Sprintf("%s: 1 value was %s, and is NaN", name, reason)
+	}
+	return fmt.Sprintf("%s: %d values were %s, and are NaN", name, count, reason)
+}
+
+// Log takes the logarithm of each value, in base 10 unless another base is
+// given. Zero and negative values have no logarithm; they become NaN, and a
+// note says how many there were.
+var Log = function.MakeFunction(
+	"transform.log",
+	func(list api.SeriesList, base *float64, context function.EvaluationContext) (api.SeriesList, error) {
+		logBase := 10.0
+		if base != nil {
+			logBase = *base
+		}
+		if !(logBase > 0) || logBase == 1 || math.IsInf(logBase, 0) {
+			return api.SeriesList{}, fmt.Errorf("transform.log: the base must be positive, finite and not 1, but was %g", logBase)
+		}
+		undefined := 0
+		result := mapper(list, func(value float64) float64 {
+			if value <= 0 {
+				undefined++
+				return math.NaN()
+			}
+			return math.Log(value) / math.Log(logBase)
+		})
+		if undefined != 0 {
+			context.AddNote(undefinedNote("transform.log", undefined, "zero or negative"))
+		}
+		return result, nil
+	},
+)
+
+// Exp raises e to the power of each value. Values too large for the result
+// to be represented become NaN, and a note says how many there were.
+var Exp = function.MakeFunction(
+	"transform.exp",
+	func(list api.SeriesList, context function.EvaluationContext) api.SeriesList {
+		overflowed := 0
+		result := mapper(list, func(value float64) float64 {
+			exp := math.Exp(value)
+			if math.IsInf(exp, 1) {
+				overflowed++
+				return math.NaN()
+			}
+			return exp
+		})
+		if overflowed != 0 {
+			context.AddNote(undefinedNote("transform.exp", overflowed, "too large to exponentiate"))
+		}
+		return result
+	},
+)
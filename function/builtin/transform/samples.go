@@ -0,0 +1,54 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+)
+
+// Samples gives the number of raw samples behind each point of the fetched
+// series, where the storage reports them. A point with no samples is 0. The
+// points of series without counts (because the storage doesn't report them,
+// or because a function computed their values) are NaN, and a note says how
+// many such series there were.
+var Samples = function.MakeFunction(
+	"samples",
+	func(list api.SeriesList, context function.EvaluationContext) api.SeriesList {
+		uncounted := 0
+		result := api.SeriesList{Series: make([]api.Timeseries, len(list.Series))}
+		for i, series := range list.Series {
+			values := make([]float64, len(series.Values))
+			for j := range values {
+				if series.Samples == nil {
+					values[j] = math.NaN()
+				} else {
+					values[j] = float64(series.Samples[j])
+				}
+			}
+			if series.Samples == nil {
+				uncounted++
+			}
+			result.Series[i] = api.Timeseries{Values: values, TagSet: series.TagSet}
+		}
+		if uncounted != 0 {
+			context.AddNote(fmt.Sprintf("samples: %d of %d series have no sample counts, since the storage doesn't report them or a function computed their values", uncounted, len(list.Series)))
+		}
+		return result
+	},
+)
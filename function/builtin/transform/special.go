@@ -23,6 +23,10 @@ import (
 	"github.com/square/metrics/function"
 )
 
+// Timeshift evaluates the series over the timerange shifted by the duration,
+// and returns its values in the query's timerange, so that (for instance)
+// transform.timeshift(cpu.user, -1w) overlays last week's series on this
+// week's.
 var Timeshift = function.MakeFunction(
 	"transform.timeshift",
 	func(expression function.Expression, duration time.Duration, context function.EvaluationContext) (function.Value, error) {
@@ -35,9 +39,6 @@ var Timeshift = function.MakeFunction(
 var MovingAverage = function.MakeFunction(
 	"transform.moving_average",
 	func(context function.EvaluationContext, listExpression function.Expression, size time.Duration) (api.SeriesList, error) {
-		if size < 0 {
-			return api.SeriesList{}, fmt.Errorf("transform.moving_average must be given a non-negative duration")
-		}
 		// Applying a similar trick as did TimeshiftFunction. It fetches data prior to the start of the timerange.
 		limit := int(float64(size)/float64(context.Timerange().Resolution()) + 0.5) // Limit is the number of items to include in the average
 		if limit < 1 {
@@ -86,14 +87,12 @@ var MovingAverage = function.MakeFunction(
 		return list, nil
 	},
 	function.Option{Name: function.WidenBy, Value: function.Argument(1)},
+	function.Option{Name: function.NonNegative, Value: function.Argument(1)},
 )
 
 var ExponentialMovingAverage = function.MakeFunction(
 	"transform.exponential_moving_average",
 	func(context function.EvaluationContext, listExpression function.Expression, size time.Duration) (api.SeriesList, error) {
-		if size < 0 {
-			return api.SeriesList{}, fmt.Errorf("transform.exponential_moving_average must be given a non-negative duration")
-		}
 		// Applying a similar trick as did TimeshiftFunction. It fetches data prior to the start of the timerange.
 		scale := float64(size) / float64(context.Timerange().Resolution())
 		extraPoints := int(scale + 0.5)
@@ -133,8 +132,73 @@ var ExponentialMovingAverage = function.MakeFunction(
 		return resultList, nil
 	},
 	function.Option{Name: function.WidenBy, Value: function.Argument(1)},
+	function.Option{Name: function.NonNegative, Value: function.Argument(1)},
 )
 
+// MovingMax gives the greatest value of each series over a trailing window of
+// the given size. Like moving_average, it fetches the window before the first
+// point, so that the first points of the result are as complete as the rest.
+var MovingMax = newMovingExtreme("transform.moving_max", func(x float64, y float64) bool { return x >= y })
+
+// MovingMin gives the least value of each series over a trailing window of
+// the given size.
+var MovingMin = newMovingExtreme("transform.moving_min", func(x float64, y float64) bool { return x <= y })
+
+// newMovingExtreme creates a moving maximum or minimum, where x dominates y
+// if it's at least as extreme. Windows holding only NaN are NaN.
+func newMovingExtreme(name string, dominates func(x float64, y float64) bool) function.MetricFunction {
+	return function.MakeFunction(
+		name,
+		func(context function.EvaluationContext, listExpression function.Expression, size time.Duration) (api.SeriesList, error) {
+			limit := int(float64(size)/float64(context.Timerange().Resolution()) + 0.5) // Limit is the number of points in each window
+			if limit < 1 {
+				limit = 1
+			}
+			timerange := context.Timerange()
+			newTimerange := timerange.ExtendBefore(time.Duration(limit-1) * timerange.Resolution())
+			list, err := function.EvaluateToSeriesList(listExpression, context.WithTimerange(newTimerange))
+			if err != nil {
+				return api.SeriesList{}, err
+			}
+			resultList := api.SeriesList{
+				Series: make([]api.Timeseries, len(list.Series)),
+			}
+			for index, series := range list.Series {
+				results := make([]float64, timerange.Slots())
+				// The window holds the indices of the points which may yet be the
+				// extreme of a window, oldest first. Each point dominates those after
+				// it, so the first is the extreme of the current window.
+				window := []int{}
+				for i, value := range series.Values {
+					if !math.IsNaN(value) {
+						for len(window) > 0 && dominates(value, series.Values[window[len(window)-1]]) {
+							window = window[:len(window)-1]
+						}
+						window = append(window, i)
+					}
+					if len(window) > 0 && window[0] <= i-limit {
+						window = window[1:]
+					}
+					if i-limit+1 < 0 || i-limit+1 >= len(results) {
+						continue
+					}
+					results[i-limit+1] = math.NaN()
+					if len(window) > 0 {
+						results[i-limit+1] = series.Values[window[0]]
+					}
+				}
+				resultList.Series[index] = api.Timeseries{
+					Values: results,
+					TagSet: series.TagSet,
+				}
+			}
+			return resultList, nil
+		},
+		function.Option{Name: function.WidenBy, Value: function.Argument(1)},
+		function.Option{Name: function.NonNegative, Value: function.Argument(1)},
+	)
+}
+
 // Derivative is special because it needs to get one extra data point to the left
 // This transform estimates the "change per second" between the two samples (scaled consecutive difference)
 var Derivative = function.MakeFunction(
@@ -172,10 +236,19 @@ var Derivative = function.MakeFunction(
 // Specifically this function is designed for strictly increasing counters that
 // only decrease when reset to zero. That is, thie function returns consecutive
 // differences which are at least 0, or math.Max of the newly reported value and 0
+// If a maximum gap is given, the rate is extrapolated across missing slots:
+// the increase between two values at most that far apart is spread evenly
+// over the slots between them.
 var Rate = function.MakeFunction(
 	"transform.rate",
-	func(listExpression function.Expression, context function.EvaluationContext) (api.SeriesList, error) {
-		newContext := context.WithTimerange(context.Timerange().ExtendBefore(context.Timerange().Resolution()))
+	func(context function.EvaluationContext, listExpression function.Expression, optionalMaxGap *time.Duration) (api.SeriesList, error) {
+		resolution := context.Timerange().Resolution()
+		gapSlots := 0
+		if optionalMaxGap != nil {
+			gapSlots = int(*optionalMaxGap / resolution)
+		}
+		extraSlots := 1 + gapSlots
+		newContext := context.WithTimerange(context.Timerange().ExtendBefore(time.Duration(extraSlots) * resolution))
 		list, err := function.EvaluateToSeriesList(listExpression, newContext)
 		if err != nil {
 			return api.SeriesList{}, err
@@ -184,24 +257,40 @@ var Rate = function.MakeFunction(
 			Series: make([]api.Timeseries, len(list.Series)),
 		}
 		for seriesIndex, series := range list.Series {
-			newValues := make([]float64, len(series.Values)-1)
+			newValues := make([]float64, len(series.Values)-extraSlots)
+			for i := range newValues {
+				newValues[i] = math.NaN()
+			}
 			for i := range series.Values {
-				if i == 0 {
+				if i == 0 || math.IsNaN(series.Values[i]) && gapSlots > 0 {
 					continue
 				}
+				// The previous value, skipping over at most gapSlots missing ones.
+				previous := i - 1
+				for previous > 0 && previous >= i-gapSlots && math.IsNaN(series.Values[previous]) {
+					previous--
+				}
+				if math.IsNaN(series.Values[previous]) {
+					previous = i - 1
+				}
 				// Scaled difference
-				newValues[i-1] = (series.Values[i] - series.Values[i-1]) / context.Timerange().Resolution().Seconds()
-				if newValues[i-1] < 0 {
-					newValues[i-1] = 0
+				rate := (series.Values[i] - series.Values[previous]) / (float64(i-previous) * resolution.Seconds())
+				if rate < 0 {
+					rate = 0
 				}
-				if i+1 < len(series.Values) && series.Values[i-1] > series.Values[i] && series.Values[i] <= series.Values[i+1] {
+				if i+1 < len(series.Values) && series.Values[previous] > series.Values[i] && series.Values[i] <= series.Values[i+1] {
 					// Downsampling may cause a drop from 1000 to 0 to look like [1000, 500, 0] instead of [1000, 1001, 0].
 					// So we check the next, in addition to the previous.
-					context.AddNote(fmt.Sprintf("Rate(%v): The underlying counter reset between %f, %f\n", series.TagSet, series.Values[i-1], series.Values[i]))
+					context.AddNote(fmt.Sprintf("Rate(%v): The underlying counter reset between %f, %f\n", series.TagSet, series.Values[previous], series.Values[i]))
 					// values[i] is our best approximatation of the delta between i-1 and i
 					// Why? This should only be used on counters, so if v[i] - v[i-1] < 0 then
 					// the counter has reset, and we know *at least* v[i] increments have happened
-					newValues[i-1] = math.Max(series.Values[i], 0) / context.Timerange().Resolution().Seconds()
+					rate = math.Max(series.Values[i], 0) / (float64(i-previous) * resolution.Seconds())
+				}
+				for slot := previous + 1; slot <= i; slot++ {
+					if slot >= extraSlots {
+						newValues[slot-extraSlots] = rate
+					}
 				}
 			}
 			resultList.Series[seriesIndex] = api.Timeseries{
@@ -211,5 +300,6 @@ var Rate = function.MakeFunction(
 		}
 		return resultList, nil
 	},
-	function.Option{Name: function.WidenBy, Value: function.Slot(1)},
+	function.Option{Name: function.WidenBy, Value: function.ArgumentPlusSlot(1)},
+	function.Option{Name: function.NonNegative, Value: function.Argument(1)},
 )
@@ -0,0 +1,83 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/function/builtin/aggregate"
+	"github.com/square/metrics/function/builtin/filter"
+)
+
+// rankAggregators are the summaries by which topk and bottomk rank series.
+var rankAggregators = map[string]func([]float64) float64{
+	"mean": aggregate.Mean,
+	"max":  aggregate.Max,
+	"min":  aggregate.Min,
+	"sum":  aggregate.Sum,
+	"current": func(values []float64) float64 {
+		for i := len(values) - 1; i >= 0; i-- {
+			if !math.IsNaN(values[i]) {
+				return values[i]
+			}
+		}
+		return math.NaN()
+	},
+}
+
+func rankAggregatorNames() string {
+	names := []string{}
+	for name := range rankAggregators {
+		names = append(names, "'"+name+"'")
+	}
+	sort.Strings(names)
+	return "one of " + strings.Join(names, ", ")
+}
+
+// newRank keeps the k series whose aggregate over the whole timerange is
+// highest (or lowest), as in `transform.topk(cpu, 10, 'max')`. Series are
+// ranked by their mean if no aggregator is given, and series whose
+// aggregate is NaN come last.
+func newRank(name string, lowest bool) function.MetricFunction {
+	return function.MakeFunction(
+		name,
+		func(list api.SeriesList, k float64, aggregator *string, timerange api.Timerange) (api.SeriesList, error) {
+			summary := aggregate.Mean
+			if aggregator != nil {
+				var ok bool
+				if summary, ok = rankAggregators[*aggregator]; !ok {
+					return api.SeriesList{}, function.ArgumentError{
+						Name:     name,
+						Index:    2,
+						Expected: rankAggregatorNames(),
+						Actual:   *aggregator,
+					}
+				}
+			}
+			return filter.ByRecent(list, int(k+0.5), summary, lowest, timerange.Slots()), nil
+		},
+		function.Option{Name: function.NonNegative, Value: function.Argument(1)},
+	)
+}
+
+// TopK keeps the k series with the highest aggregate.
+var TopK = newRank("transform.topk", false)
+
+// BottomK keeps the k series with the lowest aggregate.
+var BottomK = newRank("transform.bottomk", true)
@@ -0,0 +1,78 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestRank(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewTimerange(0, 20, 10)
+	a.CheckError(err)
+	list := literal{function.SeriesListValue(api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{1, 9, 1}, TagSet: api.TagSet{"host": "spiky"}},
+		{Values: []float64{4, 4, 4}, TagSet: api.TagSet{"host": "steady"}},
+		{Values: []float64{math.NaN(), math.NaN(), math.NaN()}, TagSet: api.TagSet{"host": "empty"}},
+		{Values: []float64{2, 3, math.NaN()}, TagSet: api.TagSet{"host": "stopped"}},
+	}})}
+	ctx := function.EvaluationContextBuilder{Timerange: timerange, Ctx: context.Background()}.Build()
+	rank := func(rank function.MetricFunction, k float64, aggregator ...string) ([]string, error) {
+		arguments := []function.Expression{list, literal{function.ScalarValue(k)}}
+		for _, name := range aggregator {
+			arguments = append(arguments, literal{function.StringValue(name)})
+		}
+		value, err := rank.Run(ctx, arguments, function.Groups{})
+		if err != nil {
+			return nil, err
+		}
+		result, convErr := value.ToSeriesList(timerange)
+		a.EqBool(convErr == nil, true)
+		hosts := []string{}
+		for _, series := range result.Series {
+			hosts = append(hosts, series.TagSet["host"])
+		}
+		return hosts, nil
+	}
+	for _, test := range []struct {
+		rank       function.MetricFunction
+		k          float64
+		aggregator []string
+		expected   []string
+	}{
+		{TopK, 1, nil, []string{"steady"}},
+		{TopK, 2, []string{"max"}, []string{"spiky", "steady"}},
+		{TopK, 10, []string{"sum"}, []string{"steady", "spiky", "stopped", "empty"}},
+		{BottomK, 1, nil, []string{"stopped"}},
+		{BottomK, 2, []string{"current"}, []string{"spiky", "stopped"}},
+		{BottomK, 0, nil, []string{}},
+	} {
+		hosts, err := rank(test.rank, test.k, test.aggregator...)
+		a.CheckError(err)
+		a.Eq(hosts, test.expected)
+	}
+	_, err = rank(TopK, 1, "median")
+	if _, ok := err.(function.ArgumentError); !ok {
+		t.Errorf("expected an ArgumentError for an unknown aggregator, but got %v", err)
+	}
+	_, err = rank(TopK, -1)
+	a.EqBool(err == nil, false)
+}
@@ -116,11 +116,8 @@ func MapMaker(name string, fun func(float64) float64) function.Function {
 var NaNFill = function.MakeFunction(
 	"transform.nan_fill",
 	func(list api.SeriesList, defaultValue float64) api.SeriesList {
-		return mapper(list, func(value float64) float64 {
-			if math.IsNaN(value) {
-				return defaultValue
-			}
-			return value
+		return transformEach(list, func(values []float64) []float64 {
+			return FillValues(values, defaultValue)
 		})
 	},
 )
@@ -129,51 +126,127 @@ var NaNFill = function.MakeFunction(
 var NaNKeepLast = function.MakeFunction(
 	"transform.nan_keep_last",
 	func(list api.SeriesList) api.SeriesList {
+		return transformEach(list, FillForwardValues)
+	},
+)
+
+// Fill replaces missing values (NaN) with the given value.
+var Fill = function.MakeFunction(
+	"transform.fill",
+	func(list api.SeriesList, value float64) api.SeriesList {
 		return transformEach(list, func(values []float64) []float64 {
-			result := make([]float64, len(values))
-			for i := range result {
-				result[i] = values[i]
-				if math.IsNaN(values[i]) && i > 0 {
-					result[i] = result[i-1]
-				}
-			}
-			return result
+			return FillValues(values, value)
 		})
 	},
 )
 
-// boundError represents an error in bounds, when (lower > upper) so the interval is empty.
+// FillForward replaces missing values (NaN) with the last value
+// before them.
+var FillForward = function.MakeFunction(
+	"transform.fill_forward",
+	func(list api.SeriesList) api.SeriesList {
+		return transformEach(list, FillForwardValues)
+	},
+)
+
+// Interpolate replaces missing values (NaN) with the straight line
+// between the values around them.
+var Interpolate = function.MakeFunction(
+	"transform.interpolate",
+	func(list api.SeriesList) api.SeriesList {
+		return transformEach(list, InterpolateValues)
+	},
+)
+
+// FillValues returns the values with each NaN replaced by the given value.
+func FillValues(values []float64, value float64) []float64 {
+	result := make([]float64, len(values))
+	for i := range values {
+		result[i] = values[i]
+		if math.IsNaN(values[i]) {
+			result[i] = value
+		}
+	}
+	return result
+}
+
+// FillForwardValues returns the values with each NaN replaced by the last value
+// before it. Leading NaNs are kept, since no value precedes them.
+func FillForwardValues(values []float64) []float64 {
+	result := make([]float64, len(values))
+	for i := range values {
+		result[i] = values[i]
+		if math.IsNaN(values[i]) && i > 0 {
+			result[i] = result[i-1]
+		}
+	}
+	return result
+}
+
+// InterpolateValues returns the values with each run of NaNs replaced by the
+// straight line between the values on either side of it. Leading and
+// trailing NaNs are kept, since they lie on one side only.
+func InterpolateValues(values []float64) []float64 {
+	result := append([]float64{}, values...)
+	last := -1 // the index of the last value which isn't NaN
+	for i, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+		if last >= 0 && last < i-1 {
+			step := (value - values[last]) / float64(i-last)
+			for j := last + 1; j < i; j++ {
+				result[j] = values[last] + step*float64(j-last)
+			}
+		}
+		last = i
+	}
+	return result
+}
+
+// boundError represents an error in bounds, when (lower > upper) so the interval is empty,
+// or when either bound is NaN.
 type boundError struct {
+	name  string
 	lower float64
 	upper float64
 }
 
 func (b boundError) Error() string {
-	return fmt.Sprintf("the lower bound (%f) should be no more than the upper bound (%f) in the parameters to transform.bound( ..., %f, %f)", b.lower, b.upper, b.lower, b.upper)
+	return fmt.Sprintf("the lower bound (%f) should be no more than the upper bound (%f) in the parameters to %s( ..., %f, %f)", b.lower, b.upper, b.name, b.lower, b.upper)
 }
 
 func (b boundError) TokenName() string {
-	return fmt.Sprintf("transform.bound(..., %f, %f)", b.lower, b.upper)
+	return fmt.Sprintf("%s(..., %f, %f)", b.name, b.lower, b.upper)
 }
 
-// Bound replaces values which fall outside the given limits with the limits themselves. If the lowest bound exceeds the upper bound, an error is returned.
-var Bound = function.MakeFunction(
-	"transform.bound",
-	func(list api.SeriesList, lowerBound float64, upperBound float64) (api.SeriesList, error) {
-		if lowerBound > upperBound {
-			return api.SeriesList{}, boundError{lowerBound, upperBound}
-		}
-		return mapper(list, func(value float64) float64 {
-			if value < lowerBound {
-				return lowerBound
-			}
-			if value > upperBound {
-				return upperBound
+// makeBound creates a function replacing values which fall outside the given limits with the limits themselves.
+// NaN values are left as they are. If the lowest bound exceeds the upper bound, or either is NaN, an error is returned.
+func makeBound(name string) function.MetricFunction {
+	return function.MakeFunction(
+		name,
+		func(list api.SeriesList, lowerBound float64, upperBound float64) (api.SeriesList, error) {
+			if math.IsNaN(lowerBound) || math.IsNaN(upperBound) || lowerBound > upperBound {
+				return api.SeriesList{}, boundError{name, lowerBound, upperBound}
 			}
-			return value
-		}), nil
-	},
-)
+			return mapper(list, func(value float64) float64 {
+				if value < lowerBound {
+					return lowerBound
+				}
+				if value > upperBound {
+					return upperBound
+				}
+				return value
+			}), nil
+		},
+	)
+}
+
+// Bound replaces values which fall outside the given limits with the limits themselves.
+var Bound = makeBound("transform.bound")
+
+// Clamp is another name for Bound.
+var Clamp = makeBound("transform.clamp")
 
 // LowerBound replaces values that fall below the given bound with the lower bound.
 var LowerBound = function.MakeFunction(
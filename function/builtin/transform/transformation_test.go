@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/square/metrics/api"
 	"github.com/square/metrics/function"
@@ -238,6 +239,59 @@ func TestApplyTransform(t *testing.T) {
 	}
 }
 
+func TestRateMaxGap(t *testing.T) {
+	epsilon := 1e-10
+	timerange, err := api.NewSnappedTimerange(0, 5*30000, 30000)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test case: %s", err.Error())
+	}
+	// With a maximum gap of two slots, the rate needs three extra slots to the left.
+	list := api.SeriesList{
+		Series: []api.Timeseries{
+			{
+				Values: []float64{0, 1, math.NaN(), math.NaN(), 4, 5, math.NaN(), 1, 2},
+				TagSet: api.TagSet{"series": "A"},
+			},
+			{
+				Values: []float64{0, math.NaN(), math.NaN(), math.NaN(), 3, 4, 5, 6, 7},
+				TagSet: api.TagSet{"series": "B"},
+			},
+		},
+	}
+	expected := [][]float64{
+		// The increase from 1 to 4 is spread over three slots, and the counter
+		// resets between 5 and 1, so it has increased by at least 1 over two slots.
+		{1.0 / 30, 1.0 / 30, 1.0 / 30, 1.0 / 60, 1.0 / 60, 1.0 / 30},
+		// The gap of three slots is too long to extrapolate across.
+		{math.NaN(), math.NaN(), 1.0 / 30, 1.0 / 30, 1.0 / 30, 1.0 / 30},
+	}
+	ctx := function.EvaluationContextBuilder{EvaluationNotes: &function.EvaluationNotes{}, Timerange: timerange, Ctx: context.Background()}.Build()
+	maxGap := literal{function.NewDurationValue("", 60*time.Second)}
+	resultValue, err := Rate.Run(ctx, []function.Expression{literal{function.SeriesListValue(list)}, maxGap}, function.Groups{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	result, convErr := resultValue.ToSeriesList(ctx.Timerange())
+	if convErr != nil {
+		t.Fatalf("Error converting to series list: %s", convErr.WithContext("test case").Error())
+	}
+	for i, series := range result.Series {
+		if len(series.Values) != len(expected[i]) {
+			t.Errorf("Expected %+v for series %s but got %+v", expected[i], series.TagSet["series"], series.Values)
+			continue
+		}
+		for j := range series.Values {
+			if math.IsNaN(expected[i][j]) != math.IsNaN(series.Values[j]) || math.Abs(series.Values[j]-expected[i][j]) > epsilon {
+				t.Errorf("Expected %+v for series %s but got %+v", expected[i], series.TagSet["series"], series.Values)
+				break
+			}
+		}
+	}
+	if len(ctx.Notes()) != 1 || ctx.Notes()[0] != "Rate(map[series:A]): The underlying counter reset between 5.000000, 1.000000\n" {
+		t.Errorf("Unexpected notes %+v", ctx.Notes())
+	}
+}
+
 func TestApplyNotes(t *testing.T) {
 	timerange, err := api.NewSnappedTimerange(0, 5*30000, 30000)
 	if err != nil {
@@ -515,6 +569,33 @@ func TestApplyTransformNaN(t *testing.T) {
 				"C": {0, 1, 2, 2, 2, 1},
 			},
 		},
+		{
+			transform:  Fill,
+			parameters: []function.Expression{listExpression, literal{function.ScalarValue(0)}},
+			expected: map[string][]float64{
+				"A": {0, 1, 0, 3, 4, 5},
+				"B": {2, 0, 0, 0, 3, 3},
+				"C": {0, 1, 2, 0, 2, 1},
+			},
+		},
+		{
+			transform:  FillForward,
+			parameters: []function.Expression{listExpression},
+			expected: map[string][]float64{
+				"A": {0, 1, 1, 3, 4, 5},
+				"B": {2, 2, 2, 2, 3, 3},
+				"C": {0, 1, 2, 2, 2, 1},
+			},
+		},
+		{
+			transform:  Interpolate,
+			parameters: []function.Expression{listExpression},
+			expected: map[string][]float64{
+				"A": {0, 1, 2, 3, 4, 5},
+				"B": {2, 2.25, 2.5, 2.75, 3, 3},
+				"C": {0, 1, 2, 2, 2, 1},
+			},
+		},
 	}
 	for _, test := range tests {
 		ctx := function.EvaluationContextBuilder{Timerange: timerange, Ctx: context.Background()}.Build()
@@ -549,6 +630,14 @@ func TestApplyTransformNaN(t *testing.T) {
 
 // Test that the transforms of the following work as expected:
 // - transform.derivative | transform.integral
+func TestInterpolateValues(t *testing.T) {
+	nan := math.NaN()
+	a := assert.New(t)
+	a.EqFloatArray(InterpolateValues([]float64{nan, 1, nan, nan, 4, nan}), []float64{nan, 1, 2, 3, 4, nan}, 1e-9)
+	a.EqFloatArray(InterpolateValues([]float64{nan, nan}), []float64{nan, nan}, 1e-9)
+	a.EqFloatArray(InterpolateValues([]float64{}), []float64{}, 1e-9)
+}
+
 func TestTransformIdentity(t *testing.T) {
 	//This is to make sure that the scale of all the data
 	//is interpreted as 30 seconds (30000 milliseconds)
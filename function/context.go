@@ -35,8 +35,14 @@ type EvaluationContextBuilder struct {
 	Registry             Registry                // Registry stores functions
 	SampleMethod         timeseries.SampleMethod // SampleMethod to use when up/downsampling to match the requested resolution
 	FetchLimit           FetchCounter            // A limit on the number of fetches which may be performed
+	MemoryLimit          MemoryCounter           // optional. A limit on the bytes of fetched series which may be held
+	Goroutines           GoroutineCounter        // optional. Limits the goroutines evaluating the query, and tracks them for leaks
 	Profiler             *inspect.Profiler       // A profiler pointer
 	EvaluationNotes      *EvaluationNotes        // Debug + numerical notes that can be added during evaluation
+	FreshnessNotes       *FreshnessNotes         // optional. Collects how far behind the fetched data is
+	Strict               bool                    // optional. Turns soft conditions, such as empty fetches, into errors
+	Sampling             *Sampling               // optional. Restricts fetches to a sample of the matching series
+	Labels               map[string]string       // optional. Sent with backend requests, to attribute them to the query's workload
 	Ctx                  context.Context
 
 	// These may be changed in sub-contexts while evaluating the query.
@@ -93,6 +99,18 @@ func (context EvaluationContext) FetchLimitConsume(n int) error {
 	return context.private.FetchLimit.Consume(n)
 }
 
+// MemoryLimitConsume counts the bytes of fetched series against the memory
+// limit, returning a MemoryLimitError if this would exceed it.
+func (context EvaluationContext) MemoryLimitConsume(bytes int64) error {
+	return context.private.MemoryLimit.Consume(bytes)
+}
+
+// Go runs the work on a new goroutine, unless the query's goroutine limit has
+// been reached.
+func (context EvaluationContext) Go(work func()) {
+	context.private.Goroutines.Go(work)
+}
+
 // Ctx returns the underlying Context instance for the evaluation.
 func (context EvaluationContext) Ctx() context.Context {
 	return context.private.Ctx
@@ -109,6 +127,32 @@ func (context EvaluationContext) Profiler() *inspect.Profiler {
 	return context.private.Profiler
 }
 
+// Labels returns the labels sent with backend requests.
+func (context EvaluationContext) Labels() map[string]string {
+	return context.private.Labels
+}
+
+// Strict returns whether soft conditions should be reported as errors.
+func (context EvaluationContext) Strict() bool {
+	return context.private.Strict
+}
+
+// Sampling returns the sampling applied to fetches, or nil if every matching
+// series is fetched.
+func (context EvaluationContext) Sampling() *Sampling {
+	return context.private.Sampling
+}
+
+// Warn adds the note to the evaluation context, or returns it as a
+// StrictError in strict mode.
+func (context EvaluationContext) Warn(note string) error {
+	if context.private.Strict {
+		return StrictError{Message: note}
+	}
+	context.AddNote(note)
+	return nil
+}
+
 // AddNote adds a note to the evaluation context.
 func (context EvaluationContext) AddNote(note string) {
 	context.private.EvaluationNotes.AddNote(note)
@@ -119,6 +163,16 @@ func (context EvaluationContext) Notes() []string {
 	return context.private.EvaluationNotes.Notes()
 }
 
+// RecordFreshness notes how far behind the series fetched for a metric are.
+func (context EvaluationContext) RecordFreshness(metric string, list api.SeriesList) {
+	context.private.FreshnessNotes.Record(metric, list, context.private.Timerange)
+}
+
+// Freshness returns the freshness of each metric fetched so far.
+func (context EvaluationContext) Freshness() []Freshness {
+	return context.private.FreshnessNotes.All()
+}
+
 // EvaluationNotes holds notes that were recorded during evaluation.
 type EvaluationNotes struct {
 	mutex sync.Mutex
@@ -200,7 +254,50 @@ func (c FetchCounter) Current() int {
 func (c FetchCounter) Consume(n int) error {
 	remaining := atomic.AddInt32(c.count, -int32(n))
 	if remaining < 0 {
-		return fmt.Errorf("performing fetch of %d additional series brings the total to %d, which exceeds the specified limit %d", n, c.limit-int(remaining), c.limit)
+		total := c.limit - int(remaining)
+		return NewLimitError(fmt.Sprintf("performing fetch of %d additional series brings the total to %d, which exceeds the specified limit %d", n, total, c.limit), total, c.limit)
+	}
+	return nil
+}
+
+// MemoryCounter counts the bytes of the series fetched by a query in a
+// thread-safe manner. The zero MemoryCounter has no limit.
+type MemoryCounter struct {
+	used  *int64
+	limit int64
+}
+
+// NewMemoryCounter creates a MemoryCounter with the given limit, in bytes.
+// A limit of 0 counts, but never refuses.
+func NewMemoryCounter(limit int64) MemoryCounter {
+	return MemoryCounter{
+		used:  new(int64),
+		limit: limit,
+	}
+}
+
+// Limit returns the number of bytes allowed by this counter.
+func (c MemoryCounter) Limit() int64 {
+	return c.limit
+}
+
+// Used returns the number of bytes counted so far.
+func (c MemoryCounter) Used() int64 {
+	if c.used == nil {
+		return 0
+	}
+	return atomic.LoadInt64(c.used)
+}
+
+// Consume adds to the bytes used, and returns an error if they exceed the
+// limit.
+func (c MemoryCounter) Consume(bytes int64) error {
+	if c.used == nil {
+		return nil
+	}
+	total := atomic.AddInt64(c.used, bytes)
+	if c.limit > 0 && total > c.limit {
+		return MemoryLimitError{NewLimitError(fmt.Sprintf("fetching %d more bytes of series brings the total to %d, which exceeds the memory limit of %d", bytes, total, c.limit), total, c.limit)}
 	}
 	return nil
 }
@@ -0,0 +1,113 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/square/metrics/api"
+)
+
+// A Distribution counts, at each time of its timerange, how many series have
+// a value in each of several buckets. It's the matrix behind a heatmap.
+type Distribution struct {
+	Bounds    []float64     `json:"bounds"` // the edges of the buckets in increasing order; bucket i spans Bounds[i] to Bounds[i+1]
+	Timerange api.Timerange `json:"timerange"`
+	Counts    [][]int       `json:"counts"`  // Counts[i][t] is the number of series in bucket i at the t-th time
+	Missing   []int         `json:"missing"` // the number of series without a value at each time
+}
+
+// NewDistribution counts the values of the series in the buckets between the
+// given bounds. Values below the first bound or above the last are counted in
+// the first or last bucket.
+func NewDistribution(list api.SeriesList, bounds []float64, timerange api.Timerange) Distribution {
+	buckets := len(bounds) - 1
+	distribution := Distribution{
+		Bounds:    bounds,
+		Timerange: timerange,
+		Counts:    make([][]int, buckets),
+		Missing:   make([]int, timerange.Slots()),
+	}
+	for i := range distribution.Counts {
+		distribution.Counts[i] = make([]int, timerange.Slots())
+	}
+	for _, series := range list.Series {
+		for t, value := range series.Values {
+			if t >= len(distribution.Missing) {
+				break
+			}
+			if math.IsNaN(value) {
+				distribution.Missing[t]++
+				continue
+			}
+			// The first edge above the value closes its bucket.
+			bucket := sort.Search(len(bounds), func(i int) bool { return bounds[i] > value }) - 1
+			if bucket < 0 {
+				bucket = 0
+			}
+			if bucket >= buckets {
+				bucket = buckets - 1
+			}
+			distribution.Counts[bucket][t]++
+		}
+	}
+	return distribution
+}
+
+// ToSeriesList is a conversion function. Each bucket becomes a series of its
+// counts, tagged with the bucket's "lower" and "upper" bounds.
+func (distribution Distribution) ToSeriesList(timerange api.Timerange) (api.SeriesList, *ConversionFailure) {
+	if timerange != distribution.Timerange {
+		return api.SeriesList{}, &ConversionFailure{"distribution", "SeriesList"}
+	}
+	list := api.SeriesList{Series: make([]api.Timeseries, len(distribution.Counts))}
+	for i, counts := range distribution.Counts {
+		values := make([]float64, len(counts))
+		for t, count := range counts {
+			values[t] = float64(count)
+		}
+		list.Series[i] = api.Timeseries{
+			Values: values,
+			TagSet: api.TagSet{
+				"lower": strconv.FormatFloat(distribution.Bounds[i], 'g', -1, 64),
+				"upper": strconv.FormatFloat(distribution.Bounds[i+1], 'g', -1, 64),
+			},
+		}
+	}
+	return list, nil
+}
+
+// ToString is a conversion function.
+func (distribution Distribution) ToString() (string, *ConversionFailure) {
+	return "", &ConversionFailure{"distribution", "string"}
+}
+
+// ToScalar is a conversion function.
+func (distribution Distribution) ToScalar() (float64, *ConversionFailure) {
+	return 0, &ConversionFailure{"distribution", "scalar"}
+}
+
+// ToScalarSet is a conversion function.
+func (distribution Distribution) ToScalarSet() (ScalarSet, *ConversionFailure) {
+	return nil, &ConversionFailure{"distribution", "scalar set"}
+}
+
+// ToDuration is a conversion function.
+func (distribution Distribution) ToDuration() (time.Duration, *ConversionFailure) {
+	return 0, &ConversionFailure{"distribution", "duration"}
+}
@@ -0,0 +1,53 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestDistribution(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewTimerange(0, 20, 10)
+	a.CheckError(err)
+	list := api.SeriesList{Series: []api.Timeseries{
+		{Values: []float64{0, 5, -3}, TagSet: api.TagSet{"host": "a"}},
+		{Values: []float64{1, math.NaN(), 10}, TagSet: api.TagSet{"host": "b"}},
+		{Values: []float64{2, 9.5, 4}, TagSet: api.TagSet{"host": "c"}},
+	}}
+	distribution := NewDistribution(list, []float64{0, 2, 4, 6}, timerange)
+	a.Eq(distribution.Counts, [][]int{{2, 0, 1}, {1, 0, 0}, {0, 2, 2}})
+	a.Eq(distribution.Missing, []int{0, 1, 0})
+
+	encoded, err := json.Marshal(distribution)
+	a.CheckError(err)
+	a.EqString(string(encoded), `{"bounds":[0,2,4,6],"timerange":{"start":0,"end":20,"resolution":10},"counts":[[2,0,1],[1,0,0],[0,2,2]],"missing":[0,1,0]}`)
+
+	converted, convErr := distribution.ToSeriesList(timerange)
+	if convErr != nil {
+		t.Fatalf("expected the distribution to convert to a series list")
+	}
+	a.EqInt(len(converted.Series), 3)
+	a.Eq(converted.Series[1].TagSet, api.TagSet{"lower": "2", "upper": "4"})
+	a.Eq(converted.Series[1].Values, []float64{1, 0, 0})
+	if _, err := distribution.ToScalarSet(); err == nil {
+		a.Errorf("expected a distribution not to convert to a scalar set")
+	}
+}
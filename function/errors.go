@@ -55,6 +55,12 @@ func (err defaultLimitError) Limit() interface{} {
 	return err.limit
 }
 
+// MemoryLimitError is the limit error of a query whose fetched series would
+// take more memory than it's allowed.
+type MemoryLimitError struct {
+	LimitError
+}
+
 // ArgumentLengthError is a kind of error that describes when a function is given too many or too few arguments.
 type ArgumentLengthError struct {
 	Name        string
@@ -89,3 +95,40 @@ func (err ArgumentLengthError) Error() string {
 		)
 	}
 }
+
+// ArgumentError describes a function argument which has the wrong type or an
+// out-of-range value.
+type ArgumentError struct {
+	Name     string // Name is the function which rejected the argument.
+	Index    int    // Index is the zero-based position of the argument.
+	Expected string // Expected describes the accepted values, e.g. "a duration".
+	Actual   string // Actual is the argument as it was written in the query.
+	Position string // Position is the location of the function call in the query, if known.
+}
+
+var ordinals = []string{"first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth", "tenth"}
+
+// Error gives a detailed description of the error.
+func (err ArgumentError) Error() string {
+	ordinal := fmt.Sprintf("argument %d", err.Index+1)
+	if err.Index >= 0 && err.Index < len(ordinals) {
+		ordinal = ordinals[err.Index] + " argument"
+	}
+	message := fmt.Sprintf("%s: %s must be %s, got '%s'", err.Name, ordinal, err.Expected, err.Actual)
+	if err.Position != "" {
+		message += fmt.Sprintf(" (at %s)", err.Position)
+	}
+	return message
+}
+
+// StrictError describes a condition which is only an error in strict mode,
+// such as a predicate which matches no series. Otherwise, it goes unreported
+// or becomes a note.
+type StrictError struct {
+	Message string
+}
+
+// Error gives a detailed description of the error.
+func (err StrictError) Error() string {
+	return fmt.Sprintf("strict mode: %s", err.Message)
+}
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/predicate"
 )
 
 // Expression is a piece of code, which can be evaluated in a given
@@ -71,6 +72,47 @@ func (w *WidestMode) AddTime(t time.Time) {
 	}
 }
 
+// PlanMode collects the fetches and function calls of an expression into
+// its Plan, without evaluating it.
+type PlanMode struct {
+	Plan      *Plan
+	Aggregate string // the function of which the expression is the only argument, when the storage may aggregate in its place
+}
+
+// A Plan lists the distinct fetches and functions of expressions, in the
+// order in which they're first found.
+type Plan struct {
+	Fetches   []PlannedFetch
+	Functions []string
+}
+
+// A PlannedFetch is a fetch of the series of a metric which satisfy a predicate.
+type PlannedFetch struct {
+	Metric    api.MetricKey
+	Predicate predicate.Predicate
+	Aggregate string // the aggregate function whose only argument is the fetch, if any; the storage may compute it instead
+}
+
+// AddFetch records a fetch, unless the same fetch was already recorded.
+func (plan *Plan) AddFetch(metric api.MetricKey, condition predicate.Predicate, aggregate string) {
+	for _, fetch := range plan.Fetches {
+		if fetch.Metric == metric && fetch.Predicate.Query() == condition.Query() && fetch.Aggregate == aggregate {
+			return
+		}
+	}
+	plan.Fetches = append(plan.Fetches, PlannedFetch{Metric: metric, Predicate: condition, Aggregate: aggregate})
+}
+
+// AddFunction records a call of the named function.
+func (plan *Plan) AddFunction(name string) {
+	for _, existing := range plan.Functions {
+		if existing == name {
+			return
+		}
+	}
+	plan.Functions = append(plan.Functions, name)
+}
+
 // StringName is for human readability and respects aliases
 func StringName() DescriptionMode {
 	return StringNameMode{}
@@ -155,6 +197,22 @@ func EvaluateToString(e Expression, context EvaluationContext) (string, error) {
 // If any evaluation errors, EvaluateMany will propagate that error. The resulting values
 // will be in the order corresponding to the provided expressions.
 func EvaluateMany(context EvaluationContext, expressions []Expression) ([]Value, error) {
+	array := make([]Value, len(expressions))
+	err := EvaluateEach(context, expressions, func(index int, value Value) {
+		array[index] = value
+	})
+	if err != nil {
+		return nil, err
+	}
+	return array, nil
+}
+
+// EvaluateEach evaluates a list of expressions concurrently using a single
+// EvaluationContext, calling each with the index and value of every expression
+// as soon as it has been evaluated, so not necessarily in order. The calls are
+// made one at a time. If any evaluation errors, EvaluateEach stops and
+// propagates that error.
+func EvaluateEach(context EvaluationContext, expressions []Expression, each func(index int, value Value)) error {
 	type result struct {
 		index int
 		err   error
@@ -162,30 +220,31 @@ func EvaluateMany(context EvaluationContext, expressions []Expression) ([]Value,
 	}
 	length := len(expressions)
 	if length == 0 {
-		return []Value{}, nil
+		return nil
 	}
 	if length == 1 {
-		result, err := expressions[0].Evaluate(context)
+		value, err := expressions[0].Evaluate(context)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		return []Value{result}, nil
+		each(0, value)
+		return nil
 	}
 	// concurrent evaluations
 	results := make(chan result, length)
 	for i, expr := range expressions {
-		go func(i int, expr Expression) {
+		i, expr := i, expr
+		context.Go(func() {
 			value, err := expr.Evaluate(context)
 			results <- result{i, err, value}
-		}(i, expr)
+		})
 	}
-	array := make([]Value, length)
 	for i := 0; i < length; i++ {
 		result := <-results
 		if result.err != nil {
-			return nil, result.err
+			return result.err
 		}
-		array[result.index] = result.value
+		each(result.index, result.value)
 	}
-	return array, nil
+	return nil
 }
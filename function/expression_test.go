@@ -15,6 +15,9 @@
 package function
 
 import (
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/square/metrics/testing_support/assert"
@@ -36,3 +39,60 @@ func Test_FetchCounter(t *testing.T) {
 	}
 	a.EqInt(c.Current(), 11)
 }
+
+func Test_GoroutineCounter(t *testing.T) {
+	a := assert.New(t)
+	c := NewGoroutineCounter(2)
+	a.EqInt(c.Limit(), 2)
+	release := make(chan struct{})
+	started := sync.WaitGroup{}
+	started.Add(2)
+	for i := 0; i < 2; i++ {
+		c.Go(func() {
+			started.Done()
+			<-release
+		})
+	}
+	started.Wait()
+	a.EqInt(c.Running(), 2)
+	a.EqBool(EvaluatorGoroutines() >= 2, true)
+
+	// Beyond the limit, the work runs on the calling goroutine.
+	ran := false
+	c.Go(func() { ran = true })
+	a.EqBool(ran, true)
+	a.EqInt(c.Inline(), 1)
+
+	// The goroutines still running are reported with the stack which spawned them.
+	leaks := c.leaks()
+	a.EqInt(len(leaks), 2)
+	a.EqBool(strings.Contains(leaks[0], "Test_GoroutineCounter"), true)
+
+	close(release)
+	for c.Running() != 0 {
+		runtime.Gosched()
+	}
+	a.EqInt(len(c.leaks()), 0)
+}
+
+func Test_MemoryCounter(t *testing.T) {
+	a := assert.New(t)
+	unlimited := MemoryCounter{}
+	a.CheckError(unlimited.Consume(1 << 40))
+	a.Eq(unlimited.Used(), int64(0))
+
+	c := NewMemoryCounter(100)
+	a.Eq(c.Limit(), int64(100))
+	a.CheckError(c.Consume(60))
+	a.CheckError(c.Consume(40))
+	a.Eq(c.Used(), int64(100))
+	err := c.Consume(8)
+	if _, ok := err.(MemoryLimitError); !ok {
+		a.Errorf("Expected a MemoryLimitError when consuming; but found %v", err)
+	}
+	a.Eq(c.Used(), int64(108))
+
+	counting := NewMemoryCounter(0)
+	a.CheckError(counting.Consume(1 << 40))
+	a.Eq(counting.Used(), int64(1<<40))
+}
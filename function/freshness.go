@@ -0,0 +1,110 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/api"
+)
+
+// SeriesLag is the time between the end of the timerange and the last
+// datapoint of the series. It is false if the series has no datapoints.
+func SeriesLag(series api.Timeseries, timerange api.Timerange) (time.Duration, bool) {
+	for i := len(series.Values) - 1; i >= 0; i-- {
+		if !math.IsNaN(series.Values[i]) {
+			return time.Duration(len(series.Values)-1-i) * timerange.Resolution(), true
+		}
+	}
+	return 0, false
+}
+
+// Freshness summarizes how far the stored data for a metric lags behind the
+// end of the requested timerange, so that clients can distinguish data which
+// has not arrived yet from genuine gaps or zeros.
+type Freshness struct {
+	Metric        string  `json:"metric"`
+	Series        int     `json:"series"`          // the number of series fetched
+	Empty         int     `json:"empty"`           // the number of series with no datapoints at all
+	MinLagSeconds float64 `json:"min_lag_seconds"` // the lag of the freshest series with datapoints
+	MaxLagSeconds float64 `json:"max_lag_seconds"` // the lag of the stalest series with datapoints
+}
+
+// FreshnessNotes collects the freshness of each metric fetched while
+// evaluating a query.
+type FreshnessNotes struct {
+	mutex   sync.Mutex
+	metrics map[string]*Freshness
+}
+
+// Record adds the series fetched for a metric in a threadsafe manner.
+func (notes *FreshnessNotes) Record(metric string, list api.SeriesList, timerange api.Timerange) {
+	if notes == nil {
+		return
+	}
+	notes.mutex.Lock()
+	defer notes.mutex.Unlock()
+	if notes.metrics == nil {
+		notes.metrics = map[string]*Freshness{}
+	}
+	freshness, ok := notes.metrics[metric]
+	if !ok {
+		freshness = &Freshness{Metric: metric, MinLagSeconds: math.NaN(), MaxLagSeconds: math.NaN()}
+		notes.metrics[metric] = freshness
+	}
+	for _, series := range list.Series {
+		freshness.Series++
+		lag, ok := SeriesLag(series, timerange)
+		if !ok {
+			freshness.Empty++
+			continue
+		}
+		seconds := lag.Seconds()
+		if math.IsNaN(freshness.MinLagSeconds) || seconds < freshness.MinLagSeconds {
+			freshness.MinLagSeconds = seconds
+		}
+		if math.IsNaN(freshness.MaxLagSeconds) || seconds > freshness.MaxLagSeconds {
+			freshness.MaxLagSeconds = seconds
+		}
+	}
+}
+
+// All returns the freshness of each metric, ordered by name. Metrics with no
+// datapoints report lags of zero.
+func (notes *FreshnessNotes) All() []Freshness {
+	if notes == nil {
+		return nil
+	}
+	notes.mutex.Lock()
+	defer notes.mutex.Unlock()
+	names := []string{}
+	for name := range notes.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []Freshness{}
+	for _, name := range names {
+		freshness := *notes.metrics[name]
+		if math.IsNaN(freshness.MinLagSeconds) {
+			freshness.MinLagSeconds = 0
+			freshness.MaxLagSeconds = 0
+		}
+		result = append(result, freshness)
+	}
+	return result
+}
@@ -16,7 +16,11 @@ package function
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/timeseries"
 )
 
 // The Function interface defines a metric function.
@@ -39,6 +43,61 @@ type Groups struct {
 	Collapses bool     // whether to "collapse by" instead of "group by"
 }
 
+// MissingTags returns the tags of the group-by (or collapse-by) clause which
+// are not found in any of the series of the list. Misspelled tags are
+// otherwise silent, since every series then falls into the same group.
+func (groups Groups) MissingTags(list api.SeriesList) []string {
+	if len(list.Series) == 0 {
+		return nil
+	}
+	missing := []string{}
+	for _, tag := range groups.List {
+		found := false
+		for _, series := range list.Series {
+			if series.TagSet.HasKey(tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// CheckGroups adds a note to the context for each tag of the clause which
+// is missing from the series that the named function groups. In strict
+// mode, this is an error instead.
+func CheckGroups(context EvaluationContext, name string, list api.SeriesList, groups Groups) error {
+	missing := groups.MissingTags(list)
+	if len(missing) == 0 {
+		return nil
+	}
+	clause := "group by"
+	if groups.Collapses {
+		clause = "collapse by"
+	}
+	return context.Warn(fmt.Sprintf("%s: none of the %d series has the tag %s named in its %q clause", name, len(list.Series), strings.Join(quoteAll(missing), " or "), clause))
+}
+
+// An AggregateFetcher is an expression, such as a metric fetch, whose series
+// the storage backend may be able to aggregate itself.
+type AggregateFetcher interface {
+	// FetchAggregated fetches the series aggregated by the storage, checking the
+	// groups on behalf of the named function. If the storage can't compute the
+	// aggregation, ok is false and nothing is fetched.
+	FetchAggregated(context EvaluationContext, name string, aggregation timeseries.Aggregation, groups Groups) (list api.SeriesList, ok bool, err error)
+}
+
+func quoteAll(tags []string) []string {
+	quoted := make([]string, len(tags))
+	for i, tag := range tags {
+		quoted[i] = fmt.Sprintf("%q", tag)
+	}
+	return quoted
+}
+
 // MetricFunction holds a generic function object with information about its parameters.
 type MetricFunction struct {
 	FunctionName  string // Name is the name of the function, used in its registration.
@@ -47,6 +106,7 @@ type MetricFunction struct {
 	AllowsGroupBy bool   // Whether the function allows a 'group by' clause.
 	Compute       func(EvaluationContext, []Expression, Groups) (Value, error)
 	Widen         func(WidestMode, []Expression) time.Time // Optional; returns new Earliest
+	Pushdown      timeseries.Aggregation                   // Optional; the aggregation which the storage may compute in place of the function, when its only argument is a metric
 }
 
 // Name returns the MetricFunction's name.
@@ -0,0 +1,164 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/square/metrics/log"
+)
+
+var (
+	evaluatorGoroutines int64 // running, across all queries
+	leakedGoroutines    int64 // found running after their query finished, since the process started
+)
+
+// EvaluatorGoroutines returns the number of goroutines evaluating queries.
+func EvaluatorGoroutines() int64 {
+	return atomic.LoadInt64(&evaluatorGoroutines)
+}
+
+// LeakedGoroutines returns the number of evaluator goroutines which have been
+// found running after their query finished.
+func LeakedGoroutines() int64 {
+	return atomic.LoadInt64(&leakedGoroutines)
+}
+
+// GoroutineCounter limits and tracks the goroutines evaluating a query in a
+// thread-safe manner. Once the limit is reached, work is run on the calling
+// goroutine instead, so the query still completes, only with less
+// concurrency. The zero GoroutineCounter has no limit, and doesn't track
+// its goroutines for leaks.
+type GoroutineCounter struct {
+	state *goroutineState
+	limit int
+}
+
+type goroutineState struct {
+	mutex   sync.Mutex
+	next    int
+	running map[int][]uintptr // the stacks which spawned the running goroutines
+	inline  int               // how many times the limit was reached
+}
+
+// NewGoroutineCounter creates a GoroutineCounter with the given limit on the
+// goroutines running at once. A limit of 0 tracks them, but never refuses.
+func NewGoroutineCounter(limit int) GoroutineCounter {
+	return GoroutineCounter{
+		state: &goroutineState{running: map[int][]uintptr{}},
+		limit: limit,
+	}
+}
+
+// Limit returns the number of goroutines allowed at once by this counter.
+func (c GoroutineCounter) Limit() int {
+	return c.limit
+}
+
+// Running returns the number of goroutines which are running.
+func (c GoroutineCounter) Running() int {
+	if c.state == nil {
+		return 0
+	}
+	c.state.mutex.Lock()
+	defer c.state.mutex.Unlock()
+	return len(c.state.running)
+}
+
+// Inline returns the number of times work was run on the calling goroutine,
+// because the limit had been reached.
+func (c GoroutineCounter) Inline() int {
+	if c.state == nil {
+		return 0
+	}
+	c.state.mutex.Lock()
+	defer c.state.mutex.Unlock()
+	return c.state.inline
+}
+
+// Go runs the work on a new goroutine, or on the calling goroutine if the
+// limit has been reached.
+func (c GoroutineCounter) Go(work func()) {
+	if c.state == nil {
+		atomic.AddInt64(&evaluatorGoroutines, 1)
+		go func() {
+			defer atomic.AddInt64(&evaluatorGoroutines, -1)
+			work()
+		}()
+		return
+	}
+	c.state.mutex.Lock()
+	if c.limit > 0 && len(c.state.running) >= c.limit {
+		c.state.inline++
+		c.state.mutex.Unlock()
+		work()
+		return
+	}
+	id := c.state.next
+	c.state.next++
+	stack := make([]uintptr, 32)
+	c.state.running[id] = stack[:runtime.Callers(2, stack)]
+	c.state.mutex.Unlock()
+	atomic.AddInt64(&evaluatorGoroutines, 1)
+	go func() {
+		defer func() {
+			atomic.AddInt64(&evaluatorGoroutines, -1)
+			c.state.mutex.Lock()
+			delete(c.state.running, id)
+			c.state.mutex.Unlock()
+		}()
+		work()
+	}()
+}
+
+// WatchLeaks should be called once the query has finished. Goroutines still
+// running after the grace period (such as those stuck on a stalled backend)
+// are logged, with the stack which spawned them.
+func (c GoroutineCounter) WatchLeaks(grace time.Duration) {
+	if c.state == nil {
+		return
+	}
+	time.AfterFunc(grace, func() {
+		for _, stack := range c.leaks() {
+			atomic.AddInt64(&leakedGoroutines, 1)
+			log.Warningf("An evaluator goroutine is still running %s after its query finished. It was spawned at:\n%s", grace, stack)
+		}
+	})
+}
+
+// leaks returns the formatted stacks which spawned the running goroutines.
+func (c GoroutineCounter) leaks() []string {
+	c.state.mutex.Lock()
+	defer c.state.mutex.Unlock()
+	stacks := []string{}
+	for _, stack := range c.state.running {
+		lines := []string{}
+		frames := runtime.CallersFrames(stack)
+		for {
+			frame, more := frames.Next()
+			lines = append(lines, fmt.Sprintf("%s\n\t%s:%d", frame.Function, frame.File, frame.Line))
+			if !more {
+				break
+			}
+		}
+		stacks = append(stacks, strings.Join(lines, "\n"))
+	}
+	return stacks
+}
@@ -0,0 +1,146 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/square/metrics/api"
+)
+
+// HistogramBoundTag tags the buckets of a cumulative histogram with their
+// upper bound, as Prometheus does: each counts the values at most its bound,
+// and the last is "+Inf".
+const HistogramBoundTag = "le"
+
+// A Histogram holds the cumulative counts of the buckets of a histogram at
+// each time.
+type Histogram struct {
+	Lower  float64     // the lower edge of the first bucket
+	Bounds []float64   // the upper bounds of the buckets, in increasing order
+	Counts [][]float64 // Counts[i][t] is the number of values at most Bounds[i] at the t-th time
+}
+
+// NewHistogram merges series of bucket counts into one histogram, adding the
+// counts of buckets with the same bound (such as those of several hosts).
+// The buckets are either cumulative and tagged with `le`, or count only their
+// own values and are tagged with `upper` (and optionally `lower`), as are the
+// buckets of a distribution.
+func NewHistogram(list []api.Timeseries) (Histogram, error) {
+	if len(list) == 0 {
+		return Histogram{}, nil
+	}
+	_, cumulative := list[0].TagSet[HistogramBoundTag]
+	boundTag := HistogramBoundTag
+	if !cumulative {
+		boundTag = "upper"
+	}
+	counts := map[float64][]float64{}
+	lower := math.Inf(1)
+	for _, series := range list {
+		raw, ok := series.TagSet[boundTag]
+		if !ok {
+			return Histogram{}, fmt.Errorf("the series %s isn't a histogram bucket, since it has no `%s` tag", series.TagSet.Serialize(), HistogramBoundTag)
+		}
+		bound, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(bound) {
+			return Histogram{}, fmt.Errorf("the series %s has an invalid bucket bound `%s`", series.TagSet.Serialize(), raw)
+		}
+		if !cumulative {
+			if raw, ok := series.TagSet["lower"]; ok {
+				if edge, err := strconv.ParseFloat(raw, 64); err == nil && edge < lower {
+					lower = edge
+				}
+			}
+		}
+		sum, ok := counts[bound]
+		if !ok {
+			sum = make([]float64, len(series.Values))
+			counts[bound] = sum
+		}
+		for t, value := range series.Values {
+			if t < len(sum) && !math.IsNaN(value) {
+				sum[t] += value
+			}
+		}
+	}
+	histogram := Histogram{Bounds: make([]float64, 0, len(counts))}
+	for bound := range counts {
+		histogram.Bounds = append(histogram.Bounds, bound)
+	}
+	sort.Float64s(histogram.Bounds)
+	histogram.Counts = make([][]float64, len(histogram.Bounds))
+	for i, bound := range histogram.Bounds {
+		histogram.Counts[i] = counts[bound]
+		if i == 0 {
+			continue
+		}
+		for t := range histogram.Counts[i] {
+			if t >= len(histogram.Counts[i-1]) {
+				break
+			}
+			if !cumulative {
+				histogram.Counts[i][t] += histogram.Counts[i-1][t]
+			}
+			// Counts which aren't monotonic (such as the buckets of counters
+			// scraped at slightly different times) are corrected upwards.
+			histogram.Counts[i][t] = math.Max(histogram.Counts[i][t], histogram.Counts[i-1][t])
+		}
+	}
+	// As in Prometheus, the first bucket starts from 0 unless it holds negative values.
+	histogram.Lower = 0
+	if !math.IsInf(lower, 1) {
+		histogram.Lower = lower
+	} else if histogram.Bounds[0] <= 0 {
+		histogram.Lower = histogram.Bounds[0]
+	}
+	return histogram, nil
+}
+
+// Quantile estimates the q-th quantile (from 0 to 1) of the values at the t-th
+// time, assuming that they're spread evenly through the bucket holding it.
+// Quantiles in the last bucket, if it's unbounded, are its lower edge. It's
+// NaN if there are no values.
+func (histogram Histogram) Quantile(q float64, t int) float64 {
+	buckets := len(histogram.Bounds)
+	if buckets == 0 || t >= len(histogram.Counts[buckets-1]) {
+		return math.NaN()
+	}
+	total := histogram.Counts[buckets-1][t]
+	if !(total > 0) {
+		return math.NaN()
+	}
+	rank := q * total
+	bucket := sort.Search(buckets, func(i int) bool { return histogram.Counts[i][t] >= rank })
+	if bucket == buckets {
+		bucket = buckets - 1
+	}
+	lower, below := histogram.Lower, 0.0
+	if bucket > 0 {
+		lower, below = histogram.Bounds[bucket-1], histogram.Counts[bucket-1][t]
+	}
+	upper := histogram.Bounds[bucket]
+	if math.IsInf(upper, 1) {
+		return lower
+	}
+	inBucket := histogram.Counts[bucket][t] - below
+	if inBucket <= 0 {
+		return upper
+	}
+	return lower + (upper-lower)*(rank-below)/inBucket
+}
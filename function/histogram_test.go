@@ -0,0 +1,64 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"math"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	a := assert.New(t)
+	// Two hosts' cumulative buckets, the second missing the 0.5 bucket.
+	histogram, err := NewHistogram([]api.Timeseries{
+		{Values: []float64{2, 0, 0}, TagSet: api.TagSet{"host": "a", "le": "0.5"}},
+		{Values: []float64{6, 0, 3}, TagSet: api.TagSet{"host": "a", "le": "1"}},
+		{Values: []float64{10, 0, 2}, TagSet: api.TagSet{"host": "a", "le": "+Inf"}},
+		{Values: []float64{4, 0, math.NaN()}, TagSet: api.TagSet{"host": "b", "le": "1"}},
+		{Values: []float64{10, 0, math.NaN()}, TagSet: api.TagSet{"host": "b", "le": "+Inf"}},
+	})
+	a.CheckError(err)
+	a.Eq(histogram.Bounds, []float64{0.5, 1, math.Inf(1)})
+	// The count of the last bucket at the third time is corrected upwards.
+	a.Eq(histogram.Counts, [][]float64{{2, 0, 0}, {10, 0, 3}, {20, 0, 3}})
+	a.EqFloat(histogram.Lower, 0, 0)
+
+	a.EqFloat(histogram.Quantile(0, 0), 0, 1e-9)
+	a.EqFloat(histogram.Quantile(0.05, 0), 0.25, 1e-9) // halfway through the first bucket
+	a.EqFloat(histogram.Quantile(0.3, 0), 0.75, 1e-9)  // halfway through the second
+	a.EqFloat(histogram.Quantile(0.5, 0), 1, 1e-9)     // the top of the second
+	a.EqFloat(histogram.Quantile(0.9, 0), 1, 1e-9)     // in the unbounded bucket
+	a.EqBool(math.IsNaN(histogram.Quantile(0.5, 1)), true)
+	a.EqFloat(histogram.Quantile(0.5, 2), 0.75, 1e-9)
+
+	// The buckets of a distribution count only their own values.
+	histogram, err = NewHistogram([]api.Timeseries{
+		{Values: []float64{1}, TagSet: api.TagSet{"lower": "-10", "upper": "0"}},
+		{Values: []float64{3}, TagSet: api.TagSet{"lower": "0", "upper": "10"}},
+	})
+	a.CheckError(err)
+	a.EqFloat(histogram.Lower, -10, 0)
+	a.Eq(histogram.Counts, [][]float64{{1}, {4}})
+	a.EqFloat(histogram.Quantile(0.125, 0), -5, 1e-9)
+	a.EqFloat(histogram.Quantile(0.625, 0), 5, 1e-9)
+
+	_, err = NewHistogram([]api.Timeseries{{Values: []float64{1}, TagSet: api.TagSet{"host": "a"}}})
+	a.EqString(err.Error(), "the series host=a isn't a histogram bucket, since it has no `le` tag")
+	_, err = NewHistogram([]api.Timeseries{{Values: []float64{1}, TagSet: api.TagSet{"le": "big"}}})
+	a.EqString(err.Error(), "the series le=big has an invalid bucket bound `big`")
+}
@@ -30,6 +30,8 @@ const (
 	InvalidOption OptionName = iota // InvalidOption represents an invalid option
 	WidenBy                         // WidenBy indicates that the given duration Argument index, or the number of Slots should be used to extend the timerange in the query into the past by the given amount.
 	ShiftBy                         // ShiftBy indicates that the given duration Argument index, or the number of Slots should be used to shift the timerange in the query (positive is forward in time into the future, negative is backward in time to the past)
+	NonNegative                     // NonNegative indicates that the scalar or duration at the given Argument index cannot be negative.
+	Positive                        // Positive indicates that the scalar or duration at the given Argument index must be greater than zero.
 )
 
 // String makes the option name human-readable.
@@ -39,6 +41,10 @@ func (n OptionName) String() string {
 		return "WidenBy"
 	case ShiftBy:
 		return "ShiftBy"
+	case NonNegative:
+		return "NonNegative"
+	case Positive:
+		return "Positive"
 	default:
 		return "Invalid"
 	}
@@ -56,6 +62,10 @@ type Argument int
 // Slot represents a number of slots
 type Slot int
 
+// ArgumentPlusSlot represents the duration at an argument index, which may be
+// omitted, plus one slot
+type ArgumentPlusSlot int
+
 // MakeFunction is a convenient way to use type-safe functions to
 // construct MetricFunctions without manually checking parameters.
 func MakeFunction(name string, function interface{}, options ...Option) MetricFunction {
@@ -83,6 +93,8 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 	requiredArgumentCount := 0
 	optionalArgumentCount := 0
 	allowsGroupBy := false
+	// argumentInputs maps the index of each query argument to the index of the function parameter that receives it.
+	argumentInputs := []int{}
 	for i := 0; i < funcType.NumIn(); i++ {
 		argType := funcType.In(i)
 		switch argType {
@@ -97,9 +109,11 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 				panic(fmt.Sprintf("MakeFunction for function `%s` has non-optional arguments after optional ones.", name))
 			}
 			requiredArgumentCount++
+			argumentInputs = append(argumentInputs, i)
 		case reflect.PtrTo(stringType), reflect.PtrTo(scalarType), reflect.PtrTo(scalarSetType), reflect.PtrTo(durationType), reflect.PtrTo(timeseriesType), reflect.PtrTo(valueType), reflect.PtrTo(expressionType):
 			// An optional argument
 			optionalArgumentCount++
+			argumentInputs = append(argumentInputs, i)
 		default:
 			panic(fmt.Sprintf("MakeFunction for function `%s` function argument asks for unsupported type: cannot supply argument %d of type %+v.", name, i, argType))
 		}
@@ -107,6 +121,13 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 	// The function has been checked and inspected.
 	// Now, generate the corresponding MetricFunction.
 
+	// constraints holds the range checks requested through the NonNegative and Positive options.
+	type constraint struct {
+		index    int
+		positive bool
+	}
+	constraints := []constraint{}
+
 	resultFunction := MetricFunction{
 		FunctionName:  name,
 		MinArguments:  requiredArgumentCount,
@@ -115,38 +136,61 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 		// Compute does a lot of reflection to get this to work.
 		Compute: func(context EvaluationContext, arguments []Expression, groups Groups) (Value, error) {
 
-			// nextArgument will extract the next argument from the expression list `arguments`.
+			// nextArgument will extract the next argument from the expression list `arguments`, along with its index.
 			// if there are not more to return, it will return nil.
 			expressionArgument := 0
-			nextArgument := func() Expression {
+			nextArgument := func() (Expression, int) {
 				if expressionArgument >= len(arguments) {
-					return nil
+					return nil, expressionArgument
 				}
 				arg := arguments[expressionArgument]
 				expressionArgument++
-				return arg
+				return arg, expressionArgument - 1
 			}
 
 			// evalTo takes an expression and a reflect.Type and evaluates to the appropriate type.
 			// If an Expression is requested, it just returns it.
-			evalTo := func(expression Expression, resultType reflect.Type) (interface{}, error) {
-				switch resultType {
-				case expressionType:
+			// If the value has the wrong type, the result is an ArgumentError for the given argument index.
+			evalTo := func(index int, expression Expression, resultType reflect.Type) (interface{}, error) {
+				if resultType == expressionType {
 					return expression, nil
+				}
+				value, err := expression.Evaluate(context)
+				if err != nil {
+					return nil, err
+				}
+				var result interface{}
+				var convErr *ConversionFailure
+				switch resultType {
 				case stringType:
-					return EvaluateToString(expression, context)
+					result, convErr = value.ToString()
 				case scalarType:
-					return EvaluateToScalar(expression, context)
+					result, convErr = value.ToScalar()
 				case scalarSetType:
-					return EvaluateToScalarSet(expression, context)
+					result, convErr = value.ToScalarSet()
 				case durationType:
-					return EvaluateToDuration(expression, context)
+					result, convErr = value.ToDuration()
 				case timeseriesType:
-					return EvaluateToSeriesList(expression, context)
+					result, convErr = value.ToSeriesList(context.Timerange())
 				case valueType:
-					return expression.Evaluate(context)
+					return value, nil
+				default:
+					panic(fmt.Sprintf("Unreachable :: Attempting to evaluate to unknown type %+v", resultType))
+				}
+				if convErr != nil {
+					return nil, ArgumentError{
+						Name:     name,
+						Index:    index,
+						Expected: "a " + typeNames[resultType],
+						Actual:   expression.ExpressionDescription(StringQuery()),
+					}
+				}
+				if list, ok := result.(api.SeriesList); ok && len(list.Series) == 0 && context.Strict() {
+					return nil, StrictError{
+						Message: fmt.Sprintf("%s: argument %d (%s) has no series", name, index+1, expression.ExpressionDescription(StringQuery())),
+					}
 				}
-				panic(fmt.Sprintf("Unreachable :: Attempting to evaluate to unknown type %+v", resultType))
+				return result, nil
 			}
 
 			// argumentFuncs holds functions to obtain the Value arguments.
@@ -181,17 +225,17 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 				case groupsType:
 					argumentFuncs[i] = provideValue(groups)
 				case stringType, scalarType, scalarSetType, durationType, timeseriesType, valueType, expressionType:
-					arg := nextArgument()
+					arg, index := nextArgument()
 					argumentFuncs[i] = func() (interface{}, error) {
-						return evalTo(arg, argType)
+						return evalTo(index, arg, argType)
 					}
 				case reflect.PtrTo(stringType), reflect.PtrTo(scalarType), reflect.PtrTo(scalarSetType), reflect.PtrTo(durationType), reflect.PtrTo(timeseriesType), reflect.PtrTo(valueType), reflect.PtrTo(expressionType):
-					arg := nextArgument()
+					arg, index := nextArgument()
 					if arg == nil {
 						argumentFuncs[i] = provideZeroValue(argType)
 					} else {
 						argumentFuncs[i] = func() (interface{}, error) {
-							resultI, err := evalTo(arg, argType.Elem())
+							resultI, err := evalTo(index, arg, argType.Elem())
 							if err != nil {
 								return nil, err
 							}
@@ -211,7 +255,7 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 			for i := range argValues {
 				i := i
 				waiter.Add(1)
-				go func() {
+				context.Go(func() {
 					defer waiter.Done()
 					arg, err := argumentFuncs[i]()
 					if err != nil {
@@ -219,7 +263,7 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 						return
 					}
 					argValues[i] = reflect.ValueOf(arg)
-				}()
+				})
 			}
 			waiter.Wait() // Wait for all the arguments to be evaluated.
 
@@ -227,6 +271,39 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 				return nil, <-errors
 			}
 
+			// Check the ranges of arguments which were given.
+			for _, constraint := range constraints {
+				if constraint.index >= len(arguments) {
+					continue
+				}
+				argValue := argValues[argumentInputs[constraint.index]]
+				if argValue.Kind() == reflect.Ptr {
+					if argValue.IsNil() {
+						continue
+					}
+					argValue = argValue.Elem()
+				}
+				number := 0.0
+				switch value := argValue.Interface().(type) {
+				case float64:
+					number = value
+				case time.Duration:
+					number = float64(value)
+				}
+				if number < 0 || (constraint.positive && number == 0) {
+					qualifier := "non-negative"
+					if constraint.positive {
+						qualifier = "positive"
+					}
+					return nil, ArgumentError{
+						Name:     name,
+						Index:    constraint.index,
+						Expected: fmt.Sprintf("a %s %s", qualifier, typeNames[argValue.Type()]),
+						Actual:   arguments[constraint.index].ExpressionDescription(StringQuery()),
+					}
+				}
+			}
+
 			output := funcValue.Call(argValues)
 
 			if len(output) == 2 && output[1].Interface() != nil {
@@ -266,18 +343,7 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 			case Argument:
 				resultFunction.Widen = func(widen WidestMode, arguments []Expression) time.Time {
 					result := widen.Current
-					if int(value) >= len(arguments) {
-						return result
-					}
-					literalInterface, ok := arguments[int(value)].(LiteralExpression)
-					if !ok {
-						return result
-					}
-					literalValue := literalInterface.Literal()
-					if literalValue == nil {
-						return result
-					}
-					duration, ok := literalValue.(time.Duration)
+					duration, ok := literalDuration(arguments, int(value))
 					if !ok {
 						return result
 					}
@@ -292,9 +358,34 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 					widen.AddTime(widen.Current.Add(-widen.Resolution))
 					return widen.Current
 				}
+			case ArgumentPlusSlot:
+				if option.Name != WidenBy {
+					panic(fmt.Sprintf("MakeFunction for function `%s` given option %s with value %v of type %T; it can only widen", name, option.Name, option.Value, option.Value))
+				}
+				resultFunction.Widen = func(widen WidestMode, arguments []Expression) time.Time {
+					duration, _ := literalDuration(arguments, int(value))
+					widen.AddTime(widen.Current.Add(-widen.Resolution - duration))
+					return widen.Current
+				}
 			default:
-				panic(fmt.Sprintf("MakeFunction for function `%s` given option %s with value %v of unsupported type %T; must be either function.Argument or function.Slot", name, option.Name, option.Value, option.Value))
+				panic(fmt.Sprintf("MakeFunction for function `%s` given option %s with value %v of unsupported type %T; must be function.Argument, function.Slot or function.ArgumentPlusSlot", name, option.Name, option.Value, option.Value))
+			}
+		case NonNegative, Positive:
+			index, ok := option.Value.(Argument)
+			if !ok {
+				panic(fmt.Sprintf("MakeFunction for function `%s` given option %s with value %v of unsupported type %T; must be function.Argument", name, option.Name, option.Value, option.Value))
 			}
+			if int(index) < 0 || int(index) >= len(argumentInputs) {
+				panic(fmt.Sprintf("MakeFunction for function `%s` given option %s for argument %d, but it only has %d arguments", name, option.Name, index, len(argumentInputs)))
+			}
+			argType := funcType.In(argumentInputs[index])
+			if argType.Kind() == reflect.Ptr {
+				argType = argType.Elem()
+			}
+			if argType != scalarType && argType != durationType {
+				panic(fmt.Sprintf("MakeFunction for function `%s` given option %s for argument %d of type %+v; must be a scalar or duration", name, option.Name, index, argType))
+			}
+			constraints = append(constraints, constraint{index: int(index), positive: option.Name == Positive})
 		default:
 			panic(fmt.Sprintf("MakeFunction for function `%s` given unrecognized option %s (with argument %v)", name, option.Name, option.Value))
 		}
@@ -303,6 +394,24 @@ func MakeFunction(name string, function interface{}, options ...Option) MetricFu
 	return resultFunction
 }
 
+// literalDuration returns the duration given as a literal at the argument
+// index, if there is one.
+func literalDuration(arguments []Expression, index int) (time.Duration, bool) {
+	if index >= len(arguments) {
+		return 0, false
+	}
+	literalInterface, ok := arguments[index].(LiteralExpression)
+	if !ok {
+		return 0, false
+	}
+	literalValue := literalInterface.Literal()
+	if literalValue == nil {
+		return 0, false
+	}
+	duration, ok := literalValue.(time.Duration)
+	return duration, ok
+}
+
 var stringType = reflect.TypeOf("")
 var scalarType = reflect.TypeOf(float64(0.0))
 var scalarSetType = reflect.TypeOf(ScalarSet{})
@@ -315,3 +424,12 @@ var contextType = reflect.TypeOf(EvaluationContext{})
 var timerangeType = reflect.TypeOf(api.Timerange{})
 
 var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// typeNames describe argument types in error messages.
+var typeNames = map[reflect.Type]string{
+	stringType:     "string",
+	scalarType:     "scalar",
+	scalarSetType:  "scalar set",
+	durationType:   "duration",
+	timeseriesType: "series list",
+}
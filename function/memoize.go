@@ -53,7 +53,7 @@ func (m *memoization) evaluate(e ActualExpression, context EvaluationContext) (V
 		return e.ActualEvaluate(context)
 	}
 	m.Lock()
-	memoIdentity := e.ExpressionDescription(StringMemoization)
+	memoIdentity := e.ExpressionDescription(StringMemoization())
 	ptr, ok := m.memoized[memoIdentity]
 	if !ok {
 		ptr = new(memoized)
@@ -80,6 +80,16 @@ func Memoize(expression ActualExpression) Expression {
 	return memoizedExpression{Expression: expression}
 }
 
+// Unmemoize returns the expression wrapped by Memoize. If the given expression
+// is not memoized, ok is false.
+func Unmemoize(expression Expression) (actual ActualExpression, ok bool) {
+	memoized, ok := expression.(memoizedExpression)
+	if !ok {
+		return nil, false
+	}
+	return memoized.Expression, true
+}
+
 // Literal exposes the underlying Expression's literal
 func (m memoizedExpression) Literal() interface{} {
 	literalExpression, ok := m.Expression.(LiteralExpression)
@@ -0,0 +1,58 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+// countedExpression counts its evaluations. Its memoization identity
+// ignores its label, as annotations' do.
+type countedExpression struct {
+	label       string
+	evaluations *int32
+}
+
+func (e countedExpression) ActualEvaluate(context EvaluationContext) (Value, error) {
+	atomic.AddInt32(e.evaluations, 1)
+	return ScalarValue(1), nil
+}
+
+func (e countedExpression) ExpressionDescription(mode DescriptionMode) string {
+	if mode == StringMemoization() {
+		return "counted"
+	}
+	return "counted {" + e.label + "}"
+}
+
+func TestMemoize(t *testing.T) {
+	a := assert.New(t)
+	evaluations := new(int32)
+	context := EvaluationContextBuilder{}.Build()
+	expressions := []Expression{
+		Memoize(countedExpression{label: "a", evaluations: evaluations}),
+		Memoize(countedExpression{label: "b", evaluations: evaluations}),
+		Memoize(countedExpression{label: "a", evaluations: evaluations}),
+	}
+	values, err := EvaluateMany(context, expressions)
+	a.CheckError(err)
+	a.EqInt(len(values), 3)
+	// The expressions are evaluated once, since they're memoized by their
+	// memoization identity rather than their description.
+	a.EqInt(int(atomic.LoadInt32(evaluations)), 1)
+}
@@ -18,101 +18,158 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/square/metrics/api"
 	"github.com/square/metrics/function"
 	"github.com/square/metrics/function/builtin/aggregate"
+	"github.com/square/metrics/function/builtin/conditional"
 	"github.com/square/metrics/function/builtin/filter"
+	"github.com/square/metrics/function/builtin/find"
 	"github.com/square/metrics/function/builtin/forecast"
+	"github.com/square/metrics/function/builtin/histogram"
 	"github.com/square/metrics/function/builtin/join"
+	"github.com/square/metrics/function/builtin/mask"
+	"github.com/square/metrics/function/builtin/sketch"
 	"github.com/square/metrics/function/builtin/summary"
 	"github.com/square/metrics/function/builtin/tag"
 	"github.com/square/metrics/function/builtin/transform"
+	"github.com/square/metrics/timeseries"
 )
 
-func init() {
+// registerBuiltins adds the functions of MQE to the builder.
+func registerBuiltins(b *Builder) {
 	// Arithmetic operators
-	MustRegister(NewOperator("+", func(x float64, y float64) float64 { return x + y }))
-	MustRegister(NewOperator("-", func(x float64, y float64) float64 { return x - y }))
-	MustRegister(NewOperator("*", func(x float64, y float64) float64 { return x * y }))
-	MustRegister(NewOperator("/", func(x float64, y float64) float64 { return x / y }))
+	b.MustRegister(NewOperator("+", func(x float64, y float64) float64 { return x + y }))
+	b.MustRegister(NewOperator("-", func(x float64, y float64) float64 { return x - y }))
+	b.MustRegister(NewOperator("*", func(x float64, y float64) float64 { return x * y }))
+	b.MustRegister(NewOperator("/", func(x float64, y float64) float64 { return x / y }))
+	// Comparisons and logical operators, whose results are boolean series
+	b.MustRegister(NewOperator(">", boolean(func(x float64, y float64) bool { return x > y })))
+	b.MustRegister(NewOperator("<", boolean(func(x float64, y float64) bool { return x < y })))
+	b.MustRegister(NewOperator(">=", boolean(func(x float64, y float64) bool { return x >= y })))
+	b.MustRegister(NewOperator("<=", boolean(func(x float64, y float64) bool { return x <= y })))
+	b.MustRegister(NewOperator("==", boolean(func(x float64, y float64) bool { return x == y })))
+	b.MustRegister(NewOperator("!=", boolean(func(x float64, y float64) bool { return x != y })))
+	b.MustRegister(NewOperator("and", boolean(func(x float64, y float64) bool { return x != 0 && y != 0 })))
+	b.MustRegister(NewOperator("or", boolean(func(x float64, y float64) bool { return x != 0 || y != 0 })))
+	b.MustRegister(NewOperator("unless", boolean(func(x float64, y float64) bool { return x != 0 && y == 0 })))
+	// Arithmetic which explains series that failed to join
+	b.MustRegister(NewDiagnosedOperator("ratio", func(x float64, y float64) float64 { return x / y }, "numerator", "denominator"))
+	b.MustRegister(NewDiagnosedOperator("residual", func(x float64, y float64) float64 { return x - y }, "actual", "expected"))
 	// Aggregates
-	MustRegister(NewAggregate("aggregate.max", aggregate.Max))
-	MustRegister(NewAggregate("aggregate.min", aggregate.Min))
-	MustRegister(NewAggregate("aggregate.mean", aggregate.Mean))
-	MustRegister(NewAggregate("aggregate.sum", aggregate.Sum))
-	MustRegister(NewAggregate("aggregate.total", aggregate.Total))
-	MustRegister(NewAggregate("aggregate.count", aggregate.Count))
+	b.MustRegister(NewAggregate("aggregate.max", aggregate.Max))
+	b.MustRegister(NewAggregate("aggregate.min", aggregate.Min))
+	b.MustRegister(NewPushdownAggregate("aggregate.mean", timeseries.AggregateMean, aggregate.Mean))
+	b.MustRegister(RescaleSampled(NewPushdownAggregate("aggregate.sum", timeseries.AggregateSum, aggregate.Sum)))
+	b.MustRegister(RescaleSampled(NewAggregate("aggregate.total", aggregate.Total)))
+	b.MustRegister(RescaleSampled(NewAggregate("aggregate.count", aggregate.Count)))
+	b.MustRegister(NewWeightedMean("aggregate.weighted_mean"))
+	// Sketches
+	b.MustRegister(sketch.Percentile)
+	b.MustRegister(histogram.Quantile)
 	// Transformations
-	MustRegister(transform.Integral)
-	MustRegister(transform.Cumulative)
-	MustRegister(transform.NaNFill)
-	MustRegister(transform.MapMaker("transform.abs", math.Abs))
-	MustRegister(transform.MapMaker("transform.log", math.Log10))
-	MustRegister(transform.NaNKeepLast)
-	MustRegister(transform.Bound)
-	MustRegister(transform.LowerBound)
-	MustRegister(transform.UpperBound)
+	b.MustRegister(transform.Integral)
+	b.MustRegister(transform.Cumulative)
+	b.MustRegister(transform.NaNFill)
+	b.MustRegister(transform.MapMaker("transform.abs", math.Abs))
+	b.MustRegister(transform.Log)
+	b.MustRegister(transform.Exp)
+	b.MustRegister(transform.NaNKeepLast)
+	b.MustRegister(transform.Fill)
+	b.MustRegister(transform.FillForward)
+	b.MustRegister(transform.Interpolate)
+	b.MustRegister(transform.Bound)
+	b.MustRegister(transform.LowerBound)
+	b.MustRegister(transform.UpperBound)
+	b.MustRegister(transform.Clamp)
+	b.MustRegister(transform.Samples)
 
 	// Filter
-	MustRegister(NewFilterCount("filter.highest_mean", aggregate.Mean, false))
-	MustRegister(NewFilterCount("filter.highest_max", aggregate.Max, false))
-	MustRegister(NewFilterCount("filter.highest_min", aggregate.Min, false))
+	b.MustRegister(NewFilterCount("filter.highest_mean", aggregate.Mean, false))
+	b.MustRegister(NewFilterCount("filter.highest_max", aggregate.Max, false))
+	b.MustRegister(NewFilterCount("filter.highest_min", aggregate.Min, false))
 
-	MustRegister(NewFilterCount("filter.lowest_mean", aggregate.Mean, true))
-	MustRegister(NewFilterCount("filter.lowest_max", aggregate.Max, true))
-	MustRegister(NewFilterCount("filter.lowest_min", aggregate.Min, true))
+	b.MustRegister(NewFilterCount("filter.lowest_mean", aggregate.Mean, true))
+	b.MustRegister(NewFilterCount("filter.lowest_max", aggregate.Max, true))
+	b.MustRegister(NewFilterCount("filter.lowest_min", aggregate.Min, true))
 
-	MustRegister(NewFilterThreshold("filter.mean_above", aggregate.Mean, false))
-	MustRegister(NewFilterThreshold("filter.max_above", aggregate.Max, false))
-	MustRegister(NewFilterThreshold("filter.min_above", aggregate.Min, false))
+	b.MustRegister(NewFilterThreshold("filter.mean_above", aggregate.Mean, false))
+	b.MustRegister(NewFilterThreshold("filter.max_above", aggregate.Max, false))
+	b.MustRegister(NewFilterThreshold("filter.min_above", aggregate.Min, false))
 
-	MustRegister(NewFilterThreshold("filter.mean_below", aggregate.Mean, true))
-	MustRegister(NewFilterThreshold("filter.max_below", aggregate.Max, true))
-	MustRegister(NewFilterThreshold("filter.min_below", aggregate.Min, true))
+	b.MustRegister(NewFilterThreshold("filter.mean_below", aggregate.Mean, true))
+	b.MustRegister(NewFilterThreshold("filter.max_below", aggregate.Max, true))
+	b.MustRegister(NewFilterThreshold("filter.min_below", aggregate.Min, true))
+
+	b.MustRegister(transform.TopK)
+	b.MustRegister(transform.BottomK)
+
+	// Threshold crossings
+	b.MustRegister(find.FirstAbove)
+	b.MustRegister(find.LastBelow)
 
 	// Weird ones
-	MustRegister(transform.Derivative)
-	MustRegister(transform.MovingAverage)
-	MustRegister(transform.ExponentialMovingAverage)
-	MustRegister(transform.Rate)
-	MustRegister(transform.Timeshift)
+	b.MustRegister(transform.Derivative)
+	b.MustRegister(transform.MovingAverage)
+	b.MustRegister(transform.ExponentialMovingAverage)
+	b.MustRegister(transform.MovingMax)
+	b.MustRegister(transform.MovingMin)
+	b.MustRegister(transform.Rate)
+	b.MustRegister(transform.Timeshift)
 
 	// Tags
-	MustRegister(tag.DropFunction)
-	MustRegister(tag.SetFunction)
-	MustRegister(tag.CopyFunction)
+	b.MustRegister(tag.DropFunction)
+	b.MustRegister(tag.SetFunction)
+	b.MustRegister(tag.CopyFunction)
+
+	// Conditionals
+	b.MustRegister(conditional.CoalesceFunction)
+	b.MustRegister(conditional.IfFunction)
+
+	// Masks
+	b.MustRegister(mask.BusinessHours)
+	b.MustRegister(mask.Exclude)
 
 	// Forecasting
-	MustRegister(forecast.FunctionRollingMultiplicativeHoltWinters)
-	MustRegister(forecast.FunctionAnomalyRollingMultiplicativeHoltWinters)
-	MustRegister(forecast.FunctionRollingSeasonal)
-	MustRegister(forecast.FunctionAnomalyRollingSeasonal)
-	MustRegister(forecast.FunctionLinear)
+	b.MustRegister(forecast.FunctionRollingMultiplicativeHoltWinters)
+	b.MustRegister(forecast.FunctionAnomalyRollingMultiplicativeHoltWinters)
+	b.MustRegister(forecast.FunctionRollingSeasonal)
+	b.MustRegister(forecast.FunctionAnomalyRollingSeasonal)
+	b.MustRegister(forecast.FunctionHoltWinters)
+	b.MustRegister(forecast.FunctionDeviationBand)
+	b.MustRegister(forecast.FunctionLinear)
 
-	MustRegister(forecast.FunctionDrop)
+	b.MustRegister(forecast.FunctionDrop)
 
 	// Summary
-	MustRegister(summary.Current)
-	MustRegister(summary.Oldest)
-	MustRegister(summary.Mean)
-	MustRegister(summary.Min)
-	MustRegister(summary.Max)
-	MustRegister(summary.Integral)
-	MustRegister(summary.LastNotNaN)
-	MustRegister(summary.FirstNotNaN)
-	MustRegister(summary.Count)
-	MustRegister(summary.Total)
-}
-
-// StandardRegistry of a functions available in MQE.
+	b.MustRegister(summary.Current)
+	b.MustRegister(summary.Oldest)
+	b.MustRegister(summary.Mean)
+	b.MustRegister(summary.Min)
+	b.MustRegister(summary.Max)
+	b.MustRegister(summary.Integral)
+	b.MustRegister(summary.LastNotNaN)
+	b.MustRegister(summary.FirstNotNaN)
+	b.MustRegister(summary.Count)
+	b.MustRegister(summary.Total)
+	b.MustRegister(summary.Availability)
+	b.MustRegister(summary.Freshness)
+	b.MustRegister(summary.Table)
+	b.MustRegister(summary.Distribution)
+}
+
+// StandardRegistry of a functions available in MQE. It is immutable once
+// built, so it may be shared by concurrent queries, and engines with
+// different functions can coexist in one process.
 type StandardRegistry struct {
 	mapping map[string]function.Function
 }
 
-var defaultRegistry = StandardRegistry{mapping: make(map[string]function.Function)}
+var defaultRegistry = Standard().Build()
 
+// Default is the registry of the functions of MQE.
 func Default() StandardRegistry {
 	return defaultRegistry
 }
@@ -134,27 +191,65 @@ func (r StandardRegistry) All() []string {
 	return result
 }
 
-// Register a new function into the registry.
-func (r StandardRegistry) Register(fun function.Function) error {
-	_, ok := r.mapping[fun.Name()]
+// Extend creates a builder holding the functions of the registry, from
+// which a registry with more functions can be built.
+func (r StandardRegistry) Extend() *Builder {
+	b := NewBuilder()
+	for name, fun := range r.mapping {
+		b.mapping[name] = fun
+	}
+	return b
+}
+
+// Builder collects the functions of a registry. It isn't safe for
+// concurrent use; the registries it builds are.
+type Builder struct {
+	mapping map[string]function.Function
+}
+
+// NewBuilder creates a builder with no functions.
+func NewBuilder() *Builder {
+	return &Builder{mapping: make(map[string]function.Function)}
+}
+
+// Standard creates a builder holding the functions of MQE.
+func Standard() *Builder {
+	b := NewBuilder()
+	registerBuiltins(b)
+	return b
+}
+
+// Register a new function into the builder.
+func (b *Builder) Register(fun function.Function) error {
+	_, ok := b.mapping[fun.Name()]
 	if ok {
 		return fmt.Errorf("function %s has already been registered", fun.Name())
 	}
 	if fun.Name() == "" {
 		return fmt.Errorf("empty function name")
 	}
-	r.mapping[fun.Name()] = fun
+	b.mapping[fun.Name()] = fun
 	return nil
 }
 
-// MustRegister adds a new metric function to the global function registry.
-func MustRegister(fun function.Function) {
-	err := defaultRegistry.Register(fun)
+// MustRegister adds a new metric function to the builder, panicking if it can't.
+func (b *Builder) MustRegister(fun function.Function) {
+	err := b.Register(fun)
 	if err != nil {
 		panic(fmt.Sprintf("function %s has failed to register", fun.Name()))
 	}
 }
 
+// Build creates a registry of the functions registered so far. Functions
+// registered later are not added to it.
+func (b *Builder) Build() StandardRegistry {
+	mapping := make(map[string]function.Function, len(b.mapping))
+	for name, fun := range b.mapping {
+		mapping[name] = fun
+	}
+	return StandardRegistry{mapping: mapping}
+}
+
 // Constructor Functions
 
 // NewFilterCount creates a new instance of a filtering function with count limit.
@@ -163,18 +258,14 @@ func NewFilterCount(name string, summary func([]float64) float64, ascending bool
 		name,
 		func(list api.SeriesList, countFloat float64, optionalDuration *time.Duration, timerange api.Timerange) (api.SeriesList, error) {
 			count := int(countFloat + 0.5)
-			if count < 0 {
-				return api.SeriesList{}, fmt.Errorf("expected positive count but got %d", count)
-			}
 			duration := timerange.Duration()
 			if optionalDuration != nil {
 				duration = *optionalDuration
 			}
-			if duration < 0 {
-				return api.SeriesList{}, fmt.Errorf("expected positive recent duration but got %+v", duration)
-			}
 			return filter.ByRecent(list, count, summary, ascending, 1+int(duration/timerange.Resolution())), nil
 		},
+		function.Option{Name: function.NonNegative, Value: function.Argument(1)},
+		function.Option{Name: function.NonNegative, Value: function.Argument(2)},
 	)
 }
 
@@ -187,11 +278,9 @@ func NewFilterThreshold(name string, summary func([]float64) float64, below bool
 			if optionalDuration != nil {
 				duration = *optionalDuration
 			}
-			if duration < 0 {
-				return api.SeriesList{}, fmt.Errorf("expected positive recent duration but got %+v", duration)
-			}
 			return filter.ThresholdByRecent(list, threshold, summary, below, 1+int(duration/timerange.Resolution())), nil
 		},
+		function.Option{Name: function.NonNegative, Value: function.Argument(2)},
 	)
 }
 
@@ -199,19 +288,160 @@ func NewFilterThreshold(name string, summary func([]float64) float64, below bool
 func NewAggregate(name string, aggregator func([]float64) float64) function.MetricFunction {
 	return function.MakeFunction(
 		name,
-		func(seriesList api.SeriesList, groups function.Groups) api.SeriesList {
-			return aggregate.By(seriesList, aggregator, groups.List, groups.Collapses)
+		func(context function.EvaluationContext, seriesList api.SeriesList, groups function.Groups) (api.SeriesList, error) {
+			if err := function.CheckGroups(context, name, seriesList, groups); err != nil {
+				return api.SeriesList{}, err
+			}
+			return aggregate.By(seriesList, aggregator, groups.List, groups.Collapses), nil
+		},
+	)
+}
+
+// NewWeightedMean creates an aggregate of two arguments, values and weights,
+// which are joined like the arguments of an operator. The result is the mean
+// of the values of each group, weighted by the matching weights. Series which
+// join with nothing are described in the evaluation notes.
+func NewWeightedMean(name string) function.MetricFunction {
+	return function.MakeFunction(
+		name,
+		func(context function.EvaluationContext, values api.SeriesList, weights api.SeriesList, groups function.Groups) (api.SeriesList, error) {
+			for _, note := range join.Diagnose(values, weights).Notes(name, "values", "weights") {
+				context.AddNote(note)
+			}
+			joined := join.Join([]api.SeriesList{values, weights})
+			joinedValues := api.SeriesList{Series: make([]api.Timeseries, len(joined.Rows))}
+			joinedWeights := api.SeriesList{Series: make([]api.Timeseries, len(joined.Rows))}
+			for i, row := range joined.Rows {
+				joinedValues.Series[i] = api.Timeseries{Values: row.Row[0].Values, TagSet: row.TagSet}
+				joinedWeights.Series[i] = api.Timeseries{Values: row.Row[1].Values, TagSet: row.TagSet}
+			}
+			if err := function.CheckGroups(context, name, joinedValues, groups); err != nil {
+				return api.SeriesList{}, err
+			}
+			return aggregate.WeightedMeanBy(joinedValues, joinedWeights, groups.List, groups.Collapses), nil
 		},
 	)
 }
 
+// NewPushdownAggregate is like NewAggregate, but when its argument is a metric
+// and the storage backend can compute the aggregation itself, only the
+// aggregated series are fetched.
+func NewPushdownAggregate(name string, aggregation timeseries.Aggregation, aggregator func([]float64) float64) function.MetricFunction {
+	result := NewAggregate(name, aggregator)
+	result.Pushdown = aggregation
+	compute := result.Compute
+	result.Compute = func(context function.EvaluationContext, arguments []function.Expression, groups function.Groups) (function.Value, error) {
+		if len(arguments) == 1 {
+			if actual, ok := function.Unmemoize(arguments[0]); ok {
+				if fetcher, ok := actual.(function.AggregateFetcher); ok {
+					list, ok, err := fetcher.FetchAggregated(context, name, aggregation, groups)
+					if err != nil {
+						return nil, err
+					}
+					if ok {
+						return function.SeriesListValue(list), nil
+					}
+				}
+			}
+		}
+		return compute(context, arguments, groups)
+	}
+	return result
+}
+
+// RescaleSampled makes an additive aggregation of a sampled metric estimate
+// the aggregation of every matching series, by scaling each group up by the
+// fraction of its series which were sampled.
+func RescaleSampled(aggregation function.MetricFunction) function.MetricFunction {
+	compute := aggregation.Compute
+	aggregation.Compute = func(context function.EvaluationContext, arguments []function.Expression, groups function.Groups) (function.Value, error) {
+		value, err := compute(context, arguments, groups)
+		sampling := context.Sampling()
+		if err != nil || sampling == nil || len(arguments) != 1 {
+			return value, err
+		}
+		list, ok := value.(function.SeriesListValue)
+		if !ok {
+			return value, nil
+		}
+		return function.SeriesListValue(sampling.Rescale(function.SampleKey(context, arguments[0]), api.SeriesList(list))), nil
+	}
+	return aggregation
+}
+
+// boolean makes an operator whose results are boolean: 1 where the predicate
+// holds, 0 where it doesn't, and NaN where either value is NaN. Any value
+// other than 0 (and NaN) counts as true.
+func boolean(predicate func(float64, float64) bool) func(float64, float64) float64 {
+	return func(x float64, y float64) float64 {
+		if math.IsNaN(x) || math.IsNaN(y) {
+			return math.NaN()
+		}
+		if predicate(x, y) {
+			return 1
+		}
+		return 0
+	}
+}
+
 // NewOperator creates a new binary operator function.
-// the binary operators display a natural join semantic.
+// the binary operators display a natural join semantic, unless an "on" or
+// "ignoring" clause (given as the group-by clause) names the matched tags.
+// Scalars and durations are combined directly (see function.Arithmetic),
+// so that `30 * 1m` is a duration and `1024 * 1024` is a scalar.
 func NewOperator(op string, operator func(float64, float64) float64) function.Function {
+	return newJoinOperator(op, operator, nil)
+}
+
+// NewDiagnosedOperator creates a binary function which joins its arguments
+// like NewOperator, and also explains the join in the evaluation notes:
+// which series matched nothing on the other side and the tags that stopped
+// them, and which tags only one side has. The names describe the arguments.
+func NewDiagnosedOperator(name string, operator func(float64, float64) float64, leftName string, rightName string) function.Function {
+	return newJoinOperator(name, operator, func(context function.EvaluationContext, left api.SeriesList, right api.SeriesList) {
+		for _, note := range join.Diagnose(left, right).Notes(name, leftName, rightName) {
+			context.AddNote(note)
+		}
+	})
+}
+
+func newJoinOperator(op string, operator func(float64, float64) float64, diagnose func(function.EvaluationContext, api.SeriesList, api.SeriesList)) function.Function {
 	return function.MakeFunction(
 		op,
-		func(leftList api.SeriesList, rightList api.SeriesList, timerange api.Timerange) (api.SeriesList, error) {
-			joined := join.Join([]api.SeriesList{leftList, rightList})
+		func(context function.EvaluationContext, leftExpression function.Expression, rightExpression function.Expression, groups function.Groups) (function.Value, error) {
+			arguments := []function.Expression{leftExpression, rightExpression}
+			values, err := function.EvaluateMany(context, arguments)
+			if err != nil {
+				return nil, err
+			}
+			if result, ok, err := function.Arithmetic(op, values[0], values[1]); ok {
+				return result, err
+			}
+			lists := make([]api.SeriesList, len(values))
+			for i, value := range values {
+				list, convErr := value.ToSeriesList(context.Timerange())
+				if convErr != nil {
+					return nil, function.ArgumentError{
+						Name:     op,
+						Index:    i,
+						Expected: "a series list",
+						Actual:   arguments[i].ExpressionDescription(function.StringQuery()),
+					}
+				}
+				lists[i] = list
+			}
+			var joined join.Result
+			if len(groups.List) == 0 {
+				if diagnose != nil {
+					diagnose(context, lists[0], lists[1])
+				}
+				joined = join.Join(lists)
+			} else {
+				if err := checkMatching(context, op, lists, groups); err != nil {
+					return nil, err
+				}
+				joined = join.JoinMatching(lists[0], lists[1], join.Matching{Tags: groups.List, Ignoring: groups.Collapses})
+			}
 
 			result := make([]api.Timeseries, len(joined.Rows))
 
@@ -225,9 +455,24 @@ func NewOperator(op string, operator func(float64, float64) float64) function.Fu
 				result[i] = api.Timeseries{Values: array, TagSet: row.TagSet}
 			}
 
-			return api.SeriesList{
+			return function.SeriesListValue(api.SeriesList{
 				Series: result,
-			}, nil
+			}), nil
 		},
 	)
 }
+
+// checkMatching warns of the tags of an "on" or "ignoring" clause which
+// neither side of the operator has, since they're most likely misspelled.
+func checkMatching(context function.EvaluationContext, op string, lists []api.SeriesList, groups function.Groups) error {
+	both := api.SeriesList{Series: append(append([]api.Timeseries{}, lists[0].Series...), lists[1].Series...)}
+	missing := groups.MissingTags(both)
+	if len(missing) == 0 {
+		return nil
+	}
+	clause := "on"
+	if groups.Collapses {
+		clause = "ignoring"
+	}
+	return context.Warn(fmt.Sprintf("%s: neither side has the tag %s named in its %q clause", op, strings.Join(missing, " or "), clause))
+}
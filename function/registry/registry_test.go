@@ -28,15 +28,30 @@ var dummyCompute = func(function.EvaluationContext, []function.Expression, funct
 
 func Test_Registry_Default(t *testing.T) {
 	a := assert.New(t)
-	sr := StandardRegistry{mapping: make(map[string]function.Function)}
-	a.Eq(sr.All(), []string{})
-	if err := sr.Register(function.MetricFunction{FunctionName: "foo", Compute: dummyCompute}); err != nil {
+	builder := NewBuilder()
+	a.Eq(builder.Build().All(), []string{})
+	if err := builder.Register(function.MetricFunction{FunctionName: "foo", Compute: dummyCompute}); err != nil {
 		a.CheckError(err)
 	}
-	if err := sr.Register(function.MetricFunction{FunctionName: "bar", Compute: dummyCompute}); err != nil {
+	sr := builder.Build()
+	if err := builder.Register(function.MetricFunction{FunctionName: "bar", Compute: dummyCompute}); err != nil {
 		a.CheckError(err)
 	}
-	a.Eq(sr.All(), []string{"bar", "foo"})
+	a.Eq(builder.Build().All(), []string{"bar", "foo"})
+	a.Eq(sr.All(), []string{"foo"}) // registries don't change once built
+}
+
+func Test_Registry_Extend(t *testing.T) {
+	a := assert.New(t)
+	extended := Default().Extend()
+	if err := extended.Register(function.MetricFunction{FunctionName: "custom.foo", Compute: dummyCompute}); err != nil {
+		a.CheckError(err)
+	}
+	a.EqInt(len(extended.Build().All()), len(Default().All())+1)
+	_, ok := Default().GetFunction("custom.foo")
+	a.EqBool(ok, false)
+	_, ok = extended.Build().GetFunction("aggregate.sum")
+	a.EqBool(ok, true)
 }
 
 func Test_Registry_Error(t *testing.T) {
@@ -48,13 +63,12 @@ func Test_Registry_Error(t *testing.T) {
 		{"duplicate name", function.MetricFunction{FunctionName: "existing", Compute: dummyCompute}},
 	} {
 		a := assert.New(t).Contextf("%s", suite.Name)
-		// set up the standard registry
-		sr := StandardRegistry{mapping: make(map[string]function.Function)}
-		if err := sr.Register(function.MetricFunction{FunctionName: "existing", Compute: dummyCompute}); err != nil {
+		builder := NewBuilder()
+		if err := builder.Register(function.MetricFunction{FunctionName: "existing", Compute: dummyCompute}); err != nil {
 			a.CheckError(err)
 			return
 		}
-		if err := sr.Register(suite.Function); err == nil {
+		if err := builder.Register(suite.Function); err == nil {
 			a.Errorf("Expected error, but got none.")
 		}
 	}
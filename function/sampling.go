@@ -0,0 +1,170 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/square/metrics/api"
+)
+
+// InSample reports whether a series belongs to a sample of the given
+// percentage. The choice depends only on the tagset, so that the same series
+// are sampled by every query.
+func InSample(tagSet api.TagSet, percent float64) bool {
+	hash := fnv.New64a()
+	hash.Write([]byte(tagSet.Serialize()))
+	return float64(hash.Sum64()%10000) < percent*100
+}
+
+// SampledFetch describes how many of the series matched by a fetch were
+// sampled. RelativeError estimates the 95% confidence margin of a rescaled sum
+// of the sample, as a fraction of the sum, assuming that the series are of
+// similar size; it is 1 if none of the series were sampled.
+type SampledFetch struct {
+	Metric        string  `json:"metric"`
+	Matched       int     `json:"matched"`
+	Fetched       int     `json:"fetched"`
+	RelativeError float64 `json:"relative_error"`
+}
+
+// Sampling restricts the fetches of a query to a deterministic sample of the
+// matching series, and remembers the series which matched so that additive
+// aggregations can be scaled back up.
+type Sampling struct {
+	Percent float64 // the percentage of series to fetch, between 0 and 100
+
+	mutex      sync.Mutex
+	population map[string][]api.TagSet // sample key => every matching tagset
+	fetches    map[string]*SampledFetch
+}
+
+// NewSampling creates a sampling of the given percentage.
+func NewSampling(percent float64) *Sampling {
+	return &Sampling{
+		Percent:    percent,
+		population: map[string][]api.TagSet{},
+		fetches:    map[string]*SampledFetch{},
+	}
+}
+
+// SampleKey identifies the fetch performed by the expression in the context.
+func SampleKey(context EvaluationContext, expression Expression) string {
+	return expression.ExpressionDescription(StringMemoization()) + " where " + context.Predicate().Query()
+}
+
+// Sample records the tagsets matched by the keyed fetch of the metric and
+// returns those belonging to the sample.
+func (s *Sampling) Sample(key string, metric string, tagSets []api.TagSet) []api.TagSet {
+	sampled := []api.TagSet{}
+	for _, tagSet := range tagSets {
+		if InSample(tagSet, s.Percent) {
+			sampled = append(sampled, tagSet)
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.population[key]; !ok {
+		s.population[key] = tagSets
+		fetch, ok := s.fetches[metric]
+		if !ok {
+			fetch = &SampledFetch{Metric: metric}
+			s.fetches[metric] = fetch
+		}
+		fetch.Matched += len(tagSets)
+		fetch.Fetched += len(sampled)
+	}
+	return sampled
+}
+
+// Rescale scales each series of an additive aggregation of the keyed fetch by
+// the ratio of matching series to sampled series in its group. Series of a
+// group which had no sampled series are left as they are.
+func (s *Sampling) Rescale(key string, list api.SeriesList) api.SeriesList {
+	s.mutex.Lock()
+	population, ok := s.population[key]
+	s.mutex.Unlock()
+	if !ok {
+		return list
+	}
+	result := api.SeriesList{Series: make([]api.Timeseries, len(list.Series))}
+	for i, series := range list.Series {
+		matched, sampled := 0, 0
+		for _, tagSet := range population {
+			if !inGroup(tagSet, series.TagSet) {
+				continue
+			}
+			matched++
+			if InSample(tagSet, s.Percent) {
+				sampled++
+			}
+		}
+		result.Series[i] = series
+		if sampled == 0 || matched == sampled {
+			continue
+		}
+		scale := float64(matched) / float64(sampled)
+		values := make([]float64, len(series.Values))
+		for j, value := range series.Values {
+			values[j] = value * scale
+		}
+		result.Series[i].Values = values
+	}
+	return result
+}
+
+// inGroup reports whether the tagset has each of the tags of the group.
+func inGroup(tagSet api.TagSet, group api.TagSet) bool {
+	for key, value := range group {
+		if tagSet[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Fetches describes each sampled metric, ordered by name.
+func (s *Sampling) Fetches() []SampledFetch {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	names := []string{}
+	for name := range s.fetches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []SampledFetch{}
+	for _, name := range names {
+		fetch := *s.fetches[name]
+		fetch.RelativeError = samplingError(fetch.Matched, fetch.Fetched)
+		result = append(result, fetch)
+	}
+	return result
+}
+
+// samplingError is the 95% confidence margin of the estimate of a total from
+// a simple random sample of n of N items, relative to the total, when the
+// items vary about as much as their mean.
+func samplingError(N int, n int) float64 {
+	if n == 0 {
+		if N == 0 {
+			return 0
+		}
+		return 1
+	}
+	return 1.96 * math.Sqrt((1-float64(n)/float64(N))/float64(n))
+}
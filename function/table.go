@@ -0,0 +1,142 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/square/metrics/api"
+)
+
+// TableRow is a single row of a Table: a tagset with one value per column.
+type TableRow struct {
+	TagSet api.TagSet
+	Values []float64
+}
+
+// MarshalJSON for TableRow marshals NaN or infinity to null.
+func (row TableRow) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{"tagset":`)
+	tagset, err := json.Marshal(row.TagSet)
+	if err != nil {
+		return nil, err
+	}
+	buffer.Write(tagset)
+	buffer.WriteString(`,"values":[`)
+	for i, value := range row.Values {
+		if i != 0 {
+			buffer.WriteString(",")
+		}
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			buffer.WriteString(`null`)
+		} else {
+			buffer.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+		}
+	}
+	buffer.WriteString("]}")
+	return buffer.Bytes(), nil
+}
+
+// A Table holds rows of tagged values under named columns, such as a summary
+// of several statistics for each series in a list.
+type Table struct {
+	Columns []string   `json:"columns"`
+	Rows    []TableRow `json:"rows"`
+}
+
+// TagKeys returns the sorted union of the tag keys used by the table's rows.
+func (table Table) TagKeys() []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, row := range table.Rows {
+		for key := range row.TagSet {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteCSV writes the table as CSV: one column for each tag key, followed by
+// the table's own columns. Missing tags and NaN values are left empty.
+func (table Table) WriteCSV(writer io.Writer) error {
+	keys := table.TagKeys()
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(append(append([]string{}, keys...), table.Columns...)); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		record := make([]string, 0, len(keys)+len(row.Values))
+		for _, key := range keys {
+			record = append(record, row.TagSet[key])
+		}
+		for _, value := range row.Values {
+			if math.IsNaN(value) {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, strconv.FormatFloat(value, 'g', -1, 64))
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// ToSeriesList is a conversion function.
+func (table Table) ToSeriesList(timerange api.Timerange) (api.SeriesList, *ConversionFailure) {
+	return api.SeriesList{}, &ConversionFailure{"table", "SeriesList"}
+}
+
+// ToString is a conversion function.
+func (table Table) ToString() (string, *ConversionFailure) {
+	return "", &ConversionFailure{"table", "string"}
+}
+
+// ToScalar is a conversion function.
+func (table Table) ToScalar() (float64, *ConversionFailure) {
+	return 0, &ConversionFailure{"table", "scalar"}
+}
+
+// ToScalarSet is a conversion function.
+// A table with a single column is equivalent to a scalar set.
+func (table Table) ToScalarSet() (ScalarSet, *ConversionFailure) {
+	if len(table.Columns) != 1 {
+		return nil, &ConversionFailure{"table", "scalar set"}
+	}
+	result := make(ScalarSet, len(table.Rows))
+	for i, row := range table.Rows {
+		result[i] = TaggedScalar{TagSet: row.TagSet, Value: row.Values[0]}
+	}
+	return result, nil
+}
+
+// ToDuration is a conversion function.
+func (table Table) ToDuration() (time.Duration, *ConversionFailure) {
+	return 0, &ConversionFailure{"table", "duration"}
+}
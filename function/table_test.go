@@ -0,0 +1,57 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package function
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestTable(t *testing.T) {
+	a := assert.New(t)
+	table := Table{
+		Columns: []string{"mean", "max"},
+		Rows: []TableRow{
+			{TagSet: api.TagSet{"host": "a", "dc": "west"}, Values: []float64{1.5, 3}},
+			{TagSet: api.TagSet{"host": "b, c"}, Values: []float64{math.NaN(), 4}},
+		},
+	}
+	var buffer bytes.Buffer
+	a.CheckError(table.WriteCSV(&buffer))
+	a.EqString(buffer.String(), "dc,host,mean,max\nwest,a,1.5,3\n,\"b, c\",,4\n")
+
+	encoded, err := json.Marshal(table)
+	a.CheckError(err)
+	a.EqString(string(encoded), `{"columns":["mean","max"],"rows":[{"tagset":{"dc":"west","host":"a"},"values":[1.5,3]},{"tagset":{"host":"b, c"},"values":[null,4]}]}`)
+
+	if _, err := table.ToScalarSet(); err == nil {
+		a.Errorf("expected a table with two columns not to convert to a scalar set")
+	}
+	table.Columns = table.Columns[:1]
+	for i := range table.Rows {
+		table.Rows[i].Values = table.Rows[i].Values[:1]
+	}
+	scalars, convErr := table.ToScalarSet()
+	if convErr != nil {
+		t.Fatalf("expected a table with one column to convert to a scalar set")
+	}
+	a.EqInt(len(scalars), 2)
+	a.EqFloat(scalars[0].Value, 1.5, 1e-9)
+}
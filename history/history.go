@@ -0,0 +1,189 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history keeps the recent queries of each user, so that the UI can
+// offer them again from any browser.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/archive"
+)
+
+// ErrNotFound is returned for an entry which isn't in the user's history.
+var ErrNotFound = errors.New("history: no such entry")
+
+var userName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Entry is one query run by a user.
+type Entry struct {
+	ID       int       `json:"id"`
+	Query    string    `json:"query"`
+	Time     time.Time `json:"time"`
+	Seconds  float64   `json:"seconds"`
+	Error    string    `json:"error,omitempty"`
+	Favorite bool      `json:"favorite"`
+}
+
+// History holds the last queries of each user, along with the queries they
+// marked as favorites, which are kept however old they are. Each user's
+// history is saved in an object store, as "history/<user>.json", whenever it
+// changes.
+type History struct {
+	limit int
+	store archive.ObjectStore // nil keeps the history in memory only
+	mutex sync.Mutex
+	users map[string][]Entry // the oldest entry first
+}
+
+// New creates a history keeping the last limit queries of each user (100 if
+// limit isn't positive).
+func New(limit int, store archive.ObjectStore) *History {
+	if limit <= 0 {
+		limit = 100
+	}
+	return &History{limit: limit, store: store, users: map[string][]Entry{}}
+}
+
+func key(user string) string {
+	return "history/" + user + ".json"
+}
+
+func (h *History) entries(user string) ([]Entry, error) {
+	if entries, ok := h.users[user]; ok {
+		return entries, nil
+	}
+	if !userName.MatchString(user) {
+		return nil, fmt.Errorf("invalid user name %q", user)
+	}
+	var entries []Entry
+	if h.store != nil {
+		data, err := h.store.Get(key(user))
+		switch {
+		case err == archive.ErrNotFound:
+		case err != nil:
+			return nil, fmt.Errorf("cannot load the history of user %s: %s", user, err.Error())
+		default:
+			if err := json.Unmarshal(data, &entries); err != nil {
+				return nil, fmt.Errorf("cannot load the history of user %s: %s", user, err.Error())
+			}
+		}
+	}
+	h.users[user] = entries
+	return entries, nil
+}
+
+// save replaces the user's entries, unless they can't be stored.
+func (h *History) save(user string, entries []Entry) error {
+	if h.store != nil {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err == nil {
+			err = h.store.Put(key(user), data)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot save the history of user %s: %s", user, err.Error())
+		}
+	}
+	h.users[user] = entries
+	return nil
+}
+
+// Record adds the query to the user's history, dropping their oldest query
+// which isn't a favorite once there are too many. It returns the new entry.
+func (h *History) Record(user string, entry Entry) (Entry, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	entries, err := h.entries(user)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	entry.Favorite = false
+	kept := append(append([]Entry(nil), entries...), entry)
+	count := 0
+	for _, other := range kept {
+		if !other.Favorite {
+			count++
+		}
+	}
+	for i := 0; i < len(kept) && count > h.limit; {
+		if kept[i].Favorite {
+			i++
+			continue
+		}
+		kept = append(kept[:i], kept[i+1:]...)
+		count--
+	}
+	return entry, h.save(user, kept)
+}
+
+// List returns the user's history, the most recent query first.
+func (h *History) List(user string) ([]Entry, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	entries, err := h.entries(user)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Entry, len(entries))
+	for i, entry := range entries {
+		result[len(entries)-1-i] = entry
+	}
+	return result, nil
+}
+
+// Get returns one entry of the user's history.
+func (h *History) Get(user string, id int) (Entry, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	entries, err := h.entries(user)
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+	return Entry{}, ErrNotFound
+}
+
+// SetFavorite marks (or unmarks) one entry of the user's history as a
+// favorite, and returns it.
+func (h *History) SetFavorite(user string, id int, favorite bool) (Entry, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	entries, err := h.entries(user)
+	if err != nil {
+		return Entry{}, err
+	}
+	for i, entry := range entries {
+		if entry.ID != id {
+			continue
+		}
+		changed := append([]Entry(nil), entries...)
+		changed[i].Favorite = favorite
+		return changed[i], h.save(user, changed)
+	}
+	return Entry{}, ErrNotFound
+}
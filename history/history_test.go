@@ -0,0 +1,76 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/square/metrics/archive"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func queries(entries []Entry) []string {
+	result := make([]string, len(entries))
+	for i, entry := range entries {
+		result[i] = entry.Query
+	}
+	return result
+}
+
+func TestHistory(t *testing.T) {
+	a := assert.New(t)
+	directory, err := ioutil.TempDir("", "history")
+	a.CheckError(err)
+	defer os.RemoveAll(directory)
+	store := archive.DirectoryStore{Directory: directory}
+
+	h := New(2, store)
+	for _, query := range []string{"a", "b"} {
+		_, err := h.Record("alice", Entry{Query: query})
+		a.CheckError(err)
+	}
+	favorite, err := h.SetFavorite("alice", 1, true)
+	a.CheckError(err)
+	a.EqBool(favorite.Favorite, true)
+	// The favorite is kept, while the oldest other query is dropped.
+	for _, query := range []string{"c", "d"} {
+		_, err := h.Record("alice", Entry{Query: query})
+		a.CheckError(err)
+	}
+	entries, err := h.List("alice")
+	a.CheckError(err)
+	a.Eq(queries(entries), []string{"d", "c", "a"})
+	a.EqInt(entries[0].ID, 4)
+
+	_, err = h.SetFavorite("alice", 2, true)
+	a.Eq(err, ErrNotFound)
+	entries, err = h.List("bob")
+	a.CheckError(err)
+	a.EqInt(len(entries), 0)
+	_, err = h.Record("../bob", Entry{Query: "a"})
+	a.EqBool(err == nil, false)
+
+	// The history outlives the server.
+	restarted := New(2, store)
+	entry, err := restarted.Get("alice", 1)
+	a.CheckError(err)
+	a.Eq(entry.Query, "a")
+	a.EqBool(entry.Favorite, true)
+	recorded, err := restarted.Record("alice", Entry{Query: "e"})
+	a.CheckError(err)
+	a.EqInt(recorded.ID, 5)
+}
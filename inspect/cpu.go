@@ -0,0 +1,112 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inspect
+
+import (
+	"sync"
+	"time"
+)
+
+// CPUAccounting attributes the CPU time of the process to the queries which
+// are running. Go cannot measure the CPU time of a goroutine (or of the
+// goroutines it starts), so the CPU consumed between any two starts or stops
+// is divided evenly among the queries running in that interval. A query
+// running alone is therefore measured exactly (up to background work such as
+// garbage collection), while concurrent queries receive a fair share.
+type CPUAccounting struct {
+	read    func() time.Duration // the CPU time of the process so far
+	mutex   sync.Mutex
+	last    time.Duration
+	running map[*CPUTimer]struct{}
+}
+
+// NewCPUAccounting creates accounting based on the given measurement of
+// cumulative CPU time.
+func NewCPUAccounting(read func() time.Duration) *CPUAccounting {
+	return &CPUAccounting{
+		read:    read,
+		running: map[*CPUTimer]struct{}{},
+	}
+}
+
+// processCPU accounts for the CPU time consumed by this process.
+var processCPU = NewCPUAccounting(processCPUTime)
+
+// CPUTimer measures the CPU time attributed to a single query.
+type CPUTimer struct {
+	accounting *CPUAccounting
+	used       time.Duration
+	shared     bool
+}
+
+// settle divides the CPU time used since the last start or stop among the
+// running timers. The mutex must be held.
+func (c *CPUAccounting) settle() {
+	now := c.read()
+	delta := now - c.last
+	c.last = now
+	if len(c.running) == 0 || delta <= 0 {
+		return
+	}
+	share := delta / time.Duration(len(c.running))
+	for timer := range c.running {
+		timer.used += share
+		timer.shared = timer.shared || len(c.running) > 1
+	}
+}
+
+// Start begins measuring a query.
+func (c *CPUAccounting) Start() *CPUTimer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.settle()
+	timer := &CPUTimer{accounting: c}
+	c.running[timer] = struct{}{}
+	return timer
+}
+
+// Stop finishes measuring the query, returning its CPU time and whether
+// other queries were running at the same time (so that the time is an
+// apportioned estimate).
+func (t *CPUTimer) Stop() (time.Duration, bool) {
+	c := t.accounting
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.running[t]; ok {
+		c.settle()
+		delete(c.running, t)
+	}
+	return t.used, t.shared
+}
+
+// RecordCPU behaves like Record, but the profile also includes the CPU time
+// attributed to the task.
+func (p *Profiler) RecordCPU(name string) func() {
+	if p == nil {
+		return func() {}
+	}
+	start := p.now()
+	timer := processCPU.Start()
+	return func() {
+		cpu, shared := timer.Stop()
+		p.AddProfile(Profile{
+			Name:      name,
+			Start:     start,
+			Finish:    p.now(),
+			CPU:       cpu,
+			CPUShared: shared,
+		})
+	}
+}
@@ -12,5 +12,14 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package static is dummy package to ensure that this directory is included in godep.
-package static
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package inspect
+
+import "time"
+
+// processCPUTime is unavailable on this platform, so no CPU time is reported.
+func processCPUTime() time.Duration {
+	return 0
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inspect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestCPUAccounting(t *testing.T) {
+	a := assert.New(t)
+	clock := time.Duration(0)
+	accounting := NewCPUAccounting(func() time.Duration {
+		return clock
+	})
+
+	clock += 5 * time.Second // Time used before any queries is not attributed.
+	first := accounting.Start()
+	clock += 2 * time.Second // first runs alone
+	second := accounting.Start()
+	clock += 4 * time.Second // first and second share
+	cpu, shared := first.Stop()
+	a.EqInt(int(cpu), int(4*time.Second))
+	a.EqBool(shared, true)
+	clock += 3 * time.Second // second runs alone
+	cpu, shared = second.Stop()
+	a.EqInt(int(cpu), int(5*time.Second))
+	a.EqBool(shared, true)
+
+	alone := accounting.Start()
+	clock += time.Second
+	cpu, shared = alone.Stop()
+	a.EqInt(int(cpu), int(time.Second))
+	a.EqBool(shared, false)
+
+	// Stopping twice reports the same time.
+	clock += time.Second
+	cpu, _ = alone.Stop()
+	a.EqInt(int(cpu), int(time.Second))
+}
+
+func TestRecordCPU(t *testing.T) {
+	a := assert.New(t)
+	profiler := New()
+	finish := profiler.RecordCPU("busy")
+	total := 0
+	for i := 0; i < 1000000; i++ {
+		total += i % 7
+	}
+	finish()
+	profiles := profiler.All()
+	a.EqInt(len(profiles), 1)
+	a.EqString(profiles[0].Name, "busy")
+	if profiles[0].CPU < 0 {
+		t.Errorf("expected a non-negative CPU time but got %s", profiles[0].CPU)
+	}
+}
@@ -24,6 +24,9 @@ type Profiler struct {
 	now      func() time.Time
 	mutex    sync.Mutex // Since profilers are only ever used as pointers, the mutex is not a pointer.
 	profiles []Profile
+
+	recordsBackends bool             // whether backend requests are kept
+	backends        []BackendRequest // the requests made to the backends, if kept
 }
 
 func New() *Profiler {
@@ -121,13 +124,70 @@ func (p *Profiler) Flush() []Profile {
 
 // A Profile is a single data point collected by the profiler.
 type Profile struct {
-	Name        string    `json:"name"` // name identifies the measured quantity ("fetchSingle() or api.GetAllMetrics()")
-	Description string    `json:"description,omitempty"`
-	Start       time.Time `json:"start"`  // the start time of the task
-	Finish      time.Time `json:"finish"` // the end time of the task
+	Name        string        `json:"name"` // name identifies the measured quantity ("fetchSingle() or api.GetAllMetrics()")
+	Description string        `json:"description,omitempty"`
+	Start       time.Time     `json:"start"`                // the start time of the task
+	Finish      time.Time     `json:"finish"`               // the end time of the task
+	CPU         time.Duration `json:"cpu,omitempty"`        // the CPU time attributed to the task, in nanoseconds, if measured
+	CPUShared   bool          `json:"cpu_shared,omitempty"` // whether the CPU time was divided with concurrent tasks
 }
 
 // Duration is the duration of the profile (Finish - Start).
 func (p Profile) Duration() time.Duration {
 	return p.Finish.Sub(p.Start)
 }
+
+// A BackendRequest is a request which a query made to a storage or metadata
+// backend, kept (for debug=backend) so that it can be reproduced directly.
+type BackendRequest struct {
+	Backend string    `json:"backend"` // such as blueflood or cassandra
+	Request string    `json:"request"` // the URL, or the CQL statement with its parameters
+	Start   time.Time `json:"start"`
+	Finish  time.Time `json:"finish"`
+	Rows    int       `json:"rows"` // the points or rows returned
+	Error   string    `json:"error,omitempty"`
+}
+
+// RecordBackendRequests makes the profiler keep the backend requests added
+// to it, which are otherwise ignored.
+func (p *Profiler) RecordBackendRequests() {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.recordsBackends = true
+}
+
+// RecordsBackendRequests reports whether the profiler keeps backend
+// requests, so that backends only describe them when they're wanted.
+func (p *Profiler) RecordsBackendRequests() bool {
+	if p == nil {
+		return false
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.recordsBackends
+}
+
+// AddBackendRequest keeps the request, if backend requests are kept.
+func (p *Profiler) AddBackendRequest(request BackendRequest) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.recordsBackends {
+		p.backends = append(p.backends, request)
+	}
+}
+
+// BackendRequests retrieves the backend requests kept by the profiler.
+func (p *Profiler) BackendRequests() []BackendRequest {
+	if p == nil {
+		return []BackendRequest{}
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]BackendRequest{}, p.backends...)
+}
@@ -85,3 +85,25 @@ func TestProfilerSimple(t *testing.T) {
 	flushed = profiler.Flush()
 	a.EqInt(len(flushed), 0)
 }
+
+func TestProfilerBackendRequests(t *testing.T) {
+	a := assert.New(t)
+	profiler := New()
+	a.EqBool(profiler.RecordsBackendRequests(), false)
+	profiler.AddBackendRequest(BackendRequest{Backend: "blueflood", Request: "ignored"})
+	a.EqInt(len(profiler.BackendRequests()), 0)
+
+	profiler.RecordBackendRequests()
+	a.EqBool(profiler.RecordsBackendRequests(), true)
+	profiler.AddBackendRequest(BackendRequest{Backend: "cassandra", Request: "SELECT now() FROM system.local", Rows: 1})
+	requests := profiler.BackendRequests()
+	a.EqInt(len(requests), 1)
+	a.EqString(requests[0].Backend, "cassandra")
+	a.EqInt(requests[0].Rows, 1)
+
+	var nilProfiler *Profiler
+	nilProfiler.RecordBackendRequests()
+	nilProfiler.AddBackendRequest(BackendRequest{})
+	a.EqBool(nilProfiler.RecordsBackendRequests(), false)
+	a.EqInt(len(nilProfiler.BackendRequests()), 0)
+}
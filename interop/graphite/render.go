@@ -0,0 +1,193 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/predicate"
+	"github.com/square/metrics/util"
+)
+
+// renderHandler serves a subset of Graphite's render API, so that tools
+// speaking Graphite (such as the Graphite datasource of Grafana) can query
+// the backend directly. Each target's paths are translated into the metrics
+// and tags they name through the conversion rules, and its functions into
+// ours.
+type renderHandler struct {
+	context command.ExecutionContext
+	rules   util.RuleSet
+	clock   util.Clock
+}
+
+// NewRenderHandler creates a handler for /render requests, executed with the
+// given context, whose paths are resolved through the given rules.
+func NewRenderHandler(context command.ExecutionContext, rules util.RuleSet) http.Handler {
+	return renderHandler{context: context, rules: rules, clock: util.RealClock{}}
+}
+
+// renderedSeries is a series in Graphite's JSON format, whose datapoints are
+// pairs of a value (or null) and a timestamp in seconds.
+type renderedSeries struct {
+	Target     string          `json:"target"`
+	Datapoints [][]interface{} `json:"datapoints"`
+}
+
+func (h renderHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" && request.Method != "POST" {
+		http.Error(writer, fmt.Sprintf("unsupported method %s", request.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := request.ParseForm(); err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format := request.Form.Get("format"); format != "" && format != "json" {
+		http.Error(writer, fmt.Sprintf("unsupported format %q; only json is supported", format), http.StatusBadRequest)
+		return
+	}
+	targets := request.Form["target"]
+	if len(targets) == 0 {
+		http.Error(writer, "no target was given", http.StatusBadRequest)
+		return
+	}
+	selectContext, err := h.selectContext(request)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	context := h.context
+	context.Ctx = request.Context()
+	translator := &translator{context: context, rules: h.rules}
+	expressions := make([]function.Expression, len(targets))
+	for i, text := range targets {
+		parsed, err := parseTarget(text)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		expressions[i], err = translator.translate(parsed)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	selectCommand := &command.SelectCommand{
+		Predicate:   predicate.TruePredicate{},
+		Expressions: expressions,
+		Context:     selectContext,
+	}
+	result, err := selectCommand.Execute(context)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(convertResult(result, targets))
+}
+
+// selectContext finds the timerange of a request. The resolution is the
+// finest the slot limit allows, or that giving at most maxDataPoints points.
+func (h renderHandler) selectContext(request *http.Request) (command.SelectContext, error) {
+	now := h.clock.Now()
+	from, err := parseTime(request.Form.Get("from"), "-24h", now)
+	if err != nil {
+		return command.SelectContext{}, err
+	}
+	until, err := parseTime(request.Form.Get("until"), "now", now)
+	if err != nil {
+		return command.SelectContext{}, err
+	}
+	if !until.After(from) {
+		return command.SelectContext{}, fmt.Errorf("from (%s) must be before until (%s)", from.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+	resolution := time.Millisecond
+	if text := request.Form.Get("maxDataPoints"); text != "" {
+		points, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || points <= 0 {
+			return command.SelectContext{}, fmt.Errorf("invalid maxDataPoints %q", text)
+		}
+		if perPoint := until.Sub(from) / time.Duration(points); perPoint > resolution {
+			resolution = perPoint
+		}
+	}
+	return command.SelectContext{
+		Start:      from,
+		End:        until,
+		Resolution: resolution,
+	}, nil
+}
+
+// parseTime parses one of the forms of time Graphite accepts: now, a time
+// relative to now (such as -1h), seconds since the epoch, YYYYMMDD or
+// HH:MM_YYYYMMDD (in UTC).
+func parseTime(text string, fallback string, now time.Time) (time.Time, error) {
+	if text == "" {
+		text = fallback
+	}
+	switch {
+	case text == "now":
+		return now, nil
+	case strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+"):
+		offset, err := parseDuration(text)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(offset), nil
+	case strings.Contains(text, "_"):
+		return time.Parse("15:04_20060102", text)
+	case len(text) == 8:
+		return time.Parse("20060102", text)
+	}
+	seconds, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", text)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// convertResult lists the series of each target, named by their Graphite
+// names.
+func convertResult(result command.Result, targets []string) []renderedSeries {
+	rendered := []renderedSeries{}
+	for i, queryResult := range result.Body.([]command.QueryResult) {
+		timerange := queryResult.Timerange
+		for _, series := range queryResult.Series {
+			name, ok := series.TagSet[nameTag]
+			if !ok {
+				name = targets[i]
+			}
+			datapoints := make([][]interface{}, len(series.Values))
+			for j, value := range series.Values {
+				timestamp := (timerange.StartMillis() + int64(j)*timerange.ResolutionMillis()) / 1000
+				if math.IsNaN(value) || math.IsInf(value, 0) {
+					datapoints[j] = []interface{}{nil, timestamp}
+				} else {
+					datapoints[j] = []interface{}{value, timestamp}
+				}
+			}
+			rendered = append(rendered, renderedSeries{Target: name, Datapoints: datapoints})
+		}
+	}
+	return rendered
+}
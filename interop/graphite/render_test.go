@@ -0,0 +1,177 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/util"
+)
+
+const testRules = `
+rules:
+  - pattern: "%app%.%host%.cpu.percentage"
+    metric_key: cpu.percentage
+  - pattern: "%app%.%host%.connection.%kind%.latency"
+    metric_key: connection.%kind%.latency
+`
+
+// datapoints builds the datapoints of a series at 0s, 30s, 60s and 90s.
+func datapoints(values ...float64) [][]interface{} {
+	result := make([][]interface{}, len(values))
+	for i, value := range values {
+		timestamp := float64(30 * i)
+		if math.IsNaN(value) {
+			result[i] = []interface{}{nil, timestamp}
+		} else {
+			result[i] = []interface{}{value, timestamp}
+		}
+	}
+	return result
+}
+
+func TestRenderHandler(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 90000, 30000)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, math.NaN(), 4}, TagSet: api.TagSet{"metric": "cpu.percentage", "app": "web", "host": "a"}},
+		api.Timeseries{Values: []float64{5, 6, 7, 8}, TagSet: api.TagSet{"metric": "cpu.percentage", "app": "web", "host": "b"}},
+		api.Timeseries{Values: []float64{9, 9, 9, 9}, TagSet: api.TagSet{"metric": "cpu.percentage", "app": "db", "host": "c"}},
+		api.Timeseries{Values: []float64{3, 3, 3, 3}, TagSet: api.TagSet{"metric": "connection.http.latency", "app": "web", "host": "a"}},
+		api.Timeseries{Values: []float64{7, 7, 7, 7}, TagSet: api.TagSet{"metric": "connection.grpc.latency", "app": "web", "host": "a"}},
+	)
+	rules, err := util.LoadYAML([]byte(testRules))
+	if err != nil {
+		t.Fatalf("Error loading rules for test: %s", err.Error())
+	}
+	handler := renderHandler{
+		context: command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			SlotLimit:            1000,
+		},
+		rules: rules,
+		clock: mocks.NewTestClock(time.Unix(90, 0)),
+	}
+	render := func(parameters url.Values) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/render?"+parameters.Encode(), nil))
+		return recorder
+	}
+	for _, test := range []struct {
+		target   string
+		expected []renderedSeries
+	}{
+		{
+			target: "web.*.cpu.percentage",
+			expected: []renderedSeries{
+				{Target: "web.a.cpu.percentage", Datapoints: datapoints(1, 2, math.NaN(), 4)},
+				{Target: "web.b.cpu.percentage", Datapoints: datapoints(5, 6, 7, 8)},
+			},
+		},
+		{
+			target: "web.a.connection.{http,grpc}.latency",
+			expected: []renderedSeries{
+				{Target: "web.a.connection.grpc.latency", Datapoints: datapoints(7, 7, 7, 7)},
+				{Target: "web.a.connection.http.latency", Datapoints: datapoints(3, 3, 3, 3)},
+			},
+		},
+		{
+			target: "sumSeries(web.*.cpu.percentage, db.c.cpu.percentage)",
+			expected: []renderedSeries{
+				{Target: "sumSeries(web.*.cpu.percentage, db.c.cpu.percentage)", Datapoints: datapoints(15, 17, 16, 21)},
+			},
+		},
+		{
+			target: "scale(db.?.cpu.percentage, 2)",
+			expected: []renderedSeries{
+				{Target: "scale(db.c.cpu.percentage,2)", Datapoints: datapoints(18, 18, 18, 18)},
+			},
+		},
+		{
+			target: "aliasByNode(offset(web.[ab].cpu.percentage, 1), 1)",
+			expected: []renderedSeries{
+				{Target: "a", Datapoints: datapoints(2, 3, math.NaN(), 5)},
+				{Target: "b", Datapoints: datapoints(6, 7, 8, 9)},
+			},
+		},
+		{
+			target: "alias(maxSeries(*.*.cpu.percentage), 'peak')",
+			expected: []renderedSeries{
+				{Target: "peak", Datapoints: datapoints(9, 9, 9, 9)},
+			},
+		},
+		{
+			target:   "web.*.disk.percentage",
+			expected: []renderedSeries{},
+		},
+	} {
+		a := assert.New(t).Contextf("%s", test.target)
+		recorder := render(url.Values{"target": {test.target}, "from": {"-90s"}, "until": {"now"}, "maxDataPoints": {"3"}})
+		a.EqInt(recorder.Code, http.StatusOK)
+		rendered := []renderedSeries{}
+		a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &rendered))
+		a.Eq(rendered, test.expected)
+	}
+
+	a := assert.New(t)
+	for _, parameters := range []url.Values{
+		{},
+		{"target": {"web.a.cpu.percentage"}, "format": {"csv"}},
+		{"target": {"holtWintersForecast(web.a.cpu.percentage)"}},
+		{"target": {"scale(web.a.cpu.percentage)"}},
+		{"target": {"sumSeries(web.a.cpu.percentage"}},
+		{"target": {"web.a.cpu.percentage"}, "from": {"yesterday"}},
+		{"target": {"web.a.cpu.percentage"}, "from": {"now"}, "until": {"-1h"}},
+	} {
+		a.Contextf("%v", parameters).EqInt(render(parameters).Code, http.StatusBadRequest)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2016, 3, 4, 12, 30, 0, 0, time.UTC)
+	for _, test := range []struct {
+		text     string
+		expected time.Time
+	}{
+		{"", now.Add(-24 * time.Hour)},
+		{"now", now},
+		{"-1h", now.Add(-time.Hour)},
+		{"-5min", now.Add(-5 * time.Minute)},
+		{"-2days", now.Add(-48 * time.Hour)},
+		{"+1w", now.Add(7 * 24 * time.Hour)},
+		{"1457094600", now},
+		{"20160304", time.Date(2016, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"12:30_20160304", now},
+	} {
+		a := assert.New(t).Contextf("%q", test.text)
+		parsed, err := parseTime(test.text, "-24h", now)
+		a.CheckError(err)
+		a.Eq(parsed.Unix(), test.expected.Unix())
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A target is a parsed Graphite target: a metric path (which may hold
+// wildcards), a call of a function, or a literal argument.
+type target struct {
+	Path      string   // the metric path, for a path
+	Function  string   // the name of the function, for a call
+	Arguments []target // the arguments of a call
+	String    *string  // the value of a string literal
+	Number    *float64 // the value of a number literal
+	Source    string   // the text of the target
+}
+
+// parseTarget parses a target such as sumSeries(app.*.cpu.percentage).
+func parseTarget(text string) (target, error) {
+	p := &targetParser{text: text}
+	parsed, err := p.parse()
+	if err != nil {
+		return target{}, err
+	}
+	p.skipSpaces()
+	if p.position != len(p.text) {
+		return target{}, fmt.Errorf("unexpected %q at position %d of target %q", p.text[p.position:], p.position, text)
+	}
+	return parsed, nil
+}
+
+type targetParser struct {
+	text     string
+	position int
+}
+
+func (p *targetParser) skipSpaces() {
+	for p.position < len(p.text) && p.text[p.position] == ' ' {
+		p.position++
+	}
+}
+
+func (p *targetParser) next() byte {
+	if p.position == len(p.text) {
+		return 0
+	}
+	return p.text[p.position]
+}
+
+func (p *targetParser) parse() (target, error) {
+	p.skipSpaces()
+	start := p.position
+	switch quote := p.next(); quote {
+	case '"', '\'':
+		end := strings.IndexByte(p.text[start+1:], quote)
+		if end < 0 {
+			return target{}, fmt.Errorf("unterminated string at position %d of target %q", start, p.text)
+		}
+		value := p.text[start+1 : start+1+end]
+		p.position = start + end + 2
+		return target{String: &value, Source: p.text[start:p.position]}, nil
+	case 0, ',', '(', ')':
+		return target{}, fmt.Errorf("expected a target at position %d of target %q", start, p.text)
+	}
+	// A word runs to the next comma or parenthesis, except for the commas of
+	// the alternatives in braces.
+	braces := 0
+	for ; p.position < len(p.text); p.position++ {
+		c := p.text[p.position]
+		if c == '{' {
+			braces++
+		} else if c == '}' && braces > 0 {
+			braces--
+		} else if braces == 0 && (c == ',' || c == '(' || c == ')') {
+			break
+		}
+	}
+	word := strings.TrimRight(p.text[start:p.position], " ")
+	if p.next() != '(' {
+		if number, err := strconv.ParseFloat(word, 64); err == nil {
+			return target{Number: &number, Source: word}, nil
+		}
+		return target{Path: word, Source: word}, nil
+	}
+	call := target{Function: word}
+	p.position++
+	p.skipSpaces()
+	for p.next() != ')' {
+		if len(call.Arguments) > 0 {
+			if p.next() != ',' {
+				return target{}, fmt.Errorf("expected \",\" or \")\" at position %d of target %q", p.position, p.text)
+			}
+			p.position++
+		}
+		argument, err := p.parse()
+		if err != nil {
+			return target{}, err
+		}
+		call.Arguments = append(call.Arguments, argument)
+		p.skipSpaces()
+	}
+	p.position++
+	call.Source = p.text[start:p.position]
+	return call, nil
+}
+
+// splitPath splits a path into its nodes, keeping the alternatives in braces
+// together.
+func splitPath(path string) []string {
+	nodes := []string{}
+	braces := 0
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			braces++
+		case '}':
+			if braces > 0 {
+				braces--
+			}
+		case '.':
+			if braces == 0 {
+				nodes = append(nodes, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(nodes, path[start:])
+}
+
+// isLiteral reports whether a node of a path has no wildcards.
+func isLiteral(node string) bool {
+	return !strings.ContainsAny(node, "*?[{")
+}
+
+// globRegex translates a node of a path into a regular expression matching
+// the same values: * matches any characters, ? matches one, [...] matches
+// one of those listed, and {a,b} matches either of the alternatives.
+func globRegex(node string) (string, error) {
+	result := &strings.Builder{}
+	for i := 0; i < len(node); i++ {
+		switch c := node[i]; c {
+		case '*':
+			result.WriteString("[^.]*")
+		case '?':
+			result.WriteString("[^.]")
+		case '[':
+			end := strings.IndexByte(node[i:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated [ in %q", node)
+			}
+			result.WriteString(node[i : i+end+1])
+			i += end
+		case '{':
+			end := strings.IndexByte(node[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated { in %q", node)
+			}
+			alternatives := strings.Split(node[i+1:i+end], ",")
+			for j, alternative := range alternatives {
+				translated, err := globRegex(alternative)
+				if err != nil {
+					return "", err
+				}
+				alternatives[j] = translated
+			}
+			result.WriteString("(?:" + strings.Join(alternatives, "|") + ")")
+			i += end
+		default:
+			result.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return result.String(), nil
+}
+
+// pathRegex compiles a regular expression matching the whole names which a
+// path matches.
+func pathRegex(path string) (*regexp.Regexp, error) {
+	nodes := splitPath(path)
+	for i, node := range nodes {
+		translated, err := globRegex(node)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = translated
+	}
+	regex, err := regexp.Compile("^" + strings.Join(nodes, `\.`) + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %s", path, err.Error())
+	}
+	return regex, nil
+}
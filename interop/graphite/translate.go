@@ -0,0 +1,554 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/predicate"
+	"github.com/square/metrics/util"
+)
+
+// nameTag is the tag holding the Graphite name of each series, which the
+// functions renaming series replace.
+const nameTag = "__graphite_name__"
+
+// aggregates are the Graphite functions combining all of their series into
+// one, by the functions they're translated into.
+var aggregates = map[string]string{
+	"sumSeries":     "aggregate.sum",
+	"sum":           "aggregate.sum",
+	"averageSeries": "aggregate.mean",
+	"avg":           "aggregate.mean",
+	"maxSeries":     "aggregate.max",
+	"minSeries":     "aggregate.min",
+	"countSeries":   "aggregate.count",
+}
+
+// translator turns targets into expressions, resolving their paths through
+// the conversion rules.
+type translator struct {
+	context command.ExecutionContext
+	rules   util.RuleSet
+	metrics []api.MetricKey // all of the metrics, once the first path needs them
+}
+
+func (t *translator) translate(parsed target) (function.Expression, error) {
+	switch {
+	case parsed.Path != "":
+		return t.resolve(parsed.Path)
+	case parsed.Function == "":
+		return nil, fmt.Errorf("expected a series list, but got %s", parsed.Source)
+	}
+	arguments := parsed.Arguments
+	if name, ok := aggregates[parsed.Function]; ok {
+		lists, err := t.seriesLists(parsed.Function, arguments)
+		if err != nil {
+			return nil, err
+		}
+		return rename(call(name, union(parsed.Source, lists)), parsed.Source, func(string) string { return parsed.Source }), nil
+	}
+	if parsed.Function == "group" {
+		lists, err := t.seriesLists(parsed.Function, arguments)
+		if err != nil {
+			return nil, err
+		}
+		return union(parsed.Source, lists), nil
+	}
+	if len(arguments) == 0 {
+		return nil, fmt.Errorf("%s expects a series list", parsed.Function)
+	}
+	list, err := t.translate(arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	rest := make([]string, len(arguments)-1)
+	for i, argument := range arguments[1:] {
+		rest[i] = argument.Source
+	}
+	// Most functions change the name of each series by wrapping it in the
+	// call, as Graphite does.
+	wrap := func(name string) string {
+		return fmt.Sprintf("%s(%s)", parsed.Function, strings.Join(append([]string{name}, rest...), ","))
+	}
+	switch parsed.Function {
+	case "scale", "offset":
+		factor, err := numberArgument(parsed, 1)
+		if err != nil {
+			return nil, err
+		}
+		operator := "*"
+		if parsed.Function == "offset" {
+			operator = "+"
+		}
+		return rename(call(operator, list, function.Memoize(expression.Scalar{Value: factor})), parsed.Source, wrap), nil
+	case "absolute", "perSecond":
+		if len(arguments) != 1 {
+			return nil, fmt.Errorf("%s expects 1 argument", parsed.Function)
+		}
+		name := "transform.abs"
+		if parsed.Function == "perSecond" {
+			name = "transform.rate"
+		}
+		return rename(call(name, list), parsed.Source, wrap), nil
+	case "movingAverage", "timeShift":
+		text, err := stringArgument(parsed, 1)
+		if err != nil {
+			return nil, err
+		}
+		duration, err := parseDuration(text)
+		if err != nil {
+			return nil, err
+		}
+		name := "transform.moving_average"
+		if parsed.Function == "timeShift" {
+			name = "transform.timeshift"
+			// Graphite shifts into the past, unless told otherwise.
+			if !strings.HasPrefix(text, "+") && !strings.HasPrefix(text, "-") {
+				duration = -duration
+			}
+		}
+		return rename(call(name, list, function.Memoize(expression.Duration{Source: text, Duration: duration})), parsed.Source, wrap), nil
+	case "alias":
+		name, err := stringArgument(parsed, 1)
+		if err != nil {
+			return nil, err
+		}
+		return rename(list, parsed.Source, func(string) string { return name }), nil
+	case "aliasByNode":
+		if len(arguments) < 2 {
+			return nil, fmt.Errorf("aliasByNode expects at least one node")
+		}
+		nodes := make([]int, len(arguments)-1)
+		for i := range nodes {
+			node, err := numberArgument(parsed, i+1)
+			if err != nil {
+				return nil, err
+			}
+			nodes[i] = int(node)
+		}
+		return rename(list, parsed.Source, func(name string) string { return aliasByNode(name, nodes) }), nil
+	}
+	return nil, fmt.Errorf("unsupported Graphite function %s", parsed.Function)
+}
+
+func (t *translator) seriesLists(name string, arguments []target) ([]function.Expression, error) {
+	if len(arguments) == 0 {
+		return nil, fmt.Errorf("%s expects a series list", name)
+	}
+	lists := make([]function.Expression, len(arguments))
+	for i, argument := range arguments {
+		list, err := t.translate(argument)
+		if err != nil {
+			return nil, err
+		}
+		lists[i] = list
+	}
+	return lists, nil
+}
+
+func numberArgument(parsed target, index int) (float64, error) {
+	if index >= len(parsed.Arguments) || parsed.Arguments[index].Number == nil {
+		return 0, fmt.Errorf("%s expects a number as argument %d", parsed.Function, index+1)
+	}
+	return *parsed.Arguments[index].Number, nil
+}
+
+func stringArgument(parsed target, index int) (string, error) {
+	if index >= len(parsed.Arguments) || parsed.Arguments[index].String == nil {
+		return "", fmt.Errorf("%s expects a string as argument %d", parsed.Function, index+1)
+	}
+	return *parsed.Arguments[index].String, nil
+}
+
+func call(name string, arguments ...function.Expression) function.Expression {
+	return function.Memoize(&expression.FunctionExpression{FunctionName: name, Arguments: arguments})
+}
+
+// aliasByNode names a series by the given nodes of its path (counted from
+// the end if negative), ignoring the functions applied to it.
+func aliasByNode(name string, nodes []int) string {
+	path := name
+	if open := strings.LastIndexByte(path, '('); open >= 0 {
+		path = path[open+1:]
+	}
+	if end := strings.IndexAny(path, ",)"); end >= 0 {
+		path = path[:end]
+	}
+	parts := strings.Split(path, ".")
+	chosen := []string{}
+	for _, node := range nodes {
+		if node < 0 {
+			node += len(parts)
+		}
+		if node >= 0 && node < len(parts) {
+			chosen = append(chosen, parts[node])
+		}
+	}
+	return strings.Join(chosen, ".")
+}
+
+// resolve finds the series a path names. Each rule whose pattern has as many
+// nodes as the path constrains the tags of its pattern by the nodes of the
+// path, and so the metrics (from the tags in their keys) and the series of
+// each metric (from the rest of them) which might be named by the path.
+func (t *translator) resolve(path string) (function.Expression, error) {
+	matcher, err := pathRegex(path)
+	if err != nil {
+		return nil, err
+	}
+	nodes := splitPath(path)
+	resolved := &pathExpression{path: path, matcher: matcher, rules: t.rules}
+	for _, rule := range t.rules.Rules {
+		raw := rule.Raw()
+		patterns := strings.Split(raw.Pattern, ".")
+		if len(patterns) != len(nodes) {
+			continue
+		}
+		tags, ok, err := constrainTags(raw, patterns, nodes)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		keyRegex, err := interpolateRegex(raw.MetricKeyPattern, tags)
+		if err != nil {
+			return nil, err
+		}
+		metrics, err := t.allMetrics()
+		if err != nil {
+			return nil, err
+		}
+		keyTags := map[string]bool{}
+		for _, tag := range patternTags(raw.MetricKeyPattern) {
+			keyTags[tag] = true
+		}
+		predicates := []predicate.Predicate{}
+		for tag, constraint := range tags {
+			if keyTags[tag] {
+				continue
+			}
+			regex, err := regexp.Compile("^(?:" + constraint + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: %s", path, err.Error())
+			}
+			predicates = append(predicates, predicate.RegexMatcher{Tag: tag, Regex: regex})
+			if avoid, ok := raw.DoNotMatch[tag]; ok {
+				predicates = append(predicates, predicate.NotPredicate{Predicate: predicate.RegexMatcher{Tag: tag, Regex: regexp.MustCompile(avoid)}})
+			}
+		}
+		for _, metric := range metrics {
+			if !keyRegex.MatchString(string(metric)) {
+				continue
+			}
+			resolved.fetches = append(resolved.fetches, graphiteFetch{
+				metric: metric,
+				expression: function.Memoize(&expression.MetricFetchExpression{
+					MetricName: string(metric),
+					Predicate:  predicate.All(predicates...),
+				}),
+			})
+		}
+	}
+	return function.Memoize(resolved), nil
+}
+
+func (t *translator) allMetrics() ([]api.MetricKey, error) {
+	if t.metrics != nil {
+		return t.metrics, nil
+	}
+	metrics, err := t.context.MetricMetadataAPI.GetAllMetrics(t.context.Ctx, metadata.Context{Profiler: t.context.Profiler})
+	if err != nil {
+		return nil, err
+	}
+	t.metrics = metrics
+	return metrics, nil
+}
+
+// constrainTags matches the nodes of a path against those of a rule's
+// pattern, finding the regular expression the value of each tag matches. The
+// nodes of the pattern holding a tag and something else can only be matched
+// by a node without wildcards.
+func constrainTags(raw util.RawRule, patterns []string, nodes []string) (map[string]string, bool, error) {
+	tags := map[string]string{}
+	for i, pattern := range patterns {
+		node := nodes[i]
+		translated, err := globRegex(node)
+		if err != nil {
+			return nil, false, err
+		}
+		parts := strings.Split(pattern, "%")
+		switch {
+		case len(parts) == 1:
+			matched, err := regexp.MatchString("^(?:"+translated+")$", pattern)
+			if err != nil {
+				return nil, false, err
+			}
+			if !matched {
+				return nil, false, nil
+			}
+		case len(parts) == 3 && parts[0] == "" && parts[2] == "":
+			tags[parts[1]] = translated
+		case isLiteral(node):
+			// The node holds the tags between literal text, so their values
+			// are those matching the pattern of the node.
+			expression := &strings.Builder{}
+			names := []string{}
+			for j, part := range parts {
+				if j%2 == 0 {
+					expression.WriteString(regexp.QuoteMeta(part))
+					continue
+				}
+				tagRegex, ok := raw.Regex[part]
+				if !ok {
+					tagRegex = "[^.]+"
+				}
+				expression.WriteString("(" + tagRegex + ")")
+				names = append(names, part)
+			}
+			matches := regexp.MustCompile("^" + expression.String() + "$").FindStringSubmatch(node)
+			if matches == nil {
+				return nil, false, nil
+			}
+			for j, name := range names {
+				tags[name] = regexp.QuoteMeta(matches[j+1])
+			}
+		default:
+			return nil, false, nil
+		}
+	}
+	return tags, true, nil
+}
+
+// patternTags lists the tags of a pattern.
+func patternTags(pattern string) []string {
+	tags := []string{}
+	for i, part := range strings.Split(pattern, "%") {
+		if i%2 == 1 {
+			tags = append(tags, part)
+		}
+	}
+	return tags
+}
+
+// interpolateRegex compiles a regular expression matching the metric keys
+// of a pattern whose tags match the given regular expressions.
+func interpolateRegex(pattern string, tags map[string]string) (*regexp.Regexp, error) {
+	expression := &strings.Builder{}
+	for i, part := range strings.Split(pattern, "%") {
+		if i%2 == 0 {
+			expression.WriteString(regexp.QuoteMeta(part))
+			continue
+		}
+		expression.WriteString("(?:" + tags[part] + ")")
+	}
+	return regexp.Compile("^" + expression.String() + "$")
+}
+
+// A graphiteFetch fetches the series of a metric which might be named by a
+// path.
+type graphiteFetch struct {
+	metric     api.MetricKey
+	expression function.Expression
+}
+
+// pathExpression evaluates to the series named by a path, with their names.
+type pathExpression struct {
+	path    string
+	matcher *regexp.Regexp
+	rules   util.RuleSet
+	fetches []graphiteFetch
+}
+
+func (expr *pathExpression) ActualEvaluate(context function.EvaluationContext) (function.Value, error) {
+	expressions := make([]function.Expression, len(expr.fetches))
+	for i, fetch := range expr.fetches {
+		expressions[i] = fetch.expression
+	}
+	values, err := function.EvaluateMany(context, expressions)
+	if err != nil {
+		return nil, err
+	}
+	result := api.SeriesList{Series: []api.Timeseries{}}
+	named := map[string]bool{}
+	for i, value := range values {
+		list, convErr := value.ToSeriesList(context.Timerange())
+		if convErr != nil {
+			return nil, convErr.WithContext(expressions[i].ExpressionDescription(function.StringQuery()))
+		}
+		for _, series := range list.Series {
+			// The series are named as the rules name them, which may differ
+			// from the rule that found them, so they're matched again.
+			name, err := expr.rules.ToGraphiteName(api.TaggedMetric{MetricKey: expr.fetches[i].metric, TagSet: series.TagSet})
+			if err != nil || !expr.matcher.MatchString(string(name)) || named[string(name)] {
+				continue
+			}
+			named[string(name)] = true
+			series.TagSet = series.TagSet.Clone()
+			series.TagSet[nameTag] = string(name)
+			result.Series = append(result.Series, series)
+		}
+	}
+	// Graphite lists the series of a path in the order of their names.
+	sort.Slice(result.Series, func(i, j int) bool {
+		return result.Series[i].TagSet[nameTag] < result.Series[j].TagSet[nameTag]
+	})
+	return function.SeriesListValue(result), nil
+}
+
+func (expr *pathExpression) ExpressionDescription(mode function.DescriptionMode) string {
+	for _, fetch := range expr.fetches {
+		switch mode.(type) {
+		case function.WidestMode, function.PlanMode:
+			fetch.expression.ExpressionDescription(mode)
+		}
+	}
+	if mode == function.StringMemoization() {
+		return "graphite path " + expr.path
+	}
+	return expr.path
+}
+
+// unionExpression evaluates to the series of all of its lists.
+type unionExpression struct {
+	source string
+	lists  []function.Expression
+}
+
+func union(source string, lists []function.Expression) function.Expression {
+	if len(lists) == 1 {
+		return lists[0]
+	}
+	return function.Memoize(&unionExpression{source: source, lists: lists})
+}
+
+func (expr *unionExpression) ActualEvaluate(context function.EvaluationContext) (function.Value, error) {
+	values, err := function.EvaluateMany(context, expr.lists)
+	if err != nil {
+		return nil, err
+	}
+	result := api.SeriesList{Series: []api.Timeseries{}}
+	for i, value := range values {
+		list, convErr := value.ToSeriesList(context.Timerange())
+		if convErr != nil {
+			return nil, convErr.WithContext(expr.lists[i].ExpressionDescription(function.StringQuery()))
+		}
+		result.Series = append(result.Series, list.Series...)
+	}
+	return function.SeriesListValue(result), nil
+}
+
+func (expr *unionExpression) ExpressionDescription(mode function.DescriptionMode) string {
+	for _, list := range expr.lists {
+		switch mode.(type) {
+		case function.WidestMode, function.PlanMode:
+			list.ExpressionDescription(mode)
+		}
+	}
+	if mode == function.StringMemoization() {
+		return "graphite union " + expr.source
+	}
+	return expr.source
+}
+
+// renameExpression renames each series of its operand.
+type renameExpression struct {
+	source  string
+	operand function.Expression
+	name    func(name string) string
+}
+
+func rename(operand function.Expression, source string, name func(string) string) function.Expression {
+	return function.Memoize(&renameExpression{source: source, operand: operand, name: name})
+}
+
+func (expr *renameExpression) ActualEvaluate(context function.EvaluationContext) (function.Value, error) {
+	list, err := function.EvaluateToSeriesList(expr.operand, context)
+	if err != nil {
+		return nil, err
+	}
+	result := api.SeriesList{Series: make([]api.Timeseries, len(list.Series))}
+	for i, series := range list.Series {
+		series.TagSet = series.TagSet.Clone()
+		series.TagSet[nameTag] = expr.name(series.TagSet[nameTag])
+		result.Series[i] = series
+	}
+	return function.SeriesListValue(result), nil
+}
+
+func (expr *renameExpression) ExpressionDescription(mode function.DescriptionMode) string {
+	switch mode.(type) {
+	case function.WidestMode, function.PlanMode:
+		expr.operand.ExpressionDescription(mode)
+	}
+	if mode == function.StringMemoization() {
+		return "graphite " + expr.source
+	}
+	return expr.source
+}
+
+// graphiteUnits are the units of Graphite's durations, such as "5min".
+var graphiteUnits = []struct {
+	names []string
+	unit  time.Duration
+}{
+	{[]string{"s", "sec", "second"}, time.Second},
+	{[]string{"min", "minute"}, time.Minute},
+	{[]string{"h", "hour"}, time.Hour},
+	{[]string{"d", "day"}, 24 * time.Hour},
+	{[]string{"w", "week"}, 7 * 24 * time.Hour},
+	{[]string{"mon", "month"}, 30 * 24 * time.Hour},
+	{[]string{"y", "year"}, 365 * 24 * time.Hour},
+}
+
+// parseDuration parses a Graphite duration such as "1h" or "-5min".
+func parseDuration(text string) (time.Duration, error) {
+	trimmed := strings.TrimLeft(text, "+-")
+	digits := strings.IndexFunc(trimmed, func(r rune) bool { return r < '0' || r > '9' })
+	if digits <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", text)
+	}
+	count, err := strconv.Atoi(trimmed[:digits])
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", text)
+	}
+	unit := strings.TrimSuffix(trimmed[digits:], "s")
+	if unit == "" {
+		unit = "s"
+	}
+	for _, candidate := range graphiteUnits {
+		for _, name := range candidate.names {
+			if unit == name {
+				duration := time.Duration(count) * candidate.unit
+				if strings.HasPrefix(text, "-") {
+					duration = -duration
+				}
+				return duration, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid duration %q", text)
+}
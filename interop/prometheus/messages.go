@@ -0,0 +1,329 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"math"
+)
+
+// MatchType is the comparison made by a LabelMatcher.
+type MatchType int
+
+// The types of label matchers.
+const (
+	MatchEqual MatchType = iota
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+)
+
+func (t MatchType) String() string {
+	switch t {
+	case MatchEqual:
+		return "="
+	case MatchNotEqual:
+		return "!="
+	case MatchRegexp:
+		return "=~"
+	case MatchNotRegexp:
+		return "!~"
+	default:
+		return fmt.Sprintf("MatchType(%d)", int(t))
+	}
+}
+
+// samplesResponse is the only type of response offered: whole samples,
+// rather than streamed chunks.
+const samplesResponse = 0
+
+// ReadRequest is a remote read request: a query for each selector of the
+// PromQL being evaluated.
+type ReadRequest struct {
+	Queries               []Query
+	AcceptedResponseTypes []int
+}
+
+// Query asks for the series matching every matcher, between the timestamps.
+type Query struct {
+	StartMillis int64
+	EndMillis   int64
+	Matchers    []LabelMatcher
+	StepMillis  int64 // from the hints; zero if not given
+}
+
+// LabelMatcher compares the value of a label.
+type LabelMatcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+}
+
+func (m LabelMatcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// ReadResponse holds a result for each query of the request, in order.
+type ReadResponse struct {
+	Results []QueryResult
+}
+
+// QueryResult holds the series found by a query.
+type QueryResult struct {
+	Series []TimeSeries
+}
+
+// TimeSeries is a series identified by its labels, which are sorted by name.
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// Label is a name and a value identifying a series.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value at a time.
+type Sample struct {
+	Value           float64
+	TimestampMillis int64
+}
+
+// DecodeReadRequest decodes a ReadRequest, once it's been decompressed.
+func DecodeReadRequest(encoded []byte) (ReadRequest, error) {
+	request := ReadRequest{}
+	reader := protoReader{buffer: encoded}
+	for {
+		field, wireType, ok, err := reader.next()
+		if err != nil {
+			return ReadRequest{}, err
+		}
+		if !ok {
+			return request, nil
+		}
+		switch {
+		case field == 1 && wireType == wireBytes:
+			message, err := reader.bytes()
+			if err != nil {
+				return ReadRequest{}, err
+			}
+			query, err := decodeQuery(message)
+			if err != nil {
+				return ReadRequest{}, err
+			}
+			request.Queries = append(request.Queries, query)
+		case field == 2 && wireType == wireVarint:
+			value, err := reader.varint()
+			if err != nil {
+				return ReadRequest{}, err
+			}
+			request.AcceptedResponseTypes = append(request.AcceptedResponseTypes, int(value))
+		case field == 2 && wireType == wireBytes:
+			// packed
+			packed, err := reader.bytes()
+			if err != nil {
+				return ReadRequest{}, err
+			}
+			values := protoReader{buffer: packed}
+			for len(values.buffer) > 0 {
+				value, err := values.varint()
+				if err != nil {
+					return ReadRequest{}, err
+				}
+				request.AcceptedResponseTypes = append(request.AcceptedResponseTypes, int(value))
+			}
+		default:
+			if err := reader.skip(wireType); err != nil {
+				return ReadRequest{}, err
+			}
+		}
+	}
+}
+
+func decodeQuery(encoded []byte) (Query, error) {
+	query := Query{}
+	reader := protoReader{buffer: encoded}
+	for {
+		field, wireType, ok, err := reader.next()
+		if err != nil {
+			return Query{}, err
+		}
+		if !ok {
+			return query, nil
+		}
+		switch {
+		case field == 1 && wireType == wireVarint:
+			value, err := reader.varint()
+			if err != nil {
+				return Query{}, err
+			}
+			query.StartMillis = int64(value)
+		case field == 2 && wireType == wireVarint:
+			value, err := reader.varint()
+			if err != nil {
+				return Query{}, err
+			}
+			query.EndMillis = int64(value)
+		case field == 3 && wireType == wireBytes:
+			message, err := reader.bytes()
+			if err != nil {
+				return Query{}, err
+			}
+			matcher, err := decodeMatcher(message)
+			if err != nil {
+				return Query{}, err
+			}
+			query.Matchers = append(query.Matchers, matcher)
+		case field == 4 && wireType == wireBytes:
+			message, err := reader.bytes()
+			if err != nil {
+				return Query{}, err
+			}
+			if query.StepMillis, err = decodeStep(message); err != nil {
+				return Query{}, err
+			}
+		default:
+			if err := reader.skip(wireType); err != nil {
+				return Query{}, err
+			}
+		}
+	}
+}
+
+func decodeMatcher(encoded []byte) (LabelMatcher, error) {
+	matcher := LabelMatcher{}
+	reader := protoReader{buffer: encoded}
+	for {
+		field, wireType, ok, err := reader.next()
+		if err != nil {
+			return LabelMatcher{}, err
+		}
+		if !ok {
+			return matcher, nil
+		}
+		switch {
+		case field == 1 && wireType == wireVarint:
+			value, err := reader.varint()
+			if err != nil {
+				return LabelMatcher{}, err
+			}
+			matcher.Type = MatchType(value)
+		case (field == 2 || field == 3) && wireType == wireBytes:
+			value, err := reader.bytes()
+			if err != nil {
+				return LabelMatcher{}, err
+			}
+			if field == 2 {
+				matcher.Name = string(value)
+			} else {
+				matcher.Value = string(value)
+			}
+		default:
+			if err := reader.skip(wireType); err != nil {
+				return LabelMatcher{}, err
+			}
+		}
+	}
+}
+
+// decodeStep finds the step of the query in its hints.
+func decodeStep(encoded []byte) (int64, error) {
+	step := int64(0)
+	reader := protoReader{buffer: encoded}
+	for {
+		field, wireType, ok, err := reader.next()
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return step, nil
+		}
+		if field == 1 && wireType == wireVarint {
+			value, err := reader.varint()
+			if err != nil {
+				return 0, err
+			}
+			step = int64(value)
+			continue
+		}
+		if err := reader.skip(wireType); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Encode encodes the response, before it's compressed.
+func (r ReadResponse) Encode() []byte {
+	writer := protoWriter{}
+	for _, result := range r.Results {
+		writer.message(1, func(w *protoWriter) {
+			for _, series := range result.Series {
+				w.message(1, series.encode)
+			}
+		})
+	}
+	return writer.buffer
+}
+
+func (s TimeSeries) encode(w *protoWriter) {
+	for _, label := range s.Labels {
+		w.message(1, func(w *protoWriter) {
+			w.string(1, label.Name)
+			w.string(2, label.Value)
+		})
+	}
+	for _, sample := range s.Samples {
+		w.message(2, func(w *protoWriter) {
+			if sample.Value != 0 || math.Signbit(sample.Value) {
+				w.double(1, sample.Value)
+			}
+			if sample.TimestampMillis != 0 {
+				w.varint(2, uint64(sample.TimestampMillis))
+			}
+		})
+	}
+}
+
+// Encode encodes the request, before it's compressed. Servers don't need
+// it, but it lets tests and tools act as Prometheus.
+func (r ReadRequest) Encode() []byte {
+	writer := protoWriter{}
+	for _, query := range r.Queries {
+		writer.message(1, func(w *protoWriter) {
+			w.varint(1, uint64(query.StartMillis))
+			w.varint(2, uint64(query.EndMillis))
+			for _, matcher := range query.Matchers {
+				w.message(3, func(w *protoWriter) {
+					if matcher.Type != MatchEqual {
+						w.varint(1, uint64(matcher.Type))
+					}
+					w.string(2, matcher.Name)
+					w.string(3, matcher.Value)
+				})
+			}
+			if query.StepMillis != 0 {
+				w.message(4, func(w *protoWriter) {
+					w.varint(1, uint64(query.StepMillis))
+				})
+			}
+		})
+	}
+	for _, responseType := range r.AcceptedResponseTypes {
+		writer.varint(2, uint64(responseType))
+	}
+	return writer.buffer
+}
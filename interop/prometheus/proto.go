@@ -0,0 +1,149 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The remote read protocol is a handful of small protobuf messages, so they
+// are encoded and decoded here by hand rather than with generated code.
+
+// The protobuf wire types used by the messages.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated protobuf message")
+
+// protoReader reads the fields of an encoded message in turn.
+type protoReader struct {
+	buffer []byte
+}
+
+// next reads the key of the next field, reporting false at the end of the
+// message.
+func (r *protoReader) next() (int, int, bool, error) {
+	if len(r.buffer) == 0 {
+		return 0, 0, false, nil
+	}
+	key, err := r.varint()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return int(key >> 3), int(key & 7), true, nil
+}
+
+func (r *protoReader) varint() (uint64, error) {
+	value, n := binary.Uvarint(r.buffer)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	r.buffer = r.buffer[n:]
+	return value, nil
+}
+
+func (r *protoReader) bytes() ([]byte, error) {
+	length, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.buffer)) < length {
+		return nil, errTruncated
+	}
+	value := r.buffer[:length]
+	r.buffer = r.buffer[length:]
+	return value, nil
+}
+
+func (r *protoReader) fixed64() (uint64, error) {
+	if len(r.buffer) < 8 {
+		return 0, errTruncated
+	}
+	value := binary.LittleEndian.Uint64(r.buffer)
+	r.buffer = r.buffer[8:]
+	return value, nil
+}
+
+// skip passes over a field which isn't needed.
+func (r *protoReader) skip(wireType int) error {
+	var err error
+	switch wireType {
+	case wireVarint:
+		_, err = r.varint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireFixed32:
+		if len(r.buffer) < 4 {
+			return errTruncated
+		}
+		r.buffer = r.buffer[4:]
+	default:
+		return fmt.Errorf("unsupported protobuf wire type %d", wireType)
+	}
+	return err
+}
+
+// protoWriter encodes the fields of a message.
+type protoWriter struct {
+	buffer []byte
+}
+
+func (w *protoWriter) uvarint(value uint64) {
+	var encoded [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(encoded[:], value)
+	w.buffer = append(w.buffer, encoded[:n]...)
+}
+
+func (w *protoWriter) key(field int, wireType int) {
+	w.uvarint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (w *protoWriter) varint(field int, value uint64) {
+	w.key(field, wireVarint)
+	w.uvarint(value)
+}
+
+func (w *protoWriter) double(field int, value float64) {
+	w.key(field, wireFixed64)
+	var encoded [8]byte
+	binary.LittleEndian.PutUint64(encoded[:], math.Float64bits(value))
+	w.buffer = append(w.buffer, encoded[:]...)
+}
+
+func (w *protoWriter) bytes(field int, value []byte) {
+	w.key(field, wireBytes)
+	w.uvarint(uint64(len(value)))
+	w.buffer = append(w.buffer, value...)
+}
+
+func (w *protoWriter) string(field int, value string) {
+	w.bytes(field, []byte(value))
+}
+
+// message encodes a nested message written by the given function.
+func (w *protoWriter) message(field int, write func(*protoWriter)) {
+	nested := protoWriter{}
+	write(&nested)
+	w.bytes(field, nested.buffer)
+}
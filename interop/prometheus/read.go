@@ -0,0 +1,264 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/golang/snappy"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/predicate"
+)
+
+// metricNameLabel is the label Prometheus uses for the name of a metric.
+const metricNameLabel = "__name__"
+
+// readHandler serves the Prometheus remote read protocol, so that Prometheus
+// (and the Prometheus datasource of Grafana, through it) can query the
+// backend directly. Each query of a request is executed as a select of the
+// metrics its matchers name, constrained by its other matchers.
+type readHandler struct {
+	context command.ExecutionContext
+}
+
+// NewReadHandler creates a handler for remote read requests, executed with
+// the given context.
+func NewReadHandler(context command.ExecutionContext) http.Handler {
+	return readHandler{context: context}
+}
+
+func (h readHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "POST" {
+		http.Error(writer, fmt.Sprintf("unsupported method %s", request.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	compressed, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	encoded, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(writer, fmt.Sprintf("cannot decompress the request: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	readRequest, err := DecodeReadRequest(encoded)
+	if err != nil {
+		http.Error(writer, fmt.Sprintf("cannot decode the request: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if !acceptsSamples(readRequest.AcceptedResponseTypes) {
+		http.Error(writer, "only sampled responses are supported", http.StatusBadRequest)
+		return
+	}
+	context := h.context
+	context.Ctx = request.Context()
+	response := ReadResponse{Results: make([]QueryResult, len(readRequest.Queries))}
+	for i, query := range readRequest.Queries {
+		selectCommand, metrics, err := newSelectCommand(context, query)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(metrics) == 0 {
+			continue // no metric is named by the matchers
+		}
+		result, err := selectCommand.Execute(context)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response.Results[i] = convertResult(metrics, result, query)
+	}
+	writer.Header().Set("Content-Type", "application/x-protobuf")
+	writer.Header().Set("Content-Encoding", "snappy")
+	writer.Write(snappy.Encode(nil, response.Encode()))
+}
+
+// acceptsSamples reports whether sampled responses are acceptable. Older
+// versions of Prometheus don't list the types, and only accept samples.
+func acceptsSamples(types []int) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, responseType := range types {
+		if responseType == samplesResponse {
+			return true
+		}
+	}
+	return false
+}
+
+// newSelectCommand translates a remote read query into a select. Its
+// expressions fetch each of the metrics named by the matchers of __name__,
+// and its predicate holds the rest of them. There's no command if no metric
+// is named.
+func newSelectCommand(context command.ExecutionContext, query Query) (*command.SelectCommand, []api.MetricKey, error) {
+	names := []LabelMatcher{}
+	predicates := []predicate.Predicate{}
+	for _, matcher := range query.Matchers {
+		if matcher.Name == metricNameLabel {
+			names = append(names, matcher)
+			continue
+		}
+		tagPredicate, err := matcherPredicate(matcher)
+		if err != nil {
+			return nil, nil, err
+		}
+		predicates = append(predicates, tagPredicate)
+	}
+	metrics, err := matchingMetrics(context, names)
+	if err != nil || len(metrics) == 0 {
+		return nil, nil, err
+	}
+	expressions := make([]function.Expression, len(metrics))
+	for i, metric := range metrics {
+		expressions[i] = function.Memoize(&expression.MetricFetchExpression{
+			MetricName: string(metric),
+			Predicate:  predicate.TruePredicate{},
+		})
+	}
+	resolution := command.MillisToDuration(query.StepMillis)
+	if resolution <= 0 {
+		resolution = time.Millisecond // the finest resolution the slot limit allows
+	}
+	return &command.SelectCommand{
+		Predicate:   predicate.All(predicates...),
+		Expressions: expressions,
+		Context: command.SelectContext{
+			Start:      command.MillisToTime(query.StartMillis),
+			End:        command.MillisToTime(query.EndMillis),
+			Resolution: resolution,
+		},
+	}, metrics, nil
+}
+
+// matchingMetrics finds the metrics whose names satisfy every matcher.
+func matchingMetrics(context command.ExecutionContext, matchers []LabelMatcher) ([]api.MetricKey, error) {
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("the query has no matcher for %s", metricNameLabel)
+	}
+	tests := make([]predicate.Predicate, len(matchers))
+	for i, matcher := range matchers {
+		test, err := matcherPredicate(matcher)
+		if err != nil {
+			return nil, err
+		}
+		tests[i] = test
+	}
+	nameTest := predicate.All(tests...)
+	// Even an exact name is checked against the known metrics, since
+	// Prometheus expects an empty result rather than an error for a
+	// metric which doesn't exist.
+	candidates, err := context.MetricMetadataAPI.GetAllMetrics(context.Ctx, metadata.Context{Profiler: context.Profiler})
+	if err != nil {
+		return nil, err
+	}
+	metrics := []api.MetricKey{}
+	for _, candidate := range candidates {
+		if nameTest.Apply(api.TagSet{metricNameLabel: string(candidate)}) {
+			metrics = append(metrics, candidate)
+		}
+	}
+	return metrics, nil
+}
+
+// matcherPredicate translates a matcher. Prometheus treats a missing label
+// as an empty one, so matchers which accept the empty string also accept
+// series without the tag.
+func matcherPredicate(matcher LabelMatcher) (predicate.Predicate, error) {
+	switch matcher.Type {
+	case MatchEqual:
+		if matcher.Value == "" {
+			return missingTag(matcher.Name), nil
+		}
+		return predicate.ListMatcher{Tag: matcher.Name, Values: []string{matcher.Value}}, nil
+	case MatchNotEqual:
+		equal, err := matcherPredicate(LabelMatcher{Type: MatchEqual, Name: matcher.Name, Value: matcher.Value})
+		if err != nil {
+			return nil, err
+		}
+		return predicate.NotPredicate{Predicate: equal}, nil
+	case MatchRegexp:
+		regex, err := regexp.Compile("^(?:" + matcher.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in %s: %s", matcher, err.Error())
+		}
+		match := predicate.Predicate(predicate.RegexMatcher{Tag: matcher.Name, Regex: regex})
+		if regex.MatchString("") {
+			match = predicate.Any(match, missingTag(matcher.Name))
+		}
+		return match, nil
+	case MatchNotRegexp:
+		match, err := matcherPredicate(LabelMatcher{Type: MatchRegexp, Name: matcher.Name, Value: matcher.Value})
+		if err != nil {
+			return nil, err
+		}
+		return predicate.NotPredicate{Predicate: match}, nil
+	default:
+		return nil, fmt.Errorf("unsupported matcher %s", matcher)
+	}
+}
+
+// anyValue matches every value of a tag.
+var anyValue = regexp.MustCompile("")
+
+func missingTag(tag string) predicate.Predicate {
+	return predicate.NotPredicate{Predicate: predicate.RegexMatcher{Tag: tag, Regex: anyValue}}
+}
+
+// convertResult turns the series fetched for each metric into labelled
+// series, keeping only the samples in the queried timerange.
+func convertResult(metrics []api.MetricKey, result command.Result, query Query) QueryResult {
+	converted := QueryResult{}
+	for i, queryResult := range result.Body.([]command.QueryResult) {
+		name := string(metrics[i])
+		timerange := queryResult.Timerange
+		for _, series := range queryResult.Series {
+			labels := []Label{{Name: metricNameLabel, Value: name}}
+			for key, value := range series.TagSet {
+				labels = append(labels, Label{Name: key, Value: value})
+			}
+			sort.Sort(byName(labels))
+			samples := []Sample{}
+			for j, value := range series.Values {
+				timestamp := timerange.StartMillis() + int64(j)*timerange.ResolutionMillis()
+				if math.IsNaN(value) || timestamp < query.StartMillis || timestamp > query.EndMillis {
+					continue
+				}
+				samples = append(samples, Sample{Value: value, TimestampMillis: timestamp})
+			}
+			converted.Series = append(converted.Series, TimeSeries{Labels: labels, Samples: samples})
+		}
+	}
+	return converted
+}
+
+type byName []Label
+
+func (labels byName) Len() int           { return len(labels) }
+func (labels byName) Less(i, j int) bool { return labels[i].Name < labels[j].Name }
+func (labels byName) Swap(i, j int)      { labels[i], labels[j] = labels[j], labels[i] }
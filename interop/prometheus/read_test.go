@@ -0,0 +1,229 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+// decodeReadResponse decodes a response as Prometheus would.
+func decodeReadResponse(t *testing.T, encoded []byte) ReadResponse {
+	response := ReadResponse{}
+	messages := func(encoded []byte, each func([]byte)) {
+		reader := protoReader{buffer: encoded}
+		for {
+			_, wireType, ok, err := reader.next()
+			if err != nil {
+				t.Fatalf("cannot decode the response: %s", err.Error())
+			}
+			if !ok {
+				return
+			}
+			if wireType != wireBytes {
+				t.Fatalf("unexpected wire type %d", wireType)
+			}
+			message, err := reader.bytes()
+			if err != nil {
+				t.Fatalf("cannot decode the response: %s", err.Error())
+			}
+			each(message)
+		}
+	}
+	messages(encoded, func(result []byte) {
+		queryResult := QueryResult{}
+		messages(result, func(series []byte) {
+			timeseries := TimeSeries{}
+			reader := protoReader{buffer: series}
+			for {
+				field, _, ok, err := reader.next()
+				if err != nil || !ok {
+					break
+				}
+				message, _ := reader.bytes()
+				fields := protoReader{buffer: message}
+				if field == 1 {
+					label := Label{}
+					for {
+						field, _, ok, _ := fields.next()
+						if !ok {
+							break
+						}
+						value, _ := fields.bytes()
+						if field == 1 {
+							label.Name = string(value)
+						} else {
+							label.Value = string(value)
+						}
+					}
+					timeseries.Labels = append(timeseries.Labels, label)
+					continue
+				}
+				sample := Sample{}
+				for {
+					field, _, ok, _ := fields.next()
+					if !ok {
+						break
+					}
+					if field == 1 {
+						bits, _ := fields.fixed64()
+						sample.Value = math.Float64frombits(bits)
+					} else {
+						timestamp, _ := fields.varint()
+						sample.TimestampMillis = int64(timestamp)
+					}
+				}
+				timeseries.Samples = append(timeseries.Samples, sample)
+			}
+			queryResult.Series = append(queryResult.Series, timeseries)
+		})
+		response.Results = append(response.Results, queryResult)
+	})
+	return response
+}
+
+func TestDecodeReadRequest(t *testing.T) {
+	a := assert.New(t)
+	request := ReadRequest{
+		Queries: []Query{
+			{
+				StartMillis: 1000,
+				EndMillis:   2000,
+				StepMillis:  30,
+				Matchers: []LabelMatcher{
+					{Type: MatchEqual, Name: "__name__", Value: "cpu"},
+					{Type: MatchNotRegexp, Name: "host", Value: "db.*"},
+				},
+			},
+		},
+		AcceptedResponseTypes: []int{0},
+	}
+	decoded, err := DecodeReadRequest(request.Encode())
+	a.CheckError(err)
+	a.Eq(decoded, request)
+
+	_, err = DecodeReadRequest(request.Encode()[:5])
+	a.Eq(err, errTruncated)
+}
+
+func TestReadHandler(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 90, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, math.NaN(), 4}, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+		api.Timeseries{Values: []float64{5, 6, 7, 8}, TagSet: api.TagSet{"metric": "cpu", "host": "b", "dc": "north"}},
+		api.Timeseries{Values: []float64{9, 9, 9, 9}, TagSet: api.TagSet{"metric": "memory", "host": "a"}},
+	)
+	handler := NewReadHandler(command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+	})
+	post := func(request ReadRequest) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		body := bytes.NewReader(snappy.Encode(nil, request.Encode()))
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/api/v1/read", body))
+		return recorder
+	}
+	for _, test := range []struct {
+		name     string
+		matchers []LabelMatcher
+		expected []TimeSeries
+	}{
+		{
+			name: "name and tag",
+			matchers: []LabelMatcher{
+				{Type: MatchEqual, Name: "__name__", Value: "cpu"},
+				{Type: MatchEqual, Name: "host", Value: "a"},
+			},
+			expected: []TimeSeries{
+				{
+					Labels:  []Label{{"__name__", "cpu"}, {"host", "a"}},
+					Samples: []Sample{{1, 0}, {2, 30}, {4, 90}},
+				},
+			},
+		},
+		{
+			name: "missing tag",
+			matchers: []LabelMatcher{
+				{Type: MatchEqual, Name: "__name__", Value: "cpu"},
+				{Type: MatchEqual, Name: "dc", Value: ""},
+			},
+			expected: []TimeSeries{
+				{
+					Labels:  []Label{{"__name__", "cpu"}, {"host", "a"}},
+					Samples: []Sample{{1, 0}, {2, 30}, {4, 90}},
+				},
+			},
+		},
+		{
+			name: "name regex",
+			matchers: []LabelMatcher{
+				{Type: MatchRegexp, Name: "__name__", Value: "mem.*"},
+				{Type: MatchNotEqual, Name: "host", Value: "b"},
+			},
+			expected: []TimeSeries{
+				{
+					Labels:  []Label{{"__name__", "memory"}, {"host", "a"}},
+					Samples: []Sample{{9, 0}, {9, 30}, {9, 60}, {9, 90}},
+				},
+			},
+		},
+		{
+			name: "no metric",
+			matchers: []LabelMatcher{
+				{Type: MatchEqual, Name: "__name__", Value: "disk"},
+			},
+			expected: nil,
+		},
+	} {
+		a := assert.New(t).Contextf("%s", test.name)
+		recorder := post(ReadRequest{
+			Queries: []Query{{StartMillis: 0, EndMillis: 90, StepMillis: 30, Matchers: test.matchers}},
+		})
+		a.EqInt(recorder.Code, http.StatusOK)
+		a.EqString(recorder.Header().Get("Content-Encoding"), "snappy")
+		encoded, err := snappy.Decode(nil, recorder.Body.Bytes())
+		a.CheckError(err)
+		response := decodeReadResponse(t, encoded)
+		if len(response.Results) != 1 {
+			// An empty result is an empty message.
+			response.Results = append(response.Results, QueryResult{})
+		}
+		a.Eq(response.Results[0].Series, test.expected)
+	}
+
+	a := assert.New(t)
+	recorder := post(ReadRequest{Queries: []Query{{EndMillis: 90, Matchers: []LabelMatcher{{Type: MatchEqual, Name: "host", Value: "a"}}}}})
+	a.EqInt(recorder.Code, http.StatusBadRequest)
+	recorder = post(ReadRequest{AcceptedResponseTypes: []int{1}})
+	a.EqInt(recorder.Code, http.StatusBadRequest)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/api/v1/read", bytes.NewReader([]byte("not snappy"))))
+	a.EqInt(recorder.Code, http.StatusBadRequest)
+}
@@ -29,6 +29,9 @@ import (
 
 var ConfigFile = flag.String("config-file", "", "specify the yaml config file from which to load the configuration.")
 
+// Dev requests a self-contained development mode, for binaries which support it.
+var Dev = flag.Bool("dev", false, "start with in-memory backends and example data instead of a config file (web server only).")
+
 func LoadConfig(config interface{}) {
 	flag.Parse()
 	if *ConfigFile == "" {
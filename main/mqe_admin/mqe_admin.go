@@ -0,0 +1,133 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// mqe_admin runs maintenance commands against the backends of a config file:
+//
+//	mqe_admin -config-file config.yaml backup-metadata -file metadata.json.gz
+//	mqe_admin -config-file config.yaml restore-metadata -file metadata.json.gz
+//
+// backup-metadata streams every metric and tag set of the Cassandra index to
+// the file; restore-metadata adds those of the file to the index, which
+// needn't be empty. Files whose names end in ".gz" are compressed.
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/square/metrics/main/common"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/metric_metadata/backup"
+	"github.com/square/metrics/metric_metadata/cassandra"
+)
+
+var commands = map[string]func(api *cassandra.MetricMetadataAPI, arguments []string) error{
+	"backup-metadata":  backupMetadata,
+	"restore-metadata": restoreMetadata,
+}
+
+func backupMetadata(metadataAPI *cassandra.MetricMetadataAPI, arguments []string) (err error) {
+	flags := flag.NewFlagSet("backup-metadata", flag.ExitOnError)
+	path := flags.String("file", "", "File to write the backup to.")
+	flags.Parse(arguments)
+	if *path == "" {
+		return fmt.Errorf("no file specified")
+	}
+
+	file, err := os.Create(*path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	var writer io.Writer = file
+	if strings.HasSuffix(*path, ".gz") {
+		compressed := gzip.NewWriter(file)
+		defer func() {
+			if closeErr := compressed.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		writer = compressed
+	}
+
+	summary, err := backup.Write(context.Background(), writer, metadataAPI, metadata.Context{})
+	fmt.Printf("backed up %d series of %d metrics\n", summary.Series, summary.Metrics)
+	return err
+}
+
+func restoreMetadata(metadataAPI *cassandra.MetricMetadataAPI, arguments []string) error {
+	flags := flag.NewFlagSet("restore-metadata", flag.ExitOnError)
+	path := flags.String("file", "", "File to read the backup from.")
+	batchSize := flags.Int("batch-size", 500, "Number of series added to the index at once.")
+	flags.Parse(arguments)
+	if *path == "" {
+		return fmt.Errorf("no file specified")
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if strings.HasSuffix(*path, ".gz") {
+		compressed, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer compressed.Close()
+		reader = compressed
+	}
+
+	summary, err := backup.Restore(context.Background(), reader, metadataAPI, *batchSize, metadata.Context{})
+	fmt.Printf("restored %d series of %d metrics\n", summary.Series, summary.Metrics)
+	return err
+}
+
+func main() {
+	config := struct {
+		Cassandra cassandra.Config `yaml:"cassandra"`
+	}{}
+
+	common.LoadConfig(&config)
+
+	if flag.NArg() == 0 {
+		common.ExitWithErrorMessage("No command specified; expected backup-metadata or restore-metadata")
+		return
+	}
+	command, ok := commands[flag.Arg(0)]
+	if !ok {
+		common.ExitWithErrorMessage("Unknown command %q; expected backup-metadata or restore-metadata", flag.Arg(0))
+		return
+	}
+
+	cassandraAPI, err := cassandra.NewMetricMetadataAPI(config.Cassandra)
+	if err != nil {
+		common.ExitWithErrorMessage("Error loading Cassandra API: %s", err.Error())
+		return
+	}
+
+	if err := command(cassandraAPI, flag.Args()[1:]); err != nil {
+		common.ExitWithErrorMessage("Error running %s: %s", flag.Arg(0), err.Error())
+	}
+}
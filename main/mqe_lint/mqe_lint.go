@@ -0,0 +1,166 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// mqe_lint checks queries offline, without any backends, so that repositories
+// of dashboards and alerts can refuse changes holding invalid queries. Each
+// query is parsed, and its calls are checked against the function registry.
+//
+// Files ending in ".json" are dashboard definitions, from which the queries
+// of each panel are taken (see lint.ParseDashboard). Other files hold one
+// query per line; blank lines and lines starting with "#" are ignored.
+//
+// The exit status is 0 if every query is valid, 1 if any query has an error
+// (or a warning, with -strict), and 2 if a file can't be read.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/query/lint"
+)
+
+var (
+	format = flag.String("format", "text", "Output format: text, or json for a report of every query.")
+	strict = flag.Bool("strict", false, "Whether warnings fail the check, as errors do.")
+)
+
+// A Query is a query found in a file.
+type Query struct {
+	File  string `json:"file"`
+	Line  int    `json:"line,omitempty"`  // in a file of queries
+	Panel string `json:"panel,omitempty"` // in a dashboard
+	Query string `json:"query"`
+}
+
+// A Report holds the problems of a query.
+type Report struct {
+	Query
+	Valid    bool           `json:"valid"`
+	Problems []lint.Problem `json:"problems"`
+}
+
+// location describes where the query was found, for the text output.
+func (q Query) location() string {
+	if q.Panel != "" {
+		return fmt.Sprintf("%s (panel %q)", q.File, q.Panel)
+	}
+	return fmt.Sprintf("%s:%d", q.File, q.Line)
+}
+
+func readQueries(path string) ([]Query, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	queries := []Query{}
+	if strings.HasSuffix(path, ".json") {
+		dashboard, err := lint.ParseDashboard(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err.Error())
+		}
+		for _, panel := range dashboard.Panels {
+			for _, query := range panel.Queries {
+				queries = append(queries, Query{File: path, Panel: panel.Title, Query: query})
+			}
+		}
+		return queries, nil
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for line := 1; scanner.Scan(); line++ {
+		query := strings.TrimSpace(scanner.Text())
+		if query == "" || strings.HasPrefix(query, "#") {
+			continue
+		}
+		queries = append(queries, Query{File: path, Line: line, Query: query})
+	}
+	return queries, scanner.Err()
+}
+
+func check(query Query) Report {
+	cmd, problems := lint.Parse(query.Query)
+	if cmd != nil {
+		problems = lint.Check(cmd, registry.Default())
+	}
+	if problems == nil {
+		problems = []lint.Problem{}
+	}
+	valid := true
+	for _, problem := range problems {
+		if problem.Severity == lint.Error || *strict {
+			valid = false
+		}
+	}
+	return Report{Query: query, Valid: valid, Problems: problems}
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] file...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || (*format != "text" && *format != "json") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	reports := []Report{}
+	for _, path := range flag.Args() {
+		queries, err := readQueries(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			os.Exit(2)
+		}
+		for _, query := range queries {
+			reports = append(reports, check(query))
+		}
+	}
+
+	valid := true
+	for _, report := range reports {
+		valid = valid && report.Valid
+	}
+	switch *format {
+	case "json":
+		encoded, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			os.Exit(2)
+		}
+		fmt.Printf("%s\n", encoded)
+	default:
+		for _, report := range reports {
+			for _, problem := range report.Problems {
+				position := ""
+				if problem.Position != "" {
+					position = fmt.Sprintf(" (at %s)", problem.Position)
+				}
+				fmt.Printf("%s: %s: %s%s\n", report.location(), problem.Severity, problem.Message, position)
+			}
+		}
+		if valid {
+			fmt.Printf("%d queries are valid\n", len(reports))
+		}
+	}
+	if !valid {
+		os.Exit(1)
+	}
+}
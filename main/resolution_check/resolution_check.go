@@ -0,0 +1,133 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// resolution_check runs each query of a file at its own resolution and at the
+// next coarser one, and reports results which differ by more than a tolerance.
+// It's meant to be run nightly over the most popular queries, to catch
+// functions whose math depends on the resolution of their input.
+//
+// The file holds one query per line; blank lines and lines starting with "#"
+// are ignored. The exit status is 1 if any query differs or fails.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/main/common"
+	"github.com/square/metrics/metric_metadata/cassandra"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/lint"
+	"github.com/square/metrics/timeseries/blueflood"
+	"github.com/square/metrics/util"
+)
+
+var (
+	queriesFile = flag.String("queries-file", "", "File of queries to check, one per line.")
+	tolerance   = flag.Float64("tolerance", 0.05, "Largest relative difference between resolutions which isn't reported.")
+)
+
+func readQueries(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	queries := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	return queries, scanner.Err()
+}
+
+func main() {
+	config := struct {
+		ConversionRulesPath string           `yaml:"conversion_rules_path"`
+		Cassandra           cassandra.Config `yaml:"cassandra"`
+		Blueflood           blueflood.Config `yaml:"blueflood"`
+	}{}
+
+	common.LoadConfig(&config)
+
+	if *queriesFile == "" {
+		common.ExitWithErrorMessage("No queries file specified")
+		return
+	}
+	queries, err := readQueries(*queriesFile)
+	if err != nil {
+		common.ExitWithErrorMessage("Error reading queries: %s", err.Error())
+		return
+	}
+
+	cassandraAPI, err := cassandra.NewMetricMetadataAPI(config.Cassandra)
+	if err != nil {
+		common.ExitWithErrorMessage("Error loading Cassandra API: %s", err.Error())
+		return
+	}
+
+	ruleset, err := util.LoadRules(config.ConversionRulesPath)
+	if err != nil {
+		common.ExitWithErrorMessage("Error loading conversion rules: %s", err.Error())
+		return
+	}
+
+	config.Blueflood.GraphiteMetricConverter = &util.RuleBasedGraphiteConverter{Ruleset: ruleset}
+
+	executionContext := command.ExecutionContext{
+		MetricMetadataAPI:    cassandraAPI,
+		TimeseriesStorageAPI: blueflood.NewBlueflood(config.Blueflood),
+		FetchLimit:           1500,
+		SlotLimit:            5000,
+		Registry:             registry.Default(),
+		Ctx:                  context.Background(),
+	}
+
+	failed := false
+	for _, query := range queries {
+		cmd, problems := lint.Parse(query)
+		if cmd == nil {
+			fmt.Printf("%s\n\tparse error: %s\n", query, problems[0].Message)
+			failed = true
+			continue
+		}
+		problems, err := lint.CompareResolutions(cmd, executionContext, *tolerance)
+		if err != nil {
+			fmt.Printf("%s\n\terror: %s\n", query, err.Error())
+			failed = true
+			continue
+		}
+		if len(problems) == 0 {
+			continue
+		}
+		fmt.Printf("%s\n", query)
+		for _, problem := range problems {
+			fmt.Printf("\t%s\n", problem.Message)
+		}
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+	fmt.Printf("%d queries agree across resolutions\n", len(queries))
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// restore_stores copies the archived results and the tenants' macros back
+// from the replicas of the web server's config, as after a node which kept
+// them in a local directory is replaced. Objects already present are kept
+// unless -overwrite is set.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/square/metrics/main/common"
+	"github.com/square/metrics/main/web/server"
+)
+
+var overwrite = flag.Bool("overwrite", false, "Replace objects which are already present with those of the replica.")
+
+func main() {
+	config := struct {
+		Web server.Config `yaml:"web"`
+	}{}
+
+	common.LoadConfig(&config)
+
+	copied, err := server.RestoreStores(config.Web, *overwrite)
+	names := []string{}
+	for name := range copied {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s: restored %d objects\n", name, copied[name])
+	}
+	if err != nil {
+		common.ExitWithErrorMessage("Error restoring: %s", err.Error())
+		return
+	}
+	if len(names) == 0 {
+		common.ExitWithErrorMessage("No store has a replica")
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/square/metrics/metric_metadata/alias"
+)
+
+// NewAliasHandler creates a handler reporting the metric alias table along
+// with the usage of each alias. A POST reloads the table from its file.
+func NewAliasHandler(table *alias.Table) http.Handler {
+	return reloadHandler{
+		reload: table.Reload,
+		report: func() interface{} {
+			return map[string]interface{}{
+				"aliases": table.Aliases(),
+				"usage":   table.Usage(),
+			}
+		},
+	}
+}
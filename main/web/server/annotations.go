@@ -0,0 +1,34 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/square/metrics/annotations"
+)
+
+// NewAnnotationsHandler creates a handler reporting the annotations, such as
+// maintenance windows. A POST reloads them from their file.
+func NewAnnotationsHandler(store *annotations.Store) http.Handler {
+	return reloadHandler{
+		reload: store.Reload,
+		report: func() interface{} {
+			return map[string]interface{}{
+				"annotations": store.Annotations(),
+			}
+		},
+	}
+}
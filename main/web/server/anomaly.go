@@ -0,0 +1,189 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/square/metrics/anomaly"
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+)
+
+// AnomalyForm is the request of /analyze/anomaly.
+type AnomalyForm struct {
+	Input     string `query:"query" json:"query"`         // a select command
+	Detector  string `query:"detector" json:"detector"`   // the name of the detector: zscore (the default) or seasonal_esd
+	Period    string `query:"period" json:"period"`       // the length of a season, such as 1d, for seasonal detectors
+	Threshold string `query:"threshold" json:"threshold"` // the score above which points are anomalous, for detectors without a test of their own
+}
+
+// AnomalyResult scores the series of one expression of the select.
+type AnomalyResult struct {
+	Query     string          `json:"query"`
+	Name      string          `json:"name"`
+	Detector  string          `json:"detector"`
+	Timerange api.Timerange   `json:"timerange"`
+	Series    []SeriesAnomaly `json:"series"` // ordered from the most to the least anomalous
+}
+
+// SeriesAnomaly holds the scores of a series and its anomalous points.
+type SeriesAnomaly struct {
+	TagSet    api.TagSet     `json:"tagset"`
+	Scores    api.Timeseries `json:"scores"`
+	MaxScore  float64        `json:"max_score"`
+	Anomalies []Anomaly      `json:"anomalies"`
+}
+
+// Anomaly is an anomalous point of a series.
+type Anomaly struct {
+	Timestamp int64   `json:"timestamp"` // milliseconds
+	Value     float64 `json:"value"`
+	Score     float64 `json:"score"`
+}
+
+// anomalyHandler runs a select and scores each point of the resulting series
+// with an anomaly detector, for tools which triage many metrics at once.
+type anomalyHandler struct {
+	context command.ExecutionContext
+	clients clientProfiles
+}
+
+func (h anomalyHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "GET" && request.Method != "POST" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	if err := request.ParseForm(); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(err))
+		return
+	}
+	form := AnomalyForm{}
+	parseStruct(request.Form, &form)
+
+	context := h.context
+	if client, ok := h.clients.match(request); ok {
+		context = client.Apply(context)
+	}
+	body, err := analyzeAnomalies(context, form)
+	if err != nil {
+		writer.WriteHeader(errorStatus(err))
+		writer.Write(encodeError(err))
+		return
+	}
+	writeResponse(writer, "anomaly", body)
+}
+
+func analyzeAnomalies(context command.ExecutionContext, form AnomalyForm) ([]AnomalyResult, error) {
+	if form.Detector == "" {
+		form.Detector = "zscore"
+	}
+	detector, err := anomaly.Lookup(form.Detector)
+	if err != nil {
+		return nil, err
+	}
+	options := anomaly.Options{}
+	var period time.Duration
+	if form.Period != "" {
+		period, err = function.StringToDuration(form.Period)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if form.Threshold != "" {
+		options.Threshold, err = strconv.ParseFloat(form.Threshold, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q", form.Threshold)
+		}
+	}
+	cmd, err := parser.Parse(form.Input)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cmd.(*command.SelectCommand); !ok {
+		return nil, fmt.Errorf("anomalies can only be found in the results of a select, not a %s", cmd.Name())
+	}
+	result, err := cmd.Execute(context)
+	if err != nil {
+		return nil, err
+	}
+
+	analyses := []AnomalyResult{}
+	for _, queryResult := range result.Body.([]command.QueryResult) {
+		if queryResult.Type != "series" {
+			return nil, fmt.Errorf("%s results in %s, but anomalies can only be found in series", queryResult.Query, queryResult.Type)
+		}
+		options.Period = int(period / queryResult.Timerange.Resolution())
+		analysis := AnomalyResult{
+			Query:     queryResult.Query,
+			Name:      queryResult.Name,
+			Detector:  form.Detector,
+			Timerange: queryResult.Timerange,
+			Series:    []SeriesAnomaly{},
+		}
+		for _, series := range queryResult.Series {
+			detected, err := detector.Detect(series.Values, options)
+			if err != nil {
+				return nil, err
+			}
+			scored := SeriesAnomaly{
+				TagSet:    series.TagSet,
+				Scores:    api.Timeseries{TagSet: series.TagSet, Values: detected.Scores},
+				Anomalies: make([]Anomaly, len(detected.Anomalies)),
+			}
+			for _, score := range detected.Scores {
+				if !math.IsNaN(score) && score > scored.MaxScore {
+					scored.MaxScore = score
+				}
+			}
+			for i, index := range detected.Anomalies {
+				scored.Anomalies[i] = Anomaly{
+					Timestamp: queryResult.Timerange.TimeOfIndex(index).UnixNano() / 1e6,
+					Value:     series.Values[index],
+					Score:     detected.Scores[index],
+				}
+			}
+			analysis.Series = append(analysis.Series, scored)
+		}
+		sort.Stable(byMaxScore(analysis.Series))
+		analyses = append(analyses, analysis)
+	}
+	return analyses, nil
+}
+
+// byMaxScore orders series from the most to the least anomalous.
+type byMaxScore []SeriesAnomaly
+
+func (s byMaxScore) Len() int {
+	return len(s)
+}
+
+func (s byMaxScore) Less(i, j int) bool {
+	return s[i].MaxScore > s[j].MaxScore
+}
+
+func (s byMaxScore) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
@@ -0,0 +1,91 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestAnomalyHandler(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 190, 10)
+	a.CheckError(err)
+	steady := make([]float64, timerange.Slots())
+	spiky := make([]float64, timerange.Slots())
+	for i := range steady {
+		steady[i] = float64(i % 2)
+		spiky[i] = float64(i % 2)
+	}
+	spiky[7] = 30
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: steady, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+		api.Timeseries{Values: spiky, TagSet: api.TagSet{"metric": "cpu", "host": "b"}},
+	)
+	handler := anomalyHandler{context: command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	}}
+	serve := func(form url.Values) (int, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/analyze/anomaly", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+
+	code, body := serve(url.Values{"query": {"select cpu from 0 to 190 resolution 10ms"}})
+	a.EqInt(code, http.StatusOK)
+	var response struct {
+		Body []struct {
+			Detector string
+			Series   []struct {
+				TagSet    api.TagSet `json:"tagset"`
+				MaxScore  float64    `json:"max_score"`
+				Anomalies []Anomaly
+			}
+		}
+	}
+	a.CheckError(json.Unmarshal([]byte(body), &response))
+	a.EqInt(len(response.Body), 1)
+	a.EqString(response.Body[0].Detector, "zscore")
+	series := response.Body[0].Series
+	a.EqInt(len(series), 2)
+	a.EqString(series[0].TagSet["host"], "b")
+	a.Eq(series[0].Anomalies, []Anomaly{{Timestamp: 70, Value: 30, Score: series[0].MaxScore}})
+	a.EqInt(len(series[1].Anomalies), 0)
+
+	for _, form := range []url.Values{
+		{"query": {"select cpu from 0 to 190 resolution 10ms"}, "detector": {"magic"}},
+		{"query": {"select cpu from 0 to 190 resolution 10ms"}, "threshold": {"high"}},
+		{"query": {"describe cpu"}},
+		{"query": {"select cpu | summarize.mean from 0 to 190 resolution 10ms"}},
+	} {
+		code, _ := serve(form)
+		a.Contextf("%v", form).EqInt(code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/square/metrics/archive"
+)
+
+// ArchiveConfig chooses where the results of queries flagged for archival
+// are kept. At most one of Directory and URL may be set.
+type ArchiveConfig struct {
+	Directory   string             `yaml:"directory"`   // a local (or mounted) directory
+	URL         string             `yaml:"url"`         // the HTTP(S) URL of an S3 or GCS bucket
+	Headers     map[string]string  `yaml:"headers"`     // sent with each request to the bucket, such as Authorization
+	Credentials *CredentialsConfig `yaml:"credentials"` // optional. Signs each request to the bucket
+	Replica     *ArchiveConfig     `yaml:"replica"`     // optional. Where objects are copied in the background, so that they outlive a local directory
+}
+
+// CredentialsConfig holds the keys which sign requests to an S3 bucket, or to
+// a GCS bucket with HMAC keys (with the region "auto").
+type CredentialsConfig struct {
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	SessionToken    string `yaml:"session_token"` // optional
+	Region          string `yaml:"region"`
+}
+
+// errArchiveToken refuses requests which would read or write the archive
+// without the API token of a client profile.
+var errArchiveToken = fmt.Errorf("the archive can only be used with the API token of a client profile")
+
+// newArchiver returns nil if archival isn't configured.
+func newArchiver(config ArchiveConfig) (*archive.Archiver, error) {
+	store, err := newObjectStore(config)
+	if store == nil || err != nil {
+		return nil, err
+	}
+	return archive.NewArchiver(store), nil
+}
+
+// newObjectStore returns nil if neither a directory nor a URL is configured.
+// If a replica is configured, the objects are replicated to it.
+func newObjectStore(config ArchiveConfig) (archive.ObjectStore, error) {
+	primary, err := newPrimaryStore(config)
+	if primary == nil || err != nil || config.Replica == nil {
+		return primary, err
+	}
+	replica, err := newReplicaStore(config)
+	if err != nil {
+		return nil, err
+	}
+	return archive.NewReplicatedStore(primary, replica), nil
+}
+
+func newReplicaStore(config ArchiveConfig) (archive.ObjectStore, error) {
+	if config.Replica.Replica != nil {
+		return nil, fmt.Errorf("a replica may not have a replica of its own")
+	}
+	replica, err := newPrimaryStore(*config.Replica)
+	if err != nil {
+		return nil, fmt.Errorf("the replica is invalid: %s", err.Error())
+	}
+	if replica == nil {
+		return nil, fmt.Errorf("the replica needs a directory or a URL")
+	}
+	return replica, nil
+}
+
+func newPrimaryStore(config ArchiveConfig) (archive.ObjectStore, error) {
+	switch {
+	case config.Directory != "" && config.URL != "":
+		return nil, fmt.Errorf("an object store may have a directory or a URL, but not both")
+	case config.Directory != "":
+		return archive.DirectoryStore{Directory: config.Directory}, nil
+	case config.URL != "":
+		store := archive.BucketStore{URL: config.URL, Headers: config.Headers}
+		if credentials := config.Credentials; credentials != nil {
+			if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" || credentials.Region == "" {
+				return nil, fmt.Errorf("the credentials of a bucket need an access key ID, a secret access key and a region")
+			}
+			store.Credentials = &archive.Credentials{
+				AccessKeyID:     credentials.AccessKeyID,
+				SecretAccessKey: credentials.SecretAccessKey,
+				SessionToken:    credentials.SessionToken,
+				Region:          credentials.Region,
+			}
+		}
+		return store, nil
+	}
+	return nil, nil
+}
+
+// RestoreStores copies the objects of the replicated stores (those of the
+// archive, of the tenants' macros and of the histories) from their
+// replicas, as when a node which lost its local directory is replaced. It
+// returns the number of objects copied to each store.
+func RestoreStores(config Config, overwrite bool) (map[string]int, error) {
+	copied := map[string]int{}
+	for _, store := range []struct {
+		name   string
+		config ArchiveConfig
+	}{
+		{"archive", config.Archive},
+		{"tenants", config.Tenants.Store},
+		{"history", config.History.Store},
+	} {
+		if store.config.Replica == nil {
+			continue
+		}
+		primary, err := newPrimaryStore(store.config)
+		if err != nil {
+			return copied, err
+		}
+		if primary == nil {
+			return copied, fmt.Errorf("the %s store has a replica, but no directory or URL", store.name)
+		}
+		replica, err := newReplicaStore(store.config)
+		if err != nil {
+			return copied, err
+		}
+		count, err := archive.Restore(replica, primary, overwrite)
+		copied[store.name] = count
+		if err != nil {
+			return copied, fmt.Errorf("cannot restore the %s store: %s", store.name, err.Error())
+		}
+	}
+	return copied, nil
+}
+
+// archiveHandler lists the archived results at /archive, and serves each
+// result at /archive/<id> as it was originally returned by /query. Requests
+// need the API token of a client profile.
+type archiveHandler struct {
+	archiver *archive.Archiver
+	clients  clientProfiles
+}
+
+func (h archiveHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if _, ok := h.clients.matchToken(request); !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write(encodeError(errArchiveToken))
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(request.URL.Path, "/archive"), "/")
+	if id == "" {
+		entries, err := h.archiver.List()
+		if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			writer.Write(encodeError(err))
+			return
+		}
+		writeResponse(writer, "archive", entries)
+		return
+	}
+	entry, data, err := h.archiver.Get(id)
+	if err == archive.ErrNotFound {
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(encodeError(fmt.Errorf("no archived result has the ID %q", id)))
+		return
+	}
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(encodeError(err))
+		return
+	}
+	writer.Header().Set("X-Archived-At", entry.ArchivedAt.Format("2006-01-02T15:04:05Z"))
+	writer.Write(data)
+}
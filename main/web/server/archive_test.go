@@ -0,0 +1,146 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/archive"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestArchiveHandlers(t *testing.T) {
+	a := assert.New(t)
+	directory, err := ioutil.TempDir("", "archive")
+	a.CheckError(err)
+	defer os.RemoveAll(directory)
+	archiver, err := newArchiver(ArchiveConfig{Directory: directory})
+	a.CheckError(err)
+
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": "a"}})
+	context := command.ExecutionContext{MetricMetadataAPI: fakeAPI, FetchLimit: 1000, Ctx: context.Background()}
+	clients, err := newClientProfiles([]ClientProfile{{Name: "reports", Tokens: []string{"reports-token"}}})
+	a.CheckError(err)
+	queries := queryHandler{context: context, archiver: archiver, clients: clients}
+	archives := archiveHandler{archiver: archiver, clients: clients}
+
+	token := "reports-token"
+	serve := func(handler http.Handler, method string, path string, body string) (int, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+
+	code, body := serve(queries, "POST", "/query", "query=describe+cpu&archive=hosts")
+	a.EqInt(code, http.StatusOK)
+	var response struct {
+		Metadata struct {
+			Archive archive.Entry `json:"archive"`
+		} `json:"metadata"`
+	}
+	a.CheckError(json.Unmarshal([]byte(body), &response))
+	entry := response.Metadata.Archive
+	a.EqString(entry.Name, "hosts")
+	a.EqString(entry.Query, "describe cpu")
+
+	code, body = serve(archives, "GET", "/archive", "")
+	a.EqInt(code, http.StatusOK)
+	var list struct {
+		Body []archive.Entry `json:"body"`
+	}
+	a.CheckError(json.Unmarshal([]byte(body), &list))
+	a.EqInt(len(list.Body), 1)
+	a.EqString(list.Body[0].ID, entry.ID)
+
+	code, body = serve(archives, "GET", "/archive/"+entry.ID, "")
+	a.EqInt(code, http.StatusOK)
+	a.EqBool(strings.HasPrefix(body, `{"success":true,"name":"describe","body":{"host":["a"]},`), true)
+
+	code, _ = serve(archives, "GET", "/archive/missing", "")
+	a.EqInt(code, http.StatusNotFound)
+
+	// Without a client's token, the archive can't be read or written.
+	token = ""
+	code, _ = serve(archives, "GET", "/archive", "")
+	a.EqInt(code, http.StatusUnauthorized)
+	code, _ = serve(archives, "GET", "/archive/"+entry.ID, "")
+	a.EqInt(code, http.StatusUnauthorized)
+	code, _ = serve(queries, "POST", "/query", "query=describe+cpu&archive=hosts")
+	a.EqInt(code, http.StatusUnauthorized)
+	token = "reports-token"
+
+	// Without an archive, flagged queries are refused.
+	code, _ = serve(queryHandler{context: context}, "POST", "/query", "query=describe+cpu&archive=hosts")
+	a.EqInt(code, http.StatusBadRequest)
+
+	if _, err := newArchiver(ArchiveConfig{Directory: directory, URL: "https://example.com/bucket"}); err == nil {
+		t.Errorf("expected an error for an archive with both a directory and a URL")
+	}
+	if _, err := newArchiver(ArchiveConfig{URL: "https://example.com/bucket", Credentials: &CredentialsConfig{AccessKeyID: "key"}}); err == nil {
+		t.Errorf("expected an error for incomplete credentials")
+	}
+}
+
+func TestRestoreStores(t *testing.T) {
+	a := assert.New(t)
+	primary, err := ioutil.TempDir("", "primary")
+	a.CheckError(err)
+	defer os.RemoveAll(primary)
+	replica, err := ioutil.TempDir("", "replica")
+	a.CheckError(err)
+	defer os.RemoveAll(replica)
+
+	config := Config{Archive: ArchiveConfig{Directory: primary, Replica: &ArchiveConfig{Directory: replica}}}
+	store, err := newObjectStore(config.Archive)
+	a.CheckError(err)
+	replicated, ok := store.(*archive.ReplicatedStore)
+	if !ok {
+		t.Fatalf("expected a replicated store, got %T", store)
+	}
+	a.CheckError(replicated.Put("results/a.json", []byte("a")))
+	replicated.Wait()
+
+	// The node is replaced, and its directory lost.
+	a.CheckError(os.RemoveAll(primary))
+	copied, err := RestoreStores(config, false)
+	a.CheckError(err)
+	a.EqInt(copied["archive"], 1)
+	data, err := archive.DirectoryStore{Directory: primary}.Get("results/a.json")
+	a.CheckError(err)
+	a.EqString(string(data), "a")
+
+	_, err = newObjectStore(ArchiveConfig{Directory: primary, Replica: &ArchiveConfig{}})
+	if err == nil {
+		t.Errorf("expected an error for a replica without a directory or URL")
+	}
+}
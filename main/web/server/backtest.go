@@ -0,0 +1,254 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/query/predicate"
+)
+
+// BacktestForm is the request of /analyze/backtest.
+type BacktestForm struct {
+	Input string `query:"query" json:"query"` // a select whose series are non-zero while the rule's condition holds, such as select cpu.user > 0.9 from -7d to now
+	For   string `query:"for" json:"for"`     // how long the condition must hold before the rule fires, such as 5m; defaults to 0
+
+	SuppressMaintenance bool `query:"suppress_maintenance" json:"suppress_maintenance"` // if true, the rule doesn't fire for series during their maintenance windows
+}
+
+// BacktestResult describes when the rule of one expression of the select
+// would have fired.
+type BacktestResult struct {
+	Query     string           `json:"query"`
+	Name      string           `json:"name"`
+	Timerange api.Timerange    `json:"timerange"`
+	Series    []SeriesBacktest `json:"series"` // the series which would have fired, from those firing longest
+}
+
+// SeriesBacktest lists the alerts which a series would have raised.
+type SeriesBacktest struct {
+	TagSet        api.TagSet   `json:"tagset"`
+	FiringSeconds float64      `json:"firing_seconds"` // the total time spent firing
+	Events        []AlertEvent `json:"events"`
+}
+
+// AlertEvent is a period during which the rule would have fired.
+type AlertEvent struct {
+	Fired           int64   `json:"fired"`              // milliseconds
+	Resolved        *int64  `json:"resolved,omitempty"` // milliseconds; missing if the rule was still firing at the end of the timerange
+	DurationSeconds float64 `json:"duration_seconds"`
+	Link            string  `json:"link"` // the UI, graphing the underlying series around the event
+}
+
+// backtestMargin is the number of slots shown on either side of an event by
+// its link.
+const backtestMargin = 10
+
+// backtestHandler evaluates a proposed alert rule over history, so that its
+// threshold and duration can be tuned before it pages anybody.
+type backtestHandler struct {
+	context command.ExecutionContext
+	clients clientProfiles
+}
+
+func (h backtestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "GET" && request.Method != "POST" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	if err := request.ParseForm(); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(err))
+		return
+	}
+	form := BacktestForm{}
+	parseStruct(request.Form, &form)
+
+	context := h.context
+	if client, ok := h.clients.match(request); ok {
+		context = client.Apply(context)
+	}
+	body, err := backtest(context, form)
+	if err != nil {
+		writer.WriteHeader(errorStatus(err))
+		writer.Write(encodeError(err))
+		return
+	}
+	writeResponse(writer, "backtest", body)
+}
+
+func backtest(context command.ExecutionContext, form BacktestForm) ([]BacktestResult, error) {
+	var pending time.Duration
+	if form.For != "" {
+		var err error
+		pending, err = function.StringToDuration(form.For)
+		if err != nil {
+			return nil, err
+		}
+		if pending < 0 {
+			return nil, fmt.Errorf("the duration of a rule's condition cannot be negative, but was %s", form.For)
+		}
+	}
+	cmd, err := parser.Parse(form.Input)
+	if err != nil {
+		return nil, err
+	}
+	selectCommand, ok := cmd.(*command.SelectCommand)
+	if !ok {
+		return nil, fmt.Errorf("rules can only be evaluated from a select, not a %s", cmd.Name())
+	}
+	context.SuppressMaintenance = form.SuppressMaintenance
+	result, err := cmd.Execute(context)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []BacktestResult{}
+	for i, queryResult := range result.Body.([]command.QueryResult) {
+		if queryResult.Type != "series" {
+			return nil, fmt.Errorf("%s results in %s, but rules can only be evaluated from series", queryResult.Query, queryResult.Type)
+		}
+		timerange := queryResult.Timerange
+		backtested := BacktestResult{
+			Query:     queryResult.Query,
+			Name:      queryResult.Name,
+			Timerange: timerange,
+			Series:    []SeriesBacktest{},
+		}
+		underlying := ruleOperand(selectCommand.Expressions[i]).ExpressionDescription(function.StringQuery())
+		for _, series := range queryResult.Series {
+			events := alertEvents(series.Values, timerange, pending)
+			if len(events) == 0 {
+				continue
+			}
+			fired := SeriesBacktest{TagSet: series.TagSet, Events: events}
+			for j := range fired.Events {
+				event := &fired.Events[j]
+				fired.FiringSeconds += event.DurationSeconds
+				end := timerange.EndMillis()
+				if event.Resolved != nil {
+					end = *event.Resolved
+				}
+				margin := backtestMargin * timerange.ResolutionMillis()
+				event.Link = eventLink(underlying, selectCommand.Predicate, series.TagSet, event.Fired-pending.Nanoseconds()/1e6-margin, end+margin, timerange.ResolutionMillis())
+			}
+			backtested.Series = append(backtested.Series, fired)
+		}
+		sort.Stable(byFiringSeconds(backtested.Series))
+		results = append(results, backtested)
+	}
+	return results, nil
+}
+
+// alertEvents finds the periods during which the rule would have fired: it
+// fires once the values have been non-zero for the pending duration, and is
+// resolved at the first zero or missing value after that.
+func alertEvents(values []float64, timerange api.Timerange, pending time.Duration) []AlertEvent {
+	events := []AlertEvent{}
+	since := -1 // the slot from which the condition has held
+	var firing *AlertEvent
+	for i, value := range values {
+		if value == 0 || math.IsNaN(value) {
+			if firing != nil {
+				resolved := timerange.TimeOfIndex(i).UnixNano() / 1e6
+				firing.Resolved = &resolved
+				firing.DurationSeconds = float64(resolved-firing.Fired) / 1000
+				events = append(events, *firing)
+				firing = nil
+			}
+			since = -1
+			continue
+		}
+		if since < 0 {
+			since = i
+		}
+		if firing == nil && timerange.TimeOfIndex(i).Sub(timerange.TimeOfIndex(since)) >= pending {
+			firing = &AlertEvent{Fired: timerange.TimeOfIndex(i).UnixNano() / 1e6}
+		}
+	}
+	if firing != nil {
+		firing.DurationSeconds = float64(timerange.EndMillis()+timerange.ResolutionMillis()-firing.Fired) / 1000
+		events = append(events, *firing)
+	}
+	return events
+}
+
+// comparisonOperators are the operators whose left operand is the series
+// which a rule watches.
+var comparisonOperators = map[string]bool{">": true, "<": true, ">=": true, "<=": true, "==": true, "!=": true}
+
+// ruleOperand returns the series which a rule compares against its
+// threshold, or the rule itself if it isn't a comparison.
+func ruleOperand(rule function.Expression) function.Expression {
+	actual, ok := function.Unmemoize(rule)
+	if !ok {
+		return rule
+	}
+	call, ok := actual.(*expression.FunctionExpression)
+	if !ok || !comparisonOperators[call.FunctionName] || len(call.Arguments) != 2 {
+		return rule
+	}
+	return call.Arguments[0]
+}
+
+// eventLink links to the UI, graphing the expression for the series with the
+// given tags.
+func eventLink(query string, condition predicate.Predicate, tagset api.TagSet, start int64, end int64, resolution int64) string {
+	matchers := []predicate.Predicate{}
+	if _, ok := condition.(predicate.TruePredicate); !ok {
+		matchers = append(matchers, condition)
+	}
+	tags := make([]string, 0, len(tagset))
+	for tag := range tagset {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		matchers = append(matchers, predicate.ListMatcher{Tag: tag, Values: []string{tagset[tag]}})
+	}
+	where := ""
+	if len(matchers) != 0 {
+		where = " where " + predicate.All(matchers...).Query()
+	}
+	selectQuery := fmt.Sprintf("select %s%s from %d to %d resolution %dms", query, where, start, end, resolution)
+	return "/ui?query=" + url.QueryEscape(selectQuery)
+}
+
+// byFiringSeconds orders series from those which would have fired longest.
+type byFiringSeconds []SeriesBacktest
+
+func (s byFiringSeconds) Len() int {
+	return len(s)
+}
+
+func (s byFiringSeconds) Less(i, j int) bool {
+	return s[i].FiringSeconds > s[j].FiringSeconds
+}
+
+func (s byFiringSeconds) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
@@ -0,0 +1,112 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/annotations"
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestBacktestHandler(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 90, 10)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 5, 5, 1, 5, 5, 5, 1, 5, 5}, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+		api.Timeseries{Values: []float64{5, 1, 5, 1, 5, 1, 5, 1, 5, 1}, TagSet: api.TagSet{"metric": "cpu", "host": "b"}},
+		api.Timeseries{Values: []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, TagSet: api.TagSet{"metric": "cpu", "host": "c"}},
+	)
+	handler := backtestHandler{context: command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	}}
+	serve := func(form url.Values) (int, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/analyze/backtest", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+	var response struct {
+		Body []BacktestResult
+	}
+
+	code, body := serve(url.Values{"query": {"select cpu > 3 from 0 to 90 resolution 10ms"}, "for": {"10ms"}})
+	a.EqInt(code, http.StatusOK)
+	a.CheckError(json.Unmarshal([]byte(body), &response))
+	a.EqInt(len(response.Body), 1)
+	series := response.Body[0].Series
+	a.EqInt(len(series), 1) // host b never holds for long enough, and host c never exceeds the threshold
+	a.EqString(series[0].TagSet["host"], "a")
+	events := series[0].Events
+	a.EqInt(len(events), 3)
+	a.EqInt(int(events[0].Fired), 20)
+	a.EqInt(int(*events[0].Resolved), 30)
+	a.EqInt(int(events[1].Fired), 50)
+	a.EqInt(int(*events[1].Resolved), 70)
+	a.EqInt(int(events[2].Fired), 90)
+	a.EqBool(events[2].Resolved == nil, true)
+	a.EqFloat(series[0].FiringSeconds, 0.04, 1e-9)
+	link, err := url.Parse(events[1].Link)
+	a.CheckError(err)
+	a.EqString(link.Path, "/ui")
+	a.EqString(link.Query().Get("query"), `select cpu where host = "a" from -60 to 170 resolution 10ms`)
+
+	code, body = serve(url.Values{"query": {"select cpu > 3 from 0 to 90 resolution 10ms"}})
+	a.EqInt(code, http.StatusOK)
+	a.CheckError(json.Unmarshal([]byte(body), &response))
+	series = response.Body[0].Series
+	a.EqInt(len(series), 2)
+	a.EqString(series[0].TagSet["host"], "a") // firing longest
+	a.EqInt(len(series[1].Events), 5)
+
+	// The rule doesn't fire for host a during its maintenance window.
+	notes, err := annotations.NewStore([]annotations.Annotation{
+		{Type: annotations.Maintenance, Where: "host = 'a'", Start: time.Unix(0, 40*1e6), End: time.Unix(0, 80*1e6)},
+	})
+	a.CheckError(err)
+	handler.context.MaintenanceAPI = notes
+	code, body = serve(url.Values{"query": {"select cpu > 3 from 0 to 90 resolution 10ms"}, "for": {"10ms"}, "suppress_maintenance": {"true"}})
+	a.EqInt(code, http.StatusOK)
+	a.CheckError(json.Unmarshal([]byte(body), &response))
+	events = response.Body[0].Series[0].Events
+	a.EqInt(len(events), 2)
+	a.EqInt(int(events[0].Fired), 20)
+	a.EqInt(int(events[1].Fired), 90)
+
+	for _, form := range []url.Values{
+		{"query": {"select cpu > 3 from 0 to 90 resolution 10ms"}, "for": {"-1m"}},
+		{"query": {"select cpu > 3 from 0 to 90 resolution 10ms"}, "for": {"soon"}},
+		{"query": {"describe cpu"}},
+		{"query": {"select cpu > 3 | summarize.mean from 0 to 90 resolution 10ms"}},
+	} {
+		code, _ := serve(form)
+		a.Contextf("%v", form).EqInt(code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/square/metrics/canary"
+)
+
+// canaryHandler reports the checks of the canary at /admin/canary. It
+// responds 503 while the last check has failed, so that it may be probed by
+// an external monitor.
+type canaryHandler struct {
+	canary *canary.Canary
+}
+
+// NewCanaryHandler creates a handler reporting the given canary.
+func NewCanaryHandler(canary *canary.Canary) http.Handler {
+	return canaryHandler{canary: canary}
+}
+
+func (h canaryHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "GET" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	status := h.canary.Status()
+	code := http.StatusOK
+	if status.Checks != 0 && !status.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(writer, code, Response{Success: true, QueryResponse: QueryResponse{Body: status}})
+}
@@ -0,0 +1,80 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/canary"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) WritePoint(metric api.TaggedMetric, t time.Time, value float64) error {
+	return errors.New("ingestion is down")
+}
+
+func TestCanaryHandler(t *testing.T) {
+	for _, test := range []struct {
+		writer func(*memory.Store) canary.Writer
+		code   int
+	}{
+		{func(store *memory.Store) canary.Writer { return canary.SketchWriter{Storage: store} }, http.StatusOK},
+		{func(store *memory.Store) canary.Writer { return failingWriter{} }, http.StatusServiceUnavailable},
+	} {
+		a := assert.New(t).Contextf("expecting %d", test.code)
+		store := memory.NewStore(time.Second)
+		probe := canary.New(canary.Config{IntervalSeconds: 60}, test.writer(store), store, command.ExecutionContext{
+			TimeseriesStorageAPI: store,
+			MetricMetadataAPI:    store,
+			FetchLimit:           10,
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		go probe.Run(ctx)
+		deadline := time.Now().Add(5 * time.Second)
+		for probe.Status().Checks == 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("the canary made no check")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+
+		handler := NewCanaryHandler(probe)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/admin/canary", nil))
+		a.EqInt(recorder.Code, test.code)
+		var response struct {
+			Body canary.Status `json:"body"`
+		}
+		a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &response))
+		a.EqInt(response.Body.Checks, 1)
+		a.EqBool(response.Body.Healthy, test.code == http.StatusOK)
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/admin/canary", nil))
+		a.EqInt(recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
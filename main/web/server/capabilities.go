@@ -0,0 +1,112 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/command"
+)
+
+// Capabilities reports which optional subsystems are enabled on this
+// deployment, so that clients can adapt to them.
+type Capabilities struct {
+	Caching     bool             `json:"caching"`     // select or describe results are served from a cache
+	Ingestion   bool             `json:"ingestion"`   // metrics can be added through /ingest
+	Aliases     bool             `json:"aliases"`     // metric aliases are administered at /admin/aliases
+	Maintenance bool             `json:"maintenance"` // select queries can suppress maintenance windows
+	Backtesting bool             `json:"backtesting"` // alert rules can be evaluated over history at /analyze/backtest
+	Scripting   bool             `json:"scripting"`   // /query runs scripts of several statements
+	Persistence bool             `json:"persistence"` // each user's queries are kept by the server, at /history
+	Alerts      bool             `json:"alerts"`      // slow and rejected queries and ejected backends are sent to webhooks
+	Backends    BackendNames     `json:"backends"`
+	Limits      CapabilityLimits `json:"limits"`
+	Formats     []string         `json:"formats"`   // formats accepted by the query endpoint
+	Functions   []string         `json:"functions"` // names of the functions in the registry
+}
+
+// BackendNames names the storage and metadata backends in use.
+type BackendNames struct {
+	Storage  string `json:"storage,omitempty"`
+	Metadata string `json:"metadata,omitempty"`
+}
+
+// CapabilityLimits are the resource limits applied to each query.
+type CapabilityLimits struct {
+	FetchLimit            int     `json:"fetch_limit"`
+	SlotLimit             int     `json:"slot_limit"`
+	MemoryLimit           int64   `json:"memory_limit,omitempty"`
+	QueryTimeoutSeconds   float64 `json:"query_timeout_seconds,omitempty"`
+	RequestTimeoutSeconds int     `json:"request_timeout_seconds,omitempty"`
+}
+
+// DefaultCapabilities determines the capabilities which follow from the
+// server configuration and the execution context. Callers should fill in
+// what the server cannot know, such as the names of the backends.
+func DefaultCapabilities(config Config, context command.ExecutionContext) Capabilities {
+	_, canUpdate := context.MetricMetadataAPI.(metadata.MetricUpdateAPI)
+	slotLimit := context.SlotLimit
+	if slotLimit == 0 {
+		slotLimit = 1000
+	}
+	queryTimeout := context.Timeout
+	if config.QueryTimeout != 0 {
+		queryTimeout = time.Duration(config.QueryTimeout) * time.Second
+	}
+	memoryLimit := context.MemoryLimit
+	if config.MemoryLimit != 0 {
+		memoryLimit = config.MemoryLimit
+	}
+	functions := []string{}
+	if context.Registry != nil {
+		functions = append(functions, context.Registry.All()...)
+		sort.Strings(functions)
+	}
+	return Capabilities{
+		Caching:     context.ResultCache != nil || newResultCache(config.ResultCache) != nil || config.DescribeCache.FreshSeconds > 0,
+		Ingestion:   config.HTTPIngestion && canUpdate,
+		Maintenance: context.MaintenanceAPI != nil,
+		Backtesting: true,
+		Scripting:   true,
+		Persistence: config.History.Enabled,
+		Alerts:      len(config.Webhooks) != 0,
+		Limits: CapabilityLimits{
+			FetchLimit:            context.FetchLimit,
+			SlotLimit:             slotLimit,
+			MemoryLimit:           memoryLimit,
+			QueryTimeoutSeconds:   queryTimeout.Seconds(),
+			RequestTimeoutSeconds: config.Timeout,
+		},
+		Formats:   append([]string{}, builtinFormats...),
+		Functions: functions,
+	}
+}
+
+type capabilitiesHandler struct {
+	capabilities Capabilities
+}
+
+// NewCapabilitiesHandler creates a handler reporting the given capabilities.
+func NewCapabilitiesHandler(capabilities Capabilities) http.Handler {
+	return capabilitiesHandler{capabilities: capabilities}
+}
+
+func (h capabilitiesHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	writeResponse(writer, "", h.capabilities)
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/webhook"
+)
+
+func TestCapabilitiesHandler(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange)
+	capabilities := DefaultCapabilities(Config{Timeout: 10, HTTPIngestion: true}, command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1500,
+		Timeout:              5 * time.Second,
+		Registry:             registry.Default(),
+	})
+	capabilities.Backends.Storage = "mock"
+
+	recorder := httptest.NewRecorder()
+	NewCapabilitiesHandler(capabilities).ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/capabilities", nil))
+	a.EqInt(recorder.Code, http.StatusOK)
+	var response struct {
+		Body Capabilities `json:"body"`
+	}
+	a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &response))
+	a.EqBool(response.Body.Ingestion, true)
+	a.EqBool(response.Body.Maintenance, false)
+	a.EqBool(response.Body.Caching, false)
+	a.EqBool(response.Body.Backtesting, true)
+	a.EqBool(response.Body.Scripting, true)
+	a.EqBool(response.Body.Persistence, false)
+	a.EqString(response.Body.Backends.Storage, "mock")
+	a.EqInt(response.Body.Limits.FetchLimit, 1500)
+	a.EqInt(response.Body.Limits.SlotLimit, 1000)
+	a.EqFloat(response.Body.Limits.QueryTimeoutSeconds, 5, 1e-9)
+	a.EqInt(response.Body.Limits.RequestTimeoutSeconds, 10)
+	a.EqInt(len(response.Body.Functions), len(registry.Default().All()))
+	a.EqBool(response.Body.Alerts, false)
+
+	// Caching and alerts follow from the configuration.
+	capabilities = DefaultCapabilities(Config{
+		ResultCache: ResultCacheConfig{TTLSeconds: 60},
+		Webhooks:    []webhook.Config{{URL: "http://example.com/hook"}},
+	}, command.ExecutionContext{})
+	a.EqBool(capabilities.Caching, true)
+	a.EqBool(capabilities.Alerts, true)
+	capabilities = DefaultCapabilities(Config{DescribeCache: DescribeCacheConfig{FreshSeconds: 60}}, command.ExecutionContext{})
+	a.EqBool(capabilities.Caching, true)
+	a.EqBool(capabilities.Alerts, false)
+}
@@ -0,0 +1,135 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/tasks"
+)
+
+// ClientProfile assigns resource limits to a class of clients, so that (for
+// example) batch reports may fetch far more than interactive dashboards.
+// A request belongs to the first profile listing its API token (sent as
+// "Authorization: Bearer <token>") or matching its User-Agent.
+type ClientProfile struct {
+	Name        string   `yaml:"name"`
+	Tokens      []string `yaml:"tokens"`
+	UserAgents  []string `yaml:"user_agents"`  // regular expressions, matched against the whole User-Agent
+	FetchLimit  int      `yaml:"fetch_limit"`  // if nonzero, replaces the default fetch limit
+	SlotLimit   int      `yaml:"slot_limit"`   // if nonzero, replaces the default slot limit
+	MemoryLimit int64    `yaml:"memory_limit"` // if nonzero, replaces the default memory limit, in bytes
+	Priority    string   `yaml:"priority"`     // interactive (the default), alerts or batch
+	Tenant      string   `yaml:"tenant"`       // if set, queries may call the macros of this tenant
+}
+
+type clientProfile struct {
+	ClientProfile
+	priority   tasks.Priority
+	userAgents []*regexp.Regexp
+}
+
+// clientProfiles chooses the profile for each request.
+type clientProfiles []clientProfile
+
+func newClientProfiles(profiles []ClientProfile) (clientProfiles, error) {
+	result := make(clientProfiles, len(profiles))
+	for i, profile := range profiles {
+		if len(profile.Tokens) == 0 && len(profile.UserAgents) == 0 {
+			return nil, fmt.Errorf("client profile %q matches no clients; give it tokens or user agents", profile.Name)
+		}
+		result[i].ClientProfile = profile
+		result[i].priority = tasks.Interactive
+		if profile.Priority != "" {
+			priority, err := tasks.ParsePriority(profile.Priority)
+			if err != nil {
+				return nil, fmt.Errorf("client profile %q: %s", profile.Name, err.Error())
+			}
+			result[i].priority = priority
+		}
+		for _, userAgent := range profile.UserAgents {
+			regex, err := regexp.Compile("^(?:" + userAgent + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("client profile %q has an invalid user agent pattern: %s", profile.Name, err.Error())
+			}
+			result[i].userAgents = append(result[i].userAgents, regex)
+		}
+	}
+	return result, nil
+}
+
+// requestToken extracts the bearer token from the request, if any.
+func requestToken(request *http.Request) string {
+	authorization := request.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+}
+
+func (c clientProfiles) match(request *http.Request) (clientProfile, bool) {
+	token := requestToken(request)
+	userAgent := request.UserAgent()
+	for _, profile := range c {
+		if token != "" {
+			for _, allowed := range profile.Tokens {
+				if allowed == token {
+					return profile, true
+				}
+			}
+		}
+		for _, regex := range profile.userAgents {
+			if regex.MatchString(userAgent) {
+				return profile, true
+			}
+		}
+	}
+	return clientProfile{}, false
+}
+
+// matchToken finds the profile listing the request's token. Unlike match, it
+// ignores User-Agents, which any client can claim.
+func (c clientProfiles) matchToken(request *http.Request) (clientProfile, bool) {
+	token := requestToken(request)
+	if token == "" {
+		return clientProfile{}, false
+	}
+	for _, profile := range c {
+		for _, allowed := range profile.Tokens {
+			if allowed == token {
+				return profile, true
+			}
+		}
+	}
+	return clientProfile{}, false
+}
+
+// Apply replaces the limits of the context with those of the profile.
+func (p ClientProfile) Apply(context command.ExecutionContext) command.ExecutionContext {
+	if p.FetchLimit != 0 {
+		context.FetchLimit = p.FetchLimit
+	}
+	if p.SlotLimit != 0 {
+		context.SlotLimit = p.SlotLimit
+	}
+	if p.MemoryLimit != 0 {
+		context.MemoryLimit = p.MemoryLimit
+	}
+	return context
+}
@@ -0,0 +1,82 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestClientProfiles(t *testing.T) {
+	a := assert.New(t)
+	clients, err := newClientProfiles([]ClientProfile{
+		{Name: "batch", Tokens: []string{"reporting-secret"}, SlotLimit: 50000, Priority: "batch"},
+		{Name: "scripts", UserAgents: []string{`curl/.*`, `python-requests/.*`}, FetchLimit: 100},
+	})
+	a.CheckError(err)
+	defaults := command.ExecutionContext{FetchLimit: 1500, SlotLimit: 1000}
+
+	tests := []struct {
+		token     string
+		userAgent string
+		name      string
+		fetch     int
+		slots     int
+	}{
+		{token: "reporting-secret", userAgent: "curl/7.1", name: "batch", fetch: 1500, slots: 50000},
+		{token: "wrong", userAgent: "curl/7.1", name: "scripts", fetch: 100, slots: 1000},
+		{userAgent: "python-requests/2.0", name: "scripts", fetch: 100, slots: 1000},
+		{userAgent: "Mozilla/5.0 (curl/7.1)", name: "", fetch: 1500, slots: 1000},
+		{name: "", fetch: 1500, slots: 1000},
+	}
+	for _, test := range tests {
+		a := a.Contextf("token %q, user agent %q", test.token, test.userAgent)
+		request := httptest.NewRequest("GET", "/query", nil)
+		if test.token != "" {
+			request.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		request.Header.Set("User-Agent", test.userAgent)
+		context := defaults
+		profile, ok := clients.match(request)
+		a.EqBool(ok, test.name != "")
+		a.EqString(profile.Name, test.name)
+		if ok {
+			context = profile.Apply(context)
+		}
+		a.EqInt(context.FetchLimit, test.fetch)
+		a.EqInt(context.SlotLimit, test.slots)
+		if test.name == "batch" {
+			a.EqString(profile.priority.String(), "batch")
+		} else if ok {
+			a.EqString(profile.priority.String(), "interactive")
+		}
+	}
+
+	_, err = newClientProfiles([]ClientProfile{{Name: "nobody", SlotLimit: 10}})
+	if err == nil {
+		t.Errorf("expected an error for a profile matching no clients")
+	}
+	_, err = newClientProfiles([]ClientProfile{{Name: "broken", UserAgents: []string{"("}}})
+	if err == nil {
+		t.Errorf("expected an error for an invalid user agent pattern")
+	}
+	_, err = newClientProfiles([]ClientProfile{{Name: "urgent", Tokens: []string{"x"}, Priority: "urgent"}})
+	if err == nil {
+		t.Errorf("expected an error for an unknown priority class")
+	}
+}
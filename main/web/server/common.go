@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"path"
 	"reflect"
 	"strconv"
 
@@ -30,6 +29,7 @@ func encodeError(err error) []byte {
 	encoded, err2 := json.MarshalIndent(Response{
 		Success: false,
 		Message: err.Error(),
+		Code:    classifyError(err).Code,
 	}, "", "  ")
 	if err2 == nil {
 		return encoded
@@ -38,18 +38,28 @@ func encodeError(err error) []byte {
 	return []byte(`{"success":false, "error": "internal server error while marshalling error message"}`)
 }
 
-// parsing functions
-// -----------------
-
-type singleStaticHandler struct {
-	Path string
-	File string
+// writeResponse writes a successful response with the given name (which may
+// be empty) and body.
+func writeResponse(writer http.ResponseWriter, name string, body interface{}) {
+	writeJSON(writer, http.StatusOK, Response{Success: true, QueryResponse: QueryResponse{Name: name, Body: body}})
 }
 
-func (h singleStaticHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	http.ServeFile(writer, request, path.Join(h.Path, h.File))
+// writeJSON writes the response with the given status, or an error if it
+// can't be encoded.
+func writeJSON(writer http.ResponseWriter, status int, response Response) {
+	encoded, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(encodeError(err))
+		return
+	}
+	writer.WriteHeader(status)
+	writer.Write(encoded)
 }
 
+// parsing functions
+// -----------------
+
 func parseStruct(form url.Values, target interface{}) {
 	targetPointer := reflect.ValueOf(target)
 	if targetPointer.Type().Kind() != reflect.Ptr {
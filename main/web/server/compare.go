@@ -0,0 +1,243 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+)
+
+// CompareForm is the request of /query/compare-baseline.
+type CompareForm struct {
+	Input  string `query:"query" json:"query"`   // a select command
+	Offset string `query:"offset" json:"offset"` // how far before the select's timerange the baseline is, such as 1d; 1w by default
+}
+
+// CompareResult compares the series of one expression of the select with
+// those of its baseline.
+type CompareResult struct {
+	Query     string             `json:"query"`
+	Name      string             `json:"name"`
+	Timerange api.Timerange      `json:"timerange"`
+	Baseline  api.Timerange      `json:"baseline_timerange"`
+	Series    []SeriesComparison `json:"series"`
+}
+
+// SeriesComparison holds a series, the series with the same tags in the
+// baseline, and their differences. A series without a counterpart has a
+// null current or baseline series, and no differences.
+type SeriesComparison struct {
+	TagSet        api.TagSet      `json:"tagset"`
+	Current       *api.Timeseries `json:"current"`
+	Baseline      *api.Timeseries `json:"baseline"`
+	Delta         *api.Timeseries `json:"delta,omitempty"`          // current - baseline
+	PercentChange *api.Timeseries `json:"percent_change,omitempty"` // the delta as a percentage of the baseline
+	Summary       *ChangeSummary  `json:"summary,omitempty"`
+}
+
+// ChangeSummary compares the means of the series over their timeranges.
+// Values are null where they are undefined.
+type ChangeSummary struct {
+	Current       *float64 `json:"current"`
+	Baseline      *float64 `json:"baseline"`
+	Delta         *float64 `json:"delta"`
+	PercentChange *float64 `json:"percent_change"`
+}
+
+// compareHandler runs a select over its timerange and over a baseline (such
+// as the same time last week), so that alert-tuning tools can preview how
+// an alert would have looked then in one request.
+type compareHandler struct {
+	context command.ExecutionContext
+	clients clientProfiles
+}
+
+func (h compareHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "GET" && request.Method != "POST" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	if err := request.ParseForm(); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(err))
+		return
+	}
+	form := CompareForm{}
+	parseStruct(request.Form, &form)
+
+	context := h.context
+	if client, ok := h.clients.match(request); ok {
+		context = client.Apply(context)
+	}
+	body, err := compareBaseline(context, form)
+	if err != nil {
+		writer.WriteHeader(errorStatus(err))
+		writer.Write(encodeError(err))
+		return
+	}
+	writeResponse(writer, "compare-baseline", body)
+}
+
+func compareBaseline(context command.ExecutionContext, form CompareForm) ([]CompareResult, error) {
+	if form.Offset == "" {
+		form.Offset = "1w"
+	}
+	offset, err := function.StringToDuration(form.Offset)
+	if err != nil {
+		return nil, err
+	}
+	if offset <= 0 {
+		return nil, fmt.Errorf("the offset of the baseline must be positive, not %s", form.Offset)
+	}
+	cmd, err := parser.Parse(form.Input)
+	if err != nil {
+		return nil, err
+	}
+	current, ok := cmd.(*command.SelectCommand)
+	if !ok {
+		return nil, fmt.Errorf("baselines can only be compared for a select, not a %s", cmd.Name())
+	}
+	currentResult, err := current.Execute(context)
+	if err != nil {
+		return nil, err
+	}
+	// The baseline is fetched at the resolution of the current window, so
+	// that their points line up; if older data is only kept at a coarser
+	// resolution, the current window is fetched again at that one.
+	resolution := currentResult.Metadata["resolution"].(time.Duration)
+	baseline := *current
+	baseline.Context.Start = baseline.Context.Start.Add(-offset)
+	baseline.Context.End = baseline.Context.End.Add(-offset)
+	baseline.Context.Resolution = resolution
+	baselineResult, err := baseline.Execute(context)
+	if err != nil {
+		return nil, err
+	}
+	if coarser := baselineResult.Metadata["resolution"].(time.Duration); coarser > resolution {
+		current.Context.Resolution = coarser
+		currentResult, err = current.Execute(context)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	currentBody := currentResult.Body.([]command.QueryResult)
+	baselineBody := baselineResult.Body.([]command.QueryResult)
+	comparisons := make([]CompareResult, len(currentBody))
+	for i, queryResult := range currentBody {
+		if queryResult.Type != "series" {
+			return nil, fmt.Errorf("%s results in %s, but only series can be compared with a baseline", queryResult.Query, queryResult.Type)
+		}
+		comparisons[i] = CompareResult{
+			Query:     queryResult.Query,
+			Name:      queryResult.Name,
+			Timerange: queryResult.Timerange,
+			Baseline:  baselineBody[i].Timerange,
+			Series:    compareSeries(queryResult.Series, baselineBody[i].Series),
+		}
+	}
+	return comparisons, nil
+}
+
+// compareSeries pairs the series by their tags, in the order of the current
+// series followed by those only in the baseline.
+func compareSeries(current []api.Timeseries, baseline []api.Timeseries) []SeriesComparison {
+	baselineByTags := map[string]int{}
+	for i, series := range baseline {
+		baselineByTags[series.TagSet.Serialize()] = i
+	}
+	paired := map[int]bool{}
+	comparisons := []SeriesComparison{}
+	for i := range current {
+		comparison := SeriesComparison{TagSet: current[i].TagSet, Current: &current[i]}
+		if j, ok := baselineByTags[current[i].TagSet.Serialize()]; ok && !paired[j] {
+			paired[j] = true
+			comparison.Baseline = &baseline[j]
+			comparison.compare()
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	for j := range baseline {
+		if !paired[j] {
+			comparisons = append(comparisons, SeriesComparison{TagSet: baseline[j].TagSet, Baseline: &baseline[j]})
+		}
+	}
+	return comparisons
+}
+
+// compare computes the differences between the current and baseline series.
+func (c *SeriesComparison) compare() {
+	slots := len(c.Current.Values)
+	if len(c.Baseline.Values) < slots {
+		slots = len(c.Baseline.Values)
+	}
+	delta := make([]float64, slots)
+	percent := make([]float64, slots)
+	for i := range delta {
+		delta[i] = c.Current.Values[i] - c.Baseline.Values[i]
+		percent[i] = percentChange(delta[i], c.Baseline.Values[i])
+	}
+	c.Delta = &api.Timeseries{TagSet: c.TagSet, Values: delta}
+	c.PercentChange = &api.Timeseries{TagSet: c.TagSet, Values: percent}
+	currentMean := mean(c.Current.Values)
+	baselineMean := mean(c.Baseline.Values)
+	c.Summary = &ChangeSummary{
+		Current:       finite(currentMean),
+		Baseline:      finite(baselineMean),
+		Delta:         finite(currentMean - baselineMean),
+		PercentChange: finite(percentChange(currentMean-baselineMean, baselineMean)),
+	}
+}
+
+// percentChange is the delta as a percentage of the baseline; it is NaN if the baseline is zero.
+func percentChange(delta float64, baseline float64) float64 {
+	if baseline == 0 {
+		return math.NaN()
+	}
+	return delta / math.Abs(baseline) * 100
+}
+
+// mean is the mean of the values which are present, or NaN if there are none.
+func mean(values []float64) float64 {
+	sum := 0.0
+	count := 0
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			sum += value
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// finite is the value, or nil if it is NaN or infinite (which can't be encoded as JSON).
+func finite(value float64) *float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return &value
+}
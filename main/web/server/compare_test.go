@@ -0,0 +1,92 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCompareHandler(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 70, 10)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 4, 0, 2, 3, 4, 0}, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+		api.Timeseries{Values: []float64{5, 5, 5, 5, 5, 5, 5, 5}, TagSet: api.TagSet{"metric": "cpu", "host": "b"}},
+	)
+	handler := compareHandler{context: command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	}}
+	serve := func(form url.Values) (int, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/query/compare-baseline", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+
+	code, body := serve(url.Values{"query": {"select cpu where host = 'a' from 40 to 70 resolution 10ms"}, "offset": {"40ms"}})
+	a.EqInt(code, http.StatusOK)
+	var response struct {
+		Body []struct {
+			Baseline api.Timerange `json:"baseline_timerange"`
+			Series   []struct {
+				Current       api.Timeseries
+				Baseline      api.Timeseries
+				Delta         api.Timeseries
+				PercentChange api.Timeseries `json:"percent_change"`
+				Summary       ChangeSummary
+			}
+		}
+	}
+	a.CheckError(json.Unmarshal([]byte(body), &response))
+	a.EqInt(len(response.Body), 1)
+	a.EqInt(int(response.Body[0].Baseline.StartMillis()), 0)
+	series := response.Body[0].Series
+	a.EqInt(len(series), 1)
+	a.EqFloatArray(series[0].Current.Values, []float64{2, 3, 4, 0}, 1e-9)
+	a.EqFloatArray(series[0].Baseline.Values, []float64{1, 2, 4, 0}, 1e-9)
+	a.EqFloatArray(series[0].Delta.Values, []float64{1, 1, 0, 0}, 1e-9)
+	var compact bytes.Buffer
+	a.CheckError(json.Compact(&compact, []byte(body)))
+	a.EqBool(strings.Contains(compact.String(), `"values":[100,50,0,null]`), true) // null, since the baseline is zero
+	a.EqFloat(*series[0].Summary.Delta, 0.5, 1e-9)
+	a.EqFloat(*series[0].Summary.PercentChange, 0.5/1.75*100, 1e-9)
+
+	for _, form := range []url.Values{
+		{"query": {"select cpu from 40 to 70 resolution 10ms"}, "offset": {"-1h"}},
+		{"query": {"select cpu from 40 to 70 resolution 10ms"}, "offset": {"soon"}},
+		{"query": {"describe cpu"}},
+		{"query": {"select cpu | summarize.mean from 40 to 70 resolution 10ms"}, "offset": {"40ms"}},
+	} {
+		code, _ := serve(form)
+		a.Contextf("%v", form).EqInt(code, http.StatusBadRequest)
+	}
+}
@@ -14,16 +14,115 @@
 
 package server
 
-import "github.com/square/metrics/inspect"
+import (
+	"fmt"
+	"time"
+
+	"github.com/square/metrics/endpoints"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/inspect"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/supervisor"
+	"github.com/square/metrics/util"
+	"github.com/square/metrics/webhook"
+)
 
 type Config struct {
-	Port          int    `yaml:"port"`
-	Timeout       int    `yaml:"timeout"`
-	StaticDir     string `yaml:"static_dir"`
-	JSONIngestion bool   `yaml:"json_ingestion"`
-	HTTPIngestion bool   `yaml:"enable_http_ingestion"`
+	Port           int                 `yaml:"port"`
+	Timeout        int                 `yaml:"timeout"`
+	HTTP           HTTPConfig          `yaml:"http"` // HTTP/2, keep-alive and connection limits
+	StaticDir      string              `yaml:"static_dir"`
+	JSONIngestion  bool                `yaml:"json_ingestion"`
+	HTTPIngestion  bool                `yaml:"enable_http_ingestion"`
+	DrainSeconds   int                 `yaml:"drain_seconds"` // how long to keep serving after shutdown begins, while load balancers stop routing here
+	Clients        []ClientProfile     `yaml:"clients"`       // limits for particular clients, in place of those of the execution context
+	Scheduler      SchedulerConfig     `yaml:"scheduler"`
+	TrailingBucket string              `yaml:"trailing_bucket"` // the default treatment of the incomplete last bucket: keep, trim or flag
+	Collation      string              `yaml:"collation"`       // the default order of tag values: natural, lexical, version, ip or locale
+	Archive        ArchiveConfig       `yaml:"archive"`         // where the results of queries flagged for archival are kept
+	Webhooks       []webhook.Config    `yaml:"webhooks"`        // notified of slow and rejected queries, and of ejected backend endpoints
+	Tenants        TenantConfig        `yaml:"tenants"`         // macros defined by each tenant
+	DescribeCache  DescribeCacheConfig `yaml:"describe_cache"`  // serves describe results (for autocompletion) from a cache
+	BackendLabels  []string            `yaml:"backend_labels"`  // the labels sent with backend requests: client, tenant, or the name of a directive such as dashboard
+	Support        SupportConfig       `yaml:"support"`         // what /admin/support-bundle collects
+	QueryTimeout   int                 `yaml:"query_timeout"`   // seconds; if set, selects which take longer fail (or return partial results)
+	PartialResults bool                `yaml:"partial_results"` // the default for selects which run short of time: return the prefix of their timerange which was fetched, rather than failing
+	CoarserRetry   bool                `yaml:"coarser_retry"`   // retry selects which exceed the slot limit or a storage limit once, at the next coarser resolution
+	ResultCache    ResultCacheConfig   `yaml:"result_cache"`    // serves repeated selects from memory
+	MemoryLimit    int64               `yaml:"memory_limit"`    // bytes; if set, selects whose fetched series would take more memory fail
+	History        HistoryConfig       `yaml:"history"`         // the recent queries of each user, for the UI
+	Share          ShareConfig         `yaml:"share"`           // signed, expiring links to graphs
+	RangeRules     []RangeRuleConfig   `yaml:"range_rules"`     // limits on the timeranges and resolutions at which particular metrics may be selected
+	GoroutineLimit int                 `yaml:"goroutine_limit"` // if set, the most goroutines a select evaluates on at once; beyond it, evaluation continues on fewer
+}
+
+// RangeRuleConfig limits the selects of the metrics whose names match its
+// pattern, such as debugging metrics which may only be selected over 48h at
+// 1m. Selects fetching more than the range fail, and those of the metrics
+// are computed at the resolution or a coarser one.
+type RangeRuleConfig struct {
+	Metrics       string `yaml:"metrics"`        // a regular expression, matched against the whole metric name
+	MaxRange      string `yaml:"max_range"`      // optional, such as 48h
+	MinResolution string `yaml:"min_resolution"` // optional, such as 1m
+}
+
+func newRangeRules(configs []RangeRuleConfig) ([]command.RangeRule, error) {
+	rules := make([]command.RangeRule, len(configs))
+	for i, config := range configs {
+		durations := make([]time.Duration, 2)
+		for j, value := range []string{config.MaxRange, config.MinResolution} {
+			if value == "" {
+				continue
+			}
+			duration, err := function.StringToDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("range rule for %q: %s", config.Metrics, err.Error())
+			}
+			durations[j] = duration
+		}
+		rule, err := command.NewRangeRule(config.Metrics, durations[0], durations[1])
+		if err != nil {
+			return nil, fmt.Errorf("range rule: %s", err.Error())
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+// ResultCacheConfig caches the results of selects in memory, keyed on their
+// query, timerange and resolution. Selects ending close to now aren't
+// cached, since their last points may still change.
+type ResultCacheConfig struct {
+	TTLSeconds    int `yaml:"ttl_seconds"`    // how long a result is served; if zero, selects aren't cached
+	RecentSeconds int `yaml:"recent_seconds"` // selects ending less than this long ago aren't cached; defaults to 300
+	MaxEntries    int `yaml:"max_entries"`    // the most results kept, 1000 by default
+}
+
+// newResultCache returns nil if selects aren't cached.
+func newResultCache(config ResultCacheConfig) *command.ResultCache {
+	if config.TTLSeconds <= 0 {
+		return nil
+	}
+	recent := config.RecentSeconds
+	if recent <= 0 {
+		recent = 300
+	}
+	return command.NewResultCache(time.Duration(config.TTLSeconds)*time.Second, time.Duration(recent)*time.Second, config.MaxEntries)
+}
+
+// SchedulerConfig limits the number of queries which run at once. Queries
+// are admitted by the priority class of their client profile; other
+// queries are interactive.
+type SchedulerConfig struct {
+	MaxConcurrentQueries int  `yaml:"max_concurrent_queries"` // if zero, queries are not scheduled
+	Preempt              bool `yaml:"preempt"`                // whether queries of higher priority cancel (and requeue) those of lower priority
 }
 
 type Hook struct {
-	OnQuery chan<- *inspect.Profiler
+	OnQuery    chan<- *inspect.Profiler
+	CacheStats func() interface{}         // optional. Describes the backends' caches in support bundles
+	Encoders   map[string]Encoder         // optional. Encoders of custom formats, by format name
+	Supervisor *supervisor.Supervisor     // optional. Runs the background components; without it, they run unsupervised
+	Graphite   *util.RuleSet              // optional. The conversion rules resolving the paths of Graphite's /render API; without them, it isn't served
+	Pools      map[string]*endpoints.Pool // optional. The endpoint pools of the backends, by backend name; ejections from them fire backend_breaker_open webhooks
 }
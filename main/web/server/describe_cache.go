@@ -0,0 +1,185 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	netcontext "context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/log"
+)
+
+// DescribeCacheConfig caches the results of describe queries in the HTTP
+// layer. It suits autocompletion, whose traffic comes in bursts but doesn't
+// mind results which are a few seconds old.
+type DescribeCacheConfig struct {
+	FreshSeconds int `yaml:"fresh_seconds"` // how long a result is served as it is; if zero, describe queries aren't cached
+	StaleSeconds int `yaml:"stale_seconds"` // for how much longer a result is served while it's refreshed in the background
+	MaxEntries   int `yaml:"max_entries"`   // the most results kept, 1000 by default
+}
+
+// describeCache serves describe results with stale-while-revalidate
+// semantics: a fresh result is served as it is, a stale one is served at
+// once while it's refreshed in the background, and one older than that is
+// computed again before it's served.
+type describeCache struct {
+	fresh      time.Duration
+	stale      time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*describeEntry
+	counts  map[string]int // the number of lookups with each outcome
+}
+
+type describeEntry struct {
+	response   QueryResponse
+	stored     time.Time
+	refreshing bool
+}
+
+// newDescribeCache returns nil if describe results aren't cached.
+func newDescribeCache(config DescribeCacheConfig) *describeCache {
+	if config.FreshSeconds <= 0 {
+		return nil
+	}
+	maxEntries := config.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &describeCache{
+		fresh:      time.Duration(config.FreshSeconds) * time.Second,
+		stale:      time.Duration(config.StaleSeconds) * time.Second,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    map[string]*describeEntry{},
+		counts:     map[string]int{},
+	}
+}
+
+// The outcomes of a cache lookup, reported in the X-Cache header.
+const (
+	cacheHit   = "hit"
+	cacheStale = "stale"
+	cacheMiss  = "miss"
+)
+
+// get returns the response cached under the key, along with its age and
+// whether it was a hit, stale or a miss. On a miss, the response is computed
+// with the given context; a refresh in the background is computed with a
+// context of its own, since it outlives the request.
+func (c *describeCache) get(ctx netcontext.Context, key string, compute func(netcontext.Context) (QueryResponse, error)) (QueryResponse, time.Duration, string, error) {
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	if ok {
+		age := c.now().Sub(entry.stored)
+		switch {
+		case age < c.fresh:
+			c.counts[cacheHit]++
+			c.mutex.Unlock()
+			return entry.response, age, cacheHit, nil
+		case age < c.fresh+c.stale:
+			if !entry.refreshing {
+				entry.refreshing = true
+				go c.refresh(key, compute)
+			}
+			c.counts[cacheStale]++
+			c.mutex.Unlock()
+			return entry.response, age, cacheStale, nil
+		}
+	}
+	c.counts[cacheMiss]++
+	c.mutex.Unlock()
+
+	response, err := compute(ctx)
+	if err != nil {
+		return QueryResponse{}, 0, cacheMiss, err
+	}
+	c.store(key, response)
+	return response, 0, cacheMiss, nil
+}
+
+// refresh recomputes a stale entry. If that fails, the stale entry is kept
+// until it expires, and the next request tries again.
+func (c *describeCache) refresh(key string, compute func(netcontext.Context) (QueryResponse, error)) {
+	response, err := compute(netcontext.Background())
+	if err != nil {
+		log.Errorf("Cannot refresh the cached describe result %s: %s", key, err.Error())
+		c.mutex.Lock()
+		if entry, ok := c.entries[key]; ok {
+			entry.refreshing = false
+		}
+		c.mutex.Unlock()
+		return
+	}
+	c.store(key, response)
+}
+
+// store caches the response, making room by dropping expired entries and
+// then, if that isn't enough, the oldest one.
+func (c *describeCache) store(key string, response QueryResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		oldestKey := ""
+		var oldest time.Time
+		for existing, entry := range c.entries {
+			if now.Sub(entry.stored) >= c.fresh+c.stale {
+				delete(c.entries, existing)
+				continue
+			}
+			if oldestKey == "" || entry.stored.Before(oldest) {
+				oldestKey, oldest = existing, entry.stored
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			delete(c.entries, oldestKey)
+		}
+	}
+	c.entries[key] = &describeEntry{response: response, stored: now}
+}
+
+// DescribeCacheStats describes the use of the describe cache.
+type DescribeCacheStats struct {
+	Entries int `json:"entries"`
+	Hits    int `json:"hits"`
+	Stale   int `json:"stale"`
+	Misses  int `json:"misses"`
+}
+
+func (c *describeCache) stats() DescribeCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return DescribeCacheStats{
+		Entries: len(c.entries),
+		Hits:    c.counts[cacheHit],
+		Stale:   c.counts[cacheStale],
+		Misses:  c.counts[cacheMiss],
+	}
+}
+
+// setHeaders lets clients and proxies cache the response with the same
+// semantics, and reports how it was served.
+func (c *describeCache) setHeaders(header http.Header, age time.Duration, status string) {
+	header.Set("Cache-Control", fmt.Sprintf("max-age=%d, stale-while-revalidate=%d", int(c.fresh.Seconds()), int(c.stale.Seconds())))
+	header.Set("Age", strconv.Itoa(int(age.Seconds())))
+	header.Set("X-Cache", status)
+}
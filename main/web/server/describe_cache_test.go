@@ -0,0 +1,120 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	netcontext "context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestDescribeCache(t *testing.T) {
+	a := assert.New(t)
+	cache := newDescribeCache(DescribeCacheConfig{FreshSeconds: 5, StaleSeconds: 10, MaxEntries: 2})
+	now := time.Unix(1000, 0)
+	cache.now = func() time.Time { return now }
+
+	var mutex sync.Mutex
+	computed := 0
+	compute := func(netcontext.Context) (QueryResponse, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		computed++
+		return QueryResponse{Name: "describe", Body: computed}, nil
+	}
+	get := func(key string) (interface{}, string) {
+		response, _, status, err := cache.get(netcontext.TODO(), key, compute)
+		a.CheckError(err)
+		return response.Body, status
+	}
+	check := func(key string, body int, status string) {
+		actualBody, actualStatus := get(key)
+		a.Contextf("%s at %s", key, now.Format(time.Kitchen)).Eq([]interface{}{actualBody, actualStatus}, []interface{}{body, status})
+	}
+
+	check("cpu", 1, cacheMiss)
+	now = now.Add(4 * time.Second)
+	check("cpu", 1, cacheHit)
+	now = now.Add(2 * time.Second)
+	// stale: served at once, and refreshed in the background
+	check("cpu", 1, cacheStale)
+	for refreshing := true; refreshing; {
+		time.Sleep(time.Millisecond)
+		cache.mutex.Lock()
+		refreshing = cache.entries["cpu"].refreshing
+		cache.mutex.Unlock()
+	}
+	check("cpu", 2, cacheHit)
+	// expired: computed again before it's served
+	now = now.Add(20 * time.Second)
+	check("cpu", 3, cacheMiss)
+
+	// the oldest entry makes room for new ones
+	now = now.Add(time.Second)
+	check("memory", 4, cacheMiss)
+	now = now.Add(time.Second)
+	check("disk", 5, cacheMiss)
+	check("memory", 4, cacheHit)
+	check("cpu", 6, cacheMiss)
+
+	// errors aren't cached
+	failure := errors.New("metadata unavailable")
+	_, _, _, err := cache.get(netcontext.TODO(), "network", func(netcontext.Context) (QueryResponse, error) {
+		return QueryResponse{}, failure
+	})
+	a.Eq(err, failure)
+	check("network", 7, cacheMiss)
+}
+
+func TestQueryHandler_DescribeCache(t *testing.T) {
+	a := assert.New(t)
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": "a"}})
+	handler := queryHandler{
+		context:   command.ExecutionContext{MetricMetadataAPI: fakeAPI, FetchLimit: 1000, Ctx: netcontext.Background()},
+		describes: newDescribeCache(DescribeCacheConfig{FreshSeconds: 60, StaleSeconds: 600}),
+	}
+	query := func(input string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/query", strings.NewReader("query="+input))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := query("describe cpu")
+	a.EqInt(first.Code, http.StatusOK)
+	a.EqString(first.Header().Get("X-Cache"), cacheMiss)
+	a.EqString(first.Header().Get("Cache-Control"), "max-age=60, stale-while-revalidate=600")
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": "b"}})
+	second := query("describe cpu")
+	a.EqString(second.Header().Get("X-Cache"), cacheHit)
+	a.EqString(second.Body.String(), first.Body.String())
+
+	// other queries aren't cached
+	invalid := query("select from")
+	a.EqInt(invalid.Code, http.StatusBadRequest)
+	a.EqString(invalid.Header().Get("X-Cache"), "")
+}
@@ -0,0 +1,100 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/ast"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+)
+
+// DrillDownForm is the request of /query/drill-down.
+type DrillDownForm struct {
+	Input      string     `query:"query" json:"query"`                             // a select command
+	Expression int        `query:"expression" query_kind:"json" json:"expression"` // the index of the expression whose series was chosen; 0 by default
+	Tags       api.TagSet `query:"tags" query_kind:"json" json:"tags"`             // the tags of the chosen series, as a JSON object
+}
+
+// DrillDownResult is the query showing the series behind the chosen one.
+type DrillDownResult struct {
+	Query string `json:"query"`
+}
+
+// drillDownHandler rewrites a select into the one showing the series behind
+// one series of its result (see ast.DrillDown), so that every frontend drills
+// down in the same way. Only the tag keys of the fetched metrics are looked up.
+type drillDownHandler struct {
+	context command.ExecutionContext
+}
+
+func (h drillDownHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "GET" && request.Method != "POST" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	if err := request.ParseForm(); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(err))
+		return
+	}
+	form := DrillDownForm{}
+	parseStruct(request.Form, &form)
+	context := h.context
+	context.Ctx = request.Context()
+	body, err := drillDown(context, form)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(err))
+		return
+	}
+	writeResponse(writer, "drill-down", body)
+}
+
+func drillDown(context command.ExecutionContext, form DrillDownForm) (DrillDownResult, error) {
+	cmd, err := parser.Parse(form.Input)
+	if err != nil {
+		return DrillDownResult{}, err
+	}
+	selectCommand, ok := cmd.(*command.SelectCommand)
+	if !ok {
+		return DrillDownResult{}, fmt.Errorf("only a select can be drilled down, not a %s", cmd.Name())
+	}
+	if form.Expression < 0 || form.Expression >= len(selectCommand.Expressions) {
+		return DrillDownResult{}, fmt.Errorf("the select has %d expressions, so there's no expression %d", len(selectCommand.Expressions), form.Expression)
+	}
+	tagKeys := func(metric string) ([]string, error) {
+		keys, err := metadata.GetTagKeys(context.Ctx, context.MetricMetadataAPI, api.MetricKey(metric), metadata.Context{Profiler: context.Profiler})
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(keys))
+		for i, key := range keys {
+			names[i] = key.Key
+		}
+		return names, nil
+	}
+	drilled, err := ast.DrillDown(selectCommand, form.Expression, form.Tags, tagKeys)
+	if err != nil {
+		return DrillDownResult{}, err
+	}
+	return DrillDownResult{Query: drilled.Query()}, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestDrillDownHandler(t *testing.T) {
+	a := assert.New(t)
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "memory", TagSet: api.TagSet{"dc": "west", "env": "prod"}})
+	handler := drillDownHandler{context: command.ExecutionContext{MetricMetadataAPI: fakeAPI, Ctx: context.Background()}}
+	serve := func(form url.Values) (int, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/query/drill-down", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+
+	code, body := serve(url.Values{
+		"query":      {"select cpu, aggregate.sum(memory group by dc) where env = 'prod' from 0 to 60000"},
+		"expression": {"1"},
+		"tags":       {`{"dc": "west"}`},
+	})
+	a.EqInt(code, http.StatusOK)
+	var response struct {
+		Body DrillDownResult `json:"body"`
+	}
+	a.CheckError(json.Unmarshal([]byte(body), &response))
+	a.EqString(response.Body.Query, `select memory[dc = "west"] where env = "prod" from 0 to 60000 resolution 30000`)
+
+	for _, form := range []url.Values{
+		{"query": {"describe cpu"}},
+		{"query": {"select cpu from 0 to 60000"}, "expression": {"1"}},
+		{"query": {"select cpu from"}},
+		{"query": {"select disk from 0 to 60000"}, "tags": {`{"dc": "west"}`}},
+	} {
+		code, _ := serve(form)
+		a.Contextf("%v", form).EqInt(code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/square/metrics/log"
+	"github.com/square/metrics/query/command"
+)
+
+// builtinFormats are the formats of query results which the server encodes
+// itself. Encoders can't replace them.
+var builtinFormats = []string{"json", "csv", "arrow", "parquet", "jsonl"}
+
+// An Encoder writes the result of a query in a format of its own, such as
+// one internal to a deployment. Encoders are registered by format name in
+// the Hook given to NewMux, and chosen with the format parameter of /query.
+// If an encoder fails before writing anything, its error is reported to the
+// client; otherwise it's only logged.
+type Encoder func(writer http.ResponseWriter, result command.Result) error
+
+// Formats lists the formats of the hook's encoders.
+func (hook Hook) Formats() []string {
+	formats := []string{}
+	for format := range hook.Encoders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// validateEncoders checks that no encoder replaces a built-in format.
+func validateEncoders(encoders map[string]Encoder) error {
+	for _, format := range builtinFormats {
+		if _, ok := encoders[format]; ok {
+			return fmt.Errorf("an encoder cannot replace the built-in format %q", format)
+		}
+	}
+	return nil
+}
+
+// writtenTracker notes whether anything has been written to the response.
+type writtenTracker struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *writtenTracker) WriteHeader(status int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *writtenTracker) Write(data []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush lets encoders stream their output, when the response can be flushed.
+func (w *writtenTracker) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// writeEncoded renders the response with a custom encoder.
+func writeEncoded(writer http.ResponseWriter, response QueryResponse, format string, encoder Encoder) {
+	tracker := &writtenTracker{ResponseWriter: writer}
+	err := encoder(tracker, command.Result{Body: response.Body, Metadata: response.Metadata})
+	if err == nil {
+		return
+	}
+	if tracker.written {
+		log.Errorf("Error encoding a query result as %s: %s", format, err.Error())
+		return
+	}
+	writer.WriteHeader(http.StatusInternalServerError)
+	writer.Write(encodeError(err))
+}
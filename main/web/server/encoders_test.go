@@ -0,0 +1,79 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestQueryHandler_Encoders(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 30, 10)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4}, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+	)
+	hook := Hook{Encoders: map[string]Encoder{
+		"count": func(writer http.ResponseWriter, result command.Result) error {
+			writer.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(writer, "%d results at %v", len(result.Body.([]command.QueryResult)), result.Metadata["resolution"])
+			return nil
+		},
+		"broken": func(writer http.ResponseWriter, result command.Result) error {
+			return fmt.Errorf("the encoder is broken")
+		},
+	}}
+	a.Eq(hook.Formats(), []string{"broken", "count"})
+	handler := queryHandler{
+		context: command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		},
+		hook: hook,
+	}
+	serve := func(format string) (int, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/query", strings.NewReader(url.Values{"query": {"select cpu from 0 to 30 resolution 10ms"}, "format": {format}}.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+
+	code, body := serve("count")
+	a.EqInt(code, http.StatusOK)
+	a.EqString(body, "1 results at 10ms")
+
+	code, body = serve("broken")
+	a.EqInt(code, http.StatusInternalServerError)
+	a.EqBool(strings.Contains(body, "the encoder is broken"), true)
+
+	a.CheckError(validateEncoders(hook.Encoders))
+	if err := validateEncoders(map[string]Encoder{"csv": hook.Encoders["count"]}); err == nil {
+		a.Errorf("expected an encoder of csv to be rejected")
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/query/script"
+	"github.com/square/metrics/tasks"
+	"github.com/square/metrics/timeseries"
+)
+
+// ErrorKind describes one class of error which may be reported by the query
+// endpoint. The Code is stable and is included in error responses, so that
+// clients can handle errors without matching on messages.
+type ErrorKind struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	matches     func(error) bool
+}
+
+// fetchErrorMatcher matches storage errors with the given code.
+func fetchErrorMatcher(code timeseries.ErrorCode) func(error) bool {
+	return func(err error) bool {
+		fetchErr, ok := err.(timeseries.Error)
+		return ok && fetchErr.Code == code
+	}
+}
+
+// errorCatalog lists every kind of error in the order in which they are
+// checked. The final entry matches any error. errors.openapi.yaml describes
+// the codes for clients, and TestErrorCatalog_Complete checks that every
+// error type of the query packages is classified.
+var errorCatalog = []ErrorKind{
+	{
+		Code:        "syntax_error",
+		Status:      http.StatusBadRequest,
+		Type:        "parser.SyntaxErrors",
+		Description: "The query could not be parsed.",
+		matches: func(err error) bool {
+			switch err.(type) {
+			case parser.SyntaxErrors, parser.SyntaxError, *parser.SyntaxError, expression.SyntaxError:
+				return true
+			}
+			return false
+		},
+	},
+	{
+		Code:        "parser_assertion",
+		Status:      http.StatusBadRequest,
+		Type:        "parser.AssertionError",
+		Description: "The parser reached an inconsistent state; this is a bug.",
+		matches: func(err error) bool {
+			switch err.(type) {
+			case parser.AssertionError, parser.Assert:
+				return true
+			}
+			return false
+		},
+	},
+	{
+		Code:        "argument_count",
+		Status:      http.StatusBadRequest,
+		Type:        "function.ArgumentLengthError",
+		Description: "A function was called with the wrong number of arguments.",
+		matches: func(err error) bool {
+			_, ok := err.(function.ArgumentLengthError)
+			return ok
+		},
+	},
+	{
+		Code:        "invalid_argument",
+		Status:      http.StatusBadRequest,
+		Type:        "function.ArgumentError",
+		Description: "A function argument had an unacceptable value.",
+		matches: func(err error) bool {
+			_, ok := err.(function.ArgumentError)
+			return ok
+		},
+	},
+	{
+		Code:        "type_mismatch",
+		Status:      http.StatusBadRequest,
+		Type:        "function.ConversionError",
+		Description: "A value could not be converted to the type required where it was used.",
+		matches: func(err error) bool {
+			_, ok := err.(function.ConversionError)
+			return ok
+		},
+	},
+	{
+		Code:        "limit_exceeded",
+		Status:      http.StatusBadRequest,
+		Type:        "function.LimitError",
+		Description: "The query exceeded a configured resource limit, such as the number of series fetched or the query timeout.",
+		matches: func(err error) bool {
+			_, ok := err.(function.LimitError)
+			return ok
+		},
+	},
+	{
+		Code:        "no_such_metric",
+		Status:      http.StatusBadRequest,
+		Type:        "metadata.NoSuchMetricError",
+		Description: "The query referred to a metric which does not exist.",
+		matches: func(err error) bool {
+			_, ok := err.(metadata.NoSuchMetricError)
+			return ok
+		},
+	},
+	{
+		Code:        "strict_violation",
+		Status:      http.StatusBadRequest,
+		Type:        "function.StrictError",
+		Description: "In strict mode, the query met a condition which is otherwise only noted, such as an expression with no series.",
+		matches: func(err error) bool {
+			_, ok := err.(function.StrictError)
+			return ok
+		},
+	},
+	{
+		Code:        "query_timeout",
+		Status:      http.StatusBadRequest,
+		Type:        "tasks.TimeoutError",
+		Description: "The query did not complete within the configured timeout.",
+		matches: func(err error) bool {
+			_, ok := err.(tasks.TimeoutError)
+			return ok
+		},
+	},
+	{
+		Code:        "query_cancelled",
+		Status:      http.StatusConflict,
+		Type:        "tasks.ErrCancelled",
+		Description: "The query was cancelled through /cancel before it completed.",
+		matches: func(err error) bool {
+			return err == tasks.ErrCancelled
+		},
+	},
+	{
+		Code:        "fetch_timeout",
+		Status:      http.StatusBadRequest,
+		Type:        "timeseries.Error",
+		Description: "The timeseries storage did not respond in time.",
+		matches:     fetchErrorMatcher(timeseries.FetchTimeoutError),
+	},
+	{
+		Code:        "fetch_io",
+		Status:      http.StatusBadRequest,
+		Type:        "timeseries.Error",
+		Description: "The timeseries storage failed to respond.",
+		matches:     fetchErrorMatcher(timeseries.FetchIOError),
+	},
+	{
+		Code:        "invalid_series",
+		Status:      http.StatusBadRequest,
+		Type:        "timeseries.Error",
+		Description: "The requested series was ill-formed.",
+		matches:     fetchErrorMatcher(timeseries.InvalidSeriesError),
+	},
+	{
+		Code:        "fetch_limit",
+		Status:      http.StatusBadRequest,
+		Type:        "timeseries.Error",
+		Description: "The timeseries storage refused a request that exceeded one of its limits.",
+		matches:     fetchErrorMatcher(timeseries.LimitError),
+	},
+	{
+		Code:        "unsupported",
+		Status:      http.StatusBadRequest,
+		Type:        "timeseries.Error",
+		Description: "The timeseries storage does not support the requested operation.",
+		matches:     fetchErrorMatcher(timeseries.Unsupported),
+	},
+	{
+		Code:        "storage_error",
+		Status:      http.StatusBadRequest,
+		Type:        "timeseries.FetchError",
+		Description: "The timeseries storage reported an error; the status is chosen by the storage.",
+		matches: func(err error) bool {
+			_, ok := err.(timeseries.FetchError)
+			return ok
+		},
+	},
+	{
+		Code:        "unknown",
+		Status:      http.StatusBadRequest,
+		Type:        "error",
+		Description: "Any other error. The message describes what went wrong.",
+		matches:     func(error) bool { return true },
+	},
+}
+
+// classifyError finds the kind of the given error in the catalog.
+func classifyError(err error) ErrorKind {
+	if statementErr, ok := err.(script.StatementError); ok {
+		err = statementErr.Err // the kind of error of the statement which failed
+	}
+	for _, kind := range errorCatalog {
+		if kind.matches(err) {
+			return kind
+		}
+	}
+	panic("unreachable: the error catalog ends with a catch-all")
+}
+
+// errorStatus is the HTTP status to respond with for the given error.
+// An HTTPError overrides the status of its catalog entry. As before the
+// catalog, errors are blamed on the client with StatusBadRequest, except for
+// cancelled queries and errors which report a status of their own.
+func errorStatus(err error) int {
+	if errHTTP, ok := err.(HTTPError); ok {
+		return errHTTP.ErrorCode()
+	}
+	return classifyError(err).Status
+}
+
+// errorsHandler serves the catalog of error codes.
+type errorsHandler struct{}
+
+func (h errorsHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	writeResponse(writer, "", errorCatalog)
+}
@@ -0,0 +1,241 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/query/script"
+	"github.com/square/metrics/tasks"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries"
+)
+
+func TestClassifyError(t *testing.T) {
+	_, syntaxErr := parser.Parse("select (")
+	for _, test := range []struct {
+		err    error
+		code   string
+		status int
+	}{
+		{syntaxErr, "syntax_error", http.StatusBadRequest},
+		{function.ArgumentLengthError{Name: "f", ExpectedMin: 1, ExpectedMax: 1, Actual: 2}, "argument_count", http.StatusBadRequest},
+		{function.ArgumentError{Name: "f", Expected: "a duration", Actual: "3"}, "invalid_argument", http.StatusBadRequest},
+		{function.NewLimitError("too many series", 10, 5), "limit_exceeded", http.StatusBadRequest},
+		{function.MemoryLimitError{LimitError: function.NewLimitError("too many bytes", 10, 5)}, "limit_exceeded", http.StatusBadRequest},
+		{function.StrictError{Message: "cpu has no series"}, "strict_violation", http.StatusBadRequest},
+		{tasks.NewTimeoutError(time.Second), "query_timeout", http.StatusBadRequest},
+		{tasks.ErrCancelled, "query_cancelled", http.StatusConflict},
+		{timeseries.Error{Code: timeseries.FetchIOError}, "fetch_io", http.StatusBadRequest},
+		{timeseries.FetchError{Message: "down", Code: http.StatusServiceUnavailable}, "storage_error", http.StatusServiceUnavailable},
+		{fmt.Errorf("something else"), "unknown", http.StatusBadRequest},
+		{script.StatementError{Index: 1, Err: function.NewLimitError("too many series", 10, 5)}, "limit_exceeded", http.StatusBadRequest},
+	} {
+		a := assert.New(t).Contextf("%s", test.err.Error())
+		a.EqString(classifyError(test.err).Code, test.code)
+		a.EqInt(errorStatus(test.err), test.status)
+	}
+}
+
+func TestErrorsHandler(t *testing.T) {
+	a := assert.New(t)
+	recorder := httptest.NewRecorder()
+	errorsHandler{}.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/errors", nil))
+	a.EqInt(recorder.Code, http.StatusOK)
+	var response struct {
+		Success bool        `json:"success"`
+		Body    []ErrorKind `json:"body"`
+	}
+	a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &response))
+	a.EqBool(response.Success, true)
+	a.EqInt(len(response.Body), len(errorCatalog))
+	seen := map[string]bool{}
+	for _, kind := range response.Body {
+		a.Contextf("%s", kind.Code).EqBool(seen[kind.Code], false)
+		seen[kind.Code] = true
+	}
+}
+
+// errorPackages are the directories of the packages whose errors reach the
+// query endpoint.
+var errorPackages = []string{
+	"../../../function",
+	"../../../metric_metadata",
+	"../../../query/expression",
+	"../../../query/parser",
+	"../../../query/script",
+	"../../../tasks",
+	"../../../timeseries",
+}
+
+// errorSamples holds a value of every exported error type of errorPackages,
+// named as in the Type of the catalog.
+var errorSamples = map[string]error{
+	"function.ArgumentError":       function.ArgumentError{Name: "f", Expected: "a duration", Actual: "3"},
+	"function.ArgumentLengthError": function.ArgumentLengthError{Name: "f", ExpectedMin: 1, ExpectedMax: 1, Actual: 2},
+	"function.ConversionError":     function.ConversionError{},
+	"function.LimitError":          function.NewLimitError("too many series", 10, 5),
+	"function.MemoryLimitError":    function.MemoryLimitError{LimitError: function.NewLimitError("too many bytes", 10, 5)},
+	"function.StrictError":         function.StrictError{Message: "cpu has no series"},
+	"metadata.NoSuchMetricError":   metadata.NewNoSuchMetricError("cpu"),
+	"expression.SyntaxError":       expression.SyntaxError{},
+	"parser.Assert":                parser.Assert{},
+	"parser.AssertionError":        parser.AssertionError{},
+	"parser.SyntaxError":           parser.SyntaxError{},
+	"parser.SyntaxErrors":          parser.SyntaxErrors{},
+	"script.StatementError":        script.StatementError{Err: function.StrictError{}},
+	"tasks.TimeoutError":           tasks.NewTimeoutError(time.Second),
+	"timeseries.Error":             timeseries.Error{Code: timeseries.FetchIOError},
+	"timeseries.FetchError":        timeseries.FetchError{Message: "down"},
+}
+
+// exportedErrorTypes finds the exported types of the package in the directory
+// which are errors: those with an Error method, interfaces embedding error,
+// and structs embedding either.
+func exportedErrorTypes(t *testing.T, dir string) []string {
+	packages, err := goparser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("parsing %s: %s", dir, err.Error())
+	}
+	found := map[string]bool{}
+	structs := map[string]*ast.StructType{}
+	for name, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				switch decl := decl.(type) {
+				case *ast.FuncDecl:
+					if decl.Recv == nil || decl.Name.Name != "Error" {
+						continue
+					}
+					receiver := decl.Recv.List[0].Type
+					if star, ok := receiver.(*ast.StarExpr); ok {
+						receiver = star.X
+					}
+					if ident, ok := receiver.(*ast.Ident); ok {
+						found[ident.Name] = true
+					}
+				case *ast.GenDecl:
+					for _, spec := range decl.Specs {
+						typeSpec, ok := spec.(*ast.TypeSpec)
+						if !ok {
+							continue
+						}
+						switch typ := typeSpec.Type.(type) {
+						case *ast.InterfaceType:
+							for _, field := range typ.Methods.List {
+								if ident, ok := field.Type.(*ast.Ident); ok && len(field.Names) == 0 && ident.Name == "error" {
+									found[typeSpec.Name.Name] = true
+								}
+							}
+						case *ast.StructType:
+							structs[typeSpec.Name.Name] = typ
+						}
+					}
+				}
+			}
+		}
+		for structName, typ := range structs {
+			for _, field := range typ.Fields.List {
+				if ident, ok := field.Type.(*ast.Ident); ok && len(field.Names) == 0 && (ident.Name == "error" || found[ident.Name]) {
+					found[structName] = true
+				}
+			}
+		}
+		result := []string{}
+		for typeName := range found {
+			if ast.IsExported(typeName) {
+				result = append(result, name+"."+typeName)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// TestErrorCatalog_Complete fails when an error type is added which the
+// catalog does not classify, or when a kind of error can no longer occur.
+func TestErrorCatalog_Complete(t *testing.T) {
+	classified := map[string]bool{}
+	for _, dir := range errorPackages {
+		for _, typeName := range exportedErrorTypes(t, dir) {
+			a := assert.New(t).Contextf("%s", typeName)
+			sample, ok := errorSamples[typeName]
+			if !ok {
+				a.Errorf("%s has no entry in errorSamples; add one, and a catalog entry if it needs its own code", typeName)
+				continue
+			}
+			code := classifyError(sample).Code
+			if code == "unknown" {
+				a.Errorf("%s is not classified by the error catalog", typeName)
+			}
+			classified[code] = true
+		}
+	}
+	// Every code of timeseries.Error has its own entry.
+	for code := timeseries.ErrorCode(1); !strings.HasSuffix(timeseries.Error{Code: code}.Error(), "unknown error"); code++ {
+		kind := classifyError(timeseries.Error{Code: code})
+		assert.New(t).Contextf("timeseries.ErrorCode(%d)", code).EqString(kind.Type, "timeseries.Error")
+		classified[kind.Code] = true
+	}
+	classified[classifyError(tasks.ErrCancelled).Code] = true
+	classified["unknown"] = true
+	for _, kind := range errorCatalog {
+		if !classified[kind.Code] {
+			t.Errorf("no error is classified as %s", kind.Code)
+		}
+	}
+}
+
+func TestErrorCatalog_OpenAPI(t *testing.T) {
+	a := assert.New(t)
+	content, err := ioutil.ReadFile("errors.openapi.yaml")
+	a.CheckError(err)
+	var document struct {
+		Components struct {
+			Schemas struct {
+				ErrorCode struct {
+					Enum []string `yaml:"enum"`
+				} `yaml:"ErrorCode"`
+			} `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	a.CheckError(yaml.Unmarshal(content, &document))
+	codes := document.Components.Schemas.ErrorCode.Enum
+	a.EqInt(len(codes), len(errorCatalog))
+	for i, kind := range errorCatalog {
+		if i < len(codes) {
+			a.EqString(codes[i], kind.Code)
+		}
+	}
+}
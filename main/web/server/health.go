@@ -0,0 +1,135 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/square/metrics/query/command"
+)
+
+// Lifecycle tracks whether the server is still accepting traffic. Once it
+// starts draining, readiness checks fail so that load balancers (such as a
+// Kubernetes Service) stop routing new requests to it, while requests
+// already in flight are allowed to complete.
+type Lifecycle struct {
+	draining int32
+}
+
+// Drain marks the server as shutting down.
+func (l *Lifecycle) Drain() {
+	atomic.StoreInt32(&l.draining, 1)
+}
+
+// Draining determines whether Drain has been called.
+func (l *Lifecycle) Draining() bool {
+	return atomic.LoadInt32(&l.draining) != 0
+}
+
+// HealthStatus is the body of a liveness or readiness response.
+type HealthStatus struct {
+	Draining bool              `json:"draining"`
+	Checks   map[string]string `json:"checks,omitempty"` // the failed checks of the backends, with their errors
+}
+
+func writeHealth(writer http.ResponseWriter, status HealthStatus, message string) {
+	writer.Header().Set("Content-Type", "application/json")
+	code := http.StatusOK
+	if message != "" {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(writer, code, Response{
+		Success:       message == "",
+		Message:       message,
+		QueryResponse: QueryResponse{Body: status},
+	})
+}
+
+type livenessHandler struct {
+	lifecycle *Lifecycle
+}
+
+// NewLivenessHandler creates a handler for liveness probes. It succeeds
+// whenever the process is able to serve requests at all, including while
+// draining, so that the process is not restarted before it finishes.
+func NewLivenessHandler(lifecycle *Lifecycle) http.Handler {
+	return livenessHandler{lifecycle: lifecycle}
+}
+
+func (h livenessHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writeHealth(writer, HealthStatus{Draining: h.lifecycle.Draining()}, "")
+}
+
+type readinessHandler struct {
+	lifecycle *Lifecycle
+	context   command.ExecutionContext
+}
+
+// NewReadinessHandler creates a handler for readiness probes. It fails while
+// the server is draining or when either backend reports itself unhealthy.
+func NewReadinessHandler(lifecycle *Lifecycle, context command.ExecutionContext) http.Handler {
+	return readinessHandler{lifecycle: lifecycle, context: context}
+}
+
+func (h readinessHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	status := HealthStatus{Draining: h.lifecycle.Draining(), Checks: map[string]string{}}
+	if status.Draining {
+		writeHealth(writer, status, "The server is shutting down.")
+		return
+	}
+	if h.context.TimeseriesStorageAPI != nil {
+		if err := h.context.TimeseriesStorageAPI.CheckHealthy(); err != nil {
+			status.Checks["storage"] = err.Error()
+		}
+	}
+	if h.context.MetricMetadataAPI != nil {
+		if err := h.context.MetricMetadataAPI.CheckHealthy(); err != nil {
+			status.Checks["metadata"] = err.Error()
+		}
+	}
+	if len(status.Checks) != 0 {
+		writeHealth(writer, status, "A backend is unhealthy.")
+		return
+	}
+	writeHealth(writer, status, "")
+}
+
+type drainHandler struct {
+	lifecycle *Lifecycle
+	delay     time.Duration
+}
+
+// NewDrainHandler creates a handler suitable for a preStop hook. It marks the
+// server as draining and then holds the request for the given delay, which
+// gives the readiness probe time to fail and endpoints time to be removed
+// before the process receives its termination signal.
+func NewDrainHandler(lifecycle *Lifecycle, delay time.Duration) http.Handler {
+	return drainHandler{lifecycle: lifecycle, delay: delay}
+}
+
+func (h drainHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "POST" && request.Method != "GET" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	h.lifecycle.Drain()
+	select {
+	case <-time.After(h.delay):
+	case <-request.Context().Done():
+	}
+	writeHealth(writer, HealthStatus{Draining: true}, "")
+}
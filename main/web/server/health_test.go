@@ -0,0 +1,64 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+)
+
+type unhealthyStorage struct {
+	timeseries.StorageAPI
+}
+
+func (unhealthyStorage) CheckHealthy() error {
+	return errors.New("unreachable")
+}
+
+func TestHealthHandlers(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange)
+	context := command.ExecutionContext{TimeseriesStorageAPI: comboAPI, MetricMetadataAPI: comboAPI}
+	lifecycle := &Lifecycle{}
+
+	probe := func(handler http.Handler) int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		return recorder.Code
+	}
+
+	a.Contextf("healthy").EqInt(probe(NewLivenessHandler(lifecycle)), http.StatusOK)
+	a.Contextf("healthy").EqInt(probe(NewReadinessHandler(lifecycle, context)), http.StatusOK)
+
+	unhealthy := context
+	unhealthy.TimeseriesStorageAPI = unhealthyStorage{comboAPI}
+	a.Contextf("unhealthy storage").EqInt(probe(NewLivenessHandler(lifecycle)), http.StatusOK)
+	a.Contextf("unhealthy storage").EqInt(probe(NewReadinessHandler(lifecycle, unhealthy)), http.StatusServiceUnavailable)
+
+	a.Contextf("drain").EqInt(probe(NewDrainHandler(lifecycle, 0)), http.StatusOK)
+	a.EqBool(lifecycle.Draining(), true)
+	a.Contextf("draining").EqInt(probe(NewLivenessHandler(lifecycle)), http.StatusOK)
+	a.Contextf("draining").EqInt(probe(NewReadinessHandler(lifecycle, context)), http.StatusServiceUnavailable)
+}
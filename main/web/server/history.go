@@ -0,0 +1,185 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/square/metrics/history"
+	"github.com/square/metrics/log"
+)
+
+// HistoryConfig keeps the recent queries of each user for the UI, at
+// /history. A user is identified by their API token (sent as
+// "Authorization: Bearer <token>"), and only queries sent with history=true
+// are recorded.
+type HistoryConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Limit   int           `yaml:"limit"` // the number of queries kept for each user, besides their favorites; 100 by default
+	Store   ArchiveConfig `yaml:"store"` // where the histories are kept; without one, they're lost on restart
+}
+
+// newHistory returns nil if history isn't enabled.
+func newHistory(config HistoryConfig) (*history.History, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	store, err := newObjectStore(config.Store)
+	if err != nil {
+		return nil, fmt.Errorf("the history store is invalid: %s", err.Error())
+	}
+	return history.New(config.Limit, store), nil
+}
+
+// historyUser names the user of the request by a hash of their token, so
+// that tokens aren't written to the store. Only the tokens of client profiles
+// name users; requests without one have no user.
+func (c clientProfiles) historyUser(request *http.Request) (string, bool) {
+	if _, ok := c.matchToken(request); !ok {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(requestToken(request)))
+	return hex.EncodeToString(sum[:16]), true
+}
+
+// recordHistory adds the query to the history of its user, if it asked to
+// be recorded.
+func (q queryHandler) recordHistory(request *http.Request, form QueryForm, record QueryRecord) {
+	if q.history == nil || !form.History {
+		return
+	}
+	user, ok := q.clients.historyUser(request)
+	if !ok {
+		return
+	}
+	entry := history.Entry{Query: record.Query, Time: record.Time, Seconds: record.Seconds, Error: record.Error}
+	if _, err := q.history.Record(user, entry); err != nil {
+		log.Errorf("Cannot record the query in the history: %s", err.Error())
+	}
+}
+
+// historyHandler serves the history of the user of the request:
+//
+//	GET  /history                lists their queries, the most recent first
+//	POST /history/<id>/favorite  marks the query as a favorite (or not, with favorite=false)
+//	POST /history/<id>/run       runs the query again, as /query would, with the other parameters given
+type historyHandler struct {
+	history *history.History
+	clients clientProfiles
+	query   http.Handler
+}
+
+func (h historyHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	fail := func(status int, err error) {
+		writer.WriteHeader(status)
+		writer.Write(encodeError(err))
+	}
+	user, ok := h.clients.historyUser(request)
+	if !ok {
+		fail(http.StatusUnauthorized, fmt.Errorf("a history needs the API token of a client profile, sent as \"Authorization: Bearer <token>\""))
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(request.URL.Path, "/history"), "/"), "/")
+	if len(parts) == 1 && parts[0] == "" {
+		if request.Method != "GET" {
+			fail(http.StatusMethodNotAllowed, fmt.Errorf("unsupported method %s", request.Method))
+			return
+		}
+		entries, err := h.history.List(user)
+		if err != nil {
+			fail(http.StatusInternalServerError, err)
+			return
+		}
+		writeHistory(writer, entries)
+		return
+	}
+	if len(parts) != 2 || (parts[1] != "favorite" && parts[1] != "run") {
+		fail(http.StatusNotFound, fmt.Errorf("expected /history[/<id>/favorite|/<id>/run]"))
+		return
+	}
+	if request.Method != "POST" {
+		fail(http.StatusMethodNotAllowed, fmt.Errorf("unsupported method %s", request.Method))
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		fail(http.StatusNotFound, fmt.Errorf("invalid history entry %q", parts[0]))
+		return
+	}
+	if err := request.ParseForm(); err != nil {
+		fail(http.StatusBadRequest, err)
+		return
+	}
+	var entry history.Entry
+	if parts[1] == "favorite" {
+		favorite := true
+		if value := request.Form.Get("favorite"); value != "" {
+			if favorite, err = strconv.ParseBool(value); err != nil {
+				fail(http.StatusBadRequest, fmt.Errorf("invalid favorite %q", value))
+				return
+			}
+		}
+		entry, err = h.history.SetFavorite(user, id, favorite)
+	} else {
+		entry, err = h.history.Get(user, id)
+	}
+	if err == history.ErrNotFound {
+		fail(http.StatusNotFound, fmt.Errorf("your history has no query %d", id))
+		return
+	}
+	if err != nil {
+		fail(http.StatusInternalServerError, err)
+		return
+	}
+	if parts[1] == "favorite" {
+		writeHistory(writer, entry)
+		return
+	}
+	h.query.ServeHTTP(writer, rerunRequest(request, entry.Query))
+}
+
+// rerunRequest is the request to /query which runs the query again, with
+// the other parameters of the original request. The run is recorded in the
+// history too.
+func rerunRequest(request *http.Request, query string) *http.Request {
+	form := url.Values{}
+	for key, values := range request.Form {
+		form[key] = values
+	}
+	form.Set("query", query)
+	form.Set("history", "true")
+	rerun := request.WithContext(request.Context())
+	rerun.URL = &url.URL{Path: "/query"}
+	rerun.Header = http.Header{}
+	for key, values := range request.Header {
+		if key != "Content-Type" {
+			rerun.Header[key] = values
+		}
+	}
+	rerun.Form = form
+	rerun.PostForm = url.Values{}
+	return rerun
+}
+
+func writeHistory(writer http.ResponseWriter, body interface{}) {
+	writeResponse(writer, "history", body)
+}
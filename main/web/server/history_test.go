@@ -0,0 +1,101 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestHistoryHandler(t *testing.T) {
+	a := assert.New(t)
+	store := memory.NewStore(time.Minute)
+	store.AddGenerated(api.TaggedMetric{MetricKey: "requests", TagSet: api.TagSet{"host": "a"}}, func(t time.Time) float64 {
+		return float64(t.Minute())
+	})
+	queryHistory, err := newHistory(HistoryConfig{Enabled: true})
+	a.CheckError(err)
+	clients, err := newClientProfiles([]ClientProfile{
+		{Name: "alice", Tokens: []string{"alice-token"}},
+		{Name: "bob", Tokens: []string{"bob-token"}},
+	})
+	a.CheckError(err)
+	queries := queryHandler{
+		context: command.ExecutionContext{TimeseriesStorageAPI: store, MetricMetadataAPI: store, FetchLimit: 1000, Ctx: context.Background()},
+		clients: clients,
+		history: queryHistory,
+	}
+	handler := historyHandler{history: queryHistory, clients: clients, query: queries}
+
+	serve := func(handler http.Handler, method string, path string, token string, body string) (int, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+
+	// Only queries asking for it are recorded, and only for their own user.
+	code, _ := serve(queries, "POST", "/query", "alice-token", "query=select+requests+from+0+to+600000&history=true")
+	a.EqInt(code, http.StatusOK)
+	serve(queries, "POST", "/query", "alice-token", "query=describe+all")
+	serve(queries, "POST", "/query", "bob-token", "query=describe+requests&history=true")
+	code, body := serve(handler, "GET", "/history", "alice-token", "")
+	a.EqInt(code, http.StatusOK)
+	a.EqBool(strings.Contains(body, `"query": "select requests from 0 to 600000"`), true)
+	a.EqBool(strings.Contains(body, "describe"), false)
+	code, _ = serve(handler, "GET", "/history", "", "")
+	a.EqInt(code, http.StatusUnauthorized)
+	// Made-up tokens have no history, and their queries aren't recorded.
+	code, _ = serve(queries, "POST", "/query", "made-up-token", "query=describe+requests&history=true")
+	a.EqInt(code, http.StatusOK)
+	code, _ = serve(handler, "GET", "/history", "made-up-token", "")
+	a.EqInt(code, http.StatusUnauthorized)
+
+	code, body = serve(handler, "POST", "/history/1/favorite", "alice-token", "")
+	a.EqInt(code, http.StatusOK)
+	a.EqBool(strings.Contains(body, `"favorite": true`), true)
+	code, _ = serve(handler, "POST", "/history/2/favorite", "bob-token", "")
+	a.EqInt(code, http.StatusNotFound)
+	code, _ = serve(handler, "GET", "/history/1/favorite", "alice-token", "")
+	a.EqInt(code, http.StatusMethodNotAllowed)
+
+	code, body = serve(handler, "POST", "/history/1/run", "alice-token", "format=jsonl")
+	a.EqInt(code, http.StatusOK)
+	a.EqBool(strings.Contains(body, "\n  "), false)
+	entries, err := queryHistory.List(historyUserOf(clients, "alice-token"))
+	a.CheckError(err)
+	a.EqInt(len(entries), 2)
+	a.Eq(entries[0].Query, "select requests from 0 to 600000")
+}
+
+func historyUserOf(clients clientProfiles, token string) string {
+	request := httptest.NewRequest("GET", "/history", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	user, _ := clients.historyUser(request)
+	return user
+}
@@ -0,0 +1,87 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPConfig tunes how the server accepts and keeps connections. Fields
+// which are zero keep Go's defaults.
+type HTTPConfig struct {
+	HTTP2                bool `yaml:"http2"`                  // also serve HTTP/2 without TLS (h2c), for proxies which multiplex requests
+	MaxConcurrentStreams int  `yaml:"max_concurrent_streams"` // the requests at once on each HTTP/2 connection
+	DisableKeepAlives    bool `yaml:"disable_keep_alives"`    // close each HTTP/1.1 connection after its response
+	IdleTimeoutSeconds   int  `yaml:"idle_timeout_seconds"`   // how long idle connections are kept; if zero, the read timeout
+	MaxConnections       int  `yaml:"max_connections"`        // if nonzero, further connections wait until others close
+}
+
+// Apply sets the protocols and timeouts of the server.
+func (c HTTPConfig) Apply(server *http.Server) {
+	if c.HTTP2 {
+		server.Protocols = new(http.Protocols)
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
+	if c.MaxConcurrentStreams != 0 {
+		server.HTTP2 = &http.HTTP2Config{MaxConcurrentStreams: c.MaxConcurrentStreams}
+	}
+	if c.IdleTimeoutSeconds != 0 {
+		server.IdleTimeout = time.Duration(c.IdleTimeoutSeconds) * time.Second
+	}
+	server.SetKeepAlivesEnabled(!c.DisableKeepAlives)
+}
+
+// Listen listens on the address, limiting the number of open connections.
+func (c HTTPConfig) Listen(address string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil || c.MaxConnections == 0 {
+		return listener, err
+	}
+	return &limitListener{Listener: listener, slots: make(chan struct{}, c.MaxConnections)}, nil
+}
+
+// limitListener accepts a connection only when fewer than cap(slots) are
+// open.
+type limitListener struct {
+	net.Listener
+	slots chan struct{}
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	l.slots <- struct{}{}
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.slots
+		return nil, err
+	}
+	return &limitConn{Conn: conn, release: func() { <-l.slots }}, nil
+}
+
+// limitConn frees its slot when it's first closed.
+type limitConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
@@ -0,0 +1,79 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestHTTPConfig_HTTP2(t *testing.T) {
+	a := assert.New(t)
+	server := &http.Server{Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(request.Proto))
+	})}
+	HTTPConfig{HTTP2: true, MaxConcurrentStreams: 10}.Apply(server)
+	listener, err := HTTPConfig{}.Listen("127.0.0.1:0")
+	a.CheckError(err)
+	go server.Serve(listener)
+	defer server.Close()
+
+	transport := &http.Transport{Protocols: new(http.Protocols)}
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	response, err := (&http.Client{Transport: transport}).Get("http://" + listener.Addr().String())
+	a.CheckError(err)
+	response.Body.Close()
+	a.EqInt(response.ProtoMajor, 2)
+}
+
+func TestHTTPConfig_MaxConnections(t *testing.T) {
+	a := assert.New(t)
+	listener, err := HTTPConfig{MaxConnections: 1}.Listen("127.0.0.1:0")
+	a.CheckError(err)
+	defer listener.Close()
+	accepted := make(chan net.Conn)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		client, err := net.Dial("tcp", listener.Addr().String())
+		a.CheckError(err)
+		defer client.Close()
+	}
+	first := <-accepted
+	select {
+	case <-accepted:
+		t.Fatalf("a second connection was accepted while the first was open")
+	case <-time.After(50 * time.Millisecond):
+	}
+	first.Close()
+	select {
+	case second := <-accepted:
+		second.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the second connection wasn't accepted after the first closed")
+	}
+}
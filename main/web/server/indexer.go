@@ -0,0 +1,47 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/square/metrics/metric_metadata/indexer"
+)
+
+// indexerHandler reports the progress of the metadata indexer and the drift
+// it has found. A POST starts a scan once the current one is done.
+type indexerHandler struct {
+	indexer *indexer.Indexer
+}
+
+// NewIndexerHandler creates a handler for administering the given indexer.
+func NewIndexerHandler(indexer *indexer.Indexer) http.Handler {
+	return indexerHandler{indexer: indexer}
+}
+
+func (h indexerHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	switch request.Method {
+	case "GET":
+	case "POST":
+		h.indexer.Trigger()
+	default:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	writeResponse(writer, "", h.indexer.Status())
+}
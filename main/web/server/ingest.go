@@ -53,7 +53,7 @@ func (h ingestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Reque
 			TagSet:    metrics[i].Tags,
 		}
 	}
-	err := h.metricMetadataAPI.AddMetrics(taggedMetrics, metadata.Context{})
+	err := h.metricMetadataAPI.AddMetrics(request.Context(), taggedMetrics, metadata.Context{})
 	if err != nil {
 		writer.WriteHeader(http.StatusBadRequest)
 		writer.Write(encodeError(err))
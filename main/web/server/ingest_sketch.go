@@ -0,0 +1,108 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/timeseries"
+)
+
+// sketchIngestHandler accepts t-digests of the raw values of series, which
+// the storage merges so that their percentiles can be queried with
+// sketch.percentile.
+type sketchIngestHandler struct {
+	storage           timeseries.SketchStorageAPI
+	metricMetadataAPI metadata.MetricUpdateAPI // optional
+}
+
+// SketchIngestRequest gives the raw values of a series at a time, either
+// listed or already summarized by an encoded t-digest (or both).
+type SketchIngestRequest struct {
+	Name      string            `json:"name"`
+	Tags      map[string]string `json:"tags"`
+	Timestamp int64             `json:"timestamp"` // milliseconds
+	Values    []float64         `json:"values,omitempty"`
+	Sketch    []byte            `json:"sketch,omitempty"` // base64, as encoded by tdigest.Digest.Encode
+}
+
+func (h sketchIngestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "POST" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	if request.Header.Get("Content-Type") != "application/json" {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(fmt.Errorf("sketch ingestion expects Content-Type: application/json")))
+		return
+	}
+	samples := []SketchIngestRequest{}
+	if err := json.NewDecoder(request.Body).Decode(&samples); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(err))
+		return
+	}
+	// Every sample is checked before any is stored.
+	metrics := make([]api.TaggedMetric, len(samples))
+	sketches := make([]*tdigest.Digest, len(samples))
+	for i, sample := range samples {
+		if sample.Name == "" {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write(encodeError(fmt.Errorf("sample %d has no metric name", i)))
+			return
+		}
+		sketch := tdigest.New(0)
+		if sample.Sketch != nil {
+			decoded, err := tdigest.Decode(sample.Sketch)
+			if err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				writer.Write(encodeError(fmt.Errorf("sample %d: %s", i, err.Error())))
+				return
+			}
+			sketch = decoded
+		}
+		for _, value := range sample.Values {
+			sketch.Add(value)
+		}
+		metrics[i] = api.TaggedMetric{MetricKey: api.MetricKey(sample.Name), TagSet: api.TagSet(sample.Tags)}
+		if metrics[i].TagSet == nil {
+			metrics[i].TagSet = api.NewTagSet()
+		}
+		sketches[i] = sketch
+	}
+	if h.metricMetadataAPI != nil {
+		if err := h.metricMetadataAPI.AddMetrics(request.Context(), metrics, metadata.Context{}); err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			writer.Write(encodeError(err))
+			return
+		}
+	}
+	for i, sample := range samples {
+		if err := h.storage.AddSketch(metrics[i], time.Unix(0, sample.Timestamp*1e6), sketches[i]); err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			writer.Write(encodeError(err))
+			return
+		}
+	}
+	writer.Write([]byte(`{"success": true}`))
+}
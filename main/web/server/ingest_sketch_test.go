@@ -0,0 +1,71 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestSketchIngestHandler(t *testing.T) {
+	a := assert.New(t)
+	store := memory.NewStore(30 * time.Second)
+	handler := sketchIngestHandler{storage: store, metricMetadataAPI: store}
+	post := func(samples interface{}) int {
+		encoded, err := json.Marshal(samples)
+		a.CheckError(err)
+		request := httptest.NewRequest("POST", "/ingest/sketch", bytes.NewReader(encoded))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+	digest := tdigest.New(0)
+	for _, value := range []float64{100, 200, 300} {
+		digest.Add(value)
+	}
+	a.EqInt(post([]SketchIngestRequest{
+		{Name: "latency", Tags: map[string]string{"host": "a"}, Timestamp: 0, Values: []float64{1, 2, 3}},
+		{Name: "latency", Tags: map[string]string{"host": "a"}, Timestamp: 10000, Sketch: digest.Encode()},
+	}), http.StatusOK)
+	a.EqInt(post([]SketchIngestRequest{{Tags: map[string]string{"host": "a"}, Values: []float64{1}}}), http.StatusBadRequest)
+	a.EqInt(post([]SketchIngestRequest{{Name: "latency", Sketch: []byte{9}}}), http.StatusBadRequest)
+
+	tagSets, err := store.GetAllTags(context.Background(), "latency", metadata.Context{})
+	a.CheckError(err)
+	a.Eq(tagSets, []api.TagSet{{"host": "a"}})
+	timerange, err := api.NewTimerange(0, 0, 30000)
+	a.CheckError(err)
+	series, err := store.FetchSingleTimeseries(timeseries.FetchRequest{
+		Metric:         api.TaggedMetric{MetricKey: "latency", TagSet: api.TagSet{"host": "a"}},
+		RequestDetails: timeseries.RequestDetails{Timerange: timerange},
+	})
+	a.CheckError(err)
+	a.Eq(series.Samples, []int{6})
+	a.EqFloat(series.Sketches[0].Quantile(0), 1, 0)
+	a.EqFloat(series.Sketches[0].Quantile(1), 300, 0)
+}
@@ -0,0 +1,161 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/log"
+	"github.com/square/metrics/query/command"
+)
+
+// jsonLinesContentType is the media type of JSON Lines.
+const jsonLinesContentType = "application/jsonl"
+
+// seriesLine is a single series of a select result, written as one line of
+// JSON Lines output. It carries the query and timerange of its result so it
+// can be read on its own.
+type seriesLine struct {
+	Query     string
+	Name      string
+	Timerange api.Timerange
+	Series    api.Timeseries
+}
+
+// MarshalJSON flattens the series into the line, with NaN values as null.
+func (line seriesLine) MarshalJSON() ([]byte, error) {
+	header, err := json.Marshal(struct {
+		Query     string        `json:"query"`
+		Name      string        `json:"name"`
+		Timerange api.Timerange `json:"timerange"`
+	}{line.Query, line.Name, line.Timerange})
+	if err != nil {
+		return nil, err
+	}
+	series, err := line.Series.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	buffer.Write(header[:len(header)-1])
+	buffer.WriteByte(',')
+	buffer.Write(series[1:])
+	return buffer.Bytes(), nil
+}
+
+// endLine is the last line of JSON Lines output. A transfer which was cut
+// short is still parseable, and can be recognized by its absence.
+type endLine struct {
+	End    bool `json:"end"`
+	Series int  `json:"series"` // the number of series lines written
+}
+
+// jsonLinesWriter writes the results of a select as JSON Lines while it's
+// evaluated: one line for each series (or for each result which isn't a list
+// of series), followed by an end line. Its write method is the Stream of the
+// select's execution context, so each result is written and flushed to the
+// client as soon as it (and those before it) are ready, and stream processors
+// can begin before the rest of the select has been evaluated.
+type jsonLinesWriter struct {
+	writer  http.ResponseWriter
+	stream  *jsonStream // nil until the first line is written
+	results int         // the number of results written
+	seen    int         // the number of results passed to write in this run of the select
+	series  int         // the number of series lines written
+}
+
+func newJSONLinesWriter(writer http.ResponseWriter) *jsonLinesWriter {
+	return &jsonLinesWriter{writer: writer}
+}
+
+// restart begins another run of the select. A query which was preempted by
+// the scheduler runs again from the start, and the results which were already
+// written are skipped.
+func (lines *jsonLinesWriter) restart() {
+	lines.seen = 0
+}
+
+// started is whether anything has been written, after which the status can
+// no longer be changed.
+func (lines *jsonLinesWriter) started() bool {
+	return lines.stream != nil
+}
+
+// write writes the lines of the next result, and flushes them.
+func (lines *jsonLinesWriter) write(result command.QueryResult) error {
+	lines.seen++
+	if lines.seen <= lines.results {
+		return nil
+	}
+	if lines.stream == nil {
+		lines.writer.Header().Set("Content-Type", jsonLinesContentType)
+		lines.stream = newJSONStream(lines.writer)
+	}
+	if result.Type != "series" {
+		lines.stream.encode(result)
+	}
+	for _, timeseries := range result.Series {
+		lines.stream.encode(seriesLine{Query: result.Query, Name: result.Name, Timerange: result.Timerange, Series: timeseries})
+		lines.series++
+	}
+	lines.stream.flush()
+	lines.results++
+	return lines.stream.err
+}
+
+// finish writes the results of the response which weren't already written,
+// and then the end line.
+func (lines *jsonLinesWriter) finish(response QueryResponse) {
+	results, ok := response.Body.([]command.QueryResult)
+	if !ok {
+		writer := lines.writer
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(fmt.Errorf("JSON Lines output is only available for select queries")))
+		return
+	}
+	for i := lines.results; i < len(results); i++ {
+		lines.write(results[i])
+	}
+	if lines.stream == nil {
+		lines.writer.Header().Set("Content-Type", jsonLinesContentType)
+		lines.stream = newJSONStream(lines.writer)
+	}
+	lines.stream.encode(endLine{End: true, Series: lines.series})
+	lines.close()
+}
+
+// fail writes an error line in place of the end line, once some results have
+// been written.
+func (lines *jsonLinesWriter) fail(err error) {
+	lines.stream.encode(Response{Success: false, Message: err.Error(), Code: classifyError(err).Code})
+	lines.close()
+}
+
+func (lines *jsonLinesWriter) close() {
+	if lines.stream.err != nil {
+		// Usually, the client has gone away.
+		log.Errorf("Error writing JSON Lines output: %s", lines.stream.err.Error())
+	}
+}
+
+// writeJSONLines renders the results of a select query which has already been
+// evaluated as JSON Lines.
+func writeJSONLines(writer http.ResponseWriter, response QueryResponse) {
+	newJSONLinesWriter(writer).finish(response)
+}
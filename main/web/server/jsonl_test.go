@@ -0,0 +1,154 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+)
+
+func TestWriteJSONLines(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 20, 10)
+	a.CheckError(err)
+	recorder := httptest.NewRecorder()
+	writeJSONLines(recorder, QueryResponse{Name: "select", Body: []command.QueryResult{
+		{Query: "cpu", Name: "cpu", Type: "series", Timerange: timerange, Series: []api.Timeseries{
+			{Values: []float64{1, math.NaN(), 3}, TagSet: api.TagSet{"host": "a"}},
+			{Values: []float64{4, 5, 6}, TagSet: api.TagSet{"host": "b"}},
+		}},
+		{Query: "summarize_table(cpu, 'max')", Name: "max", Type: "table", Table: &function.Table{Columns: []string{"max"}}},
+	}})
+	a.EqInt(recorder.Code, http.StatusOK)
+	a.EqString(recorder.Header().Get("Content-Type"), "application/jsonl")
+	a.EqBool(recorder.Flushed, true)
+	lines := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n"), "\n")
+	a.EqInt(len(lines), 4)
+	a.EqString(lines[0], `{"query":"cpu","name":"cpu","timerange":{"start":0,"end":20,"resolution":10},"tagset":{"host":"a"},"values":[1,null,3]}`)
+	a.EqString(lines[1], `{"query":"cpu","name":"cpu","timerange":{"start":0,"end":20,"resolution":10},"tagset":{"host":"b"},"values":[4,5,6]}`)
+	a.EqBool(strings.HasPrefix(lines[2], `{"query":"summarize_table(cpu, 'max')","name":"max","type":"table"`), true)
+	a.EqString(lines[3], `{"end":true,"series":2}`)
+
+	recorder = httptest.NewRecorder()
+	writeJSONLines(recorder, QueryResponse{Name: "describe", Body: map[string][]string{}})
+	a.EqInt(recorder.Code, http.StatusBadRequest)
+}
+
+// flushSignal is a recorder which closes flushed the first time it's flushed.
+type flushSignal struct {
+	*httptest.ResponseRecorder
+	flushed chan struct{}
+}
+
+func (recorder flushSignal) Flush() {
+	select {
+	case <-recorder.flushed:
+	default:
+		close(recorder.flushed)
+	}
+	recorder.ResponseRecorder.Flush()
+}
+
+// slowStorage only returns the "slow" metric once the output has been flushed.
+type slowStorage struct {
+	mocks.FakeComboAPI
+	flushed chan struct{}
+}
+
+func (storage slowStorage) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	for _, metric := range request.Metrics {
+		if metric.MetricKey != "slow" {
+			continue
+		}
+		select {
+		case <-storage.flushed:
+		case <-time.After(time.Second):
+			return api.SeriesList{}, fmt.Errorf("slow was fetched before anything was flushed")
+		}
+	}
+	return storage.FakeComboAPI.FetchMultipleTimeseries(request)
+}
+
+func TestServeJSONLines_Streams(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 20, 10)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3}, TagSet: api.TagSet{"metric": "fast", "host": "a"}},
+		api.Timeseries{Values: []float64{4, 5, 6}, TagSet: api.TagSet{"metric": "slow", "host": "a"}},
+	)
+	recorder := flushSignal{httptest.NewRecorder(), make(chan struct{})}
+	handler := queryHandler{context: command.ExecutionContext{
+		TimeseriesStorageAPI: slowStorage{comboAPI, recorder.flushed},
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	}}
+	form := url.Values{"query": {"select fast, slow from 0 to 20 resolution 10ms"}, "format": {"jsonl"}}
+	request := httptest.NewRequest("POST", "/query", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(recorder, request)
+
+	// The fast series was written before the slow one was fetched.
+	lines := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n"), "\n")
+	a.EqInt(len(lines), 3)
+	a.EqString(lines[0], `{"query":"fast","name":"fast","timerange":{"start":0,"end":20,"resolution":10},"tagset":{"host":"a"},"values":[1,2,3]}`)
+	a.EqString(lines[1], `{"query":"slow","name":"slow","timerange":{"start":0,"end":20,"resolution":10},"tagset":{"host":"a"},"values":[4,5,6]}`)
+	a.EqString(lines[2], `{"end":true,"series":2}`)
+}
+
+func TestServeJSONLines_FailsAfterLines(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 20, 10)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3}, TagSet: api.TagSet{"metric": "fast", "host": "a"}},
+		api.Timeseries{Values: []float64{4, 5, 6}, TagSet: api.TagSet{"metric": "slow", "host": "a"}},
+	)
+	// Nothing flushes this signal, so fetching slow fails once fast is written.
+	handler := queryHandler{context: command.ExecutionContext{
+		TimeseriesStorageAPI: slowStorage{comboAPI, make(chan struct{})},
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	}}
+	form := url.Values{"query": {"select fast, slow from 0 to 20 resolution 10ms"}, "format": {"jsonl"}}
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/query", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(recorder, request)
+
+	// The status was sent with the first line, so the error is the last line.
+	a.EqInt(recorder.Code, http.StatusOK)
+	lines := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n"), "\n")
+	a.EqInt(len(lines), 2)
+	a.EqBool(strings.HasPrefix(lines[0], `{"query":"fast"`), true)
+	a.EqBool(strings.HasPrefix(lines[1], `{"success":false`), true)
+	a.EqBool(strings.Contains(lines[1], "slow was fetched before anything was flushed"), true)
+}
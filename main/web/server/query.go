@@ -15,22 +15,34 @@
 package server
 
 import (
+	"bytes"
+	netcontext "context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/square/metrics/archive"
+	"github.com/square/metrics/columnar"
+	"github.com/square/metrics/history"
 	"github.com/square/metrics/inspect"
 	"github.com/square/metrics/log"
 	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/macro"
 	"github.com/square/metrics/query/parser"
 	"github.com/square/metrics/query/predicate"
+	"github.com/square/metrics/query/script"
+	"github.com/square/metrics/tasks"
+	"github.com/square/metrics/webhook"
 )
 
 type Response struct {
 	Success bool   `json:"success"`
 	Message string `json:"message,omitempty"`
+	Code    string `json:"code,omitempty"` // Code identifies the kind of error; see /api/v1/errors.
 	QueryResponse
 	Profile []inspect.Profile `json:"profile,omitempty"`
 }
@@ -42,8 +54,26 @@ type QueryResponse struct {
 }
 
 type queryHandler struct {
-	hook    Hook
-	context command.ExecutionContext
+	hook       Hook
+	context    command.ExecutionContext
+	clients    clientProfiles
+	scheduler  *tasks.Scheduler      // optional
+	running    *tasks.RunningQueries // optional
+	rejections *rejectionLog         // optional
+	archiver   *archive.Archiver     // optional
+	webhooks   *webhook.Dispatcher   // optional
+	tenants    *macro.Tenants        // optional
+	describes  *describeCache        // optional
+	labels     []string              // the labels sent with backend requests
+	support    *supportRecorder      // optional
+	history    *history.History      // optional
+}
+
+func newScheduler(config SchedulerConfig) *tasks.Scheduler {
+	if config.MaxConcurrentQueries == 0 {
+		return nil
+	}
+	return tasks.NewScheduler(config.MaxConcurrentQueries, config.Preempt)
 }
 
 type KeyIs struct {
@@ -61,13 +91,25 @@ type KeyMatch struct {
 	Regex string `json:"regex"`
 }
 
+type KeyInCIDR struct {
+	Key    string   `json:"key"`
+	Blocks []string `json:"blocks"`
+}
+
+type KeyGlob struct {
+	Key      string   `json:"key"`
+	Patterns []string `json:"patterns"`
+}
+
 type Constraint struct {
-	Not      *Constraint  `json:"not,omitempty"`
-	All      []Constraint `json:"all,omitempty"`
-	Any      []Constraint `json:"any,omitempty"`
-	KeyIs    *KeyIs       `json:"key_is,omitempty"`
-	KeyIn    *KeyIn       `json:"key_in,omitempty"`
-	KeyMatch *KeyMatch    `json:"key_match,omitempty"`
+	Not       *Constraint  `json:"not,omitempty"`
+	All       []Constraint `json:"all,omitempty"`
+	Any       []Constraint `json:"any,omitempty"`
+	KeyIs     *KeyIs       `json:"key_is,omitempty"`
+	KeyIn     *KeyIn       `json:"key_in,omitempty"`
+	KeyMatch  *KeyMatch    `json:"key_match,omitempty"`
+	KeyInCIDR *KeyInCIDR   `json:"key_in_cidr,omitempty"`
+	KeyGlob   *KeyGlob     `json:"key_glob,omitempty"`
 }
 
 type singleChecker struct {
@@ -107,6 +149,12 @@ func predicateFromConstraint(c Constraint) (predicate.Predicate, error) {
 	if err := only.add(c.KeyMatch != nil, "key_match"); err != nil {
 		return nil, err
 	}
+	if err := only.add(c.KeyInCIDR != nil, "key_in_cidr"); err != nil {
+		return nil, err
+	}
+	if err := only.add(c.KeyGlob != nil, "key_glob"); err != nil {
+		return nil, err
+	}
 	if !only.found {
 		return nil, fmt.Errorf("constraint has no contents")
 	}
@@ -165,34 +213,76 @@ func predicateFromConstraint(c Constraint) (predicate.Predicate, error) {
 			Tag:   c.KeyMatch.Key,
 			Regex: regex,
 		}, nil
+	case "key_in_cidr":
+		if c.KeyInCIDR.Key == "" {
+			return nil, fmt.Errorf(`key is given no value in "key_in_cidr" constraint`)
+		}
+		return predicate.NewCIDRMatcher(c.KeyInCIDR.Key, c.KeyInCIDR.Blocks)
+	case "key_glob":
+		if c.KeyGlob.Key == "" {
+			return nil, fmt.Errorf(`key is given no value in "key_glob" constraint`)
+		}
+		return predicate.NewGlobMatcher(c.KeyGlob.Key, c.KeyGlob.Patterns)
 	default:
 		panic(fmt.Sprintf("internal error: unknown constraint name: %q", only.name))
 	}
 }
 
 type QueryForm struct {
-	Input       string      `query:"query" json:"query"`     // query to execute.
-	Profile     bool        `query:"profile" json:"profile"` // if true, then profile information will be exposed to the user.
-	Constraints *Constraint `query:"-" json:"where"`
+	Input               string      `query:"query" json:"query"`     // query to execute.
+	Profile             bool        `query:"profile" json:"profile"` // if true, then profile information will be exposed to the user.
+	Constraints         *Constraint `query:"-" json:"where"`
+	SuppressMaintenance bool        `query:"suppress_maintenance" json:"suppress_maintenance"` // if true, series are masked during their maintenance windows.
+	Format              string      `query:"format" json:"format"`                             // if "csv", table results are rendered as CSV instead of JSON; if "arrow" or "parquet", series are rendered in that format; if "jsonl", as a line of JSON each; otherwise, by the hook's encoder of that name.
+	Mode                string      `query:"mode" json:"mode"`                                 // if "fuzzy", describe all ranks metrics by similarity to its match text.
+	TrailingBucket      string      `query:"trailing_bucket" json:"trailing_bucket"`           // "keep", "trim" or "flag" the incomplete last bucket; overrides the server's default.
+	Collation           string      `query:"collation" json:"collation"`                       // the collation used to order tag values; overrides the server's default.
+	Strict              bool        `query:"strict" json:"strict"`                             // if true, empty fetches, unknown group-by tags and NaN-only results are errors.
+	Archive             string      `query:"archive" json:"archive"`                           // if set, the result is archived under this name (such as "2016-09 capacity report").
+	Partial             bool        `query:"partial" json:"partial"`                           // if true, a select which runs short of time returns the prefix of its timerange which was fetched.
+	Stream              bool        `query:"stream" json:"stream"`                             // if true, the JSON response is written as it's encoded, a series at a time, rather than all at once (still only once the query has been evaluated).
+	History             bool        `query:"history" json:"history"`                           // if true, the query is recorded in the history of the user of the request's token.
+	Debug               string      `query:"debug" json:"debug"`                               // if "backend", the requests made to the backends are returned in the metadata as backend_requests.
 }
 
-func (q queryHandler) process(profiler *inspect.Profiler, parsedForm QueryForm) (QueryResponse, error) {
+// debugBackend is the value of the debug parameter which records the
+// requests made to the backends.
+const debugBackend = "backend"
+
+// process runs the query, also returning the directives of its comments so
+// that they can be recorded even if it fails.
+func (q queryHandler) process(context command.ExecutionContext, profiler *inspect.Profiler, parsedForm QueryForm) (QueryResponse, parser.Directives, error) {
 	log.Infof("INPUT: %+v\n", parsedForm)
 	var rawCommand command.Command
+	var directives parser.Directives
 	var err error
 	profiler.Do("Parsing Query", func() {
-		rawCommand, err = parser.Parse(parsedForm.Input)
+		if script.IsScript(parsedForm.Input) {
+			rawCommand, err = script.Parse(parsedForm.Input)
+			return
+		}
+		rawCommand, directives, err = parser.ParseWithDirectives(parsedForm.Input)
 	})
 	if err != nil {
-		return QueryResponse{}, err
+		return QueryResponse{}, nil, err
 	}
 
-	context := q.context
+	context.Labels = q.backendLabels(context.Labels, directives)
+	context.SuppressMaintenance = parsedForm.SuppressMaintenance
+	context.Strict = parsedForm.Strict
+	context.PartialResults = context.PartialResults || parsedForm.Partial
+	context.DescribeMode = parsedForm.Mode
+	if parsedForm.TrailingBucket != "" {
+		context.TrailingBucket = parsedForm.TrailingBucket
+	}
+	if parsedForm.Collation != "" {
+		context.Collation = parsedForm.Collation
+	}
 
 	if parsedForm.Constraints != nil {
 		predicate, err := predicateFromConstraint(*parsedForm.Constraints)
 		if err != nil {
-			return QueryResponse{}, err
+			return QueryResponse{}, directives, err
 		}
 		context.AdditionalConstraints = predicate // Attach the predicate to the context.
 	}
@@ -204,15 +294,152 @@ func (q queryHandler) process(profiler *inspect.Profiler, parsedForm QueryForm)
 	profiler.Do("Total Execution", func() {
 		result, err = profiledCommand.Execute(context)
 	})
+	logExecution(parsedForm.Input, directives, profiler.All(), profiledCommand.Name()+".Execute", err)
 	if err != nil {
-		return QueryResponse{}, err
+		return QueryResponse{}, directives, err
+	}
+	if len(directives) != 0 {
+		if result.Metadata == nil {
+			result.Metadata = map[string]interface{}{}
+		}
+		result.Metadata["directives"] = directives
 	}
 
 	return QueryResponse{
 		Body:     result.Body,
 		Metadata: result.Metadata,
 		Name:     profiledCommand.Name(),
-	}, nil
+	}, directives, nil
+}
+
+// backendLabels chooses the labels sent with backend requests, so that they
+// can be attributed to the query: the configured ones among those of the
+// client (its name and tenant) and the query's directives. The client's
+// labels can't be replaced by directives.
+func (q queryHandler) backendLabels(client map[string]string, directives parser.Directives) map[string]string {
+	var labels map[string]string
+	for _, name := range q.labels {
+		value, ok := client[name]
+		if !ok {
+			value, ok = directives[name]
+		}
+		if !ok {
+			continue
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[name] = value
+	}
+	return labels
+}
+
+// logExecution writes the wall and CPU time of a query to the query log, so
+// that queries which wait on the backends can be told apart from queries
+// which are expensive to compute. The query's directives are logged too, so
+// that expensive queries can be traced to their owners.
+func logExecution(input string, directives parser.Directives, profiles []inspect.Profile, name string, err error) {
+	for _, profile := range profiles {
+		if profile.Name != name {
+			continue
+		}
+		shared := ""
+		if profile.CPUShared {
+			shared = " (shared with concurrent queries)"
+		}
+		outcome := "succeeded"
+		if err != nil {
+			outcome = "failed"
+		}
+		if len(directives) != 0 {
+			outcome += " [" + directives.String() + "]"
+		}
+		log.Infof("QUERY %s in %s wall time, %s CPU time%s: %q", outcome, profile.Duration(), profile.CPU, shared, input)
+		return
+	}
+}
+
+// rejectionCodes are the error codes of queries rejected by limits.
+var rejectionCodes = map[string]bool{
+	"limit_exceeded": true,
+	"fetch_limit":    true,
+	"query_timeout":  true,
+}
+
+// label describes the query to those inspecting the scheduler. Its owner is
+// found by parsing the query ahead of its admission.
+func (q queryHandler) label(input string, client string) tasks.Label {
+	label := tasks.Label{Query: input, Client: client}
+	if !script.IsScript(input) {
+		if _, directives, err := parser.ParseWithDirectives(input); err == nil {
+			label.Owner = directives["owner"]
+		}
+	}
+	return label
+}
+
+// notify fires the webhooks for slow and rejected queries. The events carry
+// the query's directives in their details.
+func (q queryHandler) notify(input string, directives parser.Directives, duration time.Duration, err error) {
+	if q.webhooks == nil {
+		return
+	}
+	var details map[string]interface{}
+	if len(directives) != 0 {
+		details = map[string]interface{}{"directives": directives}
+	}
+	q.webhooks.Fire(webhook.Event{
+		Type:    webhook.SlowQuery,
+		Query:   input,
+		Seconds: duration.Seconds(),
+		Details: details,
+	})
+	if err == nil {
+		return
+	}
+	if code := classifyError(err).Code; rejectionCodes[code] {
+		q.webhooks.Fire(webhook.Event{
+			Type:    webhook.QueryRejected,
+			Query:   input,
+			Seconds: duration.Seconds(),
+			Message: err.Error(),
+			Code:    code,
+			Details: details,
+		})
+	}
+}
+
+// archive stores the response, and notes where in its metadata. A failure
+// to archive doesn't fail the query, whose result is still returned.
+func (q queryHandler) archive(form QueryForm, response *QueryResponse) {
+	entry, err := q.archiver.Archive(form.Archive, form.Input, Response{Success: true, QueryResponse: *response})
+	if response.Metadata == nil {
+		response.Metadata = map[string]interface{}{}
+	}
+	if err != nil {
+		log.Errorf("Cannot archive the result of %q: %s", form.Input, err.Error())
+		response.Metadata["archive_error"] = err.Error()
+		return
+	}
+	response.Metadata["archive"] = entry
+}
+
+// describeKey returns the key under which the result of the query is cached,
+// if it's a describe query and describe results are cached. Profiled (or
+// debugged) and archived queries always run.
+func (q queryHandler) describeKey(client string, form QueryForm, profile bool) (string, bool) {
+	if q.describes == nil || profile || form.Archive != "" {
+		return "", false
+	}
+	parsed, err := parser.Parse(form.Input)
+	if err != nil || !strings.HasPrefix(parsed.Name(), "describe") {
+		return "", false
+	}
+	encoded, err := json.Marshal(form)
+	if err != nil {
+		return "", false
+	}
+	return client + " " + string(encoded), true
 }
 
 // HTTPError indicates that an error should override the return code.
@@ -242,27 +469,164 @@ func (q queryHandler) ServeHTTP(writer http.ResponseWriter, request *http.Reques
 		parseStruct(request.Form, &queryForm)
 	}
 
-	// "process" does the hard work for the handler, but doesn't touch the HTTP details.
-	responseMessage, err := q.process(profiler, queryForm)
-	if err != nil {
-		if errHTTP, ok := err.(HTTPError); ok {
-			// If an HTTPError is returned, then we use its reported code instead of
-			// StatusBadRequest. This can be used to identify errors as 500s instead
-			// of always blaming the client.
-			writer.WriteHeader(errHTTP.ErrorCode())
+	if queryForm.Archive != "" && q.archiver == nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(fmt.Errorf("results cannot be archived, since no archive is configured")))
+		return
+	}
+	if _, ok := q.clients.matchToken(request); queryForm.Archive != "" && !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write(encodeError(errArchiveToken))
+		return
+	}
+	switch queryForm.Debug {
+	case "":
+	case debugBackend:
+		profiler.RecordBackendRequests()
+	default:
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(fmt.Errorf("unknown debug option %q; the only option is %q", queryForm.Debug, debugBackend)))
+		return
+	}
+
+	context := q.context
+	priority := tasks.Interactive
+	clientName := ""
+	if client, ok := q.clients.match(request); ok {
+		log.Infof("Using the limits of client profile %q", client.Name)
+		context = client.Apply(context)
+		priority = client.priority
+		clientName = client.Name
+		context.Labels = map[string]string{"client": client.Name}
+		if client.Tenant != "" {
+			context.Labels["tenant"] = client.Tenant
+		}
+		if client.Tenant != "" && q.tenants != nil {
+			registry, err := q.tenants.Registry(client.Tenant)
+			if err != nil {
+				writer.WriteHeader(http.StatusInternalServerError)
+				writer.Write(encodeError(err))
+				return
+			}
+			context.Registry = registry
+		}
+	}
+
+	// JSON Lines are written as the select is evaluated.
+	var lines *jsonLinesWriter
+	if queryForm.Format == "jsonl" {
+		lines = newJSONLinesWriter(writer)
+		context.Stream = lines.write
+	}
+
+	// The ID lets the query be cancelled through /cancel while it runs.
+	label := q.label(queryForm.Input, clientName)
+	ctx, queryID, done := q.running.Start(context.Ctx, label)
+	defer done()
+	if queryID != "" {
+		writer.Header().Set("X-Query-Id", queryID)
+	}
+
+	// execute does the hard work for the handler, but doesn't touch the HTTP details.
+	execute := func(parent netcontext.Context, profiler *inspect.Profiler) (QueryResponse, error) {
+		var responseMessage QueryResponse
+		var directives parser.Directives
+		context := context
+		run := func(ctx netcontext.Context) error {
+			context.Ctx = ctx
+			if lines != nil {
+				lines.restart()
+			}
+			var err error
+			responseMessage, directives, err = q.process(context, profiler, queryForm)
+			return err
+		}
+		var err error
+		start := time.Now()
+		if q.scheduler != nil {
+			if parent == nil {
+				parent = netcontext.Background()
+			}
+			err = q.scheduler.RunLabeled(parent, priority, label, run)
 		} else {
-			writer.WriteHeader(http.StatusBadRequest)
+			err = run(parent)
+		}
+		if err != nil && q.running.Cancelled(queryID) {
+			err = tasks.ErrCancelled
 		}
+		duration := time.Since(start)
+		q.notify(queryForm.Input, directives, duration, err)
+		record := QueryRecord{Time: start, Query: queryForm.Input, Client: clientName, Directives: directives, Seconds: duration.Seconds()}
+		if err != nil {
+			record.Error = err.Error()
+			if code := classifyError(err).Code; rejectionCodes[code] {
+				q.rejections.record(Rejection{QueryRecord: record, Priority: priority.String(), Code: code})
+			}
+		}
+		q.support.recordQuery(record, profiler)
+		q.recordHistory(request, queryForm, record)
+		return responseMessage, err
+	}
+
+	showProfile, _ := strconv.ParseBool(request.Form.Get("profile"))
+
+	var responseMessage QueryResponse
+	var err error
+	if key, ok := q.describeKey(clientName, queryForm, showProfile || profiler.RecordsBackendRequests()); ok {
+		var age time.Duration
+		var status string
+		responseMessage, age, status, err = q.describes.get(ctx, key, func(ctx netcontext.Context) (QueryResponse, error) {
+			return execute(ctx, inspect.New())
+		})
+		q.describes.setHeaders(writer.Header(), age, status)
+	} else {
+		responseMessage, err = execute(ctx, profiler)
+	}
+	if err != nil && lines != nil && lines.started() {
+		lines.fail(err)
+		return
+	}
+	if err != nil {
+		// The status comes from the error catalog, unless the error is an
+		// HTTPError reporting its own status.
+		writer.WriteHeader(errorStatus(err))
 		writer.Write(encodeError(err))
 		return
 	}
 
+	if profiler.RecordsBackendRequests() {
+		if responseMessage.Metadata == nil {
+			responseMessage.Metadata = map[string]interface{}{}
+		}
+		responseMessage.Metadata["backend_requests"] = profiler.BackendRequests()
+	}
+
+	if queryForm.Archive != "" {
+		q.archive(queryForm, &responseMessage)
+	}
+
+	switch queryForm.Format {
+	case "csv":
+		writeCSV(writer, responseMessage)
+		return
+	case "arrow", "parquet":
+		writeColumnar(writer, responseMessage, queryForm.Format)
+		return
+	case "jsonl":
+		lines.finish(responseMessage)
+		return
+	}
+	if encoder, ok := q.hook.Encoders[queryForm.Format]; ok {
+		writeEncoded(writer, responseMessage, queryForm.Format, encoder)
+		return
+	}
+
 	responseJSON := Response{
 		Success:       true,
 		QueryResponse: responseMessage,
 	}
 
-	if showProfile, _ := strconv.ParseBool(request.Form.Get("profile")); showProfile {
+	if showProfile {
 		responseJSON.Profile = profiler.All()
 	}
 
@@ -273,6 +637,11 @@ func (q queryHandler) ServeHTTP(writer http.ResponseWriter, request *http.Reques
 		}()
 	}
 
+	if queryForm.Stream {
+		writeStreamed(writer, responseJSON)
+		return
+	}
+
 	pretty, _ := strconv.ParseBool(request.Form.Get("pretty")) // If it's absent, default to false.
 
 	var encoded []byte
@@ -289,3 +658,66 @@ func (q queryHandler) ServeHTTP(writer http.ResponseWriter, request *http.Reques
 
 	writer.Write(encoded)
 }
+
+// writeCSV renders the table results of a select query as CSV.
+// Tables from several expressions are separated by an empty line.
+func writeCSV(writer http.ResponseWriter, response QueryResponse) {
+	results, ok := response.Body.([]command.QueryResult)
+	if !ok {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(fmt.Errorf("CSV output is only available for select queries")))
+		return
+	}
+	for _, result := range results {
+		if result.Table == nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write(encodeError(fmt.Errorf("CSV output is only available for table results, but %s is of type %s", result.Query, result.Type)))
+			return
+		}
+	}
+	var buffer bytes.Buffer
+	for i, result := range results {
+		if i != 0 {
+			buffer.WriteString("\n")
+		}
+		if err := result.Table.WriteCSV(&buffer); err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			writer.Write(encodeError(err))
+			return
+		}
+	}
+	writer.Header().Set("Content-Type", "text/csv")
+	writer.Write(buffer.Bytes())
+}
+
+// writeColumnar renders the series results of a select query as an Arrow IPC
+// stream or a Parquet file, for data-science tools which load them directly.
+func writeColumnar(writer http.ResponseWriter, response QueryResponse, format string) {
+	results, ok := response.Body.([]command.QueryResult)
+	if !ok {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(fmt.Errorf("%s output is only available for select queries", format)))
+		return
+	}
+	frame, err := columnar.FromResults(results)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(err))
+		return
+	}
+	var buffer bytes.Buffer
+	contentType := columnar.ArrowContentType
+	if format == "parquet" {
+		contentType = columnar.ParquetContentType
+		err = frame.WriteParquet(&buffer)
+	} else {
+		err = frame.WriteArrow(&buffer)
+	}
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(encodeError(err))
+		return
+	}
+	writer.Header().Set("Content-Type", contentType)
+	writer.Write(buffer.Bytes())
+}
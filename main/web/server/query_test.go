@@ -15,11 +15,22 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
 	"github.com/square/metrics/query/predicate"
 	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
 )
 
 func TestPredicateFromConstraint(t *testing.T) {
@@ -172,6 +183,36 @@ func TestPredicateFromConstraint(t *testing.T) {
 				},
 			},
 		},
+		{
+			constraint: Constraint{
+				KeyInCIDR: &KeyInCIDR{
+					Key:    "peer",
+					Blocks: []string{"10.0.0.0/8", "fd00::/8"},
+				},
+			},
+			result: predicate.CIDRMatcher{
+				Tag:      "peer",
+				Networks: []*net.IPNet{mustParseCIDR("10.0.0.0/8"), mustParseCIDR("fd00::/8")},
+			},
+		},
+		{
+			err: "invalid CIDR block",
+			constraint: Constraint{
+				KeyInCIDR: &KeyInCIDR{
+					Key:    "peer",
+					Blocks: []string{"10.0.0.0/33"},
+				},
+			},
+		},
+		{
+			constraint: Constraint{
+				KeyGlob: &KeyGlob{
+					Key:      "dc",
+					Patterns: []string{"us-*", "eu-?"},
+				},
+			},
+			result: mustGlobMatcher("dc", "us-*", "eu-?"),
+		},
 		{
 			err:        "zero value is not a legal Constraint",
 			constraint: Constraint{},
@@ -202,3 +243,127 @@ func TestPredicateFromConstraint(t *testing.T) {
 		a.Contextf("test %d", i).Eq(result, test.result)
 	}
 }
+
+func mustParseCIDR(block string) *net.IPNet {
+	_, network, err := net.ParseCIDR(block)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+func mustGlobMatcher(tag string, patterns ...string) predicate.GlobMatcher {
+	matcher, err := predicate.NewGlobMatcher(tag, patterns)
+	if err != nil {
+		panic(err)
+	}
+	return matcher
+}
+
+func TestQueryHandler_Directives(t *testing.T) {
+	a := assert.New(t)
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": "a"}})
+	handler := queryHandler{context: command.ExecutionContext{MetricMetadataAPI: fakeAPI, FetchLimit: 1000, Ctx: context.Background()}}
+
+	for _, test := range []struct {
+		query    string
+		expected parser.Directives
+	}{
+		{"describe cpu", nil},
+		{"-- @owner: payments @dashboard: checkout\ndescribe cpu", parser.Directives{"owner": "payments", "dashboard": "checkout"}},
+	} {
+		a := a.Contextf("%q", test.query)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/query", strings.NewReader("query="+strings.Replace(test.query, "\n", "%0A", -1)))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		a.EqInt(recorder.Code, http.StatusOK)
+		var response struct {
+			Metadata struct {
+				Directives parser.Directives `json:"directives"`
+			} `json:"metadata"`
+		}
+		a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &response))
+		a.Eq(response.Metadata.Directives, test.expected)
+	}
+}
+
+func TestQueryHandler_DebugBackend(t *testing.T) {
+	a := assert.New(t)
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": "a"}})
+	handler := queryHandler{context: command.ExecutionContext{MetricMetadataAPI: fakeAPI, FetchLimit: 1000, Ctx: context.Background()}}
+
+	for _, test := range []struct {
+		debug    string
+		status   int
+		recorded bool
+	}{
+		{"", http.StatusOK, false},
+		{"backend", http.StatusOK, true},
+		{"everything", http.StatusBadRequest, false},
+	} {
+		a := a.Contextf("debug=%q", test.debug)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/query", strings.NewReader("query=describe+cpu&debug="+test.debug))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		a.EqInt(recorder.Code, test.status)
+		var response struct {
+			Metadata map[string]json.RawMessage `json:"metadata"`
+		}
+		a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &response))
+		_, recorded := response.Metadata["backend_requests"]
+		a.Eq(recorded, test.recorded)
+	}
+}
+
+func TestQueryHandler_BackendLabels(t *testing.T) {
+	a := assert.New(t)
+	handler := queryHandler{labels: []string{"tenant", "dashboard", "alert"}}
+	a.Eq(handler.backendLabels(
+		map[string]string{"client": "grafana", "tenant": "payments"},
+		parser.Directives{"dashboard": "checkout", "owner": "payments-oncall", "tenant": "spoofed"},
+	), map[string]string{"tenant": "payments", "dashboard": "checkout"})
+	a.Eq(handler.backendLabels(nil, nil), map[string]string(nil))
+	a.Eq(queryHandler{}.backendLabels(map[string]string{"client": "grafana"}, nil), map[string]string(nil))
+}
+
+func TestQueryHandler_Columnar(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 30, 10)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4}, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+	)
+	handler := queryHandler{context: command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	}}
+	for _, test := range []struct {
+		query       string
+		format      string
+		code        int
+		contentType string
+		body        string // a part of the body
+	}{
+		{"select cpu from 0 to 30 resolution 10ms", "arrow", http.StatusOK, "application/vnd.apache.arrow.stream", "\xff\xff\xff\xff"},
+		{"select cpu from 0 to 30 resolution 10ms", "parquet", http.StatusOK, "application/vnd.apache.parquet", "PAR1"},
+		{"select summarize_table(cpu, 'mean') from 0 to 30 resolution 10ms", "parquet", http.StatusBadRequest, "", "is of type table"},
+		{"describe cpu", "arrow", http.StatusBadRequest, "", "only available for select queries"},
+	} {
+		a := a.Contextf("%s as %s", test.query, test.format)
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/query", strings.NewReader(url.Values{"query": {test.query}, "format": {test.format}}.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		a.EqInt(recorder.Code, test.code)
+		if test.contentType != "" {
+			a.EqString(recorder.Header().Get("Content-Type"), test.contentType)
+		}
+		a.EqBool(strings.Contains(recorder.Body.String(), test.body), true)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/square/metrics/tasks"
+)
+
+// maxRejections is the number of recent rejections reported at /admin/queue.
+const maxRejections = 50
+
+// Rejection is a query which was refused by a limit, such as the fetch
+// limit, or which timed out (perhaps while waiting to be admitted).
+type Rejection struct {
+	QueryRecord
+	Priority string `json:"priority"`
+	Code     string `json:"code"` // see /api/v1/errors
+}
+
+// rejectionLog keeps the most recent rejections.
+type rejectionLog struct {
+	mutex      sync.Mutex
+	rejections []Rejection
+}
+
+func (l *rejectionLog) record(rejection Rejection) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.rejections = append(l.rejections, rejection)
+	if len(l.rejections) > maxRejections {
+		l.rejections = l.rejections[len(l.rejections)-maxRejections:]
+	}
+}
+
+// recent lists the rejections, the most recent first.
+func (l *rejectionLog) recent() []Rejection {
+	result := []Rejection{}
+	if l == nil {
+		return result
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i := len(l.rejections) - 1; i >= 0; i-- {
+		result = append(result, l.rejections[i])
+	}
+	return result
+}
+
+// QueueStatus is the body served at /admin/queue.
+type QueueStatus struct {
+	Scheduler  *tasks.SchedulerSnapshot `json:"scheduler"` // null unless a scheduler is configured
+	Rejections []Rejection              `json:"rejections"`
+}
+
+// queueHandler reports the state of the scheduler at /admin/queue: the
+// queries waiting in each priority class and how long they've waited, the
+// running queries, and the queries rejected recently.
+type queueHandler struct {
+	scheduler  *tasks.Scheduler // optional
+	rejections *rejectionLog
+}
+
+func (h queueHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "GET" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	status := QueueStatus{Rejections: h.rejections.recent()}
+	if h.scheduler != nil {
+		snapshot := h.scheduler.Snapshot()
+		status.Scheduler = &snapshot
+	}
+	writeResponse(writer, "", status)
+}
@@ -0,0 +1,97 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/tasks"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestQueueHandler(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 30, 10)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, math.NaN(), 4}, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+		api.Timeseries{Values: []float64{5, 6, 7, 8}, TagSet: api.TagSet{"metric": "cpu", "host": "b"}},
+	)
+	scheduler := tasks.NewScheduler(2, false)
+	rejections := &rejectionLog{}
+	queries := queryHandler{
+		context: command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1,
+			Ctx:                  context.Background(),
+		},
+		scheduler:  scheduler,
+		rejections: rejections,
+	}
+	for _, query := range []string{
+		"select cpu where host = 'a' from 0 to 30 resolution 10ms",
+		"select cpu from 0 to 30 resolution 10ms -- @owner: payments",
+	} {
+		form := url.Values{"query": {query}}
+		request := httptest.NewRequest("POST", "/query", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		queries.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	handler := queueHandler{scheduler: scheduler, rejections: rejections}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/admin/queue", nil))
+	a.EqInt(recorder.Code, http.StatusOK)
+	var response struct {
+		Body QueueStatus `json:"body"`
+	}
+	a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &response))
+	a.EqInt(response.Body.Scheduler.Slots, 2)
+	a.EqInt(len(response.Body.Scheduler.Running), 0)
+	a.EqInt(response.Body.Scheduler.Waits["interactive"].Admitted, 2)
+	// Only the query fetching both series exceeded the fetch limit.
+	a.EqInt(len(response.Body.Rejections), 1)
+	rejection := response.Body.Rejections[0]
+	a.EqString(rejection.Code, "limit_exceeded")
+	a.EqString(rejection.Priority, "interactive")
+	a.EqString(rejection.Directives["owner"], "payments")
+
+	recorder = httptest.NewRecorder()
+	queueHandler{rejections: rejections}.ServeHTTP(recorder, httptest.NewRequest("GET", "/admin/queue", nil))
+	a.Eq(strings.Contains(recorder.Body.String(), `"scheduler": null`), true)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/admin/queue", nil))
+	a.EqInt(recorder.Code, http.StatusMethodNotAllowed)
+}
+
+func TestQueryHandler_Label(t *testing.T) {
+	a := assert.New(t)
+	label := queryHandler{}.label("select cpu from -1h to now /* @owner: payments */", "grafana")
+	a.EqString(label.Owner, "payments")
+	a.EqString(label.Client, "grafana")
+	a.EqString(queryHandler{}.label("select cpu from", "").Owner, "")
+}
@@ -0,0 +1,45 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// reloadHandler administers something which is loaded from a file, such as
+// the alias table: a GET reports it, and a POST reloads it first.
+type reloadHandler struct {
+	reload func() error
+	report func() interface{}
+}
+
+func (h reloadHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	switch request.Method {
+	case "GET":
+	case "POST":
+		if err := h.reload(); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write(encodeError(err))
+			return
+		}
+	default:
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	writeResponse(writer, "", h.report())
+}
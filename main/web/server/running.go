@@ -0,0 +1,69 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/square/metrics/tasks"
+)
+
+// runningHandler lists the queries being executed, with the IDs which
+// cancel them.
+type runningHandler struct {
+	running *tasks.RunningQueries
+}
+
+func (h runningHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "GET" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	writeRunning(writer, map[string]interface{}{"queries": h.running.List()})
+}
+
+// cancelHandler cancels the running query whose ID is given by the id
+// parameter. The query fails with the query_cancelled error.
+type cancelHandler struct {
+	running *tasks.RunningQueries
+}
+
+func (h cancelHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "POST" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	id := request.FormValue("id")
+	if id == "" {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(fmt.Errorf("the id of the query to cancel is required")))
+		return
+	}
+	if !h.running.Cancel(id) {
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write(encodeError(fmt.Errorf("no query with id %q is running", id)))
+		return
+	}
+	writeRunning(writer, map[string]interface{}{"cancelled": id})
+}
+
+func writeRunning(writer http.ResponseWriter, body interface{}) {
+	writeResponse(writer, "", body)
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/tasks"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCancelHandler(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 30, 10)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange)
+	running := tasks.NewRunningQueries()
+	queries := queryHandler{
+		context: command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		},
+		running: running,
+	}
+	// The fetch of series_timeout takes 30 seconds.
+	finished := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		form := url.Values{"query": {"select series_timeout from 0 to 30 resolution 10ms"}}
+		request := httptest.NewRequest("POST", "/query", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+		queries.ServeHTTP(recorder, request)
+		finished <- recorder
+	}()
+
+	var listed struct {
+		Body struct {
+			Queries []tasks.RunningQuery `json:"queries"`
+		} `json:"body"`
+	}
+	for deadline := time.Now().Add(5 * time.Second); len(listed.Body.Queries) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("the query was never listed")
+		}
+		time.Sleep(time.Millisecond)
+		recorder := httptest.NewRecorder()
+		runningHandler{running: running}.ServeHTTP(recorder, httptest.NewRequest("GET", "/admin/queries", nil))
+		a.EqInt(recorder.Code, http.StatusOK)
+		a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &listed))
+	}
+	query := listed.Body.Queries[0]
+	a.EqString(query.Query, "select series_timeout from 0 to 30 resolution 10ms")
+
+	cancel := func(id string) int {
+		recorder := httptest.NewRecorder()
+		cancelHandler{running: running}.ServeHTTP(recorder, httptest.NewRequest("POST", "/cancel?id="+id, nil))
+		return recorder.Code
+	}
+	a.EqInt(cancel("no-such-query"), http.StatusNotFound)
+	a.EqInt(cancel(query.ID), http.StatusOK)
+
+	select {
+	case recorder := <-finished:
+		a.EqInt(recorder.Code, http.StatusConflict)
+		a.EqString(recorder.Header().Get("X-Query-Id"), query.ID)
+		var response Response
+		a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &response))
+		a.EqString(response.Code, "query_cancelled")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the cancelled query didn't stop")
+	}
+	a.EqInt(len(running.List()), 0)
+	a.EqInt(cancel(query.ID), http.StatusNotFound)
+}
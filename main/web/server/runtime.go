@@ -0,0 +1,52 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/square/metrics/supervisor"
+)
+
+// RuntimeStatus is the body of /admin/runtime: the process, and the state of
+// each background component.
+type RuntimeStatus struct {
+	Runtime    RuntimeStats        `json:"runtime"`
+	Components []supervisor.Status `json:"components"`
+}
+
+type runtimeHandler struct {
+	supervisor *supervisor.Supervisor
+}
+
+// NewRuntimeHandler creates a handler reporting the components run by the
+// supervisor, including their failures and restarts.
+func NewRuntimeHandler(supervisor *supervisor.Supervisor) http.Handler {
+	return runtimeHandler{supervisor: supervisor}
+}
+
+func (h runtimeHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != "GET" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("unsupported method %s", request.Method)))
+		return
+	}
+	writeResponse(writer, "", RuntimeStatus{
+		Runtime:    runtimeStats(h.supervisor.Started()),
+		Components: h.supervisor.Status(),
+	})
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/supervisor"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestRuntimeHandler(t *testing.T) {
+	a := assert.New(t)
+	components := supervisor.New(supervisor.Config{InitialBackoffSeconds: 60})
+	a.CheckError(components.Add("blocking", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	a.CheckError(components.Add("failing", func(ctx context.Context) error {
+		return errors.New("unreachable")
+	}))
+	components.Start(context.Background())
+	defer components.Stop(context.Background())
+
+	handler := NewRuntimeHandler(components)
+	var status RuntimeStatus
+	deadline := time.Now().Add(5 * time.Second)
+	for len(status.Components) != 2 || status.Components[1].State != supervisor.StateBackoff {
+		if time.Now().After(deadline) {
+			t.Fatalf("the failing component was not backed off: %+v", status.Components)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/admin/runtime", nil))
+		a.EqInt(recorder.Code, http.StatusOK)
+		var response struct {
+			Body RuntimeStatus `json:"body"`
+		}
+		a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &response))
+		status = response.Body
+	}
+	a.EqString(status.Components[0].Name, "blocking")
+	a.EqString(status.Components[0].State, supervisor.StateRunning)
+	a.EqString(status.Components[1].LastError, "unreachable")
+	a.EqBool(status.Runtime.Goroutines > 0, true)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/admin/runtime", nil))
+	a.EqInt(recorder.Code, http.StatusMethodNotAllowed)
+}
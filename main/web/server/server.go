@@ -15,24 +15,162 @@
 package server
 
 import (
+	netcontext "context"
 	"fmt"
+	"io/fs"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/interop/graphite"
+	"github.com/square/metrics/interop/prometheus"
+	"github.com/square/metrics/main/web/static"
 	"github.com/square/metrics/metric_metadata"
 	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/natural_sort"
+	"github.com/square/metrics/tasks"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/webhook"
 )
 
 func NewMux(config Config, context command.ExecutionContext, hook Hook) (*http.ServeMux, error) {
 	// Wrap the given API and Backend in their Profiling counterparts.
+	// The UI is embedded in the binary, unless a directory is configured to replace it.
+	var files fs.FS = static.Files
+	if config.StaticDir != "" {
+		files = os.DirFS(config.StaticDir)
+	}
+	assets, err := newStaticAssets(files)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load static files: %s", err.Error())
+	}
+	clients, err := newClientProfiles(config.Clients)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEncoders(hook.Encoders); err != nil {
+		return nil, err
+	}
+	if err := command.ValidateTrailingBucket(config.TrailingBucket); err != nil {
+		return nil, err
+	}
+	if config.TrailingBucket != "" {
+		context.TrailingBucket = config.TrailingBucket
+	}
+	if _, err := natural_sort.Lookup(config.Collation); err != nil {
+		return nil, err
+	}
+	if config.Collation != "" {
+		context.Collation = config.Collation
+	}
+	if config.QueryTimeout != 0 {
+		context.Timeout = time.Duration(config.QueryTimeout) * time.Second
+	}
+	if config.MemoryLimit != 0 {
+		context.MemoryLimit = config.MemoryLimit
+	}
+	if config.GoroutineLimit != 0 {
+		context.GoroutineLimit = config.GoroutineLimit
+	}
+	if len(config.RangeRules) != 0 {
+		rules, err := newRangeRules(config.RangeRules)
+		if err != nil {
+			return nil, err
+		}
+		context.RangeRules = rules
+	}
+	context.PartialResults = context.PartialResults || config.PartialResults
+	context.CoarserRetry = context.CoarserRetry || config.CoarserRetry
+	if cache := newResultCache(config.ResultCache); cache != nil {
+		context.ResultCache = cache
+	}
+	archiver, err := newArchiver(config.Archive)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := webhook.NewDispatcher(config.Webhooks)
+	if err != nil {
+		return nil, err
+	}
+	if webhooks != nil {
+		for backend, pool := range hook.Pools {
+			pool.OnEject(breakerWebhook(webhooks, backend))
+		}
+	}
+	base := context.Registry
+	if base == nil {
+		base = registry.Default()
+	}
+	tenants, err := newTenants(config.Tenants, base)
+	if err != nil {
+		return nil, err
+	}
+	queryHistory, err := newHistory(config.History)
+	if err != nil {
+		return nil, err
+	}
+	for _, client := range config.Clients {
+		if client.Tenant != "" && tenants == nil {
+			return nil, fmt.Errorf("client profile %q has a tenant, but tenants aren't enabled", client.Name)
+		}
+	}
 	httpMux := http.NewServeMux()
 	httpMux.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
 		http.Redirect(writer, request, "/ui", http.StatusTemporaryRedirect)
 	})
-	httpMux.Handle("/ui", singleStaticHandler{config.StaticDir, "index.html"})
-	httpMux.Handle("/embed", singleStaticHandler{config.StaticDir, "embed.html"})
-	httpMux.Handle("/query", queryHandler{
+	httpMux.Handle("/ui", assets.page("index.html"))
+	httpMux.Handle("/ui/", assets.page("index.html"))
+	httpMux.Handle("/embed", assets.page("embed.html"))
+	describes := newDescribeCache(config.DescribeCache)
+	support := newSupportRecorder(config.Support)
+	scheduler := newScheduler(config.Scheduler)
+	rejections := &rejectionLog{}
+	running := tasks.NewRunningQueries()
+	query := queryHandler{
+		context:    context,
+		hook:       hook,
+		clients:    clients,
+		scheduler:  scheduler,
+		running:    running,
+		rejections: rejections,
+		archiver:   archiver,
+		webhooks:   webhooks,
+		tenants:    tenants,
+		describes:  describes,
+		labels:     config.BackendLabels,
+		support:    support,
+		history:    queryHistory,
+	}
+	httpMux.Handle("/query", query)
+	httpMux.Handle("/admin/queue", queueHandler{
+		scheduler:  scheduler,
+		rejections: rejections,
+	})
+	httpMux.Handle("/admin/queries", runningHandler{running: running})
+	httpMux.Handle("/cancel", cancelHandler{running: running})
+	httpMux.Handle("/api/v1/errors", errorsHandler{})
+	httpMux.Handle("/api/v1/read", prometheus.NewReadHandler(context))
+	if hook.Graphite != nil {
+		httpMux.Handle("/render", graphite.NewRenderHandler(context, *hook.Graphite))
+	}
+	httpMux.Handle("/query/compare-baseline", compareHandler{
+		context: context,
+		clients: clients,
+	})
+	httpMux.Handle("/query/drill-down", drillDownHandler{
+		context: context,
+	})
+	httpMux.Handle("/analyze/anomaly", anomalyHandler{
+		context: context,
+		clients: clients,
+	})
+	httpMux.Handle("/analyze/backtest", backtestHandler{
+		context: context,
+		clients: clients,
+	})
+	httpMux.Handle("/validate/dashboard", dashboardHandler{
 		context: context,
-		hook:    hook,
 	})
 	httpMux.Handle("/token", tokenHandler{
 		context: context,
@@ -46,12 +184,59 @@ func NewMux(config Config, context command.ExecutionContext, hook Hook) (*http.S
 			return nil, fmt.Errorf("HTTP Ingestion is on, but the metadata API does not implement updates")
 		}
 	}
-	httpMux.Handle(
-		"/static/",
-		http.StripPrefix(
-			"/static/",
-			http.FileServer(http.Dir(config.StaticDir)),
-		),
-	)
+	if config.HTTPIngestion {
+		if storage, ok := context.TimeseriesStorageAPI.(timeseries.SketchStorageAPI); ok {
+			updateAPI, _ := context.MetricMetadataAPI.(metadata.MetricUpdateAPI)
+			httpMux.Handle("/ingest/sketch", sketchIngestHandler{
+				storage:           storage,
+				metricMetadataAPI: updateAPI,
+			})
+		}
+	}
+	if archiver != nil {
+		httpMux.Handle("/archive", archiveHandler{archiver: archiver, clients: clients})
+		httpMux.Handle("/archive/", archiveHandler{archiver: archiver, clients: clients})
+	}
+	if tenants != nil {
+		httpMux.Handle("/admin/tenants/", tenantHandler{tenants: tenants, clients: clients})
+	}
+	if signer := newShareSigner(config.Share); signer != nil {
+		httpMux.Handle("/share", shareHandler{signer: signer, query: query, archiver: archiver})
+		httpMux.Handle("/shared", sharedHandler{signer: signer, query: query, archiver: archiver})
+	}
+	if queryHistory != nil {
+		httpMux.Handle("/history", historyHandler{history: queryHistory, clients: clients, query: query})
+		httpMux.Handle("/history/", historyHandler{history: queryHistory, clients: clients, query: query})
+	}
+	if support != nil {
+		if hook.Supervisor != nil {
+			if err := hook.Supervisor.Add("support-health-monitor", support.monitor(context)); err != nil {
+				return nil, err
+			}
+		} else {
+			go support.monitor(context)(netcontext.Background())
+		}
+		httpMux.Handle("/admin/support-bundle", supportHandler{
+			recorder:  support,
+			config:    config,
+			clients:   clients,
+			context:   context,
+			describes: describes,
+			caches:    hook.CacheStats,
+		})
+	}
+	httpMux.Handle("/static/", assets)
 	return httpMux, nil
 }
+
+// breakerWebhook fires backend_breaker_open webhooks when an endpoint is
+// ejected from the backend's pool.
+func breakerWebhook(webhooks *webhook.Dispatcher, backend string) func(url string, failures int) {
+	return func(url string, failures int) {
+		webhooks.Fire(webhook.Event{
+			Type:    webhook.BackendBreakerOpen,
+			Message: fmt.Sprintf("%s was ejected from the %s pool after %d consecutive failures", url, backend, failures),
+			Details: map[string]interface{}{"backend": backend, "endpoint": url, "failures": failures},
+		})
+	}
+}
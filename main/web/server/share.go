@@ -0,0 +1,259 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/square/metrics/archive"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/inspect"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+)
+
+// ShareConfig lets graphs be shared through links, made at /share, which
+// anyone may open until they expire. The links are signed, so they can't be
+// altered to run other queries.
+type ShareConfig struct {
+	SigningKey    string `yaml:"signing_key"`     // signs the links; if empty, links can't be made
+	MaxAgeSeconds int    `yaml:"max_age_seconds"` // the longest a link may last; 30 days by default
+}
+
+// shareLink is what a link carries. A link with a snapshot shows the
+// archived result with that ID, rather than running the query again.
+type shareLink struct {
+	Query    string `json:"q"`
+	Expires  int64  `json:"e"` // seconds since the epoch
+	Snapshot string `json:"s,omitempty"`
+}
+
+// errLinkExpired is returned for a link which was valid, but has expired.
+var errLinkExpired = errors.New("the link has expired")
+
+// shareSigner makes and checks the tokens of links: their content, then an
+// HMAC-SHA256 of it, both base64-encoded.
+type shareSigner struct {
+	secret []byte
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// newShareSigner returns nil if links can't be shared.
+func newShareSigner(config ShareConfig) *shareSigner {
+	if config.SigningKey == "" {
+		return nil
+	}
+	maxAge := 30 * 24 * time.Hour
+	if config.MaxAgeSeconds > 0 {
+		maxAge = time.Duration(config.MaxAgeSeconds) * time.Second
+	}
+	return &shareSigner{secret: []byte(config.SigningKey), maxAge: maxAge, now: time.Now}
+}
+
+func (s *shareSigner) mac(content string) string {
+	hash := hmac.New(sha256.New, s.secret)
+	hash.Write([]byte(content))
+	return base64.RawURLEncoding.EncodeToString(hash.Sum(nil))
+}
+
+func (s *shareSigner) sign(link shareLink) (string, error) {
+	encoded, err := json.Marshal(link)
+	if err != nil {
+		return "", err
+	}
+	content := base64.RawURLEncoding.EncodeToString(encoded)
+	return content + "." + s.mac(content), nil
+}
+
+func (s *shareSigner) verify(token string) (shareLink, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(s.mac(parts[0]))) {
+		return shareLink{}, fmt.Errorf("the link is invalid")
+	}
+	encoded, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return shareLink{}, fmt.Errorf("the link is invalid")
+	}
+	var link shareLink
+	if err := json.Unmarshal(encoded, &link); err != nil {
+		return shareLink{}, fmt.Errorf("the link is invalid")
+	}
+	if s.now().Unix() >= link.Expires {
+		return shareLink{}, errLinkExpired
+	}
+	return link, nil
+}
+
+// ShareForm is the request of /share.
+type ShareForm struct {
+	Input    string `query:"query" json:"query"`       // a select command
+	Expires  string `query:"expires" json:"expires"`   // how long the link lasts, such as 2w; 1d by default
+	Snapshot bool   `query:"snapshot" json:"snapshot"` // if true, the link shows the result as it is now, archived, rather than running the query again
+}
+
+// SharedLink is the response of /share.
+type SharedLink struct {
+	Token   string    `json:"token"`
+	URL     string    `json:"url"` // the embedded graph
+	Expires time.Time `json:"expires"`
+}
+
+// shareHandler makes links to the graph of a select. Relative times in the
+// query (such as "from -1h") are relative to when the link is opened,
+// unless it's a snapshot.
+type shareHandler struct {
+	signer   *shareSigner
+	query    queryHandler
+	archiver *archive.Archiver // optional; without one, snapshots can't be shared
+}
+
+func (h shareHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	fail := func(status int, err error) {
+		writer.WriteHeader(status)
+		writer.Write(encodeError(err))
+	}
+	if request.Method != "POST" {
+		fail(http.StatusMethodNotAllowed, fmt.Errorf("unsupported method %s", request.Method))
+		return
+	}
+	if err := request.ParseForm(); err != nil {
+		fail(http.StatusBadRequest, err)
+		return
+	}
+	form := ShareForm{}
+	parseStruct(request.Form, &form)
+	if form.Expires == "" {
+		form.Expires = "1d"
+	}
+	duration, err := function.StringToDuration(form.Expires)
+	if err != nil {
+		fail(http.StatusBadRequest, err)
+		return
+	}
+	if duration <= 0 || duration > h.signer.maxAge {
+		fail(http.StatusBadRequest, fmt.Errorf("a link must expire within %s, not %s", h.signer.maxAge, form.Expires))
+		return
+	}
+	parsed, err := parser.Parse(form.Input)
+	if err != nil {
+		fail(http.StatusBadRequest, err)
+		return
+	}
+	if _, ok := parsed.(*command.SelectCommand); !ok {
+		fail(http.StatusBadRequest, fmt.Errorf("only a select can be shared, not a %s", parsed.Name()))
+		return
+	}
+	expires := h.signer.now().Add(duration)
+	link := shareLink{Query: form.Input, Expires: expires.Unix()}
+	if form.Snapshot {
+		if h.archiver == nil {
+			fail(http.StatusBadRequest, fmt.Errorf("snapshots cannot be shared, since no archive is configured"))
+			return
+		}
+		if _, ok := h.query.clients.matchToken(request); !ok {
+			fail(http.StatusUnauthorized, errArchiveToken)
+			return
+		}
+		context := h.query.context
+		if client, ok := h.query.clients.match(request); ok {
+			context = client.Apply(context)
+		}
+		response, _, err := h.query.process(context, inspect.New(), QueryForm{Input: form.Input})
+		if err != nil {
+			fail(errorStatus(err), err)
+			return
+		}
+		entry, err := h.archiver.Archive("shared snapshot", form.Input, Response{Success: true, QueryResponse: response})
+		if err != nil {
+			fail(http.StatusInternalServerError, err)
+			return
+		}
+		link.Snapshot = entry.ID
+	}
+	token, err := h.signer.sign(link)
+	if err != nil {
+		fail(http.StatusInternalServerError, err)
+		return
+	}
+	writeResponse(writer, "share", SharedLink{
+		Token:   token,
+		URL:     "/embed?" + url.Values{"share": {token}}.Encode(),
+		Expires: time.Unix(link.Expires, 0).UTC(),
+	})
+}
+
+// sharedHandler serves the result of a link's query, as /query would,
+// without any credentials: the link's signature is its authorization. The
+// query runs with the server's default limits.
+type sharedHandler struct {
+	signer   *shareSigner
+	query    http.Handler
+	archiver *archive.Archiver // optional
+}
+
+func (h sharedHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	fail := func(status int, err error) {
+		writer.WriteHeader(status)
+		writer.Write(encodeError(err))
+	}
+	if request.Method != "GET" {
+		fail(http.StatusMethodNotAllowed, fmt.Errorf("unsupported method %s", request.Method))
+		return
+	}
+	link, err := h.signer.verify(request.FormValue("token"))
+	if err == errLinkExpired {
+		fail(http.StatusGone, err)
+		return
+	}
+	if err != nil {
+		fail(http.StatusForbidden, err)
+		return
+	}
+	if link.Snapshot != "" {
+		if h.archiver == nil {
+			fail(http.StatusNotFound, fmt.Errorf("the snapshot is no longer available"))
+			return
+		}
+		_, data, err := h.archiver.Get(link.Snapshot)
+		if err == archive.ErrNotFound {
+			fail(http.StatusNotFound, fmt.Errorf("the snapshot is no longer available"))
+			return
+		}
+		if err != nil {
+			fail(http.StatusInternalServerError, err)
+			return
+		}
+		writer.Write(data)
+		return
+	}
+	run, err := http.NewRequest("GET", "/query?"+url.Values{"query": {link.Query}}.Encode(), nil)
+	if err != nil {
+		fail(http.StatusInternalServerError, err)
+		return
+	}
+	h.query.ServeHTTP(writer, run.WithContext(request.Context()))
+}
@@ -0,0 +1,114 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/archive"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestShareHandler(t *testing.T) {
+	a := assert.New(t)
+	directory, err := ioutil.TempDir("", "share")
+	a.CheckError(err)
+	defer os.RemoveAll(directory)
+	store := memory.NewStore(time.Minute)
+	store.AddGenerated(api.TaggedMetric{MetricKey: "requests", TagSet: api.TagSet{"host": "a"}}, func(t time.Time) float64 {
+		return float64(t.Minute())
+	})
+	clients, err := newClientProfiles([]ClientProfile{{Name: "dashboards", Tokens: []string{"dashboards-token"}}})
+	a.CheckError(err)
+	queries := queryHandler{
+		context: command.ExecutionContext{TimeseriesStorageAPI: store, MetricMetadataAPI: store, FetchLimit: 1000, Ctx: context.Background()},
+		clients: clients,
+	}
+	now := time.Unix(1000, 0)
+	signer := newShareSigner(ShareConfig{SigningKey: "secret", MaxAgeSeconds: 7 * 24 * 3600})
+	signer.now = func() time.Time { return now }
+	archiver := archive.NewArchiver(archive.DirectoryStore{Directory: directory})
+	share := shareHandler{signer: signer, query: queries, archiver: archiver}
+	shared := sharedHandler{signer: signer, query: queries, archiver: archiver}
+
+	serve := func(handler http.Handler, method string, path string, body string) (int, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Authorization", "Bearer dashboards-token")
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+	makeLink := func(form url.Values) string {
+		code, body := serve(share, "POST", "/share", form.Encode())
+		a.EqInt(code, http.StatusOK)
+		var response struct {
+			Body SharedLink `json:"body"`
+		}
+		a.CheckError(json.Unmarshal([]byte(body), &response))
+		a.Eq(response.Body.URL, "/embed?share="+response.Body.Token)
+		return response.Body.Token
+	}
+	query := "select requests from 0 to 600000"
+
+	token := makeLink(url.Values{"query": {query}})
+	code, body := serve(shared, "GET", "/shared?token="+token, "")
+	a.EqInt(code, http.StatusOK)
+	a.EqBool(strings.Contains(body, `"host":"a"`), true)
+
+	// Links can't be altered, and stop working once they expire.
+	code, _ = serve(shared, "GET", "/shared?token=x"+token, "")
+	a.EqInt(code, http.StatusForbidden)
+	other, err := (&shareSigner{secret: []byte("other"), now: signer.now}).sign(shareLink{Query: "select other from 0 to 1", Expires: 2000})
+	a.CheckError(err)
+	code, _ = serve(shared, "GET", "/shared?token="+other, "")
+	a.EqInt(code, http.StatusForbidden)
+	now = now.Add(25 * time.Hour)
+	code, _ = serve(shared, "GET", "/shared?token="+token, "")
+	a.EqInt(code, http.StatusGone)
+
+	// A snapshot is served from the archive, even once the data is gone.
+	snapshot := makeLink(url.Values{"query": {query}, "snapshot": {"true"}, "expires": {"1w"}})
+	empty := memory.NewStore(time.Minute)
+	shared.query = queryHandler{
+		context: command.ExecutionContext{TimeseriesStorageAPI: empty, MetricMetadataAPI: empty, FetchLimit: 1000, Ctx: context.Background()},
+	}
+	code, body = serve(shared, "GET", "/shared?token="+snapshot, "")
+	a.EqInt(code, http.StatusOK)
+	a.EqBool(strings.Contains(body, `"host":"a"`), true)
+
+	for _, form := range []url.Values{
+		{"query": {query}, "expires": {"2w"}},
+		{"query": {"describe all"}},
+		{"query": {"select"}},
+	} {
+		code, _ = serve(share, "POST", "/share", form.Encode())
+		a.EqInt(code, http.StatusBadRequest)
+	}
+	code, _ = serve(share, "GET", "/share?query=x", "")
+	a.EqInt(code, http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// staticAssets serves the files of the UI. Every file is also available under
+// a name which includes a hash of its contents, and is served under that name
+// with headers allowing it to be cached indefinitely. The HTML pages refer to
+// the hashed names, so browsers pick up new versions as soon as they are
+// deployed. Only files found when the assets are loaded are ever served.
+type staticAssets struct {
+	files  fs.FS
+	hashes map[string]string // file name => hash of its contents
+	hashed map[string]string // hashed name => file name
+	pages  map[string][]byte // HTML pages, rewritten to refer to hashed names
+}
+
+// hashedName inserts the hash before the extension of the file name.
+func hashedName(name string, hash string) string {
+	extension := path.Ext(name)
+	return strings.TrimSuffix(name, extension) + "." + hash + extension
+}
+
+// newStaticAssets loads the files of the UI. Go source files are ignored.
+func newStaticAssets(files fs.FS) (*staticAssets, error) {
+	assets := &staticAssets{
+		files:  files,
+		hashes: map[string]string{},
+		hashed: map[string]string{},
+		pages:  map[string][]byte{},
+	}
+	err := fs.WalkDir(files, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Ext(name) == ".go" {
+			return err
+		}
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return err
+		}
+		if path.Ext(name) == ".html" {
+			// Pages are hashed once they're rewritten.
+			assets.pages[name] = content
+			return nil
+		}
+		assets.add(name, content)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for page, content := range assets.pages {
+		for name, hash := range assets.hashes {
+			if path.Ext(name) == ".html" {
+				continue
+			}
+			content = bytes.Replace(content, []byte(`"/static/`+name+`"`), []byte(`"/static/`+hashedName(name, hash)+`"`), -1)
+		}
+		assets.pages[page] = content
+	}
+	// A page's hash covers the hashed names it refers to, so that it changes
+	// whenever any of the files it loads does.
+	for page, content := range assets.pages {
+		assets.add(page, content)
+	}
+	return assets, nil
+}
+
+// add records the hash of the file's contents, as served.
+func (assets *staticAssets) add(name string, content []byte) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])[:12]
+	assets.hashes[name] = hash
+	assets.hashed[hashedName(name, hash)] = name
+}
+
+// serveFile writes the named file. Files requested by their hashed name are
+// immutable; others must be revalidated using their hash as the ETag.
+func (assets *staticAssets) serveFile(writer http.ResponseWriter, request *http.Request, name string, immutable bool) {
+	content, ok := assets.pages[name]
+	if !ok {
+		var err error
+		content, err = fs.ReadFile(assets.files, name)
+		if err != nil {
+			http.Error(writer, "failed to read static file", http.StatusInternalServerError)
+			return
+		}
+	}
+	if immutable {
+		writer.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		writer.Header().Set("Cache-Control", "no-cache")
+		writer.Header().Set("ETag", `"`+assets.hashes[name]+`"`)
+	}
+	http.ServeContent(writer, request, name, time.Time{}, bytes.NewReader(content))
+}
+
+// ServeHTTP serves the files under /static/.
+func (assets *staticAssets) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(request.URL.Path, "/static/")), "/")
+	if original, ok := assets.hashed[name]; ok {
+		assets.serveFile(writer, request, original, true)
+		return
+	}
+	if _, ok := assets.hashes[name]; ok {
+		assets.serveFile(writer, request, name, false)
+		return
+	}
+	http.NotFound(writer, request)
+}
+
+// page creates a handler which always serves the given HTML page, so that
+// every route of the single-page UI loads the UI.
+func (assets *staticAssets) page(name string) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if _, ok := assets.pages[name]; !ok {
+			http.NotFound(writer, request)
+			return
+		}
+		assets.serveFile(writer, request, name, false)
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/square/metrics/main/web/static"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestStaticAssets(t *testing.T) {
+	a := assert.New(t)
+	assets, err := newStaticAssets(fstest.MapFS{
+		"index.html":      {Data: []byte(`<script src="/static/script.js"></script><link href="/static/style.css">`)},
+		"script.js":       {Data: []byte(`console.log("hi");`)},
+		"style.css":       {Data: []byte(`body {}`)},
+		"js/lib.js":       {Data: []byte(`var lib;`)},
+		"dummy.go":        {Data: []byte(`package static`)},
+		"style_embed.css": {Data: []byte(`div {}`)},
+	})
+	a.CheckError(err)
+
+	scriptName := hashedName("script.js", assets.hashes["script.js"])
+	page := string(assets.pages["index.html"])
+	a.EqBool(strings.Contains(page, `"/static/`+scriptName+`"`), true)
+	a.EqBool(strings.Contains(page, `"/static/`+hashedName("style.css", assets.hashes["style.css"])+`"`), true)
+
+	get := func(handler http.Handler, url string, header map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", url, nil)
+		for key, value := range header {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	response := get(assets, "/static/"+scriptName, nil)
+	a.EqInt(response.Code, http.StatusOK)
+	a.EqString(response.Body.String(), `console.log("hi");`)
+	a.EqString(response.Header().Get("Cache-Control"), "public, max-age=31536000, immutable")
+
+	response = get(assets, "/static/js/lib.js", nil)
+	a.EqInt(response.Code, http.StatusOK)
+	a.EqString(response.Header().Get("Cache-Control"), "no-cache")
+	etag := response.Header().Get("ETag")
+	a.EqInt(get(assets, "/static/js/lib.js", map[string]string{"If-None-Match": etag}).Code, http.StatusNotModified)
+
+	for _, url := range []string{"/static/dummy.go", "/static/../server.go", "/static/js/../../static.go", "/static/missing.js", "/static/"} {
+		a.Contextf("%s", url).EqInt(get(assets, url, nil).Code, http.StatusNotFound)
+	}
+
+	for _, url := range []string{"/ui", "/ui/some/route"} {
+		response = get(assets.page("index.html"), url, nil)
+		a.Contextf("%s", url).EqInt(response.Code, http.StatusOK)
+		a.Contextf("%s", url).EqString(response.Body.String(), page)
+		a.Contextf("%s", url).EqString(response.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	}
+}
+
+func TestStaticAssets_PageHash(t *testing.T) {
+	a := assert.New(t)
+	load := func(script string) *staticAssets {
+		assets, err := newStaticAssets(fstest.MapFS{
+			"index.html": {Data: []byte(`<script src="/static/script.js"></script>`)},
+			"script.js":  {Data: []byte(script)},
+		})
+		a.CheckError(err)
+		return assets
+	}
+	before := load(`console.log("hi");`)
+	after := load(`console.log("bye");`)
+	// The page's source is the same, but it refers to a new script.
+	a.EqBool(before.hashes["index.html"] == after.hashes["index.html"], false)
+
+	request := httptest.NewRequest("GET", "/ui", nil)
+	request.Header.Set("If-None-Match", `"`+before.hashes["index.html"]+`"`)
+	recorder := httptest.NewRecorder()
+	after.page("index.html").ServeHTTP(recorder, request)
+	a.EqInt(recorder.Code, http.StatusOK)
+	a.EqString(recorder.Body.String(), string(after.pages["index.html"]))
+}
+
+func TestStaticAssets_Embedded(t *testing.T) {
+	a := assert.New(t)
+	assets, err := newStaticAssets(static.Files)
+	a.CheckError(err)
+	for _, name := range []string{"index.html", "embed.html", "script.js", "js/underscore-min.js"} {
+		_, ok := assets.hashes[name]
+		a.Contextf("%s", name).EqBool(ok, true)
+	}
+	a.EqBool(strings.Contains(string(assets.pages["index.html"]), `"/static/script.js"`), false)
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/log"
+	"github.com/square/metrics/query/command"
+)
+
+// streamedResultHeader holds the fields of a series result which precede its
+// series, when it's streamed.
+type streamedResultHeader struct {
+	Query     string              `json:"query"`
+	Name      string              `json:"name"`
+	Type      string              `json:"type"`
+	Timerange api.Timerange       `json:"timerange"`
+	Format    *command.FormatHint `json:"format,omitempty"`
+}
+
+// writeStreamed writes the response as it's encoded, instead of marshaling
+// all of it first, so the memory used for a select of thousands of series
+// isn't doubled. The series of each result are encoded one at a time, and
+// each result is flushed to the client once written. Nothing is written
+// until the whole select has been evaluated, since its results are ordered,
+// limited and checked together; streaming saves memory, not time to the first
+// byte. The JSON is equivalent to that of an ordinary response. Once anything
+// has been written, failures can only be logged: the client sees the JSON cut
+// short.
+func writeStreamed(writer http.ResponseWriter, response Response) {
+	results, ok := response.Body.([]command.QueryResult)
+	if !ok {
+		// Only selects have results large enough to be worth streaming.
+		encoded, err := json.Marshal(response)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write(encodeError(err))
+			return
+		}
+		writer.Write(encoded)
+		return
+	}
+	stream := newJSONStream(writer)
+	stream.raw(`{"success":true,"name":`)
+	stream.encode(response.Name)
+	stream.raw(`,"body":[`)
+	for i, result := range results {
+		if i != 0 {
+			stream.raw(",")
+		}
+		if result.Type != "series" {
+			stream.encode(result)
+			continue
+		}
+		header, err := json.Marshal(streamedResultHeader{Query: result.Query, Name: result.Name, Type: result.Type, Timerange: result.Timerange, Format: result.Format})
+		if err != nil {
+			stream.fail(err)
+			break
+		}
+		stream.raw(string(header[:len(header)-1]) + `,"series":[`)
+		for j, series := range result.Series {
+			if j != 0 {
+				stream.raw(",")
+			}
+			stream.encode(series)
+		}
+		stream.raw("]}")
+		stream.flush()
+	}
+	stream.raw("]")
+	if response.Metadata != nil {
+		stream.raw(`,"metadata":`)
+		stream.encode(response.Metadata)
+	}
+	if response.Profile != nil {
+		stream.raw(`,"profile":`)
+		stream.encode(response.Profile)
+	}
+	stream.raw("}")
+	if stream.err != nil {
+		log.Errorf("Error streaming a query response: %s", stream.err.Error())
+	}
+}
+
+// jsonStream writes JSON piece by piece, stopping at the first error. Each
+// encoded value is followed by a newline, so values encoded one after another
+// are also JSON Lines.
+type jsonStream struct {
+	writer  io.Writer
+	encoder *json.Encoder
+	flusher http.Flusher // nil if the writer can't flush
+	err     error
+}
+
+func newJSONStream(writer http.ResponseWriter) *jsonStream {
+	flusher, _ := writer.(http.Flusher)
+	return &jsonStream{writer: writer, encoder: json.NewEncoder(writer), flusher: flusher}
+}
+
+func (stream *jsonStream) raw(text string) {
+	if stream.err == nil {
+		_, stream.err = io.WriteString(stream.writer, text)
+	}
+}
+
+func (stream *jsonStream) encode(value interface{}) {
+	if stream.err == nil {
+		stream.err = stream.encoder.Encode(value)
+	}
+}
+
+// flush sends what has been written to the client.
+func (stream *jsonStream) flush() {
+	if stream.flusher != nil && stream.err == nil {
+		stream.flusher.Flush()
+	}
+}
+
+func (stream *jsonStream) fail(err error) {
+	if stream.err == nil {
+		stream.err = err
+	}
+}
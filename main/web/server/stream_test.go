@@ -0,0 +1,79 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestQueryHandler_Stream(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 30, 10)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, math.NaN(), 4}, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+		api.Timeseries{Values: []float64{5, 6, 7, 8}, TagSet: api.TagSet{"metric": "cpu", "host": "b"}},
+	)
+	handler := queryHandler{context: command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	}}
+	serve := func(query string, stream bool) (*httptest.ResponseRecorder, interface{}) {
+		form := url.Values{"query": {query}}
+		if stream {
+			form.Set("stream", "true")
+		}
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/query", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(recorder, request)
+		var decoded interface{}
+		a.CheckError(json.Unmarshal(recorder.Body.Bytes(), &decoded))
+		return recorder, decoded
+	}
+
+	for _, query := range []string{
+		"select cpu, cpu | aggregate.sum, summarize_table(cpu, 'max') from 0 to 30 resolution 10ms",
+		"select cpu where host = 'none' from 0 to 30 resolution 10ms",
+		"describe cpu",
+	} {
+		a := a.Contextf("%s", query)
+		recorder, streamed := serve(query, true)
+		a.EqInt(recorder.Code, http.StatusOK)
+		_, ordinary := serve(query, false)
+		// The profile records when the query ran.
+		for _, response := range []interface{}{streamed, ordinary} {
+			delete(response.(map[string]interface{})["metadata"].(map[string]interface{}), "profile")
+		}
+		a.Eq(streamed, ordinary)
+		if strings.HasPrefix(query, "select cpu,") {
+			a.EqBool(recorder.Flushed, true)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	netcontext "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/inspect"
+	"github.com/square/metrics/log"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/supervisor"
+)
+
+// SupportConfig enables /admin/support-bundle, which packages what's needed
+// to diagnose the server into a tarball that can be attached to an issue.
+type SupportConfig struct {
+	Enabled            bool    `yaml:"enabled"`
+	QueryLogSize       int     `yaml:"query_log_size"`       // how many recent queries are kept; defaults to 200
+	SlowQuerySeconds   float64 `yaml:"slow_query_seconds"`   // queries taking longer keep their profiles; defaults to 5
+	SlowQueryProfiles  int     `yaml:"slow_query_profiles"`  // how many profiles of slow queries are kept; defaults to 20
+	HealthCheckSeconds int     `yaml:"health_check_seconds"` // how often the backends' health is recorded; defaults to 30
+}
+
+// QueryRecord describes a query in the support bundle.
+type QueryRecord struct {
+	Time       time.Time         `json:"time"`
+	Query      string            `json:"query"`
+	Client     string            `json:"client,omitempty"`
+	Directives parser.Directives `json:"directives,omitempty"`
+	Seconds    float64           `json:"seconds"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// SlowQuery is a query which took too long, with its profile.
+type SlowQuery struct {
+	QueryRecord
+	Profile []inspect.Profile `json:"profile"`
+}
+
+// HealthRecord is the outcome of consecutive health checks of the backends
+// which all failed in the same way (or all succeeded).
+type HealthRecord struct {
+	Since  time.Time         `json:"since"`
+	Until  time.Time         `json:"until"`
+	Checks map[string]string `json:"checks,omitempty"` // the failed checks, with their errors
+}
+
+// supportRecorder keeps the recent history included in support bundles.
+type supportRecorder struct {
+	config  SupportConfig
+	started time.Time
+
+	mutex   sync.Mutex
+	queries []QueryRecord
+	slow    []SlowQuery
+	health  []HealthRecord
+}
+
+// maxHealthRecords is the number of changes in health which are kept.
+const maxHealthRecords = 100
+
+// newSupportRecorder returns nil if support bundles aren't enabled.
+func newSupportRecorder(config SupportConfig) *supportRecorder {
+	if !config.Enabled {
+		return nil
+	}
+	if config.QueryLogSize <= 0 {
+		config.QueryLogSize = 200
+	}
+	if config.SlowQuerySeconds <= 0 {
+		config.SlowQuerySeconds = 5
+	}
+	if config.SlowQueryProfiles <= 0 {
+		config.SlowQueryProfiles = 20
+	}
+	if config.HealthCheckSeconds <= 0 {
+		config.HealthCheckSeconds = 30
+	}
+	return &supportRecorder{config: config, started: time.Now()}
+}
+
+// recordQuery adds the query to the log, keeping its profile if it was slow.
+// It does nothing if the recorder is nil.
+func (r *supportRecorder) recordQuery(record QueryRecord, profiler *inspect.Profiler) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.queries = append(r.queries, record)
+	if len(r.queries) > r.config.QueryLogSize {
+		r.queries = r.queries[len(r.queries)-r.config.QueryLogSize:]
+	}
+	if record.Seconds < r.config.SlowQuerySeconds {
+		return
+	}
+	r.slow = append(r.slow, SlowQuery{QueryRecord: record, Profile: profiler.All()})
+	if len(r.slow) > r.config.SlowQueryProfiles {
+		r.slow = r.slow[len(r.slow)-r.config.SlowQueryProfiles:]
+	}
+}
+
+// checkHealth records the health of the backends.
+func (r *supportRecorder) checkHealth(context command.ExecutionContext, now time.Time) {
+	checks := map[string]string{}
+	if context.TimeseriesStorageAPI != nil {
+		if err := context.TimeseriesStorageAPI.CheckHealthy(); err != nil {
+			checks["storage"] = err.Error()
+		}
+	}
+	if context.MetricMetadataAPI != nil {
+		if err := context.MetricMetadataAPI.CheckHealthy(); err != nil {
+			checks["metadata"] = err.Error()
+		}
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if last := len(r.health) - 1; last >= 0 && sameChecks(r.health[last].Checks, checks) {
+		r.health[last].Until = now
+		return
+	}
+	r.health = append(r.health, HealthRecord{Since: now, Until: now, Checks: checks})
+	if len(r.health) > maxHealthRecords {
+		r.health = r.health[len(r.health)-maxHealthRecords:]
+	}
+}
+
+func sameChecks(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, err := range a {
+		if other, ok := b[name]; !ok || other != err {
+			return false
+		}
+	}
+	return true
+}
+
+// monitor checks the health of the backends periodically, until stopped.
+func (r *supportRecorder) monitor(context command.ExecutionContext) supervisor.Component {
+	return func(ctx netcontext.Context) error {
+		ticker := time.NewTicker(time.Duration(r.config.HealthCheckSeconds) * time.Second)
+		defer ticker.Stop()
+		r.checkHealth(context, time.Now())
+		for {
+			select {
+			case now := <-ticker.C:
+				r.checkHealth(context, now)
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// redactedKey matches the configuration keys whose values are secret: those
+// with a word such as "key" or "token" among the words of their name, like
+// signing_key and secret_access_key, and headers, which hold authorization.
+var redactedKey = regexp.MustCompile(`(?i)(^|_)(secrets?|tokens?|keys?|passwords?|credentials?|headers)(_|$)`)
+
+// redactConfig renders the configuration as YAML with its secrets replaced.
+// URLs only keep their scheme and host, since their paths may hold tokens
+// (as those of chat webhooks often do).
+func redactConfig(config interface{}) ([]byte, error) {
+	encoded, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := yaml.Unmarshal(encoded, &generic); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(redact(generic, false))
+}
+
+func redact(value interface{}, secret bool) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[interface{}]interface{}{}
+		for key, child := range value {
+			result[key] = redact(child, secret || redactedKey.MatchString(fmt.Sprint(key)))
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, child := range value {
+			result[i] = redact(child, secret)
+		}
+		return result
+	case string:
+		if secret {
+			return "REDACTED"
+		}
+		if parsed, err := url.Parse(value); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+			return (&url.URL{Scheme: parsed.Scheme, Host: parsed.Host}).String()
+		}
+		return value
+	}
+	if secret && value != nil {
+		return "REDACTED"
+	}
+	return value
+}
+
+// RuntimeStats describes the process in the support bundle.
+type RuntimeStats struct {
+	GoVersion           string    `json:"go_version"`
+	Started             time.Time `json:"started"`
+	UptimeSeconds       float64   `json:"uptime_seconds"`
+	Goroutines          int       `json:"goroutines"`
+	EvaluatorGoroutines int64     `json:"evaluator_goroutines"`        // evaluating queries
+	LeakedGoroutines    int64     `json:"leaked_evaluator_goroutines"` // found running after their query finished
+	GOMAXPROCS          int       `json:"gomaxprocs"`
+	HeapAlloc           uint64    `json:"heap_alloc_bytes"`
+	HeapObjects         uint64    `json:"heap_objects"`
+	Sys                 uint64    `json:"sys_bytes"`
+	NumGC               uint32    `json:"num_gc"`
+	PauseTotalNs        uint64    `json:"gc_pause_total_ns"`
+}
+
+func runtimeStats(started time.Time) RuntimeStats {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	return RuntimeStats{
+		GoVersion:           runtime.Version(),
+		Started:             started,
+		UptimeSeconds:       time.Since(started).Seconds(),
+		Goroutines:          runtime.NumGoroutine(),
+		EvaluatorGoroutines: function.EvaluatorGoroutines(),
+		LeakedGoroutines:    function.LeakedGoroutines(),
+		GOMAXPROCS:          runtime.GOMAXPROCS(0),
+		HeapAlloc:           memory.HeapAlloc,
+		HeapObjects:         memory.HeapObjects,
+		Sys:                 memory.Sys,
+		NumGC:               memory.NumGC,
+		PauseTotalNs:        memory.PauseTotalNs,
+	}
+}
+
+// errSupportToken refuses requests for the support bundle without the API
+// token of a client profile.
+var errSupportToken = fmt.Errorf("the support bundle can only be requested with the API token of a client profile")
+
+// supportHandler serves the support bundle at /admin/support-bundle.
+type supportHandler struct {
+	recorder  *supportRecorder
+	config    Config
+	clients   clientProfiles
+	context   command.ExecutionContext
+	describes *describeCache     // optional
+	caches    func() interface{} // optional
+}
+
+func (h supportHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := h.clients.matchToken(request); !ok {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusUnauthorized)
+		writer.Write(encodeError(errSupportToken))
+		return
+	}
+	now := time.Now()
+	h.recorder.checkHealth(h.context, now)
+	bundle, err := h.bundle(now)
+	if err != nil {
+		log.Errorf("Cannot create the support bundle: %s", err.Error())
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusInternalServerError)
+		writer.Write(encodeError(err))
+		return
+	}
+	writer.Header().Set("Content-Type", "application/gzip")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="mqe-support-%s.tar.gz"`, now.UTC().Format("20060102T150405Z")))
+	writer.Write(bundle)
+}
+
+// bundleFile is a file of the support bundle. Contents other than bytes are
+// written as JSON.
+type bundleFile struct {
+	name     string
+	contents interface{}
+}
+
+// bundle writes the tarball of the support bundle.
+func (h supportHandler) bundle(now time.Time) ([]byte, error) {
+	config, err := redactConfig(h.config)
+	if err != nil {
+		return nil, err
+	}
+	var goroutines bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutines, 1); err != nil {
+		return nil, err
+	}
+	caches := map[string]interface{}{}
+	if h.describes != nil {
+		caches["describe"] = h.describes.stats()
+	}
+	if h.caches != nil {
+		caches["backends"] = h.caches()
+	}
+	caches["interned_tags"] = api.DefaultInternStats()
+
+	h.recorder.mutex.Lock()
+	queries := append([]QueryRecord{}, h.recorder.queries...)
+	slow := append([]SlowQuery{}, h.recorder.slow...)
+	health := append([]HealthRecord{}, h.recorder.health...)
+	h.recorder.mutex.Unlock()
+
+	files := []bundleFile{
+		{"config.yaml", config},
+		{"runtime.json", runtimeStats(h.recorder.started)},
+		{"goroutines.txt", goroutines.Bytes()},
+		{"caches.json", caches},
+		{"health.json", health},
+		{"queries.json", queries},
+	}
+	for i, query := range slow {
+		files = append(files, bundleFile{fmt.Sprintf("slow_queries/%03d.json", i+1), query})
+	}
+
+	var buffer bytes.Buffer
+	compressed := gzip.NewWriter(&buffer)
+	archive := tar.NewWriter(compressed)
+	for _, file := range files {
+		contents, ok := file.contents.([]byte)
+		if !ok {
+			encoded, err := json.MarshalIndent(file.contents, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			contents = encoded
+		}
+		header := &tar.Header{Name: file.name, Mode: 0644, Size: int64(len(contents)), ModTime: now}
+		if err := archive.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := archive.Write(contents); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	if err := compressed.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
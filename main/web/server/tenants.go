@@ -0,0 +1,130 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/macro"
+)
+
+// TenantConfig lets tenants define their own macros, called as
+// tenant.<name>(...), at /admin/tenants/<tenant>/macros. A query uses the
+// macros of the tenant of its client profile.
+type TenantConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Store   ArchiveConfig `yaml:"store"` // where the macros are kept; without one, they're lost on restart
+}
+
+// newTenants returns nil if tenants aren't enabled.
+func newTenants(config TenantConfig, base function.Registry) (*macro.Tenants, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	store, err := newObjectStore(config.Store)
+	if err != nil {
+		return nil, fmt.Errorf("the macro store is invalid: %s", err.Error())
+	}
+	return macro.NewTenants(base, store), nil
+}
+
+// tenantHandler administers the macros of each tenant:
+//
+//	GET    /admin/tenants/<tenant>/macros         lists the macros
+//	POST   /admin/tenants/<tenant>/macros         defines (or replaces) the macro in the body
+//	GET    /admin/tenants/<tenant>/macros/<name>  shows one macro
+//	DELETE /admin/tenants/<tenant>/macros/<name>  removes it
+//
+// Requests must carry the API token of a client profile of the tenant, so
+// tenants can't see or change each other's macros, and only the registries of
+// configured tenants are ever loaded.
+type tenantHandler struct {
+	tenants *macro.Tenants
+	clients clientProfiles
+}
+
+func (h tenantHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	fail := func(status int, err error) {
+		writer.WriteHeader(status)
+		writer.Write(encodeError(err))
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(request.URL.Path, "/admin/tenants"), "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] != "macros" {
+		fail(http.StatusNotFound, fmt.Errorf("expected /admin/tenants/<tenant>/macros[/<name>]"))
+		return
+	}
+	tenant := parts[0]
+	client, ok := h.clients.matchToken(request)
+	if !ok {
+		fail(http.StatusUnauthorized, fmt.Errorf("the macros of a tenant can only be administered with its API token"))
+		return
+	}
+	if client.Tenant != tenant {
+		fail(http.StatusForbidden, fmt.Errorf("the token can't administer the macros of tenant %s", tenant))
+		return
+	}
+	name := ""
+	if len(parts) == 3 {
+		name = parts[2]
+	}
+	registry, err := h.tenants.Registry(tenant)
+	if err != nil {
+		fail(http.StatusBadRequest, err)
+		return
+	}
+	var body interface{}
+	switch {
+	case request.Method == "GET" && name == "":
+		body = registry.Definitions()
+	case request.Method == "GET":
+		found := false
+		for _, definition := range registry.Definitions() {
+			if definition.Name == name {
+				body = definition
+				found = true
+			}
+		}
+		if !found {
+			fail(http.StatusNotFound, fmt.Errorf("tenant %s has no macro %s", tenant, name))
+			return
+		}
+	case request.Method == "POST" && name == "":
+		var definition macro.Definition
+		if err := json.NewDecoder(request.Body).Decode(&definition); err != nil {
+			fail(http.StatusBadRequest, fmt.Errorf("cannot decode the macro: %s", err.Error()))
+			return
+		}
+		if err := h.tenants.Define(tenant, definition); err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		body = definition
+	case request.Method == "DELETE" && name != "":
+		if err := h.tenants.Remove(tenant, name); err != nil {
+			fail(http.StatusBadRequest, err)
+			return
+		}
+		body = registry.Definitions()
+	default:
+		fail(http.StatusMethodNotAllowed, fmt.Errorf("unsupported method %s", request.Method))
+		return
+	}
+	writeResponse(writer, "macros", body)
+}
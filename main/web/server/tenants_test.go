@@ -0,0 +1,97 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestTenantHandler(t *testing.T) {
+	a := assert.New(t)
+	store := memory.NewStore(time.Minute)
+	store.AddGenerated(api.TaggedMetric{MetricKey: "requests", TagSet: api.TagSet{"host": "a"}}, func(t time.Time) float64 {
+		return float64(t.Minute())
+	})
+	tenants, err := newTenants(TenantConfig{Enabled: true}, registry.Default())
+	a.CheckError(err)
+	clients, err := newClientProfiles([]ClientProfile{
+		{Name: "payments", Tokens: []string{"payments-token"}, Tenant: "payments"},
+		{Name: "storage", Tokens: []string{"storage-token"}, Tenant: "storage"},
+	})
+	a.CheckError(err)
+	queries := queryHandler{
+		context: command.ExecutionContext{TimeseriesStorageAPI: store, MetricMetadataAPI: store, FetchLimit: 1000, Ctx: context.Background()},
+		clients: clients,
+		tenants: tenants,
+	}
+	admin := tenantHandler{tenants: tenants, clients: clients}
+
+	serve := func(handler http.Handler, method string, path string, token string, body string) (int, string) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+
+	code, _ := serve(admin, "POST", "/admin/tenants/payments/macros", "payments-token", `{"name": "double", "parameters": ["x"], "expression": "x * 2"}`)
+	a.EqInt(code, http.StatusOK)
+	code, body := serve(admin, "POST", "/admin/tenants/payments/macros", "payments-token", `{"name": "loop", "parameters": ["x"], "expression": "tenant.loop(x)"}`)
+	a.EqInt(code, http.StatusBadRequest)
+	a.EqBool(strings.Contains(body, "macro loop calls itself"), true)
+	code, body = serve(admin, "GET", "/admin/tenants/payments/macros", "payments-token", "")
+	a.EqInt(code, http.StatusOK)
+	a.EqBool(strings.Contains(body, `"expression": "x * 2"`), true)
+	code, _ = serve(admin, "GET", "/admin/tenants/payments/macros/missing", "payments-token", "")
+	a.EqInt(code, http.StatusNotFound)
+
+	// Only the tenant which defined the macro may call it.
+	query := "query=select+tenant.double(requests)+from+0+to+600000"
+	code, _ = serve(queries, "POST", "/query", "payments-token", query)
+	a.EqInt(code, http.StatusOK)
+	code, body = serve(queries, "POST", "/query", "storage-token", query)
+	a.EqBool(code == http.StatusOK, false)
+	a.EqBool(strings.Contains(body, "tenant.double"), true)
+
+	code, _ = serve(admin, "DELETE", "/admin/tenants/payments/macros/double", "payments-token", "")
+	a.EqInt(code, http.StatusOK)
+	code, _ = serve(queries, "POST", "/query", "payments-token", query)
+	a.EqBool(code == http.StatusOK, false)
+
+	code, _ = serve(admin, "GET", "/admin/tenants/payments", "payments-token", "")
+	a.EqInt(code, http.StatusNotFound)
+
+	// Tenants can only administer their own macros.
+	code, _ = serve(admin, "POST", "/admin/tenants/payments/macros", "storage-token", `{"name": "triple", "parameters": ["x"], "expression": "x * 3"}`)
+	a.EqInt(code, http.StatusForbidden)
+	code, _ = serve(admin, "GET", "/admin/tenants/payments/macros", "", "")
+	a.EqInt(code, http.StatusUnauthorized)
+	code, _ = serve(admin, "GET", "/admin/tenants/unknown/macros", "payments-token", "")
+	a.EqInt(code, http.StatusForbidden)
+}
@@ -0,0 +1,138 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/lint"
+)
+
+// DashboardReport is the result of validating each query of a dashboard.
+type DashboardReport struct {
+	Title    string        `json:"title"`
+	Valid    bool          `json:"valid"` // whether no query has an error
+	Errors   int           `json:"errors"`
+	Warnings int           `json:"warnings"`
+	Panels   []PanelReport `json:"panels"`
+}
+
+// PanelReport holds the problems of the queries of a panel.
+type PanelReport struct {
+	Title   string        `json:"title"`
+	Queries []QueryReport `json:"queries"`
+}
+
+// QueryReport holds the problems of one query.
+type QueryReport struct {
+	Query    string         `json:"query"`
+	Valid    bool           `json:"valid"`
+	Problems []lint.Problem `json:"problems"`
+}
+
+// defaultResolutionTolerance is the relative difference between the results at
+// adjacent resolutions which is reported when no tolerance is given.
+const defaultResolutionTolerance = 0.05
+
+// dashboardHandler validates the queries of a dashboard definition, which is
+// posted as JSON, without fetching any data. Each query is parsed, linted, and
+// its metrics are looked up in the metadata API.
+//
+// With "resolutions=true", each valid select query is also run at its own
+// resolution and the next coarser one, and results which differ by more than
+// "tolerance" (a fraction, 0.05 by default) are reported as warnings.
+type dashboardHandler struct {
+	context command.ExecutionContext
+}
+
+func (h dashboardHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if request.Method != http.MethodPost {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		writer.Write(encodeError(fmt.Errorf("the dashboard must be posted")))
+		return
+	}
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(err))
+		return
+	}
+	dashboard, err := lint.ParseDashboard(body)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write(encodeError(err))
+		return
+	}
+	compare, _ := strconv.ParseBool(request.URL.Query().Get("resolutions"))
+	tolerance := defaultResolutionTolerance
+	if value := request.URL.Query().Get("tolerance"); value != "" {
+		tolerance, err = strconv.ParseFloat(value, 64)
+		if err != nil || tolerance < 0 {
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write(encodeError(fmt.Errorf("the tolerance must be a non-negative number, not %q", value)))
+			return
+		}
+	}
+	// The selects stop when the client goes away.
+	context := h.context
+	context.Ctx = request.Context()
+	writeResponse(writer, "validate dashboard", validateDashboard(dashboard, context, compare, tolerance))
+}
+
+func validateDashboard(dashboard lint.Dashboard, context command.ExecutionContext, compare bool, tolerance float64) DashboardReport {
+	functions := context.Registry
+	if functions == nil {
+		functions = registry.Default()
+	}
+	report := DashboardReport{Title: dashboard.Title, Valid: true, Panels: make([]PanelReport, len(dashboard.Panels))}
+	for i, panel := range dashboard.Panels {
+		report.Panels[i] = PanelReport{Title: panel.Title, Queries: make([]QueryReport, len(panel.Queries))}
+		for j, query := range panel.Queries {
+			cmd, problems := lint.Parse(query)
+			if cmd != nil {
+				problems = append(problems, lint.Check(cmd, functions)...)
+				problems = append(problems, lint.Resolve(context.Ctx, cmd, context.MetricMetadataAPI, context.AdditionalConstraints, context.FetchLimit)...)
+				if compare && len(problems) == 0 {
+					compared, err := lint.CompareResolutions(cmd, context, tolerance)
+					if err != nil {
+						compared = []lint.Problem{{Severity: lint.Warning, Message: fmt.Sprintf("resolutions weren't compared: %s", err.Error())}}
+					}
+					problems = append(problems, compared...)
+				}
+			}
+			if problems == nil {
+				problems = []lint.Problem{}
+			}
+			valid := true
+			for _, problem := range problems {
+				if problem.Severity == lint.Error {
+					valid = false
+					report.Errors++
+				} else {
+					report.Warnings++
+				}
+			}
+			report.Valid = report.Valid && valid
+			report.Panels[i].Queries[j] = QueryReport{Query: query, Valid: valid, Problems: problems}
+		}
+	}
+	return report
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestDashboardHandler(t *testing.T) {
+	a := assert.New(t)
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": "a"}})
+	handler := dashboardHandler{context: command.ExecutionContext{MetricMetadataAPI: fakeAPI, FetchLimit: 1000}}
+
+	post := func(method string, body string) (int, DashboardReport) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, "/validate/dashboard", strings.NewReader(body)))
+		var response struct {
+			Body DashboardReport `json:"body"`
+		}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder.Code, response.Body
+	}
+
+	code, report := post("POST", `{"title": "Hosts", "panels": [
+		{"title": "CPU", "query": "select cpu from -1h to now"},
+		{"title": "Broken", "queries": ["select cpu[host = 'b'] from -1h to now", "select disk from -1h to now", "select (cpu"]}
+	]}`)
+	a.EqInt(code, http.StatusOK)
+	a.EqString(report.Title, "Hosts")
+	a.EqBool(report.Valid, false)
+	a.EqInt(report.Errors, 2)
+	a.EqInt(report.Warnings, 1)
+	a.EqInt(len(report.Panels), 2)
+	a.EqBool(report.Panels[0].Queries[0].Valid, true)
+	a.EqInt(len(report.Panels[0].Queries[0].Problems), 0)
+	a.EqBool(report.Panels[1].Queries[0].Valid, true)
+	a.EqBool(report.Panels[1].Queries[1].Valid, false)
+	a.EqBool(report.Panels[1].Queries[2].Valid, false)
+
+	code, report = post("POST", `{"panels": [{"targets": [{"target": "select cpu from -1h to now"}]}]}`)
+	a.EqInt(code, http.StatusOK)
+	a.EqBool(report.Valid, true)
+
+	code, _ = post("POST", `{"title": "no queries"}`)
+	a.EqInt(code, http.StatusBadRequest)
+	code, _ = post("GET", "")
+	a.EqInt(code, http.StatusMethodNotAllowed)
+}
+
+// cancellableStorage fails the fetches whose context has been cancelled.
+type cancellableStorage struct {
+	*memory.Store
+}
+
+func (s cancellableStorage) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	if err := request.Ctx.Err(); err != nil {
+		return api.SeriesList{}, err
+	}
+	return s.Store.FetchMultipleTimeseries(request)
+}
+
+func TestDashboardHandler_Resolutions(t *testing.T) {
+	a := assert.New(t)
+	store := memory.NewStore(time.Minute)
+	store.AddGenerated(api.TaggedMetric{MetricKey: "requests", TagSet: api.TagSet{"host": "a"}}, func(t time.Time) float64 {
+		return float64(t.Unix())
+	})
+	handler := dashboardHandler{context: command.ExecutionContext{TimeseriesStorageAPI: store, MetricMetadataAPI: store, FetchLimit: 1000}}
+	body := `{"panels": [{"queries": ["select transform.integral(requests) from 0 to 7200000 resolution 1m", "select requests from 0 to 7200000 resolution 1m"]}]}`
+
+	for _, test := range []struct {
+		target   string
+		code     int
+		warnings int
+	}{
+		{"/validate/dashboard", http.StatusOK, 0},
+		{"/validate/dashboard?resolutions=true", http.StatusOK, 0},
+		{"/validate/dashboard?resolutions=true&tolerance=0.01", http.StatusOK, 1},
+		{"/validate/dashboard?resolutions=true&tolerance=-1", http.StatusBadRequest, 0},
+	} {
+		a := a.Contextf("%s", test.target)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", test.target, strings.NewReader(body)))
+		var response struct {
+			Body DashboardReport `json:"body"`
+		}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		a.EqInt(recorder.Code, test.code)
+		a.EqInt(response.Body.Warnings, test.warnings)
+		a.EqBool(response.Body.Valid, test.code == http.StatusOK)
+	}
+
+	// The selects are cancelled with the request, even though the handler's
+	// context has its own.
+	handler.context.Ctx = context.Background()
+	handler.context.TimeseriesStorageAPI = cancellableStorage{store}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/validate/dashboard?resolutions=true", strings.NewReader(body)).WithContext(cancelled))
+	var response struct {
+		Body DashboardReport `json:"body"`
+	}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	a.EqInt(response.Body.Warnings, 2)
+	a.EqBool(strings.Contains(recorder.Body.String(), "resolutions weren't compared"), true)
+}
@@ -0,0 +1,28 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.16
+// +build go1.16
+
+// Package static holds the files of the web UI, embedded so that the server
+// binary is self-contained. Embedding needs Go 1.16, so older toolchains
+// don't build it at all.
+package static
+
+import "embed"
+
+// Files are the UI's pages, scripts and stylesheets.
+//
+//go:embed *.html *.css *.js js/*.js
+var Files embed.FS
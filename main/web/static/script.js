@@ -56,8 +56,8 @@ module.directive('queryInput', function ($http) {
     link: function (scope, elem, attrs) {
       var autocom = new Autocom(elem[0]);
       var keywords = [
-        "all", "by", "collapse", "describe", "from", "group", "match", "metrics",
-        "now", "resolution", "sample", "select", "to", "where"
+        "all", "by", "collapse", "describe", "explain", "forecast", "from", "group", "match",
+        "metrics", "now", "reach", "resolution", "sample", "select", "to", "where"
       ];
       var latterKeywords = [
         "from", "match", "now", "resolution", "sample", "by", "to",
@@ -297,11 +297,12 @@ module.factory("$chartWaiting", function () {
 });
 
 module.service("timedQuery", function ($http, $q, $launchedQueries) {
-  return function (params) {
+  // path is "/query" unless given, as "/shared" is for a shared link.
+  return function (params, path) {
     var resultPromise = $q.defer(); // Will be resolved with received value.
     var start = new Date();
     $launchedQueries.inc();
-    var request = $http.get("/query", {
+    var request = $http.get(path || "/query", {
       params: params
     }).success(function (data, status, headers, config) {
       $launchedQueries.dec();
@@ -396,7 +397,7 @@ module.controller("CommonController", function (
       return false;
     }
     for (var i = 0; i < result.body.length; i++) {
-      if (result.body[i].series.length === 0) {
+      if (result.body[i].type == "series" && result.body[i].series.length === 0) {
         if (result.body.length == 1) {
           $scope.queryEmptyMessage = "the query resulted in 0 series";
         } else {
@@ -650,9 +651,38 @@ module.controller("MainController", function (
     $scope.inputModel.query = query;
   };
 
+  // the tag keys of the rows of a table result.
+  $scope.tableTagKeys = function (table) {
+    return $scope.tagKeys(table.rows);
+  };
+  // the tag keys of rows (such as forecasts) which each have a tagset.
+  $scope.tagKeys = function (rows) {
+    var keys = {};
+    _.each(rows, function (row) {
+      _.each(_.keys(row.tagset), function (key) {
+        keys[key] = true;
+      });
+    });
+    return _.keys(keys).sort();
+  };
+  // the rows of a heatmap of a distribution, with the highest bucket first.
+  $scope.heatmapRows = function (distribution) {
+    var max = 1;
+    _.each(distribution.counts, function (counts) {
+      max = Math.max(max, _.max(counts));
+    });
+    return _.map(distribution.counts, function (counts, i) {
+      return {
+        label: distribution.bounds[i] + " to " + distribution.bounds[i + 1],
+        cells: _.map(counts, function (count) {
+          return {count: count, opacity: count / max};
+        })
+      };
+    }).reverse();
+  };
   // true if the output should be tabular.
   $scope.isTabular = function () {
-    return ["describe all", "describe metrics", "describe"].indexOf($scope.queryResult.name) >= 0;
+    return ["describe all", "describe metrics", "describe keys", "describe values", "describe"].indexOf($scope.queryResult.name) >= 0;
   };
   updateEmbed();
 });
@@ -682,10 +712,18 @@ module.controller("EmbedController", function (
   $scope.inputModel.profile = false;
   $scope.inputModel.query = "";
   $scope.inputModel.renderType = queries.renderType || "line";
-  timedQuery({
-    profile: false,
-    query: queries.query || ""
-  }).then(function (data) {
+  var result;
+  if (queries.share) {
+    // A shared link carries its own query, which can't be explored.
+    $scope.hidden.explore = true;
+    result = timedQuery({token: queries.share}, "/shared");
+  } else {
+    result = timedQuery({
+      profile: false,
+      query: queries.query || ""
+    });
+  }
+  result.then(function (data) {
     $scope.setQueryResult(data.payload);
   });
 
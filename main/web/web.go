@@ -24,23 +24,55 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/square/metrics/annotations"
+	"github.com/square/metrics/canary"
+	"github.com/square/metrics/endpoints"
 	"github.com/square/metrics/function/registry"
 	"github.com/square/metrics/log"
 	"github.com/square/metrics/main/common"
 	"github.com/square/metrics/main/web/server"
-	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/metric_metadata/alias"
 	"github.com/square/metrics/metric_metadata/cached"
 	"github.com/square/metrics/metric_metadata/cassandra"
+	"github.com/square/metrics/metric_metadata/indexer"
 	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/supervisor"
+	"github.com/square/metrics/timeseries"
 	"github.com/square/metrics/timeseries/blueflood"
+	"github.com/square/metrics/timeseries/memory"
+	"github.com/square/metrics/timeseries/peers"
 	"github.com/square/metrics/util"
 )
 
-func startServer(config server.Config, context command.ExecutionContext) error {
-	httpMux, err := server.NewMux(config, context, server.Hook{})
+func startServer(config server.Config, context command.ExecutionContext, aliases *alias.Table, notes *annotations.Store, indexer *indexer.Indexer, peerCache *peers.Cache, probe *canary.Canary, capabilities server.Capabilities, hook server.Hook) error {
+	if hook.Supervisor == nil {
+		hook.Supervisor = supervisor.New(supervisor.Config{})
+	}
+	httpMux, err := server.NewMux(config, context, hook)
 	if err != nil {
 		return err
 	}
+	httpMux.Handle("/admin/runtime", server.NewRuntimeHandler(hook.Supervisor))
+	httpMux.Handle("/admin/aliases", server.NewAliasHandler(aliases))
+	if notes != nil {
+		httpMux.Handle("/admin/annotations", server.NewAnnotationsHandler(notes))
+	}
+	if indexer != nil {
+		httpMux.Handle("/admin/indexer", server.NewIndexerHandler(indexer))
+	}
+	if peerCache != nil {
+		httpMux.Handle(peers.FetchPath, peerCache.Handler())
+	}
+	if probe != nil {
+		httpMux.Handle("/admin/canary", server.NewCanaryHandler(probe))
+	}
+	capabilities.Formats = append(capabilities.Formats, hook.Formats()...)
+	httpMux.Handle("/api/v1/capabilities", server.NewCapabilitiesHandler(capabilities))
+	drainPeriod := time.Duration(config.DrainSeconds) * time.Second
+	lifecycle := &server.Lifecycle{}
+	httpMux.Handle("/healthz", server.NewLivenessHandler(lifecycle))
+	httpMux.Handle("/readyz", server.NewReadinessHandler(lifecycle, context))
+	httpMux.Handle("/admin/drain", server.NewDrainHandler(lifecycle, drainPeriod))
 
 	server := &http.Server{
 		Addr:           fmt.Sprintf(":%d", config.Port),
@@ -49,8 +81,75 @@ func startServer(config server.Config, context command.ExecutionContext) error {
 		WriteTimeout:   time.Duration(config.Timeout) * time.Second,
 		MaxHeaderBytes: 1 << 20,
 	}
+	config.HTTP.Apply(server)
+	hook.Supervisor.Start(context.Ctx)
+	// On SIGTERM, stop reporting ready and keep serving for the drain period
+	// (unless a preStop hook has already drained) before shutting down.
+	stopped := make(chan struct{})
+	terminate := make(chan os.Signal, 1)
+	signal.Notify(terminate, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-terminate
+		if !lifecycle.Draining() {
+			lifecycle.Drain()
+			time.Sleep(drainPeriod)
+		}
+		log.Infof("Shutting down the server.")
+		ctx, cancel := contextWithTimeout(server.WriteTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Errorf("Error shutting down the server: %s", err.Error())
+		}
+		if err := hook.Supervisor.Stop(ctx); err != nil {
+			log.Errorf("Error stopping the background components: %s", err.Error())
+		}
+		close(stopped)
+	}()
+
+	listener, err := config.HTTP.Listen(server.Addr)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Listening on port %d.\n", config.Port)
-	return server.ListenAndServe()
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	<-stopped
+	return nil
+}
+
+// contextWithTimeout creates a context that expires after the timeout, or
+// never if the timeout is zero.
+func contextWithTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// startDevServer runs the server with the embedded UI and in-memory backends
+// holding generated example metrics, so the engine can be tried without any
+// infrastructure or configuration.
+func startDevServer() error {
+	store := memory.NewStore(30 * time.Second)
+	memory.AddExampleData(store)
+	aliases, err := alias.LoadTable("")
+	if err != nil {
+		return err
+	}
+	config := server.Config{Port: 8080, Timeout: 30, HTTPIngestion: true}
+	executionContext := command.ExecutionContext{
+		MetricMetadataAPI:    store,
+		TimeseriesStorageAPI: store,
+		FetchLimit:           1500,
+		SlotLimit:            5000,
+		Registry:             registry.Default(),
+		Ctx:                  context.Background(),
+	}
+	capabilities := server.DefaultCapabilities(config, executionContext)
+	capabilities.Backends = server.BackendNames{Storage: "memory", Metadata: "memory"}
+	fmt.Printf("Development mode: try the UI at http://localhost:%d/ui with a query such as\n\tselect cpu.user | aggregate.mean(group by dc) from -6h to now\n", config.Port)
+	return startServer(config, executionContext, aliases, nil, nil, nil, nil, capabilities, server.Hook{})
 }
 
 func main() {
@@ -64,11 +163,24 @@ func main() {
 		}
 	}()
 
+	if *common.Dev {
+		if err := startDevServer(); err != nil {
+			log.Infof(err.Error())
+		}
+		return
+	}
+
 	config := struct {
-		ConversionRulesPath string           `yaml:"conversion_rules_path"`
-		Cassandra           cassandra.Config `yaml:"cassandra"`
-		Blueflood           blueflood.Config `yaml:"blueflood"`
-		Web                 server.Config    `yaml:"web"`
+		ConversionRulesPath string            `yaml:"conversion_rules_path"`
+		AliasesPath         string            `yaml:"aliases_path"`
+		AnnotationsPath     string            `yaml:"annotations_path"` // optional. Maintenance annotations mask the series of selects which ask for it
+		Cassandra           cassandra.Config  `yaml:"cassandra"`
+		Blueflood           blueflood.Config  `yaml:"blueflood"`
+		Indexer             indexer.Config    `yaml:"indexer"`
+		Canary              canary.Config     `yaml:"canary"`
+		Supervisor          supervisor.Config `yaml:"supervisor"`
+		Peers               peers.Config      `yaml:"peers"`
+		Web                 server.Config     `yaml:"web"`
 	}{}
 
 	common.LoadConfig(&config)
@@ -85,34 +197,99 @@ func main() {
 		return
 	}
 
+	aliases, err := alias.LoadTable(config.AliasesPath)
+	if err != nil {
+		common.ExitWithErrorMessage("Error loading metric aliases: %s", err.Error())
+		return
+	}
+
+	var notes *annotations.Store
+	if config.AnnotationsPath != "" {
+		notes, err = annotations.LoadStore(config.AnnotationsPath)
+		if err != nil {
+			common.ExitWithErrorMessage("Error loading annotations: %s", err.Error())
+			return
+		}
+	}
+
 	config.Blueflood.GraphiteMetricConverter = &util.RuleBasedGraphiteConverter{Ruleset: ruleset}
 
 	blueflood := blueflood.NewBlueflood(config.Blueflood)
 
+	components := supervisor.New(config.Supervisor)
+
+	// The indexer compares the raw backends, beneath the cache and aliases.
+	var metadataIndexer *indexer.Indexer
+	if config.Indexer.Enabled {
+		metadataIndexer = indexer.New(config.Indexer, blueflood.(timeseries.ScanningStorageAPI), metadataAPI, metadataAPI)
+		components.Add("metadata-indexer", metadataIndexer.Run)
+	}
+
 	optimizedMetadataAPI := cached.NewMetricMetadataAPI(metadataAPI, cached.Config{
 		TimeToLive:   time.Minute * 5, // Cache items invalidated after 5 minutes.
 		RequestLimit: 500,
 	})
 	for i := 0; i < 10; i++ {
-		// Start goroutines to update the metadata cache in the background.
-		go func() {
-			for {
-				err := optimizedMetadataAPI.GetBackgroundAction()(metadata.Context{})
-				if err != nil {
-					log.Errorf("Error performing background cache-update: %s", err.Error())
-				}
-			}
-		}()
+		// Update the metadata cache in the background.
+		components.Add(fmt.Sprintf("metadata-cache-refresher-%d", i), optimizedMetadataAPI.RunBackground)
+	}
+
+	// Query nodes may share the series they fetch, each caching a part of them.
+	storageAPI := timeseries.StorageAPI(blueflood)
+	peerCache, err := peers.NewCache(blueflood, config.Peers)
+	if err != nil {
+		common.ExitWithErrorMessage("Error configuring the peers: %s", err.Error())
+		return
+	}
+	if peerCache != nil {
+		storageAPI = peerCache
 	}
 
-	err = startServer(config.Web, command.ExecutionContext{
-		MetricMetadataAPI:    optimizedMetadataAPI,
-		TimeseriesStorageAPI: blueflood,
+	executionContext := command.ExecutionContext{
+		MetricMetadataAPI:    alias.NewMetricMetadataAPI(optimizedMetadataAPI, aliases),
+		TimeseriesStorageAPI: alias.NewStorageAPI(storageAPI, aliases),
 		FetchLimit:           1500,
 		SlotLimit:            5000,
 		Registry:             registry.Default(),
 		Ctx:                  context.Background(),
-	})
+	}
+	if notes != nil {
+		executionContext.MaintenanceAPI = notes
+	}
+	// The canary writes to Blueflood directly, and reads back through the
+	// whole engine.
+	var probe *canary.Canary
+	if writer, ok := blueflood.(canary.Writer); ok {
+		probe = canary.New(config.Canary, writer, metadataAPI, executionContext)
+	}
+	if probe != nil {
+		components.Add("canary", probe.Run)
+	}
+
+	capabilities := server.DefaultCapabilities(config.Web, executionContext)
+	capabilities.Aliases = true
+	capabilities.Backends = server.BackendNames{Storage: "blueflood", Metadata: "cassandra"}
+
+	hook := server.Hook{
+		Supervisor: components,
+		Graphite:   &ruleset,
+		Pools:      map[string]*endpoints.Pool{},
+		CacheStats: func() interface{} {
+			stats := map[string]interface{}{
+				"metadata_index":         optimizedMetadataAPI.IndexStats(),
+				"metadata_live_requests": optimizedMetadataAPI.CurrentLiveRequests(),
+				"metadata_max_requests":  optimizedMetadataAPI.MaximumLiveRequests(),
+			}
+			if peerCache != nil {
+				stats["fetch_cache"] = peerCache.Stats()
+			}
+			return stats
+		},
+	}
+	if pooled, ok := blueflood.(interface{ Pool() *endpoints.Pool }); ok && pooled.Pool() != nil {
+		hook.Pools["blueflood"] = pooled.Pool()
+	}
+	err = startServer(config.Web, executionContext, aliases, notes, metadataIndexer, peerCache, probe, capabilities, hook)
 	if err != nil {
 		log.Infof(err.Error())
 	}
@@ -0,0 +1,225 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alias maps retired metric names onto their replacements, so that
+// renaming a metric does not break the queries which still use the old name.
+package alias
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/util"
+	"gopkg.in/yaml.v2"
+)
+
+// Alias describes how an old metric name is served by a new one.
+type Alias struct {
+	Target api.MetricKey     `yaml:"target" json:"target"` // The metric which now holds the data
+	Tags   map[string]string `yaml:"tags" json:"tags"`     // Renamed tag keys, from the old key to the new key
+}
+
+// toTarget renames the tags of an old tagset to those used by the target metric.
+func (alias Alias) toTarget(tagSet api.TagSet) api.TagSet {
+	result := api.NewTagSet()
+	for key, value := range tagSet {
+		if renamed, ok := alias.Tags[key]; ok {
+			key = renamed
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// fromTarget renames the tags of a target tagset back to those used by the old metric.
+func (alias Alias) fromTarget(tagSet api.TagSet) api.TagSet {
+	result := api.NewTagSet()
+	for key, value := range tagSet {
+		for old, renamed := range alias.Tags {
+			if renamed == key {
+				key = old
+				break
+			}
+		}
+		result[key] = value
+	}
+	return result.Intern() // shared by the aliased tagsets of every metric
+}
+
+// Usage records how often an alias has been used, so that its old name can
+// eventually be retired.
+type Usage struct {
+	Name     api.MetricKey `json:"name"`
+	Target   api.MetricKey `json:"target"`
+	Count    int64         `json:"count"`
+	LastUsed time.Time     `json:"last_used,omitempty"`
+}
+
+// Table holds the current set of aliases along with their usage.
+// It is safe for concurrent use.
+type Table struct {
+	path    string
+	clock   util.Clock
+	mutex   sync.RWMutex
+	aliases map[api.MetricKey]Alias
+	usage   map[api.MetricKey]*Usage
+}
+
+// NewTable creates a table holding the given aliases.
+func NewTable(aliases map[api.MetricKey]Alias) (*Table, error) {
+	table := &Table{
+		clock: util.RealClock{},
+		usage: map[api.MetricKey]*Usage{},
+	}
+	if err := table.Replace(aliases); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// LoadTable creates a table from the given YAML file, which maps each old
+// metric name to its alias. An empty path results in an empty table.
+func LoadTable(path string) (*Table, error) {
+	table, err := NewTable(nil)
+	if err != nil {
+		return nil, err
+	}
+	table.path = path
+	if err := table.Reload(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// Reload re-reads the file that the table was loaded from.
+// The current aliases are kept if the file cannot be loaded.
+func (table *Table) Reload() error {
+	if table.path == "" {
+		return nil
+	}
+	bytes, err := ioutil.ReadFile(table.path)
+	if err != nil {
+		return err
+	}
+	aliases := map[api.MetricKey]Alias{}
+	if err := yaml.Unmarshal(bytes, &aliases); err != nil {
+		return fmt.Errorf("unable to parse alias table `%s`: %s", table.path, err.Error())
+	}
+	return table.Replace(aliases)
+}
+
+// Replace validates the given aliases and swaps them in for the current ones.
+// Usage is kept for aliases which remain in the table.
+func (table *Table) Replace(aliases map[api.MetricKey]Alias) error {
+	for name, alias := range aliases {
+		if name == "" || alias.Target == "" {
+			return fmt.Errorf("alias `%s` must have both a name and a target", name)
+		}
+		if _, ok := aliases[alias.Target]; ok {
+			return fmt.Errorf("alias `%s` targets `%s`, which is itself an alias", name, alias.Target)
+		}
+		targets := map[string]bool{}
+		for old, renamed := range alias.Tags {
+			if old == "" || renamed == "" {
+				return fmt.Errorf("alias `%s` renames tags to or from an empty key", name)
+			}
+			if targets[renamed] {
+				return fmt.Errorf("alias `%s` renames several tags to `%s`", name, renamed)
+			}
+			targets[renamed] = true
+		}
+	}
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+	table.aliases = aliases
+	for name, usage := range table.usage {
+		if alias, ok := aliases[name]; ok {
+			usage.Target = alias.Target
+		} else {
+			delete(table.usage, name)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the alias for the given metric name, if there is one, and
+// records its use. It is called once per query, when the metric's tags are
+// resolved; the fetches which follow use Resolve so that they aren't counted.
+func (table *Table) Lookup(name api.MetricKey) (Alias, bool) {
+	if table == nil {
+		return Alias{}, false
+	}
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+	alias, ok := table.aliases[name]
+	if !ok {
+		return Alias{}, false
+	}
+	usage, ok := table.usage[name]
+	if !ok {
+		usage = &Usage{Name: name, Target: alias.Target}
+		table.usage[name] = usage
+	}
+	usage.Count++
+	usage.LastUsed = table.clock.Now()
+	return alias, true
+}
+
+// Resolve returns the alias for the given metric name, if there is one,
+// without recording its use.
+func (table *Table) Resolve(name api.MetricKey) (Alias, bool) {
+	if table == nil {
+		return Alias{}, false
+	}
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+	alias, ok := table.aliases[name]
+	return alias, ok
+}
+
+// Aliases returns a copy of the current aliases.
+func (table *Table) Aliases() map[api.MetricKey]Alias {
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+	result := make(map[api.MetricKey]Alias, len(table.aliases))
+	for name, alias := range table.aliases {
+		result[name] = alias
+	}
+	return result
+}
+
+// Usage reports the usage of every alias in the table, sorted by name.
+// Aliases which have never been used are reported with a count of zero.
+func (table *Table) Usage() []Usage {
+	table.mutex.RLock()
+	defer table.mutex.RUnlock()
+	names := make(api.MetricKeys, 0, len(table.aliases))
+	for name := range table.aliases {
+		names = append(names, name)
+	}
+	sort.Sort(names)
+	result := make([]Usage, len(names))
+	for i, name := range names {
+		if usage, ok := table.usage[name]; ok {
+			result[i] = *usage
+			continue
+		}
+		result[i] = Usage{Name: name, Target: table.aliases[name].Target}
+	}
+	return result
+}
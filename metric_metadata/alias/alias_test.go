@@ -0,0 +1,229 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alias
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestTable_Load(t *testing.T) {
+	a := assert.New(t)
+	file, err := ioutil.TempFile("", "aliases")
+	a.CheckError(err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("cpu.user:\n  target: system.cpu\n  tags:\n    hostname: host\n")
+	a.CheckError(err)
+	a.CheckError(file.Close())
+
+	table, err := LoadTable(file.Name())
+	a.CheckError(err)
+	alias, ok := table.Lookup("cpu.user")
+	a.EqBool(ok, true)
+	a.EqString(string(alias.Target), "system.cpu")
+	a.EqString(alias.Tags["hostname"], "host")
+
+	// A broken file keeps the previous aliases.
+	a.CheckError(ioutil.WriteFile(file.Name(), []byte("cpu.user:\n  tags: {}\n"), 0644))
+	if err := table.Reload(); err == nil {
+		a.Errorf("expected an error reloading an alias without a target")
+	}
+	_, ok = table.Lookup("cpu.user")
+	a.EqBool(ok, true)
+
+	empty, err := LoadTable("")
+	a.CheckError(err)
+	_, ok = empty.Lookup("cpu.user")
+	a.EqBool(ok, false)
+}
+
+func TestTable_Replace(t *testing.T) {
+	for _, test := range []struct {
+		aliases map[api.MetricKey]Alias
+		valid   bool
+	}{
+		{map[api.MetricKey]Alias{"a": {Target: "b"}}, true},
+		{map[api.MetricKey]Alias{"a": {Target: "b", Tags: map[string]string{"x": "y"}}}, true},
+		{map[api.MetricKey]Alias{"a": {}}, false},
+		{map[api.MetricKey]Alias{"a": {Target: "b"}, "b": {Target: "c"}}, false},
+		{map[api.MetricKey]Alias{"a": {Target: "b", Tags: map[string]string{"x": ""}}}, false},
+		{map[api.MetricKey]Alias{"a": {Target: "b", Tags: map[string]string{"x": "z", "y": "z"}}}, false},
+	} {
+		a := assert.New(t).Contextf("%+v", test.aliases)
+		_, err := NewTable(test.aliases)
+		a.EqBool(err == nil, test.valid)
+	}
+}
+
+func TestTable_Usage(t *testing.T) {
+	a := assert.New(t)
+	table, err := NewTable(map[api.MetricKey]Alias{
+		"old.a": {Target: "new.a"},
+		"old.b": {Target: "new.b"},
+	})
+	a.CheckError(err)
+	table.clock = mocks.NewTestClock(time.Unix(100, 0))
+	table.Lookup("old.a")
+	table.Lookup("old.a")
+	table.Lookup("new.a")
+	// Resolving an alias doesn't count as a use.
+	if _, ok := table.Resolve("old.b"); !ok {
+		a.Errorf("expected old.b to resolve")
+	}
+	usage := table.Usage()
+	a.EqInt(len(usage), 2)
+	a.EqString(string(usage[0].Name), "old.a")
+	a.EqInt(int(usage[0].Count), 2)
+	a.Eq(usage[0].LastUsed, time.Unix(100, 0))
+	a.EqString(string(usage[1].Name), "old.b")
+	a.EqInt(int(usage[1].Count), 0)
+
+	// Usage is forgotten once an alias is removed.
+	a.CheckError(table.Replace(map[api.MetricKey]Alias{"old.b": {Target: "new.b"}}))
+	usage = table.Usage()
+	a.EqInt(len(usage), 1)
+	a.EqString(string(usage[0].Name), "old.b")
+}
+
+func TestAPI(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 60, 30)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3}, TagSet: api.TagSet{"metric": "system.cpu", "host": "a"}},
+		api.Timeseries{Values: []float64{4, 5, 6}, TagSet: api.TagSet{"metric": "system.cpu", "host": "b"}},
+		api.Timeseries{Values: []float64{7, 8, 9}, TagSet: api.TagSet{"metric": "other", "host": "a"}},
+	)
+	table, err := NewTable(map[api.MetricKey]Alias{
+		"cpu.user": {Target: "system.cpu", Tags: map[string]string{"hostname": "host"}},
+	})
+	a.CheckError(err)
+	metadataAPI := NewMetricMetadataAPI(comboAPI, table)
+	storageAPI := NewStorageAPI(comboAPI, table)
+
+	if _, ok := metadataAPI.(metadata.MetricUpdateAPI); !ok {
+		a.Errorf("expected the wrapped API to support updates")
+	}
+
+	tagSets, err := metadataAPI.GetAllTags(context.Background(), "cpu.user", metadata.Context{})
+	a.CheckError(err)
+	a.EqInt(len(tagSets), 2)
+	for _, tagSet := range tagSets {
+		if _, ok := tagSet["hostname"]; !ok {
+			a.Errorf("expected tagset %+v to use the old tag key", tagSet)
+		}
+	}
+
+	list, err := storageAPI.FetchMultipleTimeseries(timeseries.FetchMultipleRequest{
+		Metrics: []api.TaggedMetric{
+			{MetricKey: "cpu.user", TagSet: api.TagSet{"hostname": "b"}},
+			{MetricKey: "other", TagSet: api.TagSet{"host": "a"}},
+		},
+		RequestDetails: timeseries.RequestDetails{Timerange: timerange},
+	})
+	a.CheckError(err)
+	a.EqInt(len(list.Series), 2)
+	a.EqFloatArray(list.Series[0].Values, []float64{4, 5, 6}, 1e-9)
+	a.EqString(list.Series[0].TagSet.Serialize(), "hostname=b")
+	a.EqFloatArray(list.Series[1].Values, []float64{7, 8, 9}, 1e-9)
+	a.EqString(list.Series[1].TagSet.Serialize(), "host=a")
+
+	// Only the metadata lookup counts; the fetch resolves without counting.
+	a.EqInt(int(table.Usage()[0].Count), 1)
+}
+
+func TestStorageAPI_Aggregation(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 60000, 30000)
+	a.CheckError(err)
+	store := memory.NewStore(30 * time.Second)
+	for i, tagSet := range []api.TagSet{{"host": "a", "core": "0"}, {"host": "a", "core": "1"}, {"host": "b", "core": "2"}} {
+		value := float64(i + 1)
+		store.AddGenerated(api.TaggedMetric{MetricKey: "system.cpu", TagSet: tagSet}, func(time.Time) float64 { return value })
+	}
+	table, err := NewTable(map[api.MetricKey]Alias{
+		"cpu.user": {Target: "system.cpu", Tags: map[string]string{"hostname": "host"}},
+	})
+	a.CheckError(err)
+	storageAPI, ok := NewStorageAPI(store, table).(timeseries.AggregatingStorageAPI)
+	if !ok {
+		t.Fatalf("expected the wrapped API to aggregate series")
+	}
+	a.EqBool(storageAPI.SupportsAggregation(timeseries.AggregateSum), true)
+
+	list, err := storageAPI.FetchAggregatedTimeseries(timeseries.FetchAggregatedRequest{
+		Metrics: []api.TaggedMetric{
+			{MetricKey: "cpu.user", TagSet: api.TagSet{"hostname": "a", "core": "0"}},
+			{MetricKey: "cpu.user", TagSet: api.TagSet{"hostname": "a", "core": "1"}},
+			{MetricKey: "cpu.user", TagSet: api.TagSet{"hostname": "b", "core": "2"}},
+		},
+		Aggregation:    timeseries.AggregateSum,
+		GroupBy:        []string{"hostname"},
+		RequestDetails: timeseries.RequestDetails{Timerange: timerange},
+	})
+	a.CheckError(err)
+	sums := map[string]float64{}
+	for _, series := range list.Series {
+		sums[series.TagSet.Serialize()] = series.Values[0]
+	}
+	a.Eq(sums, map[string]float64{"hostname=a": 3, "hostname=b": 3})
+}
+
+func TestStorageAPI_Sketches(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 60000, 30000)
+	a.CheckError(err)
+	store := memory.NewStore(30 * time.Second)
+	table, err := NewTable(map[api.MetricKey]Alias{
+		"latency.old": {Target: "latency", Tags: map[string]string{"hostname": "host"}},
+	})
+	a.CheckError(err)
+	storageAPI, ok := NewStorageAPI(store, table).(timeseries.SketchStorageAPI)
+	if !ok {
+		t.Fatalf("expected the wrapped API to accept sketches")
+	}
+	sketch := tdigest.New(100)
+	for _, value := range []float64{1, 2, 3, 4} {
+		sketch.Add(value)
+	}
+	a.CheckError(storageAPI.AddSketch(api.TaggedMetric{MetricKey: "latency", TagSet: api.TagSet{"host": "a"}}, time.Unix(0, 0), sketch))
+
+	// The sketch is written under its real name, and fetched through the alias.
+	series, err := storageAPI.FetchSingleTimeseries(timeseries.FetchRequest{
+		Metric:         api.TaggedMetric{MetricKey: "latency.old", TagSet: api.TagSet{"hostname": "a"}},
+		RequestDetails: timeseries.RequestDetails{Timerange: timerange, SampleMethod: timeseries.SampleMean},
+	})
+	a.CheckError(err)
+	a.EqString(series.TagSet.Serialize(), "hostname=a")
+	if len(series.Sketches) == 0 || series.Sketches[0] == nil {
+		t.Fatalf("expected the sketch to be fetched through the alias, got %#v", series.Sketches)
+	}
+	a.EqFloat(series.Sketches[0].Count(), 4, 0)
+
+	if _, ok := NewStorageAPI(mocks.NewComboAPI(timerange), table).(timeseries.SketchStorageAPI); ok {
+		a.Errorf("expected the wrapper of storage without sketches not to accept them")
+	}
+}
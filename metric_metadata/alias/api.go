@@ -0,0 +1,257 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alias
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/log"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/timeseries"
+)
+
+// metricMetadataAPI answers metadata queries for aliased metrics using their targets.
+type metricMetadataAPI struct {
+	metricMetadataAPI metadata.MetricAPI
+	table             *Table
+}
+
+// metricUpdateAPI is a wrapper for when the underlying metadata.MetricAPI is also a metadata.MetricUpdateAPI.
+// Updates are never aliased: new data should always be written under its real name.
+type metricUpdateAPI struct {
+	metricMetadataAPI
+}
+
+func (a *metricUpdateAPI) AddMetric(ctx context.Context, metric api.TaggedMetric, context metadata.Context) error {
+	return a.metricMetadataAPI.metricMetadataAPI.(metadata.MetricUpdateAPI).AddMetric(ctx, metric, context)
+}
+
+func (a *metricUpdateAPI) AddMetrics(ctx context.Context, metrics []api.TaggedMetric, context metadata.Context) error {
+	return a.metricMetadataAPI.metricMetadataAPI.(metadata.MetricUpdateAPI).AddMetrics(ctx, metrics, context)
+}
+
+// NewMetricMetadataAPI wraps the given API so that aliased metric names are
+// transparently resolved using the table.
+func NewMetricMetadataAPI(apiInstance metadata.MetricAPI, table *Table) metadata.MetricAPI {
+	result := metricMetadataAPI{
+		metricMetadataAPI: apiInstance,
+		table:             table,
+	}
+	if _, ok := apiInstance.(metadata.MetricUpdateAPI); ok {
+		return &metricUpdateAPI{result}
+	}
+	return &result
+}
+
+// GetAllTags returns the tagsets of the alias target, with its tags renamed back to the old keys.
+func (a *metricMetadataAPI) GetAllTags(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+	alias, ok := a.table.Lookup(metricKey)
+	if !ok {
+		return a.metricMetadataAPI.GetAllTags(ctx, metricKey, context)
+	}
+	log.Debugf("Metric `%s` is an alias for `%s`", metricKey, alias.Target)
+	tagSets, err := a.metricMetadataAPI.GetAllTags(ctx, alias.Target, context)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]api.TagSet, len(tagSets))
+	for i, tagSet := range tagSets {
+		result[i] = alias.fromTarget(tagSet)
+	}
+	return result, nil
+}
+
+// GetAllMetrics returns the metrics of the underlying API. Aliases are not
+// included, so that old names are not suggested to new users.
+func (a *metricMetadataAPI) GetAllMetrics(ctx context.Context, context metadata.Context) ([]api.MetricKey, error) {
+	return a.metricMetadataAPI.GetAllMetrics(ctx, context)
+}
+
+// SearchMetrics searches the metrics of the underlying API. Like
+// GetAllMetrics, it does not include aliases.
+func (a *metricMetadataAPI) SearchMetrics(ctx context.Context, matcher *regexp.Regexp, context metadata.Context) ([]api.MetricKey, error) {
+	return metadata.SearchMetrics(ctx, a.metricMetadataAPI, matcher, context)
+}
+
+// GetTagKeys lists the tag keys of a metric. The keys of an alias are found
+// from the tagsets of its target, since its tags may be renamed.
+func (a *metricMetadataAPI) GetTagKeys(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]metadata.TagKey, error) {
+	if _, ok := a.table.Resolve(metricKey); ok {
+		tagsets, err := a.GetAllTags(ctx, metricKey, context)
+		if err != nil {
+			return nil, err
+		}
+		return metadata.SummarizeTagKeys(tagsets), nil
+	}
+	return metadata.GetTagKeys(ctx, a.metricMetadataAPI, metricKey, context)
+}
+
+// GetMetricsForTag returns the metrics of the underlying API.
+func (a *metricMetadataAPI) GetMetricsForTag(ctx context.Context, tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
+	return a.metricMetadataAPI.GetMetricsForTag(ctx, tagKey, tagValue, context)
+}
+
+// CheckHealthy checks if the underlying MetricAPI is healthy.
+func (a *metricMetadataAPI) CheckHealthy() error {
+	return a.metricMetadataAPI.CheckHealthy()
+}
+
+// storageAPI fetches aliased metrics using their targets.
+type storageAPI struct {
+	storageAPI timeseries.StorageAPI
+	table      *Table
+}
+
+// sketchStorageAPI is a wrapper for when the underlying timeseries.StorageAPI
+// is also a timeseries.SketchStorageAPI. Like updates, sketches are never
+// aliased: new data should always be written under its real name.
+type sketchStorageAPI struct {
+	storageAPI
+}
+
+func (a sketchStorageAPI) AddSketch(metric api.TaggedMetric, t time.Time, sketch *tdigest.Digest) error {
+	return a.storageAPI.storageAPI.(timeseries.SketchStorageAPI).AddSketch(metric, t, sketch)
+}
+
+// NewStorageAPI wraps the given API so that aliased metrics are transparently
+// fetched from their targets using the table.
+func NewStorageAPI(storage timeseries.StorageAPI, table *Table) timeseries.StorageAPI {
+	result := storageAPI{
+		storageAPI: storage,
+		table:      table,
+	}
+	if _, ok := storage.(timeseries.SketchStorageAPI); ok {
+		return sketchStorageAPI{result}
+	}
+	return result
+}
+
+// ChooseResolution defers to the underlying StorageAPI.
+func (a storageAPI) ChooseResolution(requested api.Timerange, lowerBound time.Duration) (time.Duration, error) {
+	return a.storageAPI.ChooseResolution(requested, lowerBound)
+}
+
+// FetchSingleTimeseries fetches the timeseries, using its alias target if it has one.
+func (a storageAPI) FetchSingleTimeseries(request timeseries.FetchRequest) (api.Timeseries, error) {
+	original := request.Metric
+	alias, ok := a.table.Resolve(original.MetricKey)
+	if !ok {
+		return a.storageAPI.FetchSingleTimeseries(request)
+	}
+	request.Metric = api.TaggedMetric{MetricKey: alias.Target, TagSet: alias.toTarget(original.TagSet)}
+	series, err := a.storageAPI.FetchSingleTimeseries(request)
+	if err != nil {
+		return api.Timeseries{}, err
+	}
+	series.TagSet = original.TagSet
+	return series, nil
+}
+
+// FetchMultipleTimeseries fetches the timeseries, using their alias targets where they have them.
+func (a storageAPI) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	original := request.Metrics
+	metrics := make([]api.TaggedMetric, len(original))
+	aliased := false
+	for i, metric := range original {
+		metrics[i] = metric
+		if alias, ok := a.table.Resolve(metric.MetricKey); ok {
+			metrics[i] = api.TaggedMetric{MetricKey: alias.Target, TagSet: alias.toTarget(metric.TagSet)}
+			aliased = true
+		}
+	}
+	if !aliased {
+		return a.storageAPI.FetchMultipleTimeseries(request)
+	}
+	request.Metrics = metrics
+	list, err := a.storageAPI.FetchMultipleTimeseries(request)
+	if err != nil {
+		return api.SeriesList{}, err
+	}
+	if len(list.Series) != len(original) {
+		return api.SeriesList{}, fmt.Errorf("fetched %d series for %d aliased metrics", len(list.Series), len(original))
+	}
+	// The series are returned in the order they were requested, so they can
+	// be given back the tags that they were requested with.
+	series := make([]api.Timeseries, len(list.Series))
+	for i := range list.Series {
+		series[i] = list.Series[i]
+		series[i].TagSet = original[i].TagSet
+	}
+	list.Series = series
+	return list, nil
+}
+
+var _ timeseries.AggregatingStorageAPI = storageAPI{}
+
+// SupportsAggregation defers to the underlying StorageAPI, if it can
+// aggregate series.
+func (a storageAPI) SupportsAggregation(aggregation timeseries.Aggregation) bool {
+	aggregating, ok := a.storageAPI.(timeseries.AggregatingStorageAPI)
+	return ok && aggregating.SupportsAggregation(aggregation)
+}
+
+// FetchAggregatedTimeseries aggregates the series, using their alias target if
+// they have one. The tags of the groups are renamed to and from the target's.
+func (a storageAPI) FetchAggregatedTimeseries(request timeseries.FetchAggregatedRequest) (api.SeriesList, error) {
+	aggregating, ok := a.storageAPI.(timeseries.AggregatingStorageAPI)
+	if !ok {
+		return api.SeriesList{}, fmt.Errorf("the storage can't aggregate series")
+	}
+	if len(request.Metrics) == 0 {
+		return aggregating.FetchAggregatedTimeseries(request)
+	}
+	name := request.Metrics[0].MetricKey
+	alias, ok := a.table.Resolve(name)
+	if !ok {
+		return aggregating.FetchAggregatedTimeseries(request)
+	}
+	metrics := make([]api.TaggedMetric, len(request.Metrics))
+	for i, metric := range request.Metrics {
+		if metric.MetricKey != name {
+			return api.SeriesList{}, fmt.Errorf("cannot aggregate the series of the alias %s with those of %s", name, metric.MetricKey)
+		}
+		metrics[i] = api.TaggedMetric{MetricKey: alias.Target, TagSet: alias.toTarget(metric.TagSet)}
+	}
+	groupBy := make([]string, len(request.GroupBy))
+	for i, key := range request.GroupBy {
+		groupBy[i] = key
+		if renamed, ok := alias.Tags[key]; ok {
+			groupBy[i] = renamed
+		}
+	}
+	request.Metrics = metrics
+	request.GroupBy = groupBy
+	list, err := aggregating.FetchAggregatedTimeseries(request)
+	if err != nil {
+		return api.SeriesList{}, err
+	}
+	series := make([]api.Timeseries, len(list.Series))
+	for i := range list.Series {
+		series[i] = list.Series[i]
+		series[i].TagSet = alias.fromTarget(list.Series[i].TagSet)
+	}
+	list.Series = series
+	return list, nil
+}
+
+// CheckHealthy checks if the underlying StorageAPI is healthy.
+func (a storageAPI) CheckHealthy() error {
+	return a.storageAPI.CheckHealthy()
+}
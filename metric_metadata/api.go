@@ -16,6 +16,10 @@
 package metadata
 
 import (
+	"context"
+	"regexp"
+	"sort"
+
 	"github.com/square/metrics/api"
 	"github.com/square/metrics/inspect"
 )
@@ -27,14 +31,43 @@ type Context struct {
 }
 
 // MetricAPI is an interface for obtaining metric metadata for indexing in MQE.
+// The ctx of each method cancels it, such as when the query which needs it
+// times out.
 type MetricAPI interface {
 	// GetAllTags takes a MetricKey and retrieves all the tagsets associated with it.
-	GetAllTags(metricKey api.MetricKey, context Context) ([]api.TagSet, error)
+	GetAllTags(ctx context.Context, metricKey api.MetricKey, context Context) ([]api.TagSet, error)
 	// GetAllMetrics returns all metrics managed by the system.
-	GetAllMetrics(context Context) ([]api.MetricKey, error)
+	GetAllMetrics(ctx context.Context, context Context) ([]api.MetricKey, error)
 	// GetMetricsForTag takes a tag key-value pair and returnsthe list of all the
 	// MetricKeys associated with them.
-	GetMetricsForTag(tagKey, tagValue string, context Context) ([]api.MetricKey, error)
+	GetMetricsForTag(ctx context.Context, tagKey, tagValue string, context Context) ([]api.MetricKey, error)
 	// CheckHealthy checks if this MetricAPI is healthy, returning a possible error
 	CheckHealthy() error
 }
+
+// MetricSearchAPI is implemented by MetricAPIs which can find the metrics whose
+// names match a regular expression without testing every metric.
+type MetricSearchAPI interface {
+	// SearchMetrics returns the sorted list of metrics matching the regular expression.
+	SearchMetrics(ctx context.Context, matcher *regexp.Regexp, context Context) ([]api.MetricKey, error)
+}
+
+// SearchMetrics returns the sorted list of metrics matching the regular
+// expression, using the API's index if it implements MetricSearchAPI.
+func SearchMetrics(ctx context.Context, metricAPI MetricAPI, matcher *regexp.Regexp, context Context) ([]api.MetricKey, error) {
+	if searchAPI, ok := metricAPI.(MetricSearchAPI); ok {
+		return searchAPI.SearchMetrics(ctx, matcher, context)
+	}
+	metrics, err := metricAPI.GetAllMetrics(ctx, context)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]api.MetricKey, 0, len(metrics))
+	for _, metric := range metrics {
+		if matcher.MatchString(string(metric)) {
+			filtered = append(filtered, metric)
+		}
+	}
+	sort.Sort(api.MetricKeys(filtered))
+	return filtered, nil
+}
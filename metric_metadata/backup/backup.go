@@ -0,0 +1,133 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backup copies the metric metadata index to a portable file and
+// back, for disaster recovery and for seeding other environments with the
+// metrics and tags of production.
+//
+// A backup is a stream of JSON values: a header, then one entry per metric
+// with all of its tag sets. Metrics are read and written one at a time, so
+// neither side holds the whole index in memory.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+)
+
+// Format identifies backup files in their header.
+const Format = "mqe-metadata"
+
+// Version is the version of the format written by Write.
+const Version = 1
+
+type header struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+type entry struct {
+	Metric  api.MetricKey `json:"metric"`
+	TagSets []api.TagSet  `json:"tag_sets"`
+}
+
+// Summary counts what was backed up or restored.
+type Summary struct {
+	Metrics int // the number of metrics
+	Series  int // the number of tag sets, over all metrics
+}
+
+// Write streams the index of the API to the writer.
+func Write(ctx context.Context, writer io.Writer, metricAPI metadata.MetricAPI, context metadata.Context) (Summary, error) {
+	summary := Summary{}
+	encoder := json.NewEncoder(writer)
+	if err := encoder.Encode(header{Format: Format, Version: Version}); err != nil {
+		return summary, err
+	}
+	metrics, err := metricAPI.GetAllMetrics(ctx, context)
+	if err != nil {
+		return summary, err
+	}
+	for _, metric := range metrics {
+		tagSets, err := metricAPI.GetAllTags(ctx, metric, context)
+		if err != nil {
+			return summary, fmt.Errorf("cannot read the tags of %s: %s", metric, err.Error())
+		}
+		if err := encoder.Encode(entry{Metric: metric, TagSets: tagSets}); err != nil {
+			return summary, err
+		}
+		summary.Metrics++
+		summary.Series += len(tagSets)
+	}
+	return summary, nil
+}
+
+// Restore adds the metrics of a backup to the API, at most batchSize series
+// at a time. Metrics already in the index are left as they are, so a
+// restore which fails part way may simply be run again.
+func Restore(ctx context.Context, reader io.Reader, updateAPI metadata.MetricUpdateAPI, batchSize int, context metadata.Context) (Summary, error) {
+	summary := Summary{}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	decoder := json.NewDecoder(reader)
+	var found header
+	if err := decoder.Decode(&found); err != nil {
+		return summary, fmt.Errorf("cannot read the header of the backup: %s", err.Error())
+	}
+	if found.Format != Format {
+		return summary, fmt.Errorf("not a metadata backup (format %q)", found.Format)
+	}
+	if found.Version > Version {
+		return summary, fmt.Errorf("the backup has version %d, but only versions up to %d can be read", found.Version, Version)
+	}
+
+	batch := make([]api.TaggedMetric, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := updateAPI.AddMetrics(ctx, batch, context); err != nil {
+			return err
+		}
+		summary.Series += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		var next entry
+		err := decoder.Decode(&next)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, fmt.Errorf("cannot read metric %d of the backup: %s", summary.Metrics+1, err.Error())
+		}
+		for _, tagSet := range next.TagSets {
+			batch = append(batch, api.TaggedMetric{MetricKey: next.Metric, TagSet: tagSet})
+			if len(batch) == batchSize {
+				if err := flush(); err != nil {
+					return summary, err
+				}
+			}
+		}
+		summary.Metrics++
+	}
+	return summary, flush()
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+// recordingAPI keeps the batches it's asked to add.
+type recordingAPI struct {
+	batches [][]api.TaggedMetric
+}
+
+func (r *recordingAPI) AddMetric(ctx context.Context, metric api.TaggedMetric, context metadata.Context) error {
+	return r.AddMetrics(ctx, []api.TaggedMetric{metric}, context)
+}
+
+func (r *recordingAPI) AddMetrics(ctx context.Context, metrics []api.TaggedMetric, context metadata.Context) error {
+	r.batches = append(r.batches, append([]api.TaggedMetric{}, metrics...))
+	return nil
+}
+
+func (r *recordingAPI) CheckHealthy() error {
+	return nil
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	a := assert.New(t)
+	source := mocks.NewFakeMetricMetadataAPI()
+	source.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": "a"}})
+	source.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": "b"}})
+	source.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "memory", TagSet: api.TagSet{"host": "a", "pool": "web"}})
+
+	buffer := &bytes.Buffer{}
+	summary, err := Write(context.Background(), buffer, source, metadata.Context{})
+	a.CheckError(err)
+	a.EqInt(summary.Metrics, 2)
+	a.EqInt(summary.Series, 3)
+
+	target := &recordingAPI{}
+	summary, err = Restore(context.Background(), buffer, target, 2, metadata.Context{})
+	a.CheckError(err)
+	a.EqInt(summary.Metrics, 2)
+	a.EqInt(summary.Series, 3)
+	a.EqInt(len(target.batches), 2)
+	a.EqInt(len(target.batches[0]), 2)
+
+	restored := map[string]bool{}
+	for _, batch := range target.batches {
+		for _, metric := range batch {
+			restored[string(metric.MetricKey)+metric.TagSet.Serialize()] = true
+		}
+	}
+	for _, metric := range []api.TaggedMetric{
+		{MetricKey: "cpu", TagSet: api.TagSet{"host": "a"}},
+		{MetricKey: "cpu", TagSet: api.TagSet{"host": "b"}},
+		{MetricKey: "memory", TagSet: api.TagSet{"host": "a", "pool": "web"}},
+	} {
+		a.Contextf("%s", metric.MetricKey).EqBool(restored[string(metric.MetricKey)+metric.TagSet.Serialize()], true)
+	}
+}
+
+func TestRestore_RejectsOtherFiles(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`{"format": "something-else", "version": 1}`,
+		`{"format": "mqe-metadata", "version": 99}`,
+		"{\"format\": \"mqe-metadata\", \"version\": 1}\n{\"metric\": ",
+	} {
+		if _, err := Restore(context.Background(), strings.NewReader(input), &recordingAPI{}, 10, metadata.Context{}); err == nil {
+			t.Errorf("expected an error restoring %q", input)
+		}
+	}
+}
@@ -15,7 +15,9 @@
 package cached
 
 import (
+	"context"
 	"errors"
+	"regexp"
 	"sync"
 	"time"
 
@@ -29,11 +31,28 @@ import (
 type BackgroundAPI interface {
 	metadata.MetricAPI
 	// GetBackgroundAction returns a function to be called to execute a background cache update.
-	GetBackgroundAction() func(metadata.Context) error
+	GetBackgroundAction() func(context.Context, metadata.Context) error
+	// RunBackground performs background cache updates as they're queued, until the context is done.
+	RunBackground(ctx context.Context) error
 	// CurrentLiveRequests returns the number of requests currently in the queue
 	CurrentLiveRequests() int
 	// MaximumLiveRequests returns the maximum number of requests that can be in the queue
 	MaximumLiveRequests() int
+	// IndexStats describes the index over metric names used to search metrics.
+	IndexStats() IndexStats
+}
+
+// IndexStats describes the builds and use of the index over metric names.
+type IndexStats struct {
+	Metrics           int           // The number of metrics in the index
+	Trigrams          int           // The number of distinct trigrams in the index
+	Builds            int           // The number of times the index has been built
+	BackgroundBuilds  int           // The number of builds performed in the background
+	BuildErrors       int           // The number of builds which failed
+	LastBuild         time.Time     // The time at which the index was last built
+	LastBuildDuration time.Duration // How long the last build took
+	Searches          int           // The number of searches using the index
+	Candidates        int           // The total number of metrics tested by searches
 }
 
 // metricMetadataAPI caches some of the metadata associated with the API to reduce latency.
@@ -47,13 +66,22 @@ type metricMetadataAPI struct {
 	getAllTagsCache      map[api.MetricKey]*TagSetList // The cache of metric -> tags
 	getAllTagsCacheMutex sync.RWMutex                  // Mutex for getAllTagsCache
 
+	// Metric name index
+	metricIndex         *trigramIndex // The index over all metric names, or nil before it is built
+	metricIndexExpiry   time.Time     // The time at which the index expires
+	metricIndexStale    time.Time     // The time at which the index becomes stale
+	metricIndexEnqueued bool          // Indicates a background rebuild has been enqueued
+	metricIndexStats    IndexStats    // Statistics for the index
+	metricIndexMutex    sync.Mutex    // Mutex for the fields above
+	metricIndexBuild    sync.Mutex    // Held while the index is being rebuilt
+
 	// Cache Config
 	freshness  time.Duration // How long until cache entries become stale
 	timeToLive time.Duration // How long until cache entries become expired
 
 	// Queue
-	backgroundQueue chan func(metadata.Context) error // A channel that holds background requests.
-	queueMutex      sync.Mutex                        // Synchronizing mutex for the queue
+	backgroundQueue chan func(context.Context, metadata.Context) error // A channel that holds background requests.
+	queueMutex      sync.Mutex                                         // Synchronizing mutex for the queue
 }
 
 // metricUpdateAPI is a wrapper for when the underlying metadata.MetricAPI is also a metadata.MetricUpdateAPI.
@@ -61,12 +89,12 @@ type metricUpdateAPI struct {
 	metricMetadataAPI
 }
 
-func (c *metricMetadataAPI) AddMetric(metric api.TaggedMetric, context metadata.Context) error {
-	return c.metricMetadataAPI.(metadata.MetricUpdateAPI).AddMetric(metric, context)
+func (c *metricMetadataAPI) AddMetric(ctx context.Context, metric api.TaggedMetric, context metadata.Context) error {
+	return c.metricMetadataAPI.(metadata.MetricUpdateAPI).AddMetric(ctx, metric, context)
 }
 
-func (c *metricMetadataAPI) AddMetrics(metrics []api.TaggedMetric, context metadata.Context) error {
-	return c.metricMetadataAPI.(metadata.MetricUpdateAPI).AddMetrics(metrics, context)
+func (c *metricMetadataAPI) AddMetrics(ctx context.Context, metrics []api.TaggedMetric, context metadata.Context) error {
+	return c.metricMetadataAPI.(metadata.MetricUpdateAPI).AddMetrics(ctx, metrics, context)
 }
 
 // Config stores data needed to instantiate a CachedMetricMetadataAPI.
@@ -89,11 +117,13 @@ type TagSetList struct {
 	wg       sync.WaitGroup // Synchronizing wait group
 
 	fetchError error // Fetch error from the last attempt
+
+	keys []metadata.TagKey // The summarized keys of TagSets, computed on demand
 }
 
 // NewMetricMetadataAPI creates a cached API given configuration and an underlying API object.
 func NewMetricMetadataAPI(apiInstance metadata.MetricAPI, config Config) BackgroundAPI {
-	requests := make(chan func(metadata.Context) error, config.RequestLimit)
+	requests := make(chan func(context.Context, metadata.Context) error, config.RequestLimit)
 	if config.Freshness == 0 {
 		config.Freshness = config.TimeToLive
 	}
@@ -140,7 +170,7 @@ func (c *metricMetadataAPI) addBackgroundGetAllTagsRequest(item *TagSetList, met
 	log.Infof("Enqueuing a background GetAllTags lookup for %s", metricKey)
 	item.enqueued = true
 
-	c.backgroundQueue <- func(context metadata.Context) error {
+	c.backgroundQueue <- func(ctx context.Context, context metadata.Context) error {
 		log.Infof("Executing the background GetAllTags lookup for %s", metricKey)
 		defer log.Infof("Finished the background GetAllTags lookup for %s", metricKey)
 
@@ -150,25 +180,40 @@ func (c *metricMetadataAPI) addBackgroundGetAllTagsRequest(item *TagSetList, met
 
 		defer context.Profiler.Record("CachedMetricMetadataAPI_BackgroundAction_GetAllTags")()
 
-		_, err := c.fetchAndUpdateCachedTagSet(item, metricKey, context)
+		_, err := c.fetchAndUpdateCachedTagSet(ctx, item, metricKey, context)
 		return err
 	}
 }
 
 // GetBackgroundAction is a blocking method that runs one queued cache update.
 // It will block until an update is available.
-func (c *metricMetadataAPI) GetBackgroundAction() func(metadata.Context) error {
+func (c *metricMetadataAPI) GetBackgroundAction() func(context.Context, metadata.Context) error {
 	return <-c.backgroundQueue
 }
 
+// RunBackground performs queued cache updates until the context is done.
+// Failed updates are logged, and don't stop it.
+func (c *metricMetadataAPI) RunBackground(ctx context.Context) error {
+	for {
+		select {
+		case action := <-c.backgroundQueue:
+			if err := action(ctx, metadata.Context{}); err != nil {
+				log.Errorf("Error performing background cache-update: %s", err.Error())
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 // GetAllMetrics waits for a slot to be open, then queries the underlying API.
-func (c *metricMetadataAPI) GetAllMetrics(context metadata.Context) ([]api.MetricKey, error) {
-	return c.metricMetadataAPI.GetAllMetrics(context)
+func (c *metricMetadataAPI) GetAllMetrics(ctx context.Context, context metadata.Context) ([]api.MetricKey, error) {
+	return c.metricMetadataAPI.GetAllMetrics(ctx, context)
 }
 
 // GetMetricsForTag wwaits for a slot to be open, then queries the underlying API.
-func (c *metricMetadataAPI) GetMetricsForTag(tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
-	return c.metricMetadataAPI.GetMetricsForTag(tagKey, tagValue, context)
+func (c *metricMetadataAPI) GetMetricsForTag(ctx context.Context, tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
+	return c.metricMetadataAPI.GetMetricsForTag(ctx, tagKey, tagValue, context)
 }
 
 // CheckHealthy checks if the underlying MetricAPI is healthy
@@ -176,10 +221,131 @@ func (c *metricMetadataAPI) CheckHealthy() error {
 	return c.metricMetadataAPI.CheckHealthy()
 }
 
+// SearchMetrics uses the cached index over metric names to find the metrics
+// matching the regular expression, testing only those which contain the
+// literal text it requires. If the index has expired it is rebuilt before the
+// search; if it is merely stale, it is rebuilt in the background.
+func (c *metricMetadataAPI) SearchMetrics(ctx context.Context, matcher *regexp.Regexp, context metadata.Context) ([]api.MetricKey, error) {
+	defer context.Profiler.Record("CachedMetricMetadataAPI_SearchMetrics")()
+
+	index, err := c.currentMetricIndex(ctx, context)
+	if err != nil {
+		return nil, err
+	}
+	result, candidates := index.search(matcher)
+
+	c.metricIndexMutex.Lock()
+	c.metricIndexStats.Searches++
+	c.metricIndexStats.Candidates += candidates
+	c.metricIndexMutex.Unlock()
+
+	return result, nil
+}
+
+// currentMetricIndex returns an unexpired index over metric names.
+func (c *metricMetadataAPI) currentMetricIndex(ctx context.Context, context metadata.Context) (*trigramIndex, error) {
+	c.metricIndexMutex.Lock()
+	index, expiry, stale := c.metricIndex, c.metricIndexExpiry, c.metricIndexStale
+	c.metricIndexMutex.Unlock()
+
+	if index == nil || expiry.Before(c.clock.Now()) {
+		c.metricIndexBuild.Lock()
+		defer c.metricIndexBuild.Unlock()
+
+		// Now that we hold the build mutex, make sure another goroutine hasn't
+		// already rebuilt the index
+		c.metricIndexMutex.Lock()
+		index, expiry = c.metricIndex, c.metricIndexExpiry
+		c.metricIndexMutex.Unlock()
+		if index != nil && !expiry.Before(c.clock.Now()) {
+			return index, nil
+		}
+
+		defer context.Profiler.Record("CachedMetricMetadataAPI_SearchMetrics_Expired")()
+		return c.rebuildMetricIndex(ctx, context, false)
+	}
+
+	if stale.Before(c.clock.Now()) {
+		c.addBackgroundMetricIndexRequest()
+	}
+	return index, nil
+}
+
+// rebuildMetricIndex fetches all metrics from the underlying API and indexes
+// them. Requires the caller hold metricIndexBuild.
+func (c *metricMetadataAPI) rebuildMetricIndex(ctx context.Context, context metadata.Context, background bool) (*trigramIndex, error) {
+	startTime := c.clock.Now()
+	metrics, err := c.metricMetadataAPI.GetAllMetrics(ctx, context)
+	if err != nil {
+		c.metricIndexMutex.Lock()
+		c.metricIndexStats.BuildErrors++
+		c.metricIndexMutex.Unlock()
+		return nil, err
+	}
+	index := newTrigramIndex(metrics)
+	finishTime := c.clock.Now()
+
+	c.metricIndexMutex.Lock()
+	c.metricIndex = index
+	c.metricIndexExpiry = startTime.Add(c.timeToLive)
+	c.metricIndexStale = startTime.Add(c.freshness)
+	c.metricIndexStats.Metrics = len(index.metrics)
+	c.metricIndexStats.Trigrams = len(index.postings)
+	c.metricIndexStats.Builds++
+	if background {
+		c.metricIndexStats.BackgroundBuilds++
+	}
+	c.metricIndexStats.LastBuild = finishTime
+	c.metricIndexStats.LastBuildDuration = finishTime.Sub(startTime)
+	c.metricIndexMutex.Unlock()
+
+	log.Infof("Built the metric name index over %d metrics with %d trigrams in %s", len(index.metrics), len(index.postings), finishTime.Sub(startTime))
+	return index, nil
+}
+
+// addBackgroundMetricIndexRequest adds a job to rebuild the index over metric names.
+func (c *metricMetadataAPI) addBackgroundMetricIndexRequest() {
+	c.queueMutex.Lock()
+	defer c.queueMutex.Unlock()
+
+	if cap(c.backgroundQueue) <= len(c.backgroundQueue) {
+		log.Warningf("Unable to enqueue a background rebuild of the metric name index due to a full queue")
+		return
+	}
+
+	c.metricIndexMutex.Lock()
+	defer c.metricIndexMutex.Unlock()
+	if c.metricIndexEnqueued {
+		return
+	}
+	c.metricIndexEnqueued = true
+
+	c.backgroundQueue <- func(ctx context.Context, context metadata.Context) error {
+		c.metricIndexBuild.Lock()
+		defer c.metricIndexBuild.Unlock()
+
+		c.metricIndexMutex.Lock()
+		c.metricIndexEnqueued = false
+		c.metricIndexMutex.Unlock()
+
+		defer context.Profiler.Record("CachedMetricMetadataAPI_BackgroundAction_MetricIndex")()
+
+		_, err := c.rebuildMetricIndex(ctx, context, true)
+		return err
+	}
+}
+
+// IndexStats describes the index over metric names.
+func (c *metricMetadataAPI) IndexStats() IndexStats {
+	c.metricIndexMutex.Lock()
+	defer c.metricIndexMutex.Unlock()
+	return c.metricIndexStats
+}
+
 // fetchAndUpdateCachedTagSet updates the in-memory cache (asusming the update
 // is newer than what is in the cache). Requires the caller hold the lock for the
 // item in the cache.
-func (c *metricMetadataAPI) fetchAndUpdateCachedTagSet(item *TagSetList, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+func (c *metricMetadataAPI) fetchAndUpdateCachedTagSet(ctx context.Context, item *TagSetList, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
 	if item == nil {
 		return nil, errors.New("missing cache list entry")
 	}
@@ -190,7 +356,7 @@ func (c *metricMetadataAPI) fetchAndUpdateCachedTagSet(item *TagSetList, metricK
 	item.Unlock()
 
 	startTime := c.clock.Now()
-	tagsets, err := c.metricMetadataAPI.GetAllTags(metricKey, context)
+	tagsets, err := c.metricMetadataAPI.GetAllTags(ctx, metricKey, context)
 
 	item.Lock()
 
@@ -207,6 +373,7 @@ func (c *metricMetadataAPI) fetchAndUpdateCachedTagSet(item *TagSetList, metricK
 	newExpiry := startTime.Add(c.timeToLive)
 	if item.Expiry.Before(newExpiry) {
 		item.TagSets = tagsets
+		item.keys = nil
 		item.Expiry = newExpiry
 		item.Stale = startTime.Add(c.freshness)
 	} else {
@@ -225,7 +392,7 @@ func (c *metricMetadataAPI) fetchAndUpdateCachedTagSet(item *TagSetList, metricK
 // to the underlying API to return to the caller. Even if the cache entry is
 // up-to-date, this method may enqueue a background request to the underlying API
 // to keep the cache fresh.
-func (c *metricMetadataAPI) GetAllTags(metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+func (c *metricMetadataAPI) GetAllTags(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
 	defer context.Profiler.Record("CachedMetricMetadataAPI_GetAllTags")()
 
 	// Get the cached result for this metric.
@@ -267,7 +434,7 @@ func (c *metricMetadataAPI) GetAllTags(metricKey api.MetricKey, context metadata
 		// We're going to execute this fetch now
 		defer context.Profiler.Record("CachedMetricMetadataAPI_GetAllTags_Expired")()
 
-		tagsets, err := c.fetchAndUpdateCachedTagSet(item, metricKey, context)
+		tagsets, err := c.fetchAndUpdateCachedTagSet(ctx, item, metricKey, context)
 		if err != nil {
 			defer context.Profiler.Record("CachedMetricMetadataAPI_GetAllTags_Errored")()
 			return nil, err
@@ -289,6 +456,28 @@ func (c *metricMetadataAPI) GetAllTags(metricKey api.MetricKey, context metadata
 	return item.TagSets, nil
 }
 
+// GetTagKeys lists the tag keys of a metric from its cached tagsets. The
+// summary is kept with the cache entry, so it is only recomputed when the
+// tagsets are refreshed.
+func (c *metricMetadataAPI) GetTagKeys(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]metadata.TagKey, error) {
+	tagsets, err := c.GetAllTags(ctx, metricKey, context)
+	if err != nil {
+		return nil, err
+	}
+	c.getAllTagsCacheMutex.RLock()
+	item, ok := c.getAllTagsCache[metricKey]
+	c.getAllTagsCacheMutex.RUnlock()
+	if !ok {
+		return metadata.SummarizeTagKeys(tagsets), nil
+	}
+	item.Lock()
+	defer item.Unlock()
+	if item.keys == nil {
+		item.keys = metadata.SummarizeTagKeys(item.TagSets)
+	}
+	return item.keys, nil
+}
+
 // CurrentLiveRequests returns the number of requests currently in the queue
 func (c *metricMetadataAPI) CurrentLiveRequests() int {
 	return len(c.backgroundQueue)
@@ -15,6 +15,7 @@
 package cached
 
 import (
+	"context"
 	"errors"
 	standard_log "log"
 	"os"
@@ -43,12 +44,12 @@ type testAPI struct {
 }
 
 // GetAllMetrics waits for a slot to be open, then queries the underlying API.
-func (c *testAPI) GetAllMetrics(context metadata.Context) ([]api.MetricKey, error) {
+func (c *testAPI) GetAllMetrics(ctx context.Context, context metadata.Context) ([]api.MetricKey, error) {
 	panic("unimplemented")
 }
 
 // GetMetricsForTag wwaits for a slot to be open, then queries the underlying API.
-func (c *testAPI) GetMetricsForTag(tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
+func (c *testAPI) GetMetricsForTag(ctx context.Context, tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
 	panic("unimplemented")
 }
 
@@ -57,7 +58,7 @@ func (c *testAPI) CheckHealthy() error {
 	panic("unimplemented")
 }
 
-func (c *testAPI) GetAllTags(metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+func (c *testAPI) GetAllTags(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
 	defer func() { c.finished <- string(metricKey) }()
 
 	// Signal we've been called and wait for permission to continue
@@ -78,6 +79,7 @@ func (c *testAPI) GetAllTags(metricKey api.MetricKey, context metadata.Context)
 }
 
 func TestCached(t *testing.T) {
+	ctx := context.Background()
 	log.InitLogger(&standard.Logger{
 		Logger: standard_log.New(os.Stderr, "", standard_log.LstdFlags),
 	})
@@ -102,27 +104,27 @@ func TestCached(t *testing.T) {
 	clock := mocks.NewTestClock(time.Now())
 	cached.clock = clock
 
-	tags, err := cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "one"}})
 
 	underlying.data["metric_one"] = "new one"
 
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "one"}}) // read from cache
 
 	// Advance the clock so the next call is stale
 	clock.Move(6 * time.Second)
 
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "one"}}) // still read from cache
 
 	a.MustEqInt(cached.CurrentLiveRequests(), 1)
-	a.CheckError(cached.GetBackgroundAction()(metadata.Context{})) // updates cache
+	a.CheckError(cached.GetBackgroundAction()(context.Background(), metadata.Context{})) // updates cache
 
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	// still read from cache, doesn't enqueue background since it's fresh
 	a.Eq(tags, []api.TagSet{{"foo": "new one"}})
@@ -130,7 +132,7 @@ func TestCached(t *testing.T) {
 	// Advance the clock so the next call is expired
 	clock.Move(11 * time.Second)
 
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "new one"}})
 
@@ -141,7 +143,7 @@ func TestCached(t *testing.T) {
 	// Advance the clock so the next call isn't stale yet
 	clock.Move(3 * time.Second)
 
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "new one"}})
 
@@ -150,23 +152,24 @@ func TestCached(t *testing.T) {
 	// Advance the clock so the next call is stale
 	clock.Move(3 * time.Second)
 
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "new one"}})
 
 	// Send another one to make sure we don't dupe the backend requests
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "new one"}})
 
 	a.MustEqInt(cached.CurrentLiveRequests(), 1)
 
-	a.CheckError(cached.GetBackgroundAction()(metadata.Context{})) // cleanout the channel
+	a.CheckError(cached.GetBackgroundAction()(context.Background(), metadata.Context{})) // cleanout the channel
 
 	a.MustEqInt(cached.CurrentLiveRequests(), 0)
 }
 
 func TestCachedNoStale(t *testing.T) {
+	ctx := context.Background()
 	log.InitLogger(&standard.Logger{
 		Logger: standard_log.New(os.Stderr, "", standard_log.LstdFlags),
 	})
@@ -190,20 +193,20 @@ func TestCachedNoStale(t *testing.T) {
 	clock := mocks.NewTestClock(time.Now())
 	cached.clock = clock
 
-	tags, err := cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "one"}})
 
 	underlying.data["metric_one"] = "new one"
 
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "one"}}) // read from cache
 
 	// Advance the clock so the next call is still fresh
 	clock.Move(6 * time.Second)
 
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "one"}}) // still read from cache
 
@@ -212,15 +215,55 @@ func TestCachedNoStale(t *testing.T) {
 	// Advance the clock so the next call is expired
 	clock.Move(5 * time.Second)
 
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "new one"}})
 
 	a.MustEqInt(cached.CurrentLiveRequests(), 0)
 }
 
+func TestCachedTagKeys(t *testing.T) {
+	ctx := context.Background()
+	a := assert.New(t)
+
+	underlying := &testAPI{
+		finished: make(chan string, 10),
+		data: map[api.MetricKey]string{
+			"metric_one": "one",
+		},
+	}
+	cached := NewMetricMetadataAPI(underlying, Config{
+		RequestLimit: 1000,
+		TimeToLive:   10 * time.Second,
+	}).(*metricMetadataAPI)
+	clock := mocks.NewTestClock(time.Now())
+	cached.clock = clock
+
+	keys, err := metadata.GetTagKeys(ctx, cached, "metric_one", metadata.Context{})
+	a.CheckError(err)
+	a.Eq(keys, []metadata.TagKey{{Key: "foo", Cardinality: 1}})
+	a.EqInt(underlying.count, 1)
+
+	keys, err = metadata.GetTagKeys(ctx, cached, "metric_one", metadata.Context{})
+	a.CheckError(err)
+	a.Eq(keys, []metadata.TagKey{{Key: "foo", Cardinality: 1}})
+	a.EqInt(underlying.count, 1) // read from cache
+
+	// Once the entry expires, the keys are summarized again.
+	underlying.data["metric_one"] = "new one"
+	clock.Move(11 * time.Second)
+	_, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
+	a.CheckError(err)
+	a.EqBool(cached.getAllTagsCache["metric_one"].keys == nil, true)
+	keys, err = metadata.GetTagKeys(ctx, cached, "metric_one", metadata.Context{})
+	a.CheckError(err)
+	a.Eq(keys, []metadata.TagKey{{Key: "foo", Cardinality: 1}})
+	a.EqInt(underlying.count, 2)
+}
+
 // Specific testing around when a request is already inflight
 func TestInflight(t *testing.T) {
+	ctx := context.Background()
 	log.InitLogger(&standard.Logger{
 		Logger: standard_log.New(os.Stderr, "", standard_log.LstdFlags),
 	})
@@ -259,7 +302,7 @@ func TestInflight(t *testing.T) {
 
 	// Routine One
 	go func() {
-		tags, err := cached.GetAllTags("metric_one", metadata.Context{})
+		tags, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 		a.CheckError(err)
 		a.Eq(tags, []api.TagSet{{"foo": "one"}})
 
@@ -273,7 +316,7 @@ func TestInflight(t *testing.T) {
 	goWgTwo.Add(1)
 	go func() {
 		goWgTwo.Done()
-		tags, err := cached.GetAllTags("metric_one", metadata.Context{})
+		tags, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 		a.CheckError(err)
 		a.Eq(tags, []api.TagSet{{"foo": "one"}})
 		goWgMain.Done()
@@ -305,7 +348,7 @@ func TestInflight(t *testing.T) {
 	goWgThree.Add(1)
 	go func() {
 		goWgThree.Done()
-		tags, err := cached.GetAllTags("metric_one", metadata.Context{})
+		tags, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 		a.CheckError(err)
 		a.Eq(tags, []api.TagSet{{"foo": "one"}})
 		goWgMain.Done()
@@ -324,6 +367,7 @@ func TestInflight(t *testing.T) {
 
 // Specific testing around when a request is already inflight and it errors
 func TestInflightError(t *testing.T) {
+	ctx := context.Background()
 	log.InitLogger(&standard.Logger{
 		Logger: standard_log.New(os.Stderr, "", standard_log.LstdFlags),
 	})
@@ -365,7 +409,7 @@ func TestInflightError(t *testing.T) {
 
 	// Routine One
 	go func() {
-		_, err := cached.GetAllTags("metric_one", metadata.Context{})
+		_, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 		routineOneError = err
 		goWgMain.Done()
 	}()
@@ -377,7 +421,7 @@ func TestInflightError(t *testing.T) {
 	goWgTwo.Add(1)
 	go func() {
 		goWgTwo.Done()
-		_, err := cached.GetAllTags("metric_one", metadata.Context{})
+		_, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 		routineTwoError = err
 		goWgMain.Done()
 	}()
@@ -423,7 +467,7 @@ func TestInflightError(t *testing.T) {
 	goWgThree.Add(1)
 	go func() {
 		goWgThree.Done()
-		tags, err := cached.GetAllTags("metric_one", metadata.Context{})
+		tags, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 		a.CheckError(err)
 		a.Eq(tags, []api.TagSet{{"foo": "one"}})
 		goWgMain.Done()
@@ -443,6 +487,7 @@ func TestInflightError(t *testing.T) {
 // Specific testing around when a request is already inflight and the requests
 // are stale
 func TestStaleInflight(t *testing.T) {
+	ctx := context.Background()
 	log.InitLogger(&standard.Logger{
 		Logger: standard_log.New(os.Stderr, "", standard_log.LstdFlags),
 	})
@@ -467,7 +512,7 @@ func TestStaleInflight(t *testing.T) {
 	clock := mocks.NewTestClock(time.Now())
 	cached.clock = clock
 
-	tags, err := cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "one"}})
 
@@ -487,7 +532,7 @@ func TestStaleInflight(t *testing.T) {
 	goWgMain.Add(1)
 
 	// This pulls from the cache but should enqueue a bg lookup
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "one"}})
 
@@ -502,7 +547,7 @@ func TestStaleInflight(t *testing.T) {
 
 	// Routine One
 	go func() {
-		a.CheckError(cached.GetBackgroundAction()(metadata.Context{}))
+		a.CheckError(cached.GetBackgroundAction()(context.Background(), metadata.Context{}))
 		goWgMain.Done()
 	}()
 
@@ -511,7 +556,7 @@ func TestStaleInflight(t *testing.T) {
 	a.MustEqInt(cached.CurrentLiveRequests(), 0)
 
 	// This pulls from the cache and should not enqueue a bg lookup
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "one"}})
 
@@ -523,12 +568,13 @@ func TestStaleInflight(t *testing.T) {
 	goWgMain.Wait()
 
 	// Make another call, the cache is now fresh
-	tags, err = cached.GetAllTags("metric_one", metadata.Context{})
+	tags, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 	a.Eq(tags, []api.TagSet{{"foo": "new one"}})
 }
 
 func TestQueueSize(t *testing.T) {
+	ctx := context.Background()
 	log.InitLogger(&standard.Logger{
 		Logger: standard_log.New(os.Stderr, "", standard_log.LstdFlags),
 	})
@@ -556,31 +602,31 @@ func TestQueueSize(t *testing.T) {
 	cached.clock = clock
 
 	// Prime the cache
-	_, err := cached.GetAllTags("metric_one", metadata.Context{})
+	_, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 
-	_, err = cached.GetAllTags("metric_two", metadata.Context{})
+	_, err = cached.GetAllTags(ctx, "metric_two", metadata.Context{})
 	a.CheckError(err)
 
 	// Advance the clock so that metric_one and metric_two are stale
 	clock.Move(6 * time.Second)
 
 	// Stale entries
-	_, err = cached.GetAllTags("metric_one", metadata.Context{})
+	_, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{})
 	a.CheckError(err)
 
-	_, err = cached.GetAllTags("metric_two", metadata.Context{})
+	_, err = cached.GetAllTags(ctx, "metric_two", metadata.Context{})
 	a.CheckError(err)
 
 	a.MustEqInt(cached.CurrentLiveRequests(), 2)
 
-	_, err = cached.GetAllTags("metric_three", metadata.Context{})
+	_, err = cached.GetAllTags(ctx, "metric_three", metadata.Context{})
 	a.CheckError(err)
 
 	// Advance the clock so that metric_three is stale
 	clock.Move(6 * time.Second)
 
-	_, err = cached.GetAllTags("metric_three", metadata.Context{})
+	_, err = cached.GetAllTags(ctx, "metric_three", metadata.Context{})
 	a.CheckError(err)
 
 	a.MustEqInt(cached.CurrentLiveRequests(), 3)
@@ -588,21 +634,57 @@ func TestQueueSize(t *testing.T) {
 	// Adding another one should not increase the number of requests,
 	// and it shouldn't cause this call to block.
 
-	_, err = cached.GetAllTags("metric_four", metadata.Context{})
+	_, err = cached.GetAllTags(ctx, "metric_four", metadata.Context{})
 	a.CheckError(err)
 
 	// Advance the clock so that metric_four is stale
 	clock.Move(6 * time.Second)
 
 	for i := 0; i < 100; i++ {
-		_, err = cached.GetAllTags("metric_four", metadata.Context{})
+		_, err = cached.GetAllTags(ctx, "metric_four", metadata.Context{})
 		a.CheckError(err)
 		a.MustEqInt(cached.CurrentLiveRequests(), 3)
 	}
 
-	a.CheckError(cached.GetBackgroundAction()(metadata.Context{}))
-	a.CheckError(cached.GetBackgroundAction()(metadata.Context{}))
-	a.CheckError(cached.GetBackgroundAction()(metadata.Context{}))
+	a.CheckError(cached.GetBackgroundAction()(context.Background(), metadata.Context{}))
+	a.CheckError(cached.GetBackgroundAction()(context.Background(), metadata.Context{}))
+	a.CheckError(cached.GetBackgroundAction()(context.Background(), metadata.Context{}))
 
 	a.MustEqInt(cached.CurrentLiveRequests(), 0)
 }
+
+func TestCachedRunBackground(t *testing.T) {
+	ctx := context.Background()
+	a := assert.New(t)
+	underlying := &testAPI{
+		finished: make(chan string, 10),
+		data:     map[api.MetricKey]string{"metric_one": "one"},
+	}
+	cached := NewMetricMetadataAPI(underlying, Config{
+		Freshness:    5 * time.Second,
+		RequestLimit: 1000,
+		TimeToLive:   10 * time.Second,
+	}).(*metricMetadataAPI)
+	clock := mocks.NewTestClock(time.Now())
+	cached.clock = clock
+
+	_, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
+	a.CheckError(err)
+	<-underlying.finished
+	underlying.data["metric_one"] = "new one"
+	clock.Move(6 * time.Second)
+	_, err = cached.GetAllTags(ctx, "metric_one", metadata.Context{}) // stale, so queues an update
+	a.CheckError(err)
+	a.MustEqInt(cached.CurrentLiveRequests(), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- cached.RunBackground(ctx) }()
+	<-underlying.finished // the queued update was performed
+	cancel()
+	a.Eq(<-stopped, context.Canceled)
+
+	tags, err := cached.GetAllTags(ctx, "metric_one", metadata.Context{})
+	a.CheckError(err)
+	a.Eq(tags, []api.TagSet{{"foo": "new one"}})
+}
@@ -0,0 +1,221 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cached
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+
+	"github.com/square/metrics/api"
+)
+
+// maxAlternatives bounds the number of alternative literal sets considered
+// for a single regular expression, beyond which it is treated as unconstrained.
+const maxAlternatives = 16
+
+// trigramIndex maps each three-byte substring of the metric names to the
+// metrics containing it, so that a regular expression only needs to be tested
+// against metrics containing the literal text it requires.
+type trigramIndex struct {
+	metrics  []api.MetricKey    // sorted metric names
+	postings map[string][]int32 // trigram => ascending indices into metrics
+}
+
+// trigrams lists the three-byte substrings of the text.
+func trigrams(text string) []string {
+	result := []string{}
+	for i := 0; i+3 <= len(text); i++ {
+		result = append(result, text[i:i+3])
+	}
+	return result
+}
+
+func newTrigramIndex(metrics []api.MetricKey) *trigramIndex {
+	sorted := append([]api.MetricKey(nil), metrics...)
+	sort.Sort(api.MetricKeys(sorted))
+	postings := map[string][]int32{}
+	for i, metric := range sorted {
+		for _, trigram := range trigrams(string(metric)) {
+			list := postings[trigram]
+			if len(list) != 0 && list[len(list)-1] == int32(i) {
+				continue // the trigram occurs more than once in this metric
+			}
+			postings[trigram] = append(list, int32(i))
+		}
+	}
+	return &trigramIndex{metrics: sorted, postings: postings}
+}
+
+// search returns the sorted list of metrics matching the regular expression,
+// along with the number of candidates which had to be tested.
+func (index *trigramIndex) search(matcher *regexp.Regexp) ([]api.MetricKey, int) {
+	candidates := index.candidates(matcher)
+	result := []api.MetricKey{}
+	for _, i := range candidates {
+		if matcher.MatchString(string(index.metrics[i])) {
+			result = append(result, index.metrics[i])
+		}
+	}
+	return result, len(candidates)
+}
+
+// candidates lists, in ascending order, the indices of the metrics which may
+// match the regular expression.
+func (index *trigramIndex) candidates(matcher *regexp.Regexp) []int32 {
+	all := func() []int32 {
+		result := make([]int32, len(index.metrics))
+		for i := range result {
+			result[i] = int32(i)
+		}
+		return result
+	}
+	parsed, err := syntax.Parse(matcher.String(), syntax.Perl)
+	if err != nil {
+		return all()
+	}
+	alternatives, ok := requiredLiterals(parsed.Simplify())
+	if !ok {
+		return all()
+	}
+	result := []int32{}
+	for _, literals := range alternatives {
+		matches, constrained := index.containingAll(literals)
+		if !constrained {
+			return all()
+		}
+		result = union(result, matches)
+	}
+	return result
+}
+
+// containingAll lists the metrics containing every trigram of the literals.
+// If the literals are too short to have any trigrams, constrained is false.
+func (index *trigramIndex) containingAll(literals []string) (matches []int32, constrained bool) {
+	for _, literal := range literals {
+		for _, trigram := range trigrams(literal) {
+			list := index.postings[trigram]
+			if !constrained {
+				matches = list
+				constrained = true
+				continue
+			}
+			matches = intersect(matches, list)
+		}
+	}
+	return matches, constrained
+}
+
+// intersect merges two ascending lists.
+func intersect(left []int32, right []int32) []int32 {
+	result := []int32{}
+	for len(left) > 0 && len(right) > 0 {
+		switch {
+		case left[0] < right[0]:
+			left = left[1:]
+		case left[0] > right[0]:
+			right = right[1:]
+		default:
+			result = append(result, left[0])
+			left, right = left[1:], right[1:]
+		}
+	}
+	return result
+}
+
+// union merges two ascending lists, removing duplicates.
+func union(left []int32, right []int32) []int32 {
+	result := make([]int32, 0, len(left)+len(right))
+	for len(left) > 0 || len(right) > 0 {
+		switch {
+		case len(right) == 0 || (len(left) > 0 && left[0] < right[0]):
+			result = append(result, left[0])
+			left = left[1:]
+		case len(left) == 0 || right[0] < left[0]:
+			result = append(result, right[0])
+			right = right[1:]
+		default:
+			result = append(result, left[0])
+			left, right = left[1:], right[1:]
+		}
+	}
+	return result
+}
+
+// requiredLiterals describes the text which a string must contain in order to
+// match the expression, as alternative sets of literals. A matching string
+// contains every literal of at least one of the sets. If ok is false, the
+// expression places no requirements on the text.
+func requiredLiterals(re *syntax.Regexp) (alternatives [][]string, ok bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return [][]string{{string(re.Rune)}}, true
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpAlternate:
+		result := [][]string{}
+		for _, sub := range re.Sub {
+			subAlternatives, ok := requiredLiterals(sub)
+			if !ok {
+				return nil, false
+			}
+			result = append(result, subAlternatives...)
+		}
+		if len(result) > maxAlternatives {
+			return nil, false
+		}
+		return result, true
+	case syntax.OpConcat:
+		result := [][]string{{}}
+		run := ""
+		flush := func() {
+			if run == "" {
+				return
+			}
+			for i := range result {
+				result[i] = append(result[i], run)
+			}
+			run = ""
+		}
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral && sub.Flags&syntax.FoldCase == 0 {
+				run += string(sub.Rune)
+				continue
+			}
+			flush()
+			subAlternatives, ok := requiredLiterals(sub)
+			if !ok || len(result)*len(subAlternatives) > maxAlternatives {
+				continue // ignoring a requirement is always safe
+			}
+			product := [][]string{}
+			for _, literals := range result {
+				for _, subLiterals := range subAlternatives {
+					product = append(product, append(append([]string{}, literals...), subLiterals...))
+				}
+			}
+			result = product
+		}
+		flush()
+		return result, true
+	}
+	return nil, false
+}
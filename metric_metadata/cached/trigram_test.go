@@ -0,0 +1,155 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cached
+
+import (
+	"context"
+	"regexp"
+	"regexp/syntax"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+var indexedMetrics = []api.MetricKey{
+	"cpu.user", "cpu.system", "cpu.idle", "memory.free", "memory.used",
+	"disk.io.read", "disk.io.write", "network.bytes_in", "network.bytes_out",
+	"app.requests.count", "app.requests.latency", "CPU.legacy", "ab", "",
+}
+
+func TestRequiredLiterals(t *testing.T) {
+	for _, test := range []struct {
+		regex    string
+		expected [][]string
+		ok       bool
+	}{
+		{"cpu", [][]string{{"cpu"}}, true},
+		{"^cpu\\.", [][]string{{"cpu."}}, true},
+		{"cpu.*idle", [][]string{{"cpu", "idle"}}, true},
+		{"(read|write)$", [][]string{{"read"}, {"write"}}, true},
+		{"disk\\.(read|write)", [][]string{{"disk.", "read"}, {"disk.", "write"}}, true},
+		{"(?i)cpu", nil, false},
+		{"x*", nil, false},
+		{"(cpu|.*)", nil, false},
+		{"", nil, false},
+	} {
+		a := assert.New(t).Contextf("%s", test.regex)
+		parsed, err := syntax.Parse(test.regex, syntax.Perl)
+		a.CheckError(err)
+		alternatives, ok := requiredLiterals(parsed.Simplify())
+		a.EqBool(ok, test.ok)
+		a.Eq(alternatives, test.expected)
+	}
+}
+
+func TestTrigramIndex(t *testing.T) {
+	index := newTrigramIndex(indexedMetrics)
+	for _, regex := range []string{
+		"", "cpu", "^cpu\\.", "(?i)cpu", "io\\.(read|write)$", "bytes_(in|out)",
+		"requests.*latency", "nothing", "a", "ab", "mem(ory)?\\.free", "u.e",
+	} {
+		a := assert.New(t).Contextf("%s", regex)
+		matcher := regexp.MustCompile(regex)
+		expected := []api.MetricKey{}
+		for _, metric := range index.metrics {
+			if matcher.MatchString(string(metric)) {
+				expected = append(expected, metric)
+			}
+		}
+		actual, candidates := index.search(matcher)
+		a.Eq(actual, expected)
+		if candidates < len(expected) || candidates > len(index.metrics) {
+			a.Errorf("unexpected number of candidates %d", candidates)
+		}
+	}
+	_, candidates := index.search(regexp.MustCompile("^disk\\.io\\.write$"))
+	assert.New(t).EqInt(candidates, 1)
+}
+
+type listAPI struct {
+	metrics []api.MetricKey
+	calls   int
+}
+
+func (l *listAPI) GetAllMetrics(ctx context.Context, context metadata.Context) ([]api.MetricKey, error) {
+	l.calls++
+	return l.metrics, nil
+}
+
+func (l *listAPI) GetAllTags(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+	panic("unimplemented")
+}
+
+func (l *listAPI) GetMetricsForTag(ctx context.Context, tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
+	panic("unimplemented")
+}
+
+func (l *listAPI) CheckHealthy() error {
+	panic("unimplemented")
+}
+
+func TestSearchMetrics(t *testing.T) {
+	ctx := context.Background()
+	a := assert.New(t)
+	underlying := &listAPI{metrics: indexedMetrics}
+	cached := NewMetricMetadataAPI(underlying, Config{
+		RequestLimit: 10,
+		Freshness:    5 * time.Second,
+		TimeToLive:   10 * time.Second,
+	}).(*metricMetadataAPI)
+	clock := mocks.NewTestClock(time.Now())
+	cached.clock = clock
+
+	result, err := metadata.SearchMetrics(ctx, cached, regexp.MustCompile("^cpu"), metadata.Context{})
+	a.CheckError(err)
+	a.Eq(result, []api.MetricKey{"cpu.idle", "cpu.system", "cpu.user"})
+	a.EqInt(underlying.calls, 1)
+
+	// While fresh, the index is reused.
+	underlying.metrics = append(underlying.metrics, "cpu.steal")
+	result, err = cached.SearchMetrics(ctx, regexp.MustCompile("^cpu"), metadata.Context{})
+	a.CheckError(err)
+	a.EqInt(len(result), 3)
+	a.EqInt(underlying.calls, 1)
+	a.EqInt(cached.CurrentLiveRequests(), 0)
+
+	// Once stale, the index is rebuilt in the background.
+	clock.Move(6 * time.Second)
+	result, err = cached.SearchMetrics(ctx, regexp.MustCompile("^cpu"), metadata.Context{})
+	a.CheckError(err)
+	a.EqInt(len(result), 3)
+	a.EqInt(cached.CurrentLiveRequests(), 1)
+	a.CheckError(cached.GetBackgroundAction()(context.Background(), metadata.Context{}))
+	result, err = cached.SearchMetrics(ctx, regexp.MustCompile("^cpu"), metadata.Context{})
+	a.CheckError(err)
+	a.EqInt(len(result), 4)
+
+	// Once expired, the index is rebuilt before searching.
+	underlying.metrics = append(underlying.metrics, "cpu.nice")
+	clock.Move(11 * time.Second)
+	result, err = cached.SearchMetrics(ctx, regexp.MustCompile("^cpu"), metadata.Context{})
+	a.CheckError(err)
+	a.EqInt(len(result), 5)
+
+	stats := cached.IndexStats()
+	a.EqInt(stats.Builds, 3)
+	a.EqInt(stats.BackgroundBuilds, 1)
+	a.EqInt(stats.Searches, 5)
+	a.EqInt(stats.Metrics, len(indexedMetrics)+2)
+}
@@ -15,10 +15,14 @@
 package cassandra
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/square/metrics/api"
+	"github.com/square/metrics/inspect"
 	"github.com/square/metrics/metric_metadata"
 )
 
@@ -28,6 +32,7 @@ type MetricMetadataAPI struct {
 
 var _ metadata.MetricAPI = (*MetricMetadataAPI)(nil)
 var _ metadata.MetricUpdateAPI = (*MetricMetadataAPI)(nil)
+var _ metadata.MetricKeysAPI = (*MetricMetadataAPI)(nil)
 
 type Config struct {
 	Hosts    []string `yaml:"hosts"`
@@ -50,48 +55,67 @@ func NewMetricMetadataAPI(config Config) (*MetricMetadataAPI, error) {
 	}, nil
 }
 
-func (a *MetricMetadataAPI) AddMetric(metric api.TaggedMetric, context metadata.Context) error {
+func (a *MetricMetadataAPI) AddMetric(ctx context.Context, metric api.TaggedMetric, context metadata.Context) error {
 	defer context.Profiler.Record("Cassandra AddMetric")()
-	if err := a.db.AddMetricName(metric.MetricKey, metric.TagSet); err != nil {
+	if err := a.db.AddMetricName(ctx, metric.MetricKey, metric.TagSet); err != nil {
 		return err
 	}
-	return a.AddMetricTagsToTagIndex(metric, context)
+	return a.AddMetricTagsToTagIndex(ctx, metric, context)
 }
-func (a *MetricMetadataAPI) AddMetricTagsToTagIndex(metric api.TaggedMetric, context metadata.Context) error {
+func (a *MetricMetadataAPI) AddMetricTagsToTagIndex(ctx context.Context, metric api.TaggedMetric, context metadata.Context) error {
 	defer context.Profiler.Record("Cassandra AddMetricTagsToTagIndex")()
 	for tagKey, tagValue := range metric.TagSet {
-		if err := a.db.AddToTagIndex(tagKey, tagValue, metric.MetricKey); err != nil {
+		if err := a.db.AddToTagIndex(ctx, tagKey, tagValue, metric.MetricKey); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (a *MetricMetadataAPI) AddMetrics(metrics []api.TaggedMetric, context metadata.Context) error {
+func (a *MetricMetadataAPI) AddMetrics(ctx context.Context, metrics []api.TaggedMetric, context metadata.Context) error {
 	defer context.Profiler.Record("Cassandra AddMetrics")()
 	// Add each of the metrics to the tag index
 	for _, metric := range metrics {
-		err := a.AddMetricTagsToTagIndex(metric, context)
+		err := a.AddMetricTagsToTagIndex(ctx, metric, context)
 		if err != nil {
 			return err
 		}
 	}
-	return a.db.AddMetricNames(metrics)
+	return a.db.AddMetricNames(ctx, metrics)
 }
 
-func (a *MetricMetadataAPI) GetAllTags(metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+func (a *MetricMetadataAPI) GetAllTags(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
 	defer context.Profiler.Record("Cassandra GetAllTags")()
-	return a.db.GetTagSet(metricKey)
+	return a.db.GetTagSet(ctx, metricKey, context.Profiler)
 }
 
-func (a *MetricMetadataAPI) GetMetricsForTag(tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
+// GetTagKeys reads the tag keys of the metric from the metric_tag_keys
+// projection of the tag index. Metrics indexed before the projection existed
+// have no rows there, so their tagsets are summarized instead.
+func (a *MetricMetadataAPI) GetTagKeys(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]metadata.TagKey, error) {
+	defer context.Profiler.Record("Cassandra GetTagKeys")()
+	keys, err := a.db.GetTagKeys(ctx, metricKey, context.Profiler)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		return keys, nil
+	}
+	tagsets, err := a.db.GetTagSet(ctx, metricKey, context.Profiler)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.SummarizeTagKeys(tagsets), nil
+}
+
+func (a *MetricMetadataAPI) GetMetricsForTag(ctx context.Context, tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
 	defer context.Profiler.Record("Cassandra GetMetricsForTag")()
-	return a.db.GetMetricKeys(tagKey, tagValue)
+	return a.db.GetMetricKeys(ctx, tagKey, tagValue, context.Profiler)
 }
 
-func (a *MetricMetadataAPI) GetAllMetrics(context metadata.Context) ([]api.MetricKey, error) {
+func (a *MetricMetadataAPI) GetAllMetrics(ctx context.Context, context metadata.Context) ([]api.MetricKey, error) {
 	defer context.Profiler.Record("Cassandra GetAllMetrics")()
-	return a.db.GetAllMetrics()
+	return a.db.GetAllMetrics(ctx, context.Profiler)
 }
 
 // CheckHealthy checks if the underlying connection to Cassandra is healthy
@@ -115,11 +139,11 @@ func newCassandraDatabase(clusterConfig *gocql.ClusterConfig) (cassandraDatabase
 }
 
 // AddMetricName inserts the metric to Cassandra.
-func (db *cassandraDatabase) AddMetricName(metricKey api.MetricKey, tagSet api.TagSet) error {
-	if err := db.session.Query("INSERT INTO metric_names (metric_key, tag_set) VALUES (?, ?)", metricKey, tagSet.Serialize()).Exec(); err != nil {
+func (db *cassandraDatabase) AddMetricName(ctx context.Context, metricKey api.MetricKey, tagSet api.TagSet) error {
+	if err := withContext(db.session.Query("INSERT INTO metric_names (metric_key, tag_set) VALUES (?, ?)", metricKey, tagSet.Serialize()), ctx).Exec(); err != nil {
 		return err
 	}
-	if err := db.session.Query("UPDATE metric_name_set SET metric_names = metric_names + ? WHERE shard = ?", []string{string(metricKey)}, 0).Exec(); err != nil {
+	if err := withContext(db.session.Query("UPDATE metric_name_set SET metric_names = metric_names + ? WHERE shard = ?", []string{string(metricKey)}, 0), ctx).Exec(); err != nil {
 		return err
 	}
 	return nil
@@ -127,7 +151,7 @@ func (db *cassandraDatabase) AddMetricName(metricKey api.MetricKey, tagSet api.T
 }
 
 // AddMetricNames adds many metric names to Cassandra (equivalent to calling AddMetricName many times, but more performant)
-func (db *cassandraDatabase) AddMetricNames(metrics []api.TaggedMetric) error {
+func (db *cassandraDatabase) AddMetricNames(ctx context.Context, metrics []api.TaggedMetric) error {
 	queryInsert := "INSERT INTO metric_names (metric_key, tag_set) VALUES (?, ?)"
 	queryUpdate := "UPDATE metric_name_set SET metric_names = metric_names + ? WHERE shard = ?"
 
@@ -140,7 +164,7 @@ func (db *cassandraDatabase) AddMetricNames(metrics []api.TaggedMetric) error {
 			}, nil
 		})
 		boundQuery.Consistency(gocql.One)
-		err := boundQuery.Exec()
+		err := withContext(boundQuery, ctx).Exec()
 		if err != nil {
 			return err
 		}
@@ -152,7 +176,7 @@ func (db *cassandraDatabase) AddMetricNames(metrics []api.TaggedMetric) error {
 			}, nil
 		})
 		boundQuery.Consistency(gocql.One)
-		err = boundQuery.Exec()
+		err = withContext(boundQuery, ctx).Exec()
 		if err != nil {
 			return err
 		}
@@ -161,30 +185,43 @@ func (db *cassandraDatabase) AddMetricNames(metrics []api.TaggedMetric) error {
 	return nil
 }
 
-func (db *cassandraDatabase) AddToTagIndex(tagKey string, tagValue string, metricKey api.MetricKey) error {
-	err := db.session.Query(
+// AddToTagIndex adds the metric to the tag index, and the tag to the
+// metric_tag_keys projection of the index.
+func (db *cassandraDatabase) AddToTagIndex(ctx context.Context, tagKey string, tagValue string, metricKey api.MetricKey) error {
+	err := withContext(db.session.Query(
 		"UPDATE tag_index SET metric_keys = metric_keys + ? WHERE tag_key = ? AND tag_value = ?",
 		[]string{string(metricKey)},
 		tagKey,
 		tagValue,
-	).Exec()
-	return err
+	), ctx).Exec()
+	if err != nil {
+		return err
+	}
+	return withContext(db.session.Query(
+		"UPDATE metric_tag_keys SET tag_values = tag_values + ? WHERE metric_key = ? AND tag_key = ?",
+		[]string{tagValue},
+		metricKey,
+		tagKey,
+	), ctx).Exec()
 }
 
-func (db *cassandraDatabase) GetTagSet(metricKey api.MetricKey) ([]api.TagSet, error) {
+func (db *cassandraDatabase) GetTagSet(ctx context.Context, metricKey api.MetricKey, profiler *inspect.Profiler) ([]api.TagSet, error) {
 	var tags []api.TagSet
 	rawTag := ""
-	iterator := db.session.Query(
-		"SELECT tag_set FROM metric_names WHERE metric_key = ?",
-		metricKey,
-	).Iter()
+	statement := "SELECT tag_set FROM metric_names WHERE metric_key = ?"
+	start := time.Now()
+	iterator := withContext(db.session.Query(statement, metricKey), ctx).Iter()
+	rows := 0
 	for iterator.Scan(&rawTag) {
+		rows++
 		parsedTagSet := api.ParseTagSet(rawTag)
 		if parsedTagSet != nil {
 			tags = append(tags, parsedTagSet)
 		}
 	}
-	if err := iterator.Close(); err != nil {
+	err := iterator.Close()
+	recordStatement(profiler, statement, []interface{}{metricKey}, start, rows, err)
+	if err != nil {
 		return nil, err
 	}
 	if len(tags) == 0 {
@@ -194,25 +231,49 @@ func (db *cassandraDatabase) GetTagSet(metricKey api.MetricKey) ([]api.TagSet, e
 	return tags, nil
 }
 
-func (db *cassandraDatabase) GetMetricKeys(tagKey string, tagValue string) ([]api.MetricKey, error) {
+func (db *cassandraDatabase) GetMetricKeys(ctx context.Context, tagKey string, tagValue string, profiler *inspect.Profiler) ([]api.MetricKey, error) {
 	var keys []api.MetricKey
-	err := db.session.Query(
-		"SELECT metric_keys FROM tag_index WHERE tag_key = ? AND tag_value = ?",
-		tagKey,
-		tagValue,
-	).Scan(&keys)
+	statement := "SELECT metric_keys FROM tag_index WHERE tag_key = ? AND tag_value = ?"
+	start := time.Now()
+	err := withContext(db.session.Query(statement, tagKey, tagValue), ctx).Scan(&keys)
 	if err == gocql.ErrNotFound {
+		recordStatement(profiler, statement, []interface{}{tagKey, tagValue}, start, 0, nil)
 		return keys, nil
 	}
+	recordStatement(profiler, statement, []interface{}{tagKey, tagValue}, start, 1, err)
 	if err != nil {
 		return nil, err
 	}
 	return keys, nil
 }
 
-func (db *cassandraDatabase) GetAllMetrics() ([]api.MetricKey, error) {
+// GetTagKeys lists the tag keys of the metric, sorted by key, with the number
+// of values each has been indexed with. Values are never removed from the
+// projection, so the cardinalities may overcount.
+func (db *cassandraDatabase) GetTagKeys(ctx context.Context, metricKey api.MetricKey, profiler *inspect.Profiler) ([]metadata.TagKey, error) {
+	keys := []metadata.TagKey{}
+	tagKey := ""
+	var tagValues []string
+	statement := "SELECT tag_key, tag_values FROM metric_tag_keys WHERE metric_key = ?"
+	start := time.Now()
+	iterator := withContext(db.session.Query(statement, metricKey), ctx).Iter()
+	for iterator.Scan(&tagKey, &tagValues) {
+		keys = append(keys, metadata.TagKey{Key: tagKey, Cardinality: len(tagValues)})
+	}
+	err := iterator.Close()
+	recordStatement(profiler, statement, []interface{}{metricKey}, start, len(keys), err)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (db *cassandraDatabase) GetAllMetrics(ctx context.Context, profiler *inspect.Profiler) ([]api.MetricKey, error) {
 	var keys []api.MetricKey
-	err := db.session.Query("SELECT metric_names FROM metric_name_set WHERE shard = ?", 0).Scan(&keys)
+	statement := "SELECT metric_names FROM metric_name_set WHERE shard = ?"
+	start := time.Now()
+	err := withContext(db.session.Query(statement, 0), ctx).Scan(&keys)
+	recordStatement(profiler, statement, []interface{}{0}, start, 1, err)
 	if err != nil {
 		return nil, err
 	}
@@ -228,6 +289,37 @@ func (db *cassandraDatabase) RemoveFromTagIndex(tagKey string, tagValue string,
 	).Exec()
 }
 
+// withContext makes the query honor the cancellation of ctx, if there is one.
+func withContext(query *gocql.Query, ctx context.Context) *gocql.Query {
+	if ctx == nil {
+		return query
+	}
+	return query.WithContext(ctx)
+}
+
+// recordStatement keeps the statement in the profiler, for debug=backend.
+func recordStatement(profiler *inspect.Profiler, statement string, values []interface{}, start time.Time, rows int, err error) {
+	if !profiler.RecordsBackendRequests() {
+		return
+	}
+	described := make([]string, len(values))
+	for i, value := range values {
+		described[i] = fmt.Sprintf("%#v", value)
+	}
+	request := inspect.BackendRequest{
+		Backend: "cassandra",
+		Request: fmt.Sprintf("%s; values: %s", statement, strings.Join(described, ", ")),
+		Start:   start,
+		Finish:  time.Now(),
+		Rows:    rows,
+	}
+	if err != nil {
+		request.Error = err.Error()
+		request.Rows = 0
+	}
+	profiler.AddBackendRequest(request)
+}
+
 // CheckHealthy checks if the connection to Cassandra is healthy
 func (db *cassandraDatabase) CheckHealthy() error {
 	return db.session.Query("SELECT now() FROM system.local").Exec()
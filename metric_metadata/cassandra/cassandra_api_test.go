@@ -15,6 +15,7 @@
 package cassandra
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"testing"
@@ -47,7 +48,7 @@ func newCassandraAPI(t *testing.T) (*MetricMetadataAPI, metadata.Context) {
 		t.Fatalf("Cannot instantiate Cassandra API: %s", err.Error())
 	}
 
-	tables := []string{"metric_names", "tag_index", "metric_name_set"}
+	tables := []string{"metric_names", "tag_index", "metric_name_set", "metric_tag_keys"}
 	for _, table := range tables {
 		// Truncate the tables
 		if err := cassandra.db.session.Query(fmt.Sprintf("TRUNCATE %s", table)).Exec(); err != nil {
@@ -63,11 +64,12 @@ func cleanAPI(t *testing.T, c *MetricMetadataAPI) {
 }
 
 func TestMetricNameGetTagSetAPI(t *testing.T) {
+	ctx := context.Background()
 	a := assert.New(t)
 	cassandra, context := newCassandraAPI(t)
 	defer cleanAPI(t, cassandra)
 
-	if _, err := cassandra.GetAllTags("sample", context); err == nil {
+	if _, err := cassandra.GetAllTags(ctx, "sample", context); err == nil {
 		t.Errorf("Cassandra API should error on fetching nonexistent metric")
 	}
 
@@ -122,7 +124,7 @@ func TestMetricNameGetTagSetAPI(t *testing.T) {
 
 	for _, c := range metricNamesTests {
 		if c.addTest {
-			a.CheckError(cassandra.AddMetric(api.TaggedMetric{
+			a.CheckError(cassandra.AddMetric(ctx, api.TaggedMetric{
 				MetricKey: api.MetricKey(c.metricName),
 				TagSet:    c.tagSet,
 			}, context))
@@ -131,7 +133,7 @@ func TestMetricNameGetTagSetAPI(t *testing.T) {
 		}
 
 		for metric, expected := range c.expectedTags {
-			tags, err := cassandra.GetAllTags(api.MetricKey(metric), context)
+			tags, err := cassandra.GetAllTags(ctx, api.MetricKey(metric), context)
 			if err != nil {
 				t.Errorf("Error fetching tags")
 				continue
@@ -144,18 +146,19 @@ func TestMetricNameGetTagSetAPI(t *testing.T) {
 }
 
 func TestGetAllMetricsAPI(t *testing.T) {
+	ctx := context.Background()
 	a := assert.New(t)
 	cassandra, context := newCassandraAPI(t)
 	defer cleanAPI(t, cassandra)
-	a.CheckError(cassandra.AddMetric(api.TaggedMetric{
+	a.CheckError(cassandra.AddMetric(ctx, api.TaggedMetric{
 		MetricKey: "metric.a",
 		TagSet:    api.TagSet{"foo": "a"},
 	}, context))
-	a.CheckError(cassandra.AddMetric(api.TaggedMetric{
+	a.CheckError(cassandra.AddMetric(ctx, api.TaggedMetric{
 		MetricKey: "metric.a",
 		TagSet:    api.TagSet{"foo": "b"},
 	}, context))
-	a.CheckError(cassandra.AddMetrics([]api.TaggedMetric{
+	a.CheckError(cassandra.AddMetrics(ctx, []api.TaggedMetric{
 		{
 			MetricKey: "metric.c",
 			TagSet: api.TagSet{
@@ -175,50 +178,77 @@ func TestGetAllMetricsAPI(t *testing.T) {
 			},
 		},
 	}, context))
-	keys, err := cassandra.GetAllMetrics(context)
+	keys, err := cassandra.GetAllMetrics(ctx, context)
 	a.CheckError(err)
 	sort.Sort(api.MetricKeys(keys))
 	a.Eq(keys, []api.MetricKey{"metric.a", "metric.c", "metric.d", "metric.e"})
-	a.CheckError(cassandra.AddMetric(api.TaggedMetric{
+	a.CheckError(cassandra.AddMetric(ctx, api.TaggedMetric{
 		MetricKey: "metric.b",
 		TagSet:    api.TagSet{"foo": "c"},
 	}, context))
-	a.CheckError(cassandra.AddMetric(api.TaggedMetric{
+	a.CheckError(cassandra.AddMetric(ctx, api.TaggedMetric{
 		MetricKey: "metric.b",
 		TagSet:    api.TagSet{"foo": "c"},
 	}, context))
-	keys, err = cassandra.GetAllMetrics(context)
+	keys, err = cassandra.GetAllMetrics(ctx, context)
 	a.CheckError(err)
 	sort.Sort(api.MetricKeys(keys))
 	a.Eq(keys, []api.MetricKey{"metric.a", "metric.b", "metric.c", "metric.d", "metric.e"})
 }
 
 func TestTagIndexAPI(t *testing.T) {
+	ctx := context.Background()
 	a := assert.New(t)
 	cassandra, context := newCassandraAPI(t)
 	defer cleanAPI(t, cassandra)
 
-	if rows, err := cassandra.GetMetricsForTag("environment", "production", context); err != nil {
+	if rows, err := cassandra.GetMetricsForTag(ctx, "environment", "production", context); err != nil {
 		a.CheckError(err)
 	} else {
 		a.EqInt(len(rows), 0)
 	}
-	a.CheckError(cassandra.AddMetric(api.TaggedMetric{
+	a.CheckError(cassandra.AddMetric(ctx, api.TaggedMetric{
 		MetricKey: "a.b.c",
 		TagSet: api.TagSet{
 			"environment": "production",
 		},
 	}, context))
-	a.CheckError(cassandra.AddMetric(api.TaggedMetric{
+	a.CheckError(cassandra.AddMetric(ctx, api.TaggedMetric{
 		MetricKey: "d.e.f",
 		TagSet: api.TagSet{
 			"environment": "production",
 		},
 	}, context))
 
-	if rows, err := cassandra.GetMetricsForTag("environment", "production", context); err != nil {
+	if rows, err := cassandra.GetMetricsForTag(ctx, "environment", "production", context); err != nil {
 		a.CheckError(err)
 	} else {
 		a.EqInt(len(rows), 2)
 	}
 }
+
+func TestGetTagKeysAPI(t *testing.T) {
+	ctx := context.Background()
+	a := assert.New(t)
+	cassandra, context := newCassandraAPI(t)
+	defer cleanAPI(t, cassandra)
+
+	a.CheckError(cassandra.AddMetrics(ctx, []api.TaggedMetric{
+		{MetricKey: "a.b.c", TagSet: api.TagSet{"environment": "production", "host": "a"}},
+		{MetricKey: "a.b.c", TagSet: api.TagSet{"environment": "production", "host": "b"}},
+	}, context))
+	keys, err := cassandra.GetTagKeys(ctx, "a.b.c", context)
+	a.CheckError(err)
+	a.Eq(keys, []metadata.TagKey{{Key: "environment", Cardinality: 1}, {Key: "host", Cardinality: 2}})
+
+	// A metric indexed before the projection existed is summarized from its tagsets.
+	a.CheckError(cassandra.db.AddMetricName(ctx, "d.e.f", api.TagSet{"host": "c"}))
+	keys, err = cassandra.GetTagKeys(ctx, "d.e.f", context)
+	a.CheckError(err)
+	a.Eq(keys, []metadata.TagKey{{Key: "host", Cardinality: 1}})
+
+	_, err = cassandra.GetTagKeys(ctx, "g.h.i", context)
+	if _, ok := err.(metadata.NoSuchMetricError); !ok {
+		t.Errorf("expected a NoSuchMetricError for a metric which does not exist, got %v", err)
+	}
+}
@@ -15,6 +15,7 @@
 package cassandra
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"testing"
@@ -22,6 +23,7 @@ import (
 
 	"github.com/gocql/gocql"
 	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
 	"github.com/square/metrics/testing_support/assert"
 )
 
@@ -39,7 +41,7 @@ func newDatabase(t *testing.T) *cassandraDatabase {
 		t.Errorf("Cannot connect to Cassandra")
 		return nil
 	}
-	tables := []string{"metric_names", "tag_index", "metric_name_set"}
+	tables := []string{"metric_names", "tag_index", "metric_name_set", "metric_tag_keys"}
 	for _, table := range tables {
 		if err := session.Query(fmt.Sprintf("TRUNCATE %s", table)).Exec(); err != nil {
 			t.Errorf("Cannot truncate %s: %s", table, err.Error())
@@ -56,13 +58,14 @@ func cleanDatabase(t *testing.T, db *cassandraDatabase) {
 }
 
 func Test_MetricName_GetTagSet_DB(t *testing.T) {
+	ctx := context.Background()
 	a := assert.New(t)
 	db := newDatabase(t)
 	if db == nil {
 		return
 	}
 	defer cleanDatabase(t, db)
-	if _, err := db.GetTagSet("sample"); err == nil {
+	if _, err := db.GetTagSet(ctx, "sample", nil); err == nil {
 		t.Errorf("Cassandra should error on fetching nonexistent metric")
 	}
 
@@ -92,13 +95,13 @@ func Test_MetricName_GetTagSet_DB(t *testing.T) {
 
 	for _, c := range metricNamesTests {
 		if c.addTest {
-			a.CheckError(db.AddMetricName(c.metricName, api.ParseTagSet(c.tagString)))
+			a.CheckError(db.AddMetricName(ctx, c.metricName, api.ParseTagSet(c.tagString)))
 		} else {
 			clearCassandraInstance(t, db, c.metricName, c.tagString)
 		}
 
 		for k, v := range c.expectedTags {
-			if tags, err := db.GetTagSet(api.MetricKey(k)); err != nil {
+			if tags, err := db.GetTagSet(ctx, api.MetricKey(k), nil); err != nil {
 				t.Errorf("Error fetching tags")
 			} else {
 				stringTags := make([]string, len(tags))
@@ -116,15 +119,16 @@ func Test_MetricName_GetTagSet_DB(t *testing.T) {
 }
 
 func Test_GetAllMetrics_DB(t *testing.T) {
+	ctx := context.Background()
 	a := assert.New(t)
 	db := newDatabase(t)
 	if db == nil {
 		return
 	}
 	defer cleanDatabase(t, db)
-	a.CheckError(db.AddMetricName("metric.a", api.TagSet{"foo": "a"}))
-	a.CheckError(db.AddMetricName("metric.a", api.TagSet{"foo": "b"}))
-	a.CheckError(db.AddMetricNames([]api.TaggedMetric{
+	a.CheckError(db.AddMetricName(ctx, "metric.a", api.TagSet{"foo": "a"}))
+	a.CheckError(db.AddMetricName(ctx, "metric.a", api.TagSet{"foo": "b"}))
+	a.CheckError(db.AddMetricNames(ctx, []api.TaggedMetric{
 		{
 			"metric.c",
 			api.TagSet{
@@ -144,19 +148,20 @@ func Test_GetAllMetrics_DB(t *testing.T) {
 			},
 		},
 	}))
-	keys, err := db.GetAllMetrics()
+	keys, err := db.GetAllMetrics(ctx, nil)
 	a.CheckError(err)
 	sort.Sort(api.MetricKeys(keys))
 	a.Eq(keys, []api.MetricKey{"metric.a", "metric.c", "metric.d", "metric.e"})
-	a.CheckError(db.AddMetricName("metric.b", api.TagSet{"foo": "c"}))
-	a.CheckError(db.AddMetricName("metric.b", api.TagSet{"foo": "c"}))
-	keys, err = db.GetAllMetrics()
+	a.CheckError(db.AddMetricName(ctx, "metric.b", api.TagSet{"foo": "c"}))
+	a.CheckError(db.AddMetricName(ctx, "metric.b", api.TagSet{"foo": "c"}))
+	keys, err = db.GetAllMetrics(ctx, nil)
 	a.CheckError(err)
 	sort.Sort(api.MetricKeys(keys))
 	a.Eq(keys, []api.MetricKey{"metric.a", "metric.b", "metric.c", "metric.d", "metric.e"})
 }
 
 func Test_TagIndex_DB(t *testing.T) {
+	ctx := context.Background()
 	a := assert.New(t)
 	db := newDatabase(t)
 	if db == nil {
@@ -164,24 +169,49 @@ func Test_TagIndex_DB(t *testing.T) {
 	}
 	defer cleanDatabase(t, db)
 
-	if rows, err := db.GetMetricKeys("environment", "production"); err != nil {
+	if rows, err := db.GetMetricKeys(ctx, "environment", "production", nil); err != nil {
 		a.CheckError(err)
 	} else {
 		a.EqInt(len(rows), 0)
 	}
-	a.CheckError(db.AddToTagIndex("environment", "production", "a.b.c"))
-	a.CheckError(db.AddToTagIndex("environment", "production", "d.e.f"))
-	if rows, err := db.GetMetricKeys("environment", "production"); err != nil {
+	a.CheckError(db.AddToTagIndex(ctx, "environment", "production", "a.b.c"))
+	a.CheckError(db.AddToTagIndex(ctx, "environment", "production", "d.e.f"))
+	if rows, err := db.GetMetricKeys(ctx, "environment", "production", nil); err != nil {
 		a.CheckError(err)
 	} else {
 		a.EqInt(len(rows), 2)
 	}
 
 	a.CheckError(db.RemoveFromTagIndex("environment", "production", "a.b.c"))
-	if rows, err := db.GetMetricKeys("environment", "production"); err != nil {
+	if rows, err := db.GetMetricKeys(ctx, "environment", "production", nil); err != nil {
 		a.CheckError(err)
 	} else {
 		a.EqInt(len(rows), 1)
 		a.EqString(string(rows[0]), "d.e.f")
 	}
 }
+
+func Test_TagKeys_DB(t *testing.T) {
+	ctx := context.Background()
+	a := assert.New(t)
+	db := newDatabase(t)
+	if db == nil {
+		return
+	}
+	defer cleanDatabase(t, db)
+
+	if keys, err := db.GetTagKeys(ctx, "a.b.c", nil); err != nil {
+		a.CheckError(err)
+	} else {
+		a.EqInt(len(keys), 0)
+	}
+	a.CheckError(db.AddToTagIndex(ctx, "host", "a", "a.b.c"))
+	a.CheckError(db.AddToTagIndex(ctx, "host", "b", "a.b.c"))
+	a.CheckError(db.AddToTagIndex(ctx, "environment", "production", "a.b.c"))
+	a.CheckError(db.AddToTagIndex(ctx, "host", "a", "d.e.f"))
+	if keys, err := db.GetTagKeys(ctx, "a.b.c", nil); err != nil {
+		a.CheckError(err)
+	} else {
+		a.Eq(keys, []metadata.TagKey{{Key: "environment", Cardinality: 1}, {Key: "host", Cardinality: 2}})
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package indexer repairs drift between the storage backend and the metadata
+// index. Series are written to storage and indexed separately, so a failed
+// index write leaves a series which holds data but can't be queried. The
+// indexer periodically scans the storage for such series and adds them to
+// the index.
+package indexer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/log"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/timeseries"
+)
+
+// Config enables and paces the indexer.
+type Config struct {
+	Enabled         bool `yaml:"enabled"`
+	IntervalSeconds int  `yaml:"interval_seconds"` // the time between the start of scans; one hour if zero
+	PageSize        int  `yaml:"page_size"`        // the number of series requested from the storage at once; 1000 if zero
+	DryRun          bool `yaml:"dry_run"`          // report drift without repairing it
+}
+
+// maxRecentDrift is the number of unindexed series kept for the status.
+const maxRecentDrift = 20
+
+// Status describes the progress of the current scan and the drift found.
+type Status struct {
+	Scanning        bool               `json:"scanning"`
+	Scans           int                `json:"scans"` // the number of completed scans
+	ScanStarted     time.Time          `json:"scan_started,omitempty"`
+	LastScanSeconds float64            `json:"last_scan_seconds"`
+	Pages           int                `json:"pages"`          // the pages scanned by the current (or last) scan
+	SeriesScanned   int                `json:"series_scanned"` // by the current (or last) scan
+	Drift           int                `json:"drift"`          // the unindexed series found by the current (or last) scan
+	TotalDrift      int                `json:"total_drift"`    // over all scans
+	TotalRepaired   int                `json:"total_repaired"` // over all scans
+	RecentDrift     []api.TaggedMetric `json:"recent_drift"`   // the latest unindexed series found
+	LastError       string             `json:"last_error,omitempty"`
+	DryRun          bool               `json:"dry_run"`
+}
+
+// Indexer scans a storage backend and adds the series it finds to the index.
+type Indexer struct {
+	config   Config
+	storage  timeseries.ScanningStorageAPI
+	metadata metadata.MetricAPI
+	update   metadata.MetricUpdateAPI
+
+	trigger chan struct{}
+
+	mutex  sync.Mutex
+	status Status
+}
+
+// New creates an indexer; call Run to start it.
+func New(config Config, storage timeseries.ScanningStorageAPI, metadataAPI metadata.MetricAPI, updateAPI metadata.MetricUpdateAPI) *Indexer {
+	if config.IntervalSeconds <= 0 {
+		config.IntervalSeconds = 3600
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = 1000
+	}
+	return &Indexer{
+		config:   config,
+		storage:  storage,
+		metadata: metadataAPI,
+		update:   updateAPI,
+		trigger:  make(chan struct{}, 1),
+		status:   Status{DryRun: config.DryRun},
+	}
+}
+
+// Run scans at each interval, or sooner when triggered, until the context is done.
+func (i *Indexer) Run(ctx context.Context) error {
+	interval := time.Duration(i.config.IntervalSeconds) * time.Second
+	for {
+		if err := i.Scan(ctx); err != nil && ctx.Err() == nil {
+			log.Errorf("Error scanning storage for unindexed series: %s", err.Error())
+		}
+		select {
+		case <-time.After(interval):
+		case <-i.trigger:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Trigger starts a scan as soon as the current one (if any) is done.
+func (i *Indexer) Trigger() {
+	select {
+	case i.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Status returns the progress of the indexer.
+func (i *Indexer) Status() Status {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	status := i.status
+	status.RecentDrift = append([]api.TaggedMetric{}, i.status.RecentDrift...)
+	return status
+}
+
+// Scan makes one pass over the storage, repairing the index as it goes.
+func (i *Indexer) Scan(ctx context.Context) error {
+	started := time.Now()
+	i.mutex.Lock()
+	i.status.Scanning = true
+	i.status.ScanStarted = started
+	i.status.Pages = 0
+	i.status.SeriesScanned = 0
+	i.status.Drift = 0
+	i.mutex.Unlock()
+
+	err := i.scan(ctx)
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.status.Scanning = false
+	i.status.LastScanSeconds = time.Since(started).Seconds()
+	i.status.LastError = ""
+	if err != nil {
+		i.status.LastError = err.Error()
+		return err
+	}
+	i.status.Scans++
+	return nil
+}
+
+func (i *Indexer) scan(ctx context.Context) error {
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, err := i.storage.ScanSeries(timeseries.ScanRequest{Cursor: cursor, Limit: i.config.PageSize, Ctx: ctx})
+		if err != nil {
+			return err
+		}
+		missing, err := i.unindexed(ctx, page.Metrics)
+		if err != nil {
+			return err
+		}
+		repaired := 0
+		if len(missing) > 0 && !i.config.DryRun {
+			if err := i.update.AddMetrics(ctx, missing, metadata.Context{}); err != nil {
+				return err
+			}
+			repaired = len(missing)
+		}
+		i.record(len(page.Metrics), missing, repaired)
+		if page.Cursor == "" {
+			return nil
+		}
+		cursor = page.Cursor
+	}
+}
+
+// unindexed returns the series missing from the index.
+func (i *Indexer) unindexed(ctx context.Context, metrics []api.TaggedMetric) ([]api.TaggedMetric, error) {
+	indexed := map[api.MetricKey]map[string]bool{}
+	missing := []api.TaggedMetric{}
+	for _, metric := range metrics {
+		tagSets, ok := indexed[metric.MetricKey]
+		if !ok {
+			found, err := i.metadata.GetAllTags(ctx, metric.MetricKey, metadata.Context{})
+			if _, noSuchMetric := err.(metadata.NoSuchMetricError); err != nil && !noSuchMetric {
+				return nil, err
+			}
+			tagSets = map[string]bool{}
+			for _, tagSet := range found {
+				tagSets[tagSet.Serialize()] = true
+			}
+			indexed[metric.MetricKey] = tagSets
+		}
+		if !tagSets[metric.TagSet.Serialize()] {
+			missing = append(missing, metric)
+		}
+	}
+	return missing, nil
+}
+
+func (i *Indexer) record(scanned int, missing []api.TaggedMetric, repaired int) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.status.Pages++
+	i.status.SeriesScanned += scanned
+	i.status.Drift += len(missing)
+	i.status.TotalDrift += len(missing)
+	i.status.TotalRepaired += repaired
+	i.status.RecentDrift = append(i.status.RecentDrift, missing...)
+	if len(i.status.RecentDrift) > maxRecentDrift {
+		i.status.RecentDrift = i.status.RecentDrift[len(i.status.RecentDrift)-maxRecentDrift:]
+	}
+	for _, metric := range missing {
+		log.Infof("Found unindexed series %s", metric.String())
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestIndexer_Scan(t *testing.T) {
+	ctx := context.Background()
+	a := assert.New(t)
+	constant := func(time.Time) float64 { return 1 }
+	storage := memory.NewStore(30 * time.Second)
+	for _, host := range []string{"a", "b", "c"} {
+		storage.AddGenerated(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": host}}, constant)
+	}
+	storage.AddGenerated(api.TaggedMetric{MetricKey: "disk", TagSet: api.TagSet{"host": "a"}}, constant)
+
+	// The index lost the writes for cpu{host=c} and for disk altogether.
+	index := memory.NewStore(30 * time.Second)
+	for _, host := range []string{"a", "b"} {
+		a.CheckError(index.AddMetric(ctx, api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": host}}, metadata.Context{}))
+	}
+
+	dryRun := New(Config{PageSize: 2, DryRun: true}, storage, index, index)
+	a.CheckError(dryRun.Scan(context.Background()))
+	status := dryRun.Status()
+	a.EqInt(status.Pages, 2)
+	a.EqInt(status.SeriesScanned, 4)
+	a.EqInt(status.Drift, 2)
+	a.EqInt(status.TotalRepaired, 0)
+	_, err := index.GetAllTags(ctx, "disk", metadata.Context{})
+	if err == nil {
+		t.Errorf("expected a dry run to leave the index alone")
+	}
+
+	indexer := New(Config{PageSize: 3}, storage, index, index)
+	a.CheckError(indexer.Scan(context.Background()))
+	status = indexer.Status()
+	a.EqInt(status.Scans, 1)
+	a.EqInt(status.Drift, 2)
+	a.EqInt(status.TotalRepaired, 2)
+	a.Eq(status.RecentDrift, []api.TaggedMetric{
+		{MetricKey: "cpu", TagSet: api.TagSet{"host": "c"}},
+		{MetricKey: "disk", TagSet: api.TagSet{"host": "a"}},
+	})
+	tagSets, err := index.GetAllTags(ctx, "cpu", metadata.Context{})
+	a.CheckError(err)
+	a.EqInt(len(tagSets), 3)
+	tagSets, err = index.GetAllTags(ctx, "disk", metadata.Context{})
+	a.CheckError(err)
+	a.EqInt(len(tagSets), 1)
+
+	// Once repaired, there's no drift.
+	a.CheckError(indexer.Scan(context.Background()))
+	status = indexer.Status()
+	a.EqInt(status.Scans, 2)
+	a.EqInt(status.Drift, 0)
+	a.EqInt(status.TotalDrift, 2)
+}
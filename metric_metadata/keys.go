@@ -0,0 +1,73 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"sort"
+
+	"github.com/square/metrics/api"
+)
+
+// TagKey is a tag key used by a metric, with the number of distinct values
+// it takes.
+type TagKey struct {
+	Key         string `json:"key"`
+	Cardinality int    `json:"cardinality"`
+}
+
+// MetricKeysAPI is implemented by MetricAPIs which can list the tag keys of a
+// metric without materializing all of its tagsets. The cardinalities they
+// report may be approximate.
+type MetricKeysAPI interface {
+	// GetTagKeys returns the tag keys of the metric, sorted by key.
+	GetTagKeys(ctx context.Context, metricKey api.MetricKey, context Context) ([]TagKey, error)
+}
+
+// GetTagKeys returns the tag keys of the metric, sorted by key, using the
+// API's projection if it implements MetricKeysAPI.
+func GetTagKeys(ctx context.Context, metricAPI MetricAPI, metricKey api.MetricKey, context Context) ([]TagKey, error) {
+	if keysAPI, ok := metricAPI.(MetricKeysAPI); ok {
+		return keysAPI.GetTagKeys(ctx, metricKey, context)
+	}
+	tagsets, err := metricAPI.GetAllTags(ctx, metricKey, context)
+	if err != nil {
+		return nil, err
+	}
+	return SummarizeTagKeys(tagsets), nil
+}
+
+// SummarizeTagKeys counts the distinct values of each key in the tagsets.
+func SummarizeTagKeys(tagsets []api.TagSet) []TagKey {
+	values := map[string]map[string]struct{}{}
+	for _, tagset := range tagsets {
+		for key, value := range tagset {
+			if values[key] == nil {
+				values[key] = map[string]struct{}{}
+			}
+			values[key][value] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]TagKey, len(keys))
+	for i, key := range keys {
+		result[i] = TagKey{Key: key, Cardinality: len(values[key])}
+	}
+	return result
+}
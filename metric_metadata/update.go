@@ -15,14 +15,18 @@
 // Package metadata holds the interface for accessing metadata for indexing metrics.
 package metadata
 
-import "github.com/square/metrics/api"
+import (
+	"context"
+
+	"github.com/square/metrics/api"
+)
 
 // MetricUpdateAPI is an interface for updating metric metadata for indexing in MQE.
 type MetricUpdateAPI interface {
 	// AddMetric adds the metric to the system.
-	AddMetric(metric api.TaggedMetric, context Context) error
+	AddMetric(ctx context.Context, metric api.TaggedMetric, context Context) error
 	// AddMetrics adds several metrics (possibly more efficiently than one at a time)
-	AddMetrics(metric []api.TaggedMetric, context Context) error
+	AddMetrics(ctx context.Context, metric []api.TaggedMetric, context Context) error
 	// CheckHealthy checks if this MetricAPI is healthy, returning a possible error
 	CheckHealthy() error
 }
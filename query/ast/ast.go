@@ -0,0 +1,165 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ast exposes the expressions of parsed queries to external tools.
+// Walk and Visitor allow a query to be analyzed, for example to find which
+// metrics it uses and where, and Rewrite allows parts of a query to be
+// replaced, for example to rename a metric during a migration.
+package ast
+
+import (
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+)
+
+// A Node is a single expression in a parsed query. It is one of
+// expression.Scalar, expression.Duration, expression.String,
+// *expression.MetricFetchExpression, *expression.FunctionExpression or
+// *expression.AnnotationExpression. Metric and function nodes include their
+// position in the query.
+type Node interface {
+	ExpressionDescription(function.DescriptionMode) string
+}
+
+// NodeOf returns the node underlying a parsed expression.
+func NodeOf(expr function.Expression) Node {
+	if actual, ok := function.Unmemoize(expr); ok {
+		return actual
+	}
+	return expr
+}
+
+// ExpressionOf turns a node back into an expression which can be evaluated.
+// Rewriters which construct a replacement node, such as a
+// *expression.MetricFetchExpression, can use it to return the node.
+func ExpressionOf(node Node) function.Expression {
+	switch node := node.(type) {
+	case function.Expression:
+		return node
+	case function.ActualExpression:
+		return function.Memoize(node)
+	}
+	panic("ast: node is neither an expression nor an actual expression")
+}
+
+// Children returns the sub-expressions of the node, in the order they appear
+// in the query.
+func Children(node Node) []function.Expression {
+	switch node := node.(type) {
+	case *expression.FunctionExpression:
+		return node.Arguments
+	case *expression.AnnotationExpression:
+		return []function.Expression{node.Expression}
+	}
+	return nil
+}
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children of
+// node with the visitor w.
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an expression in depth-first order.
+func Walk(visitor Visitor, expr function.Expression) {
+	node := NodeOf(expr)
+	if visitor = visitor.Visit(node); visitor == nil {
+		return
+	}
+	for _, child := range Children(node) {
+		Walk(visitor, child)
+	}
+}
+
+// WalkCommand walks each expression of a select command. Other commands have
+// no expressions and are ignored.
+func WalkCommand(visitor Visitor, cmd command.Command) {
+	if selectCommand, ok := cmd.(*command.SelectCommand); ok {
+		for _, expr := range selectCommand.Expressions {
+			Walk(visitor, expr)
+		}
+	}
+}
+
+// A Rewriter's Rewrite method is invoked for each node by Rewrite, after the
+// node's children have been rewritten. It returns the replacement for the node,
+// or nil to keep the node as it is.
+type Rewriter interface {
+	Rewrite(node Node) function.Expression
+}
+
+// RewriterFunc adapts an ordinary function into a Rewriter.
+type RewriterFunc func(node Node) function.Expression
+
+// Rewrite calls the function.
+func (f RewriterFunc) Rewrite(node Node) function.Expression {
+	return f(node)
+}
+
+// Rewrite returns a copy of the expression with nodes replaced by the rewriter.
+// The given expression is not modified.
+func Rewrite(rewriter Rewriter, expr function.Expression) function.Expression {
+	result, _ := rewrite(rewriter, expr)
+	return result
+}
+
+// rewrite rewrites the expression, reporting whether anything was replaced.
+func rewrite(rewriter Rewriter, expr function.Expression) (function.Expression, bool) {
+	node := NodeOf(expr)
+	changed := false
+	switch original := node.(type) {
+	case *expression.FunctionExpression:
+		arguments := make([]function.Expression, len(original.Arguments))
+		for i, argument := range original.Arguments {
+			var argumentChanged bool
+			arguments[i], argumentChanged = rewrite(rewriter, argument)
+			changed = changed || argumentChanged
+		}
+		if changed {
+			copied := *original
+			copied.Arguments = arguments
+			node = &copied
+			expr = function.Memoize(&copied)
+		}
+	case *expression.AnnotationExpression:
+		if child, childChanged := rewrite(rewriter, original.Expression); childChanged {
+			copied := *original
+			copied.Expression = child
+			node = &copied
+			expr = &copied
+			changed = true
+		}
+	}
+	if replacement := rewriter.Rewrite(node); replacement != nil {
+		return replacement, true
+	}
+	return expr, changed
+}
+
+// RewriteCommand returns a copy of a select command with each of its
+// expressions rewritten. Other commands are returned unchanged.
+func RewriteCommand(rewriter Rewriter, cmd command.Command) command.Command {
+	selectCommand, ok := cmd.(*command.SelectCommand)
+	if !ok {
+		return cmd
+	}
+	copied := *selectCommand
+	copied.Expressions = make([]function.Expression, len(selectCommand.Expressions))
+	for i, expr := range selectCommand.Expressions {
+		copied.Expressions[i] = Rewrite(rewriter, expr)
+	}
+	return &copied
+}
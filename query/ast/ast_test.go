@@ -0,0 +1,92 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	a := assert.New(t)
+	cmd, err := parser.Parse("select cpu.user[host = 'a'] + 1,\n  transform.rate(requests {reqs}) from -1h to now")
+	a.CheckError(err)
+	a.Eq(Metrics(cmd), []MetricUse{
+		{Name: "cpu.user", Predicate: `host = "a"`, Position: "line 1, column 8", Function: "+"},
+		{Name: "requests", Predicate: "true", Position: "line 2, column 18", Function: "transform.rate"},
+	})
+
+	cmd, err = parser.Parse("describe cpu.user where host = 'a'")
+	a.CheckError(err)
+	a.Eq(Metrics(cmd), []MetricUse{{Name: "cpu.user", Predicate: `host = "a"`}})
+}
+
+type countingVisitor map[string]int
+
+func (v countingVisitor) Visit(node Node) Visitor {
+	switch node := node.(type) {
+	case *expression.FunctionExpression:
+		v[node.FunctionName]++
+		if node.FunctionName == "skip" {
+			return nil
+		}
+	case *expression.MetricFetchExpression:
+		v[node.MetricName]++
+	}
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	a := assert.New(t)
+	cmd, err := parser.Parse("select f(a, g(b), skip(c)) + a from 0 to 0")
+	a.CheckError(err)
+	counts := countingVisitor{}
+	WalkCommand(counts, cmd)
+	a.Eq(map[string]int(counts), map[string]int{"+": 1, "f": 1, "g": 1, "skip": 1, "a": 2, "b": 1})
+}
+
+func TestRewrite(t *testing.T) {
+	a := assert.New(t)
+	cmd, err := parser.Parse("select cpu.user[host = 'a'] + transform.rate(cpu.user) {rate}, memory from 0 to 0")
+	a.CheckError(err)
+	rename := RewriterFunc(func(node Node) function.Expression {
+		metric, ok := node.(*expression.MetricFetchExpression)
+		if !ok || metric.MetricName != "cpu.user" {
+			return nil
+		}
+		renamed := *metric
+		renamed.MetricName = "cpu.user_v2"
+		return ExpressionOf(&renamed)
+	})
+	rewritten := RewriteCommand(rename, cmd).(*command.SelectCommand)
+	original := cmd.(*command.SelectCommand)
+
+	describe := func(expressions []function.Expression) []string {
+		result := []string{}
+		for _, expr := range expressions {
+			result = append(result, expr.ExpressionDescription(function.StringQuery()))
+		}
+		return result
+	}
+	a.Eq(describe(rewritten.Expressions), []string{`(cpu.user_v2[host = "a"] + transform.rate(cpu.user_v2) {rate})`, "memory"})
+	a.Eq(describe(original.Expressions), []string{`(cpu.user[host = "a"] + transform.rate(cpu.user) {rate})`, "memory"})
+	// Unchanged expressions are shared rather than copied.
+	a.EqBool(rewritten.Expressions[1] == original.Expressions[1], true)
+}
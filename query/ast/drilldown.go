@@ -0,0 +1,129 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/predicate"
+)
+
+// TagKeys returns the tag keys of a metric.
+type TagKeys func(metric string) ([]string, error)
+
+// DrillDown returns the select which shows the series behind one series of
+// the result of the select's expression (given by index): the expression
+// alone, with the grouping removed and each of its fetches narrowed to the
+// series' tags. Aggregations are replaced by the series they aggregate, and
+// the group-by clauses of other functions are dropped, but operators keep
+// their 'on' and 'ignoring' clauses.
+//
+// A fetch is narrowed only on the tags which its metric has (given by
+// tagKeys), so that the other side of an operator isn't filtered out by the
+// tags of this one, and never on tags which tag.set or tag.copy introduce.
+func DrillDown(cmd *command.SelectCommand, index int, tagset api.TagSet, tagKeys TagKeys) (*command.SelectCommand, error) {
+	expr := cmd.Expressions[index]
+	introduced := map[string]bool{}
+	Walk(tagVisitor(introduced), expr)
+	tags := make([]string, 0, len(tagset))
+	for tag := range tagset {
+		if !introduced[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	var err error
+	narrow := func(node Node) function.Expression {
+		if ungrouped := ungroup(node); ungrouped != nil {
+			return ungrouped
+		}
+		fetch, ok := node.(*expression.MetricFetchExpression)
+		if !ok || err != nil {
+			return nil
+		}
+		var keys []string
+		keys, err = tagKeys(fetch.MetricName)
+		if err != nil {
+			return nil
+		}
+		has := map[string]bool{}
+		for _, key := range keys {
+			has[key] = true
+		}
+		matchers := []predicate.Predicate{}
+		if _, ok := fetch.Predicate.(predicate.TruePredicate); !ok && fetch.Predicate != nil {
+			matchers = append(matchers, fetch.Predicate)
+		}
+		narrowed := false
+		for _, tag := range tags {
+			if has[tag] {
+				matchers = append(matchers, predicate.ListMatcher{Tag: tag, Values: []string{tagset[tag]}})
+				narrowed = true
+			}
+		}
+		if !narrowed {
+			return nil
+		}
+		copied := *fetch
+		copied.Predicate = predicate.All(matchers...)
+		return ExpressionOf(&copied)
+	}
+	drilled := Rewrite(RewriterFunc(narrow), expr)
+	if err != nil {
+		return nil, err
+	}
+	return &command.SelectCommand{
+		Predicate:   predicate.All(cmd.Predicate),
+		Expressions: []function.Expression{drilled},
+		Context:     cmd.Context,
+	}, nil
+}
+
+// tagVisitor collects the tags which tag.set and tag.copy introduce.
+type tagVisitor map[string]bool
+
+func (v tagVisitor) Visit(node Node) Visitor {
+	call, ok := node.(*expression.FunctionExpression)
+	if ok && (call.FunctionName == "tag.set" || call.FunctionName == "tag.copy") && len(call.Arguments) > 1 {
+		if tag, ok := NodeOf(call.Arguments[1]).(expression.String); ok {
+			v[tag.Value] = true
+		}
+	}
+	return v
+}
+
+// ungroup removes the grouping of a call.
+func ungroup(node Node) function.Expression {
+	call, ok := node.(*expression.FunctionExpression)
+	if !ok || call.IsOperator() {
+		return nil
+	}
+	if strings.HasPrefix(call.FunctionName, "aggregate.") && len(call.Arguments) != 0 {
+		return call.Arguments[0]
+	}
+	if len(call.GroupBy) == 0 {
+		return nil
+	}
+	ungrouped := *call
+	ungrouped.GroupBy = nil
+	ungrouped.GroupByCollapses = false
+	return ExpressionOf(&ungrouped)
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestDrillDown(t *testing.T) {
+	keys := map[string][]string{
+		"cpu":      {"dc", "host"},
+		"requests": {"app", "dc", "env"},
+		"errors":   {"code", "env", "host"},
+	}
+	tagKeys := func(metric string) ([]string, error) {
+		if keys, ok := keys[metric]; ok {
+			return keys, nil
+		}
+		return nil, fmt.Errorf("no such metric %s", metric)
+	}
+	for _, test := range []struct {
+		query    string
+		index    int
+		tagset   api.TagSet
+		expected string
+	}{
+		{
+			query:    "select aggregate.sum(cpu group by dc) from 0 to 60000",
+			tagset:   api.TagSet{"dc": "west"},
+			expected: `select cpu[dc = "west"] from 0 to 60000 resolution 30000`,
+		},
+		{
+			query:    "select cpu, transform.rate(aggregate.max(requests group by dc, app)) where env = 'prod' from 0 to 60000 resolution 10s order by max desc limit 5",
+			index:    1,
+			tagset:   api.TagSet{"dc": "west", "app": "web"},
+			expected: `select transform.rate(requests[(app = "web" and dc = "west")]) where env = "prod" from 0 to 60000 resolution 10000 order by max desc limit 5`,
+		},
+		{
+			query:    "select filter.highest_max(cpu, 2 group by dc) / on (dc) aggregate.sum(cpu group by dc) from 0 to 60000 sample by 'max' fill 0",
+			tagset:   api.TagSet{"dc": "east"},
+			expected: `select (filter.highest_max(cpu[dc = "east"], 2) / on (dc) cpu[dc = "east"]) from 0 to 60000 resolution 30000 sample by 'max' fill 0`,
+		},
+		{
+			query:    "select aggregate.mean(cpu) from 0 to 60000 fill interpolate",
+			tagset:   api.TagSet{},
+			expected: `select cpu from 0 to 60000 resolution 30000 fill interpolate`,
+		},
+		{
+			// Each side is narrowed only on its own tags.
+			query:    "select errors[code = '500'] / ignoring (code, host) requests from 0 to 60000",
+			tagset:   api.TagSet{"code": "500", "env": "moon", "host": "a"},
+			expected: `select (errors[(code = "500" and code = "500" and env = "moon" and host = "a")] / ignoring (code, host) requests[env = "moon"]) from 0 to 60000 resolution 30000`,
+		},
+		{
+			// Tags set by functions aren't fetched.
+			query:    "select tag.set(cpu, 'region', 'us') from 0 to 60000",
+			tagset:   api.TagSet{"region": "us", "host": "a"},
+			expected: `select tag.set(cpu[host = "a"], "region", "us") from 0 to 60000 resolution 30000`,
+		},
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		cmd, err := parser.Parse(test.query)
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		drilled, err := DrillDown(cmd.(*command.SelectCommand), test.index, test.tagset, tagKeys)
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		a.EqString(drilled.Query(), test.expected)
+		_, err = parser.Parse(drilled.Query())
+		a.CheckError(err)
+	}
+
+	cmd, err := parser.Parse("select memory from 0 to 60000")
+	if err != nil {
+		t.Fatalf("Error parsing query for test: %s", err.Error())
+	}
+	if _, err := DrillDown(cmd.(*command.SelectCommand), 0, api.TagSet{"dc": "west"}, tagKeys); err == nil {
+		t.Errorf("expected the missing metric to be reported")
+	}
+}
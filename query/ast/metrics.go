@@ -0,0 +1,60 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+)
+
+// MetricUse is an occurrence of a metric in a query.
+type MetricUse struct {
+	Name      string // Name is the metric which is fetched.
+	Predicate string // Predicate is the query form of the metric's own predicate.
+	Position  string // Position is the location of the metric in the query.
+	Function  string // Function is the innermost function applied to the metric, if any.
+}
+
+// metricVisitor collects metric uses, tracking the enclosing function.
+type metricVisitor struct {
+	uses     *[]MetricUse
+	function string
+}
+
+func (v metricVisitor) Visit(node Node) Visitor {
+	switch node := node.(type) {
+	case *expression.MetricFetchExpression:
+		*v.uses = append(*v.uses, MetricUse{
+			Name:      node.MetricName,
+			Predicate: node.Predicate.Query(),
+			Position:  node.Position,
+			Function:  v.function,
+		})
+	case *expression.FunctionExpression:
+		return metricVisitor{uses: v.uses, function: node.FunctionName}
+	}
+	return v
+}
+
+// Metrics lists the metrics used by a command in the order they appear.
+// The metric of a describe command is included.
+func Metrics(cmd command.Command) []MetricUse {
+	uses := []MetricUse{}
+	if describe, ok := cmd.(*command.DescribeCommand); ok {
+		return append(uses, MetricUse{Name: string(describe.MetricName), Predicate: describe.Predicate.Query()})
+	}
+	WalkCommand(metricVisitor{uses: &uses}, cmd)
+	return uses
+}
@@ -0,0 +1,181 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/function"
+)
+
+// ResultCache keeps the results of recent selects in memory, so that a
+// select repeated within its time to live (such as by several viewers of
+// the same dashboard) isn't executed again. Selects whose timerange ends
+// close to now aren't cached, since their last points may still change.
+// The least recently used result is dropped to make room for a new one.
+type ResultCache struct {
+	ttl        time.Duration
+	recent     time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // of *cachedResult, the most recently used first
+	hits    int
+	misses  int
+}
+
+type cachedResult struct {
+	key    string
+	result Result
+	stored time.Time
+}
+
+// CacheReport is added to the metadata of selects run with a ResultCache.
+type CacheReport struct {
+	Status     string  `json:"status"` // hit, miss or uncacheable
+	AgeSeconds float64 `json:"age_seconds,omitempty"`
+	Hits       int     `json:"hits"`    // by every select so far
+	Misses     int     `json:"misses"`  // by every select so far
+	Entries    int     `json:"entries"` // the number of results cached
+}
+
+// The outcomes of a lookup in a ResultCache.
+const (
+	CacheHit         = "hit"
+	CacheMiss        = "miss"
+	CacheUncacheable = "uncacheable"
+)
+
+// NewResultCache creates a ResultCache which serves results for the given
+// time to live, keeping at most maxEntries of them (1000 if zero). Selects
+// ending within recent of now are executed every time.
+func NewResultCache(ttl time.Duration, recent time.Duration, maxEntries int) *ResultCache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &ResultCache{
+		ttl:        ttl,
+		recent:     recent,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// cacheKey identifies the results of the select: its query, its timerange
+// and resolution, and the settings of the context which change its result.
+func (cmd *SelectCommand) cacheKey(context ExecutionContext) string {
+	expressions := make([]string, len(cmd.Expressions))
+	for i, expression := range cmd.Expressions {
+		expressions[i] = expression.ExpressionDescription(function.StringQuery())
+	}
+	constraints := ""
+	if context.AdditionalConstraints != nil {
+		constraints = context.AdditionalConstraints.Query()
+	}
+	return fmt.Sprintf("select %s where %s constrained by %s %s tenant=%q fetches=%d slots=%d memory=%d collation=%q trailing=%q maintenance=%t strict=%t partial=%t coarser=%t",
+		strings.Join(expressions, ", "), cmd.Predicate.Query(), constraints, cmd.Context.Query(),
+		context.Labels["tenant"], context.FetchLimit, context.SlotLimit, context.MemoryLimit, context.Collation, context.TrailingBucket,
+		context.SuppressMaintenance, context.Strict, context.PartialResults, context.CoarserRetry)
+}
+
+// execute returns the cached result of the select, or executes it and caches
+// what it returns.
+func (c *ResultCache) execute(cmd *SelectCommand, context ExecutionContext, run func() (Result, error)) (Result, error) {
+	if cmd.Context.End.After(c.now().Add(-c.recent)) {
+		result, err := run()
+		return c.report(result, err, CacheUncacheable, 0)
+	}
+	key := cmd.cacheKey(context)
+	if result, age, ok := c.get(key); ok {
+		return c.report(result, nil, CacheHit, age)
+	}
+	result, err := run()
+	if err == nil {
+		if _, partial := result.Metadata["partial"]; !partial {
+			c.store(key, result)
+		}
+	}
+	return c.report(result, err, CacheMiss, 0)
+}
+
+// get returns the result cached under the key and its age, unless it has
+// expired.
+func (c *ResultCache) get(key string) (Result, time.Duration, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[key]
+	if ok {
+		entry := element.Value.(*cachedResult)
+		age := c.now().Sub(entry.stored)
+		if age < c.ttl {
+			c.hits++
+			c.order.MoveToFront(element)
+			return entry.result, age, true
+		}
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+	c.misses++
+	return Result{}, 0, false
+}
+
+// store caches the result, dropping the least recently used one if the cache
+// is full.
+func (c *ResultCache) store(key string, result Result) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry := &cachedResult{key: key, result: Result{Body: result.Body, Metadata: copyMetadata(result.Metadata)}, stored: c.now()}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResult).key)
+	}
+}
+
+// report adds the outcome of the lookup to the metadata of the result. The
+// metadata is copied, so that the cached result isn't changed by the caller.
+func (c *ResultCache) report(result Result, err error, status string, age time.Duration) (Result, error) {
+	if err != nil {
+		return result, err
+	}
+	c.mutex.Lock()
+	report := CacheReport{Status: status, AgeSeconds: age.Seconds(), Hits: c.hits, Misses: c.misses, Entries: c.order.Len()}
+	c.mutex.Unlock()
+	result.Metadata = copyMetadata(result.Metadata)
+	result.Metadata["cache"] = report
+	return result, nil
+}
+
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(metadata)+1)
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/timeseries"
+)
+
+// slotLimitError is the limit error of a select whose timerange has more
+// points than the slot limit allows.
+type slotLimitError struct {
+	function.LimitError
+}
+
+// CoarserResolution describes a select which was retried at a coarser
+// resolution after exceeding a limit.
+type CoarserResolution struct {
+	From   time.Duration `json:"from"`
+	To     time.Duration `json:"to"`
+	Reason string        `json:"reason"` // the error of the first attempt
+}
+
+// note describes the substitution for the notes of a query.
+func (coarser CoarserResolution) note() string {
+	return fmt.Sprintf("The query exceeded a limit at a resolution of %s (%s), so it was computed at the coarser resolution of %s.", coarser.From, coarser.Reason, coarser.To)
+}
+
+// exceedsResolutionLimit reports whether the error is one which a coarser
+// resolution, having fewer points, might avoid: the slot or memory limit, or
+// a storage refusing a request as too large.
+func exceedsResolutionLimit(err error) bool {
+	switch err := err.(type) {
+	case slotLimitError, function.MemoryLimitError:
+		return true
+	case timeseries.Error:
+		return err.Code == timeseries.LimitError
+	case timeseries.FetchError:
+		return err.Code == http.StatusRequestEntityTooLarge
+	}
+	return false
+}
+
+// retryCoarser runs the select again at the next resolution offered by the
+// storage which is coarser than the one which failed. If there's none, or the
+// retry fails too, the original error is returned.
+func (cmd *SelectCommand) retryCoarser(context ExecutionContext, failed time.Duration, original error) (Result, error) {
+	var chosen time.Duration
+	result, err := cmd.execute(context, failed+time.Millisecond, &chosen)
+	if err != nil || chosen <= failed {
+		return Result{}, original
+	}
+	coarser := CoarserResolution{From: failed, To: chosen, Reason: original.Error()}
+	if result.Metadata == nil {
+		result.Metadata = map[string]interface{}{}
+	}
+	notes, _ := result.Metadata["notes"].([]string)
+	result.Metadata["notes"] = append([]string{coarser.note()}, notes...)
+	result.Metadata["coarser_resolution"] = coarser
+	return result, nil
+}
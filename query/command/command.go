@@ -18,7 +18,8 @@ import (
 	netcontext "context"
 	"fmt"
 	"regexp"
-	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,14 +35,29 @@ import (
 
 // ExecutionContext is the context supplied when invoking a command.
 type ExecutionContext struct {
-	TimeseriesStorageAPI  timeseries.StorageAPI // the backend
-	MetricMetadataAPI     metadata.MetricAPI    // the api
-	FetchLimit            int                   // the maximum number of fetches
-	Timeout               time.Duration         // optional
-	Registry              function.Registry     // optional
-	SlotLimit             int                   // optional (0 => default 1000)
-	Profiler              *inspect.Profiler     // optional
-	AdditionalConstraints predicate.Predicate   // optional. Additional contrains for describe and select commands
+	TimeseriesStorageAPI  timeseries.StorageAPI   // the backend
+	MetricMetadataAPI     metadata.MetricAPI      // the api
+	FetchLimit            int                     // the maximum number of fetches
+	Timeout               time.Duration           // optional
+	Registry              function.Registry       // optional
+	SlotLimit             int                     // optional (0 => default 1000)
+	Profiler              *inspect.Profiler       // optional
+	AdditionalConstraints predicate.Predicate     // optional. Additional contrains for describe and select commands
+	MaintenanceAPI        MaintenanceAPI          // optional
+	SuppressMaintenance   bool                    // optional. If set, fetched series are masked during maintenance windows
+	DescribeMode          string                  // optional. If "fuzzy", describe all ranks metrics by similarity to its match text
+	TrailingBucket        string                  // optional. One of "keep" (the default), "trim" or "flag"
+	Now                   func() time.Time        // optional. The current time, used to find incomplete buckets; defaults to time.Now
+	Collation             string                  // optional. The name of the natural_sort collation used to order tag values
+	Strict                bool                    // optional. If set, empty fetches, unknown group-by tags and NaN-only results are errors
+	Labels                map[string]string       // optional. Sent with backend requests (such as the tenant or dashboard), so that they can be attributed to the query
+	PartialResults        bool                    // optional. If set, a select which runs short of time returns the prefix of its timerange which was fetched, rather than failing
+	CoarserRetry          bool                    // optional. If set, a select which exceeds the slot limit or a storage limit is retried once at the next coarser resolution
+	ResultCache           *ResultCache            // optional. If set, repeated selects are served from it
+	MemoryLimit           int64                   // optional. The maximum bytes of fetched series a select may hold (0 => unlimited)
+	RangeRules            []RangeRule             // optional. Limits on the timeranges and resolutions at which particular metrics may be selected
+	GoroutineLimit        int                     // optional. The most goroutines a select may evaluate its expressions on at once (0 => unlimited)
+	Stream                func(QueryResult) error // optional. If set, a select passes it each of its results in order, as soon as that result and those before it are ready
 
 	Ctx netcontext.Context
 }
@@ -68,7 +84,22 @@ type DescribeCommand struct {
 
 // DescribeAllCommand returns all the metrics available in the system.
 type DescribeAllCommand struct {
-	Matcher *regexp.Regexp
+	Match      string         // the text of the match clause, as written; fuzzy mode ranks metrics against it
+	Matcher    *regexp.Regexp // Match compiled as a regular expression, or nil if it isn't one
+	MatchError error          // why Match isn't a regular expression; only an error outside fuzzy mode
+}
+
+// DescribeKeysCommand returns the tag keys of a metric, with their cardinalities.
+type DescribeKeysCommand struct {
+	MetricName api.MetricKey
+}
+
+// DescribeValuesCommand returns the values of a tag key which are found in
+// all of the metrics, or in any of them.
+type DescribeValuesCommand struct {
+	TagKey  string
+	Metrics []api.MetricKey
+	Any     bool
 }
 
 // DescribeMetricsCommand returns all metrics that use a particular key-value pair.
@@ -78,10 +109,42 @@ type DescribeMetricsCommand struct {
 }
 
 type SelectContext struct {
-	Start        int64                   // Start of data timerange
-	End          int64                   // End of data timerange
-	Resolution   int64                   // Resolution of data timerange
+	Start        time.Time               // Start of data timerange
+	End          time.Time               // End of data timerange
+	Resolution   time.Duration           // Resolution of data timerange
 	SampleMethod timeseries.SampleMethod // to use when up/downsampling to match requested resolution
+	OrderBy      string                  // optional summary used to order the series of each expression
+	Descending   bool                    // whether OrderBy sorts in descending order
+	Limit        int                     // optional maximum number of series for each expression (0 => unlimited)
+	Sample       float64                 // optional percentage of the matching series to fetch (0 => all)
+	Fill         FillPolicy              // optional policy filling the missing values of the results
+}
+
+// MillisToTime converts milliseconds since the epoch, as they're written in
+// queries and sent over the wire, into a time.
+func MillisToTime(millis int64) time.Time {
+	return time.Unix(0, millis*int64(time.Millisecond))
+}
+
+// MillisToDuration converts milliseconds, as they're written in queries and
+// sent over the wire, into a duration.
+func MillisToDuration(millis int64) time.Duration {
+	return time.Duration(millis) * time.Millisecond
+}
+
+// StartMillis is the start of the timerange, in milliseconds since the epoch.
+func (context SelectContext) StartMillis() int64 {
+	return context.Start.UnixNano() / int64(time.Millisecond)
+}
+
+// EndMillis is the end of the timerange, in milliseconds since the epoch.
+func (context SelectContext) EndMillis() int64 {
+	return context.End.UnixNano() / int64(time.Millisecond)
+}
+
+// ResolutionMillis is the resolution, in milliseconds.
+func (context SelectContext) ResolutionMillis() int64 {
+	return int64(context.Resolution / time.Millisecond)
 }
 
 // SelectCommand is the bread and butter of the metrics query engine.
@@ -98,9 +161,11 @@ func (cmd *DescribeCommand) Execute(context ExecutionContext) (Result, error) {
 	// We generate a simple update function that closes around the profiler
 	// so if we do have a cache miss it's correctly reported on this request.
 
-	tagsets, err := context.MetricMetadataAPI.GetAllTags(cmd.MetricName, metadata.Context{
-		Profiler: context.Profiler,
-	})
+	collation, err := natural_sort.Lookup(context.Collation)
+	if err != nil {
+		return Result{}, err
+	}
+	tagsets, err := context.MetricMetadataAPI.GetAllTags(context.Ctx, cmd.MetricName, metadata.Context{Profiler: context.Profiler})
 	if err != nil {
 		return Result{}, err
 	}
@@ -126,7 +191,7 @@ func (cmd *DescribeCommand) Execute(context ExecutionContext) (Result, error) {
 			list = append(list, value)
 		}
 		// sort the result
-		natural_sort.Sort(list)
+		natural_sort.SortWith(collation, list)
 		keyValueLists[key] = list
 	}
 	return Result{Body: keyValueLists}, nil
@@ -138,36 +203,138 @@ func (cmd *DescribeCommand) Name() string {
 
 // Execute of a DescribeAllCommand returns the list of all metrics.
 func (cmd *DescribeAllCommand) Execute(context ExecutionContext) (Result, error) {
-	result, err := context.MetricMetadataAPI.GetAllMetrics(metadata.Context{
-		Profiler: context.Profiler,
-	})
-	if err == nil {
-		filtered := make([]api.MetricKey, 0, len(result))
-		for _, row := range result {
-			if cmd.Matcher.MatchString(string(row)) {
-				filtered = append(filtered, row)
-			}
-		}
-		sort.Sort(api.MetricKeys(filtered))
-		return Result{
-			Body: filtered,
-			Metadata: map[string]interface{}{
-				"count": len(filtered),
-			},
-		}, nil
+	switch context.DescribeMode {
+	case "":
+	case FuzzyMode:
+		return cmd.executeFuzzy(context)
+	default:
+		return Result{}, fmt.Errorf("unknown describe mode %q; expected %q or none", context.DescribeMode, FuzzyMode)
 	}
-	return Result{}, err
+	if cmd.MatchError != nil {
+		return Result{}, cmd.MatchError
+	}
+	filtered, err := metadata.SearchMetrics(context.Ctx, context.MetricMetadataAPI, cmd.Matcher, metadata.Context{Profiler: context.Profiler})
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Body: filtered,
+		Metadata: map[string]interface{}{
+			"count": len(filtered),
+		},
+	}, nil
+}
+
+// executeFuzzy treats the match text as a loosely-written metric name rather
+// than a regular expression and ranks every metric against it.
+func (cmd *DescribeAllCommand) executeFuzzy(context ExecutionContext) (Result, error) {
+	metrics, err := context.MetricMetadataAPI.GetAllMetrics(context.Ctx, metadata.Context{Profiler: context.Profiler})
+	if err != nil {
+		return Result{}, err
+	}
+	ranked := fuzzySearch(cmd.Match, metrics)
+	return Result{
+		Body: ranked,
+		Metadata: map[string]interface{}{
+			"count": len(ranked),
+			"mode":  FuzzyMode,
+		},
+	}, nil
 }
 
 func (cmd *DescribeAllCommand) Name() string {
 	return "describe all"
 }
 
+// Execute lists the tag keys of the metric. Additional constraints require
+// the tagsets themselves, so they bypass any projection by the API.
+func (cmd *DescribeKeysCommand) Execute(context ExecutionContext) (Result, error) {
+	metadataContext := metadata.Context{Profiler: context.Profiler}
+	var keys []metadata.TagKey
+	if context.AdditionalConstraints != nil {
+		tagsets, err := context.MetricMetadataAPI.GetAllTags(context.Ctx, cmd.MetricName, metadataContext)
+		if err != nil {
+			return Result{}, err
+		}
+		filtered := []api.TagSet{}
+		for _, tagset := range tagsets {
+			if context.AdditionalConstraints.Apply(tagset) {
+				filtered = append(filtered, tagset)
+			}
+		}
+		keys = metadata.SummarizeTagKeys(filtered)
+	} else {
+		var err error
+		keys, err = metadata.GetTagKeys(context.Ctx, context.MetricMetadataAPI, cmd.MetricName, metadataContext)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	return Result{
+		Body: keys,
+		Metadata: map[string]interface{}{
+			"count": len(keys),
+		},
+	}, nil
+}
+
+func (cmd *DescribeKeysCommand) Name() string {
+	return "describe keys"
+}
+
+// Execute finds the values of the tag key in each metric, and combines them.
+func (cmd *DescribeValuesCommand) Execute(context ExecutionContext) (Result, error) {
+	collation, err := natural_sort.Lookup(context.Collation)
+	if err != nil {
+		return Result{}, err
+	}
+	predicate := predicate.All(context.AdditionalConstraints)
+	counts := map[string]int{} // the number of metrics in which each value appears
+	for _, metric := range cmd.Metrics {
+		tagsets, err := context.MetricMetadataAPI.GetAllTags(context.Ctx, metric, metadata.Context{Profiler: context.Profiler})
+		if err != nil {
+			return Result{}, err
+		}
+		values := map[string]bool{}
+		for _, tagset := range tagsets {
+			if value, ok := tagset[cmd.TagKey]; ok && predicate.Apply(tagset) {
+				values[value] = true
+			}
+		}
+		for value := range values {
+			counts[value]++
+		}
+	}
+	quantifier := "all"
+	required := len(cmd.Metrics)
+	if cmd.Any {
+		quantifier = "any"
+		required = 1
+	}
+	result := []string{}
+	for value, count := range counts {
+		if count >= required {
+			result = append(result, value)
+		}
+	}
+	natural_sort.SortWith(collation, result)
+	return Result{
+		Body: result,
+		Metadata: map[string]interface{}{
+			"count":   len(result),
+			"metrics": len(cmd.Metrics),
+			"mode":    quantifier,
+		},
+	}, nil
+}
+
+func (cmd *DescribeValuesCommand) Name() string {
+	return "describe values"
+}
+
 // Execute asks for all metrics with the given name.
 func (cmd *DescribeMetricsCommand) Execute(context ExecutionContext) (Result, error) {
-	data, err := context.MetricMetadataAPI.GetMetricsForTag(cmd.TagKey, cmd.TagValue, metadata.Context{
-		Profiler: context.Profiler,
-	})
+	data, err := context.MetricMetadataAPI.GetMetricsForTag(context.Ctx, cmd.TagKey, cmd.TagValue, metadata.Context{Profiler: context.Profiler})
 	if err != nil {
 		return Result{}, err
 	}
@@ -186,20 +353,86 @@ func (cmd *DescribeMetricsCommand) Name() string {
 type QueryResult struct {
 	Query string `json:"query"`
 	Name  string `json:"name"`
-	Type  string `json:"type"` // one of "series" or "scalars"
+	Type  string `json:"type"` // one of "series", "scalars", "table" or "distribution"
 	// for "series" type
 	Series    []api.Timeseries `json:"series"`
 	Timerange api.Timerange    `json:"timerange,omitempty"`
 	// for "scalar" type
 	Scalars []function.TaggedScalar `json:"scalars,omitempty"`
+	// for "table" type
+	Table *function.Table `json:"table,omitempty"`
+	// for "distribution" type
+	Distribution *function.Distribution `json:"distribution,omitempty"`
+	// for "series" and "scalars" types
+	Format *FormatHint `json:"format,omitempty"` // how clients should render the values
 }
 
 // Execute performs the query represented by the given query string, and returs the result.
 func (cmd *SelectCommand) Execute(context ExecutionContext) (Result, error) {
-	userTimerange, err := api.NewSnappedTimerange(cmd.Context.Start, cmd.Context.End, cmd.Context.Resolution)
+	if context.Stream == nil {
+		return cmd.executeCached(context)
+	}
+	// Results are streamed as they're evaluated when possible; those which
+	// weren't (such as those served from the cache) are streamed at the end.
+	stream := context.Stream
+	streamed := 0
+	context.Stream = func(result QueryResult) error {
+		streamed++
+		return stream(result)
+	}
+	result, err := cmd.executeCached(context)
 	if err != nil {
 		return Result{}, err
 	}
+	for _, queryResult := range result.Body.([]QueryResult)[streamed:] {
+		if err := stream(queryResult); err != nil {
+			return Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// executeCached performs the select, using the result cache if there is one.
+func (cmd *SelectCommand) executeCached(context ExecutionContext) (Result, error) {
+	if context.ResultCache != nil {
+		return context.ResultCache.execute(cmd, context, func() (Result, error) {
+			return cmd.executeUncached(context)
+		})
+	}
+	return cmd.executeUncached(context)
+}
+
+// executeUncached performs the select, retrying it at a coarser resolution if
+// it was too large and the context allows it.
+func (cmd *SelectCommand) executeUncached(context ExecutionContext) (Result, error) {
+	var chosen time.Duration
+	result, err := cmd.execute(context, 0, &chosen)
+	if err == nil || !context.CoarserRetry || chosen == 0 || !exceedsResolutionLimit(err) {
+		return result, err
+	}
+	return cmd.retryCoarser(context, chosen, err)
+}
+
+// selectPlan holds the timeranges of a select, which are decided before
+// anything is fetched.
+type selectPlan struct {
+	user           api.Timerange   // as requested, at the requested resolution
+	widened        api.Timerange   // extended back for functions which need earlier points, at the requested resolution
+	resolution     time.Duration   // chosen by the storage
+	timerange      api.Timerange   // as requested, at the chosen resolution
+	trailingBucket *TrailingBucket // the treatment of an incomplete last bucket, if any
+	slotLimit      int
+	rangeNote      string // if a range rule made the resolution coarser, the note explaining it
+}
+
+// plan chooses the finest resolution offered by the storage which is at
+// least the lower bound, and the timeranges which follow from it. The
+// resolution is set whenever the storage chose it, even if there's an error.
+func (cmd *SelectCommand) plan(context ExecutionContext, lowerBound time.Duration) (selectPlan, error) {
+	userTimerange, err := api.NewSnappedTimerange(cmd.Context.StartMillis(), cmd.Context.EndMillis(), cmd.Context.ResolutionMillis())
+	if err != nil {
+		return selectPlan{}, err
+	}
 	slotLimit := context.SlotLimit
 	defaultLimit := 1000
 	if slotLimit == 0 {
@@ -213,6 +446,9 @@ func (cmd *SelectCommand) Execute(context ExecutionContext) (Result, error) {
 	// end - start <= res * (slots - 2)
 	// so
 	// res >= (end - start) / (slots - 2)
+	if lowerBound > smallestResolution {
+		smallestResolution = lowerBound
+	}
 
 	earliest := new(time.Time)
 	*earliest = userTimerange.Start()
@@ -239,21 +475,66 @@ func (cmd *SelectCommand) Execute(context ExecutionContext) (Result, error) {
 		widenedTimerange = userTimerange
 	}
 
+	ruleResolution, rangeNote, err := cmd.rangeLimits(context.RangeRules, widenedTimerange)
+	if err != nil {
+		return selectPlan{}, err
+	}
+	if ruleResolution <= smallestResolution || ruleResolution <= userTimerange.Resolution() {
+		rangeNote = "" // the resolution would have been at least as coarse anyway
+	}
+	if ruleResolution > smallestResolution {
+		smallestResolution = ruleResolution
+	}
+
 	// Update the timerange by applying the insights of the storage API:
 	chosenResolution, err := context.TimeseriesStorageAPI.ChooseResolution(widenedTimerange, smallestResolution)
 	if err != nil {
-		return Result{}, err
+		return selectPlan{}, err
+	}
+	plan := selectPlan{
+		user:       userTimerange,
+		widened:    widenedTimerange,
+		resolution: chosenResolution,
+		slotLimit:  slotLimit,
+		rangeNote:  rangeNote,
 	}
 
 	chosenTimerange, err := api.NewSnappedTimerange(userTimerange.StartMillis(), userTimerange.EndMillis(), int64(chosenResolution/time.Millisecond))
+	if err != nil {
+		return plan, err
+	}
+
+	now := time.Now
+	if context.Now != nil {
+		now = context.Now
+	}
+	plan.timerange, plan.trailingBucket, err = checkTrailingBucket(context.TrailingBucket, chosenTimerange, now())
+	return plan, err
+}
+
+// leakGracePeriod is how long the goroutines evaluating a select may keep
+// running after it finishes (such as after a timeout) before they're logged
+// as leaked.
+const leakGracePeriod = 30 * time.Second
+
+// execute runs the select at the finest resolution offered by the storage
+// which is at least the lower bound, and stores that resolution in chosen.
+func (cmd *SelectCommand) execute(context ExecutionContext, lowerBound time.Duration, chosen *time.Duration) (Result, error) {
+	plan, err := cmd.plan(context, lowerBound)
+	*chosen = plan.resolution
+	if err != nil {
+		return Result{}, err
+	}
+	chosenTimerange, trailingBucket, slotLimit, chosenResolution := plan.timerange, plan.trailingBucket, plan.slotLimit, plan.resolution
+	collation, err := natural_sort.Lookup(context.Collation)
 	if err != nil {
 		return Result{}, err
 	}
 
 	if chosenTimerange.Slots() > slotLimit {
-		return Result{}, function.NewLimitError(
+		return Result{}, slotLimitError{function.NewLimitError(
 			"Requested number of data points exceeds the configured limit",
-			chosenTimerange.Slots(), slotLimit)
+			chosenTimerange.Slots(), slotLimit)}
 	}
 
 	ctx, cancelFunc := context.Ctx, netcontext.CancelFunc(nil)
@@ -273,10 +554,34 @@ func (cmd *SelectCommand) Execute(context ExecutionContext) (Result, error) {
 		r = registry.Default()
 	}
 
+	storage := context.TimeseriesStorageAPI
+	var maintenance *maintenanceStorage
+	if context.SuppressMaintenance && context.MaintenanceAPI != nil {
+		maintenance = newMaintenanceStorage(storage, context.MaintenanceAPI)
+		storage = maintenance
+	}
+	var partial *partialStorage
+	if context.PartialResults {
+		if chunked, ok := newPartialStorage(storage, ctx); ok {
+			partial = chunked
+			storage = chunked
+		}
+	}
+
+	var sampling *function.Sampling
+	if cmd.Context.Sample > 0 && cmd.Context.Sample < 100 {
+		sampling = function.NewSampling(cmd.Context.Sample)
+	}
+
+	goroutines := function.NewGoroutineCounter(context.GoroutineLimit)
+	defer goroutines.WatchLeaks(leakGracePeriod)
+
 	evaluationContext := function.EvaluationContextBuilder{
 		MetricMetadataAPI:    context.MetricMetadataAPI,
 		FetchLimit:           function.NewFetchCounter(context.FetchLimit),
-		TimeseriesStorageAPI: context.TimeseriesStorageAPI,
+		MemoryLimit:          function.NewMemoryCounter(context.MemoryLimit),
+		Goroutines:           goroutines,
+		TimeseriesStorageAPI: storage,
 		Predicate:            predicate.All(cmd.Predicate, context.AdditionalConstraints),
 		SampleMethod:         cmd.Context.SampleMethod,
 		Timerange:            chosenTimerange,
@@ -284,94 +589,260 @@ func (cmd *SelectCommand) Execute(context ExecutionContext) (Result, error) {
 		Registry:        r,
 		Profiler:        context.Profiler,
 		EvaluationNotes: new(function.EvaluationNotes),
+		FreshnessNotes:  new(function.FreshnessNotes),
+		Strict:          context.Strict,
+		Sampling:        sampling,
+		Labels:          context.Labels,
 
 		Ctx: ctx,
 	}.Build()
 
-	results := make(chan []function.Value, 1)
+	type evaluated struct {
+		index int
+		value function.Value
+	}
+	// Goroutines are never garbage collected, so we need to provide capacity so that the sends always succeed.
+	values := make(chan evaluated, len(cmd.Expressions))
 	errors := make(chan error, 1)
-	// Goroutines are never garbage collected, so we need to provide capacity so that the send always succeeds.
-	go func() {
-		// Evaluate the result, and send it along the goroutines.
-		result, err := function.EvaluateMany(evaluationContext, cmd.Expressions)
+	evaluationContext.Go(func() {
+		// Evaluate the results, and send each along as it's ready.
+		err := function.EvaluateEach(evaluationContext, cmd.Expressions, func(index int, value function.Value) {
+			values <- evaluated{index, value}
+		})
 		if err != nil {
 			errors <- err
-			return
 		}
-		results <- result
-	}()
-	select {
-	case <-ctx.Done():
-		return Result{}, function.NewLimitError("Timeout while executing the query.", context.Timeout, context.Timeout)
-	case err := <-errors:
-		return Result{}, err
-	case result := <-results:
-		description := map[string][]string{}
-		for _, value := range result {
-			listValue, err := value.ToSeriesList(evaluationContext.Timerange())
+	})
+	// Results can only be streamed early if nothing which is decided at the
+	// end (a partial timerange, or a coarser retry) can change them.
+	stream := context.Stream != nil && partial == nil && !context.CoarserRetry
+	result := make([]function.Value, len(cmd.Expressions))
+	ready := make([]bool, len(cmd.Expressions))
+	body := make([]QueryResult, len(cmd.Expressions))
+	streamed := 0
+	for received := 0; received < len(result); received++ {
+		select {
+		case <-ctx.Done():
+			return Result{}, function.NewLimitError("Timeout while executing the query.", context.Timeout, context.Timeout)
+		case err := <-errors:
+			return Result{}, err
+		case value := <-values:
+			result[value.index] = value.value
+			ready[value.index] = true
+		}
+		for stream && streamed < len(result) && ready[streamed] {
+			queryResult, err := cmd.queryResult(context, streamed, result[streamed], chosenTimerange)
 			if err != nil {
-				continue
+				return Result{}, err
 			}
-			list := api.SeriesList(listValue)
-			for _, series := range list.Series {
-				for key, value := range series.TagSet {
-					description[key] = append(description[key], value)
-				}
+			if err := context.Stream(queryResult); err != nil {
+				return Result{}, err
 			}
+			body[streamed] = queryResult
+			streamed++
 		}
-		for key, values := range description {
-			natural_sort.Sort(values)
-			filtered := []string{}
-			for i := range values {
-				if i == 0 || values[i-1] != values[i] {
-					filtered = append(filtered, values[i])
-				}
+	}
+
+	var partialReport *PartialRange
+	if partial != nil {
+		if missing, truncated := partial.missingTail(); truncated {
+			prefix, report, ok := partialRange(chosenTimerange, missing)
+			if !ok {
+				return Result{}, function.NewLimitError("Timeout while executing the query.", context.Timeout, context.Timeout)
 			}
-			description[key] = filtered
+			chosenTimerange = prefix
+			partialReport = &report
 		}
+	}
+	for i := streamed; i < len(result); i++ {
+		if list, ok := result[i].(function.SeriesListValue); ok && partialReport != nil {
+			list.Series = trimSeries(list.Series, chosenTimerange.Slots())
+			result[i] = list
+		}
+		queryResult, err := cmd.queryResult(context, i, result[i], chosenTimerange)
+		if err != nil {
+			return Result{}, err
+		}
+		body[i] = queryResult
+	}
+	if maintenance != nil {
+		if masked := maintenance.maskedSeries(); masked != 0 {
+			evaluationContext.AddNote(maintenanceNote(masked))
+		}
+	}
+	if plan.rangeNote != "" {
+		evaluationContext.AddNote(plan.rangeNote)
+	}
+	if trailingBucket != nil {
+		evaluationContext.AddNote(trailingBucket.note())
+	}
+	if partialReport != nil {
+		evaluationContext.AddNote(partialReport.note())
+	}
+	if sampling != nil {
+		evaluationContext.AddNote(fmt.Sprintf("computed from a %g%% sample of the matching series; sums and counts are scaled up to estimate every series", sampling.Percent))
+	}
 
-		// Body adds the Query as an annotation.
-		// It's a slice of interfaces; it will be cast to an interface
-		// when returned from this function in a Result.
-		body := make([]QueryResult, len(result))
-		for i := range body {
-			if list, ok := result[i].(function.SeriesListValue); ok {
-				body[i] = QueryResult{
-					Query:     cmd.Expressions[i].ExpressionDescription(function.StringQuery()),
-					Name:      cmd.Expressions[i].ExpressionDescription(function.StringName()),
-					Type:      "series",
-					Series:    list.Series,
-					Timerange: chosenTimerange,
-				}
+	description := map[string][]string{}
+	for i, value := range result {
+		list := api.SeriesList{Series: body[i].Series}
+		if body[i].Type != "series" {
+			listValue, err := value.ToSeriesList(evaluationContext.Timerange())
+			if err != nil {
 				continue
 			}
-			if scalars, err := result[i].ToScalarSet(); err == nil {
-				body[i] = QueryResult{
-					Query:   cmd.Expressions[i].ExpressionDescription(function.StringQuery()),
-					Name:    cmd.Expressions[i].ExpressionDescription(function.StringName()),
-					Type:    "scalars",
-					Scalars: scalars,
-				}
-				continue
+			list = api.SeriesList(listValue)
+		}
+		for _, series := range list.Series {
+			for key, value := range series.TagSet {
+				description[key] = append(description[key], value)
+			}
+		}
+	}
+	for key, values := range description {
+		natural_sort.SortWith(collation, values)
+		filtered := []string{}
+		for i := range values {
+			if i == 0 || values[i-1] != values[i] {
+				filtered = append(filtered, values[i])
 			}
-			return Result{}, fmt.Errorf("query %s does not result in a timeseries or scalar.", cmd.Expressions[i].ExpressionDescription(function.StringQuery))
 		}
+		description[key] = filtered
+	}
 
-		return Result{
-			Body: body,
-			Metadata: map[string]interface{}{
-				"description": description,
-				"notes":       evaluationContext.Notes(),
-				"resolution":  chosenResolution,
-			},
+	response := Result{
+		Body: body,
+		Metadata: map[string]interface{}{
+			"description": description,
+			"notes":       evaluationContext.Notes(),
+			"resolution":  chosenResolution,
+			"freshness":   evaluationContext.Freshness(),
+		},
+	}
+	if trailingBucket != nil {
+		response.Metadata["trailing_bucket"] = *trailingBucket
+	}
+	if partialReport != nil {
+		response.Metadata["partial"] = *partialReport
+	}
+	if sampling != nil {
+		response.Metadata["sample"] = SampleReport{Percent: sampling.Percent, Fetches: sampling.Fetches()}
+	}
+	return response, nil
+}
+
+// queryResult checks, fills and orders the value of the i-th expression, and
+// annotates it with its query.
+func (cmd *SelectCommand) queryResult(context ExecutionContext, i int, value function.Value, timerange api.Timerange) (QueryResult, error) {
+	query := cmd.Expressions[i].ExpressionDescription(function.StringQuery())
+	name := cmd.Expressions[i].ExpressionDescription(function.StringName())
+	if list, ok := value.(function.SeriesListValue); ok {
+		if context.Strict {
+			if err := checkStrict(cmd.Expressions[i], list.Series); err != nil {
+				return QueryResult{}, err
+			}
+		}
+		list.Series = cmd.Context.Fill.apply(list.Series)
+		list.Series = orderSeries(list.Series, cmd.Context)
+		return QueryResult{
+			Query:     query,
+			Name:      name,
+			Type:      "series",
+			Series:    list.Series,
+			Timerange: timerange,
+			Format:    seriesFormat(cmd.Expressions[i], list.Series),
 		}, nil
 	}
+	if table, ok := value.(function.Table); ok {
+		if cmd.Context.orders() {
+			return QueryResult{}, fmt.Errorf("'order by' and 'limit' only apply to series and scalars, but %s is a table", query)
+		}
+		return QueryResult{
+			Query: query,
+			Name:  name,
+			Type:  "table",
+			Table: &table,
+		}, nil
+	}
+	if distribution, ok := value.(function.Distribution); ok {
+		if cmd.Context.orders() {
+			return QueryResult{}, fmt.Errorf("'order by' and 'limit' only apply to series and scalars, but %s is a distribution", query)
+		}
+		return QueryResult{
+			Query:        query,
+			Name:         name,
+			Type:         "distribution",
+			Distribution: &distribution,
+		}, nil
+	}
+	if scalars, err := value.ToScalarSet(); err == nil {
+		scalars = orderScalars(scalars, cmd.Context)
+		return QueryResult{
+			Query:   query,
+			Name:    name,
+			Type:    "scalars",
+			Scalars: scalars,
+			Format:  scalarsFormat(cmd.Expressions[i], scalars),
+		}, nil
+	}
+	return QueryResult{}, fmt.Errorf("query %s does not result in a timeseries or scalar.", query)
 }
 
 func (cmd *SelectCommand) Name() string {
 	return "select"
 }
 
+// Query renders the select as a query which parses back into it. Its
+// timerange is absolute, even if it was given relative to now.
+func (cmd *SelectCommand) Query() string {
+	expressions := make([]string, len(cmd.Expressions))
+	for i, expression := range cmd.Expressions {
+		expressions[i] = expression.ExpressionDescription(function.StringQuery())
+	}
+	query := "select " + strings.Join(expressions, ", ")
+	if _, ok := cmd.Predicate.(predicate.TruePredicate); !ok && cmd.Predicate != nil {
+		query += " where " + cmd.Predicate.Query()
+	}
+	return query + " " + cmd.Context.Query()
+}
+
+// Query renders the select context as a property clause.
+func (context SelectContext) Query() string {
+	clauses := []string{fmt.Sprintf("from %d to %d resolution %d", context.StartMillis(), context.EndMillis(), context.ResolutionMillis())}
+	switch context.SampleMethod {
+	case timeseries.SampleMax:
+		clauses = append(clauses, "sample by 'max'")
+	case timeseries.SampleMin:
+		clauses = append(clauses, "sample by 'min'")
+	}
+	if context.OrderBy != "" {
+		direction := "asc"
+		if context.Descending {
+			direction = "desc"
+		}
+		clauses = append(clauses, fmt.Sprintf("order by %s %s", context.OrderBy, direction))
+	}
+	if context.Limit != 0 {
+		clauses = append(clauses, fmt.Sprintf("limit %d", context.Limit))
+	}
+	if context.Sample != 0 {
+		clauses = append(clauses, fmt.Sprintf("sample %s%%", strconv.FormatFloat(context.Sample, 'f', -1, 64)))
+	}
+	switch context.Fill.Method {
+	case FillValue:
+		clauses = append(clauses, "fill "+strconv.FormatFloat(context.Fill.Value, 'g', -1, 64))
+	case FillForward, FillInterpolate:
+		clauses = append(clauses, "fill "+context.Fill.Method)
+	}
+	return strings.Join(clauses, " ")
+}
+
+// SampleReport describes the sample of series used by a sampled select.
+type SampleReport struct {
+	Percent float64                 `json:"percent"`
+	Fetches []function.SampledFetch `json:"fetches"`
+}
+
 //ProfilingCommand is a Command that also performs profiling actions.
 type ProfilingCommand struct {
 	Profiler *inspect.Profiler
@@ -390,9 +861,10 @@ func (cmd ProfilingCommand) Name() string {
 }
 
 func (cmd ProfilingCommand) Execute(context ExecutionContext) (Result, error) {
-	defer cmd.Profiler.Record(fmt.Sprintf("%s.Execute", cmd.Name()))()
+	finish := cmd.Profiler.RecordCPU(fmt.Sprintf("%s.Execute", cmd.Name()))
 	context.Profiler = cmd.Profiler
 	result, err := cmd.Command.Execute(context)
+	finish()
 	if err != nil {
 		return Result{}, err
 	}
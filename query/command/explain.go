@@ -0,0 +1,166 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/predicate"
+	"github.com/square/metrics/timeseries"
+)
+
+// ExplainCommand describes how a select would be evaluated, without fetching
+// anything from the timeseries storage: the timeranges and resolution it
+// would use, the series it would fetch, and the functions it would call.
+// Expensive queries can be checked against the limits before they're run.
+type ExplainCommand struct {
+	Select SelectCommand
+}
+
+// Explanation is the evaluation plan of a select.
+type Explanation struct {
+	Timerange        api.Timerange   `json:"timerange"`         // as requested, at the chosen resolution
+	WidenedTimerange api.Timerange   `json:"widened_timerange"` // including the earlier points needed by functions such as moving averages
+	Slots            int             `json:"slots"`
+	SlotLimit        int             `json:"slot_limit"`
+	Fetches          []FetchPlan     `json:"fetches"`
+	ExpectedFetches  int             `json:"expected_fetches"` // the number of series counted against the fetch limit, including those aggregated by the storage
+	FetchLimit       int             `json:"fetch_limit"`
+	Functions        []string        `json:"functions"` // the functions called, in the order they're first found
+	TrailingBucket   *TrailingBucket `json:"trailing_bucket,omitempty"`
+	Problems         []string        `json:"problems"` // the reasons the select would fail, if any
+}
+
+// FetchPlan describes one fetch of a metric: the series which match its
+// predicate (together with the select's), and those which would be fetched.
+// They differ only when the select is sampled. When the storage computes the
+// aggregate of the series itself, PushedDown names the aggregate, and only
+// its groups are transferred.
+type FetchPlan struct {
+	Metric     api.MetricKey `json:"metric"`
+	Predicate  string        `json:"predicate"`
+	Matched    int           `json:"matched"`
+	Fetched    int           `json:"fetched"`
+	PushedDown string        `json:"pushed_down,omitempty"`
+}
+
+// Execute plans the select. Series are looked up in the metadata, but
+// nothing is fetched from the storage.
+func (cmd *ExplainCommand) Execute(context ExecutionContext) (Result, error) {
+	plan, err := cmd.Select.plan(context, 0)
+	if err != nil {
+		return Result{}, err
+	}
+	widened, err := api.NewSnappedTimerange(plan.widened.StartMillis(), plan.timerange.EndMillis(), plan.timerange.ResolutionMillis())
+	if err != nil {
+		return Result{}, err
+	}
+	explanation := Explanation{
+		Timerange:        plan.timerange,
+		WidenedTimerange: widened,
+		Slots:            plan.timerange.Slots(),
+		SlotLimit:        plan.slotLimit,
+		FetchLimit:       context.FetchLimit,
+		TrailingBucket:   plan.trailingBucket,
+		Fetches:          []FetchPlan{},
+		Functions:        []string{},
+		Problems:         []string{},
+	}
+	if explanation.Slots > explanation.SlotLimit {
+		explanation.Problems = append(explanation.Problems, fmt.Sprintf("the %d points of each series exceed the slot limit of %d", explanation.Slots, explanation.SlotLimit))
+	}
+
+	calls := function.Plan{}
+	for _, expression := range cmd.Select.Expressions {
+		expression.ExpressionDescription(function.PlanMode{Plan: &calls})
+	}
+	explanation.Functions = append(explanation.Functions, calls.Functions...)
+	r := context.Registry
+	if r == nil {
+		r = registry.Default()
+	}
+	for _, name := range calls.Functions {
+		if _, ok := r.GetFunction(name); !ok {
+			explanation.Problems = append(explanation.Problems, fmt.Sprintf("no such function %s", name))
+		}
+	}
+
+	selectPredicate := predicate.All(cmd.Select.Predicate, context.AdditionalConstraints)
+	storage := context.TimeseriesStorageAPI
+	if context.SuppressMaintenance && context.MaintenanceAPI != nil {
+		// Masked fetches aren't pushed down, as when the select is run.
+		storage = newMaintenanceStorage(storage, context.MaintenanceAPI)
+	}
+	for _, fetch := range calls.Fetches {
+		tagSets, err := context.MetricMetadataAPI.GetAllTags(context.Ctx, fetch.Metric, metadata.Context{Profiler: context.Profiler})
+		if err != nil {
+			return Result{}, err
+		}
+		condition := selectPredicate
+		if _, ok := fetch.Predicate.(predicate.TruePredicate); !ok {
+			condition = predicate.All(fetch.Predicate, selectPredicate)
+		}
+		planned := FetchPlan{Metric: fetch.Metric, Predicate: condition.Query()}
+		if pushesDown(r, storage, fetch.Aggregate) {
+			planned.PushedDown = fetch.Aggregate
+		}
+		for _, tagSet := range tagSets {
+			if !condition.Apply(tagSet) {
+				continue
+			}
+			planned.Matched++
+			if sample := cmd.Select.Context.Sample; sample <= 0 || sample >= 100 || function.InSample(tagSet, sample) {
+				planned.Fetched++
+			}
+		}
+		explanation.Fetches = append(explanation.Fetches, planned)
+		explanation.ExpectedFetches += planned.Fetched
+	}
+	if explanation.ExpectedFetches > explanation.FetchLimit {
+		explanation.Problems = append(explanation.Problems, fmt.Sprintf("the %d series fetched exceed the fetch limit of %d", explanation.ExpectedFetches, explanation.FetchLimit))
+	}
+	return Result{
+		Body: explanation,
+		Metadata: map[string]interface{}{
+			"resolution": plan.resolution,
+		},
+	}, nil
+}
+
+// pushesDown determines whether the storage computes the named aggregate of
+// a fetch in its place.
+func pushesDown(r function.Registry, storage timeseries.StorageAPI, aggregate string) bool {
+	if aggregate == "" {
+		return false
+	}
+	registered, ok := r.GetFunction(aggregate)
+	if !ok {
+		return false
+	}
+	metricFunction, ok := registered.(function.MetricFunction)
+	if !ok || metricFunction.Pushdown == "" {
+		return false
+	}
+	aggregating, ok := storage.(timeseries.AggregatingStorageAPI)
+	return ok && aggregating.SupportsAggregation(metricFunction.Pushdown)
+}
+
+func (cmd *ExplainCommand) Name() string {
+	return "explain"
+}
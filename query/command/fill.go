@@ -0,0 +1,76 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function/builtin/transform"
+)
+
+// The methods of a fill policy.
+const (
+	FillNone        = ""            // missing values are kept
+	FillValue       = "value"       // missing values are replaced by a constant
+	FillForward     = "forward"     // missing values are replaced by the last value before them
+	FillInterpolate = "interpolate" // missing values are interpolated between the values around them
+)
+
+// FillPolicy describes how a select fills the missing values (NaN) of the
+// series it returns, as given by its 'fill' clause. It's the default for
+// every expression; the transform.fill functions override it.
+type FillPolicy struct {
+	Method string  // one of the Fill methods
+	Value  float64 // the value which replaces missing values, for FillValue
+}
+
+// ParseFillPolicy parses the value of a 'fill' clause: a number, "forward"
+// or "interpolate".
+func ParseFillPolicy(value string) (FillPolicy, error) {
+	switch value {
+	case FillForward, FillInterpolate:
+		return FillPolicy{Method: value}, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return FillPolicy{}, fmt.Errorf("Expected a number, 'forward' or 'interpolate' for 'fill' but got %s", value)
+	}
+	return FillPolicy{Method: FillValue, Value: number}, nil
+}
+
+// apply returns the series with their missing values filled.
+func (policy FillPolicy) apply(series []api.Timeseries) []api.Timeseries {
+	var fill func([]float64) []float64
+	switch policy.Method {
+	case FillValue:
+		fill = func(values []float64) []float64 {
+			return transform.FillValues(values, policy.Value)
+		}
+	case FillForward:
+		fill = transform.FillForwardValues
+	case FillInterpolate:
+		fill = transform.InterpolateValues
+	default:
+		return series
+	}
+	result := make([]api.Timeseries, len(series))
+	for i := range series {
+		result[i] = series[i]
+		result[i].Values = fill(series[i].Values)
+	}
+	return result
+}
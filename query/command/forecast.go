@@ -0,0 +1,233 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/square/metrics/api"
+)
+
+// forecastConfidence is the quantile of the normal distribution which bounds
+// the (approximately 95%) confidence intervals of forecast crossings.
+const forecastConfidence = 1.96
+
+// The statuses of a forecast series.
+const (
+	ForecastReached      = "reached"           // the series has already reached the threshold
+	ForecastApproaching  = "approaching"       // the trend reaches the threshold in the future
+	ForecastNever        = "never"             // the trend is flat, or moves away from the threshold
+	ForecastInsufficient = "insufficient_data" // too few points to fit a trend
+)
+
+// ForecastCommand fits a linear trend to each series of a select, and
+// estimates when each will reach a threshold, for capacity reports such as
+// "when will each host run out of disk".
+type ForecastCommand struct {
+	Select    SelectCommand
+	Threshold float64
+}
+
+// ForecastResult holds the forecasts of the series of one expression.
+type ForecastResult struct {
+	Query     string           `json:"query"`
+	Name      string           `json:"name"`
+	Threshold float64          `json:"threshold"`
+	Timerange api.Timerange    `json:"timerange"`
+	Series    []SeriesForecast `json:"series"`
+}
+
+// SeriesForecast is the forecast of a single series. Times are milliseconds
+// since the epoch; Earliest and Latest bound the estimate, and Latest is nil
+// when the trend is too uncertain for the threshold to be certain to be reached.
+type SeriesForecast struct {
+	TagSet     api.TagSet `json:"tagset"`
+	Status     string     `json:"status"`
+	Estimate   *int64     `json:"estimate"`
+	Earliest   *int64     `json:"earliest"`
+	Latest     *int64     `json:"latest"`
+	Current    float64    `json:"current"`      // the value of the trend at the end of the timerange
+	RatePerDay float64    `json:"rate_per_day"` // the slope of the trend
+	Points     int        `json:"points"`       // the number of points fitted
+}
+
+// Execute evaluates the select, and forecasts each series it returns.
+func (cmd *ForecastCommand) Execute(context ExecutionContext) (Result, error) {
+	context.Stream = nil // the results are forecasts, not those of the select
+	selected, err := cmd.Select.Execute(context)
+	if err != nil {
+		return Result{}, err
+	}
+	results := selected.Body.([]QueryResult)
+	body := make([]ForecastResult, len(results))
+	for i, result := range results {
+		if result.Type != "series" {
+			return Result{}, fmt.Errorf("forecast expects %s to result in series, not %s", result.Query, result.Type)
+		}
+		forecasts := make([]SeriesForecast, len(result.Series))
+		for j, series := range result.Series {
+			forecasts[j] = forecastSeries(series, result.Timerange, cmd.Threshold)
+		}
+		body[i] = ForecastResult{
+			Query:     result.Query,
+			Name:      result.Name,
+			Threshold: cmd.Threshold,
+			Timerange: result.Timerange,
+			Series:    forecasts,
+		}
+	}
+	return Result{Body: body, Metadata: selected.Metadata}, nil
+}
+
+func (cmd *ForecastCommand) Name() string {
+	return "forecast"
+}
+
+// trend is a least-squares line fitted to a series, as a function of the
+// seconds since the start of its timerange.
+type trend struct {
+	points   int
+	mean     float64 // of the times
+	level    float64 // the value of the line at the mean time
+	slope    float64
+	spread   float64 // the sum of squared deviations of the times from their mean
+	residual float64 // the standard deviation of the residuals
+}
+
+func fitTrend(values []float64, timerange api.Timerange) trend {
+	step := timerange.Resolution().Seconds()
+	fit := trend{}
+	sumY := 0.0
+	for i, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		fit.points++
+		fit.mean += float64(i) * step
+		sumY += value
+	}
+	if fit.points == 0 {
+		return fit
+	}
+	fit.mean /= float64(fit.points)
+	fit.level = sumY / float64(fit.points)
+	covariance := 0.0
+	for i, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		dx := float64(i)*step - fit.mean
+		fit.spread += dx * dx
+		covariance += dx * (value - fit.level)
+	}
+	if fit.spread == 0 {
+		return fit
+	}
+	fit.slope = covariance / fit.spread
+	if fit.points > 2 {
+		squares := 0.0
+		for i, value := range values {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+			deviation := value - fit.at(float64(i)*step)
+			squares += deviation * deviation
+		}
+		fit.residual = math.Sqrt(squares / float64(fit.points-2))
+	}
+	return fit
+}
+
+// at is the value of the line at the given time.
+func (fit trend) at(t float64) float64 {
+	return fit.level + fit.slope*(t-fit.mean)
+}
+
+// crossings bounds the time at which the line reaches the threshold, as the
+// times at which the edges of its confidence band do (Fieller's interval).
+// The interval is unbounded when the slope is not significantly different
+// from zero.
+func (fit trend) crossings(threshold float64) (float64, float64) {
+	k := forecastConfidence * forecastConfidence * fit.residual * fit.residual
+	d := threshold - fit.level
+	// (d - slope*u)^2 = k*(1/n + u^2/spread), for u = t - mean.
+	a := fit.slope*fit.slope - k/fit.spread
+	b := -2 * d * fit.slope
+	c := d*d - k/float64(fit.points)
+	if a <= 0 {
+		// The slope is not significantly different from zero, so the
+		// threshold may be reached at any time, or never.
+		return math.Inf(-1), math.Inf(1)
+	}
+	discriminant := math.Max(b*b-4*a*c, 0)
+	lower := (-b - math.Sqrt(discriminant)) / (2 * a)
+	upper := (-b + math.Sqrt(discriminant)) / (2 * a)
+	return fit.mean + lower, fit.mean + upper
+}
+
+// forecastSeries fits a trend to the series, and estimates when it reaches
+// the threshold. The threshold is approached from whichever side the series
+// ends on.
+func forecastSeries(series api.Timeseries, timerange api.Timerange, threshold float64) SeriesForecast {
+	forecast := SeriesForecast{TagSet: series.TagSet}
+	fit := fitTrend(series.Values, timerange)
+	forecast.Points = fit.points
+	if fit.points < 3 || fit.spread == 0 {
+		forecast.Status = ForecastInsufficient
+		return forecast
+	}
+	end := timerange.End().Sub(timerange.Start()).Seconds()
+	forecast.Current = fit.at(end)
+	forecast.RatePerDay = fit.slope * 86400
+	rising := forecast.Current < threshold
+	if forecast.Current == threshold || reached(series.Values, threshold, rising) {
+		forecast.Status = ForecastReached
+		return forecast
+	}
+	if fit.slope == 0 || (fit.slope > 0) != rising {
+		forecast.Status = ForecastNever
+		return forecast
+	}
+	forecast.Status = ForecastApproaching
+	earliest, latest := fit.crossings(threshold)
+	estimate := fit.mean + (threshold-fit.level)/fit.slope
+	// The band may reach the threshold within the timerange, though the line doesn't.
+	earliest = math.Max(earliest, end)
+	millis := func(seconds float64) *int64 {
+		result := timerange.StartMillis() + int64(math.Round(seconds*1000))
+		return &result
+	}
+	forecast.Estimate = millis(estimate)
+	forecast.Earliest = millis(earliest)
+	if !math.IsInf(latest, 0) && latest >= estimate {
+		forecast.Latest = millis(latest)
+	}
+	return forecast
+}
+
+// reached reports whether the last point of the series is at or beyond the threshold.
+func reached(values []float64, threshold float64, rising bool) bool {
+	for i := len(values) - 1; i >= 0; i-- {
+		if math.IsNaN(values[i]) {
+			continue
+		}
+		if rising {
+			return values[i] >= threshold
+		}
+		return values[i] <= threshold
+	}
+	return false
+}
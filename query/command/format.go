@@ -0,0 +1,176 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"math"
+	"strings"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+)
+
+// FormatHint suggests how clients render the values of an expression, so
+// that they all render them alike: values are divided by Scale, shown with
+// Decimals decimal places, and labelled with Label. For instance, 1234567
+// bytes per second is rendered as "1.23 MB/s".
+type FormatHint struct {
+	Unit     string  `json:"unit,omitempty"`   // such as "B/s", if known
+	Prefix   string  `json:"prefix,omitempty"` // the SI prefix, such as "M"
+	Scale    float64 `json:"scale"`            // the value of the prefix, such as 1e6
+	Decimals int     `json:"decimals"`         // the decimal places which show three significant digits of the largest value
+	Label    string  `json:"label,omitempty"`  // for the axis, such as "MB/s"
+}
+
+// metricUnits are the units of the metrics whose names end in their words.
+var metricUnits = map[string]string{
+	"bytes":        "B",
+	"bits":         "b",
+	"seconds":      "s",
+	"secs":         "s",
+	"ms":           "ms",
+	"millis":       "ms",
+	"milliseconds": "ms",
+	"percent":      "%",
+	"pct":          "%",
+}
+
+// prefixedUnits are the units which take SI prefixes. Values without a unit
+// take them too.
+var prefixedUnits = map[string]bool{"": true, "B": true, "b": true, "B/s": true, "b/s": true}
+
+// siPrefixes are the prefixes of positive powers of 1000.
+var siPrefixes = []string{"", "k", "M", "G", "T", "P", "E"}
+
+// unitPreserving are the functions whose results have the unit of their
+// series argument. Functions outside this list (and their prefixes) make the
+// unit of an expression unknown.
+var unitPreserving = []string{
+	"aggregate.max", "aggregate.mean", "aggregate.median", "aggregate.min", "aggregate.sum",
+	"filter.", "tag.",
+	"transform.abs", "transform.bound", "transform.clamp", "transform.fill", "transform.interpolate",
+	"transform.lower_bound", "transform.moving_", "transform.nan_", "transform.timeshift", "transform.upper_bound",
+	"+", "-",
+}
+
+// perSecond are the functions whose results are their argument's unit per second.
+var perSecond = map[string]bool{"transform.rate": true, "transform.derivative": true}
+
+// metricUnit returns the unit of the metric from the last words of its
+// name, such as "B/s" for "net.bytes_per_second".
+func metricUnit(metric api.MetricKey) string {
+	words := strings.FieldsFunc(strings.ToLower(string(metric)), func(r rune) bool {
+		return r == '.' || r == '_' || r == '-'
+	})
+	suffix := ""
+	if n := len(words); n >= 3 && words[n-2] == "per" && (words[n-1] == "second" || words[n-1] == "sec") {
+		words = words[:n-2]
+		suffix = "/s"
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	unit, ok := metricUnits[words[len(words)-1]]
+	if !ok {
+		return ""
+	}
+	return unit + suffix
+}
+
+// expressionUnit returns the unit of the expression's results, or "" if it
+// isn't known: the expression's metrics must share a unit, and it may only
+// call functions which keep their argument's unit (or make it per second).
+func expressionUnit(expression function.Expression) string {
+	plan := function.Plan{}
+	expression.ExpressionDescription(function.PlanMode{Plan: &plan})
+	if len(plan.Fetches) == 0 {
+		return ""
+	}
+	unit := metricUnit(plan.Fetches[0].Metric)
+	for _, fetch := range plan.Fetches[1:] {
+		if metricUnit(fetch.Metric) != unit {
+			return ""
+		}
+	}
+	rates := 0
+	for _, name := range plan.Functions {
+		if perSecond[name] {
+			rates++
+			continue
+		}
+		preserved := false
+		for _, prefix := range unitPreserving {
+			if name == prefix || (strings.HasSuffix(prefix, ".") || strings.HasSuffix(prefix, "_")) && strings.HasPrefix(name, prefix) {
+				preserved = true
+				break
+			}
+		}
+		if !preserved {
+			return ""
+		}
+	}
+	switch {
+	case rates > 1 || (rates == 1 && strings.HasSuffix(unit, "/s")):
+		return ""
+	case rates == 1 && unit != "":
+		return unit + "/s"
+	}
+	return unit
+}
+
+// formatHint suggests the format of values of the unit, from the largest of
+// their magnitudes.
+func formatHint(unit string, values [][]float64) *FormatHint {
+	largest := 0.0
+	for _, list := range values {
+		for _, value := range list {
+			if !math.IsNaN(value) && !math.IsInf(value, 0) {
+				largest = math.Max(largest, math.Abs(value))
+			}
+		}
+	}
+	hint := &FormatHint{Unit: unit, Scale: 1}
+	if prefixedUnits[unit] {
+		for power := len(siPrefixes) - 1; power > 0; power-- {
+			if scale := math.Pow(1000, float64(power)); largest >= scale {
+				hint.Prefix = siPrefixes[power]
+				hint.Scale = scale
+				break
+			}
+		}
+	}
+	if scaled := largest / hint.Scale; scaled > 0 {
+		// Three significant digits, and at most six decimal places.
+		hint.Decimals = int(math.Max(0, math.Min(6, 2-math.Floor(math.Log10(scaled)))))
+	}
+	hint.Label = hint.Prefix + unit
+	return hint
+}
+
+func seriesFormat(expression function.Expression, series []api.Timeseries) *FormatHint {
+	values := make([][]float64, len(series))
+	for i := range series {
+		values[i] = series[i].Values
+	}
+	return formatHint(expressionUnit(expression), values)
+}
+
+func scalarsFormat(expression function.Expression, scalars []function.TaggedScalar) *FormatHint {
+	values := make([]float64, len(scalars))
+	for i := range scalars {
+		values[i] = scalars[i].Value
+	}
+	return formatHint(expressionUnit(expression), [][]float64{values})
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/square/metrics/api"
+)
+
+// FuzzyMode is the describe mode which ranks metrics by their similarity to
+// the match text instead of applying it as a regular expression.
+const FuzzyMode = "fuzzy"
+
+// fuzzyThreshold is the minimum similarity for a metric to be included in the
+// results of a fuzzy search when it does not contain the search text.
+const fuzzyThreshold = 0.6
+
+// ScoredMetric is a metric found by a fuzzy search along with its score.
+// Scores range from 0 to 1, with 1 being an exact match.
+type ScoredMetric struct {
+	Metric api.MetricKey `json:"metric"`
+	Score  float64       `json:"score"`
+}
+
+// normalizeForSearch lowercases the text and removes punctuation and spaces,
+// since users often don't know how a metric name is cased or separated.
+func normalizeForSearch(text string) []rune {
+	result := []rune{}
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// substringDistance is the least number of edits needed to turn the query into
+// some substring of the text.
+func substringDistance(query []rune, text []rune) int {
+	previous := make([]int, len(text)+1) // any substring may start for free
+	current := make([]int, len(text)+1)
+	for i := 1; i <= len(query); i++ {
+		current[0] = i
+		for j := 1; j <= len(text); j++ {
+			cost := 1
+			if query[i-1] == text[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	best := len(query)
+	for _, distance := range previous {
+		if distance < best {
+			best = distance
+		}
+	}
+	return best
+}
+
+// fuzzyScore rates how well the metric name matches the query. Names which
+// contain the query score above 0.5, with shorter names scoring higher, and
+// names which nearly contain it score below 0.5. Other names score 0.
+func fuzzyScore(query string, name string) float64 {
+	q := normalizeForSearch(query)
+	n := normalizeForSearch(name)
+	if len(q) == 0 {
+		return 0
+	}
+	if string(q) == string(n) {
+		return 1
+	}
+	if strings.Contains(string(n), string(q)) {
+		return 0.5 + 0.4*float64(len(q))/float64(len(n))
+	}
+	similarity := 1 - float64(substringDistance(q, n))/float64(len(q))
+	if similarity < fuzzyThreshold {
+		return 0
+	}
+	return 0.5 * similarity
+}
+
+// fuzzySearch ranks the metrics by their score for the query, omitting those
+// which do not match at all. Ties are ordered by name.
+func fuzzySearch(query string, metrics []api.MetricKey) []ScoredMetric {
+	result := []ScoredMetric{}
+	for _, metric := range metrics {
+		if score := fuzzyScore(query, string(metric)); score > 0 {
+			result = append(result, ScoredMetric{Metric: metric, Score: score})
+		}
+	}
+	sort.Sort(scoredMetrics(result))
+	return result
+}
+
+type scoredMetrics []ScoredMetric
+
+func (s scoredMetrics) Len() int {
+	return len(s)
+}
+
+func (s scoredMetrics) Less(i, j int) bool {
+	if s[i].Score != s[j].Score {
+		return s[i].Score > s[j].Score
+	}
+	return s[i].Metric < s[j].Metric
+}
+
+func (s scoredMetrics) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+)
+
+// RangeRule limits the selects of the metrics matching its pattern, so that
+// combinations the storage can't serve cheaply (such as months of a verbose
+// debugging metric at its finest resolution) are refused before anything is
+// fetched.
+type RangeRule struct {
+	Metrics       *regexp.Regexp // matched against the whole metric name
+	MaxRange      time.Duration  // optional. The longest timerange which may be fetched, including the earlier points needed by functions such as moving averages
+	MinResolution time.Duration  // optional. The finest resolution at which the metrics may be fetched; selects of them are computed at this resolution or a coarser one
+}
+
+// NewRangeRule creates a rule for the metrics whose names match the pattern.
+func NewRangeRule(pattern string, maxRange time.Duration, minResolution time.Duration) (RangeRule, error) {
+	regex, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return RangeRule{}, fmt.Errorf("invalid metric pattern %q: %s", pattern, err.Error())
+	}
+	if maxRange < 0 || minResolution < 0 {
+		return RangeRule{}, fmt.Errorf("the limits of metric pattern %q cannot be negative", pattern)
+	}
+	return RangeRule{Metrics: regex, MaxRange: maxRange, MinResolution: minResolution}, nil
+}
+
+// rangeLimits checks the fetches of the select against the rules, returning
+// the finest resolution permitted and a note naming the metric which
+// requires it, if any.
+func (cmd *SelectCommand) rangeLimits(rules []RangeRule, widened api.Timerange) (time.Duration, string, error) {
+	if len(rules) == 0 {
+		return 0, "", nil
+	}
+	calls := function.Plan{}
+	for _, expression := range cmd.Expressions {
+		expression.ExpressionDescription(function.PlanMode{Plan: &calls})
+	}
+	minimum := time.Duration(0)
+	note := ""
+	for _, fetch := range calls.Fetches {
+		for _, rule := range rules {
+			if !rule.Metrics.MatchString(string(fetch.Metric)) {
+				continue
+			}
+			if rule.MaxRange != 0 && widened.Duration() > rule.MaxRange {
+				return 0, "", function.NewLimitError(
+					fmt.Sprintf("The metric %s may only be selected over at most %s, but the select fetches %s", fetch.Metric, rule.MaxRange, widened.Duration()),
+					widened.Duration(), rule.MaxRange)
+			}
+			if rule.MinResolution > minimum {
+				minimum = rule.MinResolution
+				note = fmt.Sprintf("The metric %s may only be selected at a resolution of %s or coarser.", fetch.Metric, rule.MinResolution)
+			}
+		}
+	}
+	return minimum, note, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/predicate"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/timeseries"
+)
+
+// MaintenanceWindow is a period of planned downtime for the series matching its predicate.
+type MaintenanceWindow struct {
+	Predicate predicate.Predicate
+	Start     time.Time // inclusive
+	End       time.Time // exclusive
+}
+
+// MaintenanceAPI provides the maintenance windows which overlap a timerange,
+// such as those recorded as "maintenance" annotations.
+type MaintenanceAPI interface {
+	GetMaintenanceWindows(timerange api.Timerange) ([]MaintenanceWindow, error)
+}
+
+// maintenanceStorage masks the points of fetched series which fall inside
+// their maintenance windows, before any function is evaluated, so that the
+// series derived from them (aggregates, joins, arithmetic) exclude the windows
+// too. It doesn't offer aggregation pushdown, which would skip the masking.
+type maintenanceStorage struct {
+	timeseries.StorageAPI
+	maintenance MaintenanceAPI
+
+	mutex  sync.Mutex
+	masked int // the number of fetched series which had points masked
+}
+
+func newMaintenanceStorage(storage timeseries.StorageAPI, maintenance MaintenanceAPI) *maintenanceStorage {
+	return &maintenanceStorage{StorageAPI: storage, maintenance: maintenance}
+}
+
+// maskedSeries returns the number of fetched series which had points masked.
+func (s *maintenanceStorage) maskedSeries() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.masked
+}
+
+func (s *maintenanceStorage) FetchSingleTimeseries(request timeseries.FetchRequest) (api.Timeseries, error) {
+	list, err := s.FetchMultipleTimeseries(timeseries.FetchMultipleRequest{
+		Metrics:        []api.TaggedMetric{request.Metric},
+		RequestDetails: request.RequestDetails,
+	})
+	if err != nil {
+		return api.Timeseries{}, err
+	}
+	return list.Series[0], nil
+}
+
+func (s *maintenanceStorage) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	// The windows are looked up for each fetch, since fetches may be shifted
+	// or widened beyond the timerange of the select.
+	windows, err := s.maintenance.GetMaintenanceWindows(request.Timerange)
+	if err != nil {
+		return api.SeriesList{}, err
+	}
+	list, err := s.StorageAPI.FetchMultipleTimeseries(request)
+	if err != nil || len(windows) == 0 {
+		return list, err
+	}
+	masked := 0
+	for i := range list.Series {
+		if series, ok := suppressMaintenance(list.Series[i], windows, request.Timerange); ok {
+			list.Series[i] = series
+			masked++
+		}
+	}
+	s.mutex.Lock()
+	s.masked += masked
+	s.mutex.Unlock()
+	return list, nil
+}
+
+// suppressMaintenance returns a copy of the series where every point falling
+// inside a matching maintenance window is replaced by NaN, and whether any
+// point was.
+func suppressMaintenance(original api.Timeseries, windows []MaintenanceWindow, timerange api.Timerange) (api.Timeseries, bool) {
+	result := original
+	masked := false
+	for _, window := range windows {
+		if window.Predicate != nil && !window.Predicate.Apply(original.TagSet) {
+			continue
+		}
+		for j := range original.Values {
+			t := timerange.TimeOfIndex(j)
+			if t.Before(window.Start) || !t.Before(window.End) {
+				continue
+			}
+			if !masked {
+				result.Values = append([]float64(nil), original.Values...)
+				if original.Samples != nil {
+					result.Samples = append([]int(nil), original.Samples...)
+				}
+				if original.Sketches != nil {
+					result.Sketches = append([]*tdigest.Digest(nil), original.Sketches...)
+				}
+				masked = true
+			}
+			result.Values[j] = math.NaN()
+			if j < len(result.Samples) {
+				result.Samples[j] = 0
+			}
+			if j < len(result.Sketches) {
+				result.Sketches[j] = nil
+			}
+		}
+	}
+	return result, masked
+}
+
+// maintenanceNote describes the suppression for the notes of a query result.
+func maintenanceNote(masked int) string {
+	return fmt.Sprintf("%d fetched series had points suppressed during maintenance windows", masked)
+}
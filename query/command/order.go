@@ -0,0 +1,138 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/function/builtin/aggregate"
+)
+
+// orderSummaries are the summaries which may be used in an 'order by' clause.
+var orderSummaries = map[string]func([]float64) float64{
+	"max":   aggregate.Max,
+	"min":   aggregate.Min,
+	"mean":  aggregate.Mean,
+	"sum":   aggregate.Sum,
+	"count": aggregate.Count,
+	"current": func(values []float64) float64 {
+		for i := len(values) - 1; i >= 0; i-- {
+			if !math.IsNaN(values[i]) {
+				return values[i]
+			}
+		}
+		return math.NaN()
+	},
+}
+
+// IsOrderSummary returns true if the name can be used in an 'order by' clause.
+func IsOrderSummary(name string) bool {
+	_, ok := orderSummaries[name]
+	return ok
+}
+
+// OrderSummaries describes the summaries which can be used in an 'order by' clause.
+func OrderSummaries() string {
+	names := []string{}
+	for name := range orderSummaries {
+		names = append(names, "'"+name+"'")
+	}
+	sort.Strings(names)
+	return "(one of " + strings.Join(names, ", ") + ")"
+}
+
+// orderSeries applies the 'order by' and 'limit' clauses of the select context
+// to the series of an expression. Series whose summary is NaN are placed last
+// in either direction, and ties are broken by tagset so the order is stable.
+func orderSeries(series []api.Timeseries, context SelectContext) []api.Timeseries {
+	summary, ok := orderSummaries[context.OrderBy]
+	if !ok && (context.Limit == 0 || len(series) <= context.Limit) {
+		return series
+	}
+	result := append([]api.Timeseries(nil), series...)
+	if ok {
+		keys := make([]float64, len(result))
+		names := make([]string, len(result))
+		for i := range result {
+			keys[i] = summary(result[i].Values)
+			names[i] = result[i].TagSet.Serialize()
+		}
+		sort.Sort(seriesOrder{series: result, keys: keys, names: names, descending: context.Descending})
+	}
+	if context.Limit != 0 && len(result) > context.Limit {
+		result = result[:context.Limit]
+	}
+	return result
+}
+
+// orderScalars applies the 'order by' and 'limit' clauses of the select
+// context to the scalars of an expression, each of which is ordered as a
+// series of a single point.
+func orderScalars(scalars []function.TaggedScalar, context SelectContext) []function.TaggedScalar {
+	series := make([]api.Timeseries, len(scalars))
+	for i, scalar := range scalars {
+		series[i] = api.Timeseries{Values: []float64{scalar.Value}, TagSet: scalar.TagSet}
+	}
+	series = orderSeries(series, context)
+	result := make([]function.TaggedScalar, len(series))
+	for i := range series {
+		result[i] = function.TaggedScalar{TagSet: series[i].TagSet, Value: series[i].Values[0]}
+	}
+	return result
+}
+
+// orders is whether the select context has an 'order by' or 'limit' clause.
+func (context SelectContext) orders() bool {
+	return context.OrderBy != "" || context.Limit != 0
+}
+
+// seriesOrder sorts series by their precomputed summaries.
+type seriesOrder struct {
+	series     []api.Timeseries
+	keys       []float64
+	names      []string
+	descending bool
+}
+
+func (order seriesOrder) Len() int {
+	return len(order.series)
+}
+
+func (order seriesOrder) Less(i, j int) bool {
+	left, right := order.keys[i], order.keys[j]
+	if math.IsNaN(left) || math.IsNaN(right) {
+		if math.IsNaN(left) && math.IsNaN(right) {
+			return order.names[i] < order.names[j]
+		}
+		return math.IsNaN(right)
+	}
+	if left == right {
+		return order.names[i] < order.names[j]
+	}
+	if order.descending {
+		return left > right
+	}
+	return left < right
+}
+
+func (order seriesOrder) Swap(i, j int) {
+	order.series[i], order.series[j] = order.series[j], order.series[i]
+	order.keys[i], order.keys[j] = order.keys[j], order.keys[i]
+	order.names[i], order.names[j] = order.names[j], order.names[i]
+}
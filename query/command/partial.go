@@ -0,0 +1,298 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/timeseries"
+)
+
+// A select with partial results enabled and a deadline fetches each series
+// whole, as usual, until the soft deadline. A fetch which is still running at
+// the soft deadline is retried in chunks, from the start of its timerange, as
+// are all the fetches after it; once the chunk deadline passes, the chunks not
+// yet fetched are left empty, and the query is trimmed to the prefix of its
+// timerange which every fetch completed, instead of failing with a timeout.
+// The chunk deadline leaves the rest of the time to evaluate what was fetched.
+const (
+	partialChunks        = 8   // the number of chunks each fetch is split into, once the soft deadline passes
+	partialFetchFraction = 0.6 // the fraction of the time until the deadline spent fetching whole series
+	partialChunkFraction = 0.8 // the fraction of the time until the deadline after which no more chunks are fetched
+)
+
+// PartialRange describes the prefix of the timerange returned by a select
+// which ran out of time.
+type PartialRange struct {
+	RequestedEnd time.Time `json:"requested_end"`
+	End          time.Time `json:"end"`      // the last point returned
+	Fraction     float64   `json:"fraction"` // the fraction of the requested points returned
+}
+
+// note describes the range for the notes of a query.
+func (partial PartialRange) note() string {
+	return fmt.Sprintf("The query ran out of time, so only the first %.0f%% of its timerange (until %s) was computed.", partial.Fraction*100, partial.End.UTC().Format(time.RFC3339))
+}
+
+// partialStorage fetches whole series until the soft deadline, then chunk by
+// chunk until the chunk deadline, and records the longest tail which a fetch
+// left empty. Tails are measured from the end of each fetch, rather than as
+// times, so that they also apply to fetches whose timerange was shifted or
+// widened. It offers aggregation pushdown when the storage beneath it does.
+type partialStorage struct {
+	timeseries.StorageAPI
+	softDeadline  time.Time
+	chunkDeadline time.Time
+
+	mutex     sync.Mutex
+	chunking  bool // whether a fetch ran past the soft deadline
+	truncated bool
+	missing   time.Duration // the longest tail left empty
+}
+
+var _ timeseries.AggregatingStorageAPI = (*partialStorage)(nil)
+
+func newPartialStorage(storage timeseries.StorageAPI, ctx context.Context) (*partialStorage, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil, false
+	}
+	now := time.Now()
+	remaining := deadline.Sub(now)
+	return &partialStorage{
+		StorageAPI:    storage,
+		softDeadline:  now.Add(time.Duration(float64(remaining) * partialFetchFraction)),
+		chunkDeadline: now.Add(time.Duration(float64(remaining) * partialChunkFraction)),
+	}, true
+}
+
+// missingTail returns the longest tail left empty, and whether any fetch was
+// truncated.
+func (s *partialStorage) missingTail() (time.Duration, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.missing, s.truncated
+}
+
+// truncate records that the fetch of the timerange stopped at the slot.
+func (s *partialStorage) truncate(timerange api.Timerange, slot int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	missing := time.Duration(timerange.Slots()-slot) * timerange.Resolution()
+	if missing > s.missing {
+		s.missing = missing
+	}
+	s.truncated = true
+}
+
+// isChunking reports whether fetches are made in chunks, and starts doing so
+// if asked to.
+func (s *partialStorage) isChunking(start bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if start {
+		s.chunking = true
+	}
+	return s.chunking
+}
+
+func (s *partialStorage) FetchSingleTimeseries(request timeseries.FetchRequest) (api.Timeseries, error) {
+	list, err := s.FetchMultipleTimeseries(timeseries.FetchMultipleRequest{
+		Metrics:        []api.TaggedMetric{request.Metric},
+		RequestDetails: request.RequestDetails,
+	})
+	if err != nil {
+		return api.Timeseries{}, err
+	}
+	return list.Series[0], nil
+}
+
+func (s *partialStorage) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	// A fetch which is cut short before its first chunk is left empty.
+	empty := api.SeriesList{Series: make([]api.Timeseries, len(request.Metrics))}
+	for i := range empty.Series {
+		empty.Series[i] = emptySeries(request.Metrics[i].TagSet, request.Timerange.Slots())
+	}
+	return s.fetch(request.RequestDetails, empty, func(details timeseries.RequestDetails) (api.SeriesList, error) {
+		return s.StorageAPI.FetchMultipleTimeseries(timeseries.FetchMultipleRequest{
+			Metrics:        request.Metrics,
+			RequestDetails: details,
+		})
+	})
+}
+
+func (s *partialStorage) SupportsAggregation(aggregation timeseries.Aggregation) bool {
+	aggregating, ok := s.StorageAPI.(timeseries.AggregatingStorageAPI)
+	return ok && aggregating.SupportsAggregation(aggregation)
+}
+
+func (s *partialStorage) FetchAggregatedTimeseries(request timeseries.FetchAggregatedRequest) (api.SeriesList, error) {
+	aggregating, ok := s.StorageAPI.(timeseries.AggregatingStorageAPI)
+	if !ok {
+		return api.SeriesList{}, fmt.Errorf("the storage can't aggregate series")
+	}
+	// The groups aren't known until they're fetched, but a fetch which is cut
+	// short before its first chunk leaves nothing of the query anyway.
+	return s.fetch(request.RequestDetails, api.SeriesList{}, func(details timeseries.RequestDetails) (api.SeriesList, error) {
+		chunk := request
+		chunk.RequestDetails = details
+		return aggregating.FetchAggregatedTimeseries(chunk)
+	})
+}
+
+// fetch fetches the whole timerange of the request, unless the soft deadline
+// has passed, and in chunks otherwise.
+func (s *partialStorage) fetch(details timeseries.RequestDetails, empty api.SeriesList, fetch func(timeseries.RequestDetails) (api.SeriesList, error)) (api.SeriesList, error) {
+	if !s.isChunking(false) {
+		soft, cancel := context.WithDeadline(details.Ctx, s.softDeadline)
+		whole := details
+		whole.Ctx = soft
+		list, err := fetch(whole)
+		late := soft.Err() != nil && details.Ctx.Err() == nil
+		cancel()
+		if err == nil || !late {
+			return list, err
+		}
+		s.isChunking(true)
+	}
+	return s.fetchChunks(details, empty, fetch)
+}
+
+// fetchChunks fetches the timerange of the request chunk by chunk until the
+// chunk deadline.
+func (s *partialStorage) fetchChunks(details timeseries.RequestDetails, empty api.SeriesList, fetch func(timeseries.RequestDetails) (api.SeriesList, error)) (api.SeriesList, error) {
+	timerange := details.Timerange
+	slots := timerange.Slots()
+	result := empty
+	var positions map[string]int
+	if len(empty.Series) == 0 {
+		positions = map[string]int{}
+	}
+	soft, cancel := context.WithDeadline(details.Ctx, s.chunkDeadline)
+	defer cancel()
+	chunkSlots := (slots + partialChunks - 1) / partialChunks
+	for start := 0; start < slots; start += chunkSlots {
+		end := start + chunkSlots
+		if end > slots {
+			end = slots
+		}
+		if soft.Err() != nil && details.Ctx.Err() == nil {
+			s.truncate(timerange, start)
+			return result, nil
+		}
+		chunk, err := api.NewTimerange(
+			timerange.StartMillis()+int64(start)*timerange.ResolutionMillis(),
+			timerange.StartMillis()+int64(end-1)*timerange.ResolutionMillis(),
+			timerange.ResolutionMillis(),
+		)
+		if err != nil {
+			return api.SeriesList{}, err
+		}
+		chunkDetails := details
+		chunkDetails.Timerange = chunk
+		chunkDetails.Ctx = soft
+		fetched, err := fetch(chunkDetails)
+		if err != nil {
+			if soft.Err() != nil && details.Ctx.Err() == nil {
+				s.truncate(timerange, start)
+				return result, nil
+			}
+			return api.SeriesList{}, err
+		}
+		if positions != nil {
+			// The groups of an aggregated fetch are matched by their tags,
+			// since their order may differ from chunk to chunk.
+			for _, series := range fetched.Series {
+				key := series.TagSet.Serialize()
+				if _, ok := positions[key]; !ok {
+					positions[key] = len(result.Series)
+					result.Series = append(result.Series, emptySeries(series.TagSet, slots))
+				}
+			}
+		}
+		for j := range fetched.Series {
+			i := j
+			if positions != nil {
+				i = positions[fetched.Series[j].TagSet.Serialize()]
+			}
+			copy(result.Series[i].Values[start:end], fetched.Series[j].Values)
+			if fetched.Series[j].Samples != nil {
+				if result.Series[i].Samples == nil {
+					result.Series[i].Samples = make([]int, slots)
+				}
+				copy(result.Series[i].Samples[start:end], fetched.Series[j].Samples)
+			}
+			if fetched.Series[j].Sketches != nil {
+				if result.Series[i].Sketches == nil {
+					result.Series[i].Sketches = make([]*tdigest.Digest, slots)
+				}
+				copy(result.Series[i].Sketches[start:end], fetched.Series[j].Sketches)
+			}
+		}
+	}
+	return result, nil
+}
+
+// emptySeries returns a series of the given number of NaN points.
+func emptySeries(tagset api.TagSet, slots int) api.Timeseries {
+	series := api.Timeseries{TagSet: tagset, Values: make([]float64, slots)}
+	for i := range series.Values {
+		series.Values[i] = math.NaN()
+	}
+	return series
+}
+
+// partialRange finds the prefix of the timerange which has no missing tail.
+// It returns false if nothing is left.
+func partialRange(timerange api.Timerange, missing time.Duration) (api.Timerange, PartialRange, bool) {
+	resolution := timerange.Resolution()
+	slots := timerange.Slots() - int((missing+resolution-1)/resolution)
+	if slots <= 0 {
+		return timerange, PartialRange{}, false
+	}
+	prefix, err := api.NewTimerange(timerange.StartMillis(), timerange.StartMillis()+int64(slots-1)*timerange.ResolutionMillis(), timerange.ResolutionMillis())
+	if err != nil {
+		return timerange, PartialRange{}, false
+	}
+	return prefix, PartialRange{
+		RequestedEnd: timerange.End(),
+		End:          prefix.End(),
+		Fraction:     float64(slots) / float64(timerange.Slots()),
+	}, true
+}
+
+// trimSeries removes the points of the series after the given number of slots.
+func trimSeries(series []api.Timeseries, slots int) []api.Timeseries {
+	trimmed := make([]api.Timeseries, len(series))
+	for i := range series {
+		trimmed[i] = series[i]
+		if len(series[i].Values) > slots {
+			trimmed[i].Values = series[i].Values[:slots]
+		}
+		if len(series[i].Samples) > slots {
+			trimmed[i].Samples = series[i].Samples[:slots]
+		}
+		if len(series[i].Sketches) > slots {
+			trimmed[i].Sketches = series[i].Sketches[:slots]
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,52 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+)
+
+// checkStrict returns an error in strict mode when the result of the
+// expression is empty, or has a series which is entirely NaN. These results
+// look the same as a quiet system, so alerts on them never fire.
+func checkStrict(expression function.Expression, series []api.Timeseries) error {
+	if len(series) == 0 {
+		return function.StrictError{
+			Message: fmt.Sprintf("%s has no series", expression.ExpressionDescription(function.StringQuery())),
+		}
+	}
+	for _, s := range series {
+		if !allNaN(s.Values) {
+			continue
+		}
+		return function.StrictError{
+			Message: fmt.Sprintf("%s has a series with no values (%s)", expression.ExpressionDescription(function.StringQuery()), s.TagSet.Serialize()),
+		}
+	}
+	return nil
+}
+
+func allNaN(values []float64) bool {
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			return false
+		}
+	}
+	return true
+}
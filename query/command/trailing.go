@@ -0,0 +1,86 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/square/metrics/api"
+)
+
+// The trailing bucket of a query ending near the present is only partially
+// filled, which makes rates and sums appear to dip at the end of every graph.
+// These modes choose how select queries treat it.
+const (
+	TrailingBucketKeep = "keep" // the default: the bucket is returned as-is
+	TrailingBucketTrim = "trim" // the bucket is removed from the timerange
+	TrailingBucketFlag = "flag" // the bucket is returned, and noted in the metadata
+)
+
+// TrailingBucket describes the incomplete bucket of a select query.
+type TrailingBucket struct {
+	Start   time.Time `json:"start"`
+	Action  string    `json:"action"`  // "trimmed" or "flagged"
+	Partial float64   `json:"partial"` // the fraction of the bucket which has elapsed
+}
+
+// ValidateTrailingBucket checks that the mode is one of the trailing bucket modes.
+func ValidateTrailingBucket(mode string) error {
+	switch mode {
+	case "", TrailingBucketKeep, TrailingBucketTrim, TrailingBucketFlag:
+		return nil
+	}
+	return fmt.Errorf("unknown trailing bucket mode %q; expected %q, %q or %q", mode, TrailingBucketKeep, TrailingBucketTrim, TrailingBucketFlag)
+}
+
+// checkTrailingBucket determines whether the last bucket of the timerange is
+// incomplete at the given time, and applies the mode to it. It returns the
+// timerange to evaluate, and a description of the bucket if it was trimmed or
+// flagged.
+func checkTrailingBucket(mode string, timerange api.Timerange, now time.Time) (api.Timerange, *TrailingBucket, error) {
+	if err := ValidateTrailingBucket(mode); err != nil {
+		return timerange, nil, err
+	}
+	if mode == "" || mode == TrailingBucketKeep {
+		return timerange, nil, nil
+	}
+	last := timerange.End()
+	resolution := timerange.Resolution()
+	if !last.Add(resolution).After(now) || last.After(now) {
+		// The bucket is complete, or entirely in the future (and so it isn't
+		// partially filled, but empty).
+		return timerange, nil, nil
+	}
+	bucket := &TrailingBucket{
+		Start:   last,
+		Action:  "flagged",
+		Partial: float64(now.Sub(last)) / float64(resolution),
+	}
+	if mode == TrailingBucketTrim && timerange.Slots() > 1 {
+		trimmed, err := api.NewSnappedTimerange(timerange.StartMillis(), timerange.EndMillis()-timerange.ResolutionMillis(), timerange.ResolutionMillis())
+		if err != nil {
+			return timerange, nil, err
+		}
+		bucket.Action = "trimmed"
+		return trimmed, bucket, nil
+	}
+	return timerange, bucket, nil
+}
+
+// note describes the bucket for the notes of a query.
+func (bucket TrailingBucket) note() string {
+	return fmt.Sprintf("The bucket starting at %s was only %.0f%% complete, and was %s.", bucket.Start.UTC().Format(time.RFC3339), bucket.Partial*100, bucket.Action)
+}
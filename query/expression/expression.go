@@ -51,7 +51,8 @@ func (expr Duration) ExpressionDescription(mode function.DescriptionMode) string
 }
 
 type Scalar struct {
-	Value float64
+	Value  float64
+	Source string // Source is the original text for a scalar folded from a constant expression; empty otherwise.
 }
 
 func (expr Scalar) Literal() interface{} {
@@ -66,6 +67,9 @@ func (expr Scalar) ExpressionDescription(mode function.DescriptionMode) string {
 	if mode == function.StringMemoization() {
 		return fmt.Sprintf("%#v", expr)
 	}
+	if expr.Source != "" {
+		return expr.Source
+	}
 	return fmt.Sprintf("%+v", expr.Value)
 }
 
@@ -85,26 +89,104 @@ func (expr String) ExpressionDescription(mode function.DescriptionMode) string {
 	if mode == function.StringMemoization() {
 		return fmt.Sprintf("%#v", expr)
 	}
-	return fmt.Sprintf("%q", expr.Value)
+	return util.EscapeString(expr.Value)
 }
 
 type MetricFetchExpression struct {
 	MetricName string
 	Predicate  predicate.Predicate
+	Position   string // Position is the location of the metric in the query.
 }
 
 func (expr *MetricFetchExpression) ActualEvaluate(context function.EvaluationContext) (function.Value, error) {
+	metrics, err := expr.matchingMetrics(context)
+	if err != nil {
+		return nil, err
+	}
+	if err := context.MemoryLimitConsume(seriesBytes(len(metrics), context.Timerange())); err != nil {
+		return nil, err
+	}
+
+	seriesList, err := context.TimeseriesStorageAPI().FetchMultipleTimeseries(
+		timeseries.FetchMultipleRequest{
+			Metrics:        metrics,
+			RequestDetails: requestDetails(context),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	context.RecordFreshness(expr.MetricName, seriesList)
+	return function.SeriesListValue(seriesList), nil
+}
+
+// FetchAggregated asks the storage to aggregate the matching series itself,
+// when it's able to.
+func (expr *MetricFetchExpression) FetchAggregated(context function.EvaluationContext, name string, aggregation timeseries.Aggregation, groups function.Groups) (api.SeriesList, bool, error) {
+	storage, ok := context.TimeseriesStorageAPI().(timeseries.AggregatingStorageAPI)
+	if !ok || !storage.SupportsAggregation(aggregation) {
+		return api.SeriesList{}, false, nil
+	}
+	metrics, err := expr.matchingMetrics(context)
+	if err != nil {
+		return api.SeriesList{}, false, err
+	}
+	// Only the tags of the series are needed to check the groups.
+	tagged := api.SeriesList{Series: make([]api.Timeseries, len(metrics))}
+	for i := range metrics {
+		tagged.Series[i].TagSet = metrics[i].TagSet
+	}
+	if err := function.CheckGroups(context, name, tagged, groups); err != nil {
+		return api.SeriesList{}, false, err
+	}
+
+	defer context.Profiler().RecordWithDescription("Aggregation push-down", fmt.Sprintf("%s(%s)", name, expr.ExpressionDescription(function.StringQuery())))()
+	seriesList, err := storage.FetchAggregatedTimeseries(
+		timeseries.FetchAggregatedRequest{
+			Metrics:        metrics,
+			Aggregation:    aggregation,
+			GroupBy:        groups.List,
+			Collapses:      groups.Collapses,
+			RequestDetails: requestDetails(context),
+		},
+	)
+	if err != nil {
+		return api.SeriesList{}, false, err
+	}
+	if err := context.MemoryLimitConsume(seriesBytes(len(seriesList.Series), context.Timerange())); err != nil {
+		return api.SeriesList{}, false, err
+	}
+	context.RecordFreshness(expr.MetricName, seriesList)
+	return seriesList, true, nil
+}
+
+// seriesBytes estimates the memory held by the values of fetched series.
+func seriesBytes(series int, timerange api.Timerange) int64 {
+	return int64(series) * int64(timerange.Slots()) * 8
+}
+
+// matchingMetrics looks up the series of the metric which satisfy the
+// predicate, keeps those in the sample if the query is sampled, and counts
+// them against the fetch limit.
+func (expr *MetricFetchExpression) matchingMetrics(context function.EvaluationContext) ([]api.TaggedMetric, error) {
 	// Merge predicates appropriately
 	p := predicate.All(expr.Predicate, context.Predicate())
 
-	metricTagSets, err := context.MetricMetadataAPI().GetAllTags(api.MetricKey(expr.MetricName), metadata.Context{
-		Profiler: context.Profiler(),
-	})
+	metricTagSets, err := context.MetricMetadataAPI().GetAllTags(context.Ctx(), api.MetricKey(expr.MetricName), metadata.Context{Profiler: context.Profiler()})
 
 	if err != nil {
 		return nil, err
 	}
 	filtered := applyPredicates(metricTagSets, p)
+	if len(filtered) == 0 && context.Strict() {
+		return nil, function.StrictError{
+			Message: fmt.Sprintf("%s matches no series", expr.ExpressionDescription(function.StringQuery())),
+		}
+	}
+
+	if sampling := context.Sampling(); sampling != nil {
+		filtered = sampling.Sample(function.SampleKey(context, function.Memoize(expr)), expr.MetricName, filtered)
+	}
 
 	if err := context.FetchLimitConsume(len(filtered)); err != nil {
 		return nil, err
@@ -114,25 +196,24 @@ func (expr *MetricFetchExpression) ActualEvaluate(context function.EvaluationCon
 	for i := range metrics {
 		metrics[i] = api.TaggedMetric{MetricKey: api.MetricKey(expr.MetricName), TagSet: filtered[i]}
 	}
+	return metrics, nil
+}
 
-	seriesList, err := context.TimeseriesStorageAPI().FetchMultipleTimeseries(
-		timeseries.FetchMultipleRequest{
-			Metrics: metrics,
-			RequestDetails: timeseries.RequestDetails{
-				SampleMethod: context.SampleMethod(),
-				Timerange:    context.Timerange(),
-				Ctx:          context.Ctx(),
-				Profiler:     context.Profiler(),
-			},
-		},
-	)
-	if err != nil {
-		return nil, err
+func requestDetails(context function.EvaluationContext) timeseries.RequestDetails {
+	return timeseries.RequestDetails{
+		SampleMethod: context.SampleMethod(),
+		Timerange:    context.Timerange(),
+		Ctx:          context.Ctx(),
+		Profiler:     context.Profiler(),
+		Labels:       context.Labels(),
 	}
-	return function.SeriesListValue(seriesList), nil
 }
 
 func (expr *MetricFetchExpression) ExpressionDescription(mode function.DescriptionMode) string {
+	if plan, ok := mode.(function.PlanMode); ok {
+		plan.Plan.AddFetch(api.MetricKey(expr.MetricName), expr.Predicate, plan.Aggregate)
+		return ""
+	}
 	if mode == function.StringMemoization() {
 		return fmt.Sprintf("fetch[%q][%s]", expr.MetricName, expr.Predicate.Query())
 	}
@@ -147,6 +228,7 @@ type FunctionExpression struct {
 	Arguments        []function.Expression
 	GroupBy          []string
 	GroupByCollapses bool
+	Position         string // Position is the location of the function call in the query, used in error messages.
 }
 
 func (expr *FunctionExpression) ActualEvaluate(context function.EvaluationContext) (function.Value, error) {
@@ -155,17 +237,39 @@ func (expr *FunctionExpression) ActualEvaluate(context function.EvaluationContex
 		return nil, SyntaxError{fmt.Sprintf("no such function %s", expr.FunctionName)}
 	}
 
-	return fun.Run(context, expr.Arguments, function.Groups{List: expr.GroupBy, Collapses: expr.GroupByCollapses})
+	value, err := fun.Run(context, expr.Arguments, function.Groups{List: expr.GroupBy, Collapses: expr.GroupByCollapses})
+	if argumentErr, ok := err.(function.ArgumentError); ok && argumentErr.Position == "" {
+		argumentErr.Position = expr.Position
+		return nil, argumentErr
+	}
+	return value, err
+}
+
+// IsOperator reports whether the function is an infix operator, whose
+// group-by clause (if any) is written as "on (...)" or "ignoring (...)".
+func (expr *FunctionExpression) IsOperator() bool {
+	switch expr.FunctionName {
+	case "+", "-", "*", "/", ">", "<", ">=", "<=", "==", "!=", "and", "or", "unless":
+		// Otherwise, it's not actually an operator.
+		return len(expr.Arguments) == 2
+	}
+	return false
 }
 
 func functionFormatString(argumentStrings []string, f FunctionExpression) string {
-	switch f.FunctionName {
-	case "+", "-", "*", "/":
-		if len(f.Arguments) != 2 {
-			// Then it's not actually an operator.
-			break
+	escapedGroupBy := []string{}
+	for _, group := range f.GroupBy {
+		escapedGroupBy = append(escapedGroupBy, util.EscapeIdentifier(group))
+	}
+	if f.IsOperator() {
+		if len(f.GroupBy) == 0 {
+			return fmt.Sprintf("(%s %s %s)", argumentStrings[0], f.FunctionName, argumentStrings[1])
+		}
+		matchKeyword := "on"
+		if f.GroupByCollapses {
+			matchKeyword = "ignoring"
 		}
-		return fmt.Sprintf("(%s %s %s)", argumentStrings[0], f.FunctionName, argumentStrings[1])
+		return fmt.Sprintf("(%s %s %s (%s) %s)", argumentStrings[0], f.FunctionName, matchKeyword, strings.Join(escapedGroupBy, ", "), argumentStrings[1])
 	}
 	argumentString := strings.Join(argumentStrings, ", ")
 	groupString := ""
@@ -174,10 +278,6 @@ func functionFormatString(argumentStrings []string, f FunctionExpression) string
 		if f.GroupByCollapses {
 			groupKeyword = "collapse by"
 		}
-		escapedGroupBy := []string{}
-		for _, group := range f.GroupBy {
-			escapedGroupBy = append(escapedGroupBy, util.EscapeIdentifier(group))
-		}
 		groupString = fmt.Sprintf(" %s %s", groupKeyword, strings.Join(escapedGroupBy, ", "))
 	}
 	return fmt.Sprintf("%s(%s%s)", f.FunctionName, argumentString, groupString)
@@ -198,6 +298,22 @@ func (expr *FunctionExpression) ExpressionDescription(mode function.DescriptionM
 		}
 		return ""
 	}
+	if plan, ok := mode.(function.PlanMode); ok {
+		plan.Plan.AddFunction(expr.FunctionName)
+		argumentMode := function.PlanMode{Plan: plan.Plan}
+		if len(expr.Arguments) == 1 {
+			// As in evaluation, only a lone metric argument may be aggregated by the storage.
+			if actual, ok := function.Unmemoize(expr.Arguments[0]); ok {
+				if _, ok := actual.(function.AggregateFetcher); ok {
+					argumentMode.Aggregate = expr.FunctionName
+				}
+			}
+		}
+		for _, argument := range expr.Arguments {
+			argument.ExpressionDescription(argumentMode)
+		}
+		return ""
+	}
 	argumentStrings := []string{}
 	for i := range expr.Arguments {
 		argumentStrings = append(argumentStrings, expr.Arguments[i].ExpressionDescription(mode))
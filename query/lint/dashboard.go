@@ -0,0 +1,113 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// A Dashboard is a titled list of panels, each of which shows the results of
+// some queries.
+type Dashboard struct {
+	Title  string  `json:"title"`
+	Panels []Panel `json:"panels"`
+}
+
+// A Panel holds the queries of one graph or table of a dashboard.
+type Panel struct {
+	Title   string   `json:"title"`
+	Queries []string `json:"queries"`
+}
+
+// ParseDashboard extracts the queries of a dashboard definition. It accepts
+// our own format:
+//
+//	{"title": "...", "panels": [{"title": "...", "query": "select ..."}]}
+//
+// where a panel may instead have a list of "queries", and Grafana's, where
+// panels (possibly nested in rows) have "targets" holding a "query",
+// "target" or "expr". Grafana's API wraps the dashboard in a "dashboard"
+// object. Panels without queries, such as text panels, are omitted.
+func ParseDashboard(data []byte) (Dashboard, error) {
+	var definition map[string]interface{}
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return Dashboard{}, fmt.Errorf("cannot parse dashboard: %s", err.Error())
+	}
+	if wrapped, ok := definition["dashboard"].(map[string]interface{}); ok {
+		definition = wrapped
+	}
+	dashboard := Dashboard{Title: stringField(definition, "title"), Panels: []Panel{}}
+	dashboard.addPanels(definition["panels"])
+	if rows, ok := definition["rows"].([]interface{}); ok {
+		for _, row := range rows {
+			if row, ok := row.(map[string]interface{}); ok {
+				dashboard.addPanels(row["panels"])
+			}
+		}
+	}
+	if len(dashboard.Panels) == 0 {
+		return Dashboard{}, fmt.Errorf("no queries were found in the dashboard")
+	}
+	return dashboard, nil
+}
+
+func (dashboard *Dashboard) addPanels(panels interface{}) {
+	list, ok := panels.([]interface{})
+	if !ok {
+		return
+	}
+	for _, item := range list {
+		panel, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		queries := []string{}
+		if query := stringField(panel, "query"); query != "" {
+			queries = append(queries, query)
+		}
+		if list, ok := panel["queries"].([]interface{}); ok {
+			for _, query := range list {
+				if query, ok := query.(string); ok && query != "" {
+					queries = append(queries, query)
+				}
+			}
+		}
+		if targets, ok := panel["targets"].([]interface{}); ok {
+			for _, target := range targets {
+				target, ok := target.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for _, field := range []string{"query", "target", "expr"} {
+					if query := stringField(target, field); query != "" {
+						queries = append(queries, query)
+						break
+					}
+				}
+			}
+		}
+		if len(queries) != 0 {
+			dashboard.Panels = append(dashboard.Panels, Panel{Title: stringField(panel, "title"), Queries: queries})
+		}
+		// Grafana nests the panels of collapsed rows.
+		dashboard.addPanels(panel["panels"])
+	}
+}
+
+func stringField(object map[string]interface{}, field string) string {
+	value, _ := object[field].(string)
+	return value
+}
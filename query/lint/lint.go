@@ -0,0 +1,256 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lint finds problems in queries without running them. Check needs
+// only the function registry, so it can run offline; Resolve also consults
+// the metadata API, to find metrics and tags which don't exist.
+package lint
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/ast"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/query/predicate"
+)
+
+// The severities of problems. Queries with errors fail when they run;
+// queries with warnings run, but probably don't do what was intended.
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+// A Problem is something wrong with a query.
+type Problem struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Position string `json:"position,omitempty"` // the location in the query, if known
+}
+
+// Parse parses the query, reporting a syntax error as a problem.
+func Parse(query string) (command.Command, []Problem) {
+	cmd, err := parser.Parse(query)
+	if err != nil {
+		return nil, []Problem{{Severity: Error, Message: err.Error()}}
+	}
+	return cmd, nil
+}
+
+// Check finds calls to functions which don't exist, or which are given the
+// wrong number of arguments or a group-by clause they don't allow.
+func Check(cmd command.Command, registry function.Registry) []Problem {
+	checker := &functionChecker{registry: registry}
+	ast.WalkCommand(checker, cmd)
+	return checker.problems
+}
+
+type functionChecker struct {
+	registry function.Registry
+	problems []Problem
+}
+
+func (c *functionChecker) Visit(node ast.Node) ast.Visitor {
+	call, ok := node.(*expression.FunctionExpression)
+	if !ok {
+		return c
+	}
+	fun, ok := c.registry.GetFunction(call.FunctionName)
+	if !ok {
+		c.report(call, "no such function %s", call.FunctionName)
+		return c
+	}
+	metricFunction, ok := fun.(function.MetricFunction)
+	if !ok {
+		return c
+	}
+	if err := checkArgumentCount(metricFunction, len(call.Arguments)); err != nil {
+		c.report(call, "%s", err.Error())
+	}
+	if len(call.GroupBy) != 0 && !metricFunction.AllowsGroupBy {
+		c.report(call, "function %s doesn't allow a group-by clause", call.FunctionName)
+	}
+	return c
+}
+
+func (c *functionChecker) report(call *expression.FunctionExpression, format string, arguments ...interface{}) {
+	c.problems = append(c.problems, Problem{
+		Severity: Error,
+		Message:  fmt.Sprintf(format, arguments...),
+		Position: call.Position,
+	})
+}
+
+func checkArgumentCount(f function.MetricFunction, count int) error {
+	if count < f.MinArguments || (f.MaxArguments != -1 && f.MaxArguments < count) {
+		return function.ArgumentLengthError{
+			Name:        f.FunctionName,
+			ExpectedMin: f.MinArguments,
+			ExpectedMax: f.MaxArguments,
+			Actual:      count,
+		}
+	}
+	return nil
+}
+
+// Resolve looks up the metrics of the command without fetching any data. It
+// reports metrics which don't exist as errors, and predicates which match no
+// series, group-by tags which none of the grouped metrics have, and fetches
+// beyond the limit (if positive) as warnings. The constraint applies to every
+// metric, as the additional constraints of an execution context do.
+func Resolve(ctx context.Context, cmd command.Command, metadataAPI metadata.MetricAPI, constraint predicate.Predicate, fetchLimit int) []Problem {
+	resolver := &resolver{
+		ctx:         ctx,
+		metadataAPI: metadataAPI,
+		tagsets:     map[string][]api.TagSet{},
+		failed:      map[string]bool{},
+	}
+	switch cmd := cmd.(type) {
+	case *command.DescribeCommand:
+		resolver.lookup(string(cmd.MetricName), "")
+	case *command.DescribeKeysCommand:
+		resolver.lookup(string(cmd.MetricName), "")
+	case *command.DescribeValuesCommand:
+		for _, metric := range cmd.Metrics {
+			resolver.lookup(string(metric), "")
+		}
+	case *command.SelectCommand:
+		resolver.constraint = predicate.All(cmd.Predicate, constraint)
+		for _, expr := range cmd.Expressions {
+			resolver.resolve(expr)
+		}
+		if fetchLimit > 0 && resolver.fetches > fetchLimit {
+			resolver.problems = append(resolver.problems, Problem{
+				Severity: Warning,
+				Message:  fmt.Sprintf("the query fetches %d series, but the limit is %d", resolver.fetches, fetchLimit),
+			})
+		}
+	}
+	return resolver.problems
+}
+
+type resolver struct {
+	ctx         context.Context
+	metadataAPI metadata.MetricAPI
+	constraint  predicate.Predicate
+	tagsets     map[string][]api.TagSet // the tagsets of each metric which exists
+	failed      map[string]bool         // the metrics which have already been reported
+	fetches     int
+	problems    []Problem
+}
+
+// lookup returns the tagsets of the metric, reporting it if it doesn't exist.
+func (r *resolver) lookup(metric string, position string) ([]api.TagSet, bool) {
+	if tagsets, ok := r.tagsets[metric]; ok {
+		return tagsets, true
+	}
+	if r.failed[metric] {
+		return nil, false
+	}
+	tagsets, err := r.metadataAPI.GetAllTags(r.ctx, api.MetricKey(metric), metadata.Context{})
+	if err != nil {
+		r.failed[metric] = true
+		r.problems = append(r.problems, Problem{Severity: Error, Message: err.Error(), Position: position})
+		return nil, false
+	}
+	r.tagsets[metric] = tagsets
+	return tagsets, true
+}
+
+// resolve checks the metrics within the expression, returning the tag keys
+// of the series which they match.
+func (r *resolver) resolve(expr function.Expression) map[string]bool {
+	keys := map[string]bool{}
+	switch node := ast.NodeOf(expr).(type) {
+	case *expression.MetricFetchExpression:
+		tagsets, ok := r.lookup(node.MetricName, node.Position)
+		if !ok {
+			return keys
+		}
+		matcher := predicate.All(node.Predicate, r.constraint)
+		matched := 0
+		for _, tagset := range tagsets {
+			if !matcher.Apply(tagset) {
+				continue
+			}
+			matched++
+			for key := range tagset {
+				keys[key] = true
+			}
+		}
+		r.fetches += matched
+		if matched == 0 {
+			r.problems = append(r.problems, Problem{
+				Severity: Warning,
+				Message:  fmt.Sprintf("%s matches no series", node.ExpressionDescription(function.StringQuery())),
+				Position: node.Position,
+			})
+		}
+	case *expression.FunctionExpression:
+		for _, argument := range node.Arguments {
+			for key := range r.resolve(argument) {
+				keys[key] = true
+			}
+		}
+		if len(keys) == 0 {
+			// Nothing is known about the series, so don't guess.
+			return keys
+		}
+		missing := []string{}
+		for _, tag := range node.GroupBy {
+			if !keys[tag] {
+				missing = append(missing, tag)
+			}
+		}
+		if len(missing) != 0 {
+			sort.Strings(missing)
+			r.problems = append(r.problems, Problem{
+				Severity: Warning,
+				Message:  fmt.Sprintf("%s: no series has the tag %s", node.FunctionName, strings.Join(missing, ", ")),
+				Position: node.Position,
+			})
+		}
+		if len(node.GroupBy) != 0 && !node.IsOperator() {
+			// Only the grouped tags remain, or the tags which weren't collapsed.
+			grouped := map[string]bool{}
+			for _, tag := range node.GroupBy {
+				if keys[tag] {
+					grouped[tag] = true
+				}
+			}
+			if node.GroupByCollapses {
+				for tag := range grouped {
+					delete(keys, tag)
+				}
+				return keys
+			}
+			return grouped
+		}
+	default:
+		for _, child := range ast.Children(ast.NodeOf(expr)) {
+			for key := range r.resolve(child) {
+				keys[key] = true
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,159 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	netcontext "context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/predicate"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestLint(t *testing.T) {
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"dc": "west", "host": "a"}})
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"dc": "east", "host": "b"}})
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "memory", TagSet: api.TagSet{"dc": "west", "app": "web"}})
+
+	for _, test := range []struct {
+		query      string
+		constraint predicate.Predicate
+		fetchLimit int
+		problems   []string // severity: message
+	}{
+		{query: "select cpu from -1h to now"},
+		{query: "describe cpu"},
+		{query: "select aggregate.sum(cpu group by dc) + memory from -1h to now"},
+		{query: "select cpu from", problems: []string{"error"}},
+		{query: "select aggregate.summ(cpu) from -1h to now", problems: []string{"error: no such function aggregate.summ"}},
+		{query: "select transform.derivative(cpu, 2) from -1h to now", problems: []string{"error: Function `transform.derivative` expected 1 arguments but received 2."}},
+		{query: "select transform.derivative(cpu group by dc) from -1h to now", problems: []string{"error: function transform.derivative doesn't allow a group-by clause"}},
+		{query: "select disk from -1h to now", problems: []string{"error: metric disk does not exist"}},
+		{query: "describe disk", problems: []string{"error: metric disk does not exist"}},
+		{query: "select cpu[host = 'z'] from -1h to now", problems: []string{`warning: cpu[host = "z"] matches no series`}},
+		{query: "select cpu from -1h to now", constraint: predicate.ListMatcher{Tag: "dc", Values: []string{"north"}}, problems: []string{"warning: cpu matches no series"}},
+		{query: "select aggregate.sum(cpu group by hostt, dc) from -1h to now", problems: []string{"warning: aggregate.sum: no series has the tag hostt"}},
+		{query: "select aggregate.max(aggregate.sum(cpu group by dc) group by host) from -1h to now", problems: []string{"warning: aggregate.max: no series has the tag host"}},
+		{query: "select aggregate.max(aggregate.sum(cpu collapse by dc) group by host) from -1h to now"},
+		{query: "select cpu, memory from -1h to now", fetchLimit: 2, problems: []string{"warning: the query fetches 3 series, but the limit is 2"}},
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		cmd, problems := Parse(test.query)
+		if cmd != nil {
+			problems = append(problems, Check(cmd, registry.Default())...)
+			problems = append(problems, Resolve(netcontext.Background(), cmd, fakeAPI, test.constraint, test.fetchLimit)...)
+		}
+		a.EqInt(len(problems), len(test.problems))
+		for i := range problems {
+			if i >= len(test.problems) {
+				break
+			}
+			if test.problems[i] == problems[i].Severity {
+				continue // only the severity is checked
+			}
+			a.EqString(problems[i].Severity+": "+problems[i].Message, test.problems[i])
+		}
+	}
+}
+
+func TestParseDashboard(t *testing.T) {
+	a := assert.New(t)
+	dashboard, err := ParseDashboard([]byte(`{
+		"title": "Hosts",
+		"panels": [
+			{"title": "CPU", "query": "select cpu from -1h to now"},
+			{"title": "Both", "queries": ["select cpu from -1h to now", "select memory from -1h to now"]},
+			{"title": "Notes", "content": "just text"}
+		]
+	}`))
+	a.CheckError(err)
+	a.Eq(dashboard, Dashboard{
+		Title: "Hosts",
+		Panels: []Panel{
+			{Title: "CPU", Queries: []string{"select cpu from -1h to now"}},
+			{Title: "Both", Queries: []string{"select cpu from -1h to now", "select memory from -1h to now"}},
+		},
+	})
+
+	grafana, err := ParseDashboard([]byte(`{
+		"dashboard": {
+			"title": "Grafana",
+			"rows": [{"panels": [{"title": "Old", "targets": [{"target": "select cpu from -1h to now"}]}]}],
+			"panels": [
+				{"title": "New", "targets": [{"refId": "A", "query": "select memory from -1h to now"}, {"refId": "B", "expr": "select cpu from -1h to now"}]},
+				{"type": "row", "title": "Collapsed", "panels": [{"title": "Nested", "targets": [{"query": "describe cpu"}]}]}
+			]
+		}
+	}`))
+	a.CheckError(err)
+	a.Eq(grafana, Dashboard{
+		Title: "Grafana",
+		Panels: []Panel{
+			{Title: "New", Queries: []string{"select memory from -1h to now", "select cpu from -1h to now"}},
+			{Title: "Nested", Queries: []string{"describe cpu"}},
+			{Title: "Old", Queries: []string{"select cpu from -1h to now"}},
+		},
+	})
+
+	for _, invalid := range []string{`[`, `{"title": "empty"}`, `{"panels": [{"title": "text"}]}`} {
+		if _, err := ParseDashboard([]byte(invalid)); err == nil {
+			t.Errorf("expected an error for dashboard %s", invalid)
+		}
+	}
+}
+
+func TestCompareResolutions(t *testing.T) {
+	store := memory.NewStore(time.Minute)
+	store.AddGenerated(api.TaggedMetric{MetricKey: "requests", TagSet: api.TagSet{"host": "a"}}, func(t time.Time) float64 {
+		return float64(t.Unix()) // a counter increasing by one each second
+	})
+	context := command.ExecutionContext{
+		TimeseriesStorageAPI: store,
+		MetricMetadataAPI:    store,
+		FetchLimit:           1000,
+		Ctx:                  netcontext.Background(),
+	}
+	for _, test := range []struct {
+		query    string
+		problems []string
+	}{
+		{query: "select requests from 0 to 7200000 resolution 1m"},
+		{query: "select transform.rate(requests), transform.derivative(requests) from 0 to 7200000 resolution 1m"},
+		{query: "select summarize.mean(requests) from 0 to 7200000 resolution 1m"},
+		{
+			query:    "select transform.integral(requests) from 0 to 7200000 resolution 1m",
+			problems: []string{"transform.integral(requests) host=a has a mean of 8.784e+06 at a resolution of 1m0s but 8.928e+06 at 2m0s (a difference of 1.6%)"},
+		},
+		{query: "describe requests"},
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		cmd, problems := Parse(test.query)
+		a.Eq(problems, []Problem(nil))
+		problems, err := CompareResolutions(cmd, context, 0.01)
+		a.CheckError(err)
+		messages := []string{}
+		for _, problem := range problems {
+			messages = append(messages, problem.Message)
+		}
+		a.Eq(messages, append([]string{}, test.problems...))
+	}
+}
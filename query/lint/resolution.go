@@ -0,0 +1,149 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/square/metrics/query/command"
+)
+
+// maxCoarsening bounds how many times coarser than the query's resolution
+// CompareResolutions looks for a second resolution.
+const maxCoarsening = 64
+
+// CompareResolutions runs a select command at its own resolution and at the
+// next coarser resolution which the storage provides, and warns about each
+// result whose mean differs between them by more than the relative
+// tolerance. The means of rates, averages and most other results shouldn't
+// depend on the resolution; those which do (such as a sum over time, or a
+// rate computed without regard to the sample width) give different answers
+// when a dashboard is zoomed out.
+func CompareResolutions(cmd command.Command, context command.ExecutionContext, tolerance float64) ([]Problem, error) {
+	selectCommand, ok := cmd.(*command.SelectCommand)
+	if !ok {
+		return nil, nil
+	}
+	fine, fineResolution, err := runAt(*selectCommand, context, selectCommand.Context.Resolution)
+	if err != nil {
+		return nil, err
+	}
+	var coarse map[string]float64
+	var coarseResolution time.Duration
+	for factor := int64(2); ; factor *= 2 {
+		if factor > maxCoarsening {
+			return nil, fmt.Errorf("the storage offers no resolution coarser than %s to compare with", fineResolution)
+		}
+		coarse, coarseResolution, err = runAt(*selectCommand, context, fineResolution*time.Duration(factor))
+		if err != nil {
+			return nil, err
+		}
+		if coarseResolution > fineResolution {
+			break
+		}
+	}
+
+	keys := []string{}
+	for key := range fine {
+		keys = append(keys, key)
+	}
+	for key := range coarse {
+		if _, ok := fine[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	problems := []Problem{}
+	for _, key := range keys {
+		fineMean, inFine := fine[key]
+		coarseMean, inCoarse := coarse[key]
+		if !inFine || !inCoarse {
+			present, absent := fineResolution, coarseResolution
+			if inCoarse {
+				present, absent = coarseResolution, fineResolution
+			}
+			problems = append(problems, Problem{
+				Severity: Warning,
+				Message:  fmt.Sprintf("%s is present at a resolution of %s but not %s", key, present, absent),
+			})
+			continue
+		}
+		difference := relativeDifference(fineMean, coarseMean)
+		if difference > tolerance {
+			problems = append(problems, Problem{
+				Severity: Warning,
+				Message: fmt.Sprintf("%s has a mean of %g at a resolution of %s but %g at %s (a difference of %.1f%%)",
+					key, fineMean, fineResolution, coarseMean, coarseResolution, 100*difference),
+			})
+		}
+	}
+	return problems, nil
+}
+
+// runAt runs the command at the requested resolution, and
+// returns the mean of each series and scalar of the result, keyed by its
+// expression and tags, along with the resolution which was used.
+func runAt(cmd command.SelectCommand, context command.ExecutionContext, resolution time.Duration) (map[string]float64, time.Duration, error) {
+	cmd.Context.Resolution = resolution
+	result, err := cmd.Execute(context)
+	if err != nil {
+		return nil, 0, err
+	}
+	used, _ := result.Metadata["resolution"].(time.Duration)
+	means := map[string]float64{}
+	results, _ := result.Body.([]command.QueryResult)
+	for _, queryResult := range results {
+		for _, series := range queryResult.Series {
+			means[queryResult.Query+" "+series.TagSet.Serialize()] = mean(series.Values)
+		}
+		for _, scalar := range queryResult.Scalars {
+			means[queryResult.Query+" "+scalar.TagSet.Serialize()] = scalar.Value
+		}
+	}
+	return means, used, nil
+}
+
+func mean(values []float64) float64 {
+	sum, count := 0.0, 0
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			sum += value
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// relativeDifference is zero for equal values (including two NaNs), and
+// infinite when exactly one value is NaN.
+func relativeDifference(a, b float64) float64 {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		if math.IsNaN(a) && math.IsNaN(b) {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	scale := math.Max(math.Abs(a), math.Abs(b))
+	if scale == 0 {
+		return 0
+	}
+	return math.Abs(a-b) / scale
+}
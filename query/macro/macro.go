@@ -0,0 +1,134 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package macro lets tenants define their own functions in terms of existing
+// ones. A macro is an MQE expression with named parameters; it is called as
+// tenant.<name>(...), and its parameters are referred to in its expression
+// as though they were metrics.
+package macro
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/ast"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/parser"
+)
+
+// Namespace prefixes the name of each macro, so that macros can't shadow
+// the builtin functions, nor be confused with them.
+const Namespace = "tenant."
+
+var identifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Definition is a macro, as it is stored and sent to the admin API.
+type Definition struct {
+	Name        string   `json:"name"`       // without the namespace
+	Parameters  []string `json:"parameters"` // referred to in the expression as metrics
+	Expression  string   `json:"expression"`
+	Description string   `json:"description,omitempty"`
+}
+
+// FunctionName is the name by which the macro is called.
+func (d Definition) FunctionName() string {
+	return Namespace + d.Name
+}
+
+// parse checks the name and parameters of the definition and parses its
+// expression.
+func (d Definition) parse() (function.Expression, error) {
+	if !identifier.MatchString(d.Name) {
+		return nil, fmt.Errorf("invalid macro name %q; use letters, digits and underscores", d.Name)
+	}
+	seen := map[string]bool{}
+	for _, parameter := range d.Parameters {
+		if !identifier.MatchString(parameter) {
+			return nil, fmt.Errorf("macro %s has an invalid parameter name %q", d.Name, parameter)
+		}
+		if seen[parameter] {
+			return nil, fmt.Errorf("macro %s has the parameter %s twice", d.Name, parameter)
+		}
+		seen[parameter] = true
+	}
+	cmd, err := parser.Parse("select " + d.Expression + " from 0 to 0")
+	if err != nil {
+		return nil, fmt.Errorf("macro %s has an invalid expression: %s", d.Name, err.Error())
+	}
+	selectCommand, ok := cmd.(*command.SelectCommand)
+	if !ok || len(selectCommand.Expressions) != 1 {
+		return nil, fmt.Errorf("macro %s must have exactly one expression", d.Name)
+	}
+	return selectCommand.Expressions[0], nil
+}
+
+// Compile turns the definition into a function which may be registered.
+func (d Definition) Compile() (function.MetricFunction, error) {
+	body, err := d.parse()
+	if err != nil {
+		return function.MetricFunction{}, err
+	}
+	parameters := map[string]int{}
+	for i, parameter := range d.Parameters {
+		parameters[parameter] = i
+	}
+	// expand substitutes the arguments for the parameters in the body.
+	expand := func(arguments []function.Expression) function.Expression {
+		return ast.Rewrite(ast.RewriterFunc(func(node ast.Node) function.Expression {
+			fetch, ok := node.(*expression.MetricFetchExpression)
+			if !ok || fetch.Predicate.Query() != "true" {
+				return nil
+			}
+			if i, ok := parameters[fetch.MetricName]; ok {
+				return arguments[i]
+			}
+			return nil
+		}), body)
+	}
+	return function.MetricFunction{
+		FunctionName: d.FunctionName(),
+		MinArguments: len(d.Parameters),
+		MaxArguments: len(d.Parameters),
+		Compute: func(context function.EvaluationContext, arguments []function.Expression, groups function.Groups) (function.Value, error) {
+			return expand(arguments).Evaluate(context)
+		},
+		Widen: func(widest function.WidestMode, arguments []function.Expression) time.Time {
+			// The functions of the body widen the timerange themselves.
+			expand(arguments).ExpressionDescription(widest)
+			return widest.Current
+		},
+	}, nil
+}
+
+// calls lists the macros called by the expression.
+func calls(expr function.Expression) []string {
+	collector := &callCollector{}
+	ast.Walk(collector, expr)
+	return collector.names
+}
+
+type callCollector struct {
+	names []string
+}
+
+func (c *callCollector) Visit(node ast.Node) ast.Visitor {
+	if call, ok := node.(*expression.FunctionExpression); ok && strings.HasPrefix(call.FunctionName, Namespace) {
+		c.names = append(c.names, call.FunctionName)
+	}
+	return c
+}
@@ -0,0 +1,105 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package macro
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/square/metrics/archive"
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestRegistry_Define(t *testing.T) {
+	macros := NewRegistry(registry.Default())
+	a := assert.New(t)
+	a.CheckError(macros.Define(Definition{Name: "error_rate", Parameters: []string{"errors", "requests"}, Expression: "errors / requests"}))
+	a.CheckError(macros.Define(Definition{Name: "error_percent", Parameters: []string{"errors", "requests"}, Expression: "tenant.error_rate(errors, requests) * 100"}))
+
+	for _, test := range []struct {
+		definition Definition
+		message    string
+	}{
+		{Definition{Name: "bad.name", Expression: "x"}, `invalid macro name "bad.name"; use letters, digits and underscores`},
+		{Definition{Name: "twice", Parameters: []string{"x", "x"}, Expression: "x"}, "macro twice has the parameter x twice"},
+		{Definition{Name: "missing", Parameters: []string{"x"}, Expression: "transform.nonexistent(x)"}, "macro missing: no such function transform.nonexistent"},
+		{Definition{Name: "arity", Parameters: []string{"x"}, Expression: "tenant.error_rate(x)"}, "macro arity: Function `tenant.error_rate` expected 2 arguments but received 1."},
+		{Definition{Name: "loop", Parameters: []string{"x"}, Expression: "tenant.loop(x)"}, "macro loop calls itself"},
+		{Definition{Name: "error_rate", Parameters: []string{"errors", "requests"}, Expression: "tenant.error_percent(errors, requests) / 100"}, "macro error_percent calls itself"},
+	} {
+		a := a.Contextf("%s", test.definition.Name)
+		err := macros.Define(test.definition)
+		if err == nil {
+			a.Errorf("expected an error")
+			continue
+		}
+		a.EqString(err.Error(), test.message)
+	}
+
+	// The failed definitions leave the registry as it was.
+	a.EqInt(len(macros.Definitions()), 2)
+	a.EqString(macros.Definitions()[1].Expression, "errors / requests")
+	_, ok := macros.GetFunction("tenant.error_rate")
+	a.EqBool(ok, true)
+	_, ok = macros.GetFunction("transform.rate")
+	a.EqBool(ok, true)
+	a.Eq(macros.All()[len(macros.All())-2:], []string{"tenant.error_percent", "tenant.error_rate"})
+
+	err := macros.Remove("error_rate")
+	if err == nil {
+		t.Fatalf("expected an error removing a macro which is called by another")
+	}
+	a.EqString(err.Error(), "macro error_rate can't be removed, since macro error_percent calls it")
+	a.CheckError(macros.Remove("error_percent"))
+	a.CheckError(macros.Remove("error_rate"))
+	a.EqInt(len(macros.Definitions()), 0)
+}
+
+func TestTenants(t *testing.T) {
+	a := assert.New(t)
+	directory, err := ioutil.TempDir("", "macros")
+	a.CheckError(err)
+	defer os.RemoveAll(directory)
+	store := archive.DirectoryStore{Directory: directory}
+
+	tenants := NewTenants(registry.Default(), store)
+	a.CheckError(tenants.Define("payments", Definition{Name: "double", Parameters: []string{"x"}, Expression: "x * 2"}))
+	payments, err := tenants.Registry("payments")
+	a.CheckError(err)
+	_, ok := payments.GetFunction("tenant.double")
+	a.EqBool(ok, true)
+
+	// Other tenants don't see the macro.
+	storage, err := tenants.Registry("storage")
+	a.CheckError(err)
+	_, ok = storage.GetFunction("tenant.double")
+	a.EqBool(ok, false)
+
+	if _, err := tenants.Registry("../payments"); err == nil {
+		a.Errorf("expected an invalid tenant name to be rejected")
+	}
+
+	// The macros are loaded again from the store.
+	reloaded, err := NewTenants(registry.Default(), store).Registry("payments")
+	a.CheckError(err)
+	a.Eq(reloaded.Definitions(), payments.Definitions())
+
+	a.CheckError(tenants.Remove("payments", "double"))
+	reloaded, err = NewTenants(registry.Default(), store).Registry("payments")
+	a.CheckError(err)
+	a.EqInt(len(reloaded.Definitions()), 0)
+}
@@ -0,0 +1,206 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package macro
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/lint"
+)
+
+// Registry adds a tenant's macros to a base registry. It's safe for
+// concurrent use, so macros may be changed while queries run.
+type Registry struct {
+	base   function.Registry
+	mutex  sync.RWMutex
+	macros map[string]compiled // by function name
+}
+
+type compiled struct {
+	definition Definition
+	function   function.MetricFunction
+	body       function.Expression
+}
+
+// NewRegistry creates a registry with no macros.
+func NewRegistry(base function.Registry) *Registry {
+	return &Registry{base: base, macros: map[string]compiled{}}
+}
+
+// GetFunction finds a macro or a function of the base registry.
+func (r *Registry) GetFunction(name string) (function.Function, bool) {
+	if strings.HasPrefix(name, Namespace) {
+		r.mutex.RLock()
+		macro, ok := r.macros[name]
+		r.mutex.RUnlock()
+		if ok {
+			return macro.function, true
+		}
+	}
+	return r.base.GetFunction(name)
+}
+
+// All lists the functions of the base registry followed by the macros.
+func (r *Registry) All() []string {
+	r.mutex.RLock()
+	names := make([]string, 0, len(r.macros))
+	for name := range r.macros {
+		names = append(names, name)
+	}
+	r.mutex.RUnlock()
+	sort.Strings(names)
+	return append(r.base.All(), names...)
+}
+
+// Definitions lists the macros, sorted by name.
+func (r *Registry) Definitions() []Definition {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.definitions(r.macros)
+}
+
+func (r *Registry) definitions(macros map[string]compiled) []Definition {
+	names := make([]string, 0, len(macros))
+	for name := range macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]Definition, len(names))
+	for i, name := range names {
+		result[i] = macros[name].definition
+	}
+	return result
+}
+
+// Define adds the macro, or replaces the macro of the same name. It fails if
+// the macro's expression calls functions which don't exist (or calls them
+// wrongly), or if the macro would call itself.
+func (r *Registry) Define(definition Definition) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	macros := r.copyMacros()
+	if err := add(macros, definition); err != nil {
+		return err
+	}
+	if err := r.check(macros); err != nil {
+		return err
+	}
+	r.macros = macros
+	return nil
+}
+
+// Remove deletes the macro. It fails if the macro doesn't exist or another
+// macro calls it.
+func (r *Registry) Remove(name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	functionName := Namespace + name
+	if _, ok := r.macros[functionName]; !ok {
+		return fmt.Errorf("no such macro %s", name)
+	}
+	for _, macro := range r.macros {
+		for _, called := range calls(macro.body) {
+			if called == functionName && macro.definition.Name != name {
+				return fmt.Errorf("macro %s can't be removed, since macro %s calls it", name, macro.definition.Name)
+			}
+		}
+	}
+	macros := r.copyMacros()
+	delete(macros, functionName)
+	r.macros = macros
+	return nil
+}
+
+// Replace replaces every macro with the given definitions. If any of them is
+// invalid, the registry is left unchanged.
+func (r *Registry) Replace(definitions []Definition) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	macros := map[string]compiled{}
+	for _, definition := range definitions {
+		if err := add(macros, definition); err != nil {
+			return err
+		}
+	}
+	if err := r.check(macros); err != nil {
+		return err
+	}
+	r.macros = macros
+	return nil
+}
+
+func (r *Registry) copyMacros() map[string]compiled {
+	macros := make(map[string]compiled, len(r.macros))
+	for name, macro := range r.macros {
+		macros[name] = macro
+	}
+	return macros
+}
+
+func add(macros map[string]compiled, definition Definition) error {
+	fun, err := definition.Compile()
+	if err != nil {
+		return err
+	}
+	body, _ := definition.parse()
+	macros[definition.FunctionName()] = compiled{definition: definition, function: fun, body: body}
+	return nil
+}
+
+// check verifies that every macro calls only functions which exist, and that
+// no macro calls itself, directly or otherwise.
+func (r *Registry) check(macros map[string]compiled) error {
+	tentative := &Registry{base: r.base, macros: macros}
+	for _, definition := range r.definitions(macros) {
+		macro := macros[definition.FunctionName()]
+		selectCommand := &command.SelectCommand{Expressions: []function.Expression{macro.body}}
+		for _, problem := range lint.Check(selectCommand, tentative) {
+			if problem.Severity == lint.Error {
+				return fmt.Errorf("macro %s: %s", definition.Name, problem.Message)
+			}
+		}
+	}
+	// Each macro is visiting (1) while its callees are searched, and finished
+	// (2) once none of them lead back to it.
+	state := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("macro %s calls itself", strings.TrimPrefix(name, Namespace))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, called := range calls(macros[name].body) {
+			if err := visit(called); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		return nil
+	}
+	for _, definition := range r.definitions(macros) {
+		if err := visit(definition.FunctionName()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
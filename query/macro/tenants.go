@@ -0,0 +1,123 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package macro
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/square/metrics/archive"
+	"github.com/square/metrics/function"
+)
+
+var tenantName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Tenants holds the macros of each tenant, so that each tenant's macros are
+// invisible to the others. The macros are saved in an object store, as
+// "tenants/<tenant>/macros.json", whenever they change.
+type Tenants struct {
+	base       function.Registry
+	store      archive.ObjectStore // nil keeps the macros in memory only
+	mutex      sync.Mutex
+	registries map[string]*Registry
+}
+
+// NewTenants creates the tenants' registries. Each tenant's macros are loaded
+// from the store when the tenant is first seen.
+func NewTenants(base function.Registry, store archive.ObjectStore) *Tenants {
+	return &Tenants{base: base, store: store, registries: map[string]*Registry{}}
+}
+
+func key(tenant string) string {
+	return "tenants/" + tenant + "/macros.json"
+}
+
+// Registry returns the registry of the tenant: the base registry along with
+// the tenant's macros.
+func (t *Tenants) Registry(tenant string) (*Registry, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.registry(tenant)
+}
+
+func (t *Tenants) registry(tenant string) (*Registry, error) {
+	if registry, ok := t.registries[tenant]; ok {
+		return registry, nil
+	}
+	if !tenantName.MatchString(tenant) {
+		return nil, fmt.Errorf("invalid tenant name %q", tenant)
+	}
+	registry := NewRegistry(t.base)
+	if t.store != nil {
+		data, err := t.store.Get(key(tenant))
+		switch {
+		case err == archive.ErrNotFound:
+		case err != nil:
+			return nil, fmt.Errorf("cannot load the macros of tenant %s: %s", tenant, err.Error())
+		default:
+			var definitions []Definition
+			if err := json.Unmarshal(data, &definitions); err != nil {
+				return nil, fmt.Errorf("cannot load the macros of tenant %s: %s", tenant, err.Error())
+			}
+			if err := registry.Replace(definitions); err != nil {
+				return nil, fmt.Errorf("cannot load the macros of tenant %s: %s", tenant, err.Error())
+			}
+		}
+	}
+	t.registries[tenant] = registry
+	return registry, nil
+}
+
+// Define adds or replaces one of the tenant's macros, and saves them.
+func (t *Tenants) Define(tenant string, definition Definition) error {
+	return t.change(tenant, func(registry *Registry) error {
+		return registry.Define(definition)
+	})
+}
+
+// Remove deletes one of the tenant's macros, and saves the rest.
+func (t *Tenants) Remove(tenant string, name string) error {
+	return t.change(tenant, func(registry *Registry) error {
+		return registry.Remove(name)
+	})
+}
+
+// change alters the tenant's macros, undoing the change if it can't be saved.
+func (t *Tenants) change(tenant string, alter func(*Registry) error) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	registry, err := t.registry(tenant)
+	if err != nil {
+		return err
+	}
+	previous := registry.Definitions()
+	if err := alter(registry); err != nil {
+		return err
+	}
+	if t.store == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(registry.Definitions(), "", "  ")
+	if err == nil {
+		err = t.store.Put(key(tenant), data)
+	}
+	if err != nil {
+		registry.Replace(previous)
+		return fmt.Errorf("cannot save the macros of tenant %s: %s", tenant, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package natural_sort
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// A Collation orders strings. Less must be a strict weak ordering.
+type Collation interface {
+	Less(a, b string) bool
+}
+
+// CollationFunc adapts a function to a Collation.
+type CollationFunc func(a, b string) bool
+
+// Less calls the function.
+func (f CollationFunc) Less(a, b string) bool {
+	return f(a, b)
+}
+
+// The names of the built-in collations.
+const (
+	Natural = "natural" // numeric-aware and case-insensitive (the default)
+	Lexical = "lexical" // byte-by-byte
+	Version = "version" // dotted version strings, with semantic-version pre-releases
+	IP      = "ip"      // IPv4 and IPv6 addresses by value, followed by other strings
+	Locale  = "locale"  // accents and case are secondary differences, as in most languages
+)
+
+var collations = struct {
+	sync.RWMutex
+	byName map[string]Collation
+}{byName: map[string]Collation{
+	Natural: CollationFunc(Less),
+	Lexical: CollationFunc(func(a, b string) bool { return a < b }),
+	Version: CollationFunc(versionLess),
+	IP:      CollationFunc(ipLess),
+	Locale:  CollationFunc(localeLess),
+}}
+
+// Register adds a collation under the given name, so that deployments can
+// provide their own (for example, one backed by golang.org/x/text/collate
+// for a specific language).
+func Register(name string, collation Collation) error {
+	collations.Lock()
+	defer collations.Unlock()
+	if _, ok := collations.byName[name]; ok {
+		return fmt.Errorf("collation %q has already been registered", name)
+	}
+	collations.byName[name] = collation
+	return nil
+}
+
+// defaultCollation is the natural collation, except that SortWith orders
+// lists made up entirely of IP addresses as addresses.
+type defaultCollation struct{}
+
+func (defaultCollation) Less(a, b string) bool {
+	return Less(a, b)
+}
+
+// Lookup finds the collation with the given name. The empty name is the
+// default: the natural collation, unless every string is an IP address.
+func Lookup(name string) (Collation, error) {
+	if name == "" {
+		return defaultCollation{}, nil
+	}
+	collations.RLock()
+	defer collations.RUnlock()
+	collation, ok := collations.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown collation %q; expected one of %s", name, strings.Join(collationNames(), ", "))
+	}
+	return collation, nil
+}
+
+// Collations returns the names of the registered collations.
+func Collations() []string {
+	collations.RLock()
+	defer collations.RUnlock()
+	return collationNames()
+}
+
+func collationNames() []string {
+	names := make([]string, 0, len(collations.byName))
+	for name := range collations.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type collatedStrings struct {
+	array     []string
+	collation Collation
+}
+
+func (c collatedStrings) Len() int           { return len(c.array) }
+func (c collatedStrings) Swap(i, j int)      { c.array[i], c.array[j] = c.array[j], c.array[i] }
+func (c collatedStrings) Less(i, j int) bool { return c.collation.Less(c.array[i], c.array[j]) }
+
+// SortWith sorts the strings using the collation.
+func SortWith(collation Collation, array []string) {
+	if _, ok := collation.(defaultCollation); ok && allAddresses(array) {
+		collation = CollationFunc(ipLess)
+	}
+	sort.Sort(collatedStrings{array: array, collation: collation})
+}
+
+// splitVersion separates a version into its release fields and its
+// pre-release identifiers, discarding build metadata.
+func splitVersion(version string) ([]string, []string) {
+	if len(version) > 1 && (version[0] == 'v' || version[0] == 'V') && unicode.IsDigit(rune(version[1])) {
+		version = version[1:]
+	}
+	if plus := strings.IndexByte(version, '+'); plus >= 0 {
+		version = version[:plus]
+	}
+	var prerelease []string
+	if dash := strings.IndexByte(version, '-'); dash >= 0 {
+		prerelease = strings.Split(version[dash+1:], ".")
+		version = version[:dash]
+	}
+	return strings.Split(version, "."), prerelease
+}
+
+// compareFields compares dotted fields pairwise with the natural collation,
+// and then by their number.
+func compareFields(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if Less(a[i], b[i]) {
+			return -1
+		}
+		if Less(b[i], a[i]) {
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// versionLess orders version strings such as "1.2.10" or "v2.0.0-rc.1" by
+// their fields, so that "1.10" follows "1.9" and a pre-release precedes its
+// release.
+func versionLess(a, b string) bool {
+	releaseA, prereleaseA := splitVersion(a)
+	releaseB, prereleaseB := splitVersion(b)
+	if c := compareFields(releaseA, releaseB); c != 0 {
+		return c < 0
+	}
+	if (prereleaseA == nil) != (prereleaseB == nil) {
+		return prereleaseA != nil
+	}
+	if c := compareFields(prereleaseA, prereleaseB); c != 0 {
+		return c < 0
+	}
+	return Less(a, b)
+}
+
+func allAddresses(array []string) bool {
+	for _, value := range array {
+		if net.ParseIP(value) == nil {
+			return false
+		}
+	}
+	return len(array) != 0
+}
+
+// ipLess orders addresses numerically, with IPv4 addresses first. Strings
+// which aren't addresses follow them in natural order.
+func ipLess(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if (ipA == nil) != (ipB == nil) {
+		return ipA != nil
+	}
+	if ipA == nil {
+		return Less(a, b)
+	}
+	v4A, v4B := ipA.To4(), ipB.To4()
+	if (v4A == nil) != (v4B == nil) {
+		return v4A != nil
+	}
+	if c := bytes.Compare(ipA.To16(), ipB.To16()); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+// accentFolding maps accented Latin letters to their base letters.
+var accentFolding = func() map[rune]rune {
+	folding := map[rune]rune{}
+	for base, accented := range map[rune]string{
+		'a': "àáâãäåāăą", 'A': "ÀÁÂÃÄÅĀĂĄ",
+		'c': "çćĉċč", 'C': "ÇĆĈĊČ",
+		'd': "ďđ", 'D': "ĎĐ",
+		'e': "èéêëēĕėęě", 'E': "ÈÉÊËĒĔĖĘĚ",
+		'g': "ĝğġģ", 'G': "ĜĞĠĢ",
+		'h': "ĥħ", 'H': "ĤĦ",
+		'i': "ìíîïĩīĭįı", 'I': "ÌÍÎÏĨĪĬĮİ",
+		'j': "ĵ", 'J': "Ĵ",
+		'k': "ķ", 'K': "Ķ",
+		'l': "ĺļľŀł", 'L': "ĹĻĽĿŁ",
+		'n': "ñńņňŉ", 'N': "ÑŃŅŇ",
+		'o': "òóôõöøōŏő", 'O': "ÒÓÔÕÖØŌŎŐ",
+		'r': "ŕŗř", 'R': "ŔŖŘ",
+		's': "śŝşš", 'S': "ŚŜŞŠ",
+		't': "ţťŧ", 'T': "ŢŤŦ",
+		'u': "ùúûüũūŭůűų", 'U': "ÙÚÛÜŨŪŬŮŰŲ",
+		'w': "ŵ", 'W': "Ŵ",
+		'y': "ýÿŷ", 'Y': "ÝŸŶ",
+		'z': "źżž", 'Z': "ŹŻŽ",
+	} {
+		for _, r := range accented {
+			folding[r] = base
+		}
+	}
+	return folding
+}()
+
+func foldAccents(s string) string {
+	return strings.Map(func(r rune) rune {
+		if base, ok := accentFolding[r]; ok {
+			return base
+		}
+		return r
+	}, s)
+}
+
+// localeLess approximates the root collation of the Unicode Collation
+// Algorithm for Latin scripts: letters are compared without accents or case
+// (and digits numerically), then accents break ties, then case.
+func localeLess(a, b string) bool {
+	foldedA, foldedB := foldAccents(a), foldAccents(b)
+	primaryA, primaryB := strings.ToLower(foldedA), strings.ToLower(foldedB)
+	if primaryA != primaryB {
+		return Less(primaryA, primaryB)
+	}
+	if secondaryA, secondaryB := strings.ToLower(a), strings.ToLower(b); secondaryA != secondaryB {
+		// Unaccented letters precede accented ones.
+		return secondaryA < secondaryB
+	}
+	return Less(a, b)
+}
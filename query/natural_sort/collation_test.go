@@ -0,0 +1,77 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package natural_sort
+
+import (
+	"testing"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestCollations(t *testing.T) {
+	a := assert.New(t)
+	tests := []struct {
+		collation string
+		expected  []string
+	}{
+		{Natural, []string{"Apple", "apple", "file2", "file10", "Zoo"}},
+		{Lexical, []string{"Apple", "Zoo", "apple", "file10", "file2"}},
+		{Version, []string{"v1.2.0", "1.9.1", "1.10.0-alpha", "1.10.0-alpha.2", "1.10.0-beta", "1.10.0", "2.0.0+build5"}},
+		{IP, []string{"9.0.0.1", "10.0.0.2", "10.0.0.10", "192.168.1.1", "::1", "fe80::1", "localhost"}},
+		{Locale, []string{"cote", "coté", "Côte", "côté", "resume", "résumé", "zebra"}},
+	}
+	for _, test := range tests {
+		collation, err := Lookup(test.collation)
+		if err != nil {
+			t.Fatalf("collation %q: %s", test.collation, err.Error())
+		}
+		for trial := 0; trial < 20; trial++ {
+			array := append([]string{}, test.expected...)
+			testShuffle(array)
+			SortWith(collation, array)
+			a.Contextf("collation %q", test.collation).Eq(array, test.expected)
+		}
+	}
+}
+
+func TestCollationRegistry(t *testing.T) {
+	a := assert.New(t)
+	if _, err := Lookup("klingon"); err == nil {
+		t.Errorf("expected an unknown collation to be an error")
+	}
+	collation, err := Lookup("")
+	a.CheckError(err)
+	a.EqBool(collation.Less("file2", "file10"), true)
+
+	// By default, addresses are ordered as addresses, but only when every string is one.
+	addresses := []string{"fe80::1", "10.0.0.10", "9.0.0.1", "10.0.0.2"}
+	SortWith(collation, addresses)
+	a.Eq(addresses, []string{"9.0.0.1", "10.0.0.2", "10.0.0.10", "fe80::1"})
+	mixed := []string{"fe80::1", "10.0.0.10", "localhost", "9.0.0.1"}
+	SortWith(collation, mixed)
+	a.Eq(mixed, []string{"9.0.0.1", "10.0.0.10", "fe80::1", "localhost"})
+
+	reversed := CollationFunc(func(x, y string) bool { return x > y })
+	a.CheckError(Register("reversed", reversed))
+	if err := Register("reversed", reversed); err == nil {
+		t.Errorf("expected a duplicate registration to be an error")
+	}
+	a.Eq(Collations(), []string{IP, Lexical, Locale, Natural, "reversed", Version})
+	collation, err = Lookup("reversed")
+	a.CheckError(err)
+	array := []string{"a", "c", "b"}
+	SortWith(collation, array)
+	a.Eq(array, []string{"c", "b", "a"})
+}
@@ -0,0 +1,96 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/square/metrics/query/command"
+)
+
+// Directives are the "@key: value" pairs written in the comments of a query,
+// such as "-- @owner: payments @dashboard: checkout". They don't change what
+// the query does, but are recorded along with it, so that queries can be
+// traced back to those responsible for them.
+type Directives map[string]string
+
+// directive matches the start of each directive; its value is the text up
+// to the next directive or the end of the comment.
+var directive = regexp.MustCompile(`(?:^|\s)@([a-zA-Z_][a-zA-Z0-9_]*)\s*:`)
+
+// String lists the directives in order of their keys.
+func (d Directives) String() string {
+	keys := make([]string, 0, len(d))
+	for key := range d {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("@%s: %s", key, d[key])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// directives finds the directives in the comments of the parsed query.
+// Those of later comments replace those of earlier ones with the same key.
+func (p *Parser) directives() Directives {
+	var comments []token32
+	for _, token := range p.Tokens() {
+		if token.pegRule == ruleCOMMENT_TRAIL || token.pegRule == ruleCOMMENT_BLOCK {
+			comments = append(comments, token)
+		}
+	}
+	sort.Sort(tokensByPosition(comments))
+	var result Directives
+	for _, token := range comments {
+		comment := string(p.buffer[token.begin+2 : token.end]) // without the leading "--" or "/*"
+		if token.pegRule == ruleCOMMENT_BLOCK {
+			comment = strings.TrimSuffix(comment, "*/")
+		}
+		matches := directive.FindAllStringSubmatchIndex(comment, -1)
+		for i, match := range matches {
+			end := len(comment)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+			if result == nil {
+				result = Directives{}
+			}
+			result[comment[match[2]:match[3]]] = strings.TrimSpace(comment[match[1]:end])
+		}
+	}
+	return result
+}
+
+type tokensByPosition []token32
+
+func (t tokensByPosition) Len() int           { return len(t) }
+func (t tokensByPosition) Less(i, j int) bool { return t[i].begin < t[j].begin }
+func (t tokensByPosition) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+
+// ParseWithDirectives parses the query as Parse does, also returning the
+// directives of its comments (nil if there are none).
+func ParseWithDirectives(query string) (command.Command, Directives, error) {
+	p := Parser{Buffer: query}
+	cmd, err := p.run()
+	if err != nil {
+		return nil, nil, err
+	}
+	return cmd, p.directives(), nil
+}
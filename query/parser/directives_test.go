@@ -0,0 +1,50 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestParseWithDirectives(t *testing.T) {
+	for _, test := range []struct {
+		query    string
+		expected Directives
+	}{
+		{"select cpu from -1h to now", nil},
+		{"select cpu from -1h to now -- just a comment", nil},
+		{"-- @owner: payments @dashboard: checkout\nselect cpu from -1h to now", Directives{"owner": "payments", "dashboard": "checkout"}},
+		{"select cpu /* @owner: payments team */ from -1h to now", Directives{"owner": "payments team"}},
+		{"select cpu -- @owner: payments\nfrom -1h to now -- @owner: storage", Directives{"owner": "storage"}},
+		{"select cpu[host = '-- @owner: payments'] from -1h to now", nil},
+		{"select cpu -- mail bob@example.com: thanks\nfrom -1h to now", nil},
+		{"describe cpu -- @ticket: OPS-123", Directives{"ticket": "OPS-123"}},
+	} {
+		a := assert.New(t).Contextf("%q", test.query)
+		_, directives, err := ParseWithDirectives(test.query)
+		a.CheckError(err)
+		a.Eq(directives, test.expected)
+	}
+
+	// Comments may appear between keywords.
+	cmd, directives, err := ParseWithDirectives("select cpu from -1h to now sample /* @owner: payments */ by 'max'")
+	a := assert.New(t)
+	a.CheckError(err)
+	a.Eq(directives, Directives{"owner": "payments"})
+	a.EqString(cmd.Name(), "select")
+	a.EqString(Directives{"owner": "payments", "dashboard": "checkout"}.String(), "@dashboard: checkout @owner: payments")
+}
@@ -26,6 +26,10 @@ func TestErrorMessages(t *testing.T) {
 			query:   "select foo from",
 			message: "line 1, column 16: expected value to follow key 'from'",
 		},
+		{
+			query:   "select foo |> 3 from 0 to 0",
+			message: `line 1, column 14: expected function name to follow pipeline "|>"`,
+		},
 		{
 			query:   "select foo\nfrom -30m to now\nwhere app = 'mqe'",
 			message: `line 3, column 6: encountered "where" after property clause; "where" blocks must go BEFORE 'from' and 'to' specifiers`,
@@ -44,11 +48,15 @@ func TestErrorMessages(t *testing.T) {
 		},
 		{
 			query:   "select crazy#2dinvalid.metric + bar\nwhere tag != 'value' and qux = 'qux'\nfrom -30m to now",
-			message: `line 1, column 13: expected key (one of 'from', 'to', 'resolution', or 'sample by') or end of input but got "#2dinvalid.metric + bar\nwhere tag != 'value' and qux = 'qux'\nfrom -30m to now" following a completed expression`,
+			message: `line 1, column 13: expected key (one of 'from', 'to', 'resolution', 'sample by', 'order by', 'limit', or 'fill') or end of input but got "#2dinvalid.metric + bar\nwhere tag != 'value' and qux = 'qux'\nfrom -30m to now" following a completed expression`,
 		},
 		{
 			query:   "serlect foo from -30m to now",
-			message: `line 1, column 9: expected key (one of 'from', 'to', 'resolution', or 'sample by') or end of input but got "foo from -30m to now" following a completed expression`,
+			message: `line 1, column 9: expected key (one of 'from', 'to', 'resolution', 'sample by', 'order by', 'limit', or 'fill') or end of input but got "foo from -30m to now" following a completed expression`,
+		},
+		{
+			query:   "select foo from -30m to now sample 10",
+			message: `line 1, column 38: expected "%" to follow the percentage in "sample" clause`,
 		},
 		{
 			query:   "describe all where host = 'foo'",
@@ -0,0 +1,118 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+)
+
+// fuzzSeeds are valid and almost-valid queries used to seed the fuzzer.
+var fuzzSeeds = []string{
+	"describe all",
+	"describe all match 'cpu'",
+	"describe metrics where host = 'a'",
+	"describe keys cpu.user",
+	"describe values host where any metrics in (cpu.user, memory.used)",
+	"describe cpu.user",
+	"describe cpu.user where host = 'a' and not (dc in ('east', 'west'))",
+	"describe cpu.user where host match 'a.*' or dc != 'north'",
+	"select cpu.user from -30m to now",
+	"select cpu.user[host = 'a'] + 1 from -1h to now resolution 30s sample by 'max'",
+	"select cpu.user | aggregate.sum group by dc from 0 to 120 resolution 30ms",
+	"select transform.timeshift(cpu.user, -1h), cpu.user {label} where app = 'mqe' from -1d to now",
+	"select cpu.user from -1h to now order by max desc limit 5",
+	"select aggregate.sum(cpu.user) from -1h to now sample 10%",
+	"select `cpu.user` * -2.5e3 / (x - y) from 1413321866 to now",
+	"select foo, bar[host = 'x' and]\nfrom -30m to now",
+	"select foo -- comment\n from -30m to now",
+	"select foo /* comment */ from -30m to now",
+	"select (((a",
+	"describe all where",
+	"",
+}
+
+// FuzzParse checks that arbitrary input never causes the parser to panic.
+// Every input must either produce a command or a regular error.
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, query string) {
+		if !utf8.ValidString(query) {
+			t.Skip()
+		}
+		cmd, err := Parse(query)
+		if err == nil && cmd == nil {
+			t.Fatalf("Parse(%q) returned neither a command nor an error", query)
+		}
+		if err != nil && err.Error() == "" {
+			t.Fatalf("Parse(%q) returned an empty error message", query)
+		}
+	})
+}
+
+// fuzzTagSets are the tagsets that fuzzed predicates are evaluated against.
+var fuzzTagSets = []api.TagSet{
+	{},
+	{"host": "a"},
+	{"host": "b", "dc": "east"},
+	{"host": "a", "dc": "west", "env": "production"},
+	{"dc": "north", "env": "staging"},
+}
+
+// FuzzPredicate checks that predicates survive a round trip through their
+// Query() representation: the re-parsed predicate must accept exactly the
+// same tagsets as the original.
+func FuzzPredicate(f *testing.F) {
+	for _, seed := range []string{
+		"host = 'a'",
+		"host != 'a'",
+		"not host = 'a'",
+		"host in ('a', 'b') and dc = 'east'",
+		"host match 'a.*' or (dc = 'west' and not env = 'staging')",
+		"`host` = \"a\" or dc in ('north')",
+		"peer in cidr '10.0.0.0/8' or peer in cidr ('192.168.0.0/16', 'fd00::/8')",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, clause string) {
+		if !utf8.ValidString(clause) {
+			t.Skip()
+		}
+		original, err := Parse("describe metric where " + clause)
+		if err != nil {
+			return
+		}
+		describe, ok := original.(*command.DescribeCommand)
+		if !ok {
+			return
+		}
+		query := describe.Predicate.Query()
+		reparsed, err := Parse("describe metric where " + query)
+		if err != nil {
+			t.Fatalf("predicate %q (from %q) does not re-parse: %s", query, clause, err.Error())
+		}
+		roundTrip := reparsed.(*command.DescribeCommand)
+		for _, tagset := range fuzzTagSets {
+			if describe.Predicate.Apply(tagset) != roundTrip.Predicate.Apply(tagset) {
+				t.Fatalf("predicate %q and its round trip %q disagree on %+v", clause, query, tagset)
+			}
+		}
+	})
+}
@@ -8,7 +8,9 @@ import (
 	"sort"
 	"strconv"
 
+	"github.com/square/metrics/function"
 	"github.com/square/metrics/query/command"
+	"time"
 )
 
 const endSymbol rune = 1114112
@@ -20,18 +22,30 @@ const (
 	ruleUnknown pegRule = iota
 	ruleroot
 	ruleselectStmt
+	rulewithClause
+	rulewithBinding
+	ruleforecastStmt
+	ruleexplainStmt
 	ruledescribeStmt
 	ruledescribeAllStmt
 	ruleoptionalMatchClause
 	rulematchClause
+	ruledescribeKeys
+	ruledescribeValues
+	rulemetricNameList
 	ruledescribeMetrics
 	ruledescribeSingleStmt
 	rulepropertyClause
 	ruleoptionalPredicateClause
 	ruleexpressionList
 	ruleexpression_start
+	ruleexpression_or
+	ruleexpression_and
+	ruleexpression_comparison
 	ruleexpression_sum
 	ruleexpression_product
+	ruleoperatorMatching
+	rulematchingTags
 	ruleadd_one_pipe
 	ruleadd_pipe
 	ruleexpression_atom
@@ -64,6 +78,7 @@ const (
 	rulePROPERTY_VALUE
 	ruleKEYWORD
 	ruleOP_PIPE
+	ruleOP_PIPELINE
 	ruleOP_ADD
 	ruleOP_SUB
 	ruleOP_MULT
@@ -71,6 +86,8 @@ const (
 	ruleOP_AND
 	ruleOP_OR
 	ruleOP_NOT
+	ruleOP_UNLESS
+	ruleOP_COMPARE
 	ruleQUOTE_SINGLE
 	ruleQUOTE_DOUBLE
 	ruleSTRING
@@ -91,11 +108,11 @@ const (
 	ruleKEY
 	ruleSPACE
 	ruleAction0
+	rulePegText
 	ruleAction1
 	ruleAction2
 	ruleAction3
 	ruleAction4
-	rulePegText
 	ruleAction5
 	ruleAction6
 	ruleAction7
@@ -145,24 +162,70 @@ const (
 	ruleAction51
 	ruleAction52
 	ruleAction53
+	ruleAction54
+	ruleAction55
+	ruleAction56
+	ruleAction57
+	ruleAction58
+	ruleAction59
+	ruleAction60
+	ruleAction61
+	ruleAction62
+	ruleAction63
+	ruleAction64
+	ruleAction65
+	ruleAction66
+	ruleAction67
+	ruleAction68
+	ruleAction69
+	ruleAction70
+	ruleAction71
+	ruleAction72
+	ruleAction73
+	ruleAction74
+	ruleAction75
+	ruleAction76
+	ruleAction77
+	ruleAction78
+	ruleAction79
+	ruleAction80
+	ruleAction81
+	ruleAction82
+	ruleAction83
+	ruleAction84
+	ruleAction85
+	ruleAction86
+	ruleAction87
 )
 
 var rul3s = [...]string{
 	"Unknown",
 	"root",
 	"selectStmt",
+	"withClause",
+	"withBinding",
+	"forecastStmt",
+	"explainStmt",
 	"describeStmt",
 	"describeAllStmt",
 	"optionalMatchClause",
 	"matchClause",
+	"describeKeys",
+	"describeValues",
+	"metricNameList",
 	"describeMetrics",
 	"describeSingleStmt",
 	"propertyClause",
 	"optionalPredicateClause",
 	"expressionList",
 	"expression_start",
+	"expression_or",
+	"expression_and",
+	"expression_comparison",
 	"expression_sum",
 	"expression_product",
+	"operatorMatching",
+	"matchingTags",
 	"add_one_pipe",
 	"add_pipe",
 	"expression_atom",
@@ -195,6 +258,7 @@ var rul3s = [...]string{
 	"PROPERTY_VALUE",
 	"KEYWORD",
 	"OP_PIPE",
+	"OP_PIPELINE",
 	"OP_ADD",
 	"OP_SUB",
 	"OP_MULT",
@@ -202,6 +266,8 @@ var rul3s = [...]string{
 	"OP_AND",
 	"OP_OR",
 	"OP_NOT",
+	"OP_UNLESS",
+	"OP_COMPARE",
 	"QUOTE_SINGLE",
 	"QUOTE_DOUBLE",
 	"STRING",
@@ -222,11 +288,11 @@ var rul3s = [...]string{
 	"KEY",
 	"SPACE",
 	"Action0",
+	"PegText",
 	"Action1",
 	"Action2",
 	"Action3",
 	"Action4",
-	"PegText",
 	"Action5",
 	"Action6",
 	"Action7",
@@ -276,6 +342,40 @@ var rul3s = [...]string{
 	"Action51",
 	"Action52",
 	"Action53",
+	"Action54",
+	"Action55",
+	"Action56",
+	"Action57",
+	"Action58",
+	"Action59",
+	"Action60",
+	"Action61",
+	"Action62",
+	"Action63",
+	"Action64",
+	"Action65",
+	"Action66",
+	"Action67",
+	"Action68",
+	"Action69",
+	"Action70",
+	"Action71",
+	"Action72",
+	"Action73",
+	"Action74",
+	"Action75",
+	"Action76",
+	"Action77",
+	"Action78",
+	"Action79",
+	"Action80",
+	"Action81",
+	"Action82",
+	"Action83",
+	"Action84",
+	"Action85",
+	"Action86",
+	"Action87",
 }
 
 type token32 struct {
@@ -407,12 +507,18 @@ type Parser struct {
 	// programming errors accumulated during the AST traversal.
 	// a non-empty list at the finish time implies a programming error.
 
+	// the time relative dates are measured from; if zero, the current time.
+	now time.Time
+
+	// the expressions bound by the with clause, by name.
+	bindings map[string]function.Expression
+
 	// final result
 	command command.Command
 
 	Buffer string
 	buffer []rune
-	rules  [128]func() bool
+	rules  [177]func() bool
 	parse  func(rule ...int) error
 	reset  func()
 	Pretty bool
@@ -507,114 +613,182 @@ func (p *Parser) Execute() {
 		case ruleAction0:
 			p.makeSelect()
 		case ruleAction1:
-			p.makeDescribeAll()
+			p.pushString(unescapeLiteral(text))
 		case ruleAction2:
-			p.addNullMatchClause()
+			p.addBinding()
 		case ruleAction3:
-			p.addMatchClause()
+			p.pushString(text)
 		case ruleAction4:
-			p.makeDescribeMetrics()
+			p.makeForecast()
 		case ruleAction5:
-			p.pushString(unescapeLiteral(text))
+			p.makeExplain()
 		case ruleAction6:
-			p.makeDescribe()
+			p.makeDescribeAll()
 		case ruleAction7:
-			p.addEvaluationContext()
+			p.addNullMatchClause()
 		case ruleAction8:
-			p.addPropertyKey(text)
+			p.addMatchClause()
 		case ruleAction9:
-
-			p.addPropertyValue(text)
+			p.pushString(unescapeLiteral(text))
 		case ruleAction10:
-			p.insertPropertyKeyValue()
+			p.makeDescribeKeys()
 		case ruleAction11:
-			p.checkPropertyClause()
+			p.pushString(text)
 		case ruleAction12:
-			p.addNullPredicate()
+			p.pushString("all")
 		case ruleAction13:
-			p.addExpressionList()
+			p.makeDescribeValues()
 		case ruleAction14:
-			p.appendExpression()
+			p.addLiteralList()
 		case ruleAction15:
-			p.appendExpression()
+			p.appendLiteral(unescapeLiteral(text))
 		case ruleAction16:
-			p.addOperatorLiteral("+")
+			p.appendLiteral(unescapeLiteral(text))
 		case ruleAction17:
-			p.addOperatorLiteral("-")
+			p.makeDescribeMetrics()
 		case ruleAction18:
-			p.addOperatorFunction()
+			p.pushString(unescapeLiteral(text))
 		case ruleAction19:
-			p.addOperatorLiteral("/")
+			p.makeDescribe()
 		case ruleAction20:
-			p.addOperatorLiteral("*")
+			p.addEvaluationContext()
 		case ruleAction21:
-			p.addOperatorFunction()
+			p.addSamplePercent(text)
 		case ruleAction22:
-			p.pushString(unescapeLiteral(text))
+			p.addPropertyKey(text)
 		case ruleAction23:
-			p.addExpressionList()
+
+			p.addPropertyValue(text)
 		case ruleAction24:
+			p.insertPropertyKeyValue()
+		case ruleAction25:
+			p.addOrderBy(text)
+		case ruleAction26:
+			p.addOrderDirection(text)
+		case ruleAction27:
+			p.addLimit(text)
+		case ruleAction28:
+			p.addFill(text)
+		case ruleAction29:
+			p.checkPropertyClause()
+		case ruleAction30:
+			p.addNullPredicate()
+		case ruleAction31:
+			p.addExpressionList()
+		case ruleAction32:
+			p.appendExpression()
+		case ruleAction33:
+			p.appendExpression()
+		case ruleAction34:
+			p.addOperatorLiteral("or")
+		case ruleAction35:
+			p.addOperatorFunction()
+		case ruleAction36:
+			p.addOperatorLiteral("and")
+		case ruleAction37:
+			p.addOperatorLiteral("unless")
+		case ruleAction38:
+			p.addOperatorFunction()
+		case ruleAction39:
+			p.addOperatorLiteral(text)
+		case ruleAction40:
+			p.addOperatorFunction()
+		case ruleAction41:
+			p.addOperatorLiteral("+")
+		case ruleAction42:
+			p.addOperatorLiteral("-")
+		case ruleAction43:
+			p.addOperatorFunction()
+		case ruleAction44:
+			p.addOperatorLiteral("/")
+		case ruleAction45:
+			p.addOperatorLiteral("*")
+		case ruleAction46:
+			p.addOperatorFunction()
+		case ruleAction47:
+			p.addGroupBy()
+		case ruleAction48:
+			p.addCollapseBy()
+		case ruleAction49:
+			p.addGroupBy()
+		case ruleAction50:
+			p.appendGroupTag(unescapeLiteral(text))
+		case ruleAction51:
+			p.appendGroupTag(unescapeLiteral(text))
+		case ruleAction52:
+			p.pushFunctionName(unescapeLiteral(text), begin)
+		case ruleAction53:
+			p.addExpressionList()
+		case ruleAction54:
 
 			p.addExpressionList()
 			p.addGroupBy()
 
-		case ruleAction25:
+		case ruleAction55:
 			p.addPipeExpression()
-		case ruleAction26:
+		case ruleAction56:
 			p.addDurationNode(text)
-		case ruleAction27:
+		case ruleAction57:
 			p.addNumberNode(text)
-		case ruleAction28:
+		case ruleAction58:
 			p.addStringNode(unescapeLiteral(text))
-		case ruleAction29:
+		case ruleAction59:
 			p.addAnnotationExpression(text)
-		case ruleAction30:
+		case ruleAction60:
 			p.addGroupBy()
-		case ruleAction31:
-			p.pushString(unescapeLiteral(text))
-		case ruleAction32:
+		case ruleAction61:
+			p.pushFunctionName(unescapeLiteral(text), begin)
+		case ruleAction62:
 			p.addFunctionInvocation()
-		case ruleAction33:
-			p.pushString(unescapeLiteral(text))
-		case ruleAction34:
+		case ruleAction63:
+			p.pushMetricName(unescapeLiteral(text), begin)
+		case ruleAction64:
 			p.addNullPredicate()
-		case ruleAction35:
+		case ruleAction65:
 			p.addMetricExpression()
-		case ruleAction36:
+		case ruleAction66:
 			p.addGroupBy()
-		case ruleAction37:
+		case ruleAction67:
 			p.appendGroupTag(unescapeLiteral(text))
-		case ruleAction38:
+		case ruleAction68:
 			p.appendGroupTag(unescapeLiteral(text))
-		case ruleAction39:
+		case ruleAction69:
 			p.addCollapseBy()
-		case ruleAction40:
+		case ruleAction70:
 			p.appendGroupTag(unescapeLiteral(text))
-		case ruleAction41:
+		case ruleAction71:
 			p.appendGroupTag(unescapeLiteral(text))
-		case ruleAction42:
+		case ruleAction72:
 			p.addOrPredicate()
-		case ruleAction43:
+		case ruleAction73:
 			p.addAndPredicate()
-		case ruleAction44:
+		case ruleAction74:
 			p.addNotPredicate()
-		case ruleAction45:
+		case ruleAction75:
 			p.addLiteralMatcher()
-		case ruleAction46:
+		case ruleAction76:
 			p.addLiteralMatcher()
-		case ruleAction47:
+		case ruleAction77:
 			p.addNotPredicate()
-		case ruleAction48:
+		case ruleAction78:
 			p.addRegexMatcher()
-		case ruleAction49:
+		case ruleAction79:
+			p.addCIDRMatcher()
+		case ruleAction80:
+			p.addCIDRListMatcher()
+		case ruleAction81:
+			p.addGlobMatcher()
+		case ruleAction82:
+			p.addGlobListMatcher()
+		case ruleAction83:
 			p.addListMatcher()
-		case ruleAction50:
+		case ruleAction84:
 			p.pushString(unescapeLiteral(text))
-		case ruleAction51:
+		case ruleAction85:
 			p.addLiteralList()
-		case ruleAction52:
+		case ruleAction86:
 			p.appendLiteral(unescapeLiteral(text))
-		case ruleAction53:
+		case ruleAction87:
 			p.addTagLiteral(unescapeLiteral(text))
 
 		}
@@ -690,7 +864,7 @@ func (p *Parser) Init() {
 
 	_rules = [...]func() bool{
 		nil,
-		/* 0 root <- <((selectStmt / describeStmt) _ !.)> */
+		/* 0 root <- <((explainStmt / forecastStmt / selectStmt / describeStmt) _ !.)> */
 		func() bool {
 			position0, tokenIndex0 := position, tokenIndex
 			{
@@ -704,5605 +878,9054 @@ func (p *Parser) Init() {
 						}
 						{
 							position5, tokenIndex5 := position, tokenIndex
-							{
-								position7, tokenIndex7 := position, tokenIndex
-								if buffer[position] != rune('s') {
-									goto l8
-								}
-								position++
-								goto l7
-							l8:
-								position, tokenIndex = position7, tokenIndex7
-								if buffer[position] != rune('S') {
-									goto l5
-								}
-								position++
+							if buffer[position] != rune('e') {
+								goto l6
 							}
-						l7:
-							{
-								position9, tokenIndex9 := position, tokenIndex
-								if buffer[position] != rune('e') {
-									goto l10
-								}
-								position++
-								goto l9
-							l10:
-								position, tokenIndex = position9, tokenIndex9
-								if buffer[position] != rune('E') {
-									goto l5
-								}
-								position++
+							position++
+							goto l5
+						l6:
+							position, tokenIndex = position5, tokenIndex5
+							if buffer[position] != rune('E') {
+								goto l3
 							}
-						l9:
-							{
-								position11, tokenIndex11 := position, tokenIndex
-								if buffer[position] != rune('l') {
-									goto l12
-								}
-								position++
-								goto l11
-							l12:
-								position, tokenIndex = position11, tokenIndex11
-								if buffer[position] != rune('L') {
-									goto l5
-								}
-								position++
+							position++
+						}
+					l5:
+						{
+							position7, tokenIndex7 := position, tokenIndex
+							if buffer[position] != rune('x') {
+								goto l8
 							}
-						l11:
-							{
-								position13, tokenIndex13 := position, tokenIndex
-								if buffer[position] != rune('e') {
-									goto l14
-								}
-								position++
-								goto l13
-							l14:
-								position, tokenIndex = position13, tokenIndex13
-								if buffer[position] != rune('E') {
-									goto l5
-								}
-								position++
+							position++
+							goto l7
+						l8:
+							position, tokenIndex = position7, tokenIndex7
+							if buffer[position] != rune('X') {
+								goto l3
 							}
-						l13:
-							{
-								position15, tokenIndex15 := position, tokenIndex
-								if buffer[position] != rune('c') {
-									goto l16
-								}
-								position++
-								goto l15
-							l16:
-								position, tokenIndex = position15, tokenIndex15
-								if buffer[position] != rune('C') {
-									goto l5
-								}
-								position++
+							position++
+						}
+					l7:
+						{
+							position9, tokenIndex9 := position, tokenIndex
+							if buffer[position] != rune('p') {
+								goto l10
 							}
-						l15:
-							{
-								position17, tokenIndex17 := position, tokenIndex
-								if buffer[position] != rune('t') {
-									goto l18
-								}
-								position++
-								goto l17
-							l18:
-								position, tokenIndex = position17, tokenIndex17
-								if buffer[position] != rune('T') {
-									goto l5
-								}
-								position++
+							position++
+							goto l9
+						l10:
+							position, tokenIndex = position9, tokenIndex9
+							if buffer[position] != rune('P') {
+								goto l3
 							}
-						l17:
-							if !_rules[ruleKEY]() {
-								goto l5
+							position++
+						}
+					l9:
+						{
+							position11, tokenIndex11 := position, tokenIndex
+							if buffer[position] != rune('l') {
+								goto l12
 							}
-							goto l6
-						l5:
-							position, tokenIndex = position5, tokenIndex5
+							position++
+							goto l11
+						l12:
+							position, tokenIndex = position11, tokenIndex11
+							if buffer[position] != rune('L') {
+								goto l3
+							}
+							position++
 						}
-					l6:
-						if !_rules[ruleexpressionList]() {
-							goto l3
+					l11:
+						{
+							position13, tokenIndex13 := position, tokenIndex
+							if buffer[position] != rune('a') {
+								goto l14
+							}
+							position++
+							goto l13
+						l14:
+							position, tokenIndex = position13, tokenIndex13
+							if buffer[position] != rune('A') {
+								goto l3
+							}
+							position++
+						}
+					l13:
+						{
+							position15, tokenIndex15 := position, tokenIndex
+							if buffer[position] != rune('i') {
+								goto l16
+							}
+							position++
+							goto l15
+						l16:
+							position, tokenIndex = position15, tokenIndex15
+							if buffer[position] != rune('I') {
+								goto l3
+							}
+							position++
+						}
+					l15:
+						{
+							position17, tokenIndex17 := position, tokenIndex
+							if buffer[position] != rune('n') {
+								goto l18
+							}
+							position++
+							goto l17
+						l18:
+							position, tokenIndex = position17, tokenIndex17
+							if buffer[position] != rune('N') {
+								goto l3
+							}
+							position++
 						}
-						if !(p.setContext("after expression of select statement")) {
+					l17:
+						if !_rules[ruleKEY]() {
 							goto l3
 						}
-						if !_rules[ruleoptionalPredicateClause]() {
+						{
+							position19, tokenIndex19 := position, tokenIndex
+							if buffer[position] != rune('.') {
+								goto l19
+							}
+							position++
 							goto l3
+						l19:
+							position, tokenIndex = position19, tokenIndex19
 						}
-						if !(p.setContext("")) {
+						if !_rules[ruleselectStmt]() {
 							goto l3
 						}
 						{
-							position19 := position
-							{
-								add(ruleAction7, position)
+							add(ruleAction5, position)
+						}
+						add(ruleexplainStmt, position4)
+					}
+					goto l2
+				l3:
+					position, tokenIndex = position2, tokenIndex2
+					{
+						position22 := position
+						if !_rules[rule_]() {
+							goto l21
+						}
+						{
+							position23, tokenIndex23 := position, tokenIndex
+							if buffer[position] != rune('f') {
+								goto l24
 							}
-						l21:
-							{
-								position22, tokenIndex22 := position, tokenIndex
-								{
-									position23, tokenIndex23 := position, tokenIndex
-									if !_rules[rule_]() {
-										goto l24
-									}
-									{
-										position25 := position
-										{
-											switch buffer[position] {
-											case 'S', 's':
-												{
-													position27 := position
-													{
-														position28, tokenIndex28 := position, tokenIndex
-														if buffer[position] != rune('s') {
-															goto l29
-														}
-														position++
-														goto l28
-													l29:
-														position, tokenIndex = position28, tokenIndex28
-														if buffer[position] != rune('S') {
-															goto l24
-														}
-														position++
-													}
-												l28:
-													{
-														position30, tokenIndex30 := position, tokenIndex
-														if buffer[position] != rune('a') {
-															goto l31
-														}
-														position++
-														goto l30
-													l31:
-														position, tokenIndex = position30, tokenIndex30
-														if buffer[position] != rune('A') {
-															goto l24
-														}
-														position++
-													}
-												l30:
-													{
-														position32, tokenIndex32 := position, tokenIndex
-														if buffer[position] != rune('m') {
-															goto l33
-														}
-														position++
-														goto l32
-													l33:
-														position, tokenIndex = position32, tokenIndex32
-														if buffer[position] != rune('M') {
-															goto l24
-														}
-														position++
-													}
-												l32:
-													{
-														position34, tokenIndex34 := position, tokenIndex
-														if buffer[position] != rune('p') {
-															goto l35
-														}
-														position++
-														goto l34
-													l35:
-														position, tokenIndex = position34, tokenIndex34
-														if buffer[position] != rune('P') {
-															goto l24
-														}
-														position++
-													}
-												l34:
-													{
-														position36, tokenIndex36 := position, tokenIndex
-														if buffer[position] != rune('l') {
-															goto l37
-														}
-														position++
-														goto l36
-													l37:
-														position, tokenIndex = position36, tokenIndex36
-														if buffer[position] != rune('L') {
-															goto l24
-														}
-														position++
-													}
-												l36:
-													{
-														position38, tokenIndex38 := position, tokenIndex
-														if buffer[position] != rune('e') {
-															goto l39
-														}
-														position++
-														goto l38
-													l39:
-														position, tokenIndex = position38, tokenIndex38
-														if buffer[position] != rune('E') {
-															goto l24
-														}
-														position++
-													}
-												l38:
-													add(rulePegText, position27)
-												}
-												if !_rules[ruleKEY]() {
-													goto l24
-												}
-												{
-													position40, tokenIndex40 := position, tokenIndex
-													if !_rules[rule_]() {
-														goto l41
-													}
-													{
-														position42, tokenIndex42 := position, tokenIndex
-														if buffer[position] != rune('b') {
-															goto l43
-														}
-														position++
-														goto l42
-													l43:
-														position, tokenIndex = position42, tokenIndex42
-														if buffer[position] != rune('B') {
-															goto l41
-														}
-														position++
-													}
-												l42:
-													{
-														position44, tokenIndex44 := position, tokenIndex
-														if buffer[position] != rune('y') {
-															goto l45
-														}
-														position++
-														goto l44
-													l45:
-														position, tokenIndex = position44, tokenIndex44
-														if buffer[position] != rune('Y') {
-															goto l41
-														}
-														position++
-													}
-												l44:
-													if !_rules[ruleKEY]() {
-														goto l41
-													}
-													goto l40
-												l41:
-													position, tokenIndex = position40, tokenIndex40
-													if !(p.errorHere(position, `expected keyword "by" to follow keyword "sample"`)) {
-														goto l24
-													}
-												}
-											l40:
-												break
-											case 'R', 'r':
-												{
-													position46 := position
-													{
-														position47, tokenIndex47 := position, tokenIndex
-														if buffer[position] != rune('r') {
-															goto l48
-														}
-														position++
-														goto l47
-													l48:
-														position, tokenIndex = position47, tokenIndex47
-														if buffer[position] != rune('R') {
-															goto l24
-														}
-														position++
-													}
-												l47:
-													{
-														position49, tokenIndex49 := position, tokenIndex
-														if buffer[position] != rune('e') {
-															goto l50
-														}
-														position++
-														goto l49
-													l50:
-														position, tokenIndex = position49, tokenIndex49
-														if buffer[position] != rune('E') {
-															goto l24
-														}
-														position++
-													}
-												l49:
-													{
-														position51, tokenIndex51 := position, tokenIndex
-														if buffer[position] != rune('s') {
-															goto l52
-														}
-														position++
-														goto l51
-													l52:
-														position, tokenIndex = position51, tokenIndex51
-														if buffer[position] != rune('S') {
-															goto l24
-														}
-														position++
-													}
-												l51:
-													{
-														position53, tokenIndex53 := position, tokenIndex
-														if buffer[position] != rune('o') {
-															goto l54
-														}
-														position++
-														goto l53
-													l54:
-														position, tokenIndex = position53, tokenIndex53
-														if buffer[position] != rune('O') {
-															goto l24
-														}
-														position++
-													}
-												l53:
-													{
-														position55, tokenIndex55 := position, tokenIndex
-														if buffer[position] != rune('l') {
-															goto l56
-														}
-														position++
-														goto l55
-													l56:
-														position, tokenIndex = position55, tokenIndex55
-														if buffer[position] != rune('L') {
-															goto l24
-														}
-														position++
-													}
-												l55:
-													{
-														position57, tokenIndex57 := position, tokenIndex
-														if buffer[position] != rune('u') {
-															goto l58
-														}
-														position++
-														goto l57
-													l58:
-														position, tokenIndex = position57, tokenIndex57
-														if buffer[position] != rune('U') {
-															goto l24
-														}
-														position++
-													}
-												l57:
-													{
-														position59, tokenIndex59 := position, tokenIndex
-														if buffer[position] != rune('t') {
-															goto l60
-														}
-														position++
-														goto l59
-													l60:
-														position, tokenIndex = position59, tokenIndex59
-														if buffer[position] != rune('T') {
-															goto l24
-														}
-														position++
-													}
-												l59:
-													{
-														position61, tokenIndex61 := position, tokenIndex
-														if buffer[position] != rune('i') {
-															goto l62
-														}
-														position++
-														goto l61
-													l62:
-														position, tokenIndex = position61, tokenIndex61
-														if buffer[position] != rune('I') {
-															goto l24
-														}
-														position++
-													}
-												l61:
-													{
-														position63, tokenIndex63 := position, tokenIndex
-														if buffer[position] != rune('o') {
-															goto l64
-														}
-														position++
-														goto l63
-													l64:
-														position, tokenIndex = position63, tokenIndex63
-														if buffer[position] != rune('O') {
-															goto l24
-														}
-														position++
-													}
-												l63:
-													{
-														position65, tokenIndex65 := position, tokenIndex
-														if buffer[position] != rune('n') {
-															goto l66
-														}
-														position++
-														goto l65
-													l66:
-														position, tokenIndex = position65, tokenIndex65
-														if buffer[position] != rune('N') {
-															goto l24
-														}
-														position++
-													}
-												l65:
-													add(rulePegText, position46)
-												}
-												if !_rules[ruleKEY]() {
-													goto l24
-												}
-												break
-											case 'T', 't':
-												{
-													position67 := position
-													{
-														position68, tokenIndex68 := position, tokenIndex
-														if buffer[position] != rune('t') {
-															goto l69
-														}
-														position++
-														goto l68
-													l69:
-														position, tokenIndex = position68, tokenIndex68
-														if buffer[position] != rune('T') {
-															goto l24
-														}
-														position++
-													}
-												l68:
-													{
-														position70, tokenIndex70 := position, tokenIndex
-														if buffer[position] != rune('o') {
-															goto l71
-														}
-														position++
-														goto l70
-													l71:
-														position, tokenIndex = position70, tokenIndex70
-														if buffer[position] != rune('O') {
-															goto l24
-														}
-														position++
-													}
-												l70:
-													add(rulePegText, position67)
-												}
-												if !_rules[ruleKEY]() {
-													goto l24
-												}
-												break
-											default:
-												{
-													position72 := position
-													{
-														position73, tokenIndex73 := position, tokenIndex
-														if buffer[position] != rune('f') {
-															goto l74
-														}
-														position++
-														goto l73
-													l74:
-														position, tokenIndex = position73, tokenIndex73
-														if buffer[position] != rune('F') {
-															goto l24
-														}
-														position++
-													}
-												l73:
-													{
-														position75, tokenIndex75 := position, tokenIndex
-														if buffer[position] != rune('r') {
-															goto l76
-														}
-														position++
-														goto l75
-													l76:
-														position, tokenIndex = position75, tokenIndex75
-														if buffer[position] != rune('R') {
-															goto l24
-														}
-														position++
-													}
-												l75:
-													{
-														position77, tokenIndex77 := position, tokenIndex
-														if buffer[position] != rune('o') {
-															goto l78
-														}
-														position++
-														goto l77
-													l78:
-														position, tokenIndex = position77, tokenIndex77
-														if buffer[position] != rune('O') {
-															goto l24
-														}
-														position++
-													}
-												l77:
-													{
-														position79, tokenIndex79 := position, tokenIndex
-														if buffer[position] != rune('m') {
-															goto l80
-														}
-														position++
-														goto l79
-													l80:
-														position, tokenIndex = position79, tokenIndex79
-														if buffer[position] != rune('M') {
-															goto l24
-														}
-														position++
-													}
-												l79:
-													add(rulePegText, position72)
-												}
-												if !_rules[ruleKEY]() {
-													goto l24
-												}
-												break
-											}
-										}
-
-										add(rulePROPERTY_KEY, position25)
-									}
-									{
-										add(ruleAction8, position)
-									}
-									{
-										position82, tokenIndex82 := position, tokenIndex
-										if !_rules[rule_]() {
-											goto l83
-										}
-										{
-											position84 := position
-											{
-												position85 := position
-												{
-													position86, tokenIndex86 := position, tokenIndex
-													if !_rules[rule_]() {
-														goto l87
-													}
-													{
-														position88 := position
-														if !_rules[ruleNUMBER]() {
-															goto l87
-														}
-													l89:
-														{
-															position90, tokenIndex90 := position, tokenIndex
-															{
-																position91, tokenIndex91 := position, tokenIndex
-																if c := buffer[position]; c < rune('a') || c > rune('z') {
-																	goto l92
-																}
-																position++
-																goto l91
-															l92:
-																position, tokenIndex = position91, tokenIndex91
-																if c := buffer[position]; c < rune('A') || c > rune('Z') {
-																	goto l90
-																}
-																position++
-															}
-														l91:
-															goto l89
-														l90:
-															position, tokenIndex = position90, tokenIndex90
-														}
-														add(rulePegText, position88)
-													}
-													goto l86
-												l87:
-													position, tokenIndex = position86, tokenIndex86
-													if !_rules[rule_]() {
-														goto l93
-													}
-													if !_rules[ruleSTRING]() {
-														goto l93
-													}
-													goto l86
-												l93:
-													position, tokenIndex = position86, tokenIndex86
-													if !_rules[rule_]() {
-														goto l83
-													}
-													{
-														position94 := position
-														{
-															position95, tokenIndex95 := position, tokenIndex
-															if buffer[position] != rune('n') {
-																goto l96
-															}
-															position++
-															goto l95
-														l96:
-															position, tokenIndex = position95, tokenIndex95
-															if buffer[position] != rune('N') {
-																goto l83
-															}
-															position++
-														}
-													l95:
-														{
-															position97, tokenIndex97 := position, tokenIndex
-															if buffer[position] != rune('o') {
-																goto l98
-															}
-															position++
-															goto l97
-														l98:
-															position, tokenIndex = position97, tokenIndex97
-															if buffer[position] != rune('O') {
-																goto l83
-															}
-															position++
-														}
-													l97:
-														{
-															position99, tokenIndex99 := position, tokenIndex
-															if buffer[position] != rune('w') {
-																goto l100
-															}
-															position++
-															goto l99
-														l100:
-															position, tokenIndex = position99, tokenIndex99
-															if buffer[position] != rune('W') {
-																goto l83
-															}
-															position++
-														}
-													l99:
-														add(rulePegText, position94)
-													}
-													if !_rules[ruleKEY]() {
-														goto l83
-													}
-												}
-											l86:
-												add(ruleTIMESTAMP, position85)
-											}
-											add(rulePROPERTY_VALUE, position84)
-										}
-										{
-											add(ruleAction9, position)
-										}
-										goto l82
-									l83:
-										position, tokenIndex = position82, tokenIndex82
-										if !(p.errorHere(position, `expected value to follow key '%s'`, p.contents(tree, tokenIndex-2))) {
-											goto l24
-										}
-									}
-								l82:
-									{
-										add(ruleAction10, position)
-									}
-									goto l23
-								l24:
-									position, tokenIndex = position23, tokenIndex23
-									if !_rules[rule_]() {
-										goto l103
-									}
-									{
-										position104, tokenIndex104 := position, tokenIndex
-										if buffer[position] != rune('w') {
-											goto l105
-										}
-										position++
-										goto l104
-									l105:
-										position, tokenIndex = position104, tokenIndex104
-										if buffer[position] != rune('W') {
-											goto l103
-										}
-										position++
-									}
-								l104:
-									{
-										position106, tokenIndex106 := position, tokenIndex
-										if buffer[position] != rune('h') {
-											goto l107
-										}
-										position++
-										goto l106
-									l107:
-										position, tokenIndex = position106, tokenIndex106
-										if buffer[position] != rune('H') {
-											goto l103
-										}
-										position++
-									}
-								l106:
-									{
-										position108, tokenIndex108 := position, tokenIndex
-										if buffer[position] != rune('e') {
-											goto l109
-										}
-										position++
-										goto l108
-									l109:
-										position, tokenIndex = position108, tokenIndex108
-										if buffer[position] != rune('E') {
-											goto l103
-										}
-										position++
-									}
-								l108:
-									{
-										position110, tokenIndex110 := position, tokenIndex
-										if buffer[position] != rune('r') {
-											goto l111
-										}
-										position++
-										goto l110
-									l111:
-										position, tokenIndex = position110, tokenIndex110
-										if buffer[position] != rune('R') {
-											goto l103
-										}
-										position++
-									}
-								l110:
-									{
-										position112, tokenIndex112 := position, tokenIndex
-										if buffer[position] != rune('e') {
-											goto l113
-										}
-										position++
-										goto l112
-									l113:
-										position, tokenIndex = position112, tokenIndex112
-										if buffer[position] != rune('E') {
-											goto l103
-										}
-										position++
-									}
-								l112:
-									if !_rules[ruleKEY]() {
-										goto l103
-									}
-									if !(p.errorHere(position, `encountered "where" after property clause; "where" blocks must go BEFORE 'from' and 'to' specifiers`)) {
-										goto l103
-									}
-									goto l23
-								l103:
-									position, tokenIndex = position23, tokenIndex23
-									if !_rules[rule_]() {
-										goto l22
-									}
-									{
-										position114, tokenIndex114 := position, tokenIndex
-										{
-											position115, tokenIndex115 := position, tokenIndex
-											if !matchDot() {
-												goto l115
-											}
-											goto l114
-										l115:
-											position, tokenIndex = position115, tokenIndex115
-										}
-										goto l22
-									l114:
-										position, tokenIndex = position114, tokenIndex114
-									}
-									if !(p.errorHere(position, `expected key (one of 'from', 'to', 'resolution', or 'sample by') or end of input but got %q following a completed expression`, p.after(position))) {
-										goto l22
-									}
+							position++
+							goto l23
+						l24:
+							position, tokenIndex = position23, tokenIndex23
+							if buffer[position] != rune('F') {
+								goto l21
+							}
+							position++
+						}
+					l23:
+						{
+							position25, tokenIndex25 := position, tokenIndex
+							if buffer[position] != rune('o') {
+								goto l26
+							}
+							position++
+							goto l25
+						l26:
+							position, tokenIndex = position25, tokenIndex25
+							if buffer[position] != rune('O') {
+								goto l21
+							}
+							position++
+						}
+					l25:
+						{
+							position27, tokenIndex27 := position, tokenIndex
+							if buffer[position] != rune('r') {
+								goto l28
+							}
+							position++
+							goto l27
+						l28:
+							position, tokenIndex = position27, tokenIndex27
+							if buffer[position] != rune('R') {
+								goto l21
+							}
+							position++
+						}
+					l27:
+						{
+							position29, tokenIndex29 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l30
+							}
+							position++
+							goto l29
+						l30:
+							position, tokenIndex = position29, tokenIndex29
+							if buffer[position] != rune('E') {
+								goto l21
+							}
+							position++
+						}
+					l29:
+						{
+							position31, tokenIndex31 := position, tokenIndex
+							if buffer[position] != rune('c') {
+								goto l32
+							}
+							position++
+							goto l31
+						l32:
+							position, tokenIndex = position31, tokenIndex31
+							if buffer[position] != rune('C') {
+								goto l21
+							}
+							position++
+						}
+					l31:
+						{
+							position33, tokenIndex33 := position, tokenIndex
+							if buffer[position] != rune('a') {
+								goto l34
+							}
+							position++
+							goto l33
+						l34:
+							position, tokenIndex = position33, tokenIndex33
+							if buffer[position] != rune('A') {
+								goto l21
+							}
+							position++
+						}
+					l33:
+						{
+							position35, tokenIndex35 := position, tokenIndex
+							if buffer[position] != rune('s') {
+								goto l36
+							}
+							position++
+							goto l35
+						l36:
+							position, tokenIndex = position35, tokenIndex35
+							if buffer[position] != rune('S') {
+								goto l21
+							}
+							position++
+						}
+					l35:
+						{
+							position37, tokenIndex37 := position, tokenIndex
+							if buffer[position] != rune('t') {
+								goto l38
+							}
+							position++
+							goto l37
+						l38:
+							position, tokenIndex = position37, tokenIndex37
+							if buffer[position] != rune('T') {
+								goto l21
+							}
+							position++
+						}
+					l37:
+						if !_rules[ruleKEY]() {
+							goto l21
+						}
+						{
+							position39, tokenIndex39 := position, tokenIndex
+							if buffer[position] != rune('.') {
+								goto l39
+							}
+							position++
+							goto l21
+						l39:
+							position, tokenIndex = position39, tokenIndex39
+						}
+						if !_rules[ruleexpressionList]() {
+							goto l21
+						}
+						{
+							position40, tokenIndex40 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l41
+							}
+							{
+								position42, tokenIndex42 := position, tokenIndex
+								if buffer[position] != rune('r') {
+									goto l43
+								}
+								position++
+								goto l42
+							l43:
+								position, tokenIndex = position42, tokenIndex42
+								if buffer[position] != rune('R') {
+									goto l41
+								}
+								position++
+							}
+						l42:
+							{
+								position44, tokenIndex44 := position, tokenIndex
+								if buffer[position] != rune('e') {
+									goto l45
+								}
+								position++
+								goto l44
+							l45:
+								position, tokenIndex = position44, tokenIndex44
+								if buffer[position] != rune('E') {
+									goto l41
+								}
+								position++
+							}
+						l44:
+							{
+								position46, tokenIndex46 := position, tokenIndex
+								if buffer[position] != rune('a') {
+									goto l47
+								}
+								position++
+								goto l46
+							l47:
+								position, tokenIndex = position46, tokenIndex46
+								if buffer[position] != rune('A') {
+									goto l41
+								}
+								position++
+							}
+						l46:
+							{
+								position48, tokenIndex48 := position, tokenIndex
+								if buffer[position] != rune('c') {
+									goto l49
+								}
+								position++
+								goto l48
+							l49:
+								position, tokenIndex = position48, tokenIndex48
+								if buffer[position] != rune('C') {
+									goto l41
+								}
+								position++
+							}
+						l48:
+							{
+								position50, tokenIndex50 := position, tokenIndex
+								if buffer[position] != rune('h') {
+									goto l51
 								}
-							l23:
+								position++
+								goto l50
+							l51:
+								position, tokenIndex = position50, tokenIndex50
+								if buffer[position] != rune('H') {
+									goto l41
+								}
+								position++
+							}
+						l50:
+							if !_rules[ruleKEY]() {
+								goto l41
+							}
+							goto l40
+						l41:
+							position, tokenIndex = position40, tokenIndex40
+							if !(p.errorHere(position, `expected keyword "reach" to follow expression of forecast statement`)) {
 								goto l21
-							l22:
-								position, tokenIndex = position22, tokenIndex22
+							}
+						}
+					l40:
+						{
+							position52, tokenIndex52 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l53
+							}
+							{
+								position54 := position
+								if !_rules[ruleNUMBER]() {
+									goto l53
+								}
+								add(rulePegText, position54)
 							}
 							{
-								add(ruleAction11, position)
+								add(ruleAction3, position)
+							}
+							goto l52
+						l53:
+							position, tokenIndex = position52, tokenIndex52
+							if !(p.errorHere(position, `expected threshold to follow keyword "reach"`)) {
+								goto l21
 							}
-							add(rulepropertyClause, position19)
+						}
+					l52:
+						if !(p.setContext("after threshold of forecast statement")) {
+							goto l21
+						}
+						if !_rules[ruleoptionalPredicateClause]() {
+							goto l21
+						}
+						if !(p.setContext("")) {
+							goto l21
+						}
+						if !_rules[rulepropertyClause]() {
+							goto l21
 						}
 						{
-							add(ruleAction0, position)
+							add(ruleAction4, position)
 						}
-						add(ruleselectStmt, position4)
+						add(ruleforecastStmt, position22)
 					}
 					goto l2
-				l3:
+				l21:
+					position, tokenIndex = position2, tokenIndex2
+					if !_rules[ruleselectStmt]() {
+						goto l57
+					}
+					goto l2
+				l57:
 					position, tokenIndex = position2, tokenIndex2
 					{
-						position118 := position
+						position58 := position
 						if !_rules[rule_]() {
 							goto l0
 						}
 						{
-							position119, tokenIndex119 := position, tokenIndex
+							position59, tokenIndex59 := position, tokenIndex
 							if buffer[position] != rune('d') {
-								goto l120
+								goto l60
 							}
 							position++
-							goto l119
-						l120:
-							position, tokenIndex = position119, tokenIndex119
+							goto l59
+						l60:
+							position, tokenIndex = position59, tokenIndex59
 							if buffer[position] != rune('D') {
 								goto l0
 							}
 							position++
 						}
-					l119:
+					l59:
 						{
-							position121, tokenIndex121 := position, tokenIndex
+							position61, tokenIndex61 := position, tokenIndex
 							if buffer[position] != rune('e') {
-								goto l122
+								goto l62
 							}
 							position++
-							goto l121
-						l122:
-							position, tokenIndex = position121, tokenIndex121
+							goto l61
+						l62:
+							position, tokenIndex = position61, tokenIndex61
 							if buffer[position] != rune('E') {
 								goto l0
 							}
 							position++
 						}
-					l121:
+					l61:
 						{
-							position123, tokenIndex123 := position, tokenIndex
+							position63, tokenIndex63 := position, tokenIndex
 							if buffer[position] != rune('s') {
-								goto l124
+								goto l64
 							}
 							position++
-							goto l123
-						l124:
-							position, tokenIndex = position123, tokenIndex123
+							goto l63
+						l64:
+							position, tokenIndex = position63, tokenIndex63
 							if buffer[position] != rune('S') {
 								goto l0
 							}
 							position++
 						}
-					l123:
+					l63:
 						{
-							position125, tokenIndex125 := position, tokenIndex
+							position65, tokenIndex65 := position, tokenIndex
 							if buffer[position] != rune('c') {
-								goto l126
+								goto l66
 							}
 							position++
-							goto l125
-						l126:
-							position, tokenIndex = position125, tokenIndex125
+							goto l65
+						l66:
+							position, tokenIndex = position65, tokenIndex65
 							if buffer[position] != rune('C') {
 								goto l0
 							}
 							position++
 						}
-					l125:
+					l65:
 						{
-							position127, tokenIndex127 := position, tokenIndex
+							position67, tokenIndex67 := position, tokenIndex
 							if buffer[position] != rune('r') {
-								goto l128
+								goto l68
 							}
 							position++
-							goto l127
-						l128:
-							position, tokenIndex = position127, tokenIndex127
+							goto l67
+						l68:
+							position, tokenIndex = position67, tokenIndex67
 							if buffer[position] != rune('R') {
 								goto l0
 							}
 							position++
 						}
-					l127:
+					l67:
 						{
-							position129, tokenIndex129 := position, tokenIndex
+							position69, tokenIndex69 := position, tokenIndex
 							if buffer[position] != rune('i') {
-								goto l130
+								goto l70
 							}
 							position++
-							goto l129
-						l130:
-							position, tokenIndex = position129, tokenIndex129
+							goto l69
+						l70:
+							position, tokenIndex = position69, tokenIndex69
 							if buffer[position] != rune('I') {
 								goto l0
 							}
 							position++
 						}
-					l129:
+					l69:
 						{
-							position131, tokenIndex131 := position, tokenIndex
+							position71, tokenIndex71 := position, tokenIndex
 							if buffer[position] != rune('b') {
-								goto l132
+								goto l72
 							}
 							position++
-							goto l131
-						l132:
-							position, tokenIndex = position131, tokenIndex131
+							goto l71
+						l72:
+							position, tokenIndex = position71, tokenIndex71
 							if buffer[position] != rune('B') {
 								goto l0
 							}
 							position++
 						}
-					l131:
+					l71:
 						{
-							position133, tokenIndex133 := position, tokenIndex
+							position73, tokenIndex73 := position, tokenIndex
 							if buffer[position] != rune('e') {
-								goto l134
+								goto l74
 							}
 							position++
-							goto l133
-						l134:
-							position, tokenIndex = position133, tokenIndex133
+							goto l73
+						l74:
+							position, tokenIndex = position73, tokenIndex73
 							if buffer[position] != rune('E') {
 								goto l0
 							}
 							position++
 						}
-					l133:
+					l73:
 						if !_rules[ruleKEY]() {
 							goto l0
 						}
 						{
-							position135, tokenIndex135 := position, tokenIndex
+							position75, tokenIndex75 := position, tokenIndex
 							{
-								position137 := position
+								position77 := position
 								if !_rules[rule_]() {
-									goto l136
+									goto l76
 								}
 								{
-									position138, tokenIndex138 := position, tokenIndex
+									position78, tokenIndex78 := position, tokenIndex
 									if buffer[position] != rune('a') {
-										goto l139
+										goto l79
 									}
 									position++
-									goto l138
-								l139:
-									position, tokenIndex = position138, tokenIndex138
+									goto l78
+								l79:
+									position, tokenIndex = position78, tokenIndex78
 									if buffer[position] != rune('A') {
-										goto l136
+										goto l76
 									}
 									position++
 								}
-							l138:
+							l78:
 								{
-									position140, tokenIndex140 := position, tokenIndex
+									position80, tokenIndex80 := position, tokenIndex
 									if buffer[position] != rune('l') {
-										goto l141
+										goto l81
 									}
 									position++
-									goto l140
-								l141:
-									position, tokenIndex = position140, tokenIndex140
+									goto l80
+								l81:
+									position, tokenIndex = position80, tokenIndex80
 									if buffer[position] != rune('L') {
-										goto l136
+										goto l76
 									}
 									position++
 								}
-							l140:
+							l80:
 								{
-									position142, tokenIndex142 := position, tokenIndex
+									position82, tokenIndex82 := position, tokenIndex
 									if buffer[position] != rune('l') {
-										goto l143
+										goto l83
 									}
 									position++
-									goto l142
-								l143:
-									position, tokenIndex = position142, tokenIndex142
+									goto l82
+								l83:
+									position, tokenIndex = position82, tokenIndex82
 									if buffer[position] != rune('L') {
-										goto l136
+										goto l76
 									}
 									position++
 								}
-							l142:
+							l82:
 								if !_rules[ruleKEY]() {
-									goto l136
+									goto l76
 								}
 								{
-									position144 := position
+									position84 := position
 									{
-										position145, tokenIndex145 := position, tokenIndex
+										position85, tokenIndex85 := position, tokenIndex
 										{
-											position147 := position
+											position87 := position
 											if !_rules[rule_]() {
-												goto l146
+												goto l86
 											}
 											{
-												position148, tokenIndex148 := position, tokenIndex
+												position88, tokenIndex88 := position, tokenIndex
 												if buffer[position] != rune('m') {
-													goto l149
+													goto l89
 												}
 												position++
-												goto l148
-											l149:
-												position, tokenIndex = position148, tokenIndex148
+												goto l88
+											l89:
+												position, tokenIndex = position88, tokenIndex88
 												if buffer[position] != rune('M') {
-													goto l146
+													goto l86
 												}
 												position++
 											}
-										l148:
+										l88:
 											{
-												position150, tokenIndex150 := position, tokenIndex
+												position90, tokenIndex90 := position, tokenIndex
 												if buffer[position] != rune('a') {
-													goto l151
+													goto l91
 												}
 												position++
-												goto l150
-											l151:
-												position, tokenIndex = position150, tokenIndex150
+												goto l90
+											l91:
+												position, tokenIndex = position90, tokenIndex90
 												if buffer[position] != rune('A') {
-													goto l146
+													goto l86
 												}
 												position++
 											}
-										l150:
+										l90:
 											{
-												position152, tokenIndex152 := position, tokenIndex
+												position92, tokenIndex92 := position, tokenIndex
 												if buffer[position] != rune('t') {
-													goto l153
+													goto l93
 												}
 												position++
-												goto l152
-											l153:
-												position, tokenIndex = position152, tokenIndex152
+												goto l92
+											l93:
+												position, tokenIndex = position92, tokenIndex92
 												if buffer[position] != rune('T') {
-													goto l146
+													goto l86
 												}
 												position++
 											}
-										l152:
+										l92:
 											{
-												position154, tokenIndex154 := position, tokenIndex
+												position94, tokenIndex94 := position, tokenIndex
 												if buffer[position] != rune('c') {
-													goto l155
+													goto l95
 												}
 												position++
-												goto l154
-											l155:
-												position, tokenIndex = position154, tokenIndex154
+												goto l94
+											l95:
+												position, tokenIndex = position94, tokenIndex94
 												if buffer[position] != rune('C') {
-													goto l146
+													goto l86
 												}
 												position++
 											}
-										l154:
+										l94:
 											{
-												position156, tokenIndex156 := position, tokenIndex
+												position96, tokenIndex96 := position, tokenIndex
 												if buffer[position] != rune('h') {
-													goto l157
+													goto l97
 												}
 												position++
-												goto l156
-											l157:
-												position, tokenIndex = position156, tokenIndex156
+												goto l96
+											l97:
+												position, tokenIndex = position96, tokenIndex96
 												if buffer[position] != rune('H') {
-													goto l146
+													goto l86
 												}
 												position++
 											}
-										l156:
+										l96:
 											if !_rules[ruleKEY]() {
-												goto l146
+												goto l86
 											}
 											{
-												position158, tokenIndex158 := position, tokenIndex
+												position98, tokenIndex98 := position, tokenIndex
 												if !_rules[ruleliteralString]() {
-													goto l159
+													goto l99
 												}
-												goto l158
-											l159:
-												position, tokenIndex = position158, tokenIndex158
+												goto l98
+											l99:
+												position, tokenIndex = position98, tokenIndex98
 												if !(p.errorHere(position, `expected string literal to follow keyword "match"`)) {
-													goto l146
+													goto l86
 												}
 											}
-										l158:
+										l98:
 											{
-												add(ruleAction3, position)
+												add(ruleAction8, position)
 											}
-											add(rulematchClause, position147)
+											add(rulematchClause, position87)
 										}
-										goto l145
-									l146:
-										position, tokenIndex = position145, tokenIndex145
+										goto l85
+									l86:
+										position, tokenIndex = position85, tokenIndex85
 										{
-											add(ruleAction2, position)
+											add(ruleAction7, position)
 										}
 									}
-								l145:
-									add(ruleoptionalMatchClause, position144)
+								l85:
+									add(ruleoptionalMatchClause, position84)
 								}
 								{
-									add(ruleAction1, position)
+									add(ruleAction6, position)
 								}
 								{
-									position163, tokenIndex163 := position, tokenIndex
+									position103, tokenIndex103 := position, tokenIndex
 									{
-										position164, tokenIndex164 := position, tokenIndex
+										position104, tokenIndex104 := position, tokenIndex
 										if !_rules[rule_]() {
-											goto l165
+											goto l105
 										}
 										{
-											position166, tokenIndex166 := position, tokenIndex
+											position106, tokenIndex106 := position, tokenIndex
 											if !matchDot() {
-												goto l166
+												goto l106
 											}
-											goto l165
-										l166:
-											position, tokenIndex = position166, tokenIndex166
+											goto l105
+										l106:
+											position, tokenIndex = position106, tokenIndex106
 										}
-										goto l164
-									l165:
-										position, tokenIndex = position164, tokenIndex164
+										goto l104
+									l105:
+										position, tokenIndex = position104, tokenIndex104
 										if !_rules[rule_]() {
-											goto l136
+											goto l76
 										}
 										if !(p.errorHere(position, `expected end of input after 'describe all' and optional match clause but got %q`, p.after(position))) {
-											goto l136
+											goto l76
 										}
 									}
-								l164:
-									position, tokenIndex = position163, tokenIndex163
+								l104:
+									position, tokenIndex = position103, tokenIndex103
 								}
-								add(ruledescribeAllStmt, position137)
+								add(ruledescribeAllStmt, position77)
 							}
-							goto l135
-						l136:
-							position, tokenIndex = position135, tokenIndex135
+							goto l75
+						l76:
+							position, tokenIndex = position75, tokenIndex75
 							{
-								position168 := position
+								position108 := position
 								if !_rules[rule_]() {
-									goto l167
+									goto l107
 								}
 								{
-									position169, tokenIndex169 := position, tokenIndex
-									if buffer[position] != rune('m') {
-										goto l170
+									position109, tokenIndex109 := position, tokenIndex
+									if buffer[position] != rune('k') {
+										goto l110
 									}
 									position++
-									goto l169
-								l170:
-									position, tokenIndex = position169, tokenIndex169
-									if buffer[position] != rune('M') {
-										goto l167
+									goto l109
+								l110:
+									position, tokenIndex = position109, tokenIndex109
+									if buffer[position] != rune('K') {
+										goto l107
 									}
 									position++
 								}
-							l169:
+							l109:
 								{
-									position171, tokenIndex171 := position, tokenIndex
+									position111, tokenIndex111 := position, tokenIndex
 									if buffer[position] != rune('e') {
-										goto l172
+										goto l112
 									}
 									position++
-									goto l171
-								l172:
-									position, tokenIndex = position171, tokenIndex171
+									goto l111
+								l112:
+									position, tokenIndex = position111, tokenIndex111
 									if buffer[position] != rune('E') {
-										goto l167
+										goto l107
 									}
 									position++
 								}
-							l171:
+							l111:
 								{
-									position173, tokenIndex173 := position, tokenIndex
-									if buffer[position] != rune('t') {
-										goto l174
+									position113, tokenIndex113 := position, tokenIndex
+									if buffer[position] != rune('y') {
+										goto l114
 									}
 									position++
-									goto l173
-								l174:
-									position, tokenIndex = position173, tokenIndex173
-									if buffer[position] != rune('T') {
-										goto l167
+									goto l113
+								l114:
+									position, tokenIndex = position113, tokenIndex113
+									if buffer[position] != rune('Y') {
+										goto l107
 									}
 									position++
 								}
-							l173:
+							l113:
 								{
-									position175, tokenIndex175 := position, tokenIndex
-									if buffer[position] != rune('r') {
-										goto l176
+									position115, tokenIndex115 := position, tokenIndex
+									if buffer[position] != rune('s') {
+										goto l116
 									}
 									position++
-									goto l175
-								l176:
-									position, tokenIndex = position175, tokenIndex175
-									if buffer[position] != rune('R') {
-										goto l167
+									goto l115
+								l116:
+									position, tokenIndex = position115, tokenIndex115
+									if buffer[position] != rune('S') {
+										goto l107
 									}
 									position++
 								}
-							l175:
+							l115:
+								if !_rules[ruleKEY]() {
+									goto l107
+								}
 								{
-									position177, tokenIndex177 := position, tokenIndex
-									if buffer[position] != rune('i') {
-										goto l178
+									position117, tokenIndex117 := position, tokenIndex
+									if !_rules[rule_]() {
+										goto l118
+									}
+									{
+										position119 := position
+										if !_rules[ruleMETRIC_NAME]() {
+											goto l118
+										}
+										add(rulePegText, position119)
+									}
+									{
+										add(ruleAction9, position)
+									}
+									goto l117
+								l118:
+									position, tokenIndex = position117, tokenIndex117
+									if !(p.errorHere(position, `expected metric name to follow "keys" in "describe keys" command`)) {
+										goto l107
+									}
+								}
+							l117:
+								{
+									add(ruleAction10, position)
+								}
+								{
+									position122, tokenIndex122 := position, tokenIndex
+									{
+										position123, tokenIndex123 := position, tokenIndex
+										if !_rules[rule_]() {
+											goto l124
+										}
+										{
+											position125, tokenIndex125 := position, tokenIndex
+											if !matchDot() {
+												goto l125
+											}
+											goto l124
+										l125:
+											position, tokenIndex = position125, tokenIndex125
+										}
+										goto l123
+									l124:
+										position, tokenIndex = position123, tokenIndex123
+										if !_rules[rule_]() {
+											goto l107
+										}
+										if !(p.errorHere(position, `expected end of input after 'describe keys' and metric name but got %q`, p.after(position))) {
+											goto l107
+										}
+									}
+								l123:
+									position, tokenIndex = position122, tokenIndex122
+								}
+								add(ruledescribeKeys, position108)
+							}
+							goto l75
+						l107:
+							position, tokenIndex = position75, tokenIndex75
+							{
+								position127 := position
+								if !_rules[rule_]() {
+									goto l126
+								}
+								{
+									position128, tokenIndex128 := position, tokenIndex
+									if buffer[position] != rune('v') {
+										goto l129
 									}
 									position++
-									goto l177
-								l178:
-									position, tokenIndex = position177, tokenIndex177
-									if buffer[position] != rune('I') {
-										goto l167
+									goto l128
+								l129:
+									position, tokenIndex = position128, tokenIndex128
+									if buffer[position] != rune('V') {
+										goto l126
 									}
 									position++
 								}
-							l177:
+							l128:
 								{
-									position179, tokenIndex179 := position, tokenIndex
-									if buffer[position] != rune('c') {
-										goto l180
+									position130, tokenIndex130 := position, tokenIndex
+									if buffer[position] != rune('a') {
+										goto l131
 									}
 									position++
-									goto l179
-								l180:
-									position, tokenIndex = position179, tokenIndex179
-									if buffer[position] != rune('C') {
-										goto l167
+									goto l130
+								l131:
+									position, tokenIndex = position130, tokenIndex130
+									if buffer[position] != rune('A') {
+										goto l126
+									}
+									position++
+								}
+							l130:
+								{
+									position132, tokenIndex132 := position, tokenIndex
+									if buffer[position] != rune('l') {
+										goto l133
+									}
+									position++
+									goto l132
+								l133:
+									position, tokenIndex = position132, tokenIndex132
+									if buffer[position] != rune('L') {
+										goto l126
+									}
+									position++
+								}
+							l132:
+								{
+									position134, tokenIndex134 := position, tokenIndex
+									if buffer[position] != rune('u') {
+										goto l135
+									}
+									position++
+									goto l134
+								l135:
+									position, tokenIndex = position134, tokenIndex134
+									if buffer[position] != rune('U') {
+										goto l126
+									}
+									position++
+								}
+							l134:
+								{
+									position136, tokenIndex136 := position, tokenIndex
+									if buffer[position] != rune('e') {
+										goto l137
+									}
+									position++
+									goto l136
+								l137:
+									position, tokenIndex = position136, tokenIndex136
+									if buffer[position] != rune('E') {
+										goto l126
 									}
 									position++
 								}
-							l179:
+							l136:
 								{
-									position181, tokenIndex181 := position, tokenIndex
+									position138, tokenIndex138 := position, tokenIndex
 									if buffer[position] != rune('s') {
-										goto l182
+										goto l139
 									}
 									position++
-									goto l181
-								l182:
-									position, tokenIndex = position181, tokenIndex181
+									goto l138
+								l139:
+									position, tokenIndex = position138, tokenIndex138
 									if buffer[position] != rune('S') {
-										goto l167
+										goto l126
 									}
 									position++
 								}
-							l181:
+							l138:
 								if !_rules[ruleKEY]() {
-									goto l167
+									goto l126
+								}
+								{
+									position140, tokenIndex140 := position, tokenIndex
+									if !_rules[ruletagName]() {
+										goto l141
+									}
+									goto l140
+								l141:
+									position, tokenIndex = position140, tokenIndex140
+									if !(p.errorHere(position, `expected tag key to follow keyword "values" in "describe values" command`)) {
+										goto l126
+									}
 								}
+							l140:
 								{
-									position183, tokenIndex183 := position, tokenIndex
+									position142, tokenIndex142 := position, tokenIndex
 									if !_rules[rule_]() {
-										goto l184
+										goto l143
 									}
 									{
-										position185, tokenIndex185 := position, tokenIndex
+										position144, tokenIndex144 := position, tokenIndex
 										if buffer[position] != rune('w') {
-											goto l186
+											goto l145
 										}
 										position++
-										goto l185
-									l186:
-										position, tokenIndex = position185, tokenIndex185
+										goto l144
+									l145:
+										position, tokenIndex = position144, tokenIndex144
 										if buffer[position] != rune('W') {
-											goto l184
+											goto l143
 										}
 										position++
 									}
-								l185:
+								l144:
 									{
-										position187, tokenIndex187 := position, tokenIndex
+										position146, tokenIndex146 := position, tokenIndex
 										if buffer[position] != rune('h') {
-											goto l188
+											goto l147
 										}
 										position++
-										goto l187
-									l188:
-										position, tokenIndex = position187, tokenIndex187
+										goto l146
+									l147:
+										position, tokenIndex = position146, tokenIndex146
 										if buffer[position] != rune('H') {
-											goto l184
+											goto l143
 										}
 										position++
 									}
-								l187:
+								l146:
 									{
-										position189, tokenIndex189 := position, tokenIndex
+										position148, tokenIndex148 := position, tokenIndex
 										if buffer[position] != rune('e') {
-											goto l190
+											goto l149
 										}
 										position++
-										goto l189
-									l190:
-										position, tokenIndex = position189, tokenIndex189
+										goto l148
+									l149:
+										position, tokenIndex = position148, tokenIndex148
 										if buffer[position] != rune('E') {
-											goto l184
+											goto l143
 										}
 										position++
 									}
-								l189:
+								l148:
 									{
-										position191, tokenIndex191 := position, tokenIndex
+										position150, tokenIndex150 := position, tokenIndex
 										if buffer[position] != rune('r') {
-											goto l192
+											goto l151
 										}
 										position++
-										goto l191
-									l192:
-										position, tokenIndex = position191, tokenIndex191
+										goto l150
+									l151:
+										position, tokenIndex = position150, tokenIndex150
 										if buffer[position] != rune('R') {
-											goto l184
+											goto l143
 										}
 										position++
 									}
-								l191:
+								l150:
 									{
-										position193, tokenIndex193 := position, tokenIndex
+										position152, tokenIndex152 := position, tokenIndex
 										if buffer[position] != rune('e') {
-											goto l194
+											goto l153
 										}
 										position++
-										goto l193
-									l194:
-										position, tokenIndex = position193, tokenIndex193
+										goto l152
+									l153:
+										position, tokenIndex = position152, tokenIndex152
 										if buffer[position] != rune('E') {
-											goto l184
+											goto l143
 										}
 										position++
 									}
-								l193:
+								l152:
 									if !_rules[ruleKEY]() {
-										goto l184
-									}
-									goto l183
-								l184:
-									position, tokenIndex = position183, tokenIndex183
-									if !(p.errorHere(position, `expected "where" to follow keyword "metrics" in "describe metrics" command`)) {
-										goto l167
-									}
-								}
-							l183:
-								{
-									position195, tokenIndex195 := position, tokenIndex
-									if !_rules[ruletagName]() {
-										goto l196
+										goto l143
 									}
-									goto l195
-								l196:
-									position, tokenIndex = position195, tokenIndex195
-									if !(p.errorHere(position, `expected tag key to follow keyword "where" in "describe metrics" command`)) {
-										goto l167
+									goto l142
+								l143:
+									position, tokenIndex = position142, tokenIndex142
+									if !(p.errorHere(position, `expected "where" to follow tag key in "describe values" command`)) {
+										goto l126
 									}
 								}
-							l195:
+							l142:
 								{
-									position197, tokenIndex197 := position, tokenIndex
+									position154, tokenIndex154 := position, tokenIndex
 									if !_rules[rule_]() {
-										goto l198
+										goto l155
 									}
-									if buffer[position] != rune('=') {
-										goto l198
+									{
+										position156 := position
+										{
+											position157, tokenIndex157 := position, tokenIndex
+											{
+												position159, tokenIndex159 := position, tokenIndex
+												if buffer[position] != rune('a') {
+													goto l160
+												}
+												position++
+												goto l159
+											l160:
+												position, tokenIndex = position159, tokenIndex159
+												if buffer[position] != rune('A') {
+													goto l158
+												}
+												position++
+											}
+										l159:
+											{
+												position161, tokenIndex161 := position, tokenIndex
+												if buffer[position] != rune('l') {
+													goto l162
+												}
+												position++
+												goto l161
+											l162:
+												position, tokenIndex = position161, tokenIndex161
+												if buffer[position] != rune('L') {
+													goto l158
+												}
+												position++
+											}
+										l161:
+											{
+												position163, tokenIndex163 := position, tokenIndex
+												if buffer[position] != rune('l') {
+													goto l164
+												}
+												position++
+												goto l163
+											l164:
+												position, tokenIndex = position163, tokenIndex163
+												if buffer[position] != rune('L') {
+													goto l158
+												}
+												position++
+											}
+										l163:
+											goto l157
+										l158:
+											position, tokenIndex = position157, tokenIndex157
+											{
+												position165, tokenIndex165 := position, tokenIndex
+												if buffer[position] != rune('a') {
+													goto l166
+												}
+												position++
+												goto l165
+											l166:
+												position, tokenIndex = position165, tokenIndex165
+												if buffer[position] != rune('A') {
+													goto l155
+												}
+												position++
+											}
+										l165:
+											{
+												position167, tokenIndex167 := position, tokenIndex
+												if buffer[position] != rune('n') {
+													goto l168
+												}
+												position++
+												goto l167
+											l168:
+												position, tokenIndex = position167, tokenIndex167
+												if buffer[position] != rune('N') {
+													goto l155
+												}
+												position++
+											}
+										l167:
+											{
+												position169, tokenIndex169 := position, tokenIndex
+												if buffer[position] != rune('y') {
+													goto l170
+												}
+												position++
+												goto l169
+											l170:
+												position, tokenIndex = position169, tokenIndex169
+												if buffer[position] != rune('Y') {
+													goto l155
+												}
+												position++
+											}
+										l169:
+										}
+									l157:
+										add(rulePegText, position156)
 									}
-									position++
-									goto l197
-								l198:
-									position, tokenIndex = position197, tokenIndex197
-									if !(p.errorHere(position, `expected "=" to follow keyword "where" in "describe metrics" command`)) {
-										goto l167
+									if !_rules[ruleKEY]() {
+										goto l155
 									}
-								}
-							l197:
-								{
-									position199, tokenIndex199 := position, tokenIndex
-									if !_rules[ruleliteralString]() {
-										goto l200
+									{
+										add(ruleAction11, position)
 									}
-									goto l199
-								l200:
-									position, tokenIndex = position199, tokenIndex199
-									if !(p.errorHere(position, `expected string literal to follow "=" in "describe metrics" command`)) {
-										goto l167
+									goto l154
+								l155:
+									position, tokenIndex = position154, tokenIndex154
+									{
+										add(ruleAction12, position)
 									}
 								}
-							l199:
-								{
-									add(ruleAction4, position)
-								}
-								add(ruledescribeMetrics, position168)
-							}
-							goto l135
-						l167:
-							position, tokenIndex = position135, tokenIndex135
-							{
-								position202 := position
+							l154:
 								{
-									position203, tokenIndex203 := position, tokenIndex
+									position173, tokenIndex173 := position, tokenIndex
 									if !_rules[rule_]() {
-										goto l204
+										goto l174
 									}
 									{
-										position205 := position
-										{
-											position206 := position
-											if !_rules[ruleIDENTIFIER]() {
-												goto l204
-											}
-											add(ruleMETRIC_NAME, position206)
+										position175, tokenIndex175 := position, tokenIndex
+										if buffer[position] != rune('m') {
+											goto l176
+										}
+										position++
+										goto l175
+									l176:
+										position, tokenIndex = position175, tokenIndex175
+										if buffer[position] != rune('M') {
+											goto l174
 										}
-										add(rulePegText, position205)
+										position++
 									}
+								l175:
 									{
-										add(ruleAction5, position)
-									}
-									goto l203
-								l204:
-									position, tokenIndex = position203, tokenIndex203
-									if !(p.errorHere(position, `expected metric name to follow "describe" in "describe" command`)) {
-										goto l0
+										position177, tokenIndex177 := position, tokenIndex
+										if buffer[position] != rune('e') {
+											goto l178
+										}
+										position++
+										goto l177
+									l178:
+										position, tokenIndex = position177, tokenIndex177
+										if buffer[position] != rune('E') {
+											goto l174
+										}
+										position++
+									}
+								l177:
+									{
+										position179, tokenIndex179 := position, tokenIndex
+										if buffer[position] != rune('t') {
+											goto l180
+										}
+										position++
+										goto l179
+									l180:
+										position, tokenIndex = position179, tokenIndex179
+										if buffer[position] != rune('T') {
+											goto l174
+										}
+										position++
+									}
+								l179:
+									{
+										position181, tokenIndex181 := position, tokenIndex
+										if buffer[position] != rune('r') {
+											goto l182
+										}
+										position++
+										goto l181
+									l182:
+										position, tokenIndex = position181, tokenIndex181
+										if buffer[position] != rune('R') {
+											goto l174
+										}
+										position++
+									}
+								l181:
+									{
+										position183, tokenIndex183 := position, tokenIndex
+										if buffer[position] != rune('i') {
+											goto l184
+										}
+										position++
+										goto l183
+									l184:
+										position, tokenIndex = position183, tokenIndex183
+										if buffer[position] != rune('I') {
+											goto l174
+										}
+										position++
+									}
+								l183:
+									{
+										position185, tokenIndex185 := position, tokenIndex
+										if buffer[position] != rune('c') {
+											goto l186
+										}
+										position++
+										goto l185
+									l186:
+										position, tokenIndex = position185, tokenIndex185
+										if buffer[position] != rune('C') {
+											goto l174
+										}
+										position++
+									}
+								l185:
+									{
+										position187, tokenIndex187 := position, tokenIndex
+										if buffer[position] != rune('s') {
+											goto l188
+										}
+										position++
+										goto l187
+									l188:
+										position, tokenIndex = position187, tokenIndex187
+										if buffer[position] != rune('S') {
+											goto l174
+										}
+										position++
+									}
+								l187:
+									if !_rules[ruleKEY]() {
+										goto l174
+									}
+									goto l173
+								l174:
+									position, tokenIndex = position173, tokenIndex173
+									if !(p.errorHere(position, `expected keyword "metrics" to follow "where" in "describe values" command`)) {
+										goto l126
+									}
+								}
+							l173:
+								{
+									position189, tokenIndex189 := position, tokenIndex
+									if !_rules[rule_]() {
+										goto l190
+									}
+									{
+										position191, tokenIndex191 := position, tokenIndex
+										if buffer[position] != rune('i') {
+											goto l192
+										}
+										position++
+										goto l191
+									l192:
+										position, tokenIndex = position191, tokenIndex191
+										if buffer[position] != rune('I') {
+											goto l190
+										}
+										position++
+									}
+								l191:
+									{
+										position193, tokenIndex193 := position, tokenIndex
+										if buffer[position] != rune('n') {
+											goto l194
+										}
+										position++
+										goto l193
+									l194:
+										position, tokenIndex = position193, tokenIndex193
+										if buffer[position] != rune('N') {
+											goto l190
+										}
+										position++
+									}
+								l193:
+									if !_rules[ruleKEY]() {
+										goto l190
+									}
+									goto l189
+								l190:
+									position, tokenIndex = position189, tokenIndex189
+									if !(p.errorHere(position, `expected keyword "in" to follow "metrics" in "describe values" command`)) {
+										goto l126
+									}
+								}
+							l189:
+								{
+									position195, tokenIndex195 := position, tokenIndex
+									{
+										position197 := position
+										{
+											add(ruleAction14, position)
+										}
+										if !_rules[rule_]() {
+											goto l196
+										}
+										if !_rules[rulePAREN_OPEN]() {
+											goto l196
+										}
+										{
+											position199, tokenIndex199 := position, tokenIndex
+											if !_rules[rule_]() {
+												goto l200
+											}
+											{
+												position201 := position
+												if !_rules[ruleMETRIC_NAME]() {
+													goto l200
+												}
+												add(rulePegText, position201)
+											}
+											{
+												add(ruleAction15, position)
+											}
+											goto l199
+										l200:
+											position, tokenIndex = position199, tokenIndex199
+											if !(p.errorHere(position, `expected metric name to follow "(" in metric list`)) {
+												goto l196
+											}
+										}
+									l199:
+									l203:
+										{
+											position204, tokenIndex204 := position, tokenIndex
+											if !_rules[rule_]() {
+												goto l204
+											}
+											if !_rules[ruleCOMMA]() {
+												goto l204
+											}
+											{
+												position205, tokenIndex205 := position, tokenIndex
+												if !_rules[rule_]() {
+													goto l206
+												}
+												{
+													position207 := position
+													if !_rules[ruleMETRIC_NAME]() {
+														goto l206
+													}
+													add(rulePegText, position207)
+												}
+												{
+													add(ruleAction16, position)
+												}
+												goto l205
+											l206:
+												position, tokenIndex = position205, tokenIndex205
+												if !(p.errorHere(position, `expected metric name to follow "," in metric list`)) {
+													goto l204
+												}
+											}
+										l205:
+											goto l203
+										l204:
+											position, tokenIndex = position204, tokenIndex204
+										}
+										{
+											position209, tokenIndex209 := position, tokenIndex
+											if !_rules[rule_]() {
+												goto l210
+											}
+											if !_rules[rulePAREN_CLOSE]() {
+												goto l210
+											}
+											goto l209
+										l210:
+											position, tokenIndex = position209, tokenIndex209
+											if !(p.errorHere(position, `expected ")" to close "(" for metric list`)) {
+												goto l196
+											}
+										}
+									l209:
+										add(rulemetricNameList, position197)
+									}
+									goto l195
+								l196:
+									position, tokenIndex = position195, tokenIndex195
+									if !(p.errorHere(position, `expected list of metric names to follow "in" in "describe values" command`)) {
+										goto l126
+									}
+								}
+							l195:
+								{
+									add(ruleAction13, position)
+								}
+								{
+									position212, tokenIndex212 := position, tokenIndex
+									{
+										position213, tokenIndex213 := position, tokenIndex
+										if !_rules[rule_]() {
+											goto l214
+										}
+										{
+											position215, tokenIndex215 := position, tokenIndex
+											if !matchDot() {
+												goto l215
+											}
+											goto l214
+										l215:
+											position, tokenIndex = position215, tokenIndex215
+										}
+										goto l213
+									l214:
+										position, tokenIndex = position213, tokenIndex213
+										if !_rules[rule_]() {
+											goto l126
+										}
+										if !(p.errorHere(position, `expected end of input after the list of metrics in 'describe values' but got %q`, p.after(position))) {
+											goto l126
+										}
+									}
+								l213:
+									position, tokenIndex = position212, tokenIndex212
+								}
+								add(ruledescribeValues, position127)
+							}
+							goto l75
+						l126:
+							position, tokenIndex = position75, tokenIndex75
+							{
+								position217 := position
+								if !_rules[rule_]() {
+									goto l216
+								}
+								{
+									position218, tokenIndex218 := position, tokenIndex
+									if buffer[position] != rune('m') {
+										goto l219
+									}
+									position++
+									goto l218
+								l219:
+									position, tokenIndex = position218, tokenIndex218
+									if buffer[position] != rune('M') {
+										goto l216
+									}
+									position++
+								}
+							l218:
+								{
+									position220, tokenIndex220 := position, tokenIndex
+									if buffer[position] != rune('e') {
+										goto l221
+									}
+									position++
+									goto l220
+								l221:
+									position, tokenIndex = position220, tokenIndex220
+									if buffer[position] != rune('E') {
+										goto l216
+									}
+									position++
+								}
+							l220:
+								{
+									position222, tokenIndex222 := position, tokenIndex
+									if buffer[position] != rune('t') {
+										goto l223
+									}
+									position++
+									goto l222
+								l223:
+									position, tokenIndex = position222, tokenIndex222
+									if buffer[position] != rune('T') {
+										goto l216
+									}
+									position++
+								}
+							l222:
+								{
+									position224, tokenIndex224 := position, tokenIndex
+									if buffer[position] != rune('r') {
+										goto l225
+									}
+									position++
+									goto l224
+								l225:
+									position, tokenIndex = position224, tokenIndex224
+									if buffer[position] != rune('R') {
+										goto l216
+									}
+									position++
+								}
+							l224:
+								{
+									position226, tokenIndex226 := position, tokenIndex
+									if buffer[position] != rune('i') {
+										goto l227
+									}
+									position++
+									goto l226
+								l227:
+									position, tokenIndex = position226, tokenIndex226
+									if buffer[position] != rune('I') {
+										goto l216
+									}
+									position++
+								}
+							l226:
+								{
+									position228, tokenIndex228 := position, tokenIndex
+									if buffer[position] != rune('c') {
+										goto l229
+									}
+									position++
+									goto l228
+								l229:
+									position, tokenIndex = position228, tokenIndex228
+									if buffer[position] != rune('C') {
+										goto l216
+									}
+									position++
+								}
+							l228:
+								{
+									position230, tokenIndex230 := position, tokenIndex
+									if buffer[position] != rune('s') {
+										goto l231
+									}
+									position++
+									goto l230
+								l231:
+									position, tokenIndex = position230, tokenIndex230
+									if buffer[position] != rune('S') {
+										goto l216
+									}
+									position++
+								}
+							l230:
+								if !_rules[ruleKEY]() {
+									goto l216
+								}
+								{
+									position232, tokenIndex232 := position, tokenIndex
+									if !_rules[rule_]() {
+										goto l233
+									}
+									{
+										position234, tokenIndex234 := position, tokenIndex
+										if buffer[position] != rune('w') {
+											goto l235
+										}
+										position++
+										goto l234
+									l235:
+										position, tokenIndex = position234, tokenIndex234
+										if buffer[position] != rune('W') {
+											goto l233
+										}
+										position++
+									}
+								l234:
+									{
+										position236, tokenIndex236 := position, tokenIndex
+										if buffer[position] != rune('h') {
+											goto l237
+										}
+										position++
+										goto l236
+									l237:
+										position, tokenIndex = position236, tokenIndex236
+										if buffer[position] != rune('H') {
+											goto l233
+										}
+										position++
+									}
+								l236:
+									{
+										position238, tokenIndex238 := position, tokenIndex
+										if buffer[position] != rune('e') {
+											goto l239
+										}
+										position++
+										goto l238
+									l239:
+										position, tokenIndex = position238, tokenIndex238
+										if buffer[position] != rune('E') {
+											goto l233
+										}
+										position++
+									}
+								l238:
+									{
+										position240, tokenIndex240 := position, tokenIndex
+										if buffer[position] != rune('r') {
+											goto l241
+										}
+										position++
+										goto l240
+									l241:
+										position, tokenIndex = position240, tokenIndex240
+										if buffer[position] != rune('R') {
+											goto l233
+										}
+										position++
+									}
+								l240:
+									{
+										position242, tokenIndex242 := position, tokenIndex
+										if buffer[position] != rune('e') {
+											goto l243
+										}
+										position++
+										goto l242
+									l243:
+										position, tokenIndex = position242, tokenIndex242
+										if buffer[position] != rune('E') {
+											goto l233
+										}
+										position++
+									}
+								l242:
+									if !_rules[ruleKEY]() {
+										goto l233
+									}
+									goto l232
+								l233:
+									position, tokenIndex = position232, tokenIndex232
+									if !(p.errorHere(position, `expected "where" to follow keyword "metrics" in "describe metrics" command`)) {
+										goto l216
+									}
+								}
+							l232:
+								{
+									position244, tokenIndex244 := position, tokenIndex
+									if !_rules[ruletagName]() {
+										goto l245
+									}
+									goto l244
+								l245:
+									position, tokenIndex = position244, tokenIndex244
+									if !(p.errorHere(position, `expected tag key to follow keyword "where" in "describe metrics" command`)) {
+										goto l216
+									}
+								}
+							l244:
+								{
+									position246, tokenIndex246 := position, tokenIndex
+									if !_rules[rule_]() {
+										goto l247
+									}
+									if buffer[position] != rune('=') {
+										goto l247
+									}
+									position++
+									goto l246
+								l247:
+									position, tokenIndex = position246, tokenIndex246
+									if !(p.errorHere(position, `expected "=" to follow keyword "where" in "describe metrics" command`)) {
+										goto l216
+									}
+								}
+							l246:
+								{
+									position248, tokenIndex248 := position, tokenIndex
+									if !_rules[ruleliteralString]() {
+										goto l249
+									}
+									goto l248
+								l249:
+									position, tokenIndex = position248, tokenIndex248
+									if !(p.errorHere(position, `expected string literal to follow "=" in "describe metrics" command`)) {
+										goto l216
+									}
+								}
+							l248:
+								{
+									add(ruleAction17, position)
+								}
+								add(ruledescribeMetrics, position217)
+							}
+							goto l75
+						l216:
+							position, tokenIndex = position75, tokenIndex75
+							{
+								position251 := position
+								{
+									position252, tokenIndex252 := position, tokenIndex
+									if !_rules[rule_]() {
+										goto l253
+									}
+									{
+										position254 := position
+										if !_rules[ruleMETRIC_NAME]() {
+											goto l253
+										}
+										add(rulePegText, position254)
+									}
+									{
+										add(ruleAction18, position)
+									}
+									goto l252
+								l253:
+									position, tokenIndex = position252, tokenIndex252
+									if !(p.errorHere(position, `expected metric name to follow "describe" in "describe" command`)) {
+										goto l0
+									}
+								}
+							l252:
+								if !_rules[ruleoptionalPredicateClause]() {
+									goto l0
+								}
+								{
+									add(ruleAction19, position)
+								}
+								add(ruledescribeSingleStmt, position251)
+							}
+						}
+					l75:
+						add(ruledescribeStmt, position58)
+					}
+				}
+			l2:
+				if !_rules[rule_]() {
+					goto l0
+				}
+				{
+					position257, tokenIndex257 := position, tokenIndex
+					if !matchDot() {
+						goto l257
+					}
+					goto l0
+				l257:
+					position, tokenIndex = position257, tokenIndex257
+				}
+				add(ruleroot, position1)
+			}
+			return true
+		l0:
+			position, tokenIndex = position0, tokenIndex0
+			return false
+		},
+		/* 1 selectStmt <- <(((withClause ((_ (('s' / 'S') ('e' / 'E') ('l' / 'L') ('e' / 'E') ('c' / 'C') ('t' / 'T')) KEY) / &{ p.errorHere(position, `expected keyword "select" to follow with clause`) })) / (_ (('s' / 'S') ('e' / 'E') ('l' / 'L') ('e' / 'E') ('c' / 'C') ('t' / 'T') KEY)?)) expressionList &{ p.setContext("after expression of select statement") } optionalPredicateClause &{ p.setContext("") } propertyClause Action0)> */
+		func() bool {
+			position258, tokenIndex258 := position, tokenIndex
+			{
+				position259 := position
+				{
+					position260, tokenIndex260 := position, tokenIndex
+					{
+						position262 := position
+						if !_rules[rule_]() {
+							goto l261
+						}
+						{
+							position263, tokenIndex263 := position, tokenIndex
+							if buffer[position] != rune('w') {
+								goto l264
+							}
+							position++
+							goto l263
+						l264:
+							position, tokenIndex = position263, tokenIndex263
+							if buffer[position] != rune('W') {
+								goto l261
+							}
+							position++
+						}
+					l263:
+						{
+							position265, tokenIndex265 := position, tokenIndex
+							if buffer[position] != rune('i') {
+								goto l266
+							}
+							position++
+							goto l265
+						l266:
+							position, tokenIndex = position265, tokenIndex265
+							if buffer[position] != rune('I') {
+								goto l261
+							}
+							position++
+						}
+					l265:
+						{
+							position267, tokenIndex267 := position, tokenIndex
+							if buffer[position] != rune('t') {
+								goto l268
+							}
+							position++
+							goto l267
+						l268:
+							position, tokenIndex = position267, tokenIndex267
+							if buffer[position] != rune('T') {
+								goto l261
+							}
+							position++
+						}
+					l267:
+						{
+							position269, tokenIndex269 := position, tokenIndex
+							if buffer[position] != rune('h') {
+								goto l270
+							}
+							position++
+							goto l269
+						l270:
+							position, tokenIndex = position269, tokenIndex269
+							if buffer[position] != rune('H') {
+								goto l261
+							}
+							position++
+						}
+					l269:
+						if !_rules[ruleKEY]() {
+							goto l261
+						}
+						{
+							position271, tokenIndex271 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l261
+							}
+							if !_rules[ruleIDENTIFIER]() {
+								goto l261
+							}
+							if !_rules[rule_]() {
+								goto l261
+							}
+							if buffer[position] != rune('=') {
+								goto l261
+							}
+							position++
+							{
+								position272, tokenIndex272 := position, tokenIndex
+								if buffer[position] != rune('=') {
+									goto l272
+								}
+								position++
+								goto l261
+							l272:
+								position, tokenIndex = position272, tokenIndex272
+							}
+							position, tokenIndex = position271, tokenIndex271
+						}
+						if !_rules[rulewithBinding]() {
+							goto l261
+						}
+					l273:
+						{
+							position274, tokenIndex274 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l274
+							}
+							if !_rules[ruleCOMMA]() {
+								goto l274
+							}
+							{
+								position275, tokenIndex275 := position, tokenIndex
+								if !_rules[rulewithBinding]() {
+									goto l276
+								}
+								goto l275
+							l276:
+								position, tokenIndex = position275, tokenIndex275
+								if !(p.errorHere(position, `expected binding (such as "name = expression") to follow "," in with clause`)) {
+									goto l274
+								}
+							}
+						l275:
+							goto l273
+						l274:
+							position, tokenIndex = position274, tokenIndex274
+						}
+						add(rulewithClause, position262)
+					}
+					{
+						position277, tokenIndex277 := position, tokenIndex
+						if !_rules[rule_]() {
+							goto l278
+						}
+						{
+							position279, tokenIndex279 := position, tokenIndex
+							if buffer[position] != rune('s') {
+								goto l280
+							}
+							position++
+							goto l279
+						l280:
+							position, tokenIndex = position279, tokenIndex279
+							if buffer[position] != rune('S') {
+								goto l278
+							}
+							position++
+						}
+					l279:
+						{
+							position281, tokenIndex281 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l282
+							}
+							position++
+							goto l281
+						l282:
+							position, tokenIndex = position281, tokenIndex281
+							if buffer[position] != rune('E') {
+								goto l278
+							}
+							position++
+						}
+					l281:
+						{
+							position283, tokenIndex283 := position, tokenIndex
+							if buffer[position] != rune('l') {
+								goto l284
+							}
+							position++
+							goto l283
+						l284:
+							position, tokenIndex = position283, tokenIndex283
+							if buffer[position] != rune('L') {
+								goto l278
+							}
+							position++
+						}
+					l283:
+						{
+							position285, tokenIndex285 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l286
+							}
+							position++
+							goto l285
+						l286:
+							position, tokenIndex = position285, tokenIndex285
+							if buffer[position] != rune('E') {
+								goto l278
+							}
+							position++
+						}
+					l285:
+						{
+							position287, tokenIndex287 := position, tokenIndex
+							if buffer[position] != rune('c') {
+								goto l288
+							}
+							position++
+							goto l287
+						l288:
+							position, tokenIndex = position287, tokenIndex287
+							if buffer[position] != rune('C') {
+								goto l278
+							}
+							position++
+						}
+					l287:
+						{
+							position289, tokenIndex289 := position, tokenIndex
+							if buffer[position] != rune('t') {
+								goto l290
+							}
+							position++
+							goto l289
+						l290:
+							position, tokenIndex = position289, tokenIndex289
+							if buffer[position] != rune('T') {
+								goto l278
+							}
+							position++
+						}
+					l289:
+						if !_rules[ruleKEY]() {
+							goto l278
+						}
+						goto l277
+					l278:
+						position, tokenIndex = position277, tokenIndex277
+						if !(p.errorHere(position, `expected keyword "select" to follow with clause`)) {
+							goto l261
+						}
+					}
+				l277:
+					goto l260
+				l261:
+					position, tokenIndex = position260, tokenIndex260
+					if !_rules[rule_]() {
+						goto l258
+					}
+					{
+						position291, tokenIndex291 := position, tokenIndex
+						{
+							position293, tokenIndex293 := position, tokenIndex
+							if buffer[position] != rune('s') {
+								goto l294
+							}
+							position++
+							goto l293
+						l294:
+							position, tokenIndex = position293, tokenIndex293
+							if buffer[position] != rune('S') {
+								goto l291
+							}
+							position++
+						}
+					l293:
+						{
+							position295, tokenIndex295 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l296
+							}
+							position++
+							goto l295
+						l296:
+							position, tokenIndex = position295, tokenIndex295
+							if buffer[position] != rune('E') {
+								goto l291
+							}
+							position++
+						}
+					l295:
+						{
+							position297, tokenIndex297 := position, tokenIndex
+							if buffer[position] != rune('l') {
+								goto l298
+							}
+							position++
+							goto l297
+						l298:
+							position, tokenIndex = position297, tokenIndex297
+							if buffer[position] != rune('L') {
+								goto l291
+							}
+							position++
+						}
+					l297:
+						{
+							position299, tokenIndex299 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l300
+							}
+							position++
+							goto l299
+						l300:
+							position, tokenIndex = position299, tokenIndex299
+							if buffer[position] != rune('E') {
+								goto l291
+							}
+							position++
+						}
+					l299:
+						{
+							position301, tokenIndex301 := position, tokenIndex
+							if buffer[position] != rune('c') {
+								goto l302
+							}
+							position++
+							goto l301
+						l302:
+							position, tokenIndex = position301, tokenIndex301
+							if buffer[position] != rune('C') {
+								goto l291
+							}
+							position++
+						}
+					l301:
+						{
+							position303, tokenIndex303 := position, tokenIndex
+							if buffer[position] != rune('t') {
+								goto l304
+							}
+							position++
+							goto l303
+						l304:
+							position, tokenIndex = position303, tokenIndex303
+							if buffer[position] != rune('T') {
+								goto l291
+							}
+							position++
+						}
+					l303:
+						if !_rules[ruleKEY]() {
+							goto l291
+						}
+						goto l292
+					l291:
+						position, tokenIndex = position291, tokenIndex291
+					}
+				l292:
+				}
+			l260:
+				if !_rules[ruleexpressionList]() {
+					goto l258
+				}
+				if !(p.setContext("after expression of select statement")) {
+					goto l258
+				}
+				if !_rules[ruleoptionalPredicateClause]() {
+					goto l258
+				}
+				if !(p.setContext("")) {
+					goto l258
+				}
+				if !_rules[rulepropertyClause]() {
+					goto l258
+				}
+				{
+					add(ruleAction0, position)
+				}
+				add(ruleselectStmt, position259)
+			}
+			return true
+		l258:
+			position, tokenIndex = position258, tokenIndex258
+			return false
+		},
+		/* 2 withClause <- <(_ (('w' / 'W') ('i' / 'I') ('t' / 'T') ('h' / 'H')) KEY &(_ IDENTIFIER _ '=' !'=') withBinding (_ COMMA (withBinding / &{ p.errorHere(position, `expected binding (such as "name = expression") to follow "," in with clause`) }))*)> */
+		nil,
+		/* 3 withBinding <- <(_ <IDENTIFIER> Action1 ((_ '=') / &{ p.errorHere(position, `expected "=" to follow name in with clause`) }) (expression_start / &{ p.errorHere(position, `expected expression to follow "=" in with clause`) }) Action2)> */
+		func() bool {
+			position307, tokenIndex307 := position, tokenIndex
+			{
+				position308 := position
+				if !_rules[rule_]() {
+					goto l307
+				}
+				{
+					position309 := position
+					if !_rules[ruleIDENTIFIER]() {
+						goto l307
+					}
+					add(rulePegText, position309)
+				}
+				{
+					add(ruleAction1, position)
+				}
+				{
+					position311, tokenIndex311 := position, tokenIndex
+					if !_rules[rule_]() {
+						goto l312
+					}
+					if buffer[position] != rune('=') {
+						goto l312
+					}
+					position++
+					goto l311
+				l312:
+					position, tokenIndex = position311, tokenIndex311
+					if !(p.errorHere(position, `expected "=" to follow name in with clause`)) {
+						goto l307
+					}
+				}
+			l311:
+				{
+					position313, tokenIndex313 := position, tokenIndex
+					if !_rules[ruleexpression_start]() {
+						goto l314
+					}
+					goto l313
+				l314:
+					position, tokenIndex = position313, tokenIndex313
+					if !(p.errorHere(position, `expected expression to follow "=" in with clause`)) {
+						goto l307
+					}
+				}
+			l313:
+				{
+					add(ruleAction2, position)
+				}
+				add(rulewithBinding, position308)
+			}
+			return true
+		l307:
+			position, tokenIndex = position307, tokenIndex307
+			return false
+		},
+		/* 4 forecastStmt <- <(_ (('f' / 'F') ('o' / 'O') ('r' / 'R') ('e' / 'E') ('c' / 'C') ('a' / 'A') ('s' / 'S') ('t' / 'T')) KEY !'.' expressionList ((_ (('r' / 'R') ('e' / 'E') ('a' / 'A') ('c' / 'C') ('h' / 'H')) KEY) / &{ p.errorHere(position, `expected keyword "reach" to follow expression of forecast statement`) }) ((_ <NUMBER> Action3) / &{ p.errorHere(position, `expected threshold to follow keyword "reach"`) }) &{ p.setContext("after threshold of forecast statement") } optionalPredicateClause &{ p.setContext("") } propertyClause Action4)> */
+		nil,
+		/* 5 explainStmt <- <(_ (('e' / 'E') ('x' / 'X') ('p' / 'P') ('l' / 'L') ('a' / 'A') ('i' / 'I') ('n' / 'N')) KEY !'.' selectStmt Action5)> */
+		nil,
+		/* 6 describeStmt <- <(_ (('d' / 'D') ('e' / 'E') ('s' / 'S') ('c' / 'C') ('r' / 'R') ('i' / 'I') ('b' / 'B') ('e' / 'E')) KEY (describeAllStmt / describeKeys / describeValues / describeMetrics / describeSingleStmt))> */
+		nil,
+		/* 7 describeAllStmt <- <(_ (('a' / 'A') ('l' / 'L') ('l' / 'L')) KEY optionalMatchClause Action6 &((_ !.) / (_ &{p.errorHere(position, `expected end of input after 'describe all' and optional match clause but got %q`, p.after(position) )})))> */
+		nil,
+		/* 8 optionalMatchClause <- <(matchClause / Action7)> */
+		nil,
+		/* 9 matchClause <- <(_ (('m' / 'M') ('a' / 'A') ('t' / 'T') ('c' / 'C') ('h' / 'H')) KEY (literalString / &{ p.errorHere(position, `expected string literal to follow keyword "match"`) }) Action8)> */
+		nil,
+		/* 10 describeKeys <- <(_ (('k' / 'K') ('e' / 'E') ('y' / 'Y') ('s' / 'S')) KEY ((_ <METRIC_NAME> Action9) / &{ p.errorHere(position, `expected metric name to follow "keys" in "describe keys" command`) }) Action10 &((_ !.) / (_ &{p.errorHere(position, `expected end of input after 'describe keys' and metric name but got %q`, p.after(position) )})))> */
+		nil,
+		/* 11 describeValues <- <(_ (('v' / 'V') ('a' / 'A') ('l' / 'L') ('u' / 'U') ('e' / 'E') ('s' / 'S')) KEY (tagName / &{ p.errorHere(position, `expected tag key to follow keyword "values" in "describe values" command`) }) ((_ (('w' / 'W') ('h' / 'H') ('e' / 'E') ('r' / 'R') ('e' / 'E')) KEY) / &{ p.errorHere(position, `expected "where" to follow tag key in "describe values" command`) }) ((_ <((('a' / 'A') ('l' / 'L') ('l' / 'L')) / (('a' / 'A') ('n' / 'N') ('y' / 'Y')))> KEY Action11) / Action12) ((_ (('m' / 'M') ('e' / 'E') ('t' / 'T') ('r' / 'R') ('i' / 'I') ('c' / 'C') ('s' / 'S')) KEY) / &{ p.errorHere(position, `expected keyword "metrics" to follow "where" in "describe values" command`) }) ((_ (('i' / 'I') ('n' / 'N')) KEY) / &{ p.errorHere(position, `expected keyword "in" to follow "metrics" in "describe values" command`) }) (metricNameList / &{ p.errorHere(position, `expected list of metric names to follow "in" in "describe values" command`) }) Action13 &((_ !.) / (_ &{p.errorHere(position, `expected end of input after the list of metrics in 'describe values' but got %q`, p.after(position) )})))> */
+		nil,
+		/* 12 metricNameList <- <(Action14 _ PAREN_OPEN ((_ <METRIC_NAME> Action15) / &{ p.errorHere(position, `expected metric name to follow "(" in metric list`) }) (_ COMMA ((_ <METRIC_NAME> Action16) / &{ p.errorHere(position, `expected metric name to follow "," in metric list`) }))* ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" for metric list`) }))> */
+		nil,
+		/* 13 describeMetrics <- <(_ (('m' / 'M') ('e' / 'E') ('t' / 'T') ('r' / 'R') ('i' / 'I') ('c' / 'C') ('s' / 'S')) KEY ((_ (('w' / 'W') ('h' / 'H') ('e' / 'E') ('r' / 'R') ('e' / 'E')) KEY) / &{ p.errorHere(position, `expected "where" to follow keyword "metrics" in "describe metrics" command`) }) (tagName / &{ p.errorHere(position, `expected tag key to follow keyword "where" in "describe metrics" command`) }) ((_ '=') / &{ p.errorHere(position, `expected "=" to follow keyword "where" in "describe metrics" command`) }) (literalString / &{ p.errorHere(position, `expected string literal to follow "=" in "describe metrics" command`) }) Action17)> */
+		nil,
+		/* 14 describeSingleStmt <- <(((_ <METRIC_NAME> Action18) / &{ p.errorHere(position, `expected metric name to follow "describe" in "describe" command`) }) optionalPredicateClause Action19)> */
+		nil,
+		/* 15 propertyClause <- <(Action20 ((_ (('s' / 'S') ('a' / 'A') ('m' / 'M') ('p' / 'P') ('l' / 'L') ('e' / 'E')) KEY &(_ ([0-9] / '.')) ((_ <NUMBER> Action21) / &{ p.errorHere(position, `expected percentage to follow keyword "sample"`) }) ((_ '%') / &{ p.errorHere(position, `expected "%%" to follow the percentage in "sample" clause`) })) / (_ PROPERTY_KEY Action22 ((_ PROPERTY_VALUE Action23) / &{ p.errorHere(position, `expected value to follow key '%s'`, p.contents(tree, tokenIndex-2)) }) Action24) / (_ (('o' / 'O') ('r' / 'R') ('d' / 'D') ('e' / 'E') ('r' / 'R')) KEY ((_ (('b' / 'B') ('y' / 'Y')) KEY) / &{ p.errorHere(position, `expected keyword "by" to follow keyword "order"`) }) ((_ <IDENTIFIER> Action25) / &{ p.errorHere(position, `expected summary (such as 'max' or 'mean') to follow "order by"`) }) (_ <((('a' / 'A') ('s' / 'S') ('c' / 'C')) / (('d' / 'D') ('e' / 'E') ('s' / 'S') ('c' / 'C')))> KEY Action26)?) / (_ (('l' / 'L') ('i' / 'I') ('m' / 'M') ('i' / 'I') ('t' / 'T')) KEY ((_ <NUMBER_NATURAL> KEY Action27) / &{ p.errorHere(position, `expected count to follow keyword "limit"`) })) / (_ (('f' / 'F') ('i' / 'I') ('l' / 'L') ('l' / 'L')) KEY ((_ <(NUMBER / IDENTIFIER)> KEY Action28) / &{ p.errorHere(position, `expected value, "forward" or "interpolate" to follow keyword "fill"`) })) / (_ (('w' / 'W') ('h' / 'H') ('e' / 'E') ('r' / 'R') ('e' / 'E')) KEY &{ p.errorHere(position, `encountered "where" after property clause; "where" blocks must go BEFORE 'from' and 'to' specifiers`) }) / (_ !!. &{ p.errorHere(position, `expected key (one of 'from', 'to', 'resolution', 'sample by', 'order by', 'limit', or 'fill') or end of input but got %q following a completed expression`, p.after(position)) }))* Action29)> */
+		func() bool {
+			{
+				position328 := position
+				{
+					add(ruleAction20, position)
+				}
+			l330:
+				{
+					position331, tokenIndex331 := position, tokenIndex
+					{
+						position332, tokenIndex332 := position, tokenIndex
+						if !_rules[rule_]() {
+							goto l333
+						}
+						{
+							position334, tokenIndex334 := position, tokenIndex
+							if buffer[position] != rune('s') {
+								goto l335
+							}
+							position++
+							goto l334
+						l335:
+							position, tokenIndex = position334, tokenIndex334
+							if buffer[position] != rune('S') {
+								goto l333
+							}
+							position++
+						}
+					l334:
+						{
+							position336, tokenIndex336 := position, tokenIndex
+							if buffer[position] != rune('a') {
+								goto l337
+							}
+							position++
+							goto l336
+						l337:
+							position, tokenIndex = position336, tokenIndex336
+							if buffer[position] != rune('A') {
+								goto l333
+							}
+							position++
+						}
+					l336:
+						{
+							position338, tokenIndex338 := position, tokenIndex
+							if buffer[position] != rune('m') {
+								goto l339
+							}
+							position++
+							goto l338
+						l339:
+							position, tokenIndex = position338, tokenIndex338
+							if buffer[position] != rune('M') {
+								goto l333
+							}
+							position++
+						}
+					l338:
+						{
+							position340, tokenIndex340 := position, tokenIndex
+							if buffer[position] != rune('p') {
+								goto l341
+							}
+							position++
+							goto l340
+						l341:
+							position, tokenIndex = position340, tokenIndex340
+							if buffer[position] != rune('P') {
+								goto l333
+							}
+							position++
+						}
+					l340:
+						{
+							position342, tokenIndex342 := position, tokenIndex
+							if buffer[position] != rune('l') {
+								goto l343
+							}
+							position++
+							goto l342
+						l343:
+							position, tokenIndex = position342, tokenIndex342
+							if buffer[position] != rune('L') {
+								goto l333
+							}
+							position++
+						}
+					l342:
+						{
+							position344, tokenIndex344 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l345
+							}
+							position++
+							goto l344
+						l345:
+							position, tokenIndex = position344, tokenIndex344
+							if buffer[position] != rune('E') {
+								goto l333
+							}
+							position++
+						}
+					l344:
+						if !_rules[ruleKEY]() {
+							goto l333
+						}
+						{
+							position346, tokenIndex346 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l333
+							}
+							{
+								position347, tokenIndex347 := position, tokenIndex
+								if c := buffer[position]; c < rune('0') || c > rune('9') {
+									goto l348
+								}
+								position++
+								goto l347
+							l348:
+								position, tokenIndex = position347, tokenIndex347
+								if buffer[position] != rune('.') {
+									goto l333
+								}
+								position++
+							}
+						l347:
+							position, tokenIndex = position346, tokenIndex346
+						}
+						{
+							position349, tokenIndex349 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l350
+							}
+							{
+								position351 := position
+								if !_rules[ruleNUMBER]() {
+									goto l350
+								}
+								add(rulePegText, position351)
+							}
+							{
+								add(ruleAction21, position)
+							}
+							goto l349
+						l350:
+							position, tokenIndex = position349, tokenIndex349
+							if !(p.errorHere(position, `expected percentage to follow keyword "sample"`)) {
+								goto l333
+							}
+						}
+					l349:
+						{
+							position353, tokenIndex353 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l354
+							}
+							if buffer[position] != rune('%') {
+								goto l354
+							}
+							position++
+							goto l353
+						l354:
+							position, tokenIndex = position353, tokenIndex353
+							if !(p.errorHere(position, `expected "%%" to follow the percentage in "sample" clause`)) {
+								goto l333
+							}
+						}
+					l353:
+						goto l332
+					l333:
+						position, tokenIndex = position332, tokenIndex332
+						if !_rules[rule_]() {
+							goto l355
+						}
+						{
+							position356 := position
+							{
+								switch buffer[position] {
+								case 'S', 's':
+									{
+										position358 := position
+										{
+											position359, tokenIndex359 := position, tokenIndex
+											if buffer[position] != rune('s') {
+												goto l360
+											}
+											position++
+											goto l359
+										l360:
+											position, tokenIndex = position359, tokenIndex359
+											if buffer[position] != rune('S') {
+												goto l355
+											}
+											position++
+										}
+									l359:
+										{
+											position361, tokenIndex361 := position, tokenIndex
+											if buffer[position] != rune('a') {
+												goto l362
+											}
+											position++
+											goto l361
+										l362:
+											position, tokenIndex = position361, tokenIndex361
+											if buffer[position] != rune('A') {
+												goto l355
+											}
+											position++
+										}
+									l361:
+										{
+											position363, tokenIndex363 := position, tokenIndex
+											if buffer[position] != rune('m') {
+												goto l364
+											}
+											position++
+											goto l363
+										l364:
+											position, tokenIndex = position363, tokenIndex363
+											if buffer[position] != rune('M') {
+												goto l355
+											}
+											position++
+										}
+									l363:
+										{
+											position365, tokenIndex365 := position, tokenIndex
+											if buffer[position] != rune('p') {
+												goto l366
+											}
+											position++
+											goto l365
+										l366:
+											position, tokenIndex = position365, tokenIndex365
+											if buffer[position] != rune('P') {
+												goto l355
+											}
+											position++
+										}
+									l365:
+										{
+											position367, tokenIndex367 := position, tokenIndex
+											if buffer[position] != rune('l') {
+												goto l368
+											}
+											position++
+											goto l367
+										l368:
+											position, tokenIndex = position367, tokenIndex367
+											if buffer[position] != rune('L') {
+												goto l355
+											}
+											position++
+										}
+									l367:
+										{
+											position369, tokenIndex369 := position, tokenIndex
+											if buffer[position] != rune('e') {
+												goto l370
+											}
+											position++
+											goto l369
+										l370:
+											position, tokenIndex = position369, tokenIndex369
+											if buffer[position] != rune('E') {
+												goto l355
+											}
+											position++
+										}
+									l369:
+										add(rulePegText, position358)
+									}
+									if !_rules[ruleKEY]() {
+										goto l355
+									}
+									{
+										position371, tokenIndex371 := position, tokenIndex
+										if !_rules[rule_]() {
+											goto l372
+										}
+										{
+											position373, tokenIndex373 := position, tokenIndex
+											if buffer[position] != rune('b') {
+												goto l374
+											}
+											position++
+											goto l373
+										l374:
+											position, tokenIndex = position373, tokenIndex373
+											if buffer[position] != rune('B') {
+												goto l372
+											}
+											position++
+										}
+									l373:
+										{
+											position375, tokenIndex375 := position, tokenIndex
+											if buffer[position] != rune('y') {
+												goto l376
+											}
+											position++
+											goto l375
+										l376:
+											position, tokenIndex = position375, tokenIndex375
+											if buffer[position] != rune('Y') {
+												goto l372
+											}
+											position++
+										}
+									l375:
+										if !_rules[ruleKEY]() {
+											goto l372
+										}
+										goto l371
+									l372:
+										position, tokenIndex = position371, tokenIndex371
+										if !(p.errorHere(position, `expected keyword "by" to follow keyword "sample"`)) {
+											goto l355
+										}
+									}
+								l371:
+									break
+								case 'R', 'r':
+									{
+										position377 := position
+										{
+											position378, tokenIndex378 := position, tokenIndex
+											if buffer[position] != rune('r') {
+												goto l379
+											}
+											position++
+											goto l378
+										l379:
+											position, tokenIndex = position378, tokenIndex378
+											if buffer[position] != rune('R') {
+												goto l355
+											}
+											position++
+										}
+									l378:
+										{
+											position380, tokenIndex380 := position, tokenIndex
+											if buffer[position] != rune('e') {
+												goto l381
+											}
+											position++
+											goto l380
+										l381:
+											position, tokenIndex = position380, tokenIndex380
+											if buffer[position] != rune('E') {
+												goto l355
+											}
+											position++
+										}
+									l380:
+										{
+											position382, tokenIndex382 := position, tokenIndex
+											if buffer[position] != rune('s') {
+												goto l383
+											}
+											position++
+											goto l382
+										l383:
+											position, tokenIndex = position382, tokenIndex382
+											if buffer[position] != rune('S') {
+												goto l355
+											}
+											position++
+										}
+									l382:
+										{
+											position384, tokenIndex384 := position, tokenIndex
+											if buffer[position] != rune('o') {
+												goto l385
+											}
+											position++
+											goto l384
+										l385:
+											position, tokenIndex = position384, tokenIndex384
+											if buffer[position] != rune('O') {
+												goto l355
+											}
+											position++
+										}
+									l384:
+										{
+											position386, tokenIndex386 := position, tokenIndex
+											if buffer[position] != rune('l') {
+												goto l387
+											}
+											position++
+											goto l386
+										l387:
+											position, tokenIndex = position386, tokenIndex386
+											if buffer[position] != rune('L') {
+												goto l355
+											}
+											position++
+										}
+									l386:
+										{
+											position388, tokenIndex388 := position, tokenIndex
+											if buffer[position] != rune('u') {
+												goto l389
+											}
+											position++
+											goto l388
+										l389:
+											position, tokenIndex = position388, tokenIndex388
+											if buffer[position] != rune('U') {
+												goto l355
+											}
+											position++
+										}
+									l388:
+										{
+											position390, tokenIndex390 := position, tokenIndex
+											if buffer[position] != rune('t') {
+												goto l391
+											}
+											position++
+											goto l390
+										l391:
+											position, tokenIndex = position390, tokenIndex390
+											if buffer[position] != rune('T') {
+												goto l355
+											}
+											position++
+										}
+									l390:
+										{
+											position392, tokenIndex392 := position, tokenIndex
+											if buffer[position] != rune('i') {
+												goto l393
+											}
+											position++
+											goto l392
+										l393:
+											position, tokenIndex = position392, tokenIndex392
+											if buffer[position] != rune('I') {
+												goto l355
+											}
+											position++
+										}
+									l392:
+										{
+											position394, tokenIndex394 := position, tokenIndex
+											if buffer[position] != rune('o') {
+												goto l395
+											}
+											position++
+											goto l394
+										l395:
+											position, tokenIndex = position394, tokenIndex394
+											if buffer[position] != rune('O') {
+												goto l355
+											}
+											position++
+										}
+									l394:
+										{
+											position396, tokenIndex396 := position, tokenIndex
+											if buffer[position] != rune('n') {
+												goto l397
+											}
+											position++
+											goto l396
+										l397:
+											position, tokenIndex = position396, tokenIndex396
+											if buffer[position] != rune('N') {
+												goto l355
+											}
+											position++
+										}
+									l396:
+										add(rulePegText, position377)
+									}
+									if !_rules[ruleKEY]() {
+										goto l355
+									}
+									break
+								case 'T', 't':
+									{
+										position398 := position
+										{
+											position399, tokenIndex399 := position, tokenIndex
+											if buffer[position] != rune('t') {
+												goto l400
+											}
+											position++
+											goto l399
+										l400:
+											position, tokenIndex = position399, tokenIndex399
+											if buffer[position] != rune('T') {
+												goto l355
+											}
+											position++
+										}
+									l399:
+										{
+											position401, tokenIndex401 := position, tokenIndex
+											if buffer[position] != rune('o') {
+												goto l402
+											}
+											position++
+											goto l401
+										l402:
+											position, tokenIndex = position401, tokenIndex401
+											if buffer[position] != rune('O') {
+												goto l355
+											}
+											position++
+										}
+									l401:
+										add(rulePegText, position398)
+									}
+									if !_rules[ruleKEY]() {
+										goto l355
+									}
+									break
+								default:
+									{
+										position403 := position
+										{
+											position404, tokenIndex404 := position, tokenIndex
+											if buffer[position] != rune('f') {
+												goto l405
+											}
+											position++
+											goto l404
+										l405:
+											position, tokenIndex = position404, tokenIndex404
+											if buffer[position] != rune('F') {
+												goto l355
+											}
+											position++
+										}
+									l404:
+										{
+											position406, tokenIndex406 := position, tokenIndex
+											if buffer[position] != rune('r') {
+												goto l407
+											}
+											position++
+											goto l406
+										l407:
+											position, tokenIndex = position406, tokenIndex406
+											if buffer[position] != rune('R') {
+												goto l355
+											}
+											position++
+										}
+									l406:
+										{
+											position408, tokenIndex408 := position, tokenIndex
+											if buffer[position] != rune('o') {
+												goto l409
+											}
+											position++
+											goto l408
+										l409:
+											position, tokenIndex = position408, tokenIndex408
+											if buffer[position] != rune('O') {
+												goto l355
+											}
+											position++
+										}
+									l408:
+										{
+											position410, tokenIndex410 := position, tokenIndex
+											if buffer[position] != rune('m') {
+												goto l411
+											}
+											position++
+											goto l410
+										l411:
+											position, tokenIndex = position410, tokenIndex410
+											if buffer[position] != rune('M') {
+												goto l355
+											}
+											position++
+										}
+									l410:
+										add(rulePegText, position403)
+									}
+									if !_rules[ruleKEY]() {
+										goto l355
+									}
+									break
+								}
+							}
+
+							add(rulePROPERTY_KEY, position356)
+						}
+						{
+							add(ruleAction22, position)
+						}
+						{
+							position413, tokenIndex413 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l414
+							}
+							{
+								position415 := position
+								{
+									position416 := position
+									{
+										position417, tokenIndex417 := position, tokenIndex
+										if !_rules[rule_]() {
+											goto l418
+										}
+										{
+											position419 := position
+											if !_rules[ruleNUMBER]() {
+												goto l418
+											}
+										l420:
+											{
+												position421, tokenIndex421 := position, tokenIndex
+												{
+													position422, tokenIndex422 := position, tokenIndex
+													if c := buffer[position]; c < rune('a') || c > rune('z') {
+														goto l423
+													}
+													position++
+													goto l422
+												l423:
+													position, tokenIndex = position422, tokenIndex422
+													if c := buffer[position]; c < rune('A') || c > rune('Z') {
+														goto l421
+													}
+													position++
+												}
+											l422:
+												goto l420
+											l421:
+												position, tokenIndex = position421, tokenIndex421
+											}
+											add(rulePegText, position419)
+										}
+										goto l417
+									l418:
+										position, tokenIndex = position417, tokenIndex417
+										if !_rules[rule_]() {
+											goto l424
+										}
+										if !_rules[ruleSTRING]() {
+											goto l424
+										}
+										goto l417
+									l424:
+										position, tokenIndex = position417, tokenIndex417
+										if !_rules[rule_]() {
+											goto l414
+										}
+										{
+											position425 := position
+											{
+												position426, tokenIndex426 := position, tokenIndex
+												if buffer[position] != rune('n') {
+													goto l427
+												}
+												position++
+												goto l426
+											l427:
+												position, tokenIndex = position426, tokenIndex426
+												if buffer[position] != rune('N') {
+													goto l414
+												}
+												position++
+											}
+										l426:
+											{
+												position428, tokenIndex428 := position, tokenIndex
+												if buffer[position] != rune('o') {
+													goto l429
+												}
+												position++
+												goto l428
+											l429:
+												position, tokenIndex = position428, tokenIndex428
+												if buffer[position] != rune('O') {
+													goto l414
+												}
+												position++
+											}
+										l428:
+											{
+												position430, tokenIndex430 := position, tokenIndex
+												if buffer[position] != rune('w') {
+													goto l431
+												}
+												position++
+												goto l430
+											l431:
+												position, tokenIndex = position430, tokenIndex430
+												if buffer[position] != rune('W') {
+													goto l414
+												}
+												position++
+											}
+										l430:
+											add(rulePegText, position425)
+										}
+										if !_rules[ruleKEY]() {
+											goto l414
+										}
+									}
+								l417:
+									add(ruleTIMESTAMP, position416)
+								}
+								add(rulePROPERTY_VALUE, position415)
+							}
+							{
+								add(ruleAction23, position)
+							}
+							goto l413
+						l414:
+							position, tokenIndex = position413, tokenIndex413
+							if !(p.errorHere(position, `expected value to follow key '%s'`, p.contents(tree, tokenIndex-2))) {
+								goto l355
+							}
+						}
+					l413:
+						{
+							add(ruleAction24, position)
+						}
+						goto l332
+					l355:
+						position, tokenIndex = position332, tokenIndex332
+						if !_rules[rule_]() {
+							goto l434
+						}
+						{
+							position435, tokenIndex435 := position, tokenIndex
+							if buffer[position] != rune('o') {
+								goto l436
+							}
+							position++
+							goto l435
+						l436:
+							position, tokenIndex = position435, tokenIndex435
+							if buffer[position] != rune('O') {
+								goto l434
+							}
+							position++
+						}
+					l435:
+						{
+							position437, tokenIndex437 := position, tokenIndex
+							if buffer[position] != rune('r') {
+								goto l438
+							}
+							position++
+							goto l437
+						l438:
+							position, tokenIndex = position437, tokenIndex437
+							if buffer[position] != rune('R') {
+								goto l434
+							}
+							position++
+						}
+					l437:
+						{
+							position439, tokenIndex439 := position, tokenIndex
+							if buffer[position] != rune('d') {
+								goto l440
+							}
+							position++
+							goto l439
+						l440:
+							position, tokenIndex = position439, tokenIndex439
+							if buffer[position] != rune('D') {
+								goto l434
+							}
+							position++
+						}
+					l439:
+						{
+							position441, tokenIndex441 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l442
+							}
+							position++
+							goto l441
+						l442:
+							position, tokenIndex = position441, tokenIndex441
+							if buffer[position] != rune('E') {
+								goto l434
+							}
+							position++
+						}
+					l441:
+						{
+							position443, tokenIndex443 := position, tokenIndex
+							if buffer[position] != rune('r') {
+								goto l444
+							}
+							position++
+							goto l443
+						l444:
+							position, tokenIndex = position443, tokenIndex443
+							if buffer[position] != rune('R') {
+								goto l434
+							}
+							position++
+						}
+					l443:
+						if !_rules[ruleKEY]() {
+							goto l434
+						}
+						{
+							position445, tokenIndex445 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l446
+							}
+							{
+								position447, tokenIndex447 := position, tokenIndex
+								if buffer[position] != rune('b') {
+									goto l448
+								}
+								position++
+								goto l447
+							l448:
+								position, tokenIndex = position447, tokenIndex447
+								if buffer[position] != rune('B') {
+									goto l446
+								}
+								position++
+							}
+						l447:
+							{
+								position449, tokenIndex449 := position, tokenIndex
+								if buffer[position] != rune('y') {
+									goto l450
+								}
+								position++
+								goto l449
+							l450:
+								position, tokenIndex = position449, tokenIndex449
+								if buffer[position] != rune('Y') {
+									goto l446
+								}
+								position++
+							}
+						l449:
+							if !_rules[ruleKEY]() {
+								goto l446
+							}
+							goto l445
+						l446:
+							position, tokenIndex = position445, tokenIndex445
+							if !(p.errorHere(position, `expected keyword "by" to follow keyword "order"`)) {
+								goto l434
+							}
+						}
+					l445:
+						{
+							position451, tokenIndex451 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l452
+							}
+							{
+								position453 := position
+								if !_rules[ruleIDENTIFIER]() {
+									goto l452
+								}
+								add(rulePegText, position453)
+							}
+							{
+								add(ruleAction25, position)
+							}
+							goto l451
+						l452:
+							position, tokenIndex = position451, tokenIndex451
+							if !(p.errorHere(position, `expected summary (such as 'max' or 'mean') to follow "order by"`)) {
+								goto l434
+							}
+						}
+					l451:
+						{
+							position455, tokenIndex455 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l455
+							}
+							{
+								position457 := position
+								{
+									position458, tokenIndex458 := position, tokenIndex
+									{
+										position460, tokenIndex460 := position, tokenIndex
+										if buffer[position] != rune('a') {
+											goto l461
+										}
+										position++
+										goto l460
+									l461:
+										position, tokenIndex = position460, tokenIndex460
+										if buffer[position] != rune('A') {
+											goto l459
+										}
+										position++
+									}
+								l460:
+									{
+										position462, tokenIndex462 := position, tokenIndex
+										if buffer[position] != rune('s') {
+											goto l463
+										}
+										position++
+										goto l462
+									l463:
+										position, tokenIndex = position462, tokenIndex462
+										if buffer[position] != rune('S') {
+											goto l459
+										}
+										position++
+									}
+								l462:
+									{
+										position464, tokenIndex464 := position, tokenIndex
+										if buffer[position] != rune('c') {
+											goto l465
+										}
+										position++
+										goto l464
+									l465:
+										position, tokenIndex = position464, tokenIndex464
+										if buffer[position] != rune('C') {
+											goto l459
+										}
+										position++
+									}
+								l464:
+									goto l458
+								l459:
+									position, tokenIndex = position458, tokenIndex458
+									{
+										position466, tokenIndex466 := position, tokenIndex
+										if buffer[position] != rune('d') {
+											goto l467
+										}
+										position++
+										goto l466
+									l467:
+										position, tokenIndex = position466, tokenIndex466
+										if buffer[position] != rune('D') {
+											goto l455
+										}
+										position++
+									}
+								l466:
+									{
+										position468, tokenIndex468 := position, tokenIndex
+										if buffer[position] != rune('e') {
+											goto l469
+										}
+										position++
+										goto l468
+									l469:
+										position, tokenIndex = position468, tokenIndex468
+										if buffer[position] != rune('E') {
+											goto l455
+										}
+										position++
+									}
+								l468:
+									{
+										position470, tokenIndex470 := position, tokenIndex
+										if buffer[position] != rune('s') {
+											goto l471
+										}
+										position++
+										goto l470
+									l471:
+										position, tokenIndex = position470, tokenIndex470
+										if buffer[position] != rune('S') {
+											goto l455
+										}
+										position++
+									}
+								l470:
+									{
+										position472, tokenIndex472 := position, tokenIndex
+										if buffer[position] != rune('c') {
+											goto l473
+										}
+										position++
+										goto l472
+									l473:
+										position, tokenIndex = position472, tokenIndex472
+										if buffer[position] != rune('C') {
+											goto l455
+										}
+										position++
+									}
+								l472:
+								}
+							l458:
+								add(rulePegText, position457)
+							}
+							if !_rules[ruleKEY]() {
+								goto l455
+							}
+							{
+								add(ruleAction26, position)
+							}
+							goto l456
+						l455:
+							position, tokenIndex = position455, tokenIndex455
+						}
+					l456:
+						goto l332
+					l434:
+						position, tokenIndex = position332, tokenIndex332
+						if !_rules[rule_]() {
+							goto l475
+						}
+						{
+							position476, tokenIndex476 := position, tokenIndex
+							if buffer[position] != rune('l') {
+								goto l477
+							}
+							position++
+							goto l476
+						l477:
+							position, tokenIndex = position476, tokenIndex476
+							if buffer[position] != rune('L') {
+								goto l475
+							}
+							position++
+						}
+					l476:
+						{
+							position478, tokenIndex478 := position, tokenIndex
+							if buffer[position] != rune('i') {
+								goto l479
+							}
+							position++
+							goto l478
+						l479:
+							position, tokenIndex = position478, tokenIndex478
+							if buffer[position] != rune('I') {
+								goto l475
+							}
+							position++
+						}
+					l478:
+						{
+							position480, tokenIndex480 := position, tokenIndex
+							if buffer[position] != rune('m') {
+								goto l481
+							}
+							position++
+							goto l480
+						l481:
+							position, tokenIndex = position480, tokenIndex480
+							if buffer[position] != rune('M') {
+								goto l475
+							}
+							position++
+						}
+					l480:
+						{
+							position482, tokenIndex482 := position, tokenIndex
+							if buffer[position] != rune('i') {
+								goto l483
+							}
+							position++
+							goto l482
+						l483:
+							position, tokenIndex = position482, tokenIndex482
+							if buffer[position] != rune('I') {
+								goto l475
+							}
+							position++
+						}
+					l482:
+						{
+							position484, tokenIndex484 := position, tokenIndex
+							if buffer[position] != rune('t') {
+								goto l485
+							}
+							position++
+							goto l484
+						l485:
+							position, tokenIndex = position484, tokenIndex484
+							if buffer[position] != rune('T') {
+								goto l475
+							}
+							position++
+						}
+					l484:
+						if !_rules[ruleKEY]() {
+							goto l475
+						}
+						{
+							position486, tokenIndex486 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l487
+							}
+							{
+								position488 := position
+								if !_rules[ruleNUMBER_NATURAL]() {
+									goto l487
+								}
+								add(rulePegText, position488)
+							}
+							if !_rules[ruleKEY]() {
+								goto l487
+							}
+							{
+								add(ruleAction27, position)
+							}
+							goto l486
+						l487:
+							position, tokenIndex = position486, tokenIndex486
+							if !(p.errorHere(position, `expected count to follow keyword "limit"`)) {
+								goto l475
+							}
+						}
+					l486:
+						goto l332
+					l475:
+						position, tokenIndex = position332, tokenIndex332
+						if !_rules[rule_]() {
+							goto l490
+						}
+						{
+							position491, tokenIndex491 := position, tokenIndex
+							if buffer[position] != rune('f') {
+								goto l492
+							}
+							position++
+							goto l491
+						l492:
+							position, tokenIndex = position491, tokenIndex491
+							if buffer[position] != rune('F') {
+								goto l490
+							}
+							position++
+						}
+					l491:
+						{
+							position493, tokenIndex493 := position, tokenIndex
+							if buffer[position] != rune('i') {
+								goto l494
+							}
+							position++
+							goto l493
+						l494:
+							position, tokenIndex = position493, tokenIndex493
+							if buffer[position] != rune('I') {
+								goto l490
+							}
+							position++
+						}
+					l493:
+						{
+							position495, tokenIndex495 := position, tokenIndex
+							if buffer[position] != rune('l') {
+								goto l496
+							}
+							position++
+							goto l495
+						l496:
+							position, tokenIndex = position495, tokenIndex495
+							if buffer[position] != rune('L') {
+								goto l490
+							}
+							position++
+						}
+					l495:
+						{
+							position497, tokenIndex497 := position, tokenIndex
+							if buffer[position] != rune('l') {
+								goto l498
+							}
+							position++
+							goto l497
+						l498:
+							position, tokenIndex = position497, tokenIndex497
+							if buffer[position] != rune('L') {
+								goto l490
+							}
+							position++
+						}
+					l497:
+						if !_rules[ruleKEY]() {
+							goto l490
+						}
+						{
+							position499, tokenIndex499 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l500
+							}
+							{
+								position501 := position
+								{
+									position502, tokenIndex502 := position, tokenIndex
+									if !_rules[ruleNUMBER]() {
+										goto l503
 									}
+									goto l502
+								l503:
+									position, tokenIndex = position502, tokenIndex502
+									if !_rules[ruleIDENTIFIER]() {
+										goto l500
+									}
+								}
+							l502:
+								add(rulePegText, position501)
+							}
+							if !_rules[ruleKEY]() {
+								goto l500
+							}
+							{
+								add(ruleAction28, position)
+							}
+							goto l499
+						l500:
+							position, tokenIndex = position499, tokenIndex499
+							if !(p.errorHere(position, `expected value, "forward" or "interpolate" to follow keyword "fill"`)) {
+								goto l490
+							}
+						}
+					l499:
+						goto l332
+					l490:
+						position, tokenIndex = position332, tokenIndex332
+						if !_rules[rule_]() {
+							goto l505
+						}
+						{
+							position506, tokenIndex506 := position, tokenIndex
+							if buffer[position] != rune('w') {
+								goto l507
+							}
+							position++
+							goto l506
+						l507:
+							position, tokenIndex = position506, tokenIndex506
+							if buffer[position] != rune('W') {
+								goto l505
+							}
+							position++
+						}
+					l506:
+						{
+							position508, tokenIndex508 := position, tokenIndex
+							if buffer[position] != rune('h') {
+								goto l509
+							}
+							position++
+							goto l508
+						l509:
+							position, tokenIndex = position508, tokenIndex508
+							if buffer[position] != rune('H') {
+								goto l505
+							}
+							position++
+						}
+					l508:
+						{
+							position510, tokenIndex510 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l511
+							}
+							position++
+							goto l510
+						l511:
+							position, tokenIndex = position510, tokenIndex510
+							if buffer[position] != rune('E') {
+								goto l505
+							}
+							position++
+						}
+					l510:
+						{
+							position512, tokenIndex512 := position, tokenIndex
+							if buffer[position] != rune('r') {
+								goto l513
+							}
+							position++
+							goto l512
+						l513:
+							position, tokenIndex = position512, tokenIndex512
+							if buffer[position] != rune('R') {
+								goto l505
+							}
+							position++
+						}
+					l512:
+						{
+							position514, tokenIndex514 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l515
+							}
+							position++
+							goto l514
+						l515:
+							position, tokenIndex = position514, tokenIndex514
+							if buffer[position] != rune('E') {
+								goto l505
+							}
+							position++
+						}
+					l514:
+						if !_rules[ruleKEY]() {
+							goto l505
+						}
+						if !(p.errorHere(position, `encountered "where" after property clause; "where" blocks must go BEFORE 'from' and 'to' specifiers`)) {
+							goto l505
+						}
+						goto l332
+					l505:
+						position, tokenIndex = position332, tokenIndex332
+						if !_rules[rule_]() {
+							goto l331
+						}
+						{
+							position516, tokenIndex516 := position, tokenIndex
+							{
+								position517, tokenIndex517 := position, tokenIndex
+								if !matchDot() {
+									goto l517
+								}
+								goto l516
+							l517:
+								position, tokenIndex = position517, tokenIndex517
+							}
+							goto l331
+						l516:
+							position, tokenIndex = position516, tokenIndex516
+						}
+						if !(p.errorHere(position, `expected key (one of 'from', 'to', 'resolution', 'sample by', 'order by', 'limit', or 'fill') or end of input but got %q following a completed expression`, p.after(position))) {
+							goto l331
+						}
+					}
+				l332:
+					goto l330
+				l331:
+					position, tokenIndex = position331, tokenIndex331
+				}
+				{
+					add(ruleAction29, position)
+				}
+				add(rulepropertyClause, position328)
+			}
+			return true
+		},
+		/* 16 optionalPredicateClause <- <(predicateClause / Action30)> */
+		func() bool {
+			{
+				position520 := position
+				{
+					position521, tokenIndex521 := position, tokenIndex
+					{
+						position523 := position
+						if !_rules[rule_]() {
+							goto l522
+						}
+						{
+							position524, tokenIndex524 := position, tokenIndex
+							if buffer[position] != rune('w') {
+								goto l525
+							}
+							position++
+							goto l524
+						l525:
+							position, tokenIndex = position524, tokenIndex524
+							if buffer[position] != rune('W') {
+								goto l522
+							}
+							position++
+						}
+					l524:
+						{
+							position526, tokenIndex526 := position, tokenIndex
+							if buffer[position] != rune('h') {
+								goto l527
+							}
+							position++
+							goto l526
+						l527:
+							position, tokenIndex = position526, tokenIndex526
+							if buffer[position] != rune('H') {
+								goto l522
+							}
+							position++
+						}
+					l526:
+						{
+							position528, tokenIndex528 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l529
+							}
+							position++
+							goto l528
+						l529:
+							position, tokenIndex = position528, tokenIndex528
+							if buffer[position] != rune('E') {
+								goto l522
+							}
+							position++
+						}
+					l528:
+						{
+							position530, tokenIndex530 := position, tokenIndex
+							if buffer[position] != rune('r') {
+								goto l531
+							}
+							position++
+							goto l530
+						l531:
+							position, tokenIndex = position530, tokenIndex530
+							if buffer[position] != rune('R') {
+								goto l522
+							}
+							position++
+						}
+					l530:
+						{
+							position532, tokenIndex532 := position, tokenIndex
+							if buffer[position] != rune('e') {
+								goto l533
+							}
+							position++
+							goto l532
+						l533:
+							position, tokenIndex = position532, tokenIndex532
+							if buffer[position] != rune('E') {
+								goto l522
+							}
+							position++
+						}
+					l532:
+						if !_rules[ruleKEY]() {
+							goto l522
+						}
+						{
+							position534, tokenIndex534 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l535
+							}
+							if !_rules[rulepredicate_1]() {
+								goto l535
+							}
+							goto l534
+						l535:
+							position, tokenIndex = position534, tokenIndex534
+							if !(p.errorHere(position, `expected predicate to follow "where" keyword`)) {
+								goto l522
+							}
+						}
+					l534:
+						add(rulepredicateClause, position523)
+					}
+					goto l521
+				l522:
+					position, tokenIndex = position521, tokenIndex521
+					{
+						add(ruleAction30, position)
+					}
+				}
+			l521:
+				add(ruleoptionalPredicateClause, position520)
+			}
+			return true
+		},
+		/* 17 expressionList <- <(Action31 expression_start Action32 (_ COMMA (expression_start / &{ p.errorHere(position, `expected expression to follow ","`) }) Action33)*)> */
+		func() bool {
+			position537, tokenIndex537 := position, tokenIndex
+			{
+				position538 := position
+				{
+					add(ruleAction31, position)
+				}
+				if !_rules[ruleexpression_start]() {
+					goto l537
+				}
+				{
+					add(ruleAction32, position)
+				}
+			l541:
+				{
+					position542, tokenIndex542 := position, tokenIndex
+					if !_rules[rule_]() {
+						goto l542
+					}
+					if !_rules[ruleCOMMA]() {
+						goto l542
+					}
+					{
+						position543, tokenIndex543 := position, tokenIndex
+						if !_rules[ruleexpression_start]() {
+							goto l544
+						}
+						goto l543
+					l544:
+						position, tokenIndex = position543, tokenIndex543
+						if !(p.errorHere(position, `expected expression to follow ","`)) {
+							goto l542
+						}
+					}
+				l543:
+					{
+						add(ruleAction33, position)
+					}
+					goto l541
+				l542:
+					position, tokenIndex = position542, tokenIndex542
+				}
+				add(ruleexpressionList, position538)
+			}
+			return true
+		l537:
+			position, tokenIndex = position537, tokenIndex537
+			return false
+		},
+		/* 18 expression_start <- <(expression_or add_pipe)> */
+		func() bool {
+			position546, tokenIndex546 := position, tokenIndex
+			{
+				position547 := position
+				{
+					position548 := position
+					if !_rules[ruleexpression_and]() {
+						goto l546
+					}
+				l549:
+					{
+						position550, tokenIndex550 := position, tokenIndex
+						if !_rules[ruleadd_pipe]() {
+							goto l550
+						}
+						if !_rules[rule_]() {
+							goto l550
+						}
+						if !_rules[ruleOP_OR]() {
+							goto l550
+						}
+						{
+							add(ruleAction34, position)
+						}
+						if !_rules[ruleoperatorMatching]() {
+							goto l550
+						}
+						{
+							position552, tokenIndex552 := position, tokenIndex
+							if !_rules[ruleexpression_and]() {
+								goto l553
+							}
+							goto l552
+						l553:
+							position, tokenIndex = position552, tokenIndex552
+							if !(p.errorHere(position, `expected expression to follow operator "or"`)) {
+								goto l550
+							}
+						}
+					l552:
+						{
+							add(ruleAction35, position)
+						}
+						goto l549
+					l550:
+						position, tokenIndex = position550, tokenIndex550
+					}
+					add(ruleexpression_or, position548)
+				}
+				if !_rules[ruleadd_pipe]() {
+					goto l546
+				}
+				add(ruleexpression_start, position547)
+			}
+			return true
+		l546:
+			position, tokenIndex = position546, tokenIndex546
+			return false
+		},
+		/* 19 expression_or <- <(expression_and (add_pipe _ OP_OR Action34 operatorMatching (expression_and / &{ p.errorHere(position, `expected expression to follow operator "or"`) }) Action35)*)> */
+		nil,
+		/* 20 expression_and <- <(expression_comparison (add_pipe ((_ OP_AND Action36) / (_ OP_UNLESS Action37)) operatorMatching (expression_comparison / &{ p.errorHere(position, `expected expression to follow operator "and" or "unless"`) }) Action38)*)> */
+		func() bool {
+			position556, tokenIndex556 := position, tokenIndex
+			{
+				position557 := position
+				if !_rules[ruleexpression_comparison]() {
+					goto l556
+				}
+			l558:
+				{
+					position559, tokenIndex559 := position, tokenIndex
+					if !_rules[ruleadd_pipe]() {
+						goto l559
+					}
+					{
+						position560, tokenIndex560 := position, tokenIndex
+						if !_rules[rule_]() {
+							goto l561
+						}
+						if !_rules[ruleOP_AND]() {
+							goto l561
+						}
+						{
+							add(ruleAction36, position)
+						}
+						goto l560
+					l561:
+						position, tokenIndex = position560, tokenIndex560
+						if !_rules[rule_]() {
+							goto l559
+						}
+						{
+							position563 := position
+							{
+								position564, tokenIndex564 := position, tokenIndex
+								if buffer[position] != rune('u') {
+									goto l565
+								}
+								position++
+								goto l564
+							l565:
+								position, tokenIndex = position564, tokenIndex564
+								if buffer[position] != rune('U') {
+									goto l559
+								}
+								position++
+							}
+						l564:
+							{
+								position566, tokenIndex566 := position, tokenIndex
+								if buffer[position] != rune('n') {
+									goto l567
+								}
+								position++
+								goto l566
+							l567:
+								position, tokenIndex = position566, tokenIndex566
+								if buffer[position] != rune('N') {
+									goto l559
+								}
+								position++
+							}
+						l566:
+							{
+								position568, tokenIndex568 := position, tokenIndex
+								if buffer[position] != rune('l') {
+									goto l569
+								}
+								position++
+								goto l568
+							l569:
+								position, tokenIndex = position568, tokenIndex568
+								if buffer[position] != rune('L') {
+									goto l559
+								}
+								position++
+							}
+						l568:
+							{
+								position570, tokenIndex570 := position, tokenIndex
+								if buffer[position] != rune('e') {
+									goto l571
 								}
-							l203:
-								if !_rules[ruleoptionalPredicateClause]() {
-									goto l0
+								position++
+								goto l570
+							l571:
+								position, tokenIndex = position570, tokenIndex570
+								if buffer[position] != rune('E') {
+									goto l559
 								}
-								{
-									add(ruleAction6, position)
+								position++
+							}
+						l570:
+							{
+								position572, tokenIndex572 := position, tokenIndex
+								if buffer[position] != rune('s') {
+									goto l573
+								}
+								position++
+								goto l572
+							l573:
+								position, tokenIndex = position572, tokenIndex572
+								if buffer[position] != rune('S') {
+									goto l559
 								}
-								add(ruledescribeSingleStmt, position202)
+								position++
+							}
+						l572:
+							{
+								position574, tokenIndex574 := position, tokenIndex
+								if buffer[position] != rune('s') {
+									goto l575
+								}
+								position++
+								goto l574
+							l575:
+								position, tokenIndex = position574, tokenIndex574
+								if buffer[position] != rune('S') {
+									goto l559
+								}
+								position++
+							}
+						l574:
+							if !_rules[ruleKEY]() {
+								goto l559
 							}
+							add(ruleOP_UNLESS, position563)
+						}
+						{
+							add(ruleAction37, position)
+						}
+					}
+				l560:
+					if !_rules[ruleoperatorMatching]() {
+						goto l559
+					}
+					{
+						position577, tokenIndex577 := position, tokenIndex
+						if !_rules[ruleexpression_comparison]() {
+							goto l578
 						}
-					l135:
-						add(ruledescribeStmt, position118)
+						goto l577
+					l578:
+						position, tokenIndex = position577, tokenIndex577
+						if !(p.errorHere(position, `expected expression to follow operator "and" or "unless"`)) {
+							goto l559
+						}
+					}
+				l577:
+					{
+						add(ruleAction38, position)
 					}
+					goto l558
+				l559:
+					position, tokenIndex = position559, tokenIndex559
 				}
-			l2:
-				if !_rules[rule_]() {
-					goto l0
+				add(ruleexpression_and, position557)
+			}
+			return true
+		l556:
+			position, tokenIndex = position556, tokenIndex556
+			return false
+		},
+		/* 21 expression_comparison <- <(expression_sum (add_pipe _ <OP_COMPARE> Action39 operatorMatching (expression_sum / &{ p.errorHere(position, `expected expression to follow comparison operator`) }) Action40)?)> */
+		func() bool {
+			position580, tokenIndex580 := position, tokenIndex
+			{
+				position581 := position
+				if !_rules[ruleexpression_sum]() {
+					goto l580
 				}
 				{
-					position209, tokenIndex209 := position, tokenIndex
-					if !matchDot() {
-						goto l209
+					position582, tokenIndex582 := position, tokenIndex
+					if !_rules[ruleadd_pipe]() {
+						goto l582
 					}
-					goto l0
-				l209:
-					position, tokenIndex = position209, tokenIndex209
+					if !_rules[rule_]() {
+						goto l582
+					}
+					{
+						position584 := position
+						{
+							position585 := position
+							{
+								position586, tokenIndex586 := position, tokenIndex
+								if buffer[position] != rune('>') {
+									goto l587
+								}
+								position++
+								if buffer[position] != rune('=') {
+									goto l587
+								}
+								position++
+								goto l586
+							l587:
+								position, tokenIndex = position586, tokenIndex586
+								if buffer[position] != rune('<') {
+									goto l588
+								}
+								position++
+								if buffer[position] != rune('=') {
+									goto l588
+								}
+								position++
+								goto l586
+							l588:
+								position, tokenIndex = position586, tokenIndex586
+								{
+									switch buffer[position] {
+									case '<':
+										if buffer[position] != rune('<') {
+											goto l582
+										}
+										position++
+										break
+									case '>':
+										if buffer[position] != rune('>') {
+											goto l582
+										}
+										position++
+										break
+									case '!':
+										if buffer[position] != rune('!') {
+											goto l582
+										}
+										position++
+										if buffer[position] != rune('=') {
+											goto l582
+										}
+										position++
+										break
+									default:
+										if buffer[position] != rune('=') {
+											goto l582
+										}
+										position++
+										if buffer[position] != rune('=') {
+											goto l582
+										}
+										position++
+										break
+									}
+								}
+
+							}
+						l586:
+							add(ruleOP_COMPARE, position585)
+						}
+						add(rulePegText, position584)
+					}
+					{
+						add(ruleAction39, position)
+					}
+					if !_rules[ruleoperatorMatching]() {
+						goto l582
+					}
+					{
+						position591, tokenIndex591 := position, tokenIndex
+						if !_rules[ruleexpression_sum]() {
+							goto l592
+						}
+						goto l591
+					l592:
+						position, tokenIndex = position591, tokenIndex591
+						if !(p.errorHere(position, `expected expression to follow comparison operator`)) {
+							goto l582
+						}
+					}
+				l591:
+					{
+						add(ruleAction40, position)
+					}
+					goto l583
+				l582:
+					position, tokenIndex = position582, tokenIndex582
 				}
-				add(ruleroot, position1)
+			l583:
+				add(ruleexpression_comparison, position581)
 			}
 			return true
-		l0:
-			position, tokenIndex = position0, tokenIndex0
+		l580:
+			position, tokenIndex = position580, tokenIndex580
 			return false
 		},
-		/* 1 selectStmt <- <(_ (('s' / 'S') ('e' / 'E') ('l' / 'L') ('e' / 'E') ('c' / 'C') ('t' / 'T') KEY)? expressionList &{ p.setContext("after expression of select statement") } optionalPredicateClause &{ p.setContext("") } propertyClause Action0)> */
-		nil,
-		/* 2 describeStmt <- <(_ (('d' / 'D') ('e' / 'E') ('s' / 'S') ('c' / 'C') ('r' / 'R') ('i' / 'I') ('b' / 'B') ('e' / 'E')) KEY (describeAllStmt / describeMetrics / describeSingleStmt))> */
-		nil,
-		/* 3 describeAllStmt <- <(_ (('a' / 'A') ('l' / 'L') ('l' / 'L')) KEY optionalMatchClause Action1 &((_ !.) / (_ &{p.errorHere(position, `expected end of input after 'describe all' and optional match clause but got %q`, p.after(position) )})))> */
-		nil,
-		/* 4 optionalMatchClause <- <(matchClause / Action2)> */
-		nil,
-		/* 5 matchClause <- <(_ (('m' / 'M') ('a' / 'A') ('t' / 'T') ('c' / 'C') ('h' / 'H')) KEY (literalString / &{ p.errorHere(position, `expected string literal to follow keyword "match"`) }) Action3)> */
-		nil,
-		/* 6 describeMetrics <- <(_ (('m' / 'M') ('e' / 'E') ('t' / 'T') ('r' / 'R') ('i' / 'I') ('c' / 'C') ('s' / 'S')) KEY ((_ (('w' / 'W') ('h' / 'H') ('e' / 'E') ('r' / 'R') ('e' / 'E')) KEY) / &{ p.errorHere(position, `expected "where" to follow keyword "metrics" in "describe metrics" command`) }) (tagName / &{ p.errorHere(position, `expected tag key to follow keyword "where" in "describe metrics" command`) }) ((_ '=') / &{ p.errorHere(position, `expected "=" to follow keyword "where" in "describe metrics" command`) }) (literalString / &{ p.errorHere(position, `expected string literal to follow "=" in "describe metrics" command`) }) Action4)> */
-		nil,
-		/* 7 describeSingleStmt <- <(((_ <METRIC_NAME> Action5) / &{ p.errorHere(position, `expected metric name to follow "describe" in "describe" command`) }) optionalPredicateClause Action6)> */
-		nil,
-		/* 8 propertyClause <- <(Action7 ((_ PROPERTY_KEY Action8 ((_ PROPERTY_VALUE Action9) / &{ p.errorHere(position, `expected value to follow key '%s'`, p.contents(tree, tokenIndex-2)) }) Action10) / (_ (('w' / 'W') ('h' / 'H') ('e' / 'E') ('r' / 'R') ('e' / 'E')) KEY &{ p.errorHere(position, `encountered "where" after property clause; "where" blocks must go BEFORE 'from' and 'to' specifiers`) }) / (_ !!. &{ p.errorHere(position, `expected key (one of 'from', 'to', 'resolution', or 'sample by') or end of input but got %q following a completed expression`, p.after(position)) }))* Action11)> */
-		nil,
-		/* 9 optionalPredicateClause <- <(predicateClause / Action12)> */
+		/* 22 expression_sum <- <(expression_product (add_pipe ((_ OP_ADD Action41) / (_ OP_SUB Action42)) operatorMatching (expression_product / &{ p.errorHere(position, `expected expression to follow operator "+" or "-"`) }) Action43)*)> */
 		func() bool {
+			position594, tokenIndex594 := position, tokenIndex
 			{
-				position219 := position
+				position595 := position
+				if !_rules[ruleexpression_product]() {
+					goto l594
+				}
+			l596:
 				{
-					position220, tokenIndex220 := position, tokenIndex
+					position597, tokenIndex597 := position, tokenIndex
+					if !_rules[ruleadd_pipe]() {
+						goto l597
+					}
 					{
-						position222 := position
+						position598, tokenIndex598 := position, tokenIndex
 						if !_rules[rule_]() {
-							goto l221
+							goto l599
 						}
 						{
-							position223, tokenIndex223 := position, tokenIndex
-							if buffer[position] != rune('w') {
-								goto l224
-							}
-							position++
-							goto l223
-						l224:
-							position, tokenIndex = position223, tokenIndex223
-							if buffer[position] != rune('W') {
-								goto l221
+							position600 := position
+							if buffer[position] != rune('+') {
+								goto l599
 							}
 							position++
+							add(ruleOP_ADD, position600)
 						}
-					l223:
 						{
-							position225, tokenIndex225 := position, tokenIndex
-							if buffer[position] != rune('h') {
-								goto l226
-							}
-							position++
-							goto l225
-						l226:
-							position, tokenIndex = position225, tokenIndex225
-							if buffer[position] != rune('H') {
-								goto l221
-							}
-							position++
+							add(ruleAction41, position)
+						}
+						goto l598
+					l599:
+						position, tokenIndex = position598, tokenIndex598
+						if !_rules[rule_]() {
+							goto l597
 						}
-					l225:
 						{
-							position227, tokenIndex227 := position, tokenIndex
-							if buffer[position] != rune('e') {
-								goto l228
-							}
-							position++
-							goto l227
-						l228:
-							position, tokenIndex = position227, tokenIndex227
-							if buffer[position] != rune('E') {
-								goto l221
+							position602 := position
+							if buffer[position] != rune('-') {
+								goto l597
 							}
 							position++
+							add(ruleOP_SUB, position602)
 						}
-					l227:
 						{
-							position229, tokenIndex229 := position, tokenIndex
-							if buffer[position] != rune('r') {
-								goto l230
-							}
-							position++
-							goto l229
-						l230:
-							position, tokenIndex = position229, tokenIndex229
-							if buffer[position] != rune('R') {
-								goto l221
-							}
-							position++
+							add(ruleAction42, position)
+						}
+					}
+				l598:
+					if !_rules[ruleoperatorMatching]() {
+						goto l597
+					}
+					{
+						position604, tokenIndex604 := position, tokenIndex
+						if !_rules[ruleexpression_product]() {
+							goto l605
+						}
+						goto l604
+					l605:
+						position, tokenIndex = position604, tokenIndex604
+						if !(p.errorHere(position, `expected expression to follow operator "+" or "-"`)) {
+							goto l597
+						}
+					}
+				l604:
+					{
+						add(ruleAction43, position)
+					}
+					goto l596
+				l597:
+					position, tokenIndex = position597, tokenIndex597
+				}
+				add(ruleexpression_sum, position595)
+			}
+			return true
+		l594:
+			position, tokenIndex = position594, tokenIndex594
+			return false
+		},
+		/* 23 expression_product <- <(expression_atom (add_pipe ((_ OP_DIV Action44) / (_ OP_MULT Action45)) operatorMatching (expression_atom / &{ p.errorHere(position, `expected expression to follow operator "*" or "/"`) }) Action46)*)> */
+		func() bool {
+			position607, tokenIndex607 := position, tokenIndex
+			{
+				position608 := position
+				if !_rules[ruleexpression_atom]() {
+					goto l607
+				}
+			l609:
+				{
+					position610, tokenIndex610 := position, tokenIndex
+					if !_rules[ruleadd_pipe]() {
+						goto l610
+					}
+					{
+						position611, tokenIndex611 := position, tokenIndex
+						if !_rules[rule_]() {
+							goto l612
 						}
-					l229:
 						{
-							position231, tokenIndex231 := position, tokenIndex
-							if buffer[position] != rune('e') {
-								goto l232
-							}
-							position++
-							goto l231
-						l232:
-							position, tokenIndex = position231, tokenIndex231
-							if buffer[position] != rune('E') {
-								goto l221
+							position613 := position
+							if buffer[position] != rune('/') {
+								goto l612
 							}
 							position++
+							add(ruleOP_DIV, position613)
 						}
-					l231:
-						if !_rules[ruleKEY]() {
-							goto l221
+						{
+							add(ruleAction44, position)
+						}
+						goto l611
+					l612:
+						position, tokenIndex = position611, tokenIndex611
+						if !_rules[rule_]() {
+							goto l610
 						}
 						{
-							position233, tokenIndex233 := position, tokenIndex
-							if !_rules[rule_]() {
-								goto l234
-							}
-							if !_rules[rulepredicate_1]() {
-								goto l234
-							}
-							goto l233
-						l234:
-							position, tokenIndex = position233, tokenIndex233
-							if !(p.errorHere(position, `expected predicate to follow "where" keyword`)) {
-								goto l221
+							position615 := position
+							if buffer[position] != rune('*') {
+								goto l610
 							}
+							position++
+							add(ruleOP_MULT, position615)
+						}
+						{
+							add(ruleAction45, position)
+						}
+					}
+				l611:
+					if !_rules[ruleoperatorMatching]() {
+						goto l610
+					}
+					{
+						position617, tokenIndex617 := position, tokenIndex
+						if !_rules[ruleexpression_atom]() {
+							goto l618
+						}
+						goto l617
+					l618:
+						position, tokenIndex = position617, tokenIndex617
+						if !(p.errorHere(position, `expected expression to follow operator "*" or "/"`)) {
+							goto l610
+						}
+					}
+				l617:
+					{
+						add(ruleAction46, position)
+					}
+					goto l609
+				l610:
+					position, tokenIndex = position610, tokenIndex610
+				}
+				add(ruleexpression_product, position608)
+			}
+			return true
+		l607:
+			position, tokenIndex = position607, tokenIndex607
+			return false
+		},
+		/* 24 operatorMatching <- <((_ (('o' / 'O') ('n' / 'N')) KEY _ PAREN_OPEN Action47 matchingTags) / (_ (('i' / 'I') ('g' / 'G') ('n' / 'N') ('o' / 'O') ('r' / 'R') ('i' / 'I') ('n' / 'N') ('g' / 'G')) KEY _ PAREN_OPEN Action48 matchingTags) / Action49)> */
+		func() bool {
+			{
+				position621 := position
+				{
+					position622, tokenIndex622 := position, tokenIndex
+					if !_rules[rule_]() {
+						goto l623
+					}
+					{
+						position624, tokenIndex624 := position, tokenIndex
+						if buffer[position] != rune('o') {
+							goto l625
+						}
+						position++
+						goto l624
+					l625:
+						position, tokenIndex = position624, tokenIndex624
+						if buffer[position] != rune('O') {
+							goto l623
+						}
+						position++
+					}
+				l624:
+					{
+						position626, tokenIndex626 := position, tokenIndex
+						if buffer[position] != rune('n') {
+							goto l627
+						}
+						position++
+						goto l626
+					l627:
+						position, tokenIndex = position626, tokenIndex626
+						if buffer[position] != rune('N') {
+							goto l623
+						}
+						position++
+					}
+				l626:
+					if !_rules[ruleKEY]() {
+						goto l623
+					}
+					if !_rules[rule_]() {
+						goto l623
+					}
+					if !_rules[rulePAREN_OPEN]() {
+						goto l623
+					}
+					{
+						add(ruleAction47, position)
+					}
+					if !_rules[rulematchingTags]() {
+						goto l623
+					}
+					goto l622
+				l623:
+					position, tokenIndex = position622, tokenIndex622
+					if !_rules[rule_]() {
+						goto l629
+					}
+					{
+						position630, tokenIndex630 := position, tokenIndex
+						if buffer[position] != rune('i') {
+							goto l631
+						}
+						position++
+						goto l630
+					l631:
+						position, tokenIndex = position630, tokenIndex630
+						if buffer[position] != rune('I') {
+							goto l629
+						}
+						position++
+					}
+				l630:
+					{
+						position632, tokenIndex632 := position, tokenIndex
+						if buffer[position] != rune('g') {
+							goto l633
+						}
+						position++
+						goto l632
+					l633:
+						position, tokenIndex = position632, tokenIndex632
+						if buffer[position] != rune('G') {
+							goto l629
+						}
+						position++
+					}
+				l632:
+					{
+						position634, tokenIndex634 := position, tokenIndex
+						if buffer[position] != rune('n') {
+							goto l635
+						}
+						position++
+						goto l634
+					l635:
+						position, tokenIndex = position634, tokenIndex634
+						if buffer[position] != rune('N') {
+							goto l629
+						}
+						position++
+					}
+				l634:
+					{
+						position636, tokenIndex636 := position, tokenIndex
+						if buffer[position] != rune('o') {
+							goto l637
+						}
+						position++
+						goto l636
+					l637:
+						position, tokenIndex = position636, tokenIndex636
+						if buffer[position] != rune('O') {
+							goto l629
+						}
+						position++
+					}
+				l636:
+					{
+						position638, tokenIndex638 := position, tokenIndex
+						if buffer[position] != rune('r') {
+							goto l639
+						}
+						position++
+						goto l638
+					l639:
+						position, tokenIndex = position638, tokenIndex638
+						if buffer[position] != rune('R') {
+							goto l629
+						}
+						position++
+					}
+				l638:
+					{
+						position640, tokenIndex640 := position, tokenIndex
+						if buffer[position] != rune('i') {
+							goto l641
+						}
+						position++
+						goto l640
+					l641:
+						position, tokenIndex = position640, tokenIndex640
+						if buffer[position] != rune('I') {
+							goto l629
+						}
+						position++
+					}
+				l640:
+					{
+						position642, tokenIndex642 := position, tokenIndex
+						if buffer[position] != rune('n') {
+							goto l643
+						}
+						position++
+						goto l642
+					l643:
+						position, tokenIndex = position642, tokenIndex642
+						if buffer[position] != rune('N') {
+							goto l629
 						}
-					l233:
-						add(rulepredicateClause, position222)
+						position++
 					}
-					goto l220
-				l221:
-					position, tokenIndex = position220, tokenIndex220
+				l642:
 					{
-						add(ruleAction12, position)
+						position644, tokenIndex644 := position, tokenIndex
+						if buffer[position] != rune('g') {
+							goto l645
+						}
+						position++
+						goto l644
+					l645:
+						position, tokenIndex = position644, tokenIndex644
+						if buffer[position] != rune('G') {
+							goto l629
+						}
+						position++
+					}
+				l644:
+					if !_rules[ruleKEY]() {
+						goto l629
 					}
-				}
-			l220:
-				add(ruleoptionalPredicateClause, position219)
-			}
-			return true
-		},
-		/* 10 expressionList <- <(Action13 expression_start Action14 (_ COMMA (expression_start / &{ p.errorHere(position, `expected expression to follow ","`) }) Action15)*)> */
-		func() bool {
-			position236, tokenIndex236 := position, tokenIndex
-			{
-				position237 := position
-				{
-					add(ruleAction13, position)
-				}
-				if !_rules[ruleexpression_start]() {
-					goto l236
-				}
-				{
-					add(ruleAction14, position)
-				}
-			l240:
-				{
-					position241, tokenIndex241 := position, tokenIndex
 					if !_rules[rule_]() {
-						goto l241
+						goto l629
 					}
-					if !_rules[ruleCOMMA]() {
-						goto l241
+					if !_rules[rulePAREN_OPEN]() {
+						goto l629
 					}
 					{
-						position242, tokenIndex242 := position, tokenIndex
-						if !_rules[ruleexpression_start]() {
-							goto l243
-						}
-						goto l242
-					l243:
-						position, tokenIndex = position242, tokenIndex242
-						if !(p.errorHere(position, `expected expression to follow ","`)) {
-							goto l241
-						}
+						add(ruleAction48, position)
+					}
+					if !_rules[rulematchingTags]() {
+						goto l629
 					}
-				l242:
+					goto l622
+				l629:
+					position, tokenIndex = position622, tokenIndex622
 					{
-						add(ruleAction15, position)
+						add(ruleAction49, position)
 					}
-					goto l240
-				l241:
-					position, tokenIndex = position241, tokenIndex241
 				}
-				add(ruleexpressionList, position237)
+			l622:
+				add(ruleoperatorMatching, position621)
 			}
 			return true
-		l236:
-			position, tokenIndex = position236, tokenIndex236
-			return false
 		},
-		/* 11 expression_start <- <(expression_sum add_pipe)> */
+		/* 25 matchingTags <- <(((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "(" in "on" or "ignoring" clause`) }) Action50 (_ COMMA ((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "," in "on" or "ignoring" clause`) }) Action51)* ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" opened by "on" or "ignoring" clause`) }))> */
 		func() bool {
-			position245, tokenIndex245 := position, tokenIndex
+			position648, tokenIndex648 := position, tokenIndex
 			{
-				position246 := position
+				position649 := position
 				{
-					position247 := position
-					if !_rules[ruleexpression_product]() {
-						goto l245
+					position650, tokenIndex650 := position, tokenIndex
+					if !_rules[rule_]() {
+						goto l651
 					}
-				l248:
 					{
-						position249, tokenIndex249 := position, tokenIndex
-						if !_rules[ruleadd_pipe]() {
-							goto l249
-						}
-						{
-							position250, tokenIndex250 := position, tokenIndex
-							if !_rules[rule_]() {
-								goto l251
-							}
-							{
-								position252 := position
-								if buffer[position] != rune('+') {
-									goto l251
-								}
-								position++
-								add(ruleOP_ADD, position252)
-							}
-							{
-								add(ruleAction16, position)
-							}
-							goto l250
-						l251:
-							position, tokenIndex = position250, tokenIndex250
-							if !_rules[rule_]() {
-								goto l249
-							}
-							{
-								position254 := position
-								if buffer[position] != rune('-') {
-									goto l249
-								}
-								position++
-								add(ruleOP_SUB, position254)
-							}
-							{
-								add(ruleAction17, position)
-							}
-						}
-					l250:
-						{
-							position256, tokenIndex256 := position, tokenIndex
-							if !_rules[ruleexpression_product]() {
-								goto l257
-							}
-							goto l256
-						l257:
-							position, tokenIndex = position256, tokenIndex256
-							if !(p.errorHere(position, `expected expression to follow operator "+" or "-"`)) {
-								goto l249
-							}
-						}
-					l256:
-						{
-							add(ruleAction18, position)
+						position652 := position
+						if !_rules[ruleCOLUMN_NAME]() {
+							goto l651
 						}
-						goto l248
-					l249:
-						position, tokenIndex = position249, tokenIndex249
+						add(rulePegText, position652)
+					}
+					goto l650
+				l651:
+					position, tokenIndex = position650, tokenIndex650
+					if !(p.errorHere(position, `expected tag key identifier to follow "(" in "on" or "ignoring" clause`)) {
+						goto l648
 					}
-					add(ruleexpression_sum, position247)
-				}
-				if !_rules[ruleadd_pipe]() {
-					goto l245
 				}
-				add(ruleexpression_start, position246)
-			}
-			return true
-		l245:
-			position, tokenIndex = position245, tokenIndex245
-			return false
-		},
-		/* 12 expression_sum <- <(expression_product (add_pipe ((_ OP_ADD Action16) / (_ OP_SUB Action17)) (expression_product / &{ p.errorHere(position, `expected expression to follow operator "+" or "-"`) }) Action18)*)> */
-		nil,
-		/* 13 expression_product <- <(expression_atom (add_pipe ((_ OP_DIV Action19) / (_ OP_MULT Action20)) (expression_atom / &{ p.errorHere(position, `expected expression to follow operator "*" or "/"`) }) Action21)*)> */
-		func() bool {
-			position260, tokenIndex260 := position, tokenIndex
-			{
-				position261 := position
-				if !_rules[ruleexpression_atom]() {
-					goto l260
+			l650:
+				{
+					add(ruleAction50, position)
 				}
-			l262:
+			l654:
 				{
-					position263, tokenIndex263 := position, tokenIndex
-					if !_rules[ruleadd_pipe]() {
-						goto l263
+					position655, tokenIndex655 := position, tokenIndex
+					if !_rules[rule_]() {
+						goto l655
+					}
+					if !_rules[ruleCOMMA]() {
+						goto l655
 					}
 					{
-						position264, tokenIndex264 := position, tokenIndex
-						if !_rules[rule_]() {
-							goto l265
-						}
-						{
-							position266 := position
-							if buffer[position] != rune('/') {
-								goto l265
-							}
-							position++
-							add(ruleOP_DIV, position266)
-						}
-						{
-							add(ruleAction19, position)
-						}
-						goto l264
-					l265:
-						position, tokenIndex = position264, tokenIndex264
+						position656, tokenIndex656 := position, tokenIndex
 						if !_rules[rule_]() {
-							goto l263
+							goto l657
 						}
 						{
-							position268 := position
-							if buffer[position] != rune('*') {
-								goto l263
+							position658 := position
+							if !_rules[ruleCOLUMN_NAME]() {
+								goto l657
 							}
-							position++
-							add(ruleOP_MULT, position268)
+							add(rulePegText, position658)
 						}
-						{
-							add(ruleAction20, position)
+						goto l656
+					l657:
+						position, tokenIndex = position656, tokenIndex656
+						if !(p.errorHere(position, `expected tag key identifier to follow "," in "on" or "ignoring" clause`)) {
+							goto l655
 						}
 					}
-				l264:
+				l656:
 					{
-						position270, tokenIndex270 := position, tokenIndex
-						if !_rules[ruleexpression_atom]() {
-							goto l271
-						}
-						goto l270
-					l271:
-						position, tokenIndex = position270, tokenIndex270
-						if !(p.errorHere(position, `expected expression to follow operator "*" or "/"`)) {
-							goto l263
-						}
+						add(ruleAction51, position)
 					}
-				l270:
-					{
-						add(ruleAction21, position)
+					goto l654
+				l655:
+					position, tokenIndex = position655, tokenIndex655
+				}
+				{
+					position660, tokenIndex660 := position, tokenIndex
+					if !_rules[rule_]() {
+						goto l661
+					}
+					if !_rules[rulePAREN_CLOSE]() {
+						goto l661
+					}
+					goto l660
+				l661:
+					position, tokenIndex = position660, tokenIndex660
+					if !(p.errorHere(position, `expected ")" to close "(" opened by "on" or "ignoring" clause`)) {
+						goto l648
 					}
-					goto l262
-				l263:
-					position, tokenIndex = position263, tokenIndex263
 				}
-				add(ruleexpression_product, position261)
+			l660:
+				add(rulematchingTags, position649)
 			}
 			return true
-		l260:
-			position, tokenIndex = position260, tokenIndex260
+		l648:
+			position, tokenIndex = position648, tokenIndex648
 			return false
 		},
-		/* 14 add_one_pipe <- <(_ OP_PIPE ((_ <IDENTIFIER>) / &{ p.errorHere(position, `expected function name to follow pipe "|"`) }) Action22 ((_ PAREN_OPEN (expressionList / Action23) optionalGroupBy ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" opened in pipe function call`) })) / Action24) Action25 expression_annotation)> */
+		/* 26 add_one_pipe <- <(((_ OP_PIPELINE ((_ <IDENTIFIER>) / &{ p.errorHere(position, `expected function name to follow pipeline "|>"`) })) / (_ OP_PIPE ((_ <IDENTIFIER>) / &{ p.errorHere(position, `expected function name to follow pipe "|"`) }))) Action52 ((_ PAREN_OPEN (expressionList / Action53) optionalGroupBy ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" opened in pipe function call`) })) / Action54) Action55 expression_annotation)> */
 		nil,
-		/* 15 add_pipe <- <add_one_pipe*> */
+		/* 27 add_pipe <- <add_one_pipe*> */
 		func() bool {
 			{
-				position275 := position
-			l276:
+				position664 := position
+			l665:
 				{
-					position277, tokenIndex277 := position, tokenIndex
+					position666, tokenIndex666 := position, tokenIndex
 					{
-						position278 := position
-						if !_rules[rule_]() {
-							goto l277
-						}
+						position667 := position
 						{
-							position279 := position
-							if buffer[position] != rune('|') {
-								goto l277
+							position668, tokenIndex668 := position, tokenIndex
+							if !_rules[rule_]() {
+								goto l669
 							}
-							position++
-							add(ruleOP_PIPE, position279)
-						}
-						{
-							position280, tokenIndex280 := position, tokenIndex
+							{
+								position670 := position
+								if buffer[position] != rune('|') {
+									goto l669
+								}
+								position++
+								if buffer[position] != rune('>') {
+									goto l669
+								}
+								position++
+								add(ruleOP_PIPELINE, position670)
+							}
+							{
+								position671, tokenIndex671 := position, tokenIndex
+								if !_rules[rule_]() {
+									goto l672
+								}
+								{
+									position673 := position
+									if !_rules[ruleIDENTIFIER]() {
+										goto l672
+									}
+									add(rulePegText, position673)
+								}
+								goto l671
+							l672:
+								position, tokenIndex = position671, tokenIndex671
+								if !(p.errorHere(position, `expected function name to follow pipeline "|>"`)) {
+									goto l669
+								}
+							}
+						l671:
+							goto l668
+						l669:
+							position, tokenIndex = position668, tokenIndex668
 							if !_rules[rule_]() {
-								goto l281
+								goto l666
 							}
 							{
-								position282 := position
-								if !_rules[ruleIDENTIFIER]() {
-									goto l281
+								position674 := position
+								if buffer[position] != rune('|') {
+									goto l666
 								}
-								add(rulePegText, position282)
+								position++
+								add(ruleOP_PIPE, position674)
 							}
-							goto l280
-						l281:
-							position, tokenIndex = position280, tokenIndex280
-							if !(p.errorHere(position, `expected function name to follow pipe "|"`)) {
-								goto l277
+							{
+								position675, tokenIndex675 := position, tokenIndex
+								if !_rules[rule_]() {
+									goto l676
+								}
+								{
+									position677 := position
+									if !_rules[ruleIDENTIFIER]() {
+										goto l676
+									}
+									add(rulePegText, position677)
+								}
+								goto l675
+							l676:
+								position, tokenIndex = position675, tokenIndex675
+								if !(p.errorHere(position, `expected function name to follow pipe "|"`)) {
+									goto l666
+								}
 							}
+						l675:
 						}
-					l280:
+					l668:
 						{
-							add(ruleAction22, position)
+							add(ruleAction52, position)
 						}
 						{
-							position284, tokenIndex284 := position, tokenIndex
+							position679, tokenIndex679 := position, tokenIndex
 							if !_rules[rule_]() {
-								goto l285
+								goto l680
 							}
 							if !_rules[rulePAREN_OPEN]() {
-								goto l285
+								goto l680
 							}
 							{
-								position286, tokenIndex286 := position, tokenIndex
+								position681, tokenIndex681 := position, tokenIndex
 								if !_rules[ruleexpressionList]() {
-									goto l287
+									goto l682
 								}
-								goto l286
-							l287:
-								position, tokenIndex = position286, tokenIndex286
+								goto l681
+							l682:
+								position, tokenIndex = position681, tokenIndex681
 								{
-									add(ruleAction23, position)
+									add(ruleAction53, position)
 								}
 							}
-						l286:
+						l681:
 							if !_rules[ruleoptionalGroupBy]() {
-								goto l285
+								goto l680
 							}
 							{
-								position289, tokenIndex289 := position, tokenIndex
+								position684, tokenIndex684 := position, tokenIndex
 								if !_rules[rule_]() {
-									goto l290
+									goto l685
 								}
 								if !_rules[rulePAREN_CLOSE]() {
-									goto l290
+									goto l685
 								}
-								goto l289
-							l290:
-								position, tokenIndex = position289, tokenIndex289
+								goto l684
+							l685:
+								position, tokenIndex = position684, tokenIndex684
 								if !(p.errorHere(position, `expected ")" to close "(" opened in pipe function call`)) {
-									goto l285
+									goto l680
 								}
 							}
-						l289:
-							goto l284
-						l285:
-							position, tokenIndex = position284, tokenIndex284
+						l684:
+							goto l679
+						l680:
+							position, tokenIndex = position679, tokenIndex679
 							{
-								add(ruleAction24, position)
+								add(ruleAction54, position)
 							}
 						}
-					l284:
+					l679:
 						{
-							add(ruleAction25, position)
+							add(ruleAction55, position)
 						}
 						if !_rules[ruleexpression_annotation]() {
-							goto l277
+							goto l666
 						}
-						add(ruleadd_one_pipe, position278)
+						add(ruleadd_one_pipe, position667)
 					}
-					goto l276
-				l277:
-					position, tokenIndex = position277, tokenIndex277
+					goto l665
+				l666:
+					position, tokenIndex = position666, tokenIndex666
 				}
-				add(ruleadd_pipe, position275)
+				add(ruleadd_pipe, position664)
 			}
 			return true
 		},
-		/* 16 expression_atom <- <(expression_atom_raw expression_annotation)> */
+		/* 28 expression_atom <- <(expression_atom_raw expression_annotation)> */
 		func() bool {
-			position293, tokenIndex293 := position, tokenIndex
+			position688, tokenIndex688 := position, tokenIndex
 			{
-				position294 := position
+				position689 := position
 				{
-					position295 := position
+					position690 := position
 					{
-						position296, tokenIndex296 := position, tokenIndex
+						position691, tokenIndex691 := position, tokenIndex
 						{
-							position298 := position
+							position693 := position
 							if !_rules[rule_]() {
-								goto l297
+								goto l692
 							}
 							{
-								position299 := position
+								position694 := position
 								if !_rules[ruleIDENTIFIER]() {
-									goto l297
+									goto l692
 								}
-								add(rulePegText, position299)
+								add(rulePegText, position694)
 							}
 							{
-								add(ruleAction31, position)
+								add(ruleAction61, position)
 							}
 							if !_rules[rule_]() {
-								goto l297
+								goto l692
 							}
 							if !_rules[rulePAREN_OPEN]() {
-								goto l297
+								goto l692
 							}
 							{
-								position301, tokenIndex301 := position, tokenIndex
+								position696, tokenIndex696 := position, tokenIndex
 								if !_rules[ruleexpressionList]() {
-									goto l302
+									goto l697
 								}
-								goto l301
-							l302:
-								position, tokenIndex = position301, tokenIndex301
+								goto l696
+							l697:
+								position, tokenIndex = position696, tokenIndex696
 								if !(p.errorHere(position, `expected expression list to follow "(" in function call`)) {
-									goto l297
+									goto l692
 								}
 							}
-						l301:
+						l696:
 							if !_rules[ruleoptionalGroupBy]() {
-								goto l297
+								goto l692
 							}
 							{
-								position303, tokenIndex303 := position, tokenIndex
+								position698, tokenIndex698 := position, tokenIndex
 								if !_rules[rule_]() {
-									goto l304
+									goto l699
 								}
 								if !_rules[rulePAREN_CLOSE]() {
-									goto l304
+									goto l699
 								}
-								goto l303
-							l304:
-								position, tokenIndex = position303, tokenIndex303
+								goto l698
+							l699:
+								position, tokenIndex = position698, tokenIndex698
 								if !(p.errorHere(position, `expected ")" to close "(" opened by function call`)) {
-									goto l297
+									goto l692
 								}
 							}
-						l303:
+						l698:
 							{
-								add(ruleAction32, position)
+								add(ruleAction62, position)
 							}
-							add(ruleexpression_function, position298)
+							add(ruleexpression_function, position693)
 						}
-						goto l296
-					l297:
-						position, tokenIndex = position296, tokenIndex296
+						goto l691
+					l692:
+						position, tokenIndex = position691, tokenIndex691
 						{
-							position307 := position
+							position702 := position
 							if !_rules[rule_]() {
-								goto l306
+								goto l701
 							}
 							{
-								position308 := position
+								position703 := position
 								if !_rules[ruleIDENTIFIER]() {
-									goto l306
+									goto l701
 								}
-								add(rulePegText, position308)
+								add(rulePegText, position703)
 							}
 							{
-								add(ruleAction33, position)
+								add(ruleAction63, position)
 							}
 							{
-								position310, tokenIndex310 := position, tokenIndex
+								position705, tokenIndex705 := position, tokenIndex
 								if !_rules[rule_]() {
-									goto l311
+									goto l706
 								}
 								if buffer[position] != rune('[') {
-									goto l311
+									goto l706
 								}
 								position++
 								{
-									position312, tokenIndex312 := position, tokenIndex
+									position707, tokenIndex707 := position, tokenIndex
 									if !_rules[rulepredicate_1]() {
-										goto l313
+										goto l708
 									}
-									goto l312
-								l313:
-									position, tokenIndex = position312, tokenIndex312
+									goto l707
+								l708:
+									position, tokenIndex = position707, tokenIndex707
 									if !(p.errorHere(position, `expected predicate to follow "[" after metric`)) {
-										goto l311
+										goto l706
 									}
 								}
-							l312:
+							l707:
 								{
-									position314, tokenIndex314 := position, tokenIndex
+									position709, tokenIndex709 := position, tokenIndex
 									if !_rules[rule_]() {
-										goto l315
+										goto l710
 									}
 									if buffer[position] != rune(']') {
-										goto l315
+										goto l710
 									}
 									position++
-									goto l314
-								l315:
-									position, tokenIndex = position314, tokenIndex314
+									goto l709
+								l710:
+									position, tokenIndex = position709, tokenIndex709
 									if !(p.errorHere(position, `expected "]" to close "[" opened to apply predicate`)) {
-										goto l311
+										goto l706
 									}
 								}
-							l314:
-								goto l310
-							l311:
-								position, tokenIndex = position310, tokenIndex310
+							l709:
+								goto l705
+							l706:
+								position, tokenIndex = position705, tokenIndex705
 								{
-									add(ruleAction34, position)
+									add(ruleAction64, position)
 								}
 							}
-						l310:
+						l705:
 							{
-								add(ruleAction35, position)
+								add(ruleAction65, position)
 							}
-							add(ruleexpression_metric, position307)
+							add(ruleexpression_metric, position702)
 						}
-						goto l296
-					l306:
-						position, tokenIndex = position296, tokenIndex296
+						goto l691
+					l701:
+						position, tokenIndex = position691, tokenIndex691
 						if !_rules[rule_]() {
-							goto l318
+							goto l713
 						}
 						if !_rules[rulePAREN_OPEN]() {
-							goto l318
+							goto l713
 						}
 						{
-							position319, tokenIndex319 := position, tokenIndex
+							position714, tokenIndex714 := position, tokenIndex
 							if !_rules[ruleexpression_start]() {
-								goto l320
+								goto l715
 							}
-							goto l319
-						l320:
-							position, tokenIndex = position319, tokenIndex319
+							goto l714
+						l715:
+							position, tokenIndex = position714, tokenIndex714
 							if !(p.errorHere(position, `expected expression to follow "("`)) {
-								goto l318
+								goto l713
 							}
 						}
-					l319:
+					l714:
 						{
-							position321, tokenIndex321 := position, tokenIndex
+							position716, tokenIndex716 := position, tokenIndex
 							if !_rules[rule_]() {
-								goto l322
+								goto l717
 							}
 							if !_rules[rulePAREN_CLOSE]() {
-								goto l322
+								goto l717
 							}
-							goto l321
-						l322:
-							position, tokenIndex = position321, tokenIndex321
+							goto l716
+						l717:
+							position, tokenIndex = position716, tokenIndex716
 							if !(p.errorHere(position, `expected ")" to close "("`)) {
-								goto l318
+								goto l713
 							}
 						}
-					l321:
-						goto l296
-					l318:
-						position, tokenIndex = position296, tokenIndex296
+					l716:
+						goto l691
+					l713:
+						position, tokenIndex = position691, tokenIndex691
 						if !_rules[rule_]() {
-							goto l323
+							goto l718
 						}
 						{
-							position324 := position
+							position719 := position
 							{
-								position325 := position
+								position720 := position
 								if !_rules[ruleNUMBER]() {
-									goto l323
+									goto l718
 								}
 								if c := buffer[position]; c < rune('a') || c > rune('z') {
-									goto l323
+									goto l718
 								}
 								position++
-							l326:
+							l721:
 								{
-									position327, tokenIndex327 := position, tokenIndex
+									position722, tokenIndex722 := position, tokenIndex
 									if c := buffer[position]; c < rune('a') || c > rune('z') {
-										goto l327
+										goto l722
 									}
 									position++
-									goto l326
-								l327:
-									position, tokenIndex = position327, tokenIndex327
+									goto l721
+								l722:
+									position, tokenIndex = position722, tokenIndex722
 								}
 								if !_rules[ruleKEY]() {
-									goto l323
+									goto l718
 								}
-								add(ruleDURATION, position325)
+								add(ruleDURATION, position720)
 							}
-							add(rulePegText, position324)
+							add(rulePegText, position719)
 						}
 						{
-							add(ruleAction26, position)
+							add(ruleAction56, position)
 						}
-						goto l296
-					l323:
-						position, tokenIndex = position296, tokenIndex296
+						goto l691
+					l718:
+						position, tokenIndex = position691, tokenIndex691
 						if !_rules[rule_]() {
-							goto l329
+							goto l724
 						}
 						{
-							position330 := position
+							position725 := position
 							if !_rules[ruleNUMBER]() {
-								goto l329
+								goto l724
 							}
-							add(rulePegText, position330)
+							add(rulePegText, position725)
 						}
 						{
-							add(ruleAction27, position)
+							add(ruleAction57, position)
 						}
-						goto l296
-					l329:
-						position, tokenIndex = position296, tokenIndex296
+						goto l691
+					l724:
+						position, tokenIndex = position691, tokenIndex691
 						if !_rules[rule_]() {
-							goto l293
+							goto l688
 						}
 						if !_rules[ruleSTRING]() {
-							goto l293
+							goto l688
 						}
 						{
-							add(ruleAction28, position)
+							add(ruleAction58, position)
 						}
 					}
-				l296:
-					add(ruleexpression_atom_raw, position295)
+				l691:
+					add(ruleexpression_atom_raw, position690)
 				}
 				if !_rules[ruleexpression_annotation]() {
-					goto l293
+					goto l688
 				}
-				add(ruleexpression_atom, position294)
+				add(ruleexpression_atom, position689)
 			}
 			return true
-		l293:
-			position, tokenIndex = position293, tokenIndex293
+		l688:
+			position, tokenIndex = position688, tokenIndex688
 			return false
 		},
-		/* 17 expression_atom_raw <- <(expression_function / expression_metric / (_ PAREN_OPEN (expression_start / &{ p.errorHere(position, `expected expression to follow "("`) }) ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "("`) })) / (_ <DURATION> Action26) / (_ <NUMBER> Action27) / (_ STRING Action28))> */
+		/* 29 expression_atom_raw <- <(expression_function / expression_metric / (_ PAREN_OPEN (expression_start / &{ p.errorHere(position, `expected expression to follow "("`) }) ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "("`) })) / (_ <DURATION> Action56) / (_ <NUMBER> Action57) / (_ STRING Action58))> */
 		nil,
-		/* 18 expression_annotation_required <- <(_ '{' <(!'}' .)*> ('}' / &{ p.errorHere(position, `expected "$CLOSEBRACE$" to close "$OPENBRACE$" opened for annotation`) }) Action29)> */
+		/* 30 expression_annotation_required <- <(_ '{' <(!'}' .)*> ('}' / &{ p.errorHere(position, `expected "$CLOSEBRACE$" to close "$OPENBRACE$" opened for annotation`) }) Action59)> */
 		nil,
-		/* 19 expression_annotation <- <expression_annotation_required?> */
+		/* 31 expression_annotation <- <expression_annotation_required?> */
 		func() bool {
 			{
-				position336 := position
+				position731 := position
 				{
-					position337, tokenIndex337 := position, tokenIndex
+					position732, tokenIndex732 := position, tokenIndex
 					{
-						position339 := position
+						position734 := position
 						if !_rules[rule_]() {
-							goto l337
+							goto l732
 						}
 						if buffer[position] != rune('{') {
-							goto l337
+							goto l732
 						}
 						position++
 						{
-							position340 := position
-						l341:
+							position735 := position
+						l736:
 							{
-								position342, tokenIndex342 := position, tokenIndex
+								position737, tokenIndex737 := position, tokenIndex
 								{
-									position343, tokenIndex343 := position, tokenIndex
+									position738, tokenIndex738 := position, tokenIndex
 									if buffer[position] != rune('}') {
-										goto l343
+										goto l738
 									}
 									position++
-									goto l342
-								l343:
-									position, tokenIndex = position343, tokenIndex343
+									goto l737
+								l738:
+									position, tokenIndex = position738, tokenIndex738
 								}
 								if !matchDot() {
-									goto l342
+									goto l737
 								}
-								goto l341
-							l342:
-								position, tokenIndex = position342, tokenIndex342
+								goto l736
+							l737:
+								position, tokenIndex = position737, tokenIndex737
 							}
-							add(rulePegText, position340)
+							add(rulePegText, position735)
 						}
 						{
-							position344, tokenIndex344 := position, tokenIndex
+							position739, tokenIndex739 := position, tokenIndex
 							if buffer[position] != rune('}') {
-								goto l345
+								goto l740
 							}
 							position++
-							goto l344
-						l345:
-							position, tokenIndex = position344, tokenIndex344
+							goto l739
+						l740:
+							position, tokenIndex = position739, tokenIndex739
 							if !(p.errorHere(position, `expected "$CLOSEBRACE$" to close "$OPENBRACE$" opened for annotation`)) {
-								goto l337
+								goto l732
 							}
 						}
-					l344:
+					l739:
 						{
-							add(ruleAction29, position)
+							add(ruleAction59, position)
 						}
-						add(ruleexpression_annotation_required, position339)
+						add(ruleexpression_annotation_required, position734)
 					}
-					goto l338
-				l337:
-					position, tokenIndex = position337, tokenIndex337
+					goto l733
+				l732:
+					position, tokenIndex = position732, tokenIndex732
 				}
-			l338:
-				add(ruleexpression_annotation, position336)
+			l733:
+				add(ruleexpression_annotation, position731)
 			}
 			return true
 		},
-		/* 20 optionalGroupBy <- <(groupByClause / collapseByClause / Action30)?> */
+		/* 32 optionalGroupBy <- <(groupByClause / collapseByClause / Action60)?> */
 		func() bool {
 			{
-				position348 := position
+				position743 := position
 				{
-					position349, tokenIndex349 := position, tokenIndex
+					position744, tokenIndex744 := position, tokenIndex
 					{
-						position351, tokenIndex351 := position, tokenIndex
+						position746, tokenIndex746 := position, tokenIndex
 						{
-							position353 := position
+							position748 := position
 							if !_rules[rule_]() {
-								goto l352
+								goto l747
 							}
 							{
-								position354, tokenIndex354 := position, tokenIndex
+								position749, tokenIndex749 := position, tokenIndex
 								if buffer[position] != rune('g') {
-									goto l355
+									goto l750
 								}
 								position++
-								goto l354
-							l355:
-								position, tokenIndex = position354, tokenIndex354
+								goto l749
+							l750:
+								position, tokenIndex = position749, tokenIndex749
 								if buffer[position] != rune('G') {
-									goto l352
+									goto l747
 								}
 								position++
 							}
-						l354:
+						l749:
 							{
-								position356, tokenIndex356 := position, tokenIndex
+								position751, tokenIndex751 := position, tokenIndex
 								if buffer[position] != rune('r') {
-									goto l357
+									goto l752
 								}
 								position++
-								goto l356
-							l357:
-								position, tokenIndex = position356, tokenIndex356
+								goto l751
+							l752:
+								position, tokenIndex = position751, tokenIndex751
 								if buffer[position] != rune('R') {
-									goto l352
+									goto l747
 								}
 								position++
 							}
-						l356:
+						l751:
 							{
-								position358, tokenIndex358 := position, tokenIndex
+								position753, tokenIndex753 := position, tokenIndex
 								if buffer[position] != rune('o') {
-									goto l359
+									goto l754
 								}
 								position++
-								goto l358
-							l359:
-								position, tokenIndex = position358, tokenIndex358
+								goto l753
+							l754:
+								position, tokenIndex = position753, tokenIndex753
 								if buffer[position] != rune('O') {
-									goto l352
+									goto l747
 								}
 								position++
 							}
-						l358:
+						l753:
 							{
-								position360, tokenIndex360 := position, tokenIndex
+								position755, tokenIndex755 := position, tokenIndex
 								if buffer[position] != rune('u') {
-									goto l361
+									goto l756
 								}
 								position++
-								goto l360
-							l361:
-								position, tokenIndex = position360, tokenIndex360
+								goto l755
+							l756:
+								position, tokenIndex = position755, tokenIndex755
 								if buffer[position] != rune('U') {
-									goto l352
+									goto l747
 								}
 								position++
 							}
-						l360:
+						l755:
 							{
-								position362, tokenIndex362 := position, tokenIndex
+								position757, tokenIndex757 := position, tokenIndex
 								if buffer[position] != rune('p') {
-									goto l363
+									goto l758
 								}
 								position++
-								goto l362
-							l363:
-								position, tokenIndex = position362, tokenIndex362
+								goto l757
+							l758:
+								position, tokenIndex = position757, tokenIndex757
 								if buffer[position] != rune('P') {
-									goto l352
+									goto l747
 								}
 								position++
 							}
-						l362:
+						l757:
 							if !_rules[ruleKEY]() {
-								goto l352
+								goto l747
 							}
 							{
-								position364, tokenIndex364 := position, tokenIndex
+								position759, tokenIndex759 := position, tokenIndex
 								if !_rules[rule_]() {
-									goto l365
+									goto l760
 								}
 								{
-									position366, tokenIndex366 := position, tokenIndex
+									position761, tokenIndex761 := position, tokenIndex
 									if buffer[position] != rune('b') {
-										goto l367
+										goto l762
 									}
 									position++
-									goto l366
-								l367:
-									position, tokenIndex = position366, tokenIndex366
+									goto l761
+								l762:
+									position, tokenIndex = position761, tokenIndex761
 									if buffer[position] != rune('B') {
-										goto l365
+										goto l760
 									}
 									position++
 								}
-							l366:
+							l761:
 								{
-									position368, tokenIndex368 := position, tokenIndex
+									position763, tokenIndex763 := position, tokenIndex
 									if buffer[position] != rune('y') {
-										goto l369
+										goto l764
 									}
 									position++
-									goto l368
-								l369:
-									position, tokenIndex = position368, tokenIndex368
+									goto l763
+								l764:
+									position, tokenIndex = position763, tokenIndex763
 									if buffer[position] != rune('Y') {
-										goto l365
+										goto l760
 									}
 									position++
 								}
-							l368:
+							l763:
 								if !_rules[ruleKEY]() {
-									goto l365
+									goto l760
 								}
-								goto l364
-							l365:
-								position, tokenIndex = position364, tokenIndex364
+								goto l759
+							l760:
+								position, tokenIndex = position759, tokenIndex759
 								if !(p.errorHere(position, `expected keyword "by" to follow keyword "group" in "group by" clause`)) {
-									goto l352
+									goto l747
 								}
 							}
-						l364:
+						l759:
 							{
-								position370, tokenIndex370 := position, tokenIndex
+								position765, tokenIndex765 := position, tokenIndex
 								if !_rules[rule_]() {
-									goto l371
+									goto l766
 								}
 								{
-									position372 := position
+									position767 := position
 									if !_rules[ruleCOLUMN_NAME]() {
-										goto l371
+										goto l766
 									}
-									add(rulePegText, position372)
+									add(rulePegText, position767)
 								}
-								goto l370
-							l371:
-								position, tokenIndex = position370, tokenIndex370
+								goto l765
+							l766:
+								position, tokenIndex = position765, tokenIndex765
 								if !(p.errorHere(position, `expected tag key identifier to follow "group by" keywords in "group by" clause`)) {
-									goto l352
+									goto l747
 								}
 							}
-						l370:
+						l765:
 							{
-								add(ruleAction36, position)
+								add(ruleAction66, position)
 							}
 							{
-								add(ruleAction37, position)
+								add(ruleAction67, position)
 							}
-						l375:
+						l770:
 							{
-								position376, tokenIndex376 := position, tokenIndex
+								position771, tokenIndex771 := position, tokenIndex
 								if !_rules[rule_]() {
-									goto l376
+									goto l771
 								}
 								if !_rules[ruleCOMMA]() {
-									goto l376
+									goto l771
 								}
 								{
-									position377, tokenIndex377 := position, tokenIndex
+									position772, tokenIndex772 := position, tokenIndex
 									if !_rules[rule_]() {
-										goto l378
+										goto l773
 									}
 									{
-										position379 := position
+										position774 := position
 										if !_rules[ruleCOLUMN_NAME]() {
-											goto l378
+											goto l773
 										}
-										add(rulePegText, position379)
+										add(rulePegText, position774)
 									}
-									goto l377
-								l378:
-									position, tokenIndex = position377, tokenIndex377
+									goto l772
+								l773:
+									position, tokenIndex = position772, tokenIndex772
 									if !(p.errorHere(position, `expected tag key identifier to follow "," in "group by" clause`)) {
-										goto l376
+										goto l771
 									}
 								}
-							l377:
+							l772:
 								{
-									add(ruleAction38, position)
+									add(ruleAction68, position)
 								}
-								goto l375
-							l376:
-								position, tokenIndex = position376, tokenIndex376
+								goto l770
+							l771:
+								position, tokenIndex = position771, tokenIndex771
 							}
-							add(rulegroupByClause, position353)
+							add(rulegroupByClause, position748)
 						}
-						goto l351
-					l352:
-						position, tokenIndex = position351, tokenIndex351
+						goto l746
+					l747:
+						position, tokenIndex = position746, tokenIndex746
 						{
-							position382 := position
+							position777 := position
 							if !_rules[rule_]() {
-								goto l381
+								goto l776
 							}
 							{
-								position383, tokenIndex383 := position, tokenIndex
+								position778, tokenIndex778 := position, tokenIndex
 								if buffer[position] != rune('c') {
-									goto l384
+									goto l779
 								}
 								position++
-								goto l383
-							l384:
-								position, tokenIndex = position383, tokenIndex383
+								goto l778
+							l779:
+								position, tokenIndex = position778, tokenIndex778
 								if buffer[position] != rune('C') {
-									goto l381
+									goto l776
 								}
 								position++
 							}
-						l383:
+						l778:
 							{
-								position385, tokenIndex385 := position, tokenIndex
+								position780, tokenIndex780 := position, tokenIndex
 								if buffer[position] != rune('o') {
-									goto l386
+									goto l781
 								}
 								position++
-								goto l385
-							l386:
-								position, tokenIndex = position385, tokenIndex385
+								goto l780
+							l781:
+								position, tokenIndex = position780, tokenIndex780
 								if buffer[position] != rune('O') {
-									goto l381
+									goto l776
 								}
 								position++
 							}
-						l385:
+						l780:
 							{
-								position387, tokenIndex387 := position, tokenIndex
+								position782, tokenIndex782 := position, tokenIndex
 								if buffer[position] != rune('l') {
-									goto l388
+									goto l783
 								}
 								position++
-								goto l387
-							l388:
-								position, tokenIndex = position387, tokenIndex387
+								goto l782
+							l783:
+								position, tokenIndex = position782, tokenIndex782
 								if buffer[position] != rune('L') {
-									goto l381
+									goto l776
 								}
 								position++
 							}
-						l387:
+						l782:
 							{
-								position389, tokenIndex389 := position, tokenIndex
+								position784, tokenIndex784 := position, tokenIndex
 								if buffer[position] != rune('l') {
-									goto l390
+									goto l785
 								}
 								position++
-								goto l389
-							l390:
-								position, tokenIndex = position389, tokenIndex389
+								goto l784
+							l785:
+								position, tokenIndex = position784, tokenIndex784
 								if buffer[position] != rune('L') {
-									goto l381
+									goto l776
 								}
 								position++
 							}
-						l389:
+						l784:
 							{
-								position391, tokenIndex391 := position, tokenIndex
+								position786, tokenIndex786 := position, tokenIndex
 								if buffer[position] != rune('a') {
-									goto l392
+									goto l787
 								}
 								position++
-								goto l391
-							l392:
-								position, tokenIndex = position391, tokenIndex391
+								goto l786
+							l787:
+								position, tokenIndex = position786, tokenIndex786
 								if buffer[position] != rune('A') {
-									goto l381
+									goto l776
 								}
 								position++
 							}
-						l391:
+						l786:
 							{
-								position393, tokenIndex393 := position, tokenIndex
+								position788, tokenIndex788 := position, tokenIndex
 								if buffer[position] != rune('p') {
-									goto l394
+									goto l789
 								}
 								position++
-								goto l393
-							l394:
-								position, tokenIndex = position393, tokenIndex393
+								goto l788
+							l789:
+								position, tokenIndex = position788, tokenIndex788
 								if buffer[position] != rune('P') {
-									goto l381
+									goto l776
 								}
 								position++
 							}
-						l393:
+						l788:
 							{
-								position395, tokenIndex395 := position, tokenIndex
+								position790, tokenIndex790 := position, tokenIndex
 								if buffer[position] != rune('s') {
-									goto l396
+									goto l791
 								}
 								position++
-								goto l395
-							l396:
-								position, tokenIndex = position395, tokenIndex395
+								goto l790
+							l791:
+								position, tokenIndex = position790, tokenIndex790
 								if buffer[position] != rune('S') {
-									goto l381
+									goto l776
 								}
 								position++
 							}
-						l395:
+						l790:
 							{
-								position397, tokenIndex397 := position, tokenIndex
+								position792, tokenIndex792 := position, tokenIndex
 								if buffer[position] != rune('e') {
-									goto l398
+									goto l793
 								}
 								position++
-								goto l397
-							l398:
-								position, tokenIndex = position397, tokenIndex397
+								goto l792
+							l793:
+								position, tokenIndex = position792, tokenIndex792
 								if buffer[position] != rune('E') {
-									goto l381
+									goto l776
 								}
 								position++
 							}
-						l397:
+						l792:
 							if !_rules[ruleKEY]() {
-								goto l381
+								goto l776
 							}
 							{
-								position399, tokenIndex399 := position, tokenIndex
+								position794, tokenIndex794 := position, tokenIndex
 								if !_rules[rule_]() {
-									goto l400
+									goto l795
 								}
 								{
-									position401, tokenIndex401 := position, tokenIndex
+									position796, tokenIndex796 := position, tokenIndex
 									if buffer[position] != rune('b') {
-										goto l402
+										goto l797
 									}
 									position++
-									goto l401
-								l402:
-									position, tokenIndex = position401, tokenIndex401
+									goto l796
+								l797:
+									position, tokenIndex = position796, tokenIndex796
 									if buffer[position] != rune('B') {
-										goto l400
+										goto l795
 									}
 									position++
 								}
-							l401:
+							l796:
 								{
-									position403, tokenIndex403 := position, tokenIndex
+									position798, tokenIndex798 := position, tokenIndex
 									if buffer[position] != rune('y') {
-										goto l404
+										goto l799
 									}
 									position++
-									goto l403
-								l404:
-									position, tokenIndex = position403, tokenIndex403
+									goto l798
+								l799:
+									position, tokenIndex = position798, tokenIndex798
 									if buffer[position] != rune('Y') {
-										goto l400
+										goto l795
 									}
 									position++
 								}
-							l403:
+							l798:
 								if !_rules[ruleKEY]() {
-									goto l400
+									goto l795
 								}
-								goto l399
-							l400:
-								position, tokenIndex = position399, tokenIndex399
+								goto l794
+							l795:
+								position, tokenIndex = position794, tokenIndex794
 								if !(p.errorHere(position, `expected keyword "by" to follow keyword "collapse" in "collapse by" clause`)) {
-									goto l381
+									goto l776
 								}
 							}
-						l399:
+						l794:
 							{
-								position405, tokenIndex405 := position, tokenIndex
+								position800, tokenIndex800 := position, tokenIndex
 								if !_rules[rule_]() {
-									goto l406
+									goto l801
 								}
 								{
-									position407 := position
+									position802 := position
 									if !_rules[ruleCOLUMN_NAME]() {
-										goto l406
+										goto l801
 									}
-									add(rulePegText, position407)
+									add(rulePegText, position802)
 								}
-								goto l405
-							l406:
-								position, tokenIndex = position405, tokenIndex405
+								goto l800
+							l801:
+								position, tokenIndex = position800, tokenIndex800
 								if !(p.errorHere(position, `expected tag key identifier to follow "collapse by" keywords in "collapse by" clause`)) {
-									goto l381
+									goto l776
 								}
 							}
-						l405:
+						l800:
 							{
-								add(ruleAction39, position)
+								add(ruleAction69, position)
 							}
 							{
-								add(ruleAction40, position)
+								add(ruleAction70, position)
 							}
-						l410:
+						l805:
 							{
-								position411, tokenIndex411 := position, tokenIndex
+								position806, tokenIndex806 := position, tokenIndex
 								if !_rules[rule_]() {
-									goto l411
+									goto l806
 								}
 								if !_rules[ruleCOMMA]() {
-									goto l411
+									goto l806
 								}
 								{
-									position412, tokenIndex412 := position, tokenIndex
+									position807, tokenIndex807 := position, tokenIndex
 									if !_rules[rule_]() {
-										goto l413
+										goto l808
 									}
 									{
-										position414 := position
+										position809 := position
 										if !_rules[ruleCOLUMN_NAME]() {
-											goto l413
+											goto l808
 										}
-										add(rulePegText, position414)
+										add(rulePegText, position809)
 									}
-									goto l412
-								l413:
-									position, tokenIndex = position412, tokenIndex412
+									goto l807
+								l808:
+									position, tokenIndex = position807, tokenIndex807
 									if !(p.errorHere(position, `expected tag key identifier to follow "," in "collapse by" clause`)) {
-										goto l411
+										goto l806
 									}
 								}
-							l412:
+							l807:
 								{
-									add(ruleAction41, position)
+									add(ruleAction71, position)
 								}
-								goto l410
-							l411:
-								position, tokenIndex = position411, tokenIndex411
+								goto l805
+							l806:
+								position, tokenIndex = position806, tokenIndex806
 							}
-							add(rulecollapseByClause, position382)
+							add(rulecollapseByClause, position777)
 						}
-						goto l351
-					l381:
-						position, tokenIndex = position351, tokenIndex351
+						goto l746
+					l776:
+						position, tokenIndex = position746, tokenIndex746
 						{
-							add(ruleAction30, position)
+							add(ruleAction60, position)
 						}
 					}
-				l351:
-					goto l350
+				l746:
+					goto l745
 
-					position, tokenIndex = position349, tokenIndex349
+					position, tokenIndex = position744, tokenIndex744
 				}
-			l350:
-				add(ruleoptionalGroupBy, position348)
+			l745:
+				add(ruleoptionalGroupBy, position743)
 			}
 			return true
 		},
-		/* 21 expression_function <- <(_ <IDENTIFIER> Action31 _ PAREN_OPEN (expressionList / &{ p.errorHere(position, `expected expression list to follow "(" in function call`) }) optionalGroupBy ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" opened by function call`) }) Action32)> */
+		/* 33 expression_function <- <(_ <IDENTIFIER> Action61 _ PAREN_OPEN (expressionList / &{ p.errorHere(position, `expected expression list to follow "(" in function call`) }) optionalGroupBy ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" opened by function call`) }) Action62)> */
 		nil,
-		/* 22 expression_metric <- <(_ <IDENTIFIER> Action33 ((_ '[' (predicate_1 / &{ p.errorHere(position, `expected predicate to follow "[" after metric`) }) ((_ ']') / &{ p.errorHere(position, `expected "]" to close "[" opened to apply predicate`) })) / Action34) Action35)> */
+		/* 34 expression_metric <- <(_ <IDENTIFIER> Action63 ((_ '[' (predicate_1 / &{ p.errorHere(position, `expected predicate to follow "[" after metric`) }) ((_ ']') / &{ p.errorHere(position, `expected "]" to close "[" opened to apply predicate`) })) / Action64) Action65)> */
 		nil,
-		/* 23 groupByClause <- <(_ (('g' / 'G') ('r' / 'R') ('o' / 'O') ('u' / 'U') ('p' / 'P')) KEY ((_ (('b' / 'B') ('y' / 'Y')) KEY) / &{ p.errorHere(position, `expected keyword "by" to follow keyword "group" in "group by" clause`) }) ((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "group by" keywords in "group by" clause`) }) Action36 Action37 (_ COMMA ((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "," in "group by" clause`) }) Action38)*)> */
+		/* 35 groupByClause <- <(_ (('g' / 'G') ('r' / 'R') ('o' / 'O') ('u' / 'U') ('p' / 'P')) KEY ((_ (('b' / 'B') ('y' / 'Y')) KEY) / &{ p.errorHere(position, `expected keyword "by" to follow keyword "group" in "group by" clause`) }) ((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "group by" keywords in "group by" clause`) }) Action66 Action67 (_ COMMA ((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "," in "group by" clause`) }) Action68)*)> */
 		nil,
-		/* 24 collapseByClause <- <(_ (('c' / 'C') ('o' / 'O') ('l' / 'L') ('l' / 'L') ('a' / 'A') ('p' / 'P') ('s' / 'S') ('e' / 'E')) KEY ((_ (('b' / 'B') ('y' / 'Y')) KEY) / &{ p.errorHere(position, `expected keyword "by" to follow keyword "collapse" in "collapse by" clause`) }) ((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "collapse by" keywords in "collapse by" clause`) }) Action39 Action40 (_ COMMA ((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "," in "collapse by" clause`) }) Action41)*)> */
+		/* 36 collapseByClause <- <(_ (('c' / 'C') ('o' / 'O') ('l' / 'L') ('l' / 'L') ('a' / 'A') ('p' / 'P') ('s' / 'S') ('e' / 'E')) KEY ((_ (('b' / 'B') ('y' / 'Y')) KEY) / &{ p.errorHere(position, `expected keyword "by" to follow keyword "collapse" in "collapse by" clause`) }) ((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "collapse by" keywords in "collapse by" clause`) }) Action69 Action70 (_ COMMA ((_ <COLUMN_NAME>) / &{ p.errorHere(position, `expected tag key identifier to follow "," in "collapse by" clause`) }) Action71)*)> */
 		nil,
-		/* 25 predicateClause <- <(_ (('w' / 'W') ('h' / 'H') ('e' / 'E') ('r' / 'R') ('e' / 'E')) KEY ((_ predicate_1) / &{ p.errorHere(position, `expected predicate to follow "where" keyword`) }))> */
+		/* 37 predicateClause <- <(_ (('w' / 'W') ('h' / 'H') ('e' / 'E') ('r' / 'R') ('e' / 'E')) KEY ((_ predicate_1) / &{ p.errorHere(position, `expected predicate to follow "where" keyword`) }))> */
 		nil,
-		/* 26 predicate_1 <- <((predicate_2 _ OP_OR (predicate_1 / &{ p.errorHere(position, `expected predicate to follow "or" operator`) }) Action42) / predicate_2)> */
+		/* 38 predicate_1 <- <((predicate_2 _ OP_OR (predicate_1 / &{ p.errorHere(position, `expected predicate to follow "or" operator`) }) Action72) / predicate_2)> */
 		func() bool {
-			position422, tokenIndex422 := position, tokenIndex
+			position817, tokenIndex817 := position, tokenIndex
 			{
-				position423 := position
+				position818 := position
 				{
-					position424, tokenIndex424 := position, tokenIndex
+					position819, tokenIndex819 := position, tokenIndex
 					if !_rules[rulepredicate_2]() {
-						goto l425
+						goto l820
 					}
 					if !_rules[rule_]() {
-						goto l425
-					}
-					{
-						position426 := position
-						{
-							position427, tokenIndex427 := position, tokenIndex
-							if buffer[position] != rune('o') {
-								goto l428
-							}
-							position++
-							goto l427
-						l428:
-							position, tokenIndex = position427, tokenIndex427
-							if buffer[position] != rune('O') {
-								goto l425
-							}
-							position++
-						}
-					l427:
-						{
-							position429, tokenIndex429 := position, tokenIndex
-							if buffer[position] != rune('r') {
-								goto l430
-							}
-							position++
-							goto l429
-						l430:
-							position, tokenIndex = position429, tokenIndex429
-							if buffer[position] != rune('R') {
-								goto l425
-							}
-							position++
-						}
-					l429:
-						if !_rules[ruleKEY]() {
-							goto l425
-						}
-						add(ruleOP_OR, position426)
-					}
-					{
-						position431, tokenIndex431 := position, tokenIndex
-						if !_rules[rulepredicate_1]() {
-							goto l432
-						}
-						goto l431
-					l432:
-						position, tokenIndex = position431, tokenIndex431
-						if !(p.errorHere(position, `expected predicate to follow "or" operator`)) {
-							goto l425
-						}
-					}
-				l431:
-					{
-						add(ruleAction42, position)
-					}
-					goto l424
-				l425:
-					position, tokenIndex = position424, tokenIndex424
-					if !_rules[rulepredicate_2]() {
-						goto l422
-					}
-				}
-			l424:
-				add(rulepredicate_1, position423)
-			}
-			return true
-		l422:
-			position, tokenIndex = position422, tokenIndex422
-			return false
-		},
-		/* 27 predicate_2 <- <((predicate_3 _ OP_AND (predicate_2 / &{ p.errorHere(position, `expected predicate to follow "and" operator`) }) Action43) / predicate_3)> */
-		func() bool {
-			position434, tokenIndex434 := position, tokenIndex
-			{
-				position435 := position
-				{
-					position436, tokenIndex436 := position, tokenIndex
-					if !_rules[rulepredicate_3]() {
-						goto l437
+						goto l820
 					}
-					if !_rules[rule_]() {
-						goto l437
+					if !_rules[ruleOP_OR]() {
+						goto l820
 					}
 					{
-						position438 := position
-						{
-							position439, tokenIndex439 := position, tokenIndex
-							if buffer[position] != rune('a') {
-								goto l440
-							}
-							position++
-							goto l439
-						l440:
-							position, tokenIndex = position439, tokenIndex439
-							if buffer[position] != rune('A') {
-								goto l437
-							}
-							position++
-						}
-					l439:
-						{
-							position441, tokenIndex441 := position, tokenIndex
-							if buffer[position] != rune('n') {
-								goto l442
-							}
-							position++
-							goto l441
-						l442:
-							position, tokenIndex = position441, tokenIndex441
-							if buffer[position] != rune('N') {
-								goto l437
-							}
-							position++
-						}
-					l441:
-						{
-							position443, tokenIndex443 := position, tokenIndex
-							if buffer[position] != rune('d') {
-								goto l444
-							}
-							position++
-							goto l443
-						l444:
-							position, tokenIndex = position443, tokenIndex443
-							if buffer[position] != rune('D') {
-								goto l437
-							}
-							position++
+						position821, tokenIndex821 := position, tokenIndex
+						if !_rules[rulepredicate_1]() {
+							goto l822
 						}
-					l443:
-						if !_rules[ruleKEY]() {
-							goto l437
+						goto l821
+					l822:
+						position, tokenIndex = position821, tokenIndex821
+						if !(p.errorHere(position, `expected predicate to follow "or" operator`)) {
+							goto l820
 						}
-						add(ruleOP_AND, position438)
+					}
+				l821:
+					{
+						add(ruleAction72, position)
+					}
+					goto l819
+				l820:
+					position, tokenIndex = position819, tokenIndex819
+					if !_rules[rulepredicate_2]() {
+						goto l817
+					}
+				}
+			l819:
+				add(rulepredicate_1, position818)
+			}
+			return true
+		l817:
+			position, tokenIndex = position817, tokenIndex817
+			return false
+		},
+		/* 39 predicate_2 <- <((predicate_3 _ OP_AND (predicate_2 / &{ p.errorHere(position, `expected predicate to follow "and" operator`) }) Action73) / predicate_3)> */
+		func() bool {
+			position824, tokenIndex824 := position, tokenIndex
+			{
+				position825 := position
+				{
+					position826, tokenIndex826 := position, tokenIndex
+					if !_rules[rulepredicate_3]() {
+						goto l827
+					}
+					if !_rules[rule_]() {
+						goto l827
+					}
+					if !_rules[ruleOP_AND]() {
+						goto l827
 					}
 					{
-						position445, tokenIndex445 := position, tokenIndex
+						position828, tokenIndex828 := position, tokenIndex
 						if !_rules[rulepredicate_2]() {
-							goto l446
+							goto l829
 						}
-						goto l445
-					l446:
-						position, tokenIndex = position445, tokenIndex445
+						goto l828
+					l829:
+						position, tokenIndex = position828, tokenIndex828
 						if !(p.errorHere(position, `expected predicate to follow "and" operator`)) {
-							goto l437
+							goto l827
 						}
 					}
-				l445:
+				l828:
 					{
-						add(ruleAction43, position)
+						add(ruleAction73, position)
 					}
-					goto l436
-				l437:
-					position, tokenIndex = position436, tokenIndex436
+					goto l826
+				l827:
+					position, tokenIndex = position826, tokenIndex826
 					if !_rules[rulepredicate_3]() {
-						goto l434
+						goto l824
 					}
 				}
-			l436:
-				add(rulepredicate_2, position435)
+			l826:
+				add(rulepredicate_2, position825)
 			}
 			return true
-		l434:
-			position, tokenIndex = position434, tokenIndex434
+		l824:
+			position, tokenIndex = position824, tokenIndex824
 			return false
 		},
-		/* 28 predicate_3 <- <((_ OP_NOT (predicate_3 / &{ p.errorHere(position, `expected predicate to follow "not" operator`) }) Action44) / (_ PAREN_OPEN (predicate_1 / &{ p.errorHere(position, `expected predicate to follow "("`) }) ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" opened in predicate`) })) / tagMatcher)> */
+		/* 40 predicate_3 <- <((_ OP_NOT (predicate_3 / &{ p.errorHere(position, `expected predicate to follow "not" operator`) }) Action74) / (_ PAREN_OPEN (predicate_1 / &{ p.errorHere(position, `expected predicate to follow "("`) }) ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" opened in predicate`) })) / tagMatcher)> */
 		func() bool {
-			position448, tokenIndex448 := position, tokenIndex
+			position831, tokenIndex831 := position, tokenIndex
 			{
-				position449 := position
+				position832 := position
 				{
-					position450, tokenIndex450 := position, tokenIndex
+					position833, tokenIndex833 := position, tokenIndex
 					if !_rules[rule_]() {
-						goto l451
+						goto l834
 					}
 					{
-						position452 := position
+						position835 := position
 						{
-							position453, tokenIndex453 := position, tokenIndex
+							position836, tokenIndex836 := position, tokenIndex
 							if buffer[position] != rune('n') {
-								goto l454
+								goto l837
 							}
 							position++
-							goto l453
-						l454:
-							position, tokenIndex = position453, tokenIndex453
+							goto l836
+						l837:
+							position, tokenIndex = position836, tokenIndex836
 							if buffer[position] != rune('N') {
-								goto l451
+								goto l834
 							}
 							position++
 						}
-					l453:
+					l836:
 						{
-							position455, tokenIndex455 := position, tokenIndex
+							position838, tokenIndex838 := position, tokenIndex
 							if buffer[position] != rune('o') {
-								goto l456
+								goto l839
 							}
 							position++
-							goto l455
-						l456:
-							position, tokenIndex = position455, tokenIndex455
+							goto l838
+						l839:
+							position, tokenIndex = position838, tokenIndex838
 							if buffer[position] != rune('O') {
-								goto l451
+								goto l834
 							}
 							position++
 						}
-					l455:
+					l838:
 						{
-							position457, tokenIndex457 := position, tokenIndex
+							position840, tokenIndex840 := position, tokenIndex
 							if buffer[position] != rune('t') {
-								goto l458
+								goto l841
 							}
 							position++
-							goto l457
-						l458:
-							position, tokenIndex = position457, tokenIndex457
+							goto l840
+						l841:
+							position, tokenIndex = position840, tokenIndex840
 							if buffer[position] != rune('T') {
-								goto l451
+								goto l834
 							}
 							position++
 						}
-					l457:
+					l840:
 						if !_rules[ruleKEY]() {
-							goto l451
+							goto l834
 						}
-						add(ruleOP_NOT, position452)
+						add(ruleOP_NOT, position835)
 					}
 					{
-						position459, tokenIndex459 := position, tokenIndex
+						position842, tokenIndex842 := position, tokenIndex
 						if !_rules[rulepredicate_3]() {
-							goto l460
+							goto l843
 						}
-						goto l459
-					l460:
-						position, tokenIndex = position459, tokenIndex459
+						goto l842
+					l843:
+						position, tokenIndex = position842, tokenIndex842
 						if !(p.errorHere(position, `expected predicate to follow "not" operator`)) {
-							goto l451
+							goto l834
 						}
 					}
-				l459:
+				l842:
 					{
-						add(ruleAction44, position)
+						add(ruleAction74, position)
 					}
-					goto l450
-				l451:
-					position, tokenIndex = position450, tokenIndex450
+					goto l833
+				l834:
+					position, tokenIndex = position833, tokenIndex833
 					if !_rules[rule_]() {
-						goto l462
+						goto l845
 					}
 					if !_rules[rulePAREN_OPEN]() {
-						goto l462
+						goto l845
 					}
 					{
-						position463, tokenIndex463 := position, tokenIndex
+						position846, tokenIndex846 := position, tokenIndex
 						if !_rules[rulepredicate_1]() {
-							goto l464
+							goto l847
 						}
-						goto l463
-					l464:
-						position, tokenIndex = position463, tokenIndex463
+						goto l846
+					l847:
+						position, tokenIndex = position846, tokenIndex846
 						if !(p.errorHere(position, `expected predicate to follow "("`)) {
-							goto l462
+							goto l845
 						}
 					}
-				l463:
+				l846:
 					{
-						position465, tokenIndex465 := position, tokenIndex
+						position848, tokenIndex848 := position, tokenIndex
 						if !_rules[rule_]() {
-							goto l466
+							goto l849
 						}
 						if !_rules[rulePAREN_CLOSE]() {
-							goto l466
+							goto l849
 						}
-						goto l465
-					l466:
-						position, tokenIndex = position465, tokenIndex465
+						goto l848
+					l849:
+						position, tokenIndex = position848, tokenIndex848
 						if !(p.errorHere(position, `expected ")" to close "(" opened in predicate`)) {
-							goto l462
+							goto l845
 						}
 					}
-				l465:
-					goto l450
-				l462:
-					position, tokenIndex = position450, tokenIndex450
+				l848:
+					goto l833
+				l845:
+					position, tokenIndex = position833, tokenIndex833
 					{
-						position467 := position
+						position850 := position
 						if !_rules[ruletagName]() {
-							goto l448
+							goto l831
 						}
 						{
-							position468, tokenIndex468 := position, tokenIndex
+							position851, tokenIndex851 := position, tokenIndex
 							if !_rules[rule_]() {
-								goto l469
+								goto l852
 							}
 							if buffer[position] != rune('=') {
-								goto l469
+								goto l852
 							}
 							position++
 							{
-								position470, tokenIndex470 := position, tokenIndex
+								position853, tokenIndex853 := position, tokenIndex
 								if !_rules[ruleliteralString]() {
-									goto l471
+									goto l854
 								}
-								goto l470
-							l471:
-								position, tokenIndex = position470, tokenIndex470
+								goto l853
+							l854:
+								position, tokenIndex = position853, tokenIndex853
 								if !(p.errorHere(position, `expected string literal to follow "="`)) {
-									goto l469
+									goto l852
 								}
 							}
-						l470:
+						l853:
 							{
-								add(ruleAction45, position)
+								add(ruleAction75, position)
 							}
-							goto l468
-						l469:
-							position, tokenIndex = position468, tokenIndex468
+							goto l851
+						l852:
+							position, tokenIndex = position851, tokenIndex851
 							if !_rules[rule_]() {
-								goto l473
+								goto l856
 							}
 							if buffer[position] != rune('!') {
-								goto l473
+								goto l856
 							}
 							position++
 							if buffer[position] != rune('=') {
-								goto l473
+								goto l856
 							}
 							position++
 							{
-								position474, tokenIndex474 := position, tokenIndex
+								position857, tokenIndex857 := position, tokenIndex
 								if !_rules[ruleliteralString]() {
-									goto l475
+									goto l858
 								}
-								goto l474
-							l475:
-								position, tokenIndex = position474, tokenIndex474
+								goto l857
+							l858:
+								position, tokenIndex = position857, tokenIndex857
 								if !(p.errorHere(position, `expected string literal to follow "!="`)) {
-									goto l473
+									goto l856
 								}
 							}
-						l474:
+						l857:
 							{
-								add(ruleAction46, position)
+								add(ruleAction76, position)
 							}
 							{
-								add(ruleAction47, position)
+								add(ruleAction77, position)
 							}
-							goto l468
-						l473:
-							position, tokenIndex = position468, tokenIndex468
+							goto l851
+						l856:
+							position, tokenIndex = position851, tokenIndex851
 							if !_rules[rule_]() {
-								goto l478
+								goto l861
 							}
 							{
-								position479, tokenIndex479 := position, tokenIndex
+								position862, tokenIndex862 := position, tokenIndex
 								if buffer[position] != rune('m') {
-									goto l480
+									goto l863
 								}
 								position++
-								goto l479
-							l480:
-								position, tokenIndex = position479, tokenIndex479
+								goto l862
+							l863:
+								position, tokenIndex = position862, tokenIndex862
 								if buffer[position] != rune('M') {
-									goto l478
+									goto l861
 								}
 								position++
 							}
-						l479:
+						l862:
 							{
-								position481, tokenIndex481 := position, tokenIndex
+								position864, tokenIndex864 := position, tokenIndex
 								if buffer[position] != rune('a') {
-									goto l482
+									goto l865
 								}
 								position++
-								goto l481
-							l482:
-								position, tokenIndex = position481, tokenIndex481
+								goto l864
+							l865:
+								position, tokenIndex = position864, tokenIndex864
 								if buffer[position] != rune('A') {
-									goto l478
+									goto l861
 								}
 								position++
 							}
-						l481:
+						l864:
 							{
-								position483, tokenIndex483 := position, tokenIndex
+								position866, tokenIndex866 := position, tokenIndex
 								if buffer[position] != rune('t') {
-									goto l484
+									goto l867
 								}
 								position++
-								goto l483
-							l484:
-								position, tokenIndex = position483, tokenIndex483
+								goto l866
+							l867:
+								position, tokenIndex = position866, tokenIndex866
 								if buffer[position] != rune('T') {
-									goto l478
+									goto l861
 								}
 								position++
 							}
-						l483:
+						l866:
 							{
-								position485, tokenIndex485 := position, tokenIndex
+								position868, tokenIndex868 := position, tokenIndex
 								if buffer[position] != rune('c') {
-									goto l486
+									goto l869
 								}
 								position++
-								goto l485
-							l486:
-								position, tokenIndex = position485, tokenIndex485
+								goto l868
+							l869:
+								position, tokenIndex = position868, tokenIndex868
 								if buffer[position] != rune('C') {
-									goto l478
+									goto l861
 								}
 								position++
 							}
-						l485:
+						l868:
 							{
-								position487, tokenIndex487 := position, tokenIndex
+								position870, tokenIndex870 := position, tokenIndex
 								if buffer[position] != rune('h') {
-									goto l488
+									goto l871
 								}
 								position++
-								goto l487
-							l488:
-								position, tokenIndex = position487, tokenIndex487
+								goto l870
+							l871:
+								position, tokenIndex = position870, tokenIndex870
 								if buffer[position] != rune('H') {
-									goto l478
+									goto l861
 								}
 								position++
 							}
-						l487:
+						l870:
 							if !_rules[ruleKEY]() {
-								goto l478
+								goto l861
 							}
 							{
-								position489, tokenIndex489 := position, tokenIndex
+								position872, tokenIndex872 := position, tokenIndex
 								if !_rules[ruleliteralString]() {
-									goto l490
+									goto l873
 								}
-								goto l489
-							l490:
-								position, tokenIndex = position489, tokenIndex489
+								goto l872
+							l873:
+								position, tokenIndex = position872, tokenIndex872
 								if !(p.errorHere(position, `expected regex string literal to follow "match"`)) {
-									goto l478
+									goto l861
 								}
 							}
-						l489:
+						l872:
 							{
-								add(ruleAction48, position)
+								add(ruleAction78, position)
 							}
-							goto l468
-						l478:
-							position, tokenIndex = position468, tokenIndex468
+							goto l851
+						l861:
+							position, tokenIndex = position851, tokenIndex851
 							if !_rules[rule_]() {
-								goto l492
+								goto l875
 							}
 							{
-								position493, tokenIndex493 := position, tokenIndex
+								position876, tokenIndex876 := position, tokenIndex
 								if buffer[position] != rune('i') {
-									goto l494
+									goto l877
 								}
 								position++
-								goto l493
-							l494:
-								position, tokenIndex = position493, tokenIndex493
+								goto l876
+							l877:
+								position, tokenIndex = position876, tokenIndex876
 								if buffer[position] != rune('I') {
-									goto l492
+									goto l875
 								}
 								position++
 							}
-						l493:
+						l876:
 							{
-								position495, tokenIndex495 := position, tokenIndex
+								position878, tokenIndex878 := position, tokenIndex
 								if buffer[position] != rune('n') {
-									goto l496
+									goto l879
 								}
 								position++
-								goto l495
-							l496:
-								position, tokenIndex = position495, tokenIndex495
+								goto l878
+							l879:
+								position, tokenIndex = position878, tokenIndex878
 								if buffer[position] != rune('N') {
-									goto l492
+									goto l875
 								}
 								position++
 							}
-						l495:
+						l878:
 							if !_rules[ruleKEY]() {
-								goto l492
+								goto l875
+							}
+							if !_rules[rule_]() {
+								goto l875
+							}
+							{
+								position880, tokenIndex880 := position, tokenIndex
+								if buffer[position] != rune('c') {
+									goto l881
+								}
+								position++
+								goto l880
+							l881:
+								position, tokenIndex = position880, tokenIndex880
+								if buffer[position] != rune('C') {
+									goto l875
+								}
+								position++
+							}
+						l880:
+							{
+								position882, tokenIndex882 := position, tokenIndex
+								if buffer[position] != rune('i') {
+									goto l883
+								}
+								position++
+								goto l882
+							l883:
+								position, tokenIndex = position882, tokenIndex882
+								if buffer[position] != rune('I') {
+									goto l875
+								}
+								position++
 							}
+						l882:
 							{
-								position497, tokenIndex497 := position, tokenIndex
+								position884, tokenIndex884 := position, tokenIndex
+								if buffer[position] != rune('d') {
+									goto l885
+								}
+								position++
+								goto l884
+							l885:
+								position, tokenIndex = position884, tokenIndex884
+								if buffer[position] != rune('D') {
+									goto l875
+								}
+								position++
+							}
+						l884:
+							{
+								position886, tokenIndex886 := position, tokenIndex
+								if buffer[position] != rune('r') {
+									goto l887
+								}
+								position++
+								goto l886
+							l887:
+								position, tokenIndex = position886, tokenIndex886
+								if buffer[position] != rune('R') {
+									goto l875
+								}
+								position++
+							}
+						l886:
+							if !_rules[ruleKEY]() {
+								goto l875
+							}
+							{
+								position888, tokenIndex888 := position, tokenIndex
+								if !_rules[ruleliteralString]() {
+									goto l889
+								}
 								{
-									position499 := position
-									{
-										add(ruleAction51, position)
-									}
-									if !_rules[rule_]() {
-										goto l498
-									}
-									if !_rules[rulePAREN_OPEN]() {
-										goto l498
-									}
-									{
-										position501, tokenIndex501 := position, tokenIndex
-										if !_rules[ruleliteralListString]() {
-											goto l502
-										}
-										goto l501
-									l502:
-										position, tokenIndex = position501, tokenIndex501
-										if !(p.errorHere(position, `expected string literal to follow "(" in literal list`)) {
-											goto l498
-										}
-									}
-								l501:
-								l503:
-									{
-										position504, tokenIndex504 := position, tokenIndex
-										if !_rules[rule_]() {
-											goto l504
-										}
-										if !_rules[ruleCOMMA]() {
-											goto l504
-										}
-										{
-											position505, tokenIndex505 := position, tokenIndex
-											if !_rules[ruleliteralListString]() {
-												goto l506
-											}
-											goto l505
-										l506:
-											position, tokenIndex = position505, tokenIndex505
-											if !(p.errorHere(position, `expected string literal to follow "," in literal list`)) {
-												goto l504
-											}
-										}
-									l505:
-										goto l503
-									l504:
-										position, tokenIndex = position504, tokenIndex504
-									}
-									{
-										position507, tokenIndex507 := position, tokenIndex
-										if !_rules[rule_]() {
-											goto l508
-										}
-										if !_rules[rulePAREN_CLOSE]() {
-											goto l508
-										}
-										goto l507
-									l508:
-										position, tokenIndex = position507, tokenIndex507
-										if !(p.errorHere(position, `expected ")" to close "(" for literal list`)) {
-											goto l498
-										}
-									}
-								l507:
-									add(ruleliteralList, position499)
+									add(ruleAction79, position)
+								}
+								goto l888
+							l889:
+								position, tokenIndex = position888, tokenIndex888
+								if !_rules[ruleliteralList]() {
+									goto l891
+								}
+								{
+									add(ruleAction80, position)
+								}
+								goto l888
+							l891:
+								position, tokenIndex = position888, tokenIndex888
+								if !(p.errorHere(position, `expected CIDR string literal or list to follow "in cidr"`)) {
+									goto l875
+								}
+							}
+						l888:
+							goto l851
+						l875:
+							position, tokenIndex = position851, tokenIndex851
+							if !_rules[rule_]() {
+								goto l893
+							}
+							{
+								position894, tokenIndex894 := position, tokenIndex
+								if buffer[position] != rune('i') {
+									goto l895
+								}
+								position++
+								goto l894
+							l895:
+								position, tokenIndex = position894, tokenIndex894
+								if buffer[position] != rune('I') {
+									goto l893
+								}
+								position++
+							}
+						l894:
+							{
+								position896, tokenIndex896 := position, tokenIndex
+								if buffer[position] != rune('n') {
+									goto l897
+								}
+								position++
+								goto l896
+							l897:
+								position, tokenIndex = position896, tokenIndex896
+								if buffer[position] != rune('N') {
+									goto l893
+								}
+								position++
+							}
+						l896:
+							if !_rules[ruleKEY]() {
+								goto l893
+							}
+							if !_rules[rule_]() {
+								goto l893
+							}
+							{
+								position898, tokenIndex898 := position, tokenIndex
+								if buffer[position] != rune('g') {
+									goto l899
+								}
+								position++
+								goto l898
+							l899:
+								position, tokenIndex = position898, tokenIndex898
+								if buffer[position] != rune('G') {
+									goto l893
+								}
+								position++
+							}
+						l898:
+							{
+								position900, tokenIndex900 := position, tokenIndex
+								if buffer[position] != rune('l') {
+									goto l901
+								}
+								position++
+								goto l900
+							l901:
+								position, tokenIndex = position900, tokenIndex900
+								if buffer[position] != rune('L') {
+									goto l893
+								}
+								position++
+							}
+						l900:
+							{
+								position902, tokenIndex902 := position, tokenIndex
+								if buffer[position] != rune('o') {
+									goto l903
+								}
+								position++
+								goto l902
+							l903:
+								position, tokenIndex = position902, tokenIndex902
+								if buffer[position] != rune('O') {
+									goto l893
+								}
+								position++
+							}
+						l902:
+							{
+								position904, tokenIndex904 := position, tokenIndex
+								if buffer[position] != rune('b') {
+									goto l905
+								}
+								position++
+								goto l904
+							l905:
+								position, tokenIndex = position904, tokenIndex904
+								if buffer[position] != rune('B') {
+									goto l893
+								}
+								position++
+							}
+						l904:
+							if !_rules[ruleKEY]() {
+								goto l893
+							}
+							{
+								position906, tokenIndex906 := position, tokenIndex
+								if !_rules[ruleliteralString]() {
+									goto l907
+								}
+								{
+									add(ruleAction81, position)
+								}
+								goto l906
+							l907:
+								position, tokenIndex = position906, tokenIndex906
+								if !_rules[ruleliteralList]() {
+									goto l909
+								}
+								{
+									add(ruleAction82, position)
+								}
+								goto l906
+							l909:
+								position, tokenIndex = position906, tokenIndex906
+								if !(p.errorHere(position, `expected glob string literal or list to follow "in glob"`)) {
+									goto l893
+								}
+							}
+						l906:
+							goto l851
+						l893:
+							position, tokenIndex = position851, tokenIndex851
+							if !_rules[rule_]() {
+								goto l911
+							}
+							{
+								position912, tokenIndex912 := position, tokenIndex
+								if buffer[position] != rune('i') {
+									goto l913
+								}
+								position++
+								goto l912
+							l913:
+								position, tokenIndex = position912, tokenIndex912
+								if buffer[position] != rune('I') {
+									goto l911
+								}
+								position++
+							}
+						l912:
+							{
+								position914, tokenIndex914 := position, tokenIndex
+								if buffer[position] != rune('n') {
+									goto l915
+								}
+								position++
+								goto l914
+							l915:
+								position, tokenIndex = position914, tokenIndex914
+								if buffer[position] != rune('N') {
+									goto l911
+								}
+								position++
+							}
+						l914:
+							if !_rules[ruleKEY]() {
+								goto l911
+							}
+							{
+								position916, tokenIndex916 := position, tokenIndex
+								if !_rules[ruleliteralList]() {
+									goto l917
 								}
-								goto l497
-							l498:
-								position, tokenIndex = position497, tokenIndex497
+								goto l916
+							l917:
+								position, tokenIndex = position916, tokenIndex916
 								if !(p.errorHere(position, `expected string literal list to follow "in" keyword`)) {
-									goto l492
+									goto l911
 								}
 							}
-						l497:
+						l916:
 							{
-								add(ruleAction49, position)
+								add(ruleAction83, position)
 							}
-							goto l468
-						l492:
-							position, tokenIndex = position468, tokenIndex468
-							if !(p.errorHere(position, `expected "=", "!=", "match", or "in" to follow tag key in predicate`)) {
-								goto l448
+							goto l851
+						l911:
+							position, tokenIndex = position851, tokenIndex851
+							if !(p.errorHere(position, `expected "=", "!=", "match", "in", "in cidr" or "in glob" to follow tag key in predicate`)) {
+								goto l831
 							}
 						}
-					l468:
-						add(ruletagMatcher, position467)
+					l851:
+						add(ruletagMatcher, position850)
 					}
 				}
-			l450:
-				add(rulepredicate_3, position449)
+			l833:
+				add(rulepredicate_3, position832)
 			}
 			return true
-		l448:
-			position, tokenIndex = position448, tokenIndex448
+		l831:
+			position, tokenIndex = position831, tokenIndex831
 			return false
 		},
-		/* 29 tagMatcher <- <(tagName ((_ '=' (literalString / &{ p.errorHere(position, `expected string literal to follow "="`) }) Action45) / (_ ('!' '=') (literalString / &{ p.errorHere(position, `expected string literal to follow "!="`) }) Action46 Action47) / (_ (('m' / 'M') ('a' / 'A') ('t' / 'T') ('c' / 'C') ('h' / 'H')) KEY (literalString / &{ p.errorHere(position, `expected regex string literal to follow "match"`) }) Action48) / (_ (('i' / 'I') ('n' / 'N')) KEY (literalList / &{ p.errorHere(position, `expected string literal list to follow "in" keyword`) }) Action49) / &{ p.errorHere(position, `expected "=", "!=", "match", or "in" to follow tag key in predicate`) }))> */
+		/* 41 tagMatcher <- <(tagName ((_ '=' (literalString / &{ p.errorHere(position, `expected string literal to follow "="`) }) Action75) / (_ ('!' '=') (literalString / &{ p.errorHere(position, `expected string literal to follow "!="`) }) Action76 Action77) / (_ (('m' / 'M') ('a' / 'A') ('t' / 'T') ('c' / 'C') ('h' / 'H')) KEY (literalString / &{ p.errorHere(position, `expected regex string literal to follow "match"`) }) Action78) / (_ (('i' / 'I') ('n' / 'N')) KEY _ (('c' / 'C') ('i' / 'I') ('d' / 'D') ('r' / 'R')) KEY ((literalString Action79) / (literalList Action80) / &{ p.errorHere(position, `expected CIDR string literal or list to follow "in cidr"`) })) / (_ (('i' / 'I') ('n' / 'N')) KEY _ (('g' / 'G') ('l' / 'L') ('o' / 'O') ('b' / 'B')) KEY ((literalString Action81) / (literalList Action82) / &{ p.errorHere(position, `expected glob string literal or list to follow "in glob"`) })) / (_ (('i' / 'I') ('n' / 'N')) KEY (literalList / &{ p.errorHere(position, `expected string literal list to follow "in" keyword`) }) Action83) / &{ p.errorHere(position, `expected "=", "!=", "match", "in", "in cidr" or "in glob" to follow tag key in predicate`) }))> */
 		nil,
-		/* 30 literalString <- <(_ STRING Action50)> */
+		/* 42 literalString <- <(_ STRING Action84)> */
 		func() bool {
-			position511, tokenIndex511 := position, tokenIndex
+			position920, tokenIndex920 := position, tokenIndex
 			{
-				position512 := position
+				position921 := position
 				if !_rules[rule_]() {
-					goto l511
+					goto l920
 				}
 				if !_rules[ruleSTRING]() {
-					goto l511
+					goto l920
 				}
 				{
-					add(ruleAction50, position)
+					add(ruleAction84, position)
 				}
-				add(ruleliteralString, position512)
+				add(ruleliteralString, position921)
 			}
 			return true
-		l511:
-			position, tokenIndex = position511, tokenIndex511
+		l920:
+			position, tokenIndex = position920, tokenIndex920
 			return false
 		},
-		/* 31 literalList <- <(Action51 _ PAREN_OPEN (literalListString / &{ p.errorHere(position, `expected string literal to follow "(" in literal list`) }) (_ COMMA (literalListString / &{ p.errorHere(position, `expected string literal to follow "," in literal list`) }))* ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" for literal list`) }))> */
-		nil,
-		/* 32 literalListString <- <(_ STRING Action52)> */
+		/* 43 literalList <- <(Action85 _ PAREN_OPEN (literalListString / &{ p.errorHere(position, `expected string literal to follow "(" in literal list`) }) (_ COMMA (literalListString / &{ p.errorHere(position, `expected string literal to follow "," in literal list`) }))* ((_ PAREN_CLOSE) / &{ p.errorHere(position, `expected ")" to close "(" for literal list`) }))> */
+		func() bool {
+			position923, tokenIndex923 := position, tokenIndex
+			{
+				position924 := position
+				{
+					add(ruleAction85, position)
+				}
+				if !_rules[rule_]() {
+					goto l923
+				}
+				if !_rules[rulePAREN_OPEN]() {
+					goto l923
+				}
+				{
+					position926, tokenIndex926 := position, tokenIndex
+					if !_rules[ruleliteralListString]() {
+						goto l927
+					}
+					goto l926
+				l927:
+					position, tokenIndex = position926, tokenIndex926
+					if !(p.errorHere(position, `expected string literal to follow "(" in literal list`)) {
+						goto l923
+					}
+				}
+			l926:
+			l928:
+				{
+					position929, tokenIndex929 := position, tokenIndex
+					if !_rules[rule_]() {
+						goto l929
+					}
+					if !_rules[ruleCOMMA]() {
+						goto l929
+					}
+					{
+						position930, tokenIndex930 := position, tokenIndex
+						if !_rules[ruleliteralListString]() {
+							goto l931
+						}
+						goto l930
+					l931:
+						position, tokenIndex = position930, tokenIndex930
+						if !(p.errorHere(position, `expected string literal to follow "," in literal list`)) {
+							goto l929
+						}
+					}
+				l930:
+					goto l928
+				l929:
+					position, tokenIndex = position929, tokenIndex929
+				}
+				{
+					position932, tokenIndex932 := position, tokenIndex
+					if !_rules[rule_]() {
+						goto l933
+					}
+					if !_rules[rulePAREN_CLOSE]() {
+						goto l933
+					}
+					goto l932
+				l933:
+					position, tokenIndex = position932, tokenIndex932
+					if !(p.errorHere(position, `expected ")" to close "(" for literal list`)) {
+						goto l923
+					}
+				}
+			l932:
+				add(ruleliteralList, position924)
+			}
+			return true
+		l923:
+			position, tokenIndex = position923, tokenIndex923
+			return false
+		},
+		/* 44 literalListString <- <(_ STRING Action86)> */
 		func() bool {
-			position515, tokenIndex515 := position, tokenIndex
+			position934, tokenIndex934 := position, tokenIndex
 			{
-				position516 := position
+				position935 := position
 				if !_rules[rule_]() {
-					goto l515
+					goto l934
 				}
 				if !_rules[ruleSTRING]() {
-					goto l515
+					goto l934
 				}
 				{
-					add(ruleAction52, position)
+					add(ruleAction86, position)
 				}
-				add(ruleliteralListString, position516)
+				add(ruleliteralListString, position935)
 			}
 			return true
-		l515:
-			position, tokenIndex = position515, tokenIndex515
+		l934:
+			position, tokenIndex = position934, tokenIndex934
 			return false
 		},
-		/* 33 tagName <- <(_ <TAG_NAME> Action53)> */
+		/* 45 tagName <- <(_ <TAG_NAME> Action87)> */
 		func() bool {
-			position518, tokenIndex518 := position, tokenIndex
+			position937, tokenIndex937 := position, tokenIndex
 			{
-				position519 := position
+				position938 := position
 				if !_rules[rule_]() {
-					goto l518
+					goto l937
 				}
 				{
-					position520 := position
+					position939 := position
 					{
-						position521 := position
+						position940 := position
 						if !_rules[ruleIDENTIFIER]() {
-							goto l518
+							goto l937
 						}
-						add(ruleTAG_NAME, position521)
+						add(ruleTAG_NAME, position940)
 					}
-					add(rulePegText, position520)
+					add(rulePegText, position939)
 				}
 				{
-					add(ruleAction53, position)
+					add(ruleAction87, position)
 				}
-				add(ruletagName, position519)
+				add(ruletagName, position938)
 			}
 			return true
-		l518:
-			position, tokenIndex = position518, tokenIndex518
+		l937:
+			position, tokenIndex = position937, tokenIndex937
 			return false
 		},
-		/* 34 COLUMN_NAME <- <IDENTIFIER> */
+		/* 46 COLUMN_NAME <- <IDENTIFIER> */
 		func() bool {
-			position523, tokenIndex523 := position, tokenIndex
+			position942, tokenIndex942 := position, tokenIndex
 			{
-				position524 := position
+				position943 := position
 				if !_rules[ruleIDENTIFIER]() {
-					goto l523
+					goto l942
 				}
-				add(ruleCOLUMN_NAME, position524)
+				add(ruleCOLUMN_NAME, position943)
 			}
 			return true
-		l523:
-			position, tokenIndex = position523, tokenIndex523
+		l942:
+			position, tokenIndex = position942, tokenIndex942
 			return false
 		},
-		/* 35 METRIC_NAME <- <IDENTIFIER> */
-		nil,
-		/* 36 TAG_NAME <- <IDENTIFIER> */
+		/* 47 METRIC_NAME <- <IDENTIFIER> */
+		func() bool {
+			position944, tokenIndex944 := position, tokenIndex
+			{
+				position945 := position
+				if !_rules[ruleIDENTIFIER]() {
+					goto l944
+				}
+				add(ruleMETRIC_NAME, position945)
+			}
+			return true
+		l944:
+			position, tokenIndex = position944, tokenIndex944
+			return false
+		},
+		/* 48 TAG_NAME <- <IDENTIFIER> */
 		nil,
-		/* 37 IDENTIFIER <- <(('`' CHAR* ('`' / &{ p.errorHere(position, "expected \"`\" to end identifier") })) / (!(KEYWORD KEY) ID_SEGMENT ('.' (ID_SEGMENT / &{ p.errorHere(position, `expected identifier segment to follow "."`) }))*))> */
+		/* 49 IDENTIFIER <- <(('`' CHAR* ('`' / &{ p.errorHere(position, "expected \"`\" to end identifier") })) / (!(KEYWORD KEY) ID_SEGMENT ('.' (ID_SEGMENT / &{ p.errorHere(position, `expected identifier segment to follow "."`) }))*))> */
 		func() bool {
-			position527, tokenIndex527 := position, tokenIndex
+			position947, tokenIndex947 := position, tokenIndex
 			{
-				position528 := position
+				position948 := position
 				{
-					position529, tokenIndex529 := position, tokenIndex
+					position949, tokenIndex949 := position, tokenIndex
 					if buffer[position] != rune('`') {
-						goto l530
+						goto l950
 					}
 					position++
-				l531:
+				l951:
 					{
-						position532, tokenIndex532 := position, tokenIndex
+						position952, tokenIndex952 := position, tokenIndex
 						if !_rules[ruleCHAR]() {
-							goto l532
+							goto l952
 						}
-						goto l531
-					l532:
-						position, tokenIndex = position532, tokenIndex532
+						goto l951
+					l952:
+						position, tokenIndex = position952, tokenIndex952
 					}
 					{
-						position533, tokenIndex533 := position, tokenIndex
+						position953, tokenIndex953 := position, tokenIndex
 						if buffer[position] != rune('`') {
-							goto l534
+							goto l954
 						}
 						position++
-						goto l533
-					l534:
-						position, tokenIndex = position533, tokenIndex533
+						goto l953
+					l954:
+						position, tokenIndex = position953, tokenIndex953
 						if !(p.errorHere(position, "expected \"`\" to end identifier")) {
-							goto l530
+							goto l950
 						}
 					}
-				l533:
-					goto l529
-				l530:
-					position, tokenIndex = position529, tokenIndex529
+				l953:
+					goto l949
+				l950:
+					position, tokenIndex = position949, tokenIndex949
 					{
-						position535, tokenIndex535 := position, tokenIndex
+						position955, tokenIndex955 := position, tokenIndex
 						{
-							position536 := position
+							position956 := position
 							{
-								position537, tokenIndex537 := position, tokenIndex
+								position957, tokenIndex957 := position, tokenIndex
 								{
-									position539, tokenIndex539 := position, tokenIndex
+									position959, tokenIndex959 := position, tokenIndex
 									if buffer[position] != rune('a') {
-										goto l540
+										goto l960
 									}
 									position++
-									goto l539
-								l540:
-									position, tokenIndex = position539, tokenIndex539
+									goto l959
+								l960:
+									position, tokenIndex = position959, tokenIndex959
 									if buffer[position] != rune('A') {
-										goto l538
+										goto l958
 									}
 									position++
 								}
-							l539:
+							l959:
 								{
-									position541, tokenIndex541 := position, tokenIndex
+									position961, tokenIndex961 := position, tokenIndex
 									if buffer[position] != rune('l') {
-										goto l542
+										goto l962
 									}
 									position++
-									goto l541
-								l542:
-									position, tokenIndex = position541, tokenIndex541
+									goto l961
+								l962:
+									position, tokenIndex = position961, tokenIndex961
 									if buffer[position] != rune('L') {
-										goto l538
+										goto l958
 									}
 									position++
 								}
-							l541:
+							l961:
 								{
-									position543, tokenIndex543 := position, tokenIndex
+									position963, tokenIndex963 := position, tokenIndex
 									if buffer[position] != rune('l') {
-										goto l544
+										goto l964
 									}
 									position++
-									goto l543
-								l544:
-									position, tokenIndex = position543, tokenIndex543
+									goto l963
+								l964:
+									position, tokenIndex = position963, tokenIndex963
 									if buffer[position] != rune('L') {
-										goto l538
+										goto l958
 									}
 									position++
 								}
-							l543:
-								goto l537
-							l538:
-								position, tokenIndex = position537, tokenIndex537
+							l963:
+								goto l957
+							l958:
+								position, tokenIndex = position957, tokenIndex957
 								{
-									position546, tokenIndex546 := position, tokenIndex
+									position966, tokenIndex966 := position, tokenIndex
 									if buffer[position] != rune('a') {
-										goto l547
+										goto l967
 									}
 									position++
-									goto l546
-								l547:
-									position, tokenIndex = position546, tokenIndex546
+									goto l966
+								l967:
+									position, tokenIndex = position966, tokenIndex966
 									if buffer[position] != rune('A') {
-										goto l545
+										goto l965
 									}
 									position++
 								}
-							l546:
+							l966:
 								{
-									position548, tokenIndex548 := position, tokenIndex
+									position968, tokenIndex968 := position, tokenIndex
 									if buffer[position] != rune('n') {
-										goto l549
+										goto l969
 									}
 									position++
-									goto l548
-								l549:
-									position, tokenIndex = position548, tokenIndex548
+									goto l968
+								l969:
+									position, tokenIndex = position968, tokenIndex968
 									if buffer[position] != rune('N') {
-										goto l545
+										goto l965
 									}
 									position++
 								}
-							l548:
+							l968:
 								{
-									position550, tokenIndex550 := position, tokenIndex
+									position970, tokenIndex970 := position, tokenIndex
 									if buffer[position] != rune('d') {
-										goto l551
+										goto l971
 									}
 									position++
-									goto l550
-								l551:
-									position, tokenIndex = position550, tokenIndex550
+									goto l970
+								l971:
+									position, tokenIndex = position970, tokenIndex970
 									if buffer[position] != rune('D') {
-										goto l545
+										goto l965
 									}
 									position++
 								}
-							l550:
-								goto l537
-							l545:
-								position, tokenIndex = position537, tokenIndex537
+							l970:
+								goto l957
+							l965:
+								position, tokenIndex = position957, tokenIndex957
 								{
-									position553, tokenIndex553 := position, tokenIndex
+									position973, tokenIndex973 := position, tokenIndex
 									if buffer[position] != rune('m') {
-										goto l554
+										goto l974
 									}
 									position++
-									goto l553
-								l554:
-									position, tokenIndex = position553, tokenIndex553
+									goto l973
+								l974:
+									position, tokenIndex = position973, tokenIndex973
 									if buffer[position] != rune('M') {
-										goto l552
+										goto l972
 									}
 									position++
 								}
-							l553:
+							l973:
 								{
-									position555, tokenIndex555 := position, tokenIndex
+									position975, tokenIndex975 := position, tokenIndex
 									if buffer[position] != rune('a') {
-										goto l556
+										goto l976
 									}
 									position++
-									goto l555
-								l556:
-									position, tokenIndex = position555, tokenIndex555
+									goto l975
+								l976:
+									position, tokenIndex = position975, tokenIndex975
 									if buffer[position] != rune('A') {
-										goto l552
+										goto l972
 									}
 									position++
 								}
-							l555:
+							l975:
 								{
-									position557, tokenIndex557 := position, tokenIndex
+									position977, tokenIndex977 := position, tokenIndex
 									if buffer[position] != rune('t') {
-										goto l558
+										goto l978
 									}
 									position++
-									goto l557
-								l558:
-									position, tokenIndex = position557, tokenIndex557
+									goto l977
+								l978:
+									position, tokenIndex = position977, tokenIndex977
 									if buffer[position] != rune('T') {
-										goto l552
+										goto l972
 									}
 									position++
 								}
-							l557:
+							l977:
 								{
-									position559, tokenIndex559 := position, tokenIndex
+									position979, tokenIndex979 := position, tokenIndex
 									if buffer[position] != rune('c') {
-										goto l560
+										goto l980
 									}
 									position++
-									goto l559
-								l560:
-									position, tokenIndex = position559, tokenIndex559
+									goto l979
+								l980:
+									position, tokenIndex = position979, tokenIndex979
 									if buffer[position] != rune('C') {
-										goto l552
+										goto l972
 									}
 									position++
 								}
-							l559:
+							l979:
 								{
-									position561, tokenIndex561 := position, tokenIndex
+									position981, tokenIndex981 := position, tokenIndex
 									if buffer[position] != rune('h') {
-										goto l562
+										goto l982
 									}
 									position++
-									goto l561
-								l562:
-									position, tokenIndex = position561, tokenIndex561
+									goto l981
+								l982:
+									position, tokenIndex = position981, tokenIndex981
 									if buffer[position] != rune('H') {
-										goto l552
+										goto l972
 									}
 									position++
 								}
-							l561:
-								goto l537
-							l552:
-								position, tokenIndex = position537, tokenIndex537
+							l981:
+								goto l957
+							l972:
+								position, tokenIndex = position957, tokenIndex957
 								{
-									position564, tokenIndex564 := position, tokenIndex
+									position984, tokenIndex984 := position, tokenIndex
 									if buffer[position] != rune('s') {
-										goto l565
+										goto l985
 									}
 									position++
-									goto l564
-								l565:
-									position, tokenIndex = position564, tokenIndex564
+									goto l984
+								l985:
+									position, tokenIndex = position984, tokenIndex984
 									if buffer[position] != rune('S') {
-										goto l563
+										goto l983
 									}
 									position++
 								}
-							l564:
+							l984:
 								{
-									position566, tokenIndex566 := position, tokenIndex
+									position986, tokenIndex986 := position, tokenIndex
 									if buffer[position] != rune('e') {
-										goto l567
+										goto l987
 									}
 									position++
-									goto l566
-								l567:
-									position, tokenIndex = position566, tokenIndex566
+									goto l986
+								l987:
+									position, tokenIndex = position986, tokenIndex986
 									if buffer[position] != rune('E') {
-										goto l563
+										goto l983
 									}
 									position++
 								}
-							l566:
+							l986:
 								{
-									position568, tokenIndex568 := position, tokenIndex
+									position988, tokenIndex988 := position, tokenIndex
 									if buffer[position] != rune('l') {
-										goto l569
+										goto l989
 									}
 									position++
-									goto l568
-								l569:
-									position, tokenIndex = position568, tokenIndex568
+									goto l988
+								l989:
+									position, tokenIndex = position988, tokenIndex988
 									if buffer[position] != rune('L') {
-										goto l563
+										goto l983
 									}
 									position++
 								}
-							l568:
+							l988:
 								{
-									position570, tokenIndex570 := position, tokenIndex
+									position990, tokenIndex990 := position, tokenIndex
 									if buffer[position] != rune('e') {
-										goto l571
+										goto l991
 									}
 									position++
-									goto l570
-								l571:
-									position, tokenIndex = position570, tokenIndex570
+									goto l990
+								l991:
+									position, tokenIndex = position990, tokenIndex990
 									if buffer[position] != rune('E') {
-										goto l563
+										goto l983
 									}
 									position++
 								}
-							l570:
+							l990:
 								{
-									position572, tokenIndex572 := position, tokenIndex
+									position992, tokenIndex992 := position, tokenIndex
 									if buffer[position] != rune('c') {
-										goto l573
+										goto l993
 									}
 									position++
-									goto l572
-								l573:
-									position, tokenIndex = position572, tokenIndex572
+									goto l992
+								l993:
+									position, tokenIndex = position992, tokenIndex992
 									if buffer[position] != rune('C') {
-										goto l563
+										goto l983
 									}
 									position++
 								}
-							l572:
+							l992:
 								{
-									position574, tokenIndex574 := position, tokenIndex
+									position994, tokenIndex994 := position, tokenIndex
 									if buffer[position] != rune('t') {
-										goto l575
+										goto l995
 									}
 									position++
-									goto l574
-								l575:
-									position, tokenIndex = position574, tokenIndex574
+									goto l994
+								l995:
+									position, tokenIndex = position994, tokenIndex994
 									if buffer[position] != rune('T') {
-										goto l563
+										goto l983
 									}
 									position++
 								}
-							l574:
-								goto l537
-							l563:
-								position, tokenIndex = position537, tokenIndex537
+							l994:
+								goto l957
+							l983:
+								position, tokenIndex = position957, tokenIndex957
 								{
 									switch buffer[position] {
+									case 'U', 'u':
+										{
+											position997, tokenIndex997 := position, tokenIndex
+											if buffer[position] != rune('u') {
+												goto l998
+											}
+											position++
+											goto l997
+										l998:
+											position, tokenIndex = position997, tokenIndex997
+											if buffer[position] != rune('U') {
+												goto l955
+											}
+											position++
+										}
+									l997:
+										{
+											position999, tokenIndex999 := position, tokenIndex
+											if buffer[position] != rune('n') {
+												goto l1000
+											}
+											position++
+											goto l999
+										l1000:
+											position, tokenIndex = position999, tokenIndex999
+											if buffer[position] != rune('N') {
+												goto l955
+											}
+											position++
+										}
+									l999:
+										{
+											position1001, tokenIndex1001 := position, tokenIndex
+											if buffer[position] != rune('l') {
+												goto l1002
+											}
+											position++
+											goto l1001
+										l1002:
+											position, tokenIndex = position1001, tokenIndex1001
+											if buffer[position] != rune('L') {
+												goto l955
+											}
+											position++
+										}
+									l1001:
+										{
+											position1003, tokenIndex1003 := position, tokenIndex
+											if buffer[position] != rune('e') {
+												goto l1004
+											}
+											position++
+											goto l1003
+										l1004:
+											position, tokenIndex = position1003, tokenIndex1003
+											if buffer[position] != rune('E') {
+												goto l955
+											}
+											position++
+										}
+									l1003:
+										{
+											position1005, tokenIndex1005 := position, tokenIndex
+											if buffer[position] != rune('s') {
+												goto l1006
+											}
+											position++
+											goto l1005
+										l1006:
+											position, tokenIndex = position1005, tokenIndex1005
+											if buffer[position] != rune('S') {
+												goto l955
+											}
+											position++
+										}
+									l1005:
+										{
+											position1007, tokenIndex1007 := position, tokenIndex
+											if buffer[position] != rune('s') {
+												goto l1008
+											}
+											position++
+											goto l1007
+										l1008:
+											position, tokenIndex = position1007, tokenIndex1007
+											if buffer[position] != rune('S') {
+												goto l955
+											}
+											position++
+										}
+									l1007:
+										break
 									case 'S', 's':
 										{
-											position577, tokenIndex577 := position, tokenIndex
+											position1009, tokenIndex1009 := position, tokenIndex
 											if buffer[position] != rune('s') {
-												goto l578
+												goto l1010
 											}
 											position++
-											goto l577
-										l578:
-											position, tokenIndex = position577, tokenIndex577
+											goto l1009
+										l1010:
+											position, tokenIndex = position1009, tokenIndex1009
 											if buffer[position] != rune('S') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l577:
+									l1009:
 										{
-											position579, tokenIndex579 := position, tokenIndex
+											position1011, tokenIndex1011 := position, tokenIndex
 											if buffer[position] != rune('a') {
-												goto l580
+												goto l1012
 											}
 											position++
-											goto l579
-										l580:
-											position, tokenIndex = position579, tokenIndex579
+											goto l1011
+										l1012:
+											position, tokenIndex = position1011, tokenIndex1011
 											if buffer[position] != rune('A') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l579:
+									l1011:
 										{
-											position581, tokenIndex581 := position, tokenIndex
+											position1013, tokenIndex1013 := position, tokenIndex
 											if buffer[position] != rune('m') {
-												goto l582
+												goto l1014
 											}
 											position++
-											goto l581
-										l582:
-											position, tokenIndex = position581, tokenIndex581
+											goto l1013
+										l1014:
+											position, tokenIndex = position1013, tokenIndex1013
 											if buffer[position] != rune('M') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l581:
+									l1013:
 										{
-											position583, tokenIndex583 := position, tokenIndex
+											position1015, tokenIndex1015 := position, tokenIndex
 											if buffer[position] != rune('p') {
-												goto l584
+												goto l1016
 											}
 											position++
-											goto l583
-										l584:
-											position, tokenIndex = position583, tokenIndex583
+											goto l1015
+										l1016:
+											position, tokenIndex = position1015, tokenIndex1015
 											if buffer[position] != rune('P') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l583:
+									l1015:
 										{
-											position585, tokenIndex585 := position, tokenIndex
+											position1017, tokenIndex1017 := position, tokenIndex
 											if buffer[position] != rune('l') {
-												goto l586
+												goto l1018
 											}
 											position++
-											goto l585
-										l586:
-											position, tokenIndex = position585, tokenIndex585
+											goto l1017
+										l1018:
+											position, tokenIndex = position1017, tokenIndex1017
 											if buffer[position] != rune('L') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l585:
+									l1017:
 										{
-											position587, tokenIndex587 := position, tokenIndex
+											position1019, tokenIndex1019 := position, tokenIndex
 											if buffer[position] != rune('e') {
-												goto l588
+												goto l1020
 											}
 											position++
-											goto l587
-										l588:
-											position, tokenIndex = position587, tokenIndex587
+											goto l1019
+										l1020:
+											position, tokenIndex = position1019, tokenIndex1019
 											if buffer[position] != rune('E') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l587:
+									l1019:
 										break
 									case 'R', 'r':
 										{
-											position589, tokenIndex589 := position, tokenIndex
+											position1021, tokenIndex1021 := position, tokenIndex
 											if buffer[position] != rune('r') {
-												goto l590
+												goto l1022
 											}
 											position++
-											goto l589
-										l590:
-											position, tokenIndex = position589, tokenIndex589
+											goto l1021
+										l1022:
+											position, tokenIndex = position1021, tokenIndex1021
 											if buffer[position] != rune('R') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l589:
+									l1021:
 										{
-											position591, tokenIndex591 := position, tokenIndex
+											position1023, tokenIndex1023 := position, tokenIndex
 											if buffer[position] != rune('e') {
-												goto l592
+												goto l1024
 											}
 											position++
-											goto l591
-										l592:
-											position, tokenIndex = position591, tokenIndex591
+											goto l1023
+										l1024:
+											position, tokenIndex = position1023, tokenIndex1023
 											if buffer[position] != rune('E') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l591:
+									l1023:
 										{
-											position593, tokenIndex593 := position, tokenIndex
+											position1025, tokenIndex1025 := position, tokenIndex
 											if buffer[position] != rune('s') {
-												goto l594
+												goto l1026
 											}
 											position++
-											goto l593
-										l594:
-											position, tokenIndex = position593, tokenIndex593
+											goto l1025
+										l1026:
+											position, tokenIndex = position1025, tokenIndex1025
 											if buffer[position] != rune('S') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l593:
+									l1025:
 										{
-											position595, tokenIndex595 := position, tokenIndex
+											position1027, tokenIndex1027 := position, tokenIndex
 											if buffer[position] != rune('o') {
-												goto l596
+												goto l1028
 											}
 											position++
-											goto l595
-										l596:
-											position, tokenIndex = position595, tokenIndex595
+											goto l1027
+										l1028:
+											position, tokenIndex = position1027, tokenIndex1027
 											if buffer[position] != rune('O') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l595:
+									l1027:
 										{
-											position597, tokenIndex597 := position, tokenIndex
+											position1029, tokenIndex1029 := position, tokenIndex
 											if buffer[position] != rune('l') {
-												goto l598
+												goto l1030
 											}
 											position++
-											goto l597
-										l598:
-											position, tokenIndex = position597, tokenIndex597
+											goto l1029
+										l1030:
+											position, tokenIndex = position1029, tokenIndex1029
 											if buffer[position] != rune('L') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l597:
+									l1029:
 										{
-											position599, tokenIndex599 := position, tokenIndex
+											position1031, tokenIndex1031 := position, tokenIndex
 											if buffer[position] != rune('u') {
-												goto l600
+												goto l1032
 											}
 											position++
-											goto l599
-										l600:
-											position, tokenIndex = position599, tokenIndex599
+											goto l1031
+										l1032:
+											position, tokenIndex = position1031, tokenIndex1031
 											if buffer[position] != rune('U') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l599:
+									l1031:
 										{
-											position601, tokenIndex601 := position, tokenIndex
+											position1033, tokenIndex1033 := position, tokenIndex
 											if buffer[position] != rune('t') {
-												goto l602
+												goto l1034
 											}
 											position++
-											goto l601
-										l602:
-											position, tokenIndex = position601, tokenIndex601
+											goto l1033
+										l1034:
+											position, tokenIndex = position1033, tokenIndex1033
 											if buffer[position] != rune('T') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l601:
+									l1033:
 										{
-											position603, tokenIndex603 := position, tokenIndex
+											position1035, tokenIndex1035 := position, tokenIndex
 											if buffer[position] != rune('i') {
-												goto l604
+												goto l1036
 											}
 											position++
-											goto l603
-										l604:
-											position, tokenIndex = position603, tokenIndex603
+											goto l1035
+										l1036:
+											position, tokenIndex = position1035, tokenIndex1035
 											if buffer[position] != rune('I') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l603:
+									l1035:
 										{
-											position605, tokenIndex605 := position, tokenIndex
+											position1037, tokenIndex1037 := position, tokenIndex
 											if buffer[position] != rune('o') {
-												goto l606
+												goto l1038
 											}
 											position++
-											goto l605
-										l606:
-											position, tokenIndex = position605, tokenIndex605
+											goto l1037
+										l1038:
+											position, tokenIndex = position1037, tokenIndex1037
 											if buffer[position] != rune('O') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l605:
+									l1037:
 										{
-											position607, tokenIndex607 := position, tokenIndex
+											position1039, tokenIndex1039 := position, tokenIndex
 											if buffer[position] != rune('n') {
-												goto l608
+												goto l1040
 											}
 											position++
-											goto l607
-										l608:
-											position, tokenIndex = position607, tokenIndex607
+											goto l1039
+										l1040:
+											position, tokenIndex = position1039, tokenIndex1039
 											if buffer[position] != rune('N') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l607:
+									l1039:
 										break
 									case 'T', 't':
 										{
-											position609, tokenIndex609 := position, tokenIndex
+											position1041, tokenIndex1041 := position, tokenIndex
 											if buffer[position] != rune('t') {
-												goto l610
+												goto l1042
 											}
 											position++
-											goto l609
-										l610:
-											position, tokenIndex = position609, tokenIndex609
+											goto l1041
+										l1042:
+											position, tokenIndex = position1041, tokenIndex1041
 											if buffer[position] != rune('T') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l609:
+									l1041:
 										{
-											position611, tokenIndex611 := position, tokenIndex
+											position1043, tokenIndex1043 := position, tokenIndex
 											if buffer[position] != rune('o') {
-												goto l612
+												goto l1044
 											}
 											position++
-											goto l611
-										l612:
-											position, tokenIndex = position611, tokenIndex611
+											goto l1043
+										l1044:
+											position, tokenIndex = position1043, tokenIndex1043
 											if buffer[position] != rune('O') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l611:
+									l1043:
 										break
 									case 'F', 'f':
 										{
-											position613, tokenIndex613 := position, tokenIndex
+											position1045, tokenIndex1045 := position, tokenIndex
 											if buffer[position] != rune('f') {
-												goto l614
+												goto l1046
 											}
 											position++
-											goto l613
-										l614:
-											position, tokenIndex = position613, tokenIndex613
+											goto l1045
+										l1046:
+											position, tokenIndex = position1045, tokenIndex1045
 											if buffer[position] != rune('F') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l613:
+									l1045:
 										{
-											position615, tokenIndex615 := position, tokenIndex
+											position1047, tokenIndex1047 := position, tokenIndex
 											if buffer[position] != rune('r') {
-												goto l616
+												goto l1048
 											}
 											position++
-											goto l615
-										l616:
-											position, tokenIndex = position615, tokenIndex615
+											goto l1047
+										l1048:
+											position, tokenIndex = position1047, tokenIndex1047
 											if buffer[position] != rune('R') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l615:
+									l1047:
 										{
-											position617, tokenIndex617 := position, tokenIndex
+											position1049, tokenIndex1049 := position, tokenIndex
 											if buffer[position] != rune('o') {
-												goto l618
+												goto l1050
 											}
 											position++
-											goto l617
-										l618:
-											position, tokenIndex = position617, tokenIndex617
+											goto l1049
+										l1050:
+											position, tokenIndex = position1049, tokenIndex1049
 											if buffer[position] != rune('O') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l617:
+									l1049:
 										{
-											position619, tokenIndex619 := position, tokenIndex
+											position1051, tokenIndex1051 := position, tokenIndex
 											if buffer[position] != rune('m') {
-												goto l620
+												goto l1052
 											}
 											position++
-											goto l619
-										l620:
-											position, tokenIndex = position619, tokenIndex619
+											goto l1051
+										l1052:
+											position, tokenIndex = position1051, tokenIndex1051
 											if buffer[position] != rune('M') {
-												goto l535
+												goto l955
+											}
+											position++
+										}
+									l1051:
+										break
+									case 'V', 'v':
+										{
+											position1053, tokenIndex1053 := position, tokenIndex
+											if buffer[position] != rune('v') {
+												goto l1054
+											}
+											position++
+											goto l1053
+										l1054:
+											position, tokenIndex = position1053, tokenIndex1053
+											if buffer[position] != rune('V') {
+												goto l955
+											}
+											position++
+										}
+									l1053:
+										{
+											position1055, tokenIndex1055 := position, tokenIndex
+											if buffer[position] != rune('a') {
+												goto l1056
+											}
+											position++
+											goto l1055
+										l1056:
+											position, tokenIndex = position1055, tokenIndex1055
+											if buffer[position] != rune('A') {
+												goto l955
+											}
+											position++
+										}
+									l1055:
+										{
+											position1057, tokenIndex1057 := position, tokenIndex
+											if buffer[position] != rune('l') {
+												goto l1058
+											}
+											position++
+											goto l1057
+										l1058:
+											position, tokenIndex = position1057, tokenIndex1057
+											if buffer[position] != rune('L') {
+												goto l955
 											}
 											position++
 										}
-									l619:
+									l1057:
+										{
+											position1059, tokenIndex1059 := position, tokenIndex
+											if buffer[position] != rune('u') {
+												goto l1060
+											}
+											position++
+											goto l1059
+										l1060:
+											position, tokenIndex = position1059, tokenIndex1059
+											if buffer[position] != rune('U') {
+												goto l955
+											}
+											position++
+										}
+									l1059:
+										{
+											position1061, tokenIndex1061 := position, tokenIndex
+											if buffer[position] != rune('e') {
+												goto l1062
+											}
+											position++
+											goto l1061
+										l1062:
+											position, tokenIndex = position1061, tokenIndex1061
+											if buffer[position] != rune('E') {
+												goto l955
+											}
+											position++
+										}
+									l1061:
+										{
+											position1063, tokenIndex1063 := position, tokenIndex
+											if buffer[position] != rune('s') {
+												goto l1064
+											}
+											position++
+											goto l1063
+										l1064:
+											position, tokenIndex = position1063, tokenIndex1063
+											if buffer[position] != rune('S') {
+												goto l955
+											}
+											position++
+										}
+									l1063:
+										break
+									case 'K', 'k':
+										{
+											position1065, tokenIndex1065 := position, tokenIndex
+											if buffer[position] != rune('k') {
+												goto l1066
+											}
+											position++
+											goto l1065
+										l1066:
+											position, tokenIndex = position1065, tokenIndex1065
+											if buffer[position] != rune('K') {
+												goto l955
+											}
+											position++
+										}
+									l1065:
+										{
+											position1067, tokenIndex1067 := position, tokenIndex
+											if buffer[position] != rune('e') {
+												goto l1068
+											}
+											position++
+											goto l1067
+										l1068:
+											position, tokenIndex = position1067, tokenIndex1067
+											if buffer[position] != rune('E') {
+												goto l955
+											}
+											position++
+										}
+									l1067:
+										{
+											position1069, tokenIndex1069 := position, tokenIndex
+											if buffer[position] != rune('y') {
+												goto l1070
+											}
+											position++
+											goto l1069
+										l1070:
+											position, tokenIndex = position1069, tokenIndex1069
+											if buffer[position] != rune('Y') {
+												goto l955
+											}
+											position++
+										}
+									l1069:
+										{
+											position1071, tokenIndex1071 := position, tokenIndex
+											if buffer[position] != rune('s') {
+												goto l1072
+											}
+											position++
+											goto l1071
+										l1072:
+											position, tokenIndex = position1071, tokenIndex1071
+											if buffer[position] != rune('S') {
+												goto l955
+											}
+											position++
+										}
+									l1071:
 										break
 									case 'M', 'm':
 										{
-											position621, tokenIndex621 := position, tokenIndex
+											position1073, tokenIndex1073 := position, tokenIndex
 											if buffer[position] != rune('m') {
-												goto l622
+												goto l1074
 											}
 											position++
-											goto l621
-										l622:
-											position, tokenIndex = position621, tokenIndex621
+											goto l1073
+										l1074:
+											position, tokenIndex = position1073, tokenIndex1073
 											if buffer[position] != rune('M') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l621:
+									l1073:
 										{
-											position623, tokenIndex623 := position, tokenIndex
+											position1075, tokenIndex1075 := position, tokenIndex
 											if buffer[position] != rune('e') {
-												goto l624
+												goto l1076
 											}
 											position++
-											goto l623
-										l624:
-											position, tokenIndex = position623, tokenIndex623
+											goto l1075
+										l1076:
+											position, tokenIndex = position1075, tokenIndex1075
 											if buffer[position] != rune('E') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l623:
+									l1075:
 										{
-											position625, tokenIndex625 := position, tokenIndex
+											position1077, tokenIndex1077 := position, tokenIndex
 											if buffer[position] != rune('t') {
-												goto l626
+												goto l1078
 											}
 											position++
-											goto l625
-										l626:
-											position, tokenIndex = position625, tokenIndex625
+											goto l1077
+										l1078:
+											position, tokenIndex = position1077, tokenIndex1077
 											if buffer[position] != rune('T') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l625:
+									l1077:
 										{
-											position627, tokenIndex627 := position, tokenIndex
+											position1079, tokenIndex1079 := position, tokenIndex
 											if buffer[position] != rune('r') {
-												goto l628
+												goto l1080
 											}
 											position++
-											goto l627
-										l628:
-											position, tokenIndex = position627, tokenIndex627
+											goto l1079
+										l1080:
+											position, tokenIndex = position1079, tokenIndex1079
 											if buffer[position] != rune('R') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l627:
+									l1079:
 										{
-											position629, tokenIndex629 := position, tokenIndex
+											position1081, tokenIndex1081 := position, tokenIndex
 											if buffer[position] != rune('i') {
-												goto l630
+												goto l1082
 											}
 											position++
-											goto l629
-										l630:
-											position, tokenIndex = position629, tokenIndex629
+											goto l1081
+										l1082:
+											position, tokenIndex = position1081, tokenIndex1081
 											if buffer[position] != rune('I') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l629:
+									l1081:
 										{
-											position631, tokenIndex631 := position, tokenIndex
+											position1083, tokenIndex1083 := position, tokenIndex
 											if buffer[position] != rune('c') {
-												goto l632
+												goto l1084
 											}
 											position++
-											goto l631
-										l632:
-											position, tokenIndex = position631, tokenIndex631
+											goto l1083
+										l1084:
+											position, tokenIndex = position1083, tokenIndex1083
 											if buffer[position] != rune('C') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l631:
+									l1083:
 										{
-											position633, tokenIndex633 := position, tokenIndex
+											position1085, tokenIndex1085 := position, tokenIndex
 											if buffer[position] != rune('s') {
-												goto l634
+												goto l1086
 											}
 											position++
-											goto l633
-										l634:
-											position, tokenIndex = position633, tokenIndex633
+											goto l1085
+										l1086:
+											position, tokenIndex = position1085, tokenIndex1085
 											if buffer[position] != rune('S') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l633:
+									l1085:
 										break
 									case 'W', 'w':
 										{
-											position635, tokenIndex635 := position, tokenIndex
+											position1087, tokenIndex1087 := position, tokenIndex
 											if buffer[position] != rune('w') {
-												goto l636
+												goto l1088
 											}
 											position++
-											goto l635
-										l636:
-											position, tokenIndex = position635, tokenIndex635
+											goto l1087
+										l1088:
+											position, tokenIndex = position1087, tokenIndex1087
 											if buffer[position] != rune('W') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l635:
+									l1087:
 										{
-											position637, tokenIndex637 := position, tokenIndex
+											position1089, tokenIndex1089 := position, tokenIndex
 											if buffer[position] != rune('h') {
-												goto l638
+												goto l1090
 											}
 											position++
-											goto l637
-										l638:
-											position, tokenIndex = position637, tokenIndex637
+											goto l1089
+										l1090:
+											position, tokenIndex = position1089, tokenIndex1089
 											if buffer[position] != rune('H') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l637:
+									l1089:
 										{
-											position639, tokenIndex639 := position, tokenIndex
+											position1091, tokenIndex1091 := position, tokenIndex
 											if buffer[position] != rune('e') {
-												goto l640
+												goto l1092
 											}
 											position++
-											goto l639
-										l640:
-											position, tokenIndex = position639, tokenIndex639
+											goto l1091
+										l1092:
+											position, tokenIndex = position1091, tokenIndex1091
 											if buffer[position] != rune('E') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l639:
+									l1091:
 										{
-											position641, tokenIndex641 := position, tokenIndex
+											position1093, tokenIndex1093 := position, tokenIndex
 											if buffer[position] != rune('r') {
-												goto l642
+												goto l1094
 											}
 											position++
-											goto l641
-										l642:
-											position, tokenIndex = position641, tokenIndex641
+											goto l1093
+										l1094:
+											position, tokenIndex = position1093, tokenIndex1093
 											if buffer[position] != rune('R') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l641:
+									l1093:
 										{
-											position643, tokenIndex643 := position, tokenIndex
+											position1095, tokenIndex1095 := position, tokenIndex
 											if buffer[position] != rune('e') {
-												goto l644
+												goto l1096
 											}
 											position++
-											goto l643
-										l644:
-											position, tokenIndex = position643, tokenIndex643
+											goto l1095
+										l1096:
+											position, tokenIndex = position1095, tokenIndex1095
 											if buffer[position] != rune('E') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l643:
+									l1095:
 										break
 									case 'O', 'o':
 										{
-											position645, tokenIndex645 := position, tokenIndex
+											position1097, tokenIndex1097 := position, tokenIndex
 											if buffer[position] != rune('o') {
-												goto l646
+												goto l1098
 											}
 											position++
-											goto l645
-										l646:
-											position, tokenIndex = position645, tokenIndex645
+											goto l1097
+										l1098:
+											position, tokenIndex = position1097, tokenIndex1097
 											if buffer[position] != rune('O') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l645:
+									l1097:
 										{
-											position647, tokenIndex647 := position, tokenIndex
+											position1099, tokenIndex1099 := position, tokenIndex
 											if buffer[position] != rune('r') {
-												goto l648
+												goto l1100
 											}
 											position++
-											goto l647
-										l648:
-											position, tokenIndex = position647, tokenIndex647
+											goto l1099
+										l1100:
+											position, tokenIndex = position1099, tokenIndex1099
 											if buffer[position] != rune('R') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l647:
+									l1099:
 										break
 									case 'N', 'n':
 										{
-											position649, tokenIndex649 := position, tokenIndex
+											position1101, tokenIndex1101 := position, tokenIndex
 											if buffer[position] != rune('n') {
-												goto l650
+												goto l1102
 											}
 											position++
-											goto l649
-										l650:
-											position, tokenIndex = position649, tokenIndex649
+											goto l1101
+										l1102:
+											position, tokenIndex = position1101, tokenIndex1101
 											if buffer[position] != rune('N') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l649:
+									l1101:
 										{
-											position651, tokenIndex651 := position, tokenIndex
+											position1103, tokenIndex1103 := position, tokenIndex
 											if buffer[position] != rune('o') {
-												goto l652
+												goto l1104
 											}
 											position++
-											goto l651
-										l652:
-											position, tokenIndex = position651, tokenIndex651
+											goto l1103
+										l1104:
+											position, tokenIndex = position1103, tokenIndex1103
 											if buffer[position] != rune('O') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l651:
+									l1103:
 										{
-											position653, tokenIndex653 := position, tokenIndex
+											position1105, tokenIndex1105 := position, tokenIndex
 											if buffer[position] != rune('t') {
-												goto l654
+												goto l1106
 											}
 											position++
-											goto l653
-										l654:
-											position, tokenIndex = position653, tokenIndex653
+											goto l1105
+										l1106:
+											position, tokenIndex = position1105, tokenIndex1105
 											if buffer[position] != rune('T') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l653:
+									l1105:
 										break
 									case 'I', 'i':
 										{
-											position655, tokenIndex655 := position, tokenIndex
+											position1107, tokenIndex1107 := position, tokenIndex
 											if buffer[position] != rune('i') {
-												goto l656
+												goto l1108
 											}
 											position++
-											goto l655
-										l656:
-											position, tokenIndex = position655, tokenIndex655
+											goto l1107
+										l1108:
+											position, tokenIndex = position1107, tokenIndex1107
 											if buffer[position] != rune('I') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l655:
+									l1107:
 										{
-											position657, tokenIndex657 := position, tokenIndex
+											position1109, tokenIndex1109 := position, tokenIndex
 											if buffer[position] != rune('n') {
-												goto l658
+												goto l1110
 											}
 											position++
-											goto l657
-										l658:
-											position, tokenIndex = position657, tokenIndex657
+											goto l1109
+										l1110:
+											position, tokenIndex = position1109, tokenIndex1109
 											if buffer[position] != rune('N') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l657:
+									l1109:
 										break
 									case 'C', 'c':
 										{
-											position659, tokenIndex659 := position, tokenIndex
+											position1111, tokenIndex1111 := position, tokenIndex
 											if buffer[position] != rune('c') {
-												goto l660
+												goto l1112
 											}
 											position++
-											goto l659
-										l660:
-											position, tokenIndex = position659, tokenIndex659
+											goto l1111
+										l1112:
+											position, tokenIndex = position1111, tokenIndex1111
 											if buffer[position] != rune('C') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l659:
+									l1111:
 										{
-											position661, tokenIndex661 := position, tokenIndex
+											position1113, tokenIndex1113 := position, tokenIndex
 											if buffer[position] != rune('o') {
-												goto l662
+												goto l1114
 											}
 											position++
-											goto l661
-										l662:
-											position, tokenIndex = position661, tokenIndex661
+											goto l1113
+										l1114:
+											position, tokenIndex = position1113, tokenIndex1113
 											if buffer[position] != rune('O') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l661:
+									l1113:
 										{
-											position663, tokenIndex663 := position, tokenIndex
+											position1115, tokenIndex1115 := position, tokenIndex
 											if buffer[position] != rune('l') {
-												goto l664
+												goto l1116
 											}
 											position++
-											goto l663
-										l664:
-											position, tokenIndex = position663, tokenIndex663
+											goto l1115
+										l1116:
+											position, tokenIndex = position1115, tokenIndex1115
 											if buffer[position] != rune('L') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l663:
+									l1115:
 										{
-											position665, tokenIndex665 := position, tokenIndex
+											position1117, tokenIndex1117 := position, tokenIndex
 											if buffer[position] != rune('l') {
-												goto l666
+												goto l1118
 											}
 											position++
-											goto l665
-										l666:
-											position, tokenIndex = position665, tokenIndex665
+											goto l1117
+										l1118:
+											position, tokenIndex = position1117, tokenIndex1117
 											if buffer[position] != rune('L') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l665:
+									l1117:
 										{
-											position667, tokenIndex667 := position, tokenIndex
+											position1119, tokenIndex1119 := position, tokenIndex
 											if buffer[position] != rune('a') {
-												goto l668
+												goto l1120
 											}
 											position++
-											goto l667
-										l668:
-											position, tokenIndex = position667, tokenIndex667
+											goto l1119
+										l1120:
+											position, tokenIndex = position1119, tokenIndex1119
 											if buffer[position] != rune('A') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l667:
+									l1119:
 										{
-											position669, tokenIndex669 := position, tokenIndex
+											position1121, tokenIndex1121 := position, tokenIndex
 											if buffer[position] != rune('p') {
-												goto l670
+												goto l1122
 											}
 											position++
-											goto l669
-										l670:
-											position, tokenIndex = position669, tokenIndex669
+											goto l1121
+										l1122:
+											position, tokenIndex = position1121, tokenIndex1121
 											if buffer[position] != rune('P') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l669:
+									l1121:
 										{
-											position671, tokenIndex671 := position, tokenIndex
+											position1123, tokenIndex1123 := position, tokenIndex
 											if buffer[position] != rune('s') {
-												goto l672
+												goto l1124
 											}
 											position++
-											goto l671
-										l672:
-											position, tokenIndex = position671, tokenIndex671
+											goto l1123
+										l1124:
+											position, tokenIndex = position1123, tokenIndex1123
 											if buffer[position] != rune('S') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l671:
+									l1123:
 										{
-											position673, tokenIndex673 := position, tokenIndex
+											position1125, tokenIndex1125 := position, tokenIndex
 											if buffer[position] != rune('e') {
-												goto l674
+												goto l1126
 											}
 											position++
-											goto l673
-										l674:
-											position, tokenIndex = position673, tokenIndex673
+											goto l1125
+										l1126:
+											position, tokenIndex = position1125, tokenIndex1125
 											if buffer[position] != rune('E') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l673:
+									l1125:
 										break
 									case 'G', 'g':
 										{
-											position675, tokenIndex675 := position, tokenIndex
+											position1127, tokenIndex1127 := position, tokenIndex
 											if buffer[position] != rune('g') {
-												goto l676
+												goto l1128
 											}
 											position++
-											goto l675
-										l676:
-											position, tokenIndex = position675, tokenIndex675
+											goto l1127
+										l1128:
+											position, tokenIndex = position1127, tokenIndex1127
 											if buffer[position] != rune('G') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l675:
+									l1127:
 										{
-											position677, tokenIndex677 := position, tokenIndex
+											position1129, tokenIndex1129 := position, tokenIndex
 											if buffer[position] != rune('r') {
-												goto l678
+												goto l1130
 											}
 											position++
-											goto l677
-										l678:
-											position, tokenIndex = position677, tokenIndex677
+											goto l1129
+										l1130:
+											position, tokenIndex = position1129, tokenIndex1129
 											if buffer[position] != rune('R') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l677:
+									l1129:
 										{
-											position679, tokenIndex679 := position, tokenIndex
+											position1131, tokenIndex1131 := position, tokenIndex
 											if buffer[position] != rune('o') {
-												goto l680
+												goto l1132
 											}
 											position++
-											goto l679
-										l680:
-											position, tokenIndex = position679, tokenIndex679
+											goto l1131
+										l1132:
+											position, tokenIndex = position1131, tokenIndex1131
 											if buffer[position] != rune('O') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l679:
+									l1131:
 										{
-											position681, tokenIndex681 := position, tokenIndex
+											position1133, tokenIndex1133 := position, tokenIndex
 											if buffer[position] != rune('u') {
-												goto l682
+												goto l1134
 											}
 											position++
-											goto l681
-										l682:
-											position, tokenIndex = position681, tokenIndex681
+											goto l1133
+										l1134:
+											position, tokenIndex = position1133, tokenIndex1133
 											if buffer[position] != rune('U') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l681:
+									l1133:
 										{
-											position683, tokenIndex683 := position, tokenIndex
+											position1135, tokenIndex1135 := position, tokenIndex
 											if buffer[position] != rune('p') {
-												goto l684
+												goto l1136
 											}
 											position++
-											goto l683
-										l684:
-											position, tokenIndex = position683, tokenIndex683
+											goto l1135
+										l1136:
+											position, tokenIndex = position1135, tokenIndex1135
 											if buffer[position] != rune('P') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l683:
+									l1135:
 										break
 									case 'D', 'd':
 										{
-											position685, tokenIndex685 := position, tokenIndex
+											position1137, tokenIndex1137 := position, tokenIndex
 											if buffer[position] != rune('d') {
-												goto l686
+												goto l1138
 											}
 											position++
-											goto l685
-										l686:
-											position, tokenIndex = position685, tokenIndex685
+											goto l1137
+										l1138:
+											position, tokenIndex = position1137, tokenIndex1137
 											if buffer[position] != rune('D') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l685:
+									l1137:
 										{
-											position687, tokenIndex687 := position, tokenIndex
+											position1139, tokenIndex1139 := position, tokenIndex
 											if buffer[position] != rune('e') {
-												goto l688
+												goto l1140
 											}
 											position++
-											goto l687
-										l688:
-											position, tokenIndex = position687, tokenIndex687
+											goto l1139
+										l1140:
+											position, tokenIndex = position1139, tokenIndex1139
 											if buffer[position] != rune('E') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l687:
+									l1139:
 										{
-											position689, tokenIndex689 := position, tokenIndex
+											position1141, tokenIndex1141 := position, tokenIndex
 											if buffer[position] != rune('s') {
-												goto l690
+												goto l1142
 											}
 											position++
-											goto l689
-										l690:
-											position, tokenIndex = position689, tokenIndex689
+											goto l1141
+										l1142:
+											position, tokenIndex = position1141, tokenIndex1141
 											if buffer[position] != rune('S') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l689:
+									l1141:
 										{
-											position691, tokenIndex691 := position, tokenIndex
+											position1143, tokenIndex1143 := position, tokenIndex
 											if buffer[position] != rune('c') {
-												goto l692
+												goto l1144
 											}
 											position++
-											goto l691
-										l692:
-											position, tokenIndex = position691, tokenIndex691
+											goto l1143
+										l1144:
+											position, tokenIndex = position1143, tokenIndex1143
 											if buffer[position] != rune('C') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l691:
+									l1143:
 										{
-											position693, tokenIndex693 := position, tokenIndex
+											position1145, tokenIndex1145 := position, tokenIndex
 											if buffer[position] != rune('r') {
-												goto l694
+												goto l1146
 											}
 											position++
-											goto l693
-										l694:
-											position, tokenIndex = position693, tokenIndex693
+											goto l1145
+										l1146:
+											position, tokenIndex = position1145, tokenIndex1145
 											if buffer[position] != rune('R') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l693:
+									l1145:
 										{
-											position695, tokenIndex695 := position, tokenIndex
+											position1147, tokenIndex1147 := position, tokenIndex
 											if buffer[position] != rune('i') {
-												goto l696
+												goto l1148
 											}
 											position++
-											goto l695
-										l696:
-											position, tokenIndex = position695, tokenIndex695
+											goto l1147
+										l1148:
+											position, tokenIndex = position1147, tokenIndex1147
 											if buffer[position] != rune('I') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l695:
+									l1147:
 										{
-											position697, tokenIndex697 := position, tokenIndex
+											position1149, tokenIndex1149 := position, tokenIndex
 											if buffer[position] != rune('b') {
-												goto l698
+												goto l1150
 											}
 											position++
-											goto l697
-										l698:
-											position, tokenIndex = position697, tokenIndex697
+											goto l1149
+										l1150:
+											position, tokenIndex = position1149, tokenIndex1149
 											if buffer[position] != rune('B') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l697:
+									l1149:
 										{
-											position699, tokenIndex699 := position, tokenIndex
+											position1151, tokenIndex1151 := position, tokenIndex
 											if buffer[position] != rune('e') {
-												goto l700
+												goto l1152
 											}
 											position++
-											goto l699
-										l700:
-											position, tokenIndex = position699, tokenIndex699
+											goto l1151
+										l1152:
+											position, tokenIndex = position1151, tokenIndex1151
 											if buffer[position] != rune('E') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l699:
+									l1151:
 										break
 									case 'B', 'b':
 										{
-											position701, tokenIndex701 := position, tokenIndex
+											position1153, tokenIndex1153 := position, tokenIndex
 											if buffer[position] != rune('b') {
-												goto l702
+												goto l1154
 											}
 											position++
-											goto l701
-										l702:
-											position, tokenIndex = position701, tokenIndex701
+											goto l1153
+										l1154:
+											position, tokenIndex = position1153, tokenIndex1153
 											if buffer[position] != rune('B') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l701:
+									l1153:
 										{
-											position703, tokenIndex703 := position, tokenIndex
+											position1155, tokenIndex1155 := position, tokenIndex
 											if buffer[position] != rune('y') {
-												goto l704
+												goto l1156
 											}
 											position++
-											goto l703
-										l704:
-											position, tokenIndex = position703, tokenIndex703
+											goto l1155
+										l1156:
+											position, tokenIndex = position1155, tokenIndex1155
 											if buffer[position] != rune('Y') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l703:
+									l1155:
 										break
 									default:
 										{
-											position705, tokenIndex705 := position, tokenIndex
+											position1157, tokenIndex1157 := position, tokenIndex
 											if buffer[position] != rune('a') {
-												goto l706
+												goto l1158
 											}
 											position++
-											goto l705
-										l706:
-											position, tokenIndex = position705, tokenIndex705
+											goto l1157
+										l1158:
+											position, tokenIndex = position1157, tokenIndex1157
 											if buffer[position] != rune('A') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l705:
+									l1157:
 										{
-											position707, tokenIndex707 := position, tokenIndex
+											position1159, tokenIndex1159 := position, tokenIndex
 											if buffer[position] != rune('s') {
-												goto l708
+												goto l1160
 											}
 											position++
-											goto l707
-										l708:
-											position, tokenIndex = position707, tokenIndex707
+											goto l1159
+										l1160:
+											position, tokenIndex = position1159, tokenIndex1159
 											if buffer[position] != rune('S') {
-												goto l535
+												goto l955
 											}
 											position++
 										}
-									l707:
+									l1159:
 										break
 									}
 								}
 
 							}
-						l537:
-							add(ruleKEYWORD, position536)
+						l957:
+							add(ruleKEYWORD, position956)
 						}
 						if !_rules[ruleKEY]() {
-							goto l535
+							goto l955
 						}
-						goto l527
-					l535:
-						position, tokenIndex = position535, tokenIndex535
+						goto l947
+					l955:
+						position, tokenIndex = position955, tokenIndex955
 					}
 					if !_rules[ruleID_SEGMENT]() {
-						goto l527
+						goto l947
 					}
-				l709:
+				l1161:
 					{
-						position710, tokenIndex710 := position, tokenIndex
+						position1162, tokenIndex1162 := position, tokenIndex
 						if buffer[position] != rune('.') {
-							goto l710
+							goto l1162
 						}
 						position++
 						{
-							position711, tokenIndex711 := position, tokenIndex
+							position1163, tokenIndex1163 := position, tokenIndex
 							if !_rules[ruleID_SEGMENT]() {
-								goto l712
+								goto l1164
 							}
-							goto l711
-						l712:
-							position, tokenIndex = position711, tokenIndex711
+							goto l1163
+						l1164:
+							position, tokenIndex = position1163, tokenIndex1163
 							if !(p.errorHere(position, `expected identifier segment to follow "."`)) {
-								goto l710
+								goto l1162
 							}
 						}
-					l711:
-						goto l709
-					l710:
-						position, tokenIndex = position710, tokenIndex710
+					l1163:
+						goto l1161
+					l1162:
+						position, tokenIndex = position1162, tokenIndex1162
 					}
 				}
-			l529:
-				add(ruleIDENTIFIER, position528)
+			l949:
+				add(ruleIDENTIFIER, position948)
 			}
 			return true
-		l527:
-			position, tokenIndex = position527, tokenIndex527
+		l947:
+			position, tokenIndex = position947, tokenIndex947
 			return false
 		},
-		/* 38 TIMESTAMP <- <((_ <(NUMBER ([a-z] / [A-Z])*)>) / (_ STRING) / (_ <(('n' / 'N') ('o' / 'O') ('w' / 'W'))> KEY))> */
+		/* 50 TIMESTAMP <- <((_ <(NUMBER ([a-z] / [A-Z])*)>) / (_ STRING) / (_ <(('n' / 'N') ('o' / 'O') ('w' / 'W'))> KEY))> */
 		nil,
-		/* 39 ID_SEGMENT <- <(ID_START ID_CONT*)> */
+		/* 51 ID_SEGMENT <- <(ID_START ID_CONT*)> */
 		func() bool {
-			position714, tokenIndex714 := position, tokenIndex
+			position1166, tokenIndex1166 := position, tokenIndex
 			{
-				position715 := position
+				position1167 := position
 				if !_rules[ruleID_START]() {
-					goto l714
+					goto l1166
 				}
-			l716:
+			l1168:
 				{
-					position717, tokenIndex717 := position, tokenIndex
+					position1169, tokenIndex1169 := position, tokenIndex
 					if !_rules[ruleID_CONT]() {
-						goto l717
+						goto l1169
 					}
-					goto l716
-				l717:
-					position, tokenIndex = position717, tokenIndex717
+					goto l1168
+				l1169:
+					position, tokenIndex = position1169, tokenIndex1169
 				}
-				add(ruleID_SEGMENT, position715)
+				add(ruleID_SEGMENT, position1167)
 			}
 			return true
-		l714:
-			position, tokenIndex = position714, tokenIndex714
+		l1166:
+			position, tokenIndex = position1166, tokenIndex1166
 			return false
 		},
-		/* 40 ID_START <- <((&('_') '_') | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))> */
+		/* 52 ID_START <- <((&('_') '_') | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))> */
 		func() bool {
-			position718, tokenIndex718 := position, tokenIndex
+			position1170, tokenIndex1170 := position, tokenIndex
 			{
-				position719 := position
+				position1171 := position
 				{
 					switch buffer[position] {
 					case '_':
 						if buffer[position] != rune('_') {
-							goto l718
+							goto l1170
 						}
 						position++
 						break
 					case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
 						if c := buffer[position]; c < rune('A') || c > rune('Z') {
-							goto l718
+							goto l1170
 						}
 						position++
 						break
 					default:
 						if c := buffer[position]; c < rune('a') || c > rune('z') {
-							goto l718
+							goto l1170
 						}
 						position++
 						break
 					}
 				}
 
-				add(ruleID_START, position719)
+				add(ruleID_START, position1171)
 			}
 			return true
-		l718:
-			position, tokenIndex = position718, tokenIndex718
+		l1170:
+			position, tokenIndex = position1170, tokenIndex1170
 			return false
 		},
-		/* 41 ID_CONT <- <(ID_START / [0-9])> */
+		/* 53 ID_CONT <- <(ID_START / [0-9])> */
 		func() bool {
-			position721, tokenIndex721 := position, tokenIndex
+			position1173, tokenIndex1173 := position, tokenIndex
 			{
-				position722 := position
+				position1174 := position
 				{
-					position723, tokenIndex723 := position, tokenIndex
+					position1175, tokenIndex1175 := position, tokenIndex
 					if !_rules[ruleID_START]() {
-						goto l724
+						goto l1176
 					}
-					goto l723
-				l724:
-					position, tokenIndex = position723, tokenIndex723
+					goto l1175
+				l1176:
+					position, tokenIndex = position1175, tokenIndex1175
 					if c := buffer[position]; c < rune('0') || c > rune('9') {
-						goto l721
+						goto l1173
 					}
 					position++
 				}
-			l723:
-				add(ruleID_CONT, position722)
+			l1175:
+				add(ruleID_CONT, position1174)
 			}
 			return true
-		l721:
-			position, tokenIndex = position721, tokenIndex721
+		l1173:
+			position, tokenIndex = position1173, tokenIndex1173
 			return false
 		},
-		/* 42 PROPERTY_KEY <- <((&('S' | 's') (<(('s' / 'S') ('a' / 'A') ('m' / 'M') ('p' / 'P') ('l' / 'L') ('e' / 'E'))> KEY ((_ (('b' / 'B') ('y' / 'Y')) KEY) / &{ p.errorHere(position, `expected keyword "by" to follow keyword "sample"`) }))) | (&('R' | 'r') (<(('r' / 'R') ('e' / 'E') ('s' / 'S') ('o' / 'O') ('l' / 'L') ('u' / 'U') ('t' / 'T') ('i' / 'I') ('o' / 'O') ('n' / 'N'))> KEY)) | (&('T' | 't') (<(('t' / 'T') ('o' / 'O'))> KEY)) | (&('F' | 'f') (<(('f' / 'F') ('r' / 'R') ('o' / 'O') ('m' / 'M'))> KEY)))> */
+		/* 54 PROPERTY_KEY <- <((&('S' | 's') (<(('s' / 'S') ('a' / 'A') ('m' / 'M') ('p' / 'P') ('l' / 'L') ('e' / 'E'))> KEY ((_ (('b' / 'B') ('y' / 'Y')) KEY) / &{ p.errorHere(position, `expected keyword "by" to follow keyword "sample"`) }))) | (&('R' | 'r') (<(('r' / 'R') ('e' / 'E') ('s' / 'S') ('o' / 'O') ('l' / 'L') ('u' / 'U') ('t' / 'T') ('i' / 'I') ('o' / 'O') ('n' / 'N'))> KEY)) | (&('T' | 't') (<(('t' / 'T') ('o' / 'O'))> KEY)) | (&('F' | 'f') (<(('f' / 'F') ('r' / 'R') ('o' / 'O') ('m' / 'M'))> KEY)))> */
 		nil,
-		/* 43 PROPERTY_VALUE <- <TIMESTAMP> */
+		/* 55 PROPERTY_VALUE <- <TIMESTAMP> */
 		nil,
-		/* 44 KEYWORD <- <((('a' / 'A') ('l' / 'L') ('l' / 'L')) / (('a' / 'A') ('n' / 'N') ('d' / 'D')) / (('m' / 'M') ('a' / 'A') ('t' / 'T') ('c' / 'C') ('h' / 'H')) / (('s' / 'S') ('e' / 'E') ('l' / 'L') ('e' / 'E') ('c' / 'C') ('t' / 'T')) / ((&('S' | 's') (('s' / 'S') ('a' / 'A') ('m' / 'M') ('p' / 'P') ('l' / 'L') ('e' / 'E'))) | (&('R' | 'r') (('r' / 'R') ('e' / 'E') ('s' / 'S') ('o' / 'O') ('l' / 'L') ('u' / 'U') ('t' / 'T') ('i' / 'I') ('o' / 'O') ('n' / 'N'))) | (&('T' | 't') (('t' / 'T') ('o' / 'O'))) | (&('F' | 'f') (('f' / 'F') ('r' / 'R') ('o' / 'O') ('m' / 'M'))) | (&('M' | 'm') (('m' / 'M') ('e' / 'E') ('t' / 'T') ('r' / 'R') ('i' / 'I') ('c' / 'C') ('s' / 'S'))) | (&('W' | 'w') (('w' / 'W') ('h' / 'H') ('e' / 'E') ('r' / 'R') ('e' / 'E'))) | (&('O' | 'o') (('o' / 'O') ('r' / 'R'))) | (&('N' | 'n') (('n' / 'N') ('o' / 'O') ('t' / 'T'))) | (&('I' | 'i') (('i' / 'I') ('n' / 'N'))) | (&('C' | 'c') (('c' / 'C') ('o' / 'O') ('l' / 'L') ('l' / 'L') ('a' / 'A') ('p' / 'P') ('s' / 'S') ('e' / 'E'))) | (&('G' | 'g') (('g' / 'G') ('r' / 'R') ('o' / 'O') ('u' / 'U') ('p' / 'P'))) | (&('D' | 'd') (('d' / 'D') ('e' / 'E') ('s' / 'S') ('c' / 'C') ('r' / 'R') ('i' / 'I') ('b' / 'B') ('e' / 'E'))) | (&('B' | 'b') (('b' / 'B') ('y' / 'Y'))) | (&('A' | 'a') (('a' / 'A') ('s' / 'S')))))> */
+		/* 56 KEYWORD <- <((('a' / 'A') ('l' / 'L') ('l' / 'L')) / (('a' / 'A') ('n' / 'N') ('d' / 'D')) / (('m' / 'M') ('a' / 'A') ('t' / 'T') ('c' / 'C') ('h' / 'H')) / (('s' / 'S') ('e' / 'E') ('l' / 'L') ('e' / 'E') ('c' / 'C') ('t' / 'T')) / ((&('U' | 'u') (('u' / 'U') ('n' / 'N') ('l' / 'L') ('e' / 'E') ('s' / 'S') ('s' / 'S'))) | (&('S' | 's') (('s' / 'S') ('a' / 'A') ('m' / 'M') ('p' / 'P') ('l' / 'L') ('e' / 'E'))) | (&('R' | 'r') (('r' / 'R') ('e' / 'E') ('s' / 'S') ('o' / 'O') ('l' / 'L') ('u' / 'U') ('t' / 'T') ('i' / 'I') ('o' / 'O') ('n' / 'N'))) | (&('T' | 't') (('t' / 'T') ('o' / 'O'))) | (&('F' | 'f') (('f' / 'F') ('r' / 'R') ('o' / 'O') ('m' / 'M'))) | (&('V' | 'v') (('v' / 'V') ('a' / 'A') ('l' / 'L') ('u' / 'U') ('e' / 'E') ('s' / 'S'))) | (&('K' | 'k') (('k' / 'K') ('e' / 'E') ('y' / 'Y') ('s' / 'S'))) | (&('M' | 'm') (('m' / 'M') ('e' / 'E') ('t' / 'T') ('r' / 'R') ('i' / 'I') ('c' / 'C') ('s' / 'S'))) | (&('W' | 'w') (('w' / 'W') ('h' / 'H') ('e' / 'E') ('r' / 'R') ('e' / 'E'))) | (&('O' | 'o') (('o' / 'O') ('r' / 'R'))) | (&('N' | 'n') (('n' / 'N') ('o' / 'O') ('t' / 'T'))) | (&('I' | 'i') (('i' / 'I') ('n' / 'N'))) | (&('C' | 'c') (('c' / 'C') ('o' / 'O') ('l' / 'L') ('l' / 'L') ('a' / 'A') ('p' / 'P') ('s' / 'S') ('e' / 'E'))) | (&('G' | 'g') (('g' / 'G') ('r' / 'R') ('o' / 'O') ('u' / 'U') ('p' / 'P'))) | (&('D' | 'd') (('d' / 'D') ('e' / 'E') ('s' / 'S') ('c' / 'C') ('r' / 'R') ('i' / 'I') ('b' / 'B') ('e' / 'E'))) | (&('B' | 'b') (('b' / 'B') ('y' / 'Y'))) | (&('A' | 'a') (('a' / 'A') ('s' / 'S')))))> */
 		nil,
-		/* 45 OP_PIPE <- <'|'> */
+		/* 57 OP_PIPE <- <'|'> */
 		nil,
-		/* 46 OP_ADD <- <'+'> */
+		/* 58 OP_PIPELINE <- <('|' '>')> */
 		nil,
-		/* 47 OP_SUB <- <'-'> */
+		/* 59 OP_ADD <- <'+'> */
 		nil,
-		/* 48 OP_MULT <- <'*'> */
+		/* 60 OP_SUB <- <'-'> */
 		nil,
-		/* 49 OP_DIV <- <'/'> */
+		/* 61 OP_MULT <- <'*'> */
 		nil,
-		/* 50 OP_AND <- <(('a' / 'A') ('n' / 'N') ('d' / 'D') KEY)> */
+		/* 62 OP_DIV <- <'/'> */
+		nil,
+		/* 63 OP_AND <- <(('a' / 'A') ('n' / 'N') ('d' / 'D') KEY)> */
+		func() bool {
+			position1186, tokenIndex1186 := position, tokenIndex
+			{
+				position1187 := position
+				{
+					position1188, tokenIndex1188 := position, tokenIndex
+					if buffer[position] != rune('a') {
+						goto l1189
+					}
+					position++
+					goto l1188
+				l1189:
+					position, tokenIndex = position1188, tokenIndex1188
+					if buffer[position] != rune('A') {
+						goto l1186
+					}
+					position++
+				}
+			l1188:
+				{
+					position1190, tokenIndex1190 := position, tokenIndex
+					if buffer[position] != rune('n') {
+						goto l1191
+					}
+					position++
+					goto l1190
+				l1191:
+					position, tokenIndex = position1190, tokenIndex1190
+					if buffer[position] != rune('N') {
+						goto l1186
+					}
+					position++
+				}
+			l1190:
+				{
+					position1192, tokenIndex1192 := position, tokenIndex
+					if buffer[position] != rune('d') {
+						goto l1193
+					}
+					position++
+					goto l1192
+				l1193:
+					position, tokenIndex = position1192, tokenIndex1192
+					if buffer[position] != rune('D') {
+						goto l1186
+					}
+					position++
+				}
+			l1192:
+				if !_rules[ruleKEY]() {
+					goto l1186
+				}
+				add(ruleOP_AND, position1187)
+			}
+			return true
+		l1186:
+			position, tokenIndex = position1186, tokenIndex1186
+			return false
+		},
+		/* 64 OP_OR <- <(('o' / 'O') ('r' / 'R') KEY)> */
+		func() bool {
+			position1194, tokenIndex1194 := position, tokenIndex
+			{
+				position1195 := position
+				{
+					position1196, tokenIndex1196 := position, tokenIndex
+					if buffer[position] != rune('o') {
+						goto l1197
+					}
+					position++
+					goto l1196
+				l1197:
+					position, tokenIndex = position1196, tokenIndex1196
+					if buffer[position] != rune('O') {
+						goto l1194
+					}
+					position++
+				}
+			l1196:
+				{
+					position1198, tokenIndex1198 := position, tokenIndex
+					if buffer[position] != rune('r') {
+						goto l1199
+					}
+					position++
+					goto l1198
+				l1199:
+					position, tokenIndex = position1198, tokenIndex1198
+					if buffer[position] != rune('R') {
+						goto l1194
+					}
+					position++
+				}
+			l1198:
+				if !_rules[ruleKEY]() {
+					goto l1194
+				}
+				add(ruleOP_OR, position1195)
+			}
+			return true
+		l1194:
+			position, tokenIndex = position1194, tokenIndex1194
+			return false
+		},
+		/* 65 OP_NOT <- <(('n' / 'N') ('o' / 'O') ('t' / 'T') KEY)> */
 		nil,
-		/* 51 OP_OR <- <(('o' / 'O') ('r' / 'R') KEY)> */
+		/* 66 OP_UNLESS <- <(('u' / 'U') ('n' / 'N') ('l' / 'L') ('e' / 'E') ('s' / 'S') ('s' / 'S') KEY)> */
 		nil,
-		/* 52 OP_NOT <- <(('n' / 'N') ('o' / 'O') ('t' / 'T') KEY)> */
+		/* 67 OP_COMPARE <- <(('>' '=') / ('<' '=') / ((&('<') '<') | (&('>') '>') | (&('!') ('!' '=')) | (&('=') ('=' '='))))> */
 		nil,
-		/* 53 QUOTE_SINGLE <- <'\''> */
+		/* 68 QUOTE_SINGLE <- <'\''> */
 		func() bool {
-			position736, tokenIndex736 := position, tokenIndex
+			position1203, tokenIndex1203 := position, tokenIndex
 			{
-				position737 := position
+				position1204 := position
 				if buffer[position] != rune('\'') {
-					goto l736
+					goto l1203
 				}
 				position++
-				add(ruleQUOTE_SINGLE, position737)
+				add(ruleQUOTE_SINGLE, position1204)
 			}
 			return true
-		l736:
-			position, tokenIndex = position736, tokenIndex736
+		l1203:
+			position, tokenIndex = position1203, tokenIndex1203
 			return false
 		},
-		/* 54 QUOTE_DOUBLE <- <'"'> */
+		/* 69 QUOTE_DOUBLE <- <'"'> */
 		func() bool {
-			position738, tokenIndex738 := position, tokenIndex
+			position1205, tokenIndex1205 := position, tokenIndex
 			{
-				position739 := position
+				position1206 := position
 				if buffer[position] != rune('"') {
-					goto l738
+					goto l1205
 				}
 				position++
-				add(ruleQUOTE_DOUBLE, position739)
+				add(ruleQUOTE_DOUBLE, position1206)
 			}
 			return true
-		l738:
-			position, tokenIndex = position738, tokenIndex738
+		l1205:
+			position, tokenIndex = position1205, tokenIndex1205
 			return false
 		},
-		/* 55 STRING <- <((QUOTE_SINGLE <(!QUOTE_SINGLE CHAR)*> (QUOTE_SINGLE / &{ p.errorHere(position, `expected "'" to close string`) })) / (QUOTE_DOUBLE <(!QUOTE_DOUBLE CHAR)*> (QUOTE_DOUBLE / &{ p.errorHere(position, `expected '"' to close string`) })))> */
+		/* 70 STRING <- <((QUOTE_SINGLE <(!QUOTE_SINGLE CHAR)*> (QUOTE_SINGLE / &{ p.errorHere(position, `expected "'" to close string`) })) / (QUOTE_DOUBLE <(!QUOTE_DOUBLE CHAR)*> (QUOTE_DOUBLE / &{ p.errorHere(position, `expected '"' to close string`) })))> */
 		func() bool {
-			position740, tokenIndex740 := position, tokenIndex
+			position1207, tokenIndex1207 := position, tokenIndex
 			{
-				position741 := position
+				position1208 := position
 				{
-					position742, tokenIndex742 := position, tokenIndex
+					position1209, tokenIndex1209 := position, tokenIndex
 					if !_rules[ruleQUOTE_SINGLE]() {
-						goto l743
+						goto l1210
 					}
 					{
-						position744 := position
-					l745:
+						position1211 := position
+					l1212:
 						{
-							position746, tokenIndex746 := position, tokenIndex
+							position1213, tokenIndex1213 := position, tokenIndex
 							{
-								position747, tokenIndex747 := position, tokenIndex
+								position1214, tokenIndex1214 := position, tokenIndex
 								if !_rules[ruleQUOTE_SINGLE]() {
-									goto l747
+									goto l1214
 								}
-								goto l746
-							l747:
-								position, tokenIndex = position747, tokenIndex747
+								goto l1213
+							l1214:
+								position, tokenIndex = position1214, tokenIndex1214
 							}
 							if !_rules[ruleCHAR]() {
-								goto l746
+								goto l1213
 							}
-							goto l745
-						l746:
-							position, tokenIndex = position746, tokenIndex746
+							goto l1212
+						l1213:
+							position, tokenIndex = position1213, tokenIndex1213
 						}
-						add(rulePegText, position744)
+						add(rulePegText, position1211)
 					}
 					{
-						position748, tokenIndex748 := position, tokenIndex
+						position1215, tokenIndex1215 := position, tokenIndex
 						if !_rules[ruleQUOTE_SINGLE]() {
-							goto l749
+							goto l1216
 						}
-						goto l748
-					l749:
-						position, tokenIndex = position748, tokenIndex748
+						goto l1215
+					l1216:
+						position, tokenIndex = position1215, tokenIndex1215
 						if !(p.errorHere(position, `expected "'" to close string`)) {
-							goto l743
+							goto l1210
 						}
 					}
-				l748:
-					goto l742
-				l743:
-					position, tokenIndex = position742, tokenIndex742
+				l1215:
+					goto l1209
+				l1210:
+					position, tokenIndex = position1209, tokenIndex1209
 					if !_rules[ruleQUOTE_DOUBLE]() {
-						goto l740
+						goto l1207
 					}
 					{
-						position750 := position
-					l751:
+						position1217 := position
+					l1218:
 						{
-							position752, tokenIndex752 := position, tokenIndex
+							position1219, tokenIndex1219 := position, tokenIndex
 							{
-								position753, tokenIndex753 := position, tokenIndex
+								position1220, tokenIndex1220 := position, tokenIndex
 								if !_rules[ruleQUOTE_DOUBLE]() {
-									goto l753
+									goto l1220
 								}
-								goto l752
-							l753:
-								position, tokenIndex = position753, tokenIndex753
+								goto l1219
+							l1220:
+								position, tokenIndex = position1220, tokenIndex1220
 							}
 							if !_rules[ruleCHAR]() {
-								goto l752
+								goto l1219
 							}
-							goto l751
-						l752:
-							position, tokenIndex = position752, tokenIndex752
+							goto l1218
+						l1219:
+							position, tokenIndex = position1219, tokenIndex1219
 						}
-						add(rulePegText, position750)
+						add(rulePegText, position1217)
 					}
 					{
-						position754, tokenIndex754 := position, tokenIndex
+						position1221, tokenIndex1221 := position, tokenIndex
 						if !_rules[ruleQUOTE_DOUBLE]() {
-							goto l755
+							goto l1222
 						}
-						goto l754
-					l755:
-						position, tokenIndex = position754, tokenIndex754
+						goto l1221
+					l1222:
+						position, tokenIndex = position1221, tokenIndex1221
 						if !(p.errorHere(position, `expected '"' to close string`)) {
-							goto l740
+							goto l1207
 						}
 					}
-				l754:
+				l1221:
 				}
-			l742:
-				add(ruleSTRING, position741)
+			l1209:
+				add(ruleSTRING, position1208)
 			}
 			return true
-		l740:
-			position, tokenIndex = position740, tokenIndex740
+		l1207:
+			position, tokenIndex = position1207, tokenIndex1207
 			return false
 		},
-		/* 56 CHAR <- <(('\\' ((&('"') (QUOTE_DOUBLE / &{ p.errorHere(position, "expected \"\\\", \"'\", \"`\", or '\"' to follow \"\\\" in string literal") })) | (&('\'') QUOTE_SINGLE) | (&('\\' | '`') ESCAPE_CLASS))) / (!ESCAPE_CLASS .))> */
+		/* 71 CHAR <- <(('\\' ((&('"') (QUOTE_DOUBLE / &{ p.errorHere(position, "expected \"\\\", \"'\", \"`\", or '\"' to follow \"\\\" in string literal") })) | (&('\'') QUOTE_SINGLE) | (&('\\' | '`') ESCAPE_CLASS))) / (!ESCAPE_CLASS .))> */
 		func() bool {
-			position756, tokenIndex756 := position, tokenIndex
+			position1223, tokenIndex1223 := position, tokenIndex
 			{
-				position757 := position
+				position1224 := position
 				{
-					position758, tokenIndex758 := position, tokenIndex
+					position1225, tokenIndex1225 := position, tokenIndex
 					if buffer[position] != rune('\\') {
-						goto l759
+						goto l1226
 					}
 					position++
 					{
 						switch buffer[position] {
 						case '"':
 							{
-								position761, tokenIndex761 := position, tokenIndex
+								position1228, tokenIndex1228 := position, tokenIndex
 								if !_rules[ruleQUOTE_DOUBLE]() {
-									goto l762
+									goto l1229
 								}
-								goto l761
-							l762:
-								position, tokenIndex = position761, tokenIndex761
+								goto l1228
+							l1229:
+								position, tokenIndex = position1228, tokenIndex1228
 								if !(p.errorHere(position, "expected \"\\\", \"'\", \"`\", or '\"' to follow \"\\\" in string literal")) {
-									goto l759
+									goto l1226
 								}
 							}
-						l761:
+						l1228:
 							break
 						case '\'':
 							if !_rules[ruleQUOTE_SINGLE]() {
-								goto l759
+								goto l1226
 							}
 							break
 						default:
 							if !_rules[ruleESCAPE_CLASS]() {
-								goto l759
+								goto l1226
 							}
 							break
 						}
 					}
 
-					goto l758
-				l759:
-					position, tokenIndex = position758, tokenIndex758
+					goto l1225
+				l1226:
+					position, tokenIndex = position1225, tokenIndex1225
 					{
-						position763, tokenIndex763 := position, tokenIndex
+						position1230, tokenIndex1230 := position, tokenIndex
 						if !_rules[ruleESCAPE_CLASS]() {
-							goto l763
+							goto l1230
 						}
-						goto l756
-					l763:
-						position, tokenIndex = position763, tokenIndex763
+						goto l1223
+					l1230:
+						position, tokenIndex = position1230, tokenIndex1230
 					}
 					if !matchDot() {
-						goto l756
+						goto l1223
 					}
 				}
-			l758:
-				add(ruleCHAR, position757)
+			l1225:
+				add(ruleCHAR, position1224)
 			}
 			return true
-		l756:
-			position, tokenIndex = position756, tokenIndex756
+		l1223:
+			position, tokenIndex = position1223, tokenIndex1223
 			return false
 		},
-		/* 57 ESCAPE_CLASS <- <('`' / '\\')> */
+		/* 72 ESCAPE_CLASS <- <('`' / '\\')> */
 		func() bool {
-			position764, tokenIndex764 := position, tokenIndex
+			position1231, tokenIndex1231 := position, tokenIndex
 			{
-				position765 := position
+				position1232 := position
 				{
-					position766, tokenIndex766 := position, tokenIndex
+					position1233, tokenIndex1233 := position, tokenIndex
 					if buffer[position] != rune('`') {
-						goto l767
+						goto l1234
 					}
 					position++
-					goto l766
-				l767:
-					position, tokenIndex = position766, tokenIndex766
+					goto l1233
+				l1234:
+					position, tokenIndex = position1233, tokenIndex1233
 					if buffer[position] != rune('\\') {
-						goto l764
+						goto l1231
 					}
 					position++
 				}
-			l766:
-				add(ruleESCAPE_CLASS, position765)
+			l1233:
+				add(ruleESCAPE_CLASS, position1232)
 			}
 			return true
-		l764:
-			position, tokenIndex = position764, tokenIndex764
+		l1231:
+			position, tokenIndex = position1231, tokenIndex1231
 			return false
 		},
-		/* 58 NUMBER <- <(NUMBER_INTEGER NUMBER_FRACTION? NUMBER_EXP?)> */
+		/* 73 NUMBER <- <(NUMBER_INTEGER NUMBER_FRACTION? NUMBER_EXP?)> */
 		func() bool {
-			position768, tokenIndex768 := position, tokenIndex
+			position1235, tokenIndex1235 := position, tokenIndex
 			{
-				position769 := position
+				position1236 := position
 				{
-					position770 := position
+					position1237 := position
 					{
-						position771, tokenIndex771 := position, tokenIndex
+						position1238, tokenIndex1238 := position, tokenIndex
 						if buffer[position] != rune('-') {
-							goto l771
+							goto l1238
 						}
 						position++
-						goto l772
-					l771:
-						position, tokenIndex = position771, tokenIndex771
+						goto l1239
+					l1238:
+						position, tokenIndex = position1238, tokenIndex1238
 					}
-				l772:
-					{
-						position773 := position
-						{
-							position774, tokenIndex774 := position, tokenIndex
-							if buffer[position] != rune('0') {
-								goto l775
-							}
-							position++
-							goto l774
-						l775:
-							position, tokenIndex = position774, tokenIndex774
-							if c := buffer[position]; c < rune('1') || c > rune('9') {
-								goto l768
-							}
-							position++
-						l776:
-							{
-								position777, tokenIndex777 := position, tokenIndex
-								if c := buffer[position]; c < rune('0') || c > rune('9') {
-									goto l777
-								}
-								position++
-								goto l776
-							l777:
-								position, tokenIndex = position777, tokenIndex777
-							}
-						}
-					l774:
-						add(ruleNUMBER_NATURAL, position773)
+				l1239:
+					if !_rules[ruleNUMBER_NATURAL]() {
+						goto l1235
 					}
-					add(ruleNUMBER_INTEGER, position770)
+					add(ruleNUMBER_INTEGER, position1237)
 				}
 				{
-					position778, tokenIndex778 := position, tokenIndex
+					position1240, tokenIndex1240 := position, tokenIndex
 					{
-						position780 := position
+						position1242 := position
 						if buffer[position] != rune('.') {
-							goto l778
+							goto l1240
 						}
 						position++
 						if c := buffer[position]; c < rune('0') || c > rune('9') {
-							goto l778
+							goto l1240
 						}
 						position++
-					l781:
+					l1243:
 						{
-							position782, tokenIndex782 := position, tokenIndex
+							position1244, tokenIndex1244 := position, tokenIndex
 							if c := buffer[position]; c < rune('0') || c > rune('9') {
-								goto l782
+								goto l1244
 							}
 							position++
-							goto l781
-						l782:
-							position, tokenIndex = position782, tokenIndex782
+							goto l1243
+						l1244:
+							position, tokenIndex = position1244, tokenIndex1244
 						}
-						add(ruleNUMBER_FRACTION, position780)
+						add(ruleNUMBER_FRACTION, position1242)
 					}
-					goto l779
-				l778:
-					position, tokenIndex = position778, tokenIndex778
+					goto l1241
+				l1240:
+					position, tokenIndex = position1240, tokenIndex1240
 				}
-			l779:
+			l1241:
 				{
-					position783, tokenIndex783 := position, tokenIndex
+					position1245, tokenIndex1245 := position, tokenIndex
 					{
-						position785 := position
+						position1247 := position
 						{
-							position786, tokenIndex786 := position, tokenIndex
+							position1248, tokenIndex1248 := position, tokenIndex
 							if buffer[position] != rune('e') {
-								goto l787
+								goto l1249
 							}
 							position++
-							goto l786
-						l787:
-							position, tokenIndex = position786, tokenIndex786
+							goto l1248
+						l1249:
+							position, tokenIndex = position1248, tokenIndex1248
 							if buffer[position] != rune('E') {
-								goto l783
+								goto l1245
 							}
 							position++
 						}
-					l786:
+					l1248:
 						{
-							position788, tokenIndex788 := position, tokenIndex
+							position1250, tokenIndex1250 := position, tokenIndex
 							{
-								position790, tokenIndex790 := position, tokenIndex
+								position1252, tokenIndex1252 := position, tokenIndex
 								if buffer[position] != rune('+') {
-									goto l791
+									goto l1253
 								}
 								position++
-								goto l790
-							l791:
-								position, tokenIndex = position790, tokenIndex790
+								goto l1252
+							l1253:
+								position, tokenIndex = position1252, tokenIndex1252
 								if buffer[position] != rune('-') {
-									goto l788
+									goto l1250
 								}
 								position++
 							}
-						l790:
-							goto l789
-						l788:
-							position, tokenIndex = position788, tokenIndex788
+						l1252:
+							goto l1251
+						l1250:
+							position, tokenIndex = position1250, tokenIndex1250
 						}
-					l789:
+					l1251:
 						{
-							position792, tokenIndex792 := position, tokenIndex
+							position1254, tokenIndex1254 := position, tokenIndex
 							if c := buffer[position]; c < rune('0') || c > rune('9') {
-								goto l793
+								goto l1255
 							}
 							position++
-						l794:
+						l1256:
 							{
-								position795, tokenIndex795 := position, tokenIndex
+								position1257, tokenIndex1257 := position, tokenIndex
 								if c := buffer[position]; c < rune('0') || c > rune('9') {
-									goto l795
+									goto l1257
 								}
 								position++
-								goto l794
-							l795:
-								position, tokenIndex = position795, tokenIndex795
+								goto l1256
+							l1257:
+								position, tokenIndex = position1257, tokenIndex1257
 							}
-							goto l792
-						l793:
-							position, tokenIndex = position792, tokenIndex792
+							goto l1254
+						l1255:
+							position, tokenIndex = position1254, tokenIndex1254
 							if !(p.errorHere(position, `expected exponent`)) {
-								goto l783
+								goto l1245
 							}
 						}
-					l792:
-						add(ruleNUMBER_EXP, position785)
+					l1254:
+						add(ruleNUMBER_EXP, position1247)
 					}
-					goto l784
-				l783:
-					position, tokenIndex = position783, tokenIndex783
+					goto l1246
+				l1245:
+					position, tokenIndex = position1245, tokenIndex1245
 				}
-			l784:
-				add(ruleNUMBER, position769)
+			l1246:
+				add(ruleNUMBER, position1236)
 			}
 			return true
-		l768:
-			position, tokenIndex = position768, tokenIndex768
+		l1235:
+			position, tokenIndex = position1235, tokenIndex1235
 			return false
 		},
-		/* 59 NUMBER_NATURAL <- <('0' / ([1-9] [0-9]*))> */
-		nil,
-		/* 60 NUMBER_FRACTION <- <('.' [0-9]+)> */
+		/* 74 NUMBER_NATURAL <- <('0' / ([1-9] [0-9]*))> */
+		func() bool {
+			position1258, tokenIndex1258 := position, tokenIndex
+			{
+				position1259 := position
+				{
+					position1260, tokenIndex1260 := position, tokenIndex
+					if buffer[position] != rune('0') {
+						goto l1261
+					}
+					position++
+					goto l1260
+				l1261:
+					position, tokenIndex = position1260, tokenIndex1260
+					if c := buffer[position]; c < rune('1') || c > rune('9') {
+						goto l1258
+					}
+					position++
+				l1262:
+					{
+						position1263, tokenIndex1263 := position, tokenIndex
+						if c := buffer[position]; c < rune('0') || c > rune('9') {
+							goto l1263
+						}
+						position++
+						goto l1262
+					l1263:
+						position, tokenIndex = position1263, tokenIndex1263
+					}
+				}
+			l1260:
+				add(ruleNUMBER_NATURAL, position1259)
+			}
+			return true
+		l1258:
+			position, tokenIndex = position1258, tokenIndex1258
+			return false
+		},
+		/* 75 NUMBER_FRACTION <- <('.' [0-9]+)> */
 		nil,
-		/* 61 NUMBER_INTEGER <- <('-'? NUMBER_NATURAL)> */
+		/* 76 NUMBER_INTEGER <- <('-'? NUMBER_NATURAL)> */
 		nil,
-		/* 62 NUMBER_EXP <- <(('e' / 'E') ('+' / '-')? ([0-9]+ / &{ p.errorHere(position, `expected exponent`) }))> */
+		/* 77 NUMBER_EXP <- <(('e' / 'E') ('+' / '-')? ([0-9]+ / &{ p.errorHere(position, `expected exponent`) }))> */
 		nil,
-		/* 63 DURATION <- <(NUMBER [a-z]+ KEY)> */
+		/* 78 DURATION <- <(NUMBER [a-z]+ KEY)> */
 		nil,
-		/* 64 PAREN_OPEN <- <'('> */
+		/* 79 PAREN_OPEN <- <'('> */
 		func() bool {
-			position801, tokenIndex801 := position, tokenIndex
+			position1268, tokenIndex1268 := position, tokenIndex
 			{
-				position802 := position
+				position1269 := position
 				if buffer[position] != rune('(') {
-					goto l801
+					goto l1268
 				}
 				position++
-				add(rulePAREN_OPEN, position802)
+				add(rulePAREN_OPEN, position1269)
 			}
 			return true
-		l801:
-			position, tokenIndex = position801, tokenIndex801
+		l1268:
+			position, tokenIndex = position1268, tokenIndex1268
 			return false
 		},
-		/* 65 PAREN_CLOSE <- <')'> */
+		/* 80 PAREN_CLOSE <- <')'> */
 		func() bool {
-			position803, tokenIndex803 := position, tokenIndex
+			position1270, tokenIndex1270 := position, tokenIndex
 			{
-				position804 := position
+				position1271 := position
 				if buffer[position] != rune(')') {
-					goto l803
+					goto l1270
 				}
 				position++
-				add(rulePAREN_CLOSE, position804)
+				add(rulePAREN_CLOSE, position1271)
 			}
 			return true
-		l803:
-			position, tokenIndex = position803, tokenIndex803
+		l1270:
+			position, tokenIndex = position1270, tokenIndex1270
 			return false
 		},
-		/* 66 COMMA <- <','> */
+		/* 81 COMMA <- <','> */
 		func() bool {
-			position805, tokenIndex805 := position, tokenIndex
+			position1272, tokenIndex1272 := position, tokenIndex
 			{
-				position806 := position
+				position1273 := position
 				if buffer[position] != rune(',') {
-					goto l805
+					goto l1272
 				}
 				position++
-				add(ruleCOMMA, position806)
+				add(ruleCOMMA, position1273)
 			}
 			return true
-		l805:
-			position, tokenIndex = position805, tokenIndex805
+		l1272:
+			position, tokenIndex = position1272, tokenIndex1272
 			return false
 		},
-		/* 67 _ <- <((&('/') COMMENT_BLOCK) | (&('-') COMMENT_TRAIL) | (&('\t' | '\n' | ' ') SPACE))*> */
+		/* 82 _ <- <((&('/') COMMENT_BLOCK) | (&('-') COMMENT_TRAIL) | (&('\t' | '\n' | ' ') SPACE))*> */
 		func() bool {
 			{
-				position808 := position
-			l809:
+				position1275 := position
+			l1276:
 				{
-					position810, tokenIndex810 := position, tokenIndex
+					position1277, tokenIndex1277 := position, tokenIndex
 					{
 						switch buffer[position] {
 						case '/':
 							{
-								position812 := position
+								position1279 := position
 								if buffer[position] != rune('/') {
-									goto l810
+									goto l1277
 								}
 								position++
 								if buffer[position] != rune('*') {
-									goto l810
+									goto l1277
 								}
 								position++
-							l813:
+							l1280:
 								{
-									position814, tokenIndex814 := position, tokenIndex
+									position1281, tokenIndex1281 := position, tokenIndex
 									{
-										position815, tokenIndex815 := position, tokenIndex
+										position1282, tokenIndex1282 := position, tokenIndex
 										if buffer[position] != rune('*') {
-											goto l815
+											goto l1282
 										}
 										position++
 										if buffer[position] != rune('/') {
-											goto l815
+											goto l1282
 										}
 										position++
-										goto l814
-									l815:
-										position, tokenIndex = position815, tokenIndex815
+										goto l1281
+									l1282:
+										position, tokenIndex = position1282, tokenIndex1282
 									}
 									if !matchDot() {
-										goto l814
+										goto l1281
 									}
-									goto l813
-								l814:
-									position, tokenIndex = position814, tokenIndex814
+									goto l1280
+								l1281:
+									position, tokenIndex = position1281, tokenIndex1281
 								}
 								if buffer[position] != rune('*') {
-									goto l810
+									goto l1277
 								}
 								position++
 								if buffer[position] != rune('/') {
-									goto l810
+									goto l1277
 								}
 								position++
-								add(ruleCOMMENT_BLOCK, position812)
+								add(ruleCOMMENT_BLOCK, position1279)
 							}
 							break
 						case '-':
 							{
-								position816 := position
+								position1283 := position
 								if buffer[position] != rune('-') {
-									goto l810
+									goto l1277
 								}
 								position++
 								if buffer[position] != rune('-') {
-									goto l810
+									goto l1277
 								}
 								position++
-							l817:
+							l1284:
 								{
-									position818, tokenIndex818 := position, tokenIndex
+									position1285, tokenIndex1285 := position, tokenIndex
 									{
-										position819, tokenIndex819 := position, tokenIndex
+										position1286, tokenIndex1286 := position, tokenIndex
 										if buffer[position] != rune('\n') {
-											goto l819
+											goto l1286
 										}
 										position++
-										goto l818
-									l819:
-										position, tokenIndex = position819, tokenIndex819
+										goto l1285
+									l1286:
+										position, tokenIndex = position1286, tokenIndex1286
 									}
 									if !matchDot() {
-										goto l818
+										goto l1285
 									}
-									goto l817
-								l818:
-									position, tokenIndex = position818, tokenIndex818
+									goto l1284
+								l1285:
+									position, tokenIndex = position1285, tokenIndex1285
 								}
-								add(ruleCOMMENT_TRAIL, position816)
+								add(ruleCOMMENT_TRAIL, position1283)
 							}
 							break
 						default:
 							{
-								position820 := position
+								position1287 := position
 								{
 									switch buffer[position] {
 									case '\t':
 										if buffer[position] != rune('\t') {
-											goto l810
+											goto l1277
 										}
 										position++
 										break
 									case '\n':
 										if buffer[position] != rune('\n') {
-											goto l810
+											goto l1277
 										}
 										position++
 										break
 									default:
 										if buffer[position] != rune(' ') {
-											goto l810
+											goto l1277
 										}
 										position++
 										break
 									}
 								}
 
-								add(ruleSPACE, position820)
+								add(ruleSPACE, position1287)
 							}
 							break
 						}
 					}
 
-					goto l809
-				l810:
-					position, tokenIndex = position810, tokenIndex810
+					goto l1276
+				l1277:
+					position, tokenIndex = position1277, tokenIndex1277
 				}
-				add(rule_, position808)
+				add(rule_, position1275)
 			}
 			return true
 		},
-		/* 68 COMMENT_TRAIL <- <('-' '-' (!'\n' .)*)> */
+		/* 83 COMMENT_TRAIL <- <('-' '-' (!'\n' .)*)> */
 		nil,
-		/* 69 COMMENT_BLOCK <- <('/' '*' (!('*' '/') .)* ('*' '/'))> */
+		/* 84 COMMENT_BLOCK <- <('/' '*' (!('*' '/') .)* ('*' '/'))> */
 		nil,
-		/* 70 KEY <- <!ID_CONT> */
+		/* 85 KEY <- <!ID_CONT> */
 		func() bool {
-			position824, tokenIndex824 := position, tokenIndex
+			position1291, tokenIndex1291 := position, tokenIndex
 			{
-				position825 := position
+				position1292 := position
 				{
-					position826, tokenIndex826 := position, tokenIndex
+					position1293, tokenIndex1293 := position, tokenIndex
 					if !_rules[ruleID_CONT]() {
-						goto l826
+						goto l1293
 					}
-					goto l824
-				l826:
-					position, tokenIndex = position826, tokenIndex826
+					goto l1291
+				l1293:
+					position, tokenIndex = position1293, tokenIndex1293
 				}
-				add(ruleKEY, position825)
+				add(ruleKEY, position1292)
 			}
 			return true
-		l824:
-			position, tokenIndex = position824, tokenIndex824
+		l1291:
+			position, tokenIndex = position1291, tokenIndex1291
 			return false
 		},
-		/* 71 SPACE <- <((&('\t') '\t') | (&('\n') '\n') | (&(' ') ' '))> */
+		/* 86 SPACE <- <((&('\t') '\t') | (&('\n') '\n') | (&(' ') ' '))> */
+		nil,
+		/* 88 Action0 <- <{ p.makeSelect() }> */
+		nil,
 		nil,
-		/* 73 Action0 <- <{ p.makeSelect() }> */
+		/* 90 Action1 <- <{ p.pushString(unescapeLiteral(text)) }> */
 		nil,
-		/* 74 Action1 <- <{ p.makeDescribeAll() }> */
+		/* 91 Action2 <- <{ p.addBinding() }> */
 		nil,
-		/* 75 Action2 <- <{ p.addNullMatchClause() }> */
+		/* 92 Action3 <- <{ p.pushString(text) }> */
 		nil,
-		/* 76 Action3 <- <{ p.addMatchClause() }> */
+		/* 93 Action4 <- <{ p.makeForecast() }> */
 		nil,
-		/* 77 Action4 <- <{ p.makeDescribeMetrics() }> */
+		/* 94 Action5 <- <{ p.makeExplain() }> */
 		nil,
+		/* 95 Action6 <- <{ p.makeDescribeAll() }> */
 		nil,
-		/* 79 Action5 <- <{ p.pushString(unescapeLiteral(text)) }> */
+		/* 96 Action7 <- <{ p.addNullMatchClause() }> */
 		nil,
-		/* 80 Action6 <- <{ p.makeDescribe() }> */
+		/* 97 Action8 <- <{ p.addMatchClause() }> */
 		nil,
-		/* 81 Action7 <- <{ p.addEvaluationContext() }> */
+		/* 98 Action9 <- <{ p.pushString(unescapeLiteral(text)) }> */
 		nil,
-		/* 82 Action8 <- <{ p.addPropertyKey(text) }> */
+		/* 99 Action10 <- <{ p.makeDescribeKeys() }> */
 		nil,
-		/* 83 Action9 <- <{
+		/* 100 Action11 <- <{ p.pushString(text) }> */
+		nil,
+		/* 101 Action12 <- <{ p.pushString("all") }> */
+		nil,
+		/* 102 Action13 <- <{ p.makeDescribeValues() }> */
+		nil,
+		/* 103 Action14 <- <{ p.addLiteralList() }> */
+		nil,
+		/* 104 Action15 <- <{ p.appendLiteral(unescapeLiteral(text)) }> */
+		nil,
+		/* 105 Action16 <- <{ p.appendLiteral(unescapeLiteral(text)) }> */
+		nil,
+		/* 106 Action17 <- <{ p.makeDescribeMetrics() }> */
+		nil,
+		/* 107 Action18 <- <{ p.pushString(unescapeLiteral(text)) }> */
+		nil,
+		/* 108 Action19 <- <{ p.makeDescribe() }> */
+		nil,
+		/* 109 Action20 <- <{ p.addEvaluationContext() }> */
+		nil,
+		/* 110 Action21 <- <{ p.addSamplePercent(text) }> */
+		nil,
+		/* 111 Action22 <- <{ p.addPropertyKey(text) }> */
+		nil,
+		/* 112 Action23 <- <{
 		   p.addPropertyValue(text) }> */
 		nil,
-		/* 84 Action10 <- <{ p.insertPropertyKeyValue() }> */
+		/* 113 Action24 <- <{ p.insertPropertyKeyValue() }> */
+		nil,
+		/* 114 Action25 <- <{ p.addOrderBy(text) }> */
+		nil,
+		/* 115 Action26 <- <{ p.addOrderDirection(text) }> */
+		nil,
+		/* 116 Action27 <- <{ p.addLimit(text) }> */
+		nil,
+		/* 117 Action28 <- <{ p.addFill(text) }> */
 		nil,
-		/* 85 Action11 <- <{ p.checkPropertyClause() }> */
+		/* 118 Action29 <- <{ p.checkPropertyClause() }> */
 		nil,
-		/* 86 Action12 <- <{ p.addNullPredicate() }> */
+		/* 119 Action30 <- <{ p.addNullPredicate() }> */
 		nil,
-		/* 87 Action13 <- <{ p.addExpressionList() }> */
+		/* 120 Action31 <- <{ p.addExpressionList() }> */
 		nil,
-		/* 88 Action14 <- <{ p.appendExpression() }> */
+		/* 121 Action32 <- <{ p.appendExpression() }> */
 		nil,
-		/* 89 Action15 <- <{ p.appendExpression() }> */
+		/* 122 Action33 <- <{ p.appendExpression() }> */
 		nil,
-		/* 90 Action16 <- <{ p.addOperatorLiteral("+") }> */
+		/* 123 Action34 <- <{ p.addOperatorLiteral("or") }> */
 		nil,
-		/* 91 Action17 <- <{ p.addOperatorLiteral("-") }> */
+		/* 124 Action35 <- <{ p.addOperatorFunction() }> */
 		nil,
-		/* 92 Action18 <- <{ p.addOperatorFunction() }> */
+		/* 125 Action36 <- <{ p.addOperatorLiteral("and") }> */
 		nil,
-		/* 93 Action19 <- <{ p.addOperatorLiteral("/") }> */
+		/* 126 Action37 <- <{ p.addOperatorLiteral("unless") }> */
 		nil,
-		/* 94 Action20 <- <{ p.addOperatorLiteral("*") }> */
+		/* 127 Action38 <- <{ p.addOperatorFunction() }> */
 		nil,
-		/* 95 Action21 <- <{ p.addOperatorFunction() }> */
+		/* 128 Action39 <- <{ p.addOperatorLiteral(text) }> */
 		nil,
-		/* 96 Action22 <- <{ p.pushString(unescapeLiteral(text)) }> */
+		/* 129 Action40 <- <{ p.addOperatorFunction() }> */
 		nil,
-		/* 97 Action23 <- <{p.addExpressionList()}> */
+		/* 130 Action41 <- <{ p.addOperatorLiteral("+") }> */
 		nil,
-		/* 98 Action24 <- <{
+		/* 131 Action42 <- <{ p.addOperatorLiteral("-") }> */
+		nil,
+		/* 132 Action43 <- <{ p.addOperatorFunction() }> */
+		nil,
+		/* 133 Action44 <- <{ p.addOperatorLiteral("/") }> */
+		nil,
+		/* 134 Action45 <- <{ p.addOperatorLiteral("*") }> */
+		nil,
+		/* 135 Action46 <- <{ p.addOperatorFunction() }> */
+		nil,
+		/* 136 Action47 <- <{ p.addGroupBy() }> */
+		nil,
+		/* 137 Action48 <- <{ p.addCollapseBy() }> */
+		nil,
+		/* 138 Action49 <- <{ p.addGroupBy() }> */
+		nil,
+		/* 139 Action50 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
+		nil,
+		/* 140 Action51 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
+		nil,
+		/* 141 Action52 <- <{ p.pushFunctionName(unescapeLiteral(text), begin) }> */
+		nil,
+		/* 142 Action53 <- <{p.addExpressionList()}> */
+		nil,
+		/* 143 Action54 <- <{
 		   p.addExpressionList()
 		   p.addGroupBy()
 		 }> */
 		nil,
-		/* 99 Action25 <- <{ p.addPipeExpression() }> */
+		/* 144 Action55 <- <{ p.addPipeExpression() }> */
+		nil,
+		/* 145 Action56 <- <{ p.addDurationNode(text) }> */
+		nil,
+		/* 146 Action57 <- <{ p.addNumberNode(text) }> */
+		nil,
+		/* 147 Action58 <- <{ p.addStringNode(unescapeLiteral(text)) }> */
+		nil,
+		/* 148 Action59 <- <{ p.addAnnotationExpression(text) }> */
 		nil,
-		/* 100 Action26 <- <{ p.addDurationNode(text) }> */
+		/* 149 Action60 <- <{ p.addGroupBy() }> */
 		nil,
-		/* 101 Action27 <- <{ p.addNumberNode(text) }> */
+		/* 150 Action61 <- <{ p.pushFunctionName(unescapeLiteral(text), begin) }> */
 		nil,
-		/* 102 Action28 <- <{ p.addStringNode(unescapeLiteral(text)) }> */
+		/* 151 Action62 <- <{ p.addFunctionInvocation() }> */
 		nil,
-		/* 103 Action29 <- <{ p.addAnnotationExpression(text) }> */
+		/* 152 Action63 <- <{ p.pushMetricName(unescapeLiteral(text), begin) }> */
 		nil,
-		/* 104 Action30 <- <{ p.addGroupBy() }> */
+		/* 153 Action64 <- <{ p.addNullPredicate() }> */
 		nil,
-		/* 105 Action31 <- <{ p.pushString(unescapeLiteral(text)) }> */
+		/* 154 Action65 <- <{ p.addMetricExpression() }> */
 		nil,
-		/* 106 Action32 <- <{ p.addFunctionInvocation() }> */
+		/* 155 Action66 <- <{ p.addGroupBy() }> */
 		nil,
-		/* 107 Action33 <- <{ p.pushString(unescapeLiteral(text)) }> */
+		/* 156 Action67 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
 		nil,
-		/* 108 Action34 <- <{ p.addNullPredicate() }> */
+		/* 157 Action68 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
 		nil,
-		/* 109 Action35 <- <{ p.addMetricExpression() }> */
+		/* 158 Action69 <- <{ p.addCollapseBy() }> */
 		nil,
-		/* 110 Action36 <- <{ p.addGroupBy() }> */
+		/* 159 Action70 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
 		nil,
-		/* 111 Action37 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
+		/* 160 Action71 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
 		nil,
-		/* 112 Action38 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
+		/* 161 Action72 <- <{ p.addOrPredicate() }> */
 		nil,
-		/* 113 Action39 <- <{ p.addCollapseBy() }> */
+		/* 162 Action73 <- <{ p.addAndPredicate() }> */
 		nil,
-		/* 114 Action40 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
+		/* 163 Action74 <- <{ p.addNotPredicate() }> */
 		nil,
-		/* 115 Action41 <- <{ p.appendGroupTag(unescapeLiteral(text)) }> */
+		/* 164 Action75 <- <{ p.addLiteralMatcher() }> */
 		nil,
-		/* 116 Action42 <- <{ p.addOrPredicate() }> */
+		/* 165 Action76 <- <{ p.addLiteralMatcher() }> */
 		nil,
-		/* 117 Action43 <- <{ p.addAndPredicate() }> */
+		/* 166 Action77 <- <{ p.addNotPredicate() }> */
 		nil,
-		/* 118 Action44 <- <{ p.addNotPredicate() }> */
+		/* 167 Action78 <- <{ p.addRegexMatcher() }> */
 		nil,
-		/* 119 Action45 <- <{ p.addLiteralMatcher() }> */
+		/* 168 Action79 <- <{ p.addCIDRMatcher() }> */
 		nil,
-		/* 120 Action46 <- <{ p.addLiteralMatcher() }> */
+		/* 169 Action80 <- <{ p.addCIDRListMatcher() }> */
 		nil,
-		/* 121 Action47 <- <{ p.addNotPredicate() }> */
+		/* 170 Action81 <- <{ p.addGlobMatcher() }> */
 		nil,
-		/* 122 Action48 <- <{ p.addRegexMatcher() }> */
+		/* 171 Action82 <- <{ p.addGlobListMatcher() }> */
 		nil,
-		/* 123 Action49 <- <{ p.addListMatcher() }> */
+		/* 172 Action83 <- <{ p.addListMatcher() }> */
 		nil,
-		/* 124 Action50 <- <{ p.pushString(unescapeLiteral(text)) }> */
+		/* 173 Action84 <- <{ p.pushString(unescapeLiteral(text)) }> */
 		nil,
-		/* 125 Action51 <- <{ p.addLiteralList() }> */
+		/* 174 Action85 <- <{ p.addLiteralList() }> */
 		nil,
-		/* 126 Action52 <- <{ p.appendLiteral(unescapeLiteral(text)) }> */
+		/* 175 Action86 <- <{ p.appendLiteral(unescapeLiteral(text)) }> */
 		nil,
-		/* 127 Action53 <- <{ p.addTagLiteral(unescapeLiteral(text)) }> */
+		/* 176 Action87 <- <{ p.addTagLiteral(unescapeLiteral(text)) }> */
 		nil,
 	}
 	p.rules = _rules
@@ -14,7 +14,12 @@
 
 package parser
 
-import "github.com/square/metrics/timeseries"
+import (
+	"time"
+
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/timeseries"
+)
 
 type any interface{} // fixes a bug in gopeg
 
@@ -33,6 +38,18 @@ type tagLiteral string
 // a single operator
 type operatorLiteral string
 
+// functionNameLiteral is the name of an invoked function, along with its location in the query.
+type functionNameLiteral struct {
+	name     string
+	position string
+}
+
+// metricNameLiteral is the name of a metric along with its position in the query.
+type metricNameLiteral struct {
+	name     string
+	position string
+}
+
 // evaluationContextKey represents a key (from, to, sampleby) for the evaluation context.
 type evaluationContextKey string
 
@@ -41,9 +58,14 @@ type evaluationContextValue string
 
 // evaluationContextMap represents a collection of key-value pairs that form the evaluation context.
 type evaluationContextNode struct {
-	Start        int64                         // Start of data timerange
-	End          int64                         // End of data timerange
-	Resolution   int64                         // Resolution of data timerange
+	Start        time.Time                     // Start of data timerange
+	End          time.Time                     // End of data timerange
+	Resolution   time.Duration                 // Resolution of data timerange
 	SampleMethod timeseries.SampleMethod       // to use when up/downsampling to match requested resolution
+	OrderBy      string                        // summary used to order the series of each expression
+	Descending   bool                          // whether the order is descending
+	Limit        int                           // maximum number of series of each expression
+	Sample       float64                       // percentage of the matching series to fetch
+	Fill         command.FillPolicy            // how the missing values of the results are filled
 	assigned     map[evaluationContextKey]bool // a map for knowing which elements of the context have been assigned
 }
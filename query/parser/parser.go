@@ -138,8 +138,21 @@ type Assert struct {
 // A ParserError wraps an error raised during parser execution.
 type ParserError error
 
-func Parse(query string) (commandResult command.Command, finalErr error) {
+// Parse parses the query into a command. Comments are ignored; use
+// ParseWithDirectives to find the directives within them.
+func Parse(query string) (command.Command, error) {
 	p := Parser{Buffer: query}
+	return p.run()
+}
+
+// ParseAt parses the query as Parse does, measuring relative dates (such as
+// "-1h" and "now") from the given time rather than the current time.
+func ParseAt(query string, now time.Time) (command.Command, error) {
+	p := Parser{Buffer: query, now: now}
+	return p.run()
+}
+
+func (p *Parser) run() (commandResult command.Command, finalErr error) {
 	p.Init()
 	defer func() {
 		r := recover()
@@ -151,7 +164,10 @@ func Parse(query string) (commandResult command.Command, finalErr error) {
 			return
 		}
 		if parserError, ok := r.(ParserError); ok {
-			finalErr = error(parserError)
+			finalErr = SyntaxErrors([]SyntaxError{{
+				token:   "",
+				message: parserError.Error(),
+			}})
 			return
 		}
 		panic(r) // Can't catch it
@@ -162,7 +178,7 @@ func Parse(query string) (commandResult command.Command, finalErr error) {
 		if _, ok := err.(*parseError); ok {
 			return nil, SyntaxErrors([]SyntaxError{{
 				token:   "",
-				message: customParseError(&p),
+				message: customParseError(p),
 			}})
 		}
 		// generic error (should not occur).
@@ -230,6 +246,16 @@ func (p *Parser) pushString(node string) {
 	p.pushNode(node)
 }
 
+// pushFunctionName pushes the name of a function with its location in the query.
+func (p *Parser) pushFunctionName(name string, begin int) {
+	p.pushNode(functionNameLiteral{name: name, position: p.currentPosition(uint32(begin))})
+}
+
+// pushMetricName pushes the name of a metric with its location in the query.
+func (p *Parser) pushMetricName(name string, begin int) {
+	p.pushNode(metricNameLiteral{name: name, position: p.currentPosition(uint32(begin))})
+}
+
 // pushExpression is just a type-safe way to push an expression
 func (p *Parser) pushExpression(node function.Expression) {
 	p.pushNode(node)
@@ -255,13 +281,48 @@ func (p *Parser) makeDescribe() {
 }
 
 func (p *Parser) makeSelect() {
+	p.command = p.popSelect()
+}
+
+// makeForecast wraps the select (which follows the threshold on the stack)
+// in a forecast command.
+func (p *Parser) makeForecast() {
+	var contextNode *evaluationContextNode
+	p.popNodeInto(&contextNode)
+	var predicate predicate.Predicate
+	p.popNodeInto(&predicate)
+	var threshold string
+	p.popNodeInto(&threshold)
+	value, err := strconv.ParseFloat(threshold, 64)
+	if err != nil {
+		p.flagSyntaxError(SyntaxError{
+			token:   threshold,
+			message: fmt.Sprintf("Cannot parse the threshold: %s", threshold),
+		})
+	}
+	p.pushNode(predicate)
+	p.pushNode(contextNode)
+	p.command = &command.ForecastCommand{
+		Select:    *p.popSelect(),
+		Threshold: value,
+	}
+}
+
+// makeExplain wraps the select command (already made) in an explain command.
+func (p *Parser) makeExplain() {
+	p.command = &command.ExplainCommand{Select: *p.command.(*command.SelectCommand)}
+}
+
+// popSelect builds a select command from the expressions, predicate and
+// properties on the stack.
+func (p *Parser) popSelect() *command.SelectCommand {
 	var contextNode *evaluationContextNode
 	p.popNodeInto(&contextNode)
 	var predicate predicate.Predicate
 	p.popNodeInto(&predicate)
 	var list []function.Expression
 	p.popNodeInto(&list)
-	p.command = &command.SelectCommand{
+	return &command.SelectCommand{
 		Predicate:   predicate,
 		Expressions: list,
 		Context: command.SelectContext{
@@ -269,23 +330,62 @@ func (p *Parser) makeSelect() {
 			End:          contextNode.End,
 			Resolution:   contextNode.Resolution,
 			SampleMethod: contextNode.SampleMethod,
+			OrderBy:      contextNode.OrderBy,
+			Descending:   contextNode.Descending,
+			Limit:        contextNode.Limit,
+			Sample:       contextNode.Sample,
+			Fill:         contextNode.Fill,
 		},
 	}
 }
 
 func (p *Parser) addNullMatchClause() {
-	p.pushNode(regexp.MustCompile(""))
+	p.pushString("")
 }
 
+// addMatchClause leaves the literal of the match clause as it is, since in
+// fuzzy mode it isn't a regular expression.
 func (p *Parser) addMatchClause() {
-	compiled := p.popRegex()
-	p.pushNode(compiled)
 }
 
 func (p *Parser) makeDescribeAll() {
-	var matcher *regexp.Regexp
-	p.popNodeInto(&matcher)
-	p.command = &command.DescribeAllCommand{Matcher: matcher}
+	var match string
+	p.popNodeInto(&match)
+	describe := &command.DescribeAllCommand{Match: match}
+	compiled, err := regexp.Compile(match)
+	if err != nil {
+		// Only reported when the command runs, since it may run in fuzzy mode.
+		describe.MatchError = SyntaxErrors{{
+			token:   match,
+			message: fmt.Sprintf("Cannot parse the regex: %s", err.Error()),
+		}}
+	}
+	describe.Matcher = compiled
+	p.command = describe
+}
+
+func (p *Parser) makeDescribeKeys() {
+	var literal string
+	p.popNodeInto(&literal)
+	p.command = &command.DescribeKeysCommand{MetricName: api.MetricKey(literal)}
+}
+
+func (p *Parser) makeDescribeValues() {
+	var metrics []string
+	p.popNodeInto(&metrics)
+	var quantifier string
+	p.popNodeInto(&quantifier)
+	var tag tagLiteral
+	p.popNodeInto(&tag)
+	keys := make([]api.MetricKey, len(metrics))
+	for i, metric := range metrics {
+		keys[i] = api.MetricKey(metric)
+	}
+	p.command = &command.DescribeValuesCommand{
+		TagKey:  string(tag),
+		Metrics: keys,
+		Any:     quantifier == "any",
+	}
 }
 
 func (p *Parser) makeDescribeMetrics() {
@@ -308,17 +408,67 @@ func (p *Parser) addOperatorLiteral(operator string) {
 func (p *Parser) addOperatorFunction() {
 	var right function.Expression
 	p.popNodeInto(&right)
+	var matching function.Groups
+	p.popNodeInto(&matching)
 	var operator operatorLiteral
 	p.popNodeInto(&operator)
 	var left function.Expression
 	p.popNodeInto(&left)
 
+	if folded, ok := foldConstants(string(operator), left, right); ok {
+		p.pushExpression(folded)
+		return
+	}
 	p.pushExpression(function.Memoize(&expression.FunctionExpression{
-		FunctionName: string(operator),
-		Arguments:    []function.Expression{left, right},
+		FunctionName:     string(operator),
+		Arguments:        []function.Expression{left, right},
+		GroupBy:          matching.List,
+		GroupByCollapses: matching.Collapses,
 	}))
 }
 
+// literalValue converts a scalar or duration literal into its value.
+func literalValue(expr function.Expression) (function.Value, bool) {
+	literal, ok := expr.(function.LiteralExpression)
+	if !ok {
+		return nil, false
+	}
+	switch value := literal.Literal().(type) {
+	case float64:
+		return function.ScalarValue(value), true
+	case time.Duration:
+		return function.NewDurationValue("", value), true
+	}
+	return nil, false
+}
+
+// foldConstants evaluates an operator applied to two scalar or duration literals,
+// so that expressions like `30 * 1m` can be used wherever a literal is expected.
+// Combinations which are not valid are left to fail during evaluation.
+func foldConstants(operator string, left function.Expression, right function.Expression) (function.Expression, bool) {
+	leftValue, ok := literalValue(left)
+	if !ok {
+		return nil, false
+	}
+	rightValue, ok := literalValue(right)
+	if !ok {
+		return nil, false
+	}
+	result, ok, err := function.Arithmetic(operator, leftValue, rightValue)
+	if !ok || err != nil {
+		return nil, false
+	}
+	source := fmt.Sprintf("(%s %s %s)", left.ExpressionDescription(function.StringQuery()), operator, right.ExpressionDescription(function.StringQuery()))
+	switch value := result.(type) {
+	case function.ScalarValue:
+		return function.Memoize(expression.Scalar{Value: float64(value), Source: source}), true
+	case function.DurationValue:
+		duration, _ := value.ToDuration()
+		return function.Memoize(expression.Duration{Source: source, Duration: duration}), true
+	}
+	return nil, false
+}
+
 func (p *Parser) addPropertyKey(key string) {
 	p.pushNode(evaluationContextKey(key))
 }
@@ -329,9 +479,11 @@ func (p *Parser) addPropertyValue(value string) {
 
 func (p *Parser) addEvaluationContext() {
 	p.pushNode(&evaluationContextNode{
-		0, 0, 30000,
-		timeseries.SampleMean,
-		make(map[evaluationContextKey]bool),
+		Start:        command.MillisToTime(0),
+		End:          command.MillisToTime(0),
+		Resolution:   30 * time.Second,
+		SampleMethod: timeseries.SampleMean,
+		assigned:     make(map[evaluationContextKey]bool),
 	})
 }
 
@@ -375,7 +527,10 @@ func (p *Parser) insertPropertyKeyValue() {
 	case "from", "to":
 		var unix int64
 		var err error
-		now := time.Now()
+		now := p.now
+		if now.IsZero() {
+			now = time.Now()
+		}
 		if unix, err = parseDate(string(value), now); err != nil {
 			p.flagSyntaxError(SyntaxError{
 				token:   string(value),
@@ -383,16 +538,16 @@ func (p *Parser) insertPropertyKeyValue() {
 			})
 		}
 		if key == "from" {
-			contextNode.Start = unix
+			contextNode.Start = command.MillisToTime(unix)
 		} else {
-			contextNode.End = unix
+			contextNode.End = command.MillisToTime(unix)
 		}
 	case "resolution":
 		// The value must be determined to be an int if the key is "resolution".
 		if intValue, err := strconv.ParseInt(string(value), 10, 64); err == nil {
-			contextNode.Resolution = intValue
+			contextNode.Resolution = command.MillisToDuration(intValue)
 		} else if duration, err := function.StringToDuration(string(value)); err == nil {
-			contextNode.Resolution = int64(duration / time.Millisecond)
+			contextNode.Resolution = duration
 		} else {
 			p.flagSyntaxError(SyntaxError{
 				token:   string(value),
@@ -408,6 +563,84 @@ func (p *Parser) insertPropertyKeyValue() {
 	p.pushNode(contextNode)
 }
 
+// assignProperty marks the key as assigned in the evaluation context, flagging
+// an error if it had already been assigned.
+func (p *Parser) assignProperty(contextNode *evaluationContextNode, key evaluationContextKey) {
+	if contextNode.assigned[key] {
+		p.flagSyntaxError(SyntaxError{
+			token:   string(key),
+			message: fmt.Sprintf("Key %s has already been assigned", key),
+		})
+	}
+	contextNode.assigned[key] = true
+}
+
+func (p *Parser) addOrderBy(summary string) {
+	var contextNode *evaluationContextNode
+	p.popNodeInto(&contextNode)
+	p.assignProperty(contextNode, "order")
+	if !command.IsOrderSummary(summary) {
+		p.flagSyntaxError(SyntaxError{
+			token:   summary,
+			message: fmt.Sprintf("Expected summary %s for 'order by' but got %s", command.OrderSummaries(), summary),
+		})
+	}
+	contextNode.OrderBy = summary
+	p.pushNode(contextNode)
+}
+
+func (p *Parser) addOrderDirection(direction string) {
+	var contextNode *evaluationContextNode
+	p.popNodeInto(&contextNode)
+	contextNode.Descending = direction == "desc"
+	p.pushNode(contextNode)
+}
+
+func (p *Parser) addLimit(value string) {
+	var contextNode *evaluationContextNode
+	p.popNodeInto(&contextNode)
+	p.assignProperty(contextNode, "limit")
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		p.flagSyntaxError(SyntaxError{
+			token:   value,
+			message: fmt.Sprintf("Expected a positive count for 'limit' but got %s", value),
+		})
+	}
+	contextNode.Limit = limit
+	p.pushNode(contextNode)
+}
+
+func (p *Parser) addSamplePercent(value string) {
+	var contextNode *evaluationContextNode
+	p.popNodeInto(&contextNode)
+	p.assignProperty(contextNode, "sample percent")
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		p.flagSyntaxError(SyntaxError{
+			token:   value,
+			message: fmt.Sprintf("Expected a percentage greater than 0 and at most 100 for 'sample' but got %s", value),
+		})
+	}
+	contextNode.Sample = percent
+	p.pushNode(contextNode)
+}
+
+func (p *Parser) addFill(value string) {
+	var contextNode *evaluationContextNode
+	p.popNodeInto(&contextNode)
+	p.assignProperty(contextNode, "fill")
+	policy, err := command.ParseFillPolicy(value)
+	if err != nil {
+		p.flagSyntaxError(SyntaxError{
+			token:   value,
+			message: err.Error(),
+		})
+	}
+	contextNode.Fill = policy
+	p.pushNode(contextNode)
+}
+
 // makePropertyClause verifies that all mandatory fields have been assigned in the evaluation context.
 func (p *Parser) checkPropertyClause() {
 	var contextNode *evaluationContextNode
@@ -429,16 +662,17 @@ func (p *Parser) addPipeExpression() {
 	p.popNodeInto(&groupBy)
 	var expressionList []function.Expression
 	p.popNodeInto(&expressionList)
-	var literal string
+	var literal functionNameLiteral
 	p.popNodeInto(&literal)
 	var expressionNode function.Expression
 	p.popNodeInto(&expressionNode)
 
 	p.pushExpression(function.Memoize(&expression.FunctionExpression{
-		FunctionName:     literal,
+		FunctionName:     literal.name,
 		Arguments:        append([]function.Expression{expressionNode}, expressionList...),
 		GroupBy:          groupBy.List,
 		GroupByCollapses: groupBy.Collapses,
+		Position:         literal.position,
 	}))
 }
 
@@ -447,14 +681,15 @@ func (p *Parser) addFunctionInvocation() {
 	p.popNodeInto(&groupBy)
 	var expressionList []function.Expression
 	p.popNodeInto(&expressionList)
-	var literal string
+	var literal functionNameLiteral
 	p.popNodeInto(&literal)
 	// user-level error generation here.
 	p.pushExpression(function.Memoize(&expression.FunctionExpression{
-		FunctionName:     literal,
+		FunctionName:     literal.name,
 		Arguments:        expressionList,
 		GroupBy:          groupBy.List,
 		GroupByCollapses: groupBy.Collapses,
+		Position:         literal.position,
 	}))
 }
 
@@ -471,15 +706,46 @@ func (p *Parser) addAnnotationExpression(annotation string) {
 func (p *Parser) addMetricExpression() {
 	var predicateNode predicate.Predicate
 	p.popNodeInto(&predicateNode)
-	var literal string
+	var literal metricNameLiteral
 	p.popNodeInto(&literal)
+	if bound, ok := p.bindings[literal.name]; ok {
+		if _, all := predicateNode.(predicate.TruePredicate); !all {
+			p.flagSyntaxError(SyntaxError{
+				token:   literal.name,
+				message: fmt.Sprintf("%s is bound in the with clause, so it can't be given a predicate", literal.name),
+			})
+		}
+		p.pushExpression(bound)
+		return
+	}
 
 	p.pushExpression(function.Memoize(&expression.MetricFetchExpression{
-		MetricName: literal,
+		MetricName: literal.name,
 		Predicate:  predicateNode,
+		Position:   literal.position,
 	}))
 }
 
+// addBinding binds the expression to its name, for the rest of the query.
+// Since the bound expression is memoized, it's evaluated only once, however
+// often it's referred to.
+func (p *Parser) addBinding() {
+	var bound function.Expression
+	p.popNodeInto(&bound)
+	var name string
+	p.popNodeInto(&name)
+	if _, ok := p.bindings[name]; ok {
+		p.flagSyntaxError(SyntaxError{
+			token:   name,
+			message: fmt.Sprintf("%s is bound more than once in with clause", name),
+		})
+	}
+	if p.bindings == nil {
+		p.bindings = map[string]function.Expression{}
+	}
+	p.bindings[name] = bound
+}
+
 func (p *Parser) addExpressionList() {
 	p.pushNode([]function.Expression{})
 }
@@ -527,6 +793,56 @@ func (p *Parser) addRegexMatcher() {
 	})
 }
 
+func (p *Parser) addCIDRMatcher() {
+	var literal string
+	p.popNodeInto(&literal)
+	p.pushCIDRMatcher([]string{literal})
+}
+
+func (p *Parser) addCIDRListMatcher() {
+	var list []string
+	p.popNodeInto(&list)
+	p.pushCIDRMatcher(list)
+}
+
+func (p *Parser) pushCIDRMatcher(blocks []string) {
+	var tag tagLiteral
+	p.popNodeInto(&tag)
+	matcher, err := predicate.NewCIDRMatcher(string(tag), blocks)
+	if err != nil {
+		p.flagSyntaxError(SyntaxError{
+			token:   strings.Join(blocks, ", "),
+			message: err.Error(),
+		})
+	}
+	p.pushPredicate(matcher)
+}
+
+func (p *Parser) addGlobMatcher() {
+	var literal string
+	p.popNodeInto(&literal)
+	p.pushGlobMatcher([]string{literal})
+}
+
+func (p *Parser) addGlobListMatcher() {
+	var list []string
+	p.popNodeInto(&list)
+	p.pushGlobMatcher(list)
+}
+
+func (p *Parser) pushGlobMatcher(patterns []string) {
+	var tag tagLiteral
+	p.popNodeInto(&tag)
+	matcher, err := predicate.NewGlobMatcher(string(tag), patterns)
+	if err != nil {
+		p.flagSyntaxError(SyntaxError{
+			token:   strings.Join(patterns, ", "),
+			message: err.Error(),
+		})
+	}
+	p.pushPredicate(matcher)
+}
+
 func (p *Parser) addTagLiteral(tag string) {
 	p.pushNode(tagLiteral(tag))
 }
@@ -638,18 +954,21 @@ func unescapeLiteral(escaped string) string {
 	if len(escaped) <= 1 {
 		return escaped
 	}
-	escapedCharacters := []string{
-		"'", "`", "\"", "\\",
+	first := escaped[0]
+	if first != '\'' && first != '"' && first != '`' {
+		return escaped
 	}
-	processed := escaped
-	first := processed[0]
-	if first == '\'' || first == '"' || first == '`' {
-		processed = processed[1 : len(processed)-1]
-		for _, char := range escapedCharacters {
-			processed = strings.Replace(processed, `\`+char, char, -1)
+	// The grammar guarantees that every backslash is followed by the
+	// character it escapes, so each pair is replaced by its second character.
+	processed := []byte{}
+	inner := escaped[1 : len(escaped)-1]
+	for i := 0; i < len(inner); i++ {
+		if inner[i] == '\\' && i+1 < len(inner) {
+			i++
 		}
+		processed = append(processed, inner[i])
 	}
-	return processed
+	return string(processed)
 }
 
 var functionNameRegex = regexp.MustCompile(`[^./]+$`)
@@ -15,10 +15,16 @@
 package parser
 
 import (
+	"io/ioutil"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
 	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/util"
 )
 
 func Test_parseRelativeTime(t *testing.T) {
@@ -74,6 +80,33 @@ func testFunction1() (string, string) {
 	return functionName(0), functionName(1)
 }
 
+// TestEscapedKeywords checks that each keyword of the grammar, used as the
+// name of a metric, is escaped so that the query reads back unchanged.
+func TestEscapedKeywords(t *testing.T) {
+	grammar, err := ioutil.ReadFile("language.peg")
+	if err != nil {
+		t.Fatalf("cannot read the grammar: %s", err.Error())
+	}
+	rule := regexp.MustCompile(`(?s)\nKEYWORD <-.*?\n\n`).Find(grammar)
+	if rule == nil {
+		t.Fatalf("the grammar has no KEYWORD rule")
+	}
+	for _, match := range regexp.MustCompile(`"([a-z]+)"`).FindAllSubmatch(rule, -1) {
+		keyword := string(match[1])
+		a := assert.New(t).Contextf("%s", keyword)
+		query := "select " + util.EscapeIdentifier(keyword) + " from 0 to 60000"
+		parsed, err := Parse(query)
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		rendered := parsed.(*command.SelectCommand).Query()
+		a.Eq(strings.HasPrefix(rendered, "select `"+keyword+"` "), true)
+		_, err = Parse(rendered)
+		a.CheckError(err)
+	}
+}
+
 func TestFunctionName(t *testing.T) {
 	a := assert.New(t)
 	a.EqString(functionName(0), "TestFunctionName")
@@ -81,3 +114,47 @@ func TestFunctionName(t *testing.T) {
 	a.EqString(first, "testFunction1")
 	a.EqString(second, "TestFunctionName")
 }
+
+func TestPipeline(t *testing.T) {
+	describe := func(query string) string {
+		cmd, err := Parse("select " + query + " from 0 to 0")
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", query, err.Error())
+		}
+		return cmd.(*command.SelectCommand).Expressions[0].ExpressionDescription(function.StringQuery())
+	}
+	for _, test := range []struct {
+		pipeline string
+		nested   string
+	}{
+		{"cpu |> transform.rate", "transform.rate(cpu)"},
+		{"cpu |> transform.rate() |> aggregate.sum(group by dc) |> filter.highest_max(5)", "filter.highest_max(aggregate.sum(transform.rate(cpu) group by dc), 5)"},
+		{"cpu |> transform.moving_average(5m) * 2", "transform.moving_average(cpu, 5m) * 2"},
+		{"cpu | transform.rate |> aggregate.max", "aggregate.max(transform.rate(cpu))"},
+	} {
+		assert.New(t).Contextf("%s", test.pipeline).EqString(describe(test.pipeline), describe(test.nested))
+	}
+}
+
+func TestOperatorPrecedence(t *testing.T) {
+	for _, test := range []struct {
+		query    string
+		expected string
+	}{
+		{"a + 1 > b * 2", "((a + 1) > (b * 2))"},
+		{"a > 1 and b < 2 or c == 3", "(((a > 1) and (b < 2)) or (c == 3))"},
+		{"a or b unless c", "(a or (b unless c))"},
+		{"a != b and c", "((a != b) and c)"},
+		{"a >= 1 | transform.abs", "transform.abs((a >= 1))"},
+		{"a / on (host) b + c", "((a / on (host) b) + c)"},
+		{"a > ignoring (code, `dc`) b * 2", "(a > ignoring (code, dc) (b * 2))"},
+		{"a / on", "(a / on)"},
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		cmd, err := Parse("select " + test.query + " from 0 to 0")
+		a.CheckError(err)
+		if err == nil {
+			a.EqString(cmd.(*command.SelectCommand).Expressions[0].ExpressionDescription(function.StringQuery()), test.expected)
+		}
+	}
+}
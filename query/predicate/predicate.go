@@ -16,6 +16,7 @@ package predicate
 
 import (
 	"fmt"
+	"net"
 	"regexp"
 	"strings"
 
@@ -163,11 +164,11 @@ func (p ListMatcher) Apply(tagset api.TagSet) bool {
 }
 func (p ListMatcher) Query() string {
 	if len(p.Values) == 1 {
-		return fmt.Sprintf("%s = %q", util.EscapeIdentifier(p.Tag), p.Values[0])
+		return fmt.Sprintf("%s = %s", util.EscapeIdentifier(p.Tag), util.EscapeString(p.Values[0]))
 	}
 	quotedValues := make([]string, len(p.Values))
 	for i, value := range p.Values {
-		quotedValues[i] = fmt.Sprintf("%q", value)
+		quotedValues[i] = util.EscapeString(value)
 	}
 	return fmt.Sprintf("%s in (%s)", util.EscapeIdentifier(p.Tag), strings.Join(quotedValues, ", "))
 }
@@ -181,5 +182,99 @@ func (p RegexMatcher) Apply(tagset api.TagSet) bool {
 	return tagset.HasKey(p.Tag) && p.Regex.MatchString(tagset[p.Tag])
 }
 func (p RegexMatcher) Query() string {
-	return fmt.Sprintf("%s match %q", util.EscapeIdentifier(p.Tag), p.Regex.String())
+	return fmt.Sprintf("%s match %s", util.EscapeIdentifier(p.Tag), util.EscapeString(p.Regex.String()))
+}
+
+// GlobMatcher accepts tag values which match any of the glob patterns, in
+// which "*" matches any run of characters and "?" any single character.
+// The patterns are compiled into a single regular expression.
+type GlobMatcher struct {
+	Tag      string
+	Patterns []string
+	regex    *regexp.Regexp
+}
+
+// NewGlobMatcher compiles the patterns (such as "us-*" or "web-??").
+func NewGlobMatcher(tag string, patterns []string) (GlobMatcher, error) {
+	alternatives := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		var expression strings.Builder
+		for _, char := range pattern {
+			switch char {
+			case '*':
+				expression.WriteString(".*")
+			case '?':
+				expression.WriteString(".")
+			default:
+				expression.WriteString(regexp.QuoteMeta(string(char)))
+			}
+		}
+		alternatives[i] = expression.String()
+	}
+	regex, err := regexp.Compile("^(?s:" + strings.Join(alternatives, "|") + ")$")
+	if err != nil {
+		return GlobMatcher{}, fmt.Errorf("cannot compile glob patterns %q: %s", patterns, err.Error())
+	}
+	return GlobMatcher{Tag: tag, Patterns: patterns, regex: regex}, nil
+}
+
+func (p GlobMatcher) Apply(tagset api.TagSet) bool {
+	value, ok := tagset[p.Tag]
+	return ok && p.regex.MatchString(value)
+}
+func (p GlobMatcher) Query() string {
+	if len(p.Patterns) == 1 {
+		return fmt.Sprintf("%s in glob %s", util.EscapeIdentifier(p.Tag), util.EscapeString(p.Patterns[0]))
+	}
+	quotedPatterns := make([]string, len(p.Patterns))
+	for i, pattern := range p.Patterns {
+		quotedPatterns[i] = util.EscapeString(pattern)
+	}
+	return fmt.Sprintf("%s in glob (%s)", util.EscapeIdentifier(p.Tag), strings.Join(quotedPatterns, ", "))
+}
+
+// CIDRMatcher accepts tag values which are IP addresses in any of the networks.
+type CIDRMatcher struct {
+	Tag      string
+	Networks []*net.IPNet
+}
+
+// NewCIDRMatcher parses the CIDR blocks (such as "10.0.0.0/8" or "fe80::/10").
+func NewCIDRMatcher(tag string, blocks []string) (CIDRMatcher, error) {
+	matcher := CIDRMatcher{Tag: tag, Networks: make([]*net.IPNet, len(blocks))}
+	for i, block := range blocks {
+		_, network, err := net.ParseCIDR(block)
+		if err != nil {
+			return CIDRMatcher{}, fmt.Errorf("cannot parse CIDR block %q", block)
+		}
+		matcher.Networks[i] = network
+	}
+	return matcher, nil
+}
+
+func (p CIDRMatcher) Apply(tagset api.TagSet) bool {
+	value, ok := tagset[p.Tag]
+	if !ok {
+		return false
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p.Networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+func (p CIDRMatcher) Query() string {
+	if len(p.Networks) == 1 {
+		return fmt.Sprintf("%s in cidr %s", util.EscapeIdentifier(p.Tag), util.EscapeString(p.Networks[0].String()))
+	}
+	quotedNetworks := make([]string, len(p.Networks))
+	for i, network := range p.Networks {
+		quotedNetworks[i] = util.EscapeString(network.String())
+	}
+	return fmt.Sprintf("%s in cidr (%s)", util.EscapeIdentifier(p.Tag), strings.Join(quotedNetworks, ", "))
 }
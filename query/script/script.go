@@ -0,0 +1,335 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package script runs several commands in one request. The statements of a
+// script are separated by ";", and each may bind its result to a variable,
+// as in
+//
+//	let hosts = describe values host where metrics in (cpu.user);
+//	select cpu.user where host in $hosts from -1h to now
+//
+// Variables are substituted into later statements as query text: a list of
+// values (from describe all or describe values) as a list of strings, a
+// single scalar as a number, and the tags of a describe as $name.tag.
+package script
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/util"
+)
+
+var (
+	letStatement = regexp.MustCompile(`(?s)^\s*let\s+([a-zA-Z_][a-zA-Z0-9_]*)\s*=(.*)$`)
+	reference    = regexp.MustCompile(`\$([a-zA-Z_][a-zA-Z0-9_]*)(\.[a-zA-Z_][a-zA-Z0-9_]*)?`)
+)
+
+// Statement is one command of a script.
+type Statement struct {
+	Variable string // optional. The variable its result is bound to
+	Query    string // may refer to the variables of earlier statements
+}
+
+// StatementError is the error of a statement which failed, failing its script.
+type StatementError struct {
+	Index int // from zero
+	Err   error
+}
+
+func (e StatementError) Error() string {
+	return fmt.Sprintf("statement %d of the script failed: %s", e.Index+1, e.Err.Error())
+}
+
+// StatementResult is the result of one statement of a script.
+type StatementResult struct {
+	Variable string                 `json:"variable,omitempty"`
+	Query    string                 `json:"query"` // with its variables substituted
+	Name     string                 `json:"name"`
+	Body     interface{}            `json:"body"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Script is a command which runs its statements in order. They are
+// evaluated at the same instant, so that relative dates agree; if any of
+// them fails, the script fails.
+type Script struct {
+	Statements []Statement
+}
+
+// IsScript reports whether the query is a script rather than a single
+// command: whether it has several statements, or binds a variable.
+func IsScript(query string) bool {
+	statements := split(query)
+	return len(statements) > 1 || (len(statements) == 1 && letStatement.MatchString(statements[0]))
+}
+
+// Parse splits the script into its statements, and checks that each
+// variable is bound once, before it is used.
+func Parse(query string) (*Script, error) {
+	script := &Script{}
+	bound := map[string]bool{}
+	for _, text := range split(query) {
+		statement := Statement{Query: text}
+		if match := letStatement.FindStringSubmatch(text); match != nil {
+			statement = Statement{Variable: match[1], Query: match[2]}
+		}
+		for _, name := range references(statement.Query) {
+			if !bound[name] {
+				return nil, fmt.Errorf("statement %d of the script refers to $%s before it is bound", len(script.Statements)+1, name)
+			}
+		}
+		if statement.Variable != "" {
+			if bound[statement.Variable] {
+				return nil, fmt.Errorf("statement %d of the script binds $%s again", len(script.Statements)+1, statement.Variable)
+			}
+			bound[statement.Variable] = true
+		}
+		script.Statements = append(script.Statements, statement)
+	}
+	if len(script.Statements) == 0 {
+		return nil, fmt.Errorf("the script has no statements")
+	}
+	return script, nil
+}
+
+func (s *Script) Name() string {
+	return "script"
+}
+
+// Execute runs each statement in turn, binding its variable (if any) for the
+// statements which follow.
+func (s *Script) Execute(context command.ExecutionContext) (command.Result, error) {
+	now := time.Now()
+	if context.Now != nil {
+		now = context.Now()
+	}
+	context.Stream = nil // the result is that of the whole script
+	variables := map[string]interface{}{}
+	results := make([]StatementResult, len(s.Statements))
+	for i, statement := range s.Statements {
+		query, err := substitute(statement.Query, variables)
+		if err != nil {
+			return command.Result{}, StatementError{Index: i, Err: err}
+		}
+		cmd, err := parser.ParseAt(query, now)
+		if err != nil {
+			return command.Result{}, StatementError{Index: i, Err: err}
+		}
+		result, err := cmd.Execute(context)
+		if err != nil {
+			return command.Result{}, StatementError{Index: i, Err: err}
+		}
+		if statement.Variable != "" {
+			variables[statement.Variable] = result.Body
+		}
+		results[i] = StatementResult{
+			Variable: statement.Variable,
+			Query:    strings.TrimSpace(query),
+			Name:     cmd.Name(),
+			Body:     result.Body,
+			Metadata: result.Metadata,
+		}
+	}
+	return command.Result{
+		Body: results,
+		Metadata: map[string]interface{}{
+			"statements": len(results),
+		},
+	}, nil
+}
+
+// segment is a span of a query which is either code, or a string literal,
+// quoted identifier or comment (in which ";" and "$" have no meaning).
+type segment struct {
+	text string
+	code bool
+}
+
+// segments divides the query into code and literals. An unterminated
+// literal runs to the end of the query, to be reported by the parser.
+func segments(query string) []segment {
+	var result []segment
+	start := 0
+	for i := 0; i < len(query); i++ {
+		end := -1
+		switch {
+		case query[i] == '\'' || query[i] == '"' || query[i] == '`':
+			end = len(query)
+			for j := i + 1; j < len(query); j++ {
+				if query[j] == '\\' {
+					j++
+					continue
+				}
+				if query[j] == query[i] {
+					end = j + 1
+					break
+				}
+			}
+		case strings.HasPrefix(query[i:], "--"):
+			end = len(query)
+			if newline := strings.IndexByte(query[i:], '\n'); newline >= 0 {
+				end = i + newline
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end = len(query)
+			if close := strings.Index(query[i+2:], "*/"); close >= 0 {
+				end = i + 2 + close + 2
+			}
+		default:
+			continue
+		}
+		if start < i {
+			result = append(result, segment{text: query[start:i], code: true})
+		}
+		result = append(result, segment{text: query[i:end]})
+		start = end
+		i = end - 1
+	}
+	if start < len(query) {
+		result = append(result, segment{text: query[start:], code: true})
+	}
+	return result
+}
+
+// split divides the query into the text of its statements, omitting those
+// which are empty or only comments.
+func split(query string) []string {
+	var statements []string
+	var current strings.Builder
+	empty := true
+	flush := func() {
+		if !empty {
+			statements = append(statements, current.String())
+		}
+		current.Reset()
+		empty = true
+	}
+	for _, segment := range segments(query) {
+		if !segment.code {
+			current.WriteString(segment.text)
+			empty = empty && isComment(segment.text)
+			continue
+		}
+		parts := strings.Split(segment.text, ";")
+		for i, part := range parts {
+			if i != 0 {
+				flush()
+			}
+			current.WriteString(part)
+			empty = empty && strings.TrimSpace(part) == ""
+		}
+	}
+	flush()
+	return statements
+}
+
+func isComment(text string) bool {
+	return strings.HasPrefix(text, "--") || strings.HasPrefix(text, "/*")
+}
+
+// references lists the variables referred to by the query.
+func references(query string) []string {
+	var names []string
+	for _, segment := range segments(query) {
+		if !segment.code {
+			continue
+		}
+		for _, match := range reference.FindAllStringSubmatch(segment.text, -1) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// substitute replaces the references of the query with their values.
+func substitute(query string, variables map[string]interface{}) (string, error) {
+	var result strings.Builder
+	var err error
+	for _, segment := range segments(query) {
+		if !segment.code {
+			result.WriteString(segment.text)
+			continue
+		}
+		result.WriteString(reference.ReplaceAllStringFunc(segment.text, func(match string) string {
+			parts := reference.FindStringSubmatch(match)
+			literal, bindErr := render(parts[1], strings.TrimPrefix(parts[2], "."), variables[parts[1]])
+			if bindErr != nil && err == nil {
+				err = bindErr
+			}
+			return literal
+		}))
+	}
+	return result.String(), err
+}
+
+// render is the query text of the value of a variable, or of one of its
+// tags if the variable holds the result of a describe.
+func render(name string, tag string, value interface{}) (string, error) {
+	if describe, ok := value.(map[string][]string); ok {
+		if tag == "" {
+			return "", fmt.Errorf("$%s holds the tags of a describe; refer to one of them, as $%s.<tag>", name, name)
+		}
+		values, ok := describe[tag]
+		if !ok {
+			return "", fmt.Errorf("$%s has no tag %q", name, tag)
+		}
+		return renderList(name+"."+tag, values)
+	}
+	if tag != "" {
+		return "", fmt.Errorf("$%s holds no tags, so $%s.%s can't be used", name, name, tag)
+	}
+	switch value := value.(type) {
+	case []string:
+		return renderList(name, value)
+	case []api.MetricKey:
+		values := make([]string, len(value))
+		for i, key := range value {
+			values[i] = string(key)
+		}
+		return renderList(name, values)
+	case []command.QueryResult:
+		if len(value) == 1 && len(value[0].Scalars) == 1 {
+			scalar := value[0].Scalars[0].Value
+			if math.IsNaN(scalar) || math.IsInf(scalar, 0) {
+				return "", fmt.Errorf("$%s is not a finite number", name)
+			}
+			return strconv.FormatFloat(scalar, 'g', -1, 64), nil
+		}
+		return "", fmt.Errorf("$%s must hold a single scalar to be used, such as the result of a summary", name)
+	}
+	return "", fmt.Errorf("$%s holds a result which can't be used in a query", name)
+}
+
+// renderList is a list of string literals, as used by "in" predicates.
+func renderList(name string, values []string) (string, error) {
+	if len(values) == 0 {
+		return "", fmt.Errorf("$%s is empty", name)
+	}
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	quoted := make([]string, len(sorted))
+	for i, value := range sorted {
+		quoted[i] = util.EscapeString(value)
+	}
+	return "(" + strings.Join(quoted, ", ") + ")", nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package script
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestSplit(t *testing.T) {
+	a := assert.New(t)
+	statements := split("let x = describe all match ';'; select `a;b` -- c;d\n; /* e;f */ ;")
+	a.EqInt(len(statements), 2)
+	a.EqString(statements[0], "let x = describe all match ';'")
+	a.EqString(statements[1], " select `a;b` -- c;d\n")
+
+	a.EqBool(IsScript("select x from 0 to 0"), false)
+	a.EqBool(IsScript("select x from 0 to 0;"), false)
+	a.EqBool(IsScript("select x where host = 'a;b' from 0 to 0"), false)
+	a.EqBool(IsScript("let x = describe all"), true)
+	a.EqBool(IsScript("describe all; describe all"), true)
+}
+
+func TestParse(t *testing.T) {
+	a := assert.New(t)
+	script, err := Parse("let hosts = describe values host where metrics in (cpu); select cpu where host in $hosts from 0 to 0")
+	a.CheckError(err)
+	a.EqInt(len(script.Statements), 2)
+	a.EqString(script.Statements[0].Variable, "hosts")
+	a.EqString(script.Statements[0].Query, " describe values host where metrics in (cpu)")
+	a.EqString(script.Statements[1].Variable, "")
+
+	for _, invalid := range []string{
+		"select cpu where host in $hosts from 0 to 0; let hosts = describe all",
+		"let x = describe all; let x = describe all",
+		" ; ",
+	} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("expected an error parsing %q", invalid)
+		}
+	}
+	// References within strings are not variables.
+	_, err = Parse("select cpu where host = '$hosts' from 0 to 0; describe all")
+	a.CheckError(err)
+}
+
+func TestRender(t *testing.T) {
+	a := assert.New(t)
+	for _, test := range []struct {
+		reference string
+		value     interface{}
+		expected  string // empty if it is an error
+	}{
+		{"$x", []string{"b", "a\"c", "d`\\"}, `("a\"c", "b", "d\` + "`" + `\\")`},
+		{"$x", []api.MetricKey{"cpu.user"}, `("cpu.user")`},
+		{"$x", []string{}, ""},
+		{"$x.host", map[string][]string{"host": {"a", "b"}}, `("a", "b")`},
+		{"$x", map[string][]string{"host": {"a", "b"}}, ""},
+		{"$x.dc", map[string][]string{"host": {"a", "b"}}, ""},
+		{"$x.host", []string{"a"}, ""},
+		{"$x", []command.QueryResult{{Type: "scalars", Scalars: []function.TaggedScalar{{Value: 2.5}}}}, "2.5"},
+		{"$x", []command.QueryResult{{Type: "series"}}, ""},
+	} {
+		actual, err := substitute(test.reference, map[string]interface{}{"x": test.value})
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("expected an error substituting %s with %+v, but got %s", test.reference, test.value, actual)
+			}
+			continue
+		}
+		a.CheckError(err)
+		a.EqString(actual, test.expected)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 30, 10)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4}, TagSet: api.TagSet{"metric": "cpu", "host": "a", "dc": "east"}},
+		api.Timeseries{Values: []float64{5, 6, 7, 8}, TagSet: api.TagSet{"metric": "cpu", "host": "b", "dc": "west"}},
+		api.Timeseries{Values: []float64{9, 9, 9, 9}, TagSet: api.TagSet{"metric": "cpu", "host": "c", "dc": "west"}},
+	)
+	context := command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+		Now:                  func() time.Time { return time.Unix(0, 0) },
+	}
+	a := assert.New(t)
+	script, err := Parse(`
+		let west = describe cpu where dc = 'west';
+		select cpu where host in $west.host from 0 to 30 resolution 10ms
+	`)
+	a.CheckError(err)
+	result, err := script.Execute(context)
+	a.CheckError(err)
+	a.EqString(script.Name(), "script")
+	body := result.Body.([]StatementResult)
+	a.EqInt(len(body), 2)
+	a.EqString(body[0].Variable, "west")
+	a.EqString(body[0].Name, "describe")
+	a.EqString(body[1].Query, `select cpu where host in ("b", "c") from 0 to 30 resolution 10ms`)
+	a.EqInt(len(body[1].Body.([]command.QueryResult)[0].Series), 2)
+
+	// A failing statement fails the script.
+	script, err = Parse("let west = describe cpu where dc = 'north'; select cpu where host in $west.host from 0 to 30")
+	a.CheckError(err)
+	_, err = script.Execute(context)
+	statementErr, ok := err.(StatementError)
+	a.EqBool(ok, true)
+	a.EqInt(statementErr.Index, 1)
+}
@@ -0,0 +1,75 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata/alias"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_Alias(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 60, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3}, TagSet: api.TagSet{"metric": "new_name", "host": "a"}},
+		api.Timeseries{Values: []float64{4, 5, 6}, TagSet: api.TagSet{"metric": "new_name", "host": "b"}},
+	)
+	table, err := alias.NewTable(map[api.MetricKey]alias.Alias{
+		"old_name": {Target: "new_name", Tags: map[string]string{"hostname": "host"}},
+	})
+	if err != nil {
+		t.Fatalf("Error creating alias table for test: %s", err.Error())
+	}
+	context := command.ExecutionContext{
+		TimeseriesStorageAPI: alias.NewStorageAPI(comboAPI, table),
+		MetricMetadataAPI:    alias.NewMetricMetadataAPI(comboAPI, table),
+		FetchLimit:           1000,
+		Timeout:              100 * time.Millisecond,
+		Ctx:                  context.Background(),
+	}
+
+	a := assert.New(t)
+	describe, err := parser.Parse("describe old_name")
+	a.CheckError(err)
+	result, err := describe.Execute(context)
+	a.CheckError(err)
+	a.Eq(result.Body, map[string][]string{"hostname": {"a", "b"}})
+
+	selectCommand, err := parser.Parse("select old_name[hostname = 'b'] * 2 from 0 to 60 resolution 30ms")
+	a.CheckError(err)
+	result, err = selectCommand.Execute(context)
+	a.CheckError(err)
+	series := result.Body.([]command.QueryResult)[0].Series
+	if len(series) != 1 {
+		t.Fatalf("expected exactly one series but got %d", len(series))
+	}
+	a.EqString(series[0].TagSet.Serialize(), "hostname=b")
+	a.EqFloatArray(series[0].Values, []float64{8, 10, 12}, 1e-9)
+
+	usage := table.Usage()
+	a.EqInt(len(usage), 1)
+	// Each query counts once, however many series it fetches.
+	a.EqInt(int(usage[0].Count), 2)
+}
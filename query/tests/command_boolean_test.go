@@ -0,0 +1,88 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestCommand_LogicalOperators(t *testing.T) {
+	store := memory.NewStore(time.Minute)
+	// Host a is busy for the whole hour, host b for its first half; only
+	// host a is also short of memory, during the second half.
+	for _, host := range []string{"a", "b"} {
+		host := host
+		store.AddGenerated(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"host": host}}, func(t time.Time) float64 {
+			if host == "a" || t.Minute() < 30 {
+				return 95
+			}
+			return 10
+		})
+		store.AddGenerated(api.TaggedMetric{MetricKey: "memory", TagSet: api.TagSet{"host": host}}, func(t time.Time) float64 {
+			if host == "a" && t.Minute() >= 30 {
+				if t.Minute() == 45 {
+					return math.NaN()
+				}
+				return 90
+			}
+			return 20
+		})
+	}
+	a := assert.New(t)
+	run := func(query string) map[string][]float64 {
+		testCommand, err := parser.Parse("select " + query + " from 0 to 3600000 resolution 15m")
+		a.CheckError(err)
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: store,
+			MetricMetadataAPI:    store,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		values := map[string][]float64{}
+		for _, series := range result.Body.([]command.QueryResult)[0].Series {
+			values[series.TagSet["host"]] = series.Values
+		}
+		return values
+	}
+	nan := math.NaN()
+	for _, test := range []struct {
+		query    string
+		expected map[string][]float64
+	}{
+		{"cpu > 90", map[string][]float64{"a": {1, 1, 1, 1, 1}, "b": {1, 1, 0, 0, 1}}},
+		{"cpu > 90 and memory > 80", map[string][]float64{"a": {0, 0, 1, nan, 0}, "b": {0, 0, 0, 0, 0}}},
+		{"cpu > 90 or memory > 80", map[string][]float64{"a": {1, 1, 1, nan, 1}, "b": {1, 1, 0, 0, 1}}},
+		{"cpu > 90 unless memory > 80", map[string][]float64{"a": {1, 1, 0, nan, 1}, "b": {1, 1, 0, 0, 1}}},
+		// aggregation skips the missing point rather than propagating it
+		{"aggregate.sum(cpu > 90 and memory > 80)", map[string][]float64{"": {0, 0, 1, 0, 0}}},
+	} {
+		a := a.Contextf("%s", test.query)
+		actual := run(test.query)
+		a.EqInt(len(actual), len(test.expected))
+		for host, values := range test.expected {
+			a.Contextf("host=%s", host).EqFloatArray(actual[host], values, 0)
+		}
+	}
+}
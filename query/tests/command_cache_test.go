@@ -0,0 +1,106 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+)
+
+// countingStorage counts the fetches it's asked to perform.
+type countingStorage struct {
+	mocks.FakeTimeseriesStorageAPI
+	fetches *int32
+}
+
+func (s countingStorage) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	atomic.AddInt32(s.fetches, 1)
+	list := api.SeriesList{}
+	for _, metric := range request.Metrics {
+		list.Series = append(list.Series, api.Timeseries{Values: make([]float64, request.Timerange.Slots()), TagSet: metric.TagSet})
+	}
+	return list, nil
+}
+
+func TestCommand_ResultCache(t *testing.T) {
+	a := assert.New(t)
+	metadataAPI := mocks.NewFakeMetricMetadataAPI()
+	metadataAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "series_1", TagSet: api.TagSet{"host": "a"}})
+	fetches := new(int32)
+	context := command.ExecutionContext{
+		TimeseriesStorageAPI: countingStorage{fetches: fetches},
+		MetricMetadataAPI:    metadataAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+		ResultCache:          command.NewResultCache(time.Hour, 5*time.Minute, 2),
+	}
+	run := func(query string) command.CacheReport {
+		testCommand, err := parser.Parse(query)
+		a.CheckError(err)
+		result, err := testCommand.Execute(context)
+		a.CheckError(err)
+		report, ok := result.Metadata["cache"].(command.CacheReport)
+		a.EqBool(ok, true)
+		// Changing the metadata of a result doesn't change the cached result.
+		a.EqBool(result.Metadata["freshness"] != nil, true)
+		result.Metadata["freshness"] = nil
+		return report
+	}
+
+	for _, test := range []struct {
+		query   string
+		status  string
+		fetches int
+	}{
+		{"select series_1 from 0 to 400 resolution 10ms", command.CacheMiss, 1},
+		{"select series_1 from 0 to 400 resolution 10ms", command.CacheHit, 1},
+		{"select series_1 from 0 to 400 resolution 20ms", command.CacheMiss, 2},
+		{"select series_1 + 1 from 0 to 400 resolution 10ms", command.CacheMiss, 3},
+		// The first select was the least recently used, and made way for the last.
+		{"select series_1 from 0 to 400 resolution 20ms", command.CacheHit, 3},
+		{"select series_1 from 0 to 400 resolution 10ms", command.CacheMiss, 4},
+		// The points of a recent timerange may still change.
+		{"select series_1 from -1h to now", command.CacheUncacheable, 5},
+		{"select series_1 from -1h to now", command.CacheUncacheable, 6},
+	} {
+		a := a.Contextf("%s", test.query)
+		report := run(test.query)
+		a.EqString(report.Status, test.status)
+		a.EqInt(int(atomic.LoadInt32(fetches)), test.fetches)
+		a.EqBool(report.Entries <= 2, true)
+	}
+	report := run("select series_1 from 0 to 400 resolution 10ms")
+	a.EqInt(report.Hits, 3)
+	a.EqInt(report.Misses, 4)
+
+	// A change to the context which changes the result misses the cache.
+	context.Collation = "lexical"
+	a.EqString(run("select series_1 from 0 to 400 resolution 10ms").Status, command.CacheMiss)
+
+	// Results expire after their time to live.
+	context.ResultCache = command.NewResultCache(10*time.Millisecond, 0, 0)
+	a.EqString(run("select series_1 from 0 to 400 resolution 10ms").Status, command.CacheMiss)
+	time.Sleep(20 * time.Millisecond)
+	a.EqString(run("select series_1 from 0 to 400 resolution 10ms").Status, command.CacheMiss)
+}
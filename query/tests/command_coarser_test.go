@@ -0,0 +1,108 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+)
+
+// tieredStorage offers several resolutions, and refuses to fetch at those
+// finer than a limit.
+type tieredStorage struct {
+	mocks.FakeTimeseriesStorageAPI
+	resolutions []time.Duration // finest first
+	finest      time.Duration   // the finest resolution which can be fetched
+}
+
+func (s tieredStorage) ChooseResolution(requested api.Timerange, lowerBound time.Duration) (time.Duration, error) {
+	for _, resolution := range s.resolutions {
+		if resolution >= requested.Resolution() && resolution >= lowerBound {
+			return resolution, nil
+		}
+	}
+	return 0, fmt.Errorf("no resolution is at least %s", lowerBound)
+}
+
+func (s tieredStorage) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	if request.Timerange.Resolution() < s.finest {
+		return api.SeriesList{}, timeseries.Error{Metric: request.Metrics[0], Code: timeseries.LimitError, Message: "too many points"}
+	}
+	list := api.SeriesList{}
+	for _, metric := range request.Metrics {
+		values := make([]float64, request.Timerange.Slots())
+		for i := range values {
+			values[i] = 1
+		}
+		list.Series = append(list.Series, api.Timeseries{Values: values, TagSet: metric.TagSet})
+	}
+	return list, nil
+}
+
+func TestCommand_CoarserRetry(t *testing.T) {
+	metadataAPI := mocks.NewFakeMetricMetadataAPI()
+	metadataAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "series_1", TagSet: api.TagSet{"host": "a"}})
+	for _, test := range []struct {
+		finest     time.Duration
+		retry      bool
+		resolution time.Duration // of the result; zero if it fails
+	}{
+		{finest: 10 * time.Millisecond, retry: true, resolution: 10 * time.Millisecond},
+		{finest: 40 * time.Millisecond, retry: false},
+		{finest: 40 * time.Millisecond, retry: true, resolution: 40 * time.Millisecond},
+		{finest: time.Second, retry: true}, // no resolution is coarse enough
+	} {
+		a := assert.New(t).Contextf("%+v", test)
+		storage := tieredStorage{
+			resolutions: []time.Duration{10 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond},
+			finest:      test.finest,
+		}
+		testCommand, err := parser.Parse("select series_1 from 0 to 400 resolution 10ms")
+		a.CheckError(err)
+		context := command.ExecutionContext{
+			TimeseriesStorageAPI: storage,
+			MetricMetadataAPI:    metadataAPI,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+			CoarserRetry:         test.retry,
+		}
+		result, err := testCommand.Execute(context)
+		if test.resolution == 0 {
+			if err == nil {
+				a.Errorf("expected an error")
+			}
+			continue
+		}
+		a.CheckError(err)
+		body := result.Body.([]command.QueryResult)[0]
+		a.EqInt(int(body.Timerange.Resolution()/time.Millisecond), int(test.resolution/time.Millisecond))
+		coarser, ok := result.Metadata["coarser_resolution"].(command.CoarserResolution)
+		a.EqBool(ok, test.resolution != 10*time.Millisecond)
+		if ok {
+			a.EqInt(int(coarser.From/time.Millisecond), 10)
+			a.EqBool(strings.HasPrefix(result.Metadata["notes"].([]string)[0], "The query exceeded a limit at a resolution of 10ms"), true)
+		}
+	}
+}
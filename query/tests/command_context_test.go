@@ -0,0 +1,92 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+// cancellableMetadataAPI fails the requests whose context has been
+// cancelled, as the Cassandra metadata API does.
+type cancellableMetadataAPI struct {
+	metadata.MetricAPI
+}
+
+func (c cancellableMetadataAPI) GetAllTags(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.MetricAPI.GetAllTags(ctx, metricKey, context)
+}
+
+func (c cancellableMetadataAPI) GetAllMetrics(ctx context.Context, context metadata.Context) ([]api.MetricKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.MetricAPI.GetAllMetrics(ctx, context)
+}
+
+func (c cancellableMetadataAPI) GetMetricsForTag(ctx context.Context, tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.MetricAPI.GetMetricsForTag(ctx, tagKey, tagValue, context)
+}
+
+func TestCommand_MetadataContext(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 60, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3}, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+	)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, query := range []string{
+		"describe all",
+		"describe cpu",
+		"describe keys cpu",
+		"describe metrics where host = 'a'",
+		"explain select cpu from 0 to 60 resolution 30ms",
+	} {
+		a := assert.New(t).Contextf("%s", query)
+		testCommand, err := parser.Parse(query)
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		executionContext := command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    cancellableMetadataAPI{comboAPI},
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		}
+		_, err = testCommand.Execute(executionContext)
+		a.CheckError(err)
+		// The metadata requests are cancelled with the query.
+		executionContext.Ctx = cancelled
+		_, err = testCommand.Execute(executionContext)
+		a.Eq(err, context.Canceled)
+	}
+}
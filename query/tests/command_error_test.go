@@ -67,3 +67,62 @@ func TestCommandError(t *testing.T) {
 		t.Errorf(`"6 additional series" expected in error message %s`, err.Error())
 	}
 }
+
+func TestCommandError_Arguments(t *testing.T) {
+	testTimerange, err := api.NewTimerange(0, 120, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(testTimerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5}, TagSet: api.TagSet{"metric": "testmetric", "host": "h1"}},
+	)
+	for _, test := range []struct {
+		query   string
+		message string
+	}{
+		{
+			"select transform.moving_average(testmetric, 5) from 0 to 120 resolution 30ms",
+			"transform.moving_average: second argument must be a duration, got '5' (at line 1, column 8)",
+		},
+		{
+			"select transform.moving_average(testmetric, -5m) from 0 to 120 resolution 30ms",
+			"transform.moving_average: second argument must be a non-negative duration, got '-5m' (at line 1, column 8)",
+		},
+		{
+			"select testmetric | filter.highest_max('three') from 0 to 120 resolution 30ms",
+			"filter.highest_max: second argument must be a scalar, got '\"three\"' (at line 1, column 21)",
+		},
+		{
+			"select summarize.mean(testmetric, -30ms) from 0 to 120 resolution 30ms",
+			"summarize.mean: second argument must be a non-negative duration, got '-30ms' (at line 1, column 8)",
+		},
+		{
+			"select testmetric\n  | transform.abs\n  | forecast.rolling_seasonal(0ms, 0.5) from 0 to 120 resolution 30ms",
+			"forecast.rolling_seasonal: second argument must be a positive duration, got '0ms' (at line 3, column 5)",
+		},
+		{
+			"select tag.set(testmetric, 'host', 5m) from 0 to 120 resolution 30ms",
+			"tag.set: third argument must be a string, got '5m' (at line 1, column 8)",
+		},
+	} {
+		testCommand, err := parser.Parse(test.query)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %s", test.query, err.Error())
+			continue
+		}
+		_, err = testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Timeout:              100 * time.Millisecond,
+			Ctx:                  context.Background(),
+		})
+		if err == nil {
+			t.Errorf("expected error for query %q", test.query)
+			continue
+		}
+		if err.Error() != test.message {
+			t.Errorf("expected error message %q but got %q for query %q", test.message, err.Error(), test.query)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+)
+
+// unfetchableAPI fails every fetch, to show that explain fetches nothing.
+type unfetchableAPI struct {
+	mocks.FakeComboAPI
+}
+
+func (unfetchableAPI) FetchSingleTimeseries(request timeseries.FetchRequest) (api.Timeseries, error) {
+	return api.Timeseries{}, fmt.Errorf("unexpected fetch")
+}
+
+func (unfetchableAPI) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	return api.SeriesList{}, fmt.Errorf("unexpected fetch")
+}
+
+func TestCommand_Explain(t *testing.T) {
+	hour := int64(3600 * 1000)
+	timerange, err := api.NewSnappedTimerange(0, 20*hour, hour)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	values := make([]float64, timerange.Slots())
+	comboAPI := unfetchableAPI{mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: values, TagSet: api.TagSet{"metric": "cpu", "host": "a"}},
+		api.Timeseries{Values: values, TagSet: api.TagSet{"metric": "cpu", "host": "b"}},
+		api.Timeseries{Values: values, TagSet: api.TagSet{"metric": "cpu", "host": "c"}},
+		api.Timeseries{Values: values, TagSet: api.TagSet{"metric": "memory", "host": "a"}},
+	)}
+	executionContext := command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           3,
+		SlotLimit:            10,
+		Ctx:                  context.Background(),
+	}
+	a := assert.New(t)
+
+	query := fmt.Sprintf("explain select cpu | transform.moving_average(3h), aggregate.sum(cpu[host = 'a'] + memory) where host != 'c' from %d to %d resolution 1h", 10*hour, 20*hour)
+	testCommand, err := parser.Parse(query)
+	if err != nil {
+		t.Fatalf("Error parsing the explain: %s", err.Error())
+	}
+	a.EqString(testCommand.Name(), "explain")
+	result, err := testCommand.Execute(executionContext)
+	a.CheckError(err)
+	explanation := result.Body.(command.Explanation)
+	a.EqInt(int(explanation.Timerange.StartMillis()), int(10*hour))
+	a.EqInt(int(explanation.WidenedTimerange.StartMillis()), int(7*hour))
+	a.EqInt(explanation.Slots, 11)
+	a.Eq(explanation.Functions, []string{"transform.moving_average", "aggregate.sum", "+"})
+	a.Eq(explanation.Fetches, []command.FetchPlan{
+		{Metric: "cpu", Predicate: `not host = "c"`, Matched: 2, Fetched: 2},
+		{Metric: "cpu", Predicate: `(host = "a" and not host = "c")`, Matched: 1, Fetched: 1},
+		{Metric: "memory", Predicate: `not host = "c"`, Matched: 1, Fetched: 1},
+	})
+	a.EqInt(explanation.ExpectedFetches, 4)
+	a.Eq(explanation.Problems, []string{
+		"the 11 points of each series exceed the slot limit of 10",
+		"the 4 series fetched exceed the fetch limit of 3",
+	})
+
+	testCommand, err = parser.Parse(fmt.Sprintf("explain select cpu from %d to %d resolution 1h sample 50%%", 10*hour, 20*hour))
+	if err != nil {
+		t.Fatalf("Error parsing the explain: %s", err.Error())
+	}
+	executionContext.SlotLimit = 100
+	result, err = testCommand.Execute(executionContext)
+	a.CheckError(err)
+	explanation = result.Body.(command.Explanation)
+	a.EqInt(explanation.Fetches[0].Matched, 3)
+	a.EqBool(explanation.Fetches[0].Fetched <= 3, true)
+	a.Eq(explanation.Problems, []string{})
+
+	// A rate widens by its maximum gap, as well as the slot before.
+	for _, test := range []struct {
+		rate  string
+		start int64
+	}{
+		{"transform.rate(cpu)", 9 * hour},
+		{"transform.rate(cpu, 2h)", 7 * hour},
+	} {
+		testCommand, err = parser.Parse(fmt.Sprintf("explain select %s from %d to %d resolution 1h", test.rate, 10*hour, 20*hour))
+		if err != nil {
+			t.Fatalf("Error parsing the explain: %s", err.Error())
+		}
+		result, err = testCommand.Execute(executionContext)
+		a.CheckError(err)
+		explanation = result.Body.(command.Explanation)
+		a.Contextf("%s", test.rate).EqInt(int(explanation.WidenedTimerange.StartMillis()), int(test.start))
+	}
+}
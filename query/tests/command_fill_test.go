@@ -0,0 +1,70 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_Fill(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	nan := math.NaN()
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{nan, 1, nan, nan, 4}, TagSet: api.TagSet{"metric": "series_1", "host": "a"}},
+	)
+	for _, test := range []struct {
+		query    string
+		expected []float64
+	}{
+		{"select series_1 from 0 to 120 resolution 30ms", []float64{nan, 1, nan, nan, 4}},
+		{"select series_1 from 0 to 120 resolution 30ms fill 0", []float64{0, 1, 0, 0, 4}},
+		{"select series_1 from 0 to 120 resolution 30ms fill forward", []float64{nan, 1, 1, 1, 4}},
+		{"select series_1 from 0 to 120 resolution 30ms fill interpolate", []float64{nan, 1, 2, 3, 4}},
+		// The clause applies to the results, after the functions.
+		{"select series_1 | transform.fill(-1) from 0 to 120 resolution 30ms fill interpolate", []float64{-1, 1, -1, -1, 4}},
+		{"select series_1 | transform.interpolate from 0 to 120 resolution 30ms fill 0", []float64{0, 1, 2, 3, 4}},
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		testCommand, err := parser.Parse(test.query)
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		series := result.Body.([]command.QueryResult)[0].Series
+		a.EqInt(len(series), 1)
+		a.EqFloatArray(series[0].Values, test.expected, 1e-9)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_Forecast(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 9*3600*1000, 3600*1000)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		// rises by 10 each hour, reaching 100 at 9h
+		api.Timeseries{Values: []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}, TagSet: api.TagSet{"metric": "disk", "host": "full"}},
+		// rises by about 5 each hour, reaching 100 at about 14h
+		api.Timeseries{Values: []float64{30, 36, 40, 44, 50, 56, 60, 64, 70, 76}, TagSet: api.TagSet{"metric": "disk", "host": "filling"}},
+		api.Timeseries{Values: []float64{50, 48, 46, 44, 42, 40, 38, 36, 34, 32}, TagSet: api.TagSet{"metric": "disk", "host": "emptying"}},
+		api.Timeseries{Values: []float64{nan, nan, nan, nan, nan, nan, nan, nan, 10, 20}, TagSet: api.TagSet{"metric": "disk", "host": "new"}},
+	)
+	testCommand, err := parser.Parse("forecast disk reach 100 from 0 to 32400000 resolution 1h")
+	if err != nil {
+		t.Fatalf("Error parsing the forecast: %s", err.Error())
+	}
+	a := assert.New(t)
+	a.EqString(testCommand.Name(), "forecast")
+	result, err := testCommand.Execute(command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	})
+	a.CheckError(err)
+	body := result.Body.([]command.ForecastResult)
+	a.EqInt(len(body), 1)
+	a.EqFloat(body[0].Threshold, 100, 0)
+	forecasts := map[string]command.SeriesForecast{}
+	for _, forecast := range body[0].Series {
+		forecasts[forecast.TagSet["host"]] = forecast
+	}
+	a.EqString(forecasts["full"].Status, command.ForecastReached)
+	a.EqString(forecasts["emptying"].Status, command.ForecastNever)
+	a.EqString(forecasts["new"].Status, command.ForecastInsufficient)
+
+	filling := forecasts["filling"]
+	a.EqString(filling.Status, command.ForecastApproaching)
+	a.EqInt(filling.Points, 10)
+	a.EqFloat(filling.RatePerDay, 5.0*24, 3)
+	hours := func(millis *int64) float64 {
+		if millis == nil {
+			t.Fatalf("expected a time")
+		}
+		return float64(*millis) / float64(time.Hour/time.Millisecond)
+	}
+	a.EqFloat(hours(filling.Estimate), 14, 0.2)
+	if !(hours(filling.Earliest) < hours(filling.Estimate) && hours(filling.Estimate) < hours(filling.Latest)) {
+		t.Errorf("expected the estimate %v to be within its interval [%v, %v]", hours(filling.Estimate), hours(filling.Earliest), hours(filling.Latest))
+	}
+	a.EqBool(hours(filling.Latest) < 16, true)
+}
+
+func TestCommand_ForecastRequiresSeries(t *testing.T) {
+	testCommand, err := parser.Parse("forecast 1 reach 2 from 0 to 0")
+	if err != nil {
+		t.Fatalf("Error parsing the forecast: %s", err.Error())
+	}
+	_, err = testCommand.Execute(command.ExecutionContext{
+		TimeseriesStorageAPI: mocks.FakeTimeseriesStorageAPI{},
+		MetricMetadataAPI:    mocks.NewFakeMetricMetadataAPI(),
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	})
+	if err == nil {
+		t.Errorf("expected an error forecasting a scalar")
+	}
+}
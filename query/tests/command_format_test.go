@@ -0,0 +1,77 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_Format(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 60, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1234567, 20, 30}, TagSet: api.TagSet{"metric": "net.bytes_per_second", "host": "a"}},
+		api.Timeseries{Values: []float64{100, 20, 30}, TagSet: api.TagSet{"metric": "net.bytes_per_second", "host": "b"}},
+		api.Timeseries{Values: []float64{0.5, 12.5, 3}, TagSet: api.TagSet{"metric": "rpc.latency_ms", "host": "a"}},
+		api.Timeseries{Values: []float64{0.004, 0.001, 0.002}, TagSet: api.TagSet{"metric": "queue.depth", "host": "a"}},
+		api.Timeseries{Values: []float64{1, 2, 3}, TagSet: api.TagSet{"metric": "disk.bytes", "host": "a"}},
+	)
+	for _, test := range []struct {
+		query    string
+		expected command.FormatHint
+	}{
+		{"select net.bytes_per_second", command.FormatHint{Unit: "B/s", Prefix: "M", Scale: 1e6, Decimals: 2, Label: "MB/s"}},
+		{"select net.bytes_per_second where host = 'b'", command.FormatHint{Unit: "B/s", Scale: 1, Decimals: 0, Label: "B/s"}},
+		{"select net.bytes_per_second | aggregate.sum", command.FormatHint{Unit: "B/s", Prefix: "M", Scale: 1e6, Decimals: 2, Label: "MB/s"}},
+		{"select rpc.latency_ms", command.FormatHint{Unit: "ms", Scale: 1, Decimals: 1, Label: "ms"}},
+		{"select queue.depth", command.FormatHint{Scale: 1, Decimals: 5}},
+		// Functions which change the unit make it unknown.
+		{"select rpc.latency_ms * 1000", command.FormatHint{Prefix: "k", Scale: 1e3, Decimals: 1, Label: "k"}},
+		{"select net.bytes_per_second + rpc.latency_ms", command.FormatHint{Prefix: "M", Scale: 1e6, Decimals: 2, Label: "M"}},
+		{"select disk.bytes | transform.rate", command.FormatHint{Unit: "B/s", Scale: 1, Decimals: 1, Label: "B/s"}},
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		testCommand, err := parser.Parse(test.query + " from 0 to 60 resolution 30ms")
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		format := result.Body.([]command.QueryResult)[0].Format
+		if format == nil {
+			a.Errorf("expected a format hint")
+			continue
+		}
+		a.Eq(*format, test.expected)
+	}
+}
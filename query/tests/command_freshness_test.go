@@ -0,0 +1,67 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_Freshness(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	a.CheckError(err)
+	nan := math.NaN()
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5}, TagSet: api.TagSet{"metric": "series_1", "host": "a"}},
+		api.Timeseries{Values: []float64{6, 7, 8, nan, nan}, TagSet: api.TagSet{"metric": "series_1", "host": "b"}},
+		api.Timeseries{Values: []float64{nan, nan, nan, nan, nan}, TagSet: api.TagSet{"metric": "series_1", "host": "c"}},
+	)
+	testCommand, err := parser.Parse("select series_1, freshness(series_1) from 0 to 120 resolution 30ms")
+	a.CheckError(err)
+	result, err := testCommand.Execute(command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Timeout:              100 * time.Millisecond,
+		Ctx:                  context.Background(),
+	})
+	a.CheckError(err)
+
+	freshness := result.Metadata["freshness"].([]function.Freshness)
+	a.EqInt(len(freshness), 1)
+	a.EqString(freshness[0].Metric, "series_1")
+	a.EqInt(freshness[0].Series, 3) // the fetch is shared by both expressions
+	a.EqInt(freshness[0].Empty, 1)
+	a.EqFloat(freshness[0].MinLagSeconds, 0, 1e-9)
+	a.EqFloat(freshness[0].MaxLagSeconds, 0.06, 1e-9)
+
+	lags := map[string]float64{}
+	for _, scalar := range result.Body.([]command.QueryResult)[1].Scalars {
+		lags[scalar.TagSet["host"]] = scalar.Value
+	}
+	a.EqFloat(lags["a"], 0, 1e-9)
+	a.EqFloat(lags["b"], 0.06, 1e-9)
+	a.EqBool(math.IsNaN(lags["c"]), true)
+}
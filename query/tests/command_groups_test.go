@@ -0,0 +1,64 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_MissingGroupTags(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5}, TagSet: api.TagSet{"metric": "series_1", "host": "a", "dc": "west"}},
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5}, TagSet: api.TagSet{"metric": "series_1", "host": "b", "dc": "east"}},
+	)
+	for _, test := range []struct {
+		query  string
+		groups int
+		notes  []string
+	}{
+		{"select aggregate.sum(series_1 group by host) from 0 to 120 resolution 30ms", 2, nil},
+		{"select aggregate.sum(series_1 group by hostt) from 0 to 120 resolution 30ms", 1, []string{`aggregate.sum: none of the 2 series has the tag "hostt" named in its "group by" clause`}},
+		{"select aggregate.max(series_1 group by dc, hostt, rack) from 0 to 120 resolution 30ms", 2, []string{`aggregate.max: none of the 2 series has the tag "hostt" or "rack" named in its "group by" clause`}},
+		{"select aggregate.sum(series_1 collapse by hostt) from 0 to 120 resolution 30ms", 2, []string{`aggregate.sum: none of the 2 series has the tag "hostt" named in its "collapse by" clause`}},
+		{"select aggregate.sum(series_1[host = 'z'] group by hostt) from 0 to 120 resolution 30ms", 0, nil}, // no series, so nothing is missing
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		testCommand, err := parser.Parse(test.query)
+		a.CheckError(err)
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Timeout:              100 * time.Millisecond,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		a.EqInt(len(result.Body.([]command.QueryResult)[0].Series), test.groups)
+		notes, _ := result.Metadata["notes"].([]string)
+		a.Eq(notes, test.notes)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/macro"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestCommand_Macros(t *testing.T) {
+	store := memory.NewStore(time.Minute)
+	for i, host := range []string{"a", "b"} {
+		i := float64(i)
+		store.AddGenerated(api.TaggedMetric{MetricKey: "errors", TagSet: api.TagSet{"host": host}}, func(t time.Time) float64 {
+			return float64(t.Minute()%5) + i
+		})
+		store.AddGenerated(api.TaggedMetric{MetricKey: "requests", TagSet: api.TagSet{"host": host}}, func(t time.Time) float64 {
+			return float64(t.Minute()) + 10*i + 1
+		})
+	}
+	macros := macro.NewRegistry(registry.Default())
+	a := assert.New(t)
+	a.CheckError(macros.Define(macro.Definition{Name: "error_rate", Parameters: []string{"errors", "requests"}, Expression: "errors / requests"}))
+	a.CheckError(macros.Define(macro.Definition{Name: "smooth", Parameters: []string{"x"}, Expression: "transform.moving_average(x, 10m)"}))
+
+	run := func(query string) command.Result {
+		testCommand, err := parser.Parse(query)
+		a.CheckError(err)
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: store,
+			MetricMetadataAPI:    store,
+			FetchLimit:           1000,
+			Registry:             macros,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		return result
+	}
+	for _, test := range []struct {
+		macro    string
+		expanded string
+	}{
+		{"tenant.error_rate(errors, requests)", "errors / requests"},
+		{"tenant.error_rate(errors[host = 'a'], requests + 1)", "errors[host = 'a'] / (requests + 1)"},
+		{"tenant.smooth(requests)", "transform.moving_average(requests, 10m)"},
+		{"tenant.smooth(tenant.error_rate(errors, requests))", "transform.moving_average(errors / requests, 10m)"},
+	} {
+		a := a.Contextf("%s", test.macro)
+		actual := run("select " + test.macro + " from 3600000 to 7200000 resolution 1m")
+		expected := run("select " + test.expanded + " from 3600000 to 7200000 resolution 1m")
+		a.Eq(actual.Body.([]command.QueryResult)[0].Series, expected.Body.([]command.QueryResult)[0].Series)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/query/predicate"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+type fakeMaintenanceAPI []command.MaintenanceWindow
+
+func (windows fakeMaintenanceAPI) GetMaintenanceWindows(timerange api.Timerange) ([]command.MaintenanceWindow, error) {
+	return windows, nil
+}
+
+func TestCommand_SuppressMaintenance(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5}, TagSet: api.TagSet{"metric": "series_1", "host": "a"}},
+		api.Timeseries{Values: []float64{6, 7, 8, 9, 10}, TagSet: api.TagSet{"metric": "series_1", "host": "b"}},
+	)
+	maintenance := fakeMaintenanceAPI{
+		{
+			Predicate: predicate.ListMatcher{Tag: "host", Values: []string{"a"}},
+			Start:     time.Unix(0, 30*1e6),
+			End:       time.Unix(0, 90*1e6),
+		},
+	}
+	nan := math.NaN()
+	for _, test := range []struct {
+		suppress bool
+		expected map[string][]float64
+	}{
+		{false, map[string][]float64{"a": {1, 2, 3, 4, 5}, "b": {6, 7, 8, 9, 10}}},
+		{true, map[string][]float64{"a": {1, nan, nan, 4, 5}, "b": {6, 7, 8, 9, 10}}},
+	} {
+		a := assert.New(t).Contextf("suppress=%t", test.suppress)
+		testCommand, err := parser.Parse("select series_1 from 0 to 120 resolution 30ms")
+		a.CheckError(err)
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			MaintenanceAPI:       maintenance,
+			SuppressMaintenance:  test.suppress,
+			FetchLimit:           1000,
+			Timeout:              100 * time.Millisecond,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		series := result.Body.([]command.QueryResult)[0].Series
+		a.EqInt(len(series), 2)
+		for _, s := range series {
+			a.Contextf("host=%s", s.TagSet["host"]).EqFloatArray(s.Values, test.expected[s.TagSet["host"]], 1e-9)
+		}
+		a.EqInt(len(result.Metadata["notes"].([]string)), map[bool]int{false: 0, true: 1}[test.suppress])
+	}
+}
+
+func TestCommand_SuppressMaintenanceBeforeFunctions(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5}, TagSet: api.TagSet{"metric": "series_1", "host": "a"}},
+		api.Timeseries{Values: []float64{6, 7, 8, 9, 10}, TagSet: api.TagSet{"metric": "series_1", "host": "b"}},
+	)
+	maintenance := fakeMaintenanceAPI{
+		{
+			Predicate: predicate.ListMatcher{Tag: "host", Values: []string{"a"}},
+			Start:     time.Unix(0, 30*1e6),
+			End:       time.Unix(0, 90*1e6),
+		},
+	}
+	// The series of host a is masked before it's aggregated, so the sums
+	// during the window are those of host b alone.
+	for _, query := range []string{
+		"select series_1 | aggregate.sum from 0 to 120 resolution 30ms",
+		"select aggregate.sum(series_1 * 1) from 0 to 120 resolution 30ms",
+	} {
+		a := assert.New(t).Contextf("%s", query)
+		testCommand, err := parser.Parse(query)
+		a.CheckError(err)
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			MaintenanceAPI:       maintenance,
+			SuppressMaintenance:  true,
+			FetchLimit:           1000,
+			Timeout:              100 * time.Millisecond,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		series := result.Body.([]command.QueryResult)[0].Series
+		a.EqInt(len(series), 1)
+		a.EqFloatArray(series[0].Values, []float64{7, 7, 8, 13, 15}, 1e-9)
+		a.Eq(result.Metadata["notes"], []string{"1 fetched series had points suppressed during maintenance windows"})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_OperatorMatching(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 30, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	// The errors and requests of each host come from different sources, so
+	// the natural join of the two matches nothing.
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2}, TagSet: api.TagSet{"metric": "errors", "host": "a", "code": "500", "source": "proxy"}},
+		api.Timeseries{Values: []float64{3, 4}, TagSet: api.TagSet{"metric": "errors", "host": "a", "code": "503", "source": "proxy"}},
+		api.Timeseries{Values: []float64{5, 6}, TagSet: api.TagSet{"metric": "errors", "host": "b", "code": "500", "source": "proxy"}},
+		api.Timeseries{Values: []float64{10, 10}, TagSet: api.TagSet{"metric": "requests", "host": "a", "source": "app"}},
+		api.Timeseries{Values: []float64{100, 100}, TagSet: api.TagSet{"metric": "requests", "host": "b", "source": "app"}},
+	)
+	for _, test := range []struct {
+		expression string
+		expected   map[string][]float64 // by serialized tagset; unchecked if nil
+		notes      []string
+	}{
+		{"errors / requests", map[string][]float64{}, nil},
+		{"errors / on (host) requests", map[string][]float64{
+			"code=500,host=a": {0.1, 0.2},
+			"code=503,host=a": {0.3, 0.4},
+			"code=500,host=b": {0.05, 0.06},
+		}, nil},
+		{"errors / ignoring (source) requests", map[string][]float64{
+			"code=500,host=a": {0.1, 0.2},
+			"code=503,host=a": {0.3, 0.4},
+			"code=500,host=b": {0.05, 0.06},
+		}, nil},
+		{"aggregate.sum(errors group by host, source) / on (host) requests > 0.1", map[string][]float64{
+			"host=a": {1, 1},
+			"host=b": {0, 0},
+		}, nil},
+		// every series matches, since neither has the misspelled tag
+		{"errors / on (hots) requests", nil, []string{`/: neither side has the tag hots named in its "on" clause`}},
+	} {
+		a := assert.New(t).Contextf("%s", test.expression)
+		testCommand, err := parser.Parse("select " + test.expression + " from 0 to 30 resolution 30ms")
+		a.CheckError(err)
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		notes, _ := result.Metadata["notes"].([]string)
+		a.Eq(notes, test.notes)
+		if test.expected == nil {
+			continue
+		}
+		actual := map[string][]float64{}
+		for _, series := range result.Body.([]command.QueryResult)[0].Series {
+			actual[series.TagSet.Serialize()] = series.Values
+		}
+		a.EqInt(len(actual), len(test.expected))
+		for tagset, values := range test.expected {
+			a.Contextf("%s", tagset).EqFloatArray(actual[tagset], values, 1e-9)
+		}
+	}
+}
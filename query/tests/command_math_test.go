@@ -0,0 +1,59 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_UndefinedMathNotes(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 90, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{-1, 0, 1, 1000}, TagSet: api.TagSet{"metric": "series_1", "host": "a"}},
+	)
+	for _, test := range []struct {
+		expression string
+		notes      []string
+	}{
+		{"transform.log(series_1)", []string{"transform.log: 2 values were zero or negative, and are NaN"}},
+		{"transform.log(series_1 + 1, 2)", []string{"transform.log: 1 value was zero or negative, and is NaN"}},
+		{"transform.exp(series_1)", []string{"transform.exp: 1 value was too large to exponentiate, and is NaN"}},
+		{"transform.exp(series_1 - 1000)", nil},
+		{"transform.clamp(series_1, 0, 1)", nil},
+	} {
+		a := assert.New(t).Contextf("%s", test.expression)
+		testCommand, err := parser.Parse("select " + test.expression + " from 0 to 90 resolution 30ms")
+		a.CheckError(err)
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		notes, _ := result.Metadata["notes"].([]string)
+		a.Eq(notes, test.notes)
+	}
+}
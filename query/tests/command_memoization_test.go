@@ -0,0 +1,63 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_SharedSubexpressions(t *testing.T) {
+	metadataAPI := mocks.NewFakeMetricMetadataAPI()
+	metadataAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "series_1", TagSet: api.TagSet{"host": "a", "dc": "east"}})
+	metadataAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "series_1", TagSet: api.TagSet{"host": "b", "dc": "east"}})
+	for _, test := range []struct {
+		query   string
+		fetches int
+	}{
+		{"select series_1, series_1 + 1, series_1 | transform.abs", 1},
+		{"select aggregate.sum(series_1 group by dc), series_1 | aggregate.sum(group by dc) | transform.abs", 1},
+		{"select aggregate.sum(series_1 group by dc), aggregate.sum(series_1 group by host)", 1},
+		{"select series_1 {first}, series_1 {second}, (series_1 {third}) + 1", 1},
+		{`select series_1[host = 'a'], series_1[host="a"] * 2`, 1},
+		{"select transform.moving_average(series_1, 20ms), transform.moving_average(series_1, 20ms) | aggregate.max", 1},
+		// Different fetches aren't shared.
+		{"select series_1[host = 'a'], series_1", 2},
+		{"select series_1, series_1 | transform.timeshift(-10ms)", 2},
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		testCommand, err := parser.Parse(test.query + " from 0 to 100 resolution 10ms")
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		fetches := new(int32)
+		_, err = testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: countingStorage{fetches: fetches},
+			MetricMetadataAPI:    metadataAPI,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		a.EqInt(int(atomic.LoadInt32(fetches)), test.fetches)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_MemoryLimit(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5}, TagSet: api.TagSet{"metric": "testmetric", "host": "h1"}},
+		api.Timeseries{Values: []float64{5, 4, 3, 4, 5}, TagSet: api.TagSet{"metric": "testmetric", "host": "h2"}},
+		api.Timeseries{Values: []float64{1, 7, 7, 7, 5}, TagSet: api.TagSet{"metric": "testmetric", "host": "h3"}},
+	)
+	// Each fetch of testmetric holds 3 series of 5 slots, which is 120 bytes.
+	for _, test := range []struct {
+		query   string
+		limit   int64
+		succeed bool
+	}{
+		{"select testmetric from 0 to 120 resolution 30ms", 0, true},
+		{"select testmetric from 0 to 120 resolution 30ms", 120, true},
+		{"select testmetric from 0 to 120 resolution 30ms", 119, false},
+		{"select testmetric, testmetric[host = 'h1'] from 0 to 120 resolution 30ms", 160, true},
+		{`select testmetric + testmetric[host != "h4"] from 0 to 120 resolution 30ms`, 200, false},
+	} {
+		a := assert.New(t).Contextf("%s with limit %d", test.query, test.limit)
+		testCommand, err := parser.Parse(test.query)
+		a.CheckError(err)
+		_, err = testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			MemoryLimit:          test.limit,
+			Ctx:                  context.Background(),
+		})
+		if test.succeed {
+			a.CheckError(err)
+			continue
+		}
+		if _, ok := err.(function.MemoryLimitError); !ok {
+			a.Errorf("expected a memory limit error, but got %v", err)
+		}
+	}
+}
@@ -60,6 +60,10 @@ func TestQueryNaming(t *testing.T) {
 			query:    "select series_1*17 from 0 to 0",
 			expected: "(series_1 * 17)",
 		},
+		{
+			query:    "select series_1 / (1024*1024) from 0 to 0",
+			expected: "(series_1 / (1024 * 1024))",
+		},
 		{
 			query:    "select aggregate.sum(series_1) from 0 to 0",
 			expected: "aggregate.sum(series_1)",
@@ -136,6 +140,14 @@ func TestQueryNaming(t *testing.T) {
 			query:    "series_1[`foo-bar` = 'qaz' and `foo-bar` match 'x' and `foo-bar` in ('a', 'b')] from 0 to 0",
 			expected: "series_1[(`foo-bar` = \"qaz\" and (`foo-bar` match \"x\" and `foo-bar` in (\"a\", \"b\")))]",
 		},
+		{
+			query:    "series_1[peer in cidr '10.1.2.3/8' and not peer in cidr ('192.168.0.0/16', 'FD00::/8')] from 0 to 0",
+			expected: `series_1[(peer in cidr "10.0.0.0/8" and not peer in cidr ("192.168.0.0/16", "fd00::/8"))]`,
+		},
+		{
+			query:    "series_1[dc in glob 'us-*' and not host in glob('web-??', \"db-*\")] from 0 to 0",
+			expected: `series_1[(dc in glob "us-*" and not host in glob ("web-??", "db-*"))]`,
+		},
 		{
 			query:    "_names423.with_.dots_and_und3rsc0r3s from 0 to 0",
 			expected: "_names423.with_.dots_and_und3rsc0r3s",
@@ -0,0 +1,117 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+// orderAPI returns a storage API with a series for each of the hosts a to e.
+func orderAPI(t *testing.T) mocks.FakeComboAPI {
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	nan := math.NaN()
+	return mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 9, 1, 1, 1}, TagSet: api.TagSet{"metric": "series_1", "host": "a"}},
+		api.Timeseries{Values: []float64{5, 5, 5, 5, 2}, TagSet: api.TagSet{"metric": "series_1", "host": "b"}},
+		api.Timeseries{Values: []float64{nan, nan, nan, nan, nan}, TagSet: api.TagSet{"metric": "series_1", "host": "c"}},
+		api.Timeseries{Values: []float64{3, 3, 3, 3, 3}, TagSet: api.TagSet{"metric": "series_1", "host": "d"}},
+		api.Timeseries{Values: []float64{2, 2, 2, 2, 9}, TagSet: api.TagSet{"metric": "series_1", "host": "e"}},
+	)
+}
+
+func TestCommand_OrderAndLimit(t *testing.T) {
+	comboAPI := orderAPI(t)
+	for _, test := range []struct {
+		clause   string
+		expected []string
+	}{
+		{"", []string{"a", "b", "c", "d", "e"}},
+		{"order by max", []string{"d", "b", "a", "e", "c"}},
+		{"order by max desc", []string{"a", "e", "b", "d", "c"}},
+		{"order by mean desc", []string{"b", "e", "d", "a", "c"}},
+		{"order by current asc", []string{"a", "b", "d", "e", "c"}},
+		{"order by sum desc limit 2", []string{"b", "e"}},
+		{"limit 2 order by min", []string{"a", "b"}},
+		{"limit 3", []string{"a", "b", "c"}},
+		{"limit 10", []string{"a", "b", "c", "d", "e"}},
+	} {
+		a := assert.New(t).Contextf("%s", test.clause)
+		testCommand, err := parser.Parse("select series_1 from 0 to 120 resolution 30ms " + test.clause)
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Timeout:              100 * time.Millisecond,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		series := result.Body.([]command.QueryResult)[0].Series
+		hosts := []string{}
+		for _, s := range series {
+			hosts = append(hosts, s.TagSet["host"])
+		}
+		a.Eq(hosts, test.expected)
+	}
+}
+
+func TestCommand_OrderAndLimitScalars(t *testing.T) {
+	comboAPI := orderAPI(t)
+	execute := func(query string) (command.Result, error) {
+		testCommand, err := parser.Parse(query)
+		if err != nil {
+			return command.Result{}, err
+		}
+		return testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Timeout:              100 * time.Millisecond,
+			Ctx:                  context.Background(),
+		})
+	}
+	a := assert.New(t)
+	result, err := execute("select series_1 | summarize.max from 0 to 120 resolution 30ms order by current limit 3")
+	a.CheckError(err)
+	if err == nil {
+		hosts := []string{}
+		for _, scalar := range result.Body.([]command.QueryResult)[0].Scalars {
+			hosts = append(hosts, scalar.TagSet["host"])
+		}
+		a.Eq(hosts, []string{"d", "b", "a"})
+	}
+
+	// Tables can't be ordered, so the clauses are rejected rather than ignored.
+	_, err = execute("select summarize_table(series_1, 'max') from 0 to 120 resolution 30ms limit 2")
+	if err == nil {
+		t.Fatalf("expected an error for a limit on a table")
+	}
+	a.EqString(err.Error(), "'order by' and 'limit' only apply to series and scalars, but summarize_table(series_1, \"max\") is a table")
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+)
+
+// stallingStorage never finishes fetching points at or after a time, until
+// the request is cancelled. It counts the fetches made.
+type stallingStorage struct {
+	mocks.FakeComboAPI
+	stallFrom int64 // milliseconds
+	fetches   *int32
+}
+
+func (s stallingStorage) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	atomic.AddInt32(s.fetches, 1)
+	if request.Timerange.EndMillis() >= s.stallFrom {
+		<-request.Ctx.Done()
+		return api.SeriesList{}, request.Ctx.Err()
+	}
+	return s.FakeComboAPI.FetchMultipleTimeseries(request)
+}
+
+func TestCommand_PartialResults(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewSnappedTimerange(0, 150, 10)
+	a.CheckError(err)
+	values := make([]float64, timerange.Slots())
+	for i := range values {
+		values[i] = float64(i)
+	}
+	storage := stallingStorage{
+		FakeComboAPI: mocks.NewComboAPI(timerange, api.Timeseries{Values: values, TagSet: api.TagSet{"metric": "series_1", "host": "a"}}),
+		stallFrom:    100,
+		fetches:      new(int32),
+	}
+	execute := func(partial bool, timeout time.Duration) (command.Result, error) {
+		testCommand, err := parser.Parse("select series_1 + 1 from 0 to 150 resolution 10ms")
+		a.CheckError(err)
+		return testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: storage,
+			MetricMetadataAPI:    storage,
+			FetchLimit:           1000,
+			Timeout:              timeout,
+			Ctx:                  context.Background(),
+			PartialResults:       partial,
+		})
+	}
+
+	// The whole fetch stalls until the soft deadline, and is retried in chunks
+	// of 2 points; the one ending at 110ms stalls.
+	result, err := execute(true, 200*time.Millisecond)
+	a.CheckError(err)
+	body := result.Body.([]command.QueryResult)[0]
+	a.EqFloatArray(body.Series[0].Values, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 1e-9)
+	a.EqInt(int(body.Timerange.EndMillis()), 90)
+	partial, ok := result.Metadata["partial"].(command.PartialRange)
+	a.EqBool(ok, true)
+	a.EqFloat(partial.Fraction, 10.0/16, 1e-9)
+	a.EqInt(int(partial.RequestedEnd.UnixNano()/1e6), 150)
+	a.EqInt(len(result.Metadata["notes"].([]string)), 1)
+
+	// Without partial results, the query times out.
+	if _, err := execute(false, 200*time.Millisecond); err == nil {
+		t.Errorf("expected a timeout without partial results")
+	}
+
+	// Queries which complete aren't marked, and fetch each series whole.
+	storage.stallFrom = math.MaxInt64
+	atomic.StoreInt32(storage.fetches, 0)
+	result, err = execute(true, time.Second)
+	a.CheckError(err)
+	a.EqInt(int(atomic.LoadInt32(storage.fetches)), 1)
+	a.EqInt(len(result.Body.([]command.QueryResult)[0].Series[0].Values), 16)
+	_, ok = result.Metadata["partial"]
+	a.EqBool(ok, false)
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/inspect"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+// plainStorage hides the push-down capability of the storage it wraps.
+type plainStorage struct {
+	timeseries.StorageAPI
+}
+
+func TestCommand_AggregationPushdown(t *testing.T) {
+	store := memory.NewStore(time.Minute)
+	for i, tags := range []api.TagSet{
+		{"host": "a", "dc": "west"},
+		{"host": "b", "dc": "west"},
+		{"host": "c", "dc": "east"},
+	} {
+		i := float64(i)
+		store.AddGenerated(api.TaggedMetric{MetricKey: "requests", TagSet: tags}, func(t time.Time) float64 {
+			if t.Minute()%7 == 0 && i == 0 {
+				return math.NaN()
+			}
+			return float64(t.Minute()) * (i + 1)
+		})
+	}
+	for _, test := range []struct {
+		query    string
+		pushed   bool
+		expected int
+	}{
+		{"select aggregate.sum(requests) from 0 to 3600000 resolution 1m", true, 1},
+		{"select aggregate.mean(requests group by dc) from 0 to 3600000 resolution 1m", true, 2},
+		{"select aggregate.sum(requests[host != 'c'] collapse by host) from 0 to 3600000 resolution 1m", true, 1},
+		{"select aggregate.sum(requests group by hostt) from 0 to 3600000 resolution 1m", true, 1},
+		{"select aggregate.max(requests group by dc) from 0 to 3600000 resolution 1m", false, 2},
+		{"select aggregate.sum(transform.rate(requests)) from 0 to 3600000 resolution 1m", false, 1},
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		testCommand, err := parser.Parse(test.query)
+		a.CheckError(err)
+		run := func(storage timeseries.StorageAPI) (command.Result, *inspect.Profiler) {
+			profiler := inspect.New()
+			result, err := testCommand.Execute(command.ExecutionContext{
+				TimeseriesStorageAPI: storage,
+				MetricMetadataAPI:    store,
+				FetchLimit:           1000,
+				Profiler:             profiler,
+				Ctx:                  context.Background(),
+			})
+			a.CheckError(err)
+			return result, profiler
+		}
+		pushed, profiler := run(store)
+		plain, _ := run(plainStorage{store})
+
+		recorded := false
+		for _, profile := range profiler.All() {
+			recorded = recorded || profile.Name == "Aggregation push-down"
+		}
+		a.EqBool(recorded, test.pushed)
+		a.EqInt(len(pushed.Body.([]command.QueryResult)[0].Series), test.expected)
+		a.Eq(pushed.Body, plain.Body)
+		a.Eq(pushed.Metadata["notes"], plain.Metadata["notes"])
+
+		// Explain marks the fetches which the storage aggregates.
+		explainCommand, err := parser.Parse("explain " + test.query)
+		a.CheckError(err)
+		for _, storage := range []timeseries.StorageAPI{store, plainStorage{store}} {
+			result, err := explainCommand.Execute(command.ExecutionContext{
+				TimeseriesStorageAPI: storage,
+				MetricMetadataAPI:    store,
+				FetchLimit:           1000,
+				Ctx:                  context.Background(),
+			})
+			a.CheckError(err)
+			fetches := result.Body.(command.Explanation).Fetches
+			a.EqInt(len(fetches), 1)
+			a.EqBool(fetches[0].PushedDown != "", test.pushed && storage == timeseries.StorageAPI(store))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestCommand_RangeRules(t *testing.T) {
+	store := memory.NewStore(time.Second)
+	for _, metric := range []api.MetricKey{"debug.queue_depth", "requests"} {
+		store.AddGenerated(api.TaggedMetric{MetricKey: metric, TagSet: api.TagSet{"host": "a"}}, func(t time.Time) float64 {
+			return 1
+		})
+	}
+	rule, err := command.NewRangeRule(`debug\..*`, 48*time.Hour, time.Minute)
+	if err != nil {
+		t.Fatalf("Error creating range rule: %s", err.Error())
+	}
+	execute := func(query string) (command.Result, error) {
+		testCommand, err := parser.Parse(query)
+		if err != nil {
+			t.Fatalf("Error parsing %s: %s", query, err.Error())
+		}
+		return testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: store,
+			MetricMetadataAPI:    store,
+			FetchLimit:           1000,
+			SlotLimit:            10000,
+			RangeRules:           []command.RangeRule{rule},
+			Ctx:                  context.Background(),
+		})
+	}
+	a := assert.New(t)
+
+	// Other metrics are unaffected.
+	result, err := execute("select requests from 0 to 3600000 resolution 1s")
+	a.CheckError(err)
+	a.Eq(result.Metadata["resolution"], time.Second)
+
+	result, err = execute("select debug.queue_depth from 0 to 3600000 resolution 1s")
+	a.CheckError(err)
+	a.Eq(result.Metadata["resolution"], time.Minute)
+	a.Eq(result.Metadata["notes"], []string{"The metric debug.queue_depth may only be selected at a resolution of 1m0s or coarser."})
+	result, err = execute("select debug.queue_depth from 0 to 3600000 resolution 5m")
+	a.CheckError(err)
+	a.Eq(result.Metadata["notes"], []string(nil))
+
+	// The range includes the points fetched for moving averages.
+	for _, query := range []string{
+		"select debug.queue_depth from -3d to now resolution 1h",
+		"select requests + debug.queue_depth | transform.moving_average(2d) from -1d to now resolution 1h",
+		"explain select debug.queue_depth from -3d to now resolution 1h",
+	} {
+		_, err = execute(query)
+		if _, ok := err.(function.LimitError); !ok || !strings.Contains(err.Error(), "debug.queue_depth may only be selected over at most 48h0m0s") {
+			a.Errorf("expected a limit error for %s, but got %v", query, err)
+		}
+	}
+	_, err = execute("select debug.queue_depth | transform.moving_average(1d) from -1d to now resolution 1h")
+	a.CheckError(err)
+
+	_, err = command.NewRangeRule("debug(", 0, 0)
+	a.EqBool(err == nil, false)
+}
@@ -0,0 +1,101 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/timeseries/memory"
+)
+
+func TestCommand_Sample(t *testing.T) {
+	store := memory.NewStore(time.Minute)
+	sampled := map[string]int{} // dc => sampled series
+	for i := 0; i < 400; i++ {
+		tags := api.TagSet{"host": fmt.Sprintf("h%d", i), "dc": []string{"east", "west"}[i%2]}
+		store.AddGenerated(api.TaggedMetric{MetricKey: "requests", TagSet: tags}, func(t time.Time) float64 {
+			return 2
+		})
+		if function.InSample(tags, 10) {
+			sampled[tags["dc"]]++
+		}
+	}
+	run := func(query string, storage timeseries.StorageAPI) command.Result {
+		testCommand, err := parser.Parse(query)
+		if err != nil {
+			t.Fatalf("Error parsing %s: %s", query, err.Error())
+		}
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: storage,
+			MetricMetadataAPI:    store,
+			FetchLimit:           100, // less than the number of series
+			Ctx:                  context.Background(),
+		})
+		if err != nil {
+			t.Fatalf("Error executing %s: %s", query, err.Error())
+		}
+		return result
+	}
+
+	a := assert.New(t)
+	total := sampled["east"] + sampled["west"]
+	a.EqBool(total > 20 && total < 60, true)
+
+	result := run("select requests from 0 to 600000 resolution 1m sample 10%", store)
+	a.EqInt(len(result.Body.([]command.QueryResult)[0].Series), total)
+	for _, series := range result.Body.([]command.QueryResult)[0].Series {
+		a.EqBool(function.InSample(series.TagSet, 10), true)
+	}
+	report := result.Metadata["sample"].(command.SampleReport)
+	a.EqFloat(report.Percent, 10, 0)
+	a.EqInt(len(report.Fetches), 1)
+	a.EqString(report.Fetches[0].Metric, "requests")
+	a.EqInt(report.Fetches[0].Matched, 400)
+	a.EqInt(report.Fetches[0].Fetched, total)
+	a.EqBool(report.Fetches[0].RelativeError > 0 && report.Fetches[0].RelativeError < 1, true)
+	a.EqInt(len(result.Metadata["notes"].([]string)), 1)
+
+	for _, test := range []struct {
+		query    string
+		expected map[string]float64 // dc => value of each point
+	}{
+		{"select aggregate.sum(requests) from 0 to 600000 resolution 1m sample 10%", map[string]float64{"": 800}},
+		{"select aggregate.sum(requests group by dc) from 0 to 600000 resolution 1m sample 10%", map[string]float64{"east": 400, "west": 400}},
+		{"select aggregate.count(requests group by dc) from 0 to 600000 resolution 1m sample 10%", map[string]float64{"east": 200, "west": 200}},
+		{"select aggregate.mean(requests) from 0 to 600000 resolution 1m sample 10%", map[string]float64{"": 2}},
+		{"select aggregate.max(requests group by dc) from 0 to 600000 resolution 1m sample 10%", map[string]float64{"east": 2, "west": 2}},
+		{"select aggregate.sum(requests[dc = 'east']) from 0 to 600000 resolution 1m sample 10%", map[string]float64{"": 400}},
+	} {
+		for _, storage := range []timeseries.StorageAPI{store, plainStorage{store}} {
+			a := a.Contextf("%s (%T)", test.query, storage)
+			series := run(test.query, storage).Body.([]command.QueryResult)[0].Series
+			a.EqInt(len(series), len(test.expected))
+			for _, s := range series {
+				for _, value := range s.Values {
+					a.EqFloat(value, test.expected[s.TagSet["dc"]], 1e-9)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/expression"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/query/predicate"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_TypedSelectContext(t *testing.T) {
+	a := assert.New(t)
+	parsed, err := parser.Parse("select series_1 from 30000 to 120000 resolution 30s")
+	a.CheckError(err)
+	parsedSelect := parsed.(*command.SelectCommand)
+	a.Eq(parsedSelect.Context.Start.Equal(time.Unix(30, 0)), true)
+	a.Eq(parsedSelect.Context.End.Equal(time.Unix(120, 0)), true)
+	a.Eq(parsedSelect.Context.Resolution, 30*time.Second)
+	a.EqString(parsedSelect.Context.Query(), "from 30000 to 120000 resolution 30000")
+
+	// A select constructed in code gives the same result as the parsed one.
+	built := &command.SelectCommand{
+		Predicate: predicate.TruePredicate{},
+		Expressions: []function.Expression{function.Memoize(&expression.MetricFetchExpression{
+			MetricName: "series_1",
+			Predicate:  predicate.TruePredicate{},
+		})},
+		Context: command.SelectContext{
+			Start:      time.Unix(30, 0),
+			End:        time.Unix(120, 0),
+			Resolution: 30 * time.Second,
+		},
+	}
+	timerange, err := api.NewSnappedTimerange(30000, 120000, 30000)
+	a.CheckError(err)
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4}, TagSet: api.TagSet{"metric": "series_1", "host": "a"}},
+	)
+	context := command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	}
+	parsedResult, err := parsed.Execute(context)
+	a.CheckError(err)
+	builtResult, err := built.Execute(context)
+	a.CheckError(err)
+	a.Eq(builtResult.Body, parsedResult.Body)
+	a.Eq(builtResult.Body.([]command.QueryResult)[0].Series[0].Values, []float64{1, 2, 3, 4})
+}
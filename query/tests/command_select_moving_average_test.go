@@ -103,6 +103,29 @@ func TestSelectMovingAverage(t *testing.T) {
 				"nc": {nnnnn, nnnnn, nnnnn},
 			},
 		},
+		// maximum and minimum
+		{
+			query: "select series_a | transform.moving_max(30ms) from 40 to 70 resolution 10ms",
+			expected: map[string][]float64{
+				"a":  {6, 7, 8, 9},
+				"b":  {2, 4, 4, 4},
+				"c":  {6, 6, 6, 6},
+				"na": {6, 6, 4, 1},
+				"nb": {6, 6, nnnnn, 4},
+				"nc": {nnnnn, nnnnn, nnnnn, nnnnn},
+			},
+		},
+		{
+			query: "select series_a | transform.moving_min(40ms) from 50 to 70 resolution 10ms",
+			expected: map[string][]float64{
+				"a":  {3, 4, 6},
+				"b":  {0, 0, 1},
+				"c":  {2, 2, 2},
+				"na": {4, 4, 1},
+				"nb": {5, 6, 4},
+				"nc": {nnnnn, nnnnn, nnnnn},
+			},
+		},
 		// 0 and negative averages
 		{
 			query: "select series_a | transform.moving_average(0ms) from 50 to 70 resolution 10ms",
@@ -134,6 +157,10 @@ func TestSelectMovingAverage(t *testing.T) {
 			query: "select series_a | transform.exponential_moving_average(-2ms) from 50 to 70 resolution 10ms",
 			err:   true,
 		},
+		{
+			query: "select series_a | transform.moving_max(-2ms) from 50 to 70 resolution 10ms",
+			err:   true,
+		},
 	}
 
 	for _, test := range tests {
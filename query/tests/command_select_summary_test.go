@@ -241,6 +241,28 @@ func TestSelectSummary(t *testing.T) {
 				api.TagSet{"dc": "miss"}.Serialize(): 5,
 			},
 		},
+		{
+			query: "select series_b | availability(3) from 0 to 120000",
+			expected: map[string]float64{
+				api.TagSet{"dc": "west", "measure": "fraction"}.Serialize():          1,
+				api.TagSet{"dc": "west", "measure": "longest_violation"}.Serialize(): 0,
+				api.TagSet{"dc": "east", "measure": "fraction"}.Serialize():          1.0 / 3,
+				api.TagSet{"dc": "east", "measure": "longest_violation"}.Serialize(): 60,
+				api.TagSet{"dc": "miss", "measure": "fraction"}.Serialize():          n,
+				api.TagSet{"dc": "miss", "measure": "longest_violation"}.Serialize(): 0,
+			},
+		},
+		{
+			query: "select series_a | availability(2, '<') from 0 to 120000",
+			expected: map[string]float64{
+				api.TagSet{"app": "web", "dc": "west", "measure": "fraction"}.Serialize():           0.2,
+				api.TagSet{"app": "web", "dc": "west", "measure": "longest_violation"}.Serialize():  120,
+				api.TagSet{"app": "web", "dc": "east", "measure": "fraction"}.Serialize():           0.8,
+				api.TagSet{"app": "web", "dc": "east", "measure": "longest_violation"}.Serialize():  30,
+				api.TagSet{"app": "fun", "dc": "north", "measure": "fraction"}.Serialize():          0,
+				api.TagSet{"app": "fun", "dc": "north", "measure": "longest_violation"}.Serialize(): 150,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -120,6 +120,17 @@ func TestCommand_Select(t *testing.T) {
 				TagSet: api.TagSet{"dc": "west"},
 			}},
 		}}},
+		// The series of an earlier period overlays the current one.
+		{"select series_1, transform.timeshift(series_1, -60ms) from 60 to 120 resolution 30ms", false, []api.SeriesList{
+			{Series: []api.Timeseries{{
+				Values: []float64{3, 4, 5},
+				TagSet: api.TagSet{"dc": "west"},
+			}}},
+			{Series: []api.Timeseries{{
+				Values: []float64{1, 2, 3},
+				TagSet: api.TagSet{"dc": "west"},
+			}}},
+		}},
 		{"select series_3 from 0 to 120 resolution 30ms", false, []api.SeriesList{{
 			Series: []api.Timeseries{
 				{
@@ -312,6 +323,25 @@ func TestCommand_Select(t *testing.T) {
 				},
 			},
 		}}},
+		{"select series_1 / (4 - 2) from 0 to 120 resolution 30ms", false, []api.SeriesList{{
+			Series: []api.Timeseries{{
+				Values: []float64{0.5, 1, 1.5, 2, 2.5},
+				TagSet: api.TagSet{"dc": "west"},
+			}},
+		}}},
+		{"select transform.moving_average(series_1, 2 * 30ms) from 30 to 120 resolution 30ms", false, []api.SeriesList{{
+			Series: []api.Timeseries{{
+				Values: []float64{1.5, 2.5, 3.5, 4.5},
+				TagSet: api.TagSet{"dc": "west"},
+			}},
+		}}},
+		{"select transform.timeshift(series_1, 90ms - 1m / 2000) from 0 to 60 resolution 30ms", false, []api.SeriesList{{
+			Series: []api.Timeseries{{
+				Values: []float64{3, 4, 5},
+				TagSet: api.TagSet{"dc": "west"},
+			}},
+		}}},
+		{"select transform.moving_average(series_1, 30ms * 30ms) from 0 to 120 resolution 30ms", true, []api.SeriesList{}},
 		{"select series_1 from -1000d to now resolution 30ms", true, []api.SeriesList{}},
 	} {
 		a := assert.New(t).Contextf("query=%s", test.query)
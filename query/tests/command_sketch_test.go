@@ -0,0 +1,83 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_SketchPercentile(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 30, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	// One host serves many fast requests and the other a few slow ones, so
+	// the mean of their medians is far from the median of every request.
+	sketched := func(host string, value float64, count int) api.Timeseries {
+		series := api.Timeseries{
+			Values:   []float64{value, value},
+			TagSet:   api.TagSet{"metric": "latency", "host": host},
+			Sketches: []*tdigest.Digest{tdigest.New(0), nil},
+		}
+		for i := 0; i < count; i++ {
+			series.Sketches[0].Add(value)
+		}
+		series.Sketches[0].Compress()
+		return series
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		sketched("a", 10, 90),
+		sketched("b", 1000, 10),
+		api.Timeseries{Values: []float64{1, 2}, TagSet: api.TagSet{"metric": "plain", "host": "a"}},
+	)
+	execute := func(query string) (command.Result, error) {
+		testCommand, err := parser.Parse("select " + query + " from 0 to 30 resolution 30ms")
+		if err != nil {
+			t.Fatalf("Error parsing %s: %s", query, err.Error())
+		}
+		return testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Ctx:                  context.Background(),
+		})
+	}
+	a := assert.New(t)
+	result, err := execute("sketch.percentile(latency, 50), sketch.percentile(latency, 95), sketch.percentile(latency, 50 group by host)")
+	a.CheckError(err)
+	body := result.Body.([]command.QueryResult)
+	a.EqFloatArray(body[0].Series[0].Values, []float64{10, nan}, 1e-9)
+	a.EqFloatArray(body[1].Series[0].Values, []float64{1000, nan}, 1e-9)
+	a.EqInt(len(body[2].Series), 2)
+	a.Eq(result.Metadata["notes"], []string(nil))
+
+	result, err = execute("sketch.percentile(plain, 50)")
+	a.CheckError(err)
+	a.Eq(result.Metadata["notes"], []string{"sketch.percentile: 1 of 1 series have no t-digests, since the storage doesn't keep them or a function computed their values"})
+
+	_, err = execute("sketch.percentile(latency, 101)")
+	if _, ok := err.(function.ArgumentError); !ok {
+		a.Errorf("expected an ArgumentError, but got %v", err)
+	}
+}
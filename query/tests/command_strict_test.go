@@ -0,0 +1,78 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_Strict(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	nan := math.NaN()
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5}, TagSet: api.TagSet{"metric": "series_1", "host": "a"}},
+		api.Timeseries{Values: []float64{nan, nan, nan, nan, nan}, TagSet: api.TagSet{"metric": "series_2", "host": "b"}},
+	)
+	for _, test := range []struct {
+		query string
+		err   string // the error in strict mode; if empty, the query succeeds in both modes
+	}{
+		{query: "select series_1 from 0 to 120 resolution 30ms"},
+		{query: "select aggregate.sum(series_1 group by host) from 0 to 120 resolution 30ms"},
+		{query: "select aggregate.sum(series_1 group by hostt) from 0 to 120 resolution 30ms", err: `none of the 1 series has the tag "hostt"`},
+		{query: "select series_1[host = 'z'] from 0 to 120 resolution 30ms", err: `series_1[host = "z"] matches no series`},
+		{query: "select aggregate.sum(filter.highest_max(series_1, 0)) from 0 to 120 resolution 30ms", err: "aggregate.sum: argument 1 (filter.highest_max(series_1, 0)) has no series"},
+		{query: "select series_2 from 0 to 120 resolution 30ms", err: "series_2 has a series with no values"},
+	} {
+		for _, strict := range []bool{false, true} {
+			a := assert.New(t).Contextf("%s (strict=%t)", test.query, strict)
+			testCommand, err := parser.Parse(test.query)
+			a.CheckError(err)
+			_, err = testCommand.Execute(command.ExecutionContext{
+				TimeseriesStorageAPI: comboAPI,
+				MetricMetadataAPI:    comboAPI,
+				FetchLimit:           1000,
+				Timeout:              100 * time.Millisecond,
+				Ctx:                  context.Background(),
+				Strict:               strict,
+			})
+			if !strict || test.err == "" {
+				a.CheckError(err)
+				continue
+			}
+			if _, ok := err.(function.StrictError); !ok {
+				t.Errorf("%s: expected a strict mode error containing %q, got %v", test.query, test.err, err)
+				continue
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected an error containing %q, got %q", test.query, test.err, err.Error())
+			}
+		}
+	}
+}
@@ -51,6 +51,13 @@ func TestCommand_Describe(t *testing.T) {
 		{"describe series_0 where env = 'production' and doesnotexist = '' or dc = 'west'", fakeAPI, map[string][]string{"dc": {"west"}, "env": {"production", "staging"}, "host": {"a", "b"}}},
 		{"describe series_0 where (dc='west' or env = 'production') and doesnotexist = ''", fakeAPI, map[string][]string{}},
 		{"describe series_0 where(dc='west' or env = 'production')and`doesnotexist` = ''", fakeAPI, map[string][]string{}},
+		{"describe series_0 where host != 'a'", fakeAPI, map[string][]string{"dc": {"east", "west"}, "env": {"production", "staging"}, "host": {"b", "c", "d"}}},
+		{"describe series_0 where not (dc = 'east' or env = 'staging')", fakeAPI, map[string][]string{"dc": {"west"}, "env": {"production"}, "host": {"a"}}},
+		{"describe series_0 where doesnotexist != 'x' and not host in ('a', 'b')", fakeAPI, map[string][]string{"dc": {"east"}, "env": {"production", "staging"}, "host": {"c", "d"}}},
+		{"describe series_0 where env in glob 'prod*'", fakeAPI, map[string][]string{"dc": {"east", "west"}, "env": {"production"}, "host": {"a", "c"}}},
+		{"describe series_0 where env in glob ('st?ging', '*tion') and dc in glob '*st'", fakeAPI, map[string][]string{"dc": {"east", "west"}, "env": {"production", "staging"}, "host": {"a", "b", "c", "d"}}},
+		{"describe series_0 where env in glob 'prod'", fakeAPI, map[string][]string{}},
+		{"describe series_0 where host match '^[ab]$'", fakeAPI, map[string][]string{"dc": {"west"}, "env": {"production", "staging"}, "host": {"a", "b"}}},
 	} {
 		a := assert.New(t).Contextf("query=%s", test.query)
 		testCommand, err := parser.Parse(test.query)
@@ -118,3 +125,196 @@ func TestCommand_DescribeAll(t *testing.T) {
 		a.Eq(rawResult.Body, test.expected)
 	}
 }
+
+func TestCommand_DescribeAllFuzzy(t *testing.T) {
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	for _, metric := range []api.MetricKey{"cpu.user", "cpu.user.total", "CPU_System", "memory.used", "cpu.usr"} {
+		fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: metric, TagSet: api.TagSet{}})
+	}
+
+	for _, test := range []struct {
+		query    string
+		mode     string
+		expected []command.ScoredMetric
+		err      bool
+	}{
+		{"describe all match 'Cpu User'", command.FuzzyMode, []command.ScoredMetric{
+			{Metric: "cpu.user", Score: 1},
+			{Metric: "cpu.user.total", Score: 0.5 + 0.4*7/12},
+			{Metric: "cpu.usr", Score: 0.5 * (1 - 1.0/7)},
+		}, false},
+		{"describe all match 'cpu-system'", command.FuzzyMode, []command.ScoredMetric{
+			{Metric: "CPU_System", Score: 1},
+		}, false},
+		{"describe all match 'xyz'", command.FuzzyMode, []command.ScoredMetric{}, false},
+		// The match text needn't be a regular expression in fuzzy mode.
+		{"describe all match 'cpu('", command.FuzzyMode, []command.ScoredMetric{
+			{Metric: "cpu.usr", Score: 0.7},
+			{Metric: "cpu.user", Score: 0.6714285714},
+			{Metric: "CPU_System", Score: 0.6333333333},
+			{Metric: "cpu.user.total", Score: 0.6},
+		}, false},
+		{"describe all match 'cpu('", "", nil, true},
+		{"describe all", "approximate", nil, true},
+	} {
+		a := assert.New(t).Contextf("query=%s", test.query)
+		testCommand, err := parser.Parse(test.query)
+		a.CheckError(err)
+		rawResult, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: mocks.FakeTimeseriesStorageAPI{},
+			MetricMetadataAPI:    fakeAPI,
+			FetchLimit:           1000,
+			DescribeMode:         test.mode,
+			Ctx:                  context.Background(),
+		})
+		if test.err {
+			if err == nil {
+				a.Errorf("expected an error for mode %q", test.mode)
+			}
+			continue
+		}
+		a.CheckError(err)
+		ranked := rawResult.Body.([]command.ScoredMetric)
+		a.EqInt(len(ranked), len(test.expected))
+		for i := range ranked {
+			if i >= len(test.expected) {
+				break
+			}
+			a.EqString(string(ranked[i].Metric), string(test.expected[i].Metric))
+			a.EqFloat(ranked[i].Score, test.expected[i].Score, 1e-9)
+		}
+	}
+}
+
+func TestCommand_DescribeKeys(t *testing.T) {
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "series_0", TagSet: api.TagSet{"dc": "west", "host": "a"}})
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "series_0", TagSet: api.TagSet{"dc": "west", "host": "b"}})
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "series_0", TagSet: api.TagSet{"dc": "east", "host": "c", "rack": "1"}})
+
+	for _, test := range []struct {
+		constraint predicate.Predicate
+		expected   []metadata.TagKey
+	}{
+		{nil, []metadata.TagKey{{Key: "dc", Cardinality: 2}, {Key: "host", Cardinality: 3}, {Key: "rack", Cardinality: 1}}},
+		{predicate.ListMatcher{Tag: "dc", Values: []string{"west"}}, []metadata.TagKey{{Key: "dc", Cardinality: 1}, {Key: "host", Cardinality: 2}}},
+	} {
+		a := assert.New(t).Contextf("constraint=%v", test.constraint)
+		testCommand, err := parser.Parse("describe keys series_0")
+		a.CheckError(err)
+		a.EqString(testCommand.Name(), "describe keys")
+		rawResult, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI:  mocks.FakeTimeseriesStorageAPI{},
+			MetricMetadataAPI:     fakeAPI,
+			FetchLimit:            1000,
+			Ctx:                   context.Background(),
+			AdditionalConstraints: test.constraint,
+		})
+		a.CheckError(err)
+		a.Eq(rawResult.Body, test.expected)
+		a.EqInt(rawResult.Metadata["count"].(int), len(test.expected))
+	}
+}
+
+func TestCommand_DescribeValues(t *testing.T) {
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"dc": "west", "host": "a"}})
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"dc": "west", "host": "b"}})
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "cpu", TagSet: api.TagSet{"dc": "east", "host": "c"}})
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "memory", TagSet: api.TagSet{"dc": "west", "host": "b"}})
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "memory", TagSet: api.TagSet{"dc": "east", "host": "c"}})
+	fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "memory", TagSet: api.TagSet{"dc": "east", "host": "d"}})
+
+	for _, test := range []struct {
+		query      string
+		constraint predicate.Predicate
+		expected   []string
+	}{
+		{"describe values host where metrics in (cpu, memory)", nil, []string{"b", "c"}},
+		{"describe values host where all metrics in (cpu, memory)", nil, []string{"b", "c"}},
+		{"describe values host where any metrics in (cpu, memory)", nil, []string{"a", "b", "c", "d"}},
+		{"describe values rack where any metrics in (cpu, memory)", nil, []string{}},
+		{"describe values dc where metrics in (cpu)", nil, []string{"east", "west"}},
+		{"describe values host where any metrics in (cpu, memory)", predicate.ListMatcher{Tag: "dc", Values: []string{"east"}}, []string{"c", "d"}},
+	} {
+		a := assert.New(t).Contextf("query=%s", test.query)
+		testCommand, err := parser.Parse(test.query)
+		a.CheckError(err)
+		a.EqString(testCommand.Name(), "describe values")
+		rawResult, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI:  mocks.FakeTimeseriesStorageAPI{},
+			MetricMetadataAPI:     fakeAPI,
+			FetchLimit:            1000,
+			Ctx:                   context.Background(),
+			AdditionalConstraints: test.constraint,
+		})
+		a.CheckError(err)
+		a.Eq(rawResult.Body, test.expected)
+	}
+
+	// Metrics which don't exist are errors, as they are for describe.
+	testCommand, err := parser.Parse("describe values host where any metrics in (cpu, disk)")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_, err = testCommand.Execute(command.ExecutionContext{
+		TimeseriesStorageAPI: mocks.FakeTimeseriesStorageAPI{},
+		MetricMetadataAPI:    fakeAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+	})
+	if err == nil {
+		t.Errorf("expected an error for a metric which does not exist")
+	}
+}
+
+func TestCommand_DescribeCollation(t *testing.T) {
+	fakeAPI := mocks.NewFakeMetricMetadataAPI()
+	for _, version := range []string{"1.10.0", "1.9.2", "1.10.0-rc1", "2.0.0"} {
+		fakeAPI.AddPairWithoutGraphite(api.TaggedMetric{MetricKey: "build", TagSet: api.TagSet{"version": version}})
+	}
+
+	for _, test := range []struct {
+		collation string
+		expected  []string
+	}{
+		{"", []string{"1.9.2", "1.10.0", "1.10.0-rc1", "2.0.0"}},
+		{"natural", []string{"1.9.2", "1.10.0", "1.10.0-rc1", "2.0.0"}},
+		{"lexical", []string{"1.10.0", "1.10.0-rc1", "1.9.2", "2.0.0"}},
+		{"version", []string{"1.9.2", "1.10.0-rc1", "1.10.0", "2.0.0"}},
+	} {
+		a := assert.New(t).Contextf("collation=%s", test.collation)
+		for _, query := range []string{"describe build", "describe values version where metrics in (build)"} {
+			testCommand, err := parser.Parse(query)
+			a.CheckError(err)
+			rawResult, err := testCommand.Execute(command.ExecutionContext{
+				TimeseriesStorageAPI: mocks.FakeTimeseriesStorageAPI{},
+				MetricMetadataAPI:    fakeAPI,
+				FetchLimit:           1000,
+				Ctx:                  context.Background(),
+				Collation:            test.collation,
+			})
+			a.CheckError(err)
+			if values, ok := rawResult.Body.(map[string][]string); ok {
+				a.Eq(values["version"], test.expected)
+			} else {
+				a.Eq(rawResult.Body, test.expected)
+			}
+		}
+	}
+
+	testCommand, err := parser.Parse("describe build")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_, err = testCommand.Execute(command.ExecutionContext{
+		TimeseriesStorageAPI: mocks.FakeTimeseriesStorageAPI{},
+		MetricMetadataAPI:    fakeAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+		Collation:            "klingon",
+	})
+	if err == nil {
+		t.Errorf("expected an error for an unknown collation")
+	}
+}
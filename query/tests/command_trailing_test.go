@@ -0,0 +1,90 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_TrailingBucket(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 120, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5}, TagSet: api.TagSet{"metric": "series_1", "host": "a"}},
+	)
+	for _, test := range []struct {
+		mode    string
+		now     int64 // milliseconds
+		values  int
+		action  string
+		partial float64
+	}{
+		{mode: "", now: 130, values: 5},
+		{mode: "keep", now: 130, values: 5},
+		{mode: "flag", now: 130, values: 5, action: "flagged", partial: 1.0 / 3},
+		{mode: "trim", now: 130, values: 4, action: "trimmed", partial: 1.0 / 3},
+		{mode: "trim", now: 150, values: 5}, // the last bucket is complete
+		{mode: "trim", now: 100, values: 5}, // the last bucket hasn't started
+	} {
+		a := assert.New(t).Contextf("mode %q at %d", test.mode, test.now)
+		testCommand, err := parser.Parse("select series_1 from 0 to 120 resolution 30ms")
+		a.CheckError(err)
+		now := time.Unix(0, test.now*1e6)
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           1000,
+			Timeout:              100 * time.Millisecond,
+			Ctx:                  context.Background(),
+			TrailingBucket:       test.mode,
+			Now:                  func() time.Time { return now },
+		})
+		a.CheckError(err)
+		series := result.Body.([]command.QueryResult)[0].Series
+		a.EqInt(len(series[0].Values), test.values)
+		bucket, ok := result.Metadata["trailing_bucket"].(command.TrailingBucket)
+		a.EqBool(ok, test.action != "")
+		if ok {
+			a.EqString(bucket.Action, test.action)
+			a.EqFloat(bucket.Partial, test.partial, 1e-9)
+			a.EqInt(len(result.Metadata["notes"].([]string)), 1)
+		}
+	}
+
+	testCommand, err := parser.Parse("select series_1 from 0 to 120 resolution 30ms")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_, err = testCommand.Execute(command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Ctx:                  context.Background(),
+		TrailingBucket:       "drop",
+	})
+	if err == nil {
+		t.Errorf("expected an error for an unknown trailing bucket mode")
+	}
+}
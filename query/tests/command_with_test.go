@@ -0,0 +1,97 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+func TestCommand_With(t *testing.T) {
+	timerange, err := api.NewSnappedTimerange(0, 60, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	comboAPI := mocks.NewComboAPI(timerange,
+		api.Timeseries{Values: []float64{1, 2, 3}, TagSet: api.TagSet{"metric": "errors", "host": "a"}},
+		api.Timeseries{Values: []float64{4, 5, 6}, TagSet: api.TagSet{"metric": "errors", "host": "b"}},
+		api.Timeseries{Values: []float64{10, 10, 10}, TagSet: api.TagSet{"metric": "requests", "host": "a"}},
+	)
+	for _, test := range []struct {
+		query    string
+		names    []string
+		expected [][]float64
+	}{
+		{
+			"with e = errors[host = 'a'] select e, e + e, e / requests",
+			[]string{`errors[host = "a"]`, `(errors[host = "a"] + errors[host = "a"])`, `(errors[host = "a"] / requests)`},
+			[][]float64{{1, 2, 3}, {2, 4, 6}, {0.1, 0.2, 0.3}},
+		},
+		{
+			// Later bindings may refer to earlier ones.
+			"with e = errors[host = 'a'], double = e * 2 select double - e",
+			[]string{`((errors[host = "a"] * 2) - errors[host = "a"])`},
+			[][]float64{{1, 2, 3}},
+		},
+	} {
+		a := assert.New(t).Contextf("%s", test.query)
+		testCommand, err := parser.Parse(test.query + " from 0 to 60 resolution 30ms")
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		// Each bound expression is fetched once, however often it's referred to,
+		// so that the fetch limit of 2 series suffices.
+		result, err := testCommand.Execute(command.ExecutionContext{
+			TimeseriesStorageAPI: comboAPI,
+			MetricMetadataAPI:    comboAPI,
+			FetchLimit:           2,
+			Ctx:                  context.Background(),
+		})
+		a.CheckError(err)
+		if err != nil {
+			continue
+		}
+		results := result.Body.([]command.QueryResult)
+		a.EqInt(len(results), len(test.names))
+		for i := range results {
+			if i >= len(test.names) {
+				break
+			}
+			a.EqString(results[i].Name, test.names[i])
+			a.EqInt(len(results[i].Series), 1)
+			if len(results[i].Series) == 1 {
+				a.EqFloatArray(results[i].Series[0].Values, test.expected[i], 1e-9)
+			}
+		}
+	}
+}
+
+func TestCommand_WithPredicate(t *testing.T) {
+	a := assert.New(t)
+	// A bound name can't be given a predicate, rather than silently referring
+	// to the metric of the same name.
+	_, err := parser.Parse("with requests = errors[host = 'b'] select requests[host = 'a'] from 0 to 60")
+	if err == nil {
+		t.Fatalf("expected a bound name with a predicate to be rejected")
+	}
+	a.EqString(err.Error(), "requests is bound in the with clause, so it can't be given a predicate")
+}
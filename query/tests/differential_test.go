@@ -0,0 +1,195 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Differential tests evaluate the same query along different paths through
+// the evaluator and check that the results agree.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+// differentialQueries are expressions whose value at each point only depends
+// on the raw data at that point (or on data held constant between points), so
+// they must agree across resolutions and evaluation paths.
+var differentialQueries = []string{
+	"series_2",
+	"series_2 + 1",
+	"series_2 * series_2 - series_2",
+	"(0 - series_2) / 4",
+	"aggregate.sum(series_2)",
+	"aggregate.max(series_3 group by dc)",
+	"series_3 | aggregate.mean(collapse by dc)",
+	"transform.abs(series_3 - 3)",
+	"series_3 | filter.highest_max(1)",
+	"series_3 | filter.lowest_mean(2)",
+	"series_2 + series_3",
+}
+
+// differentialData is the raw data behind both storage APIs.
+var differentialData = []struct {
+	metric string
+	dc     string
+	values []float64
+}{
+	{"series_2", "west", []float64{1, 2, 3, 4, 5}},
+	{"series_2", "east", []float64{3, 0, 3, 6, 2}},
+	{"series_3", "west", []float64{1, 1, 1, 4, 4}},
+	{"series_3", "east", []float64{5, 5, 5, 2, 2}},
+	{"series_3", "north", []float64{3, 3, 3, 3, 3}},
+}
+
+// differentialAPI builds a storage API at the given resolution from the
+// differential data. Each raw point is held until the next one, so finer
+// resolutions contain the coarse data at every `factor`-th slot.
+func differentialAPI(t *testing.T, factor int) mocks.FakeComboAPI {
+	resolution := int64(60 / factor)
+	timerange, err := api.NewSnappedTimerange(0, 240, resolution)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	series := []api.Timeseries{}
+	for _, data := range differentialData {
+		values := make([]float64, timerange.Slots())
+		for i := range values {
+			values[i] = data.values[i/factor]
+		}
+		series = append(series, api.Timeseries{Values: values, TagSet: api.TagSet{"metric": data.metric, "dc": data.dc}})
+	}
+	return mocks.NewComboAPI(timerange, series...)
+}
+
+// runDifferential executes the given select query and returns its results.
+func runDifferential(a assert.Assert, comboAPI mocks.FakeComboAPI, query string) []command.QueryResult {
+	return runWithStream(a, comboAPI, query, nil)
+}
+
+// runWithStream executes the given select query, streaming its results to
+// stream if it's not nil, and returns its results.
+func runWithStream(a assert.Assert, comboAPI mocks.FakeComboAPI, query string, stream func(command.QueryResult) error) []command.QueryResult {
+	testCommand, err := parser.Parse(query)
+	if err != nil {
+		a.Errorf("Unexpected error while parsing: %s", err.Error())
+		return nil
+	}
+	rawResult, err := testCommand.Execute(command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Timeout:              100 * time.Millisecond,
+		Stream:               stream,
+		Ctx:                  context.Background(),
+	})
+	if err != nil {
+		a.Errorf("Unexpected error while executing: %s", err.Error())
+		return nil
+	}
+	return rawResult.Body.([]command.QueryResult)
+}
+
+// checkSameSeries checks that the two results contain the same series, up to
+// ordering, after taking every `stride`-th value of the fine series.
+func checkSameSeries(a assert.Assert, coarse, fine []api.Timeseries, stride int) {
+	if len(coarse) != len(fine) {
+		a.Errorf("series count differs: %d vs %d", len(coarse), len(fine))
+		return
+	}
+	for _, coarseSeries := range coarse {
+		found := false
+		for _, fineSeries := range fine {
+			if !coarseSeries.TagSet.Equals(fineSeries.TagSet) {
+				continue
+			}
+			found = true
+			sampled := []float64{}
+			for i := 0; i < len(fineSeries.Values); i += stride {
+				sampled = append(sampled, fineSeries.Values[i])
+			}
+			a.Contextf("tagset=%+v", coarseSeries.TagSet).EqFloatArray(sampled, coarseSeries.Values, 1e-9)
+		}
+		if !found {
+			a.Errorf("series with tagset %+v is missing", coarseSeries.TagSet)
+		}
+	}
+}
+
+func TestDifferential_Resolution(t *testing.T) {
+	coarseAPI := differentialAPI(t, 1)
+	for _, factor := range []int{2, 3, 4} {
+		fineAPI := differentialAPI(t, factor)
+		for _, query := range differentialQueries {
+			a := assert.New(t).Contextf("query=%s factor=%d", query, factor)
+			coarse := runDifferential(a, coarseAPI, fmt.Sprintf("select %s from 0 to 240 resolution 60ms", query))
+			fine := runDifferential(a, fineAPI, fmt.Sprintf("select %s from 0 to 240 resolution %dms", query, 60/factor))
+			if len(coarse) != 1 || len(fine) != 1 {
+				a.Errorf("expected exactly one result for each resolution")
+				continue
+			}
+			checkSameSeries(a, coarse[0].Series, fine[0].Series, factor)
+		}
+	}
+}
+
+func TestDifferential_MultipleExpressions(t *testing.T) {
+	comboAPI := differentialAPI(t, 1)
+	a := assert.New(t)
+	together := runDifferential(a, comboAPI, fmt.Sprintf("select %s from 0 to 240 resolution 60ms", strings.Join(differentialQueries, ", ")))
+	if len(together) != len(differentialQueries) {
+		t.Fatalf("expected %d results but got %d", len(differentialQueries), len(together))
+	}
+	for i, query := range differentialQueries {
+		a := a.Contextf("query=%s", query)
+		alone := runDifferential(a, comboAPI, fmt.Sprintf("select %s from 0 to 240 resolution 60ms", query))
+		if len(alone) != 1 {
+			a.Errorf("expected exactly one result")
+			continue
+		}
+		checkSameSeries(a, alone[0].Series, together[i].Series, 1)
+	}
+}
+
+func TestDifferential_Streaming(t *testing.T) {
+	comboAPI := differentialAPI(t, 1)
+	a := assert.New(t)
+	query := fmt.Sprintf("select %s from 0 to 240 resolution 60ms", strings.Join(differentialQueries, ", "))
+	buffered := runDifferential(a, comboAPI, query)
+	streamed := []command.QueryResult{}
+	body := runWithStream(a, comboAPI, query, func(result command.QueryResult) error {
+		streamed = append(streamed, result)
+		return nil
+	})
+	if len(buffered) != len(differentialQueries) || len(streamed) != len(buffered) || len(body) != len(buffered) {
+		t.Fatalf("expected %d results but got %d buffered, %d streamed and %d in the streamed body", len(differentialQueries), len(buffered), len(streamed), len(body))
+	}
+	for i, query := range differentialQueries {
+		a := a.Contextf("query=%s", query)
+		// The results are streamed in order, and agree with those of both bodies.
+		a.EqString(streamed[i].Query, buffered[i].Query)
+		a.EqString(streamed[i].Type, buffered[i].Type)
+		a.Eq(streamed[i].Timerange, buffered[i].Timerange)
+		checkSameSeries(a, buffered[i].Series, streamed[i].Series, 1)
+		checkSameSeries(a, body[i].Series, streamed[i].Series, 1)
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Golden tests run every registered function over a shared set of fixture
+// series and compare the results against files in testdata/golden.
+//
+// After changing or adding a function, regenerate the expected output with
+//
+//     go test ./query/tests -run TestGolden -update
+//
+// and review the diff of testdata/golden before committing it.
+
+package tests
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function/registry"
+	"github.com/square/metrics/query/command"
+	"github.com/square/metrics/query/parser"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+var nan = math.NaN()
+
+// goldenInputs are substituted for `$input` in each golden case. Together
+// they cover ordinary data, missing data, a single series and an empty list.
+var goldenInputs = []string{
+	"golden_basic",
+	"golden_nan",
+	"golden_single",
+	"golden_basic[dc = 'nowhere']",
+}
+
+// goldenCases lists the expressions checked for each registered function.
+var goldenCases = []struct {
+	function    string
+	expressions []string
+}{
+	{"+", []string{"$input + 1", "$input + $input"}},
+	{"-", []string{"$input - 2", "$input - $input"}},
+	{"*", []string{"$input * 3", "$input * $input"}},
+	{"/", []string{"$input / 0", "$input / $input", "$input / on (env) aggregate.sum($input group by env)"}},
+	{">", []string{"$input > 3", "$input > golden_single"}},
+	{"<", []string{"$input < 3", "$input < golden_single"}},
+	{">=", []string{"$input >= 3", "$input >= golden_single"}},
+	{"<=", []string{"$input <= 3", "$input <= golden_single"}},
+	{"==", []string{"$input == 3", "$input == $input"}},
+	{"!=", []string{"$input != 3", "$input != $input"}},
+	{"and", []string{"$input > 2 and $input < 5", "$input and golden_nan"}},
+	{"or", []string{"$input < 2 or $input > 5", "$input - $input or golden_nan"}},
+	{"unless", []string{"$input > 2 unless $input > 5", "$input unless golden_single > 4"}},
+	{"aggregate.count", []string{"aggregate.count($input)", "aggregate.count($input group by env)"}},
+	{"aggregate.max", []string{"aggregate.max($input)", "aggregate.max($input group by env)"}},
+	{"aggregate.mean", []string{"aggregate.mean($input)", "aggregate.mean($input collapse by dc)"}},
+	{"aggregate.min", []string{"aggregate.min($input)", "aggregate.min($input group by env)"}},
+	{"aggregate.sum", []string{"aggregate.sum($input)", "aggregate.sum($input group by env)"}},
+	{"aggregate.total", []string{"aggregate.total($input)", "aggregate.total($input group by env)"}},
+	{"histogram_quantile", []string{"histogram_quantile(0.5, $input)", "histogram_quantile(0.9, golden_histogram)", "histogram_quantile(0.5, golden_histogram group by dc)"}},
+	{"sketch.percentile", []string{"sketch.percentile($input, 50)", "sketch.percentile(golden_sketch, 90 group by dc)"}},
+	{"aggregate.weighted_mean", []string{"aggregate.weighted_mean($input, golden_basic)", "aggregate.weighted_mean($input, golden_single group by env)"}},
+	{"availability", []string{"availability($input, 3)", "availability($input, 3, '<')"}},
+	{"coalesce", []string{"coalesce($input, golden_basic)", "coalesce(golden_nan, $input)"}},
+	{"freshness", []string{"freshness($input)", "freshness(golden_nan)"}},
+	{"distribution", []string{"distribution($input, 4)", "distribution($input, 2, 0, 4)"}},
+	{"filter.highest_max", []string{"filter.highest_max($input, 2)", "filter.highest_max($input, 1, 60ms)"}},
+	{"filter.highest_mean", []string{"filter.highest_mean($input, 2)", "filter.highest_mean($input, 1, 60ms)"}},
+	{"filter.highest_min", []string{"filter.highest_min($input, 2)", "filter.highest_min($input, 1, 60ms)"}},
+	{"filter.lowest_max", []string{"filter.lowest_max($input, 2)", "filter.lowest_max($input, 1, 60ms)"}},
+	{"filter.lowest_mean", []string{"filter.lowest_mean($input, 2)", "filter.lowest_mean($input, 1, 60ms)"}},
+	{"filter.lowest_min", []string{"filter.lowest_min($input, 2)", "filter.lowest_min($input, 1, 60ms)"}},
+	{"filter.max_above", []string{"filter.max_above($input, 5)", "filter.max_above($input, 5, 60ms)"}},
+	{"filter.max_below", []string{"filter.max_below($input, 5)", "filter.max_below($input, 5, 60ms)"}},
+	{"filter.mean_above", []string{"filter.mean_above($input, 3)", "filter.mean_above($input, 3, 60ms)"}},
+	{"filter.mean_below", []string{"filter.mean_below($input, 3)", "filter.mean_below($input, 3, 60ms)"}},
+	{"filter.min_above", []string{"filter.min_above($input, 1)", "filter.min_above($input, 1, 60ms)"}},
+	{"filter.min_below", []string{"filter.min_below($input, 1)", "filter.min_below($input, 1, 60ms)"}},
+	{"find.first_above", []string{"find.first_above($input, 4)", "find.first_above($input, 100)"}},
+	{"find.last_below", []string{"find.last_below($input, 4)", "find.last_below($input, -100)"}},
+	{"forecast.anomaly_rolling_multiplicative_holt_winters", []string{"forecast.anomaly_rolling_multiplicative_holt_winters($input, 90ms, 0.5, 0.5, 0.5)"}},
+	{"forecast.anomaly_rolling_seasonal", []string{"forecast.anomaly_rolling_seasonal($input, 90ms, 0.5)"}},
+	{"forecast.deviation_band", []string{"forecast.deviation_band($input, 90ms, 2, 30ms)"}},
+	{"forecast.drop", []string{"forecast.drop($input, 150ms)"}},
+	{"forecast.holt_winters", []string{"forecast.holt_winters($input, 90ms, 30ms)"}},
+	{"forecast.linear", []string{"forecast.linear($input)", "forecast.linear($input, 150ms)"}},
+	{"forecast.rolling_multiplicative_holt_winters", []string{"forecast.rolling_multiplicative_holt_winters($input, 90ms, 0.5, 0.5, 0.5)"}},
+	{"forecast.rolling_seasonal", []string{"forecast.rolling_seasonal($input, 90ms, 0.5)"}},
+	{"if", []string{"if($input - 4, $input, 0)", "if(golden_nan, golden_single, $input)"}},
+	{"mask.business_hours", []string{"mask.business_hours($input, 'Mon-Fri 00:00-01:00')", "mask.business_hours($input, 'Thu 00:00-01:00', 'America/New_York')"}},
+	{"mask.exclude", []string{"mask.exclude($input, 'Thu')", "mask.exclude($input, 'Sat,Sun; 09:00-17:00')"}},
+	{"ratio", []string{"ratio($input, golden_single)", "ratio($input, aggregate.sum($input group by env))"}},
+	{"residual", []string{"residual($input, golden_single)", "residual($input, aggregate.mean($input))"}},
+	{"samples", []string{"samples($input)", "samples($input + 1)"}},
+	{"summarize.count", []string{"summarize.count($input)", "summarize.count($input, 60ms)"}},
+	{"summarize.current", []string{"summarize.current($input)"}},
+	{"summarize.first_not_nan", []string{"summarize.first_not_nan($input)", "summarize.first_not_nan($input, 60ms)"}},
+	{"summarize.integral", []string{"summarize.integral($input)", "summarize.integral($input, 60ms)"}},
+	{"summarize.last_not_nan", []string{"summarize.last_not_nan($input)", "summarize.last_not_nan($input, 60ms)"}},
+	{"summarize.max", []string{"summarize.max($input)", "summarize.max($input, 60ms)"}},
+	{"summarize.mean", []string{"summarize.mean($input)", "summarize.mean($input, 60ms)"}},
+	{"summarize.min", []string{"summarize.min($input)", "summarize.min($input, 60ms)"}},
+	{"summarize.oldest", []string{"summarize.oldest($input)"}},
+	{"summarize.total", []string{"summarize.total($input)", "summarize.total($input, 60ms)"}},
+	{"summarize_table", []string{"summarize_table($input, 'mean', 'p50', 'p99', 'max', 'count')", "summarize_table($input, 'current')"}},
+	{"tag.copy", []string{"tag.copy($input, 'copied', 'dc')"}},
+	{"tag.drop", []string{"tag.drop($input, 'dc')"}},
+	{"tag.set", []string{"tag.set($input, 'dc', 'moon')"}},
+	{"transform.abs", []string{"transform.abs($input - 4)"}},
+	{"transform.bottomk", []string{"transform.bottomk($input, 1)", "transform.bottomk($input, 2, 'current')"}},
+	{"transform.bound", []string{"transform.bound($input, 2, 5)"}},
+	{"transform.clamp", []string{"transform.clamp($input, 2, 5)", "transform.clamp($input, 5, 2)"}},
+	{"transform.cumulative", []string{"transform.cumulative($input)"}},
+	{"transform.derivative", []string{"transform.derivative($input)"}},
+	{"transform.exp", []string{"transform.exp($input)", "transform.exp($input * 1000)"}},
+	{"transform.exponential_moving_average", []string{"transform.exponential_moving_average($input, 90ms)"}},
+	{"transform.integral", []string{"transform.integral($input)"}},
+	{"transform.log", []string{"transform.log($input)", "transform.log($input, 2)", "transform.log($input, 1)"}},
+	{"transform.lower_bound", []string{"transform.lower_bound($input, 2)"}},
+	{"transform.moving_average", []string{"transform.moving_average($input, 90ms)"}},
+	{"transform.moving_max", []string{"transform.moving_max($input, 90ms)", "transform.moving_max($input, 0ms)"}},
+	{"transform.moving_min", []string{"transform.moving_min($input, 90ms)"}},
+	{"transform.nan_fill", []string{"transform.nan_fill($input, -1)"}},
+	{"transform.nan_keep_last", []string{"transform.nan_keep_last($input)"}},
+	{"transform.fill", []string{"transform.fill($input, 0)"}},
+	{"transform.fill_forward", []string{"transform.fill_forward($input)"}},
+	{"transform.interpolate", []string{"transform.interpolate($input)"}},
+	{"transform.rate", []string{"transform.rate($input)", "transform.rate($input, 60ms)"}},
+	{"transform.topk", []string{"transform.topk($input, 1)", "transform.topk($input, 2, 'max')", "transform.topk($input, 0, 'sum')"}},
+	{"transform.timeshift", []string{"transform.timeshift($input, 60ms)", "transform.timeshift($input, -60ms)"}},
+	{"transform.upper_bound", []string{"transform.upper_bound($input, 5)"}},
+}
+
+// goldenOperatorNames gives file names to the operators, which cannot be used
+// in file names directly.
+var goldenOperatorNames = map[string]string{
+	"+":  "operator_add",
+	"-":  "operator_subtract",
+	"*":  "operator_multiply",
+	"/":  "operator_divide",
+	">":  "operator_greater",
+	"<":  "operator_less",
+	">=": "operator_greater_or_equal",
+	"<=": "operator_less_or_equal",
+	"==": "operator_equal",
+	"!=": "operator_not_equal",
+}
+
+func goldenAPI(t *testing.T) mocks.FakeComboAPI {
+	timerange, err := api.NewSnappedTimerange(0, 300, 30)
+	if err != nil {
+		t.Fatalf("Error creating timerange for test: %s", err.Error())
+	}
+	return mocks.NewComboAPI(
+		timerange,
+		api.Timeseries{Values: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, TagSet: api.TagSet{"metric": "golden_basic", "dc": "west", "env": "production"}},
+		api.Timeseries{Values: []float64{3, 0, 3, 6, 2, 8, 1, 0, 4, 4, 2}, TagSet: api.TagSet{"metric": "golden_basic", "dc": "east", "env": "production"}},
+		api.Timeseries{Values: []float64{5, 5, 5, 2, 2, 2, 9, 9, 9, -3, -3}, TagSet: api.TagSet{"metric": "golden_basic", "dc": "north", "env": "staging"}},
+		api.Timeseries{Values: []float64{nan, 1, nan, 3, 4, nan, nan, 7, 8, nan, 10}, TagSet: api.TagSet{"metric": "golden_nan", "dc": "west", "env": "production"}},
+		api.Timeseries{Values: []float64{2, 2, 2, nan, nan, nan, nan, nan, 6, 6, 6}, TagSet: api.TagSet{"metric": "golden_nan", "dc": "east", "env": "production"}},
+		api.Timeseries{Values: []float64{nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan}, TagSet: api.TagSet{"metric": "golden_nan", "dc": "north", "env": "staging"}},
+		api.Timeseries{Values: []float64{4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4}, TagSet: api.TagSet{"metric": "golden_single", "dc": "west", "env": "production"}, Samples: []int{1, 1, 2, 2, 0, 3, 3, 3, 1, 1, 1}},
+		api.Timeseries{Values: []float64{0, 1, 2, 4, 4, 4, 8, 0, 0, 1, 2}, TagSet: api.TagSet{"metric": "golden_histogram", "dc": "west", "le": "0.1"}},
+		api.Timeseries{Values: []float64{2, 2, 4, 8, 8, 6, 10, 0, 1, 1, 4}, TagSet: api.TagSet{"metric": "golden_histogram", "dc": "west", "le": "1"}},
+		api.Timeseries{Values: []float64{4, 5, 6, 8, 9, 12, 12, 0, 2, 1, 8}, TagSet: api.TagSet{"metric": "golden_histogram", "dc": "west", "le": "+Inf"}},
+		api.Timeseries{Values: []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, nan}, TagSet: api.TagSet{"metric": "golden_histogram", "dc": "east", "le": "1"}},
+		api.Timeseries{Values: []float64{3, 3, 3, 3, 3, 3, 3, 3, 3, 3, nan}, TagSet: api.TagSet{"metric": "golden_histogram", "dc": "east", "le": "+Inf"}},
+		goldenSketched(api.TagSet{"metric": "golden_sketch", "dc": "west", "env": "production"}, func(i int) []float64 {
+			return []float64{float64(i + 1), float64(i + 2), float64(i + 3), float64(10 * (i + 1))}
+		}),
+		goldenSketched(api.TagSet{"metric": "golden_sketch", "dc": "east", "env": "production"}, func(i int) []float64 {
+			if i == 4 {
+				return nil
+			}
+			return []float64{float64(2 * (i + 1)), 100}
+		}),
+	)
+}
+
+// goldenSketched makes a series of 11 points from a t-digest of the raw
+// values of each point, whose value is their mean. A point without raw
+// values is NaN, and has no digest.
+func goldenSketched(tagSet api.TagSet, raw func(int) []float64) api.Timeseries {
+	series := api.Timeseries{
+		Values:   make([]float64, 11),
+		TagSet:   tagSet,
+		Samples:  make([]int, 11),
+		Sketches: make([]*tdigest.Digest, 11),
+	}
+	for i := range series.Values {
+		values := raw(i)
+		if len(values) == 0 {
+			series.Values[i] = nan
+			continue
+		}
+		sketch := tdigest.New(0)
+		for _, value := range values {
+			sketch.Add(value)
+		}
+		sketch.Compress()
+		series.Values[i] = sketch.Mean()
+		series.Samples[i] = len(values)
+		series.Sketches[i] = sketch
+	}
+	return series
+}
+
+// formatGoldenFloat renders a value with enough precision to catch real
+// changes while staying stable across platforms.
+func formatGoldenFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', 10, 64)
+}
+
+// renderGolden evaluates the expression and renders its result as text.
+// Lines within a result are sorted so that the output is deterministic.
+func renderGolden(comboAPI mocks.FakeComboAPI, expression string) string {
+	query := fmt.Sprintf("select %s from 0 to 300 resolution 30ms", expression)
+	testCommand, err := parser.Parse(query)
+	if err != nil {
+		return fmt.Sprintf("parse error: %s\n", err.Error())
+	}
+	rawResult, err := testCommand.Execute(command.ExecutionContext{
+		TimeseriesStorageAPI: comboAPI,
+		MetricMetadataAPI:    comboAPI,
+		FetchLimit:           1000,
+		Timeout:              100 * time.Millisecond,
+		Ctx:                  context.Background(),
+	})
+	if err != nil {
+		return fmt.Sprintf("error: %s\n", err.Error())
+	}
+	lines := []string{}
+	for _, result := range rawResult.Body.([]command.QueryResult) {
+		for _, series := range result.Series {
+			values := make([]string, len(series.Values))
+			for i, value := range series.Values {
+				values[i] = formatGoldenFloat(value)
+			}
+			lines = append(lines, fmt.Sprintf("series {%s} [%s]", series.TagSet.Serialize(), strings.Join(values, " ")))
+		}
+		for _, scalar := range result.Scalars {
+			lines = append(lines, fmt.Sprintf("scalar {%s} %s", scalar.TagSet.Serialize(), formatGoldenFloat(scalar.Value)))
+		}
+		if result.Table != nil {
+			for _, row := range result.Table.Rows {
+				cells := make([]string, len(row.Values))
+				for i, value := range row.Values {
+					cells[i] = fmt.Sprintf("%s=%s", result.Table.Columns[i], formatGoldenFloat(value))
+				}
+				lines = append(lines, fmt.Sprintf("row {%s} %s", row.TagSet.Serialize(), strings.Join(cells, " ")))
+			}
+		}
+		if result.Distribution != nil {
+			for i, counts := range result.Distribution.Counts {
+				cells := make([]string, len(counts))
+				for t, count := range counts {
+					cells[t] = strconv.Itoa(count)
+				}
+				lines = append(lines, fmt.Sprintf("bucket %02d [%s, %s) [%s]", i, formatGoldenFloat(result.Distribution.Bounds[i]), formatGoldenFloat(result.Distribution.Bounds[i+1]), strings.Join(cells, " ")))
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return "empty\n"
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func goldenFileName(function string) string {
+	if name, ok := goldenOperatorNames[function]; ok {
+		return filepath.Join("testdata", "golden", name+".golden")
+	}
+	return filepath.Join("testdata", "golden", function+".golden")
+}
+
+func TestGolden(t *testing.T) {
+	comboAPI := goldenAPI(t)
+	for _, test := range goldenCases {
+		a := assert.New(t).Contextf("function=%s", test.function)
+		actual := ""
+		for _, expression := range test.expressions {
+			for _, input := range goldenInputs {
+				instance := strings.Replace(expression, "$input", input, -1)
+				actual += fmt.Sprintf("== %s\n%s\n", instance, renderGolden(comboAPI, instance))
+			}
+		}
+		filename := goldenFileName(test.function)
+		if *updateGolden {
+			if err := ioutil.WriteFile(filename, []byte(actual), 0644); err != nil {
+				t.Fatalf("cannot write golden file %s: %s", filename, err.Error())
+			}
+			continue
+		}
+		expected, err := ioutil.ReadFile(filename)
+		if err != nil {
+			a.Errorf("cannot read golden file %s (run with -update to create it): %s", filename, err.Error())
+			continue
+		}
+		a.EqString(actual, string(expected))
+	}
+}
+
+// TestGolden_Coverage checks that every registered function has golden cases.
+func TestGolden_Coverage(t *testing.T) {
+	covered := map[string]bool{}
+	for _, test := range goldenCases {
+		covered[test.function] = true
+	}
+	for _, name := range registry.Default().All() {
+		if !covered[name] {
+			t.Errorf("function %s has no golden test cases; add it to goldenCases", name)
+		}
+	}
+}
@@ -46,6 +46,27 @@ var inputs = []string{
 	"describe cpu_usage where key in ('value', 'value')",
 	"describe cpu_usage where key match 'abc'",
 	"describe nodes.cpu.usage where datacenter='sjc1b' and type='idle' and host match 'fwd'",
+	"describe connections where peer in cidr '10.0.0.0/8'",
+	"describe connections where peer in cidr ('10.0.0.0/8', 'fd00::/8') and not peer in cidr '10.1.0.0/16'",
+	"describe connections where cidr in ('a')",
+	"describe cpu_usage where dc in glob 'us-*'",
+	"describe cpu_usage where dc in glob('us-*', 'eu-?') and not host match 'web-[0-9]+'",
+	"describe cpu_usage where glob in ('a')",
+	// describe keys
+	"describe keys cpu_usage",
+	"describe keys `keys`",
+	// describe values
+	"describe values host where metrics in (cpu.user)",
+	"describe values host where all metrics in (cpu.user, `memory.used`)",
+	"describe values `host` where any metrics in (cpu.user,memory.used)",
+	// forecasts
+	"forecast disk.used_percent reach 95 from -30d to now",
+	"forecast disk.used_percent, disk.free reach -1.5e2 where host = 'a' from 0 to 0",
+	"forecast.linear(x, 1h) from 0 to 0", // a select of a function in the forecast namespace
+	// explains
+	"explain select cpu.user | transform.moving_average(1h) from -1d to now",
+	"explain cpu.user where host = 'a' from 0 to 0",
+	"select explain.requests from 0 to 0", // a metric which begins with the keyword
 	// predicate parenthesis test
 	"describe cpu_usage where key = 'value' and (key = 'value')",
 	"describe cpu_usage where (key = 'value') and key = 'value'",
@@ -74,6 +95,15 @@ var inputs = []string{
 	"describe/* inline */all",
 	"describe/**/all",
 	"select/**/(/**/f/**/(/**/g/**/(/**/5/**/)group/**/by/**/`a`/**/,/**/w/**/,/**/q/**/)/**/)/**/from/**/0/**/to/**/0",
+	// with clauses
+	"with x = cpu[host = 'a'] select x + x, x | transform.rate from 0 to 0",
+	"with x = a, y = transform.rate(x) select y / x from 0 to 0",
+	"with `x-y` = a select `x-y` from 0 to 0",
+	"explain with x = a select x from 0 to 0",
+	// "with" is only a keyword before a binding.
+	"select with from 0 to 0",
+	"with + 1 from 0 to 0",
+	"with == 1 from 0 to 0",
 }
 
 var selects = []string{
@@ -117,6 +147,15 @@ var selects = []string{
 	"x from 0 to 0 resolution '17m'",
 	"x from 0 to 0 sample by 'max'",
 	"x from 0 to 0 sample   by 'max'",
+	"x from 0 to 0 order by max",
+	"x from 0 to 0 order by mean desc limit 10",
+	"x from 0 to 0 limit 3 order by current asc",
+	"x from 0 to 0 fill 0",
+	"x from 0 to 0 fill -1.5 limit 3",
+	"x from 0 to 0 fill forward",
+	"x from 0 to 0 fill interpolate order by max",
+	"x from 0 to 0 sample 10%",
+	"x from 0 to 0 sample by 'max' sample 0.5 %",
 	// selects - aggregate functions
 	"scalar.max(x) from 0 to 0",
 	"aggregate.max(x, y) from 0 to 0",
@@ -151,6 +190,21 @@ var selects = []string{
 	"x|f(1s,2,3y) + y|g(4mo) from 0 to 0",
 	"x|f(1s,'r3r2',3y) + y|g(4mo) from 0 to 0",
 	"1 + 2 | f from 0 to 0",
+	// comparisons and logical operators
+	"x > 1 from 0 to 0",
+	"x>=y from 0 to 0",
+	"x + 1 <= y * 2 from 0 to 0",
+	"x == 0 or y != 0 from 0 to 0",
+	"x > 90 and y > 80 unless z from 0 to 0",
+	"(x > 1) > 0 from 0 to 0",
+	"x / on (host) y from 0 to 0",
+	"x > ignoring(a, b) 1 and y from 0 to 0",
+	"x + on from 0 to 0",
+	// pipeline expressions
+	"x |> y from 0 to 0",
+	"x |> y() |> z(group by a) |> w(5) from 0 to 0",
+	"x|>f(1, 'a') + y |> g from 0 to 0",
+	"x | y |> z from 0 to 0",
 }
 
 // these queries should fail with a syntax error.
@@ -162,8 +216,14 @@ var syntaxErrorQuery = []string{
 	" ",
 	"// comment only",
 	// invalid regex
-	"describe all match 'ab['",
 	"describe invalid_regex where key match 'ab['",
+	// invalid CIDR
+	"describe invalid_cidr where peer in cidr '10.0.0.0/33'",
+	"describe invalid_cidr where peer in cidr ('10.0.0.0/8', 'east')",
+	"describe invalid_cidr where peer in cidr",
+	// invalid glob
+	"describe invalid_glob where dc in glob",
+	"describe invalid_glob where dc in glob 7",
 	// invalid syntax
 	"describe (",
 	"describe ( from 0 to 0",
@@ -171,6 +231,16 @@ var syntaxErrorQuery = []string{
 	"describe in from 0 to 0",
 	"describe invalid_property \nwhere key match 'ab' from 0 to 0",
 	"describe all matches 'abc'", // matches is not a keyword.
+	"describe keys",
+	"describe keys cpu_usage where key = 'value'",
+	"describe values host where metrics in ()",
+	"describe values host where metrics in (cpu.user",
+	"describe values host metrics in (cpu.user)",
+	"describe values host where each metrics in (cpu.user)",
+	"forecast x from 0 to 0",
+	"forecast x reach from 0 to 0",
+	"explain describe x",
+	"explain x",
 	"select 'a\nac\nabc",
 	"select ( from 0 to 0",
 	"select ) from 0 to 0",
@@ -185,10 +255,38 @@ var syntaxErrorQuery = []string{
 	"select x from 0 to 1 to 0",
 	"select x from 0 resolution '30s' resolution '25s' to 0",
 	"select x from 0 from 1 sample by 'min' sample by 'min' to 0",
+	"select x from 0 to 0 order max",
+	"select x from 0 to 0 order by median",
+	"select x from 0 to 0 order by max order by min",
+	"select x from 0 to 0 limit 0",
+	"select x from 0 to 0 limit 2 limit 3",
+	"select x from 0 to 0 limit 'ten'",
+	"select x from 0 to 0 fill",
+	"select x from 0 to 0 fill backward",
+	"select x from 0 to 0 fill 0 fill forward",
+	"with x = a x from 0 to 0",
+	"with x = select x from 0 to 0",
+	"with x = a, select x from 0 to 0",
+	"with x = a, x = b select x from 0 to 0",
+	"select x from 0 to 0 sample 10",
+	"select x from 0 to 0 sample 0%",
+	"select x from 0 to 0 sample 150%",
+	"select x from 0 to 0 sample 10% sample 20%",
 	"select f(3 groupby x) from 0 to 0",
 	"select c group by a from 0 to 0",
 	"select x[] from 0 to 0",
 	"select cpu | transform.moving_average(10qq) from 0 to 0",
+	"select cpu |> from 0 to 0",
+	"select x > from 0 to 0",
+	"select x > 1 > 2 from 0 to 0",
+	"select x and from 0 to 0",
+	"select x unless from 0 to 0",
+	"select unless from 0 to 0",
+	"select x / on (host y from 0 to 0",
+	"select x / on () y from 0 to 0",
+	"select x / ignoring (a,) y from 0 to 0",
+	"select cpu |> transform.rate( from 0 to 0",
+	"select cpu | > transform.rate from 0 to 0",
 }
 
 func TestParse_success(t *testing.T) {
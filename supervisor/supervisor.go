@@ -0,0 +1,235 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package supervisor runs the long-lived background components of the server
+// (such as cache refreshers and the indexer). A component that fails or
+// panics is restarted after a delay which doubles with each consecutive
+// failure, and every component is stopped together on shutdown.
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/log"
+)
+
+// Component is a background task. It should run until the context is done,
+// and then return. Returning earlier (or panicking) is treated as a failure.
+type Component func(ctx context.Context) error
+
+// Config paces the restarts of failed components.
+type Config struct {
+	InitialBackoffSeconds int `yaml:"initial_backoff_seconds"` // the delay before restarting a component after its first failure; 1 if zero
+	MaxBackoffSeconds     int `yaml:"max_backoff_seconds"`     // the longest delay between restarts; 300 if zero
+}
+
+// The states of a component.
+const (
+	StateRunning = "running"
+	StateBackoff = "backoff" // waiting to be restarted after a failure
+	StateStopped = "stopped"
+)
+
+// Status describes a component.
+type Status struct {
+	Name        string    `json:"name"`
+	State       string    `json:"state"`
+	Started     time.Time `json:"started"`  // when the component was last (re)started
+	Restarts    int       `json:"restarts"` // the number of times the component has been restarted
+	LastError   string    `json:"last_error,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+	NextRestart time.Time `json:"next_restart,omitempty"` // while in backoff
+}
+
+type component struct {
+	run    Component
+	status Status
+}
+
+// Supervisor starts, monitors and restarts components.
+type Supervisor struct {
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	mutex      sync.Mutex
+	ctx        context.Context // nil until started
+	cancel     context.CancelFunc
+	started    time.Time
+	components map[string]*component
+	waitgroup  sync.WaitGroup
+}
+
+// New creates a supervisor; call Start to run the components added to it.
+func New(config Config) *Supervisor {
+	if config.InitialBackoffSeconds <= 0 {
+		config.InitialBackoffSeconds = 1
+	}
+	if config.MaxBackoffSeconds <= 0 {
+		config.MaxBackoffSeconds = 300
+	}
+	return &Supervisor{
+		initialBackoff: time.Duration(config.InitialBackoffSeconds) * time.Second,
+		maxBackoff:     time.Duration(config.MaxBackoffSeconds) * time.Second,
+		components:     map[string]*component{},
+	}
+}
+
+// Add registers a component under a unique name. If the supervisor has
+// already started, so does the component.
+func (s *Supervisor) Add(name string, run Component) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.components[name]; ok {
+		return fmt.Errorf("the component %q has already been added", name)
+	}
+	c := &component{run: run, status: Status{Name: name, State: StateStopped}}
+	s.components[name] = c
+	if s.ctx != nil && s.ctx.Err() == nil {
+		s.launch(c)
+	}
+	return nil
+}
+
+// Start runs every component until the context is done or Stop is called.
+func (s *Supervisor) Start(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.ctx != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.started = time.Now()
+	for _, c := range s.components {
+		s.launch(c)
+	}
+}
+
+// Started returns the time at which the supervisor was started.
+func (s *Supervisor) Started() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.started
+}
+
+// Stop cancels the components and waits for them to return. If some are
+// still running once the context is done, it returns an error naming them.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.mutex.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mutex.Unlock()
+	done := make(chan struct{})
+	go func() {
+		s.waitgroup.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+	running := []string{}
+	for _, status := range s.Status() {
+		if status.State != StateStopped {
+			running = append(running, status.Name)
+		}
+	}
+	return fmt.Errorf("components did not stop in time: %s", strings.Join(running, ", "))
+}
+
+// Status describes every component, ordered by name.
+func (s *Supervisor) Status() []Status {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := make([]Status, 0, len(s.components))
+	for _, c := range s.components {
+		result = append(result, c.status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// launch starts the goroutine supervising the component. The mutex must be held.
+func (s *Supervisor) launch(c *component) {
+	s.waitgroup.Add(1)
+	go s.supervise(s.ctx, c)
+}
+
+// supervise runs the component until the context is done, restarting it
+// when it fails. The backoff is reset once the component has run for at
+// least as long as the longest backoff.
+func (s *Supervisor) supervise(ctx context.Context, c *component) {
+	defer s.waitgroup.Done()
+	backoff := s.initialBackoff
+	for {
+		started := time.Now()
+		s.update(c, func(status *Status) {
+			status.State = StateRunning
+			status.Started = started
+			status.NextRestart = time.Time{}
+		})
+		err := runSafely(ctx, c.run)
+		if ctx.Err() != nil {
+			s.update(c, func(status *Status) { status.State = StateStopped })
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("returned before shutdown")
+		}
+		if time.Since(started) >= s.maxBackoff {
+			backoff = s.initialBackoff
+		}
+		log.Errorf("Background component %s failed (restarting in %s): %s", c.status.Name, backoff, err.Error())
+		now := time.Now()
+		s.update(c, func(status *Status) {
+			status.State = StateBackoff
+			status.LastError = err.Error()
+			status.LastFailure = now
+			status.NextRestart = now.Add(backoff)
+		})
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			s.update(c, func(status *Status) { status.State = StateStopped })
+			return
+		}
+		s.update(c, func(status *Status) { status.Restarts++ })
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+func (s *Supervisor) update(c *component, change func(*Status)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	change(&c.status)
+}
+
+// runSafely runs the component, converting a panic into an error.
+func runSafely(ctx context.Context, run Component) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return run(ctx)
+}
@@ -0,0 +1,116 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestSupervisor() *Supervisor {
+	s := New(Config{})
+	s.initialBackoff = time.Millisecond
+	s.maxBackoff = 4 * time.Millisecond
+	return s
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSupervisorRestartsFailures(t *testing.T) {
+	s := newTestSupervisor()
+	var runs int32
+	s.Add("failing", func(ctx context.Context) error {
+		if atomic.AddInt32(&runs, 1) == 2 {
+			panic("second run")
+		}
+		return errors.New("broken")
+	})
+	s.Start(context.Background())
+	waitFor(t, func() bool { return atomic.LoadInt32(&runs) >= 4 })
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("unexpected error stopping: %s", err.Error())
+	}
+	status := s.Status()[0]
+	if status.Name != "failing" || status.State != StateStopped {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.Restarts < 3 {
+		t.Errorf("expected at least 3 restarts, but got %d", status.Restarts)
+	}
+	if status.LastError != "broken" && status.LastError != "panic: second run" {
+		t.Errorf("unexpected last error %q", status.LastError)
+	}
+}
+
+func TestSupervisorStops(t *testing.T) {
+	s := newTestSupervisor()
+	blocking := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	s.Add("b", blocking)
+	s.Start(context.Background())
+	s.Add("a", blocking) // added after starting
+	if err := s.Add("a", blocking); err == nil {
+		t.Errorf("expected an error adding a duplicate component")
+	}
+	waitFor(t, func() bool {
+		for _, status := range s.Status() {
+			if status.State != StateRunning {
+				return false
+			}
+		}
+		return true
+	})
+	if names := []string{s.Status()[0].Name, s.Status()[1].Name}; names[0] != "a" || names[1] != "b" {
+		t.Errorf("expected the components in order, but got %v", names)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("unexpected error stopping: %s", err.Error())
+	}
+	for _, status := range s.Status() {
+		if status.State != StateStopped || status.Restarts != 0 {
+			t.Errorf("unexpected status %+v", status)
+		}
+	}
+}
+
+func TestSupervisorStopTimeout(t *testing.T) {
+	s := newTestSupervisor()
+	release := make(chan struct{})
+	defer close(release)
+	s.Add("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	s.Start(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := s.Stop(ctx)
+	if err == nil || err.Error() != "components did not stop in time: stuck" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCancelled is the error of a query cancelled through RunningQueries.
+var ErrCancelled = errors.New("the query was cancelled")
+
+// RunningQueries assigns an ID to each query being executed, so that they
+// can be listed and cancelled.
+type RunningQueries struct {
+	mutex   sync.Mutex
+	next    int
+	queries map[string]*runningQuery
+}
+
+type runningQuery struct {
+	label     Label
+	started   time.Time
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// NewRunningQueries creates an empty RunningQueries.
+func NewRunningQueries() *RunningQueries {
+	return &RunningQueries{queries: map[string]*runningQuery{}}
+}
+
+// RunningQuery describes a query being executed. Its Seconds are the time
+// since it began, including any wait to be scheduled.
+type RunningQuery struct {
+	ID string `json:"id"`
+	Label
+	Seconds   float64 `json:"seconds"`
+	Cancelled bool    `json:"cancelled,omitempty"` // it was cancelled, but hasn't stopped yet
+}
+
+// Start assigns an ID to a query, which runs with the returned context
+// until it calls done. A nil RunningQueries only passes the context along.
+func (r *RunningQueries) Start(parent context.Context, label Label) (context.Context, string, func()) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	if r == nil {
+		return parent, "", func() {}
+	}
+	ctx, cancel := context.WithCancel(parent)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.next++
+	id := strconv.Itoa(r.next)
+	r.queries[id] = &runningQuery{label: label, started: time.Now(), cancel: cancel}
+	return ctx, id, func() {
+		r.mutex.Lock()
+		delete(r.queries, id)
+		r.mutex.Unlock()
+		cancel()
+	}
+}
+
+// Cancel cancels the context of the query with the given ID, returning
+// false if no such query is running.
+func (r *RunningQueries) Cancel(id string) bool {
+	if r == nil {
+		return false
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	query, ok := r.queries[id]
+	if !ok {
+		return false
+	}
+	query.cancelled = true
+	query.cancel()
+	return true
+}
+
+// Cancelled reports whether the query with the given ID was cancelled,
+// rather than stopping for some other reason.
+func (r *RunningQueries) Cancelled(id string) bool {
+	if r == nil {
+		return false
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	query, ok := r.queries[id]
+	return ok && query.cancelled
+}
+
+// List describes the running queries, longest running first.
+func (r *RunningQueries) List() []RunningQuery {
+	list := []RunningQuery{}
+	if r == nil {
+		return list
+	}
+	now := time.Now()
+	r.mutex.Lock()
+	for id, query := range r.queries {
+		list = append(list, RunningQuery{
+			ID:        id,
+			Label:     query.label,
+			Seconds:   now.Sub(query.started).Seconds(),
+			Cancelled: query.cancelled,
+		})
+	}
+	r.mutex.Unlock()
+	sort.Sort(longestRunning(list))
+	return list
+}
+
+type longestRunning []RunningQuery
+
+func (l longestRunning) Len() int           { return len(l) }
+func (l longestRunning) Less(i, j int) bool { return l[i].Seconds > l[j].Seconds }
+func (l longestRunning) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
@@ -0,0 +1,57 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestRunningQueries(t *testing.T) {
+	a := assert.New(t)
+	running := NewRunningQueries()
+	first, firstID, firstDone := running.Start(context.Background(), Label{Query: "select a from 0 to 1"})
+	_, secondID, secondDone := running.Start(nil, Label{Query: "select b from 0 to 1", Client: "reports"})
+	a.Eq(firstID != secondID, true)
+
+	list := running.List()
+	a.EqInt(len(list), 2)
+	clients := map[string]string{}
+	for _, query := range list {
+		clients[query.ID] = query.Client
+	}
+	a.Eq(clients, map[string]string{firstID: "", secondID: "reports"})
+
+	a.Eq(running.Cancel(firstID), true)
+	a.Eq(first.Err(), context.Canceled)
+	a.Eq(running.Cancelled(firstID), true)
+	a.Eq(running.Cancelled(secondID), false)
+	a.Eq(running.List()[0].Cancelled, true)
+
+	firstDone()
+	secondDone()
+	a.EqInt(len(running.List()), 0)
+	a.Eq(running.Cancel(firstID), false)
+	a.Eq(running.Cancelled(firstID), false)
+
+	var none *RunningQueries
+	ctx, id, done := none.Start(nil, Label{})
+	done()
+	a.EqString(id, "")
+	a.Eq(ctx.Err(), nil)
+	a.EqInt(len(none.List()), 0)
+}
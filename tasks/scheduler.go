@@ -0,0 +1,352 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Priority orders the classes of queries sharing a Scheduler.
+type Priority int
+
+const (
+	// Batch queries (such as report generation) run when nothing else is waiting.
+	Batch Priority = iota
+	// Alerting queries are evaluated ahead of batch work.
+	Alerting
+	// Interactive queries (from dashboards and the UI) come first.
+	Interactive
+
+	priorityCount = iota
+)
+
+var priorityNames = map[Priority]string{
+	Batch:       "batch",
+	Alerting:    "alerts",
+	Interactive: "interactive",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// ParsePriority converts the name of a priority class to its Priority.
+func ParsePriority(name string) (Priority, error) {
+	for priority, priorityName := range priorityNames {
+		if priorityName == name {
+			return priority, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority class %q; expected one of interactive, alerts or batch", name)
+}
+
+// maxPreemptions bounds how often a single query can be preempted, so that
+// batch work still finishes under sustained high-priority load.
+const maxPreemptions = 3
+
+// waitWindow is the number of recent admissions of each class whose waits
+// are summarized by Snapshot.
+const waitWindow = 100
+
+// Label describes a query to those inspecting the scheduler. It doesn't
+// affect how the query is scheduled.
+type Label struct {
+	Query  string `json:"query"`
+	Client string `json:"client,omitempty"` // the name of the client profile
+	Owner  string `json:"owner,omitempty"`  // from the query's @owner directive
+}
+
+type job struct {
+	priority    Priority
+	label       Label
+	queued      time.Time // when it last joined the queue
+	started     time.Time // when it was last admitted
+	admitted    chan struct{}
+	cancel      context.CancelFunc
+	preempted   bool
+	preemptions int
+}
+
+// Scheduler limits the number of queries running at once, admitting
+// waiting queries in order of priority. With preemption enabled, a query
+// arriving when every slot is taken cancels a running query of lower
+// priority; the cancelled query is queued again and rerun from the start.
+type Scheduler struct {
+	mutex   sync.Mutex
+	slots   int
+	preempt bool
+	running map[*job]struct{}
+	waiting [priorityCount][]*job
+	waits   [priorityCount][]time.Duration // those of the most recent admissions, oldest first
+}
+
+// NewScheduler creates a Scheduler running at most the given number of
+// queries at once.
+func NewScheduler(slots int, preempt bool) *Scheduler {
+	if slots < 1 {
+		slots = 1
+	}
+	return &Scheduler{
+		slots:   slots,
+		preempt: preempt,
+		running: map[*job]struct{}{},
+	}
+}
+
+// SchedulerStatus describes the load on a Scheduler.
+type SchedulerStatus struct {
+	Running int            `json:"running"`
+	Waiting map[string]int `json:"waiting"` // by priority class
+}
+
+// Status reports the number of running and waiting queries.
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	status := SchedulerStatus{Running: len(s.running), Waiting: map[string]int{}}
+	for priority, queue := range s.waiting {
+		status.Waiting[Priority(priority).String()] = len(queue)
+	}
+	return status
+}
+
+// SchedulerSnapshot describes each query held by a Scheduler.
+type SchedulerSnapshot struct {
+	Slots   int                         `json:"slots"`
+	Preempt bool                        `json:"preempt"`
+	Running []ScheduledQuery            `json:"running"`
+	Waiting map[string][]ScheduledQuery `json:"waiting"` // by priority class, in the order they'll be admitted
+	Waits   map[string]WaitSummary      `json:"waits"`   // by priority class
+}
+
+// ScheduledQuery is a query which is running or waiting to run. Its Seconds
+// are the time since it was admitted or since it joined the queue.
+type ScheduledQuery struct {
+	Label
+	Priority    string  `json:"priority"`
+	Seconds     float64 `json:"seconds"`
+	Preemptions int     `json:"preemptions,omitempty"`
+}
+
+// WaitSummary describes how long the recently admitted queries of a class
+// waited to run.
+type WaitSummary struct {
+	Admitted    int     `json:"admitted"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	MaxSeconds  float64 `json:"max_seconds"`
+}
+
+// Snapshot describes the running and waiting queries, and the waits of those
+// admitted recently.
+func (s *Scheduler) Snapshot() SchedulerSnapshot {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	snapshot := SchedulerSnapshot{
+		Slots:   s.slots,
+		Preempt: s.preempt,
+		Running: []ScheduledQuery{},
+		Waiting: map[string][]ScheduledQuery{},
+		Waits:   map[string]WaitSummary{},
+	}
+	for j := range s.running {
+		snapshot.Running = append(snapshot.Running, j.describe(now.Sub(j.started)))
+	}
+	// The longest-running queries come first.
+	sort.Sort(longestFirst(snapshot.Running))
+	for priority := priorityCount - 1; priority >= 0; priority-- {
+		name := Priority(priority).String()
+		queue := []ScheduledQuery{}
+		for _, j := range s.waiting[priority] {
+			queue = append(queue, j.describe(now.Sub(j.queued)))
+		}
+		snapshot.Waiting[name] = queue
+		summary := WaitSummary{Admitted: len(s.waits[priority])}
+		for _, wait := range s.waits[priority] {
+			summary.MeanSeconds += wait.Seconds()
+			if wait.Seconds() > summary.MaxSeconds {
+				summary.MaxSeconds = wait.Seconds()
+			}
+		}
+		if summary.Admitted > 0 {
+			summary.MeanSeconds /= float64(summary.Admitted)
+		}
+		snapshot.Waits[name] = summary
+	}
+	return snapshot
+}
+
+type longestFirst []ScheduledQuery
+
+func (l longestFirst) Len() int           { return len(l) }
+func (l longestFirst) Less(i, j int) bool { return l[i].Seconds > l[j].Seconds }
+func (l longestFirst) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+func (j *job) describe(elapsed time.Duration) ScheduledQuery {
+	return ScheduledQuery{
+		Label:       j.label,
+		Priority:    j.priority.String(),
+		Seconds:     elapsed.Seconds(),
+		Preemptions: j.preemptions,
+	}
+}
+
+// Run performs the action once the scheduler admits it. The action must stop
+// promptly when its context is cancelled. If it was cancelled because it
+// was preempted, it is queued and performed again; otherwise its result is
+// returned. Run fails without performing the action if ctx is done first.
+func (s *Scheduler) Run(ctx context.Context, priority Priority, action func(context.Context) error) error {
+	return s.RunLabeled(ctx, priority, Label{}, action)
+}
+
+// RunLabeled is Run for a query described by the label in snapshots.
+func (s *Scheduler) RunLabeled(ctx context.Context, priority Priority, label Label, action func(context.Context) error) error {
+	if priority < 0 || priority >= priorityCount {
+		return fmt.Errorf("unknown priority class %s", priority)
+	}
+	j := &job{priority: priority, label: label}
+	for {
+		if err := s.admit(ctx, j); err != nil {
+			return err
+		}
+		actionCtx, cancel := context.WithCancel(ctx)
+		s.mutex.Lock()
+		j.cancel = cancel
+		preempted := j.preempted // it may have been chosen before its cancel existed
+		s.mutex.Unlock()
+		if preempted {
+			cancel()
+		}
+		err := action(actionCtx)
+		cancel()
+		if !s.release(j) || err == nil || ctx.Err() != nil {
+			// A preempted action which finished anyway keeps its result.
+			return err
+		}
+	}
+}
+
+// admit waits until j may run.
+func (s *Scheduler) admit(ctx context.Context, j *job) error {
+	s.mutex.Lock()
+	j.admitted = make(chan struct{})
+	j.preempted = false
+	j.cancel = nil
+	j.queued = time.Now()
+	if j.preemptions > 0 {
+		// A preempted job resumes ahead of others of its class.
+		s.waiting[j.priority] = append([]*job{j}, s.waiting[j.priority]...)
+	} else {
+		s.waiting[j.priority] = append(s.waiting[j.priority], j)
+	}
+	s.dispatch()
+	if _, ok := s.running[j]; !ok && s.preempt {
+		s.preemptFor(j)
+	}
+	s.mutex.Unlock()
+
+	select {
+	case <-j.admitted:
+		return nil
+	case <-ctx.Done():
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if _, ok := s.running[j]; ok {
+			// It was admitted at the same moment; give the slot back.
+			delete(s.running, j)
+			s.dispatch()
+		} else {
+			s.remove(j)
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees the slot of j, reporting whether it must run again.
+func (s *Scheduler) release(j *job) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.running, j)
+	s.dispatch()
+	if j.preempted {
+		j.preemptions++
+		return true
+	}
+	return false
+}
+
+// dispatch admits waiting jobs, highest priority first, while slots are free.
+// The mutex must be held.
+func (s *Scheduler) dispatch() {
+	for priority := priorityCount - 1; priority >= 0; priority-- {
+		for len(s.running) < s.slots && len(s.waiting[priority]) > 0 {
+			next := s.waiting[priority][0]
+			s.waiting[priority] = s.waiting[priority][1:]
+			s.running[next] = struct{}{}
+			next.started = time.Now()
+			s.recordWait(Priority(priority), next.started.Sub(next.queued))
+			close(next.admitted)
+		}
+	}
+}
+
+// recordWait notes how long an admitted job of the class waited, keeping the
+// most recent waitWindow of them. The mutex must be held.
+func (s *Scheduler) recordWait(priority Priority, wait time.Duration) {
+	waits := append(s.waits[priority], wait)
+	if len(waits) > waitWindow {
+		waits = waits[len(waits)-waitWindow:]
+	}
+	s.waits[priority] = waits
+}
+
+// preemptFor cancels the running job of lowest priority below that of j, if
+// there is one which can still be preempted. The mutex must be held.
+func (s *Scheduler) preemptFor(j *job) {
+	var victim *job
+	for candidate := range s.running {
+		if candidate.priority >= j.priority || candidate.preempted || candidate.preemptions >= maxPreemptions {
+			continue
+		}
+		if victim == nil || candidate.priority < victim.priority {
+			victim = candidate
+		}
+	}
+	if victim == nil {
+		return
+	}
+	victim.preempted = true
+	if victim.cancel != nil {
+		victim.cancel()
+	}
+}
+
+// remove takes j out of its queue. The mutex must be held.
+func (s *Scheduler) remove(j *job) {
+	queue := s.waiting[j.priority]
+	for i, waiting := range queue {
+		if waiting == j {
+			s.waiting[j.priority] = append(queue[:i:i], queue[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+// blockingAction runs until released or cancelled, announcing each start.
+func blockingAction(started chan<- struct{}, release <-chan struct{}) func(context.Context) error {
+	return func(ctx context.Context) error {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func waitFor(t *testing.T, channel <-chan struct{}, what string) {
+	select {
+	case <-channel:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestScheduler_Priority(t *testing.T) {
+	a := assert.New(t)
+	scheduler := NewScheduler(1, false)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go scheduler.Run(context.Background(), Batch, blockingAction(started, release))
+	waitFor(t, started, "the first batch query")
+
+	order := make(chan Priority, 2)
+	done := make(chan struct{}, 2)
+	run := func(priority Priority) {
+		scheduler.Run(context.Background(), priority, func(context.Context) error {
+			order <- priority
+			return nil
+		})
+		done <- struct{}{}
+	}
+	go run(Batch)
+	for scheduler.Status().Waiting["batch"] != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	go run(Interactive)
+	for scheduler.Status().Waiting["interactive"] != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	waitFor(t, done, "the queued queries")
+	waitFor(t, done, "the queued queries")
+	a.EqString((<-order).String(), "interactive")
+	a.EqString((<-order).String(), "batch")
+	a.EqInt(scheduler.Status().Running, 0)
+}
+
+func TestScheduler_Preemption(t *testing.T) {
+	a := assert.New(t)
+	scheduler := NewScheduler(1, true)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	batchDone := make(chan error, 1)
+	go func() {
+		batchDone <- scheduler.Run(context.Background(), Batch, blockingAction(started, release))
+	}()
+	waitFor(t, started, "the batch query")
+
+	// The alert preempts the batch query, which starts again afterwards.
+	alertRan := false
+	a.CheckError(scheduler.Run(context.Background(), Alerting, func(context.Context) error {
+		alertRan = true
+		return nil
+	}))
+	a.EqBool(alertRan, true)
+	waitFor(t, started, "the batch query to be rerun")
+	close(release)
+	a.CheckError(<-batchDone)
+}
+
+func TestScheduler_CancelWhileWaiting(t *testing.T) {
+	a := assert.New(t)
+	scheduler := NewScheduler(1, false)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go scheduler.Run(context.Background(), Interactive, blockingAction(started, release))
+	waitFor(t, started, "the first query")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := scheduler.Run(ctx, Batch, func(context.Context) error {
+		t.Errorf("the cancelled query should not run")
+		return nil
+	})
+	a.EqString(err.Error(), context.DeadlineExceeded.Error())
+	a.EqInt(scheduler.Status().Waiting["batch"], 0)
+	close(release)
+}
+
+func TestScheduler_Snapshot(t *testing.T) {
+	a := assert.New(t)
+	scheduler := NewScheduler(1, false)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{}, 2)
+	go func() {
+		scheduler.RunLabeled(context.Background(), Interactive, Label{Query: "select cpu from -1h to now", Client: "grafana"}, blockingAction(started, release))
+		done <- struct{}{}
+	}()
+	waitFor(t, started, "the first query")
+	go func() {
+		scheduler.RunLabeled(context.Background(), Batch, Label{Query: "describe all", Owner: "payments"}, func(context.Context) error { return nil })
+		done <- struct{}{}
+	}()
+	for scheduler.Status().Waiting["batch"] != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	snapshot := scheduler.Snapshot()
+	a.EqInt(snapshot.Slots, 1)
+	a.EqInt(len(snapshot.Running), 1)
+	a.EqString(snapshot.Running[0].Query, "select cpu from -1h to now")
+	a.EqString(snapshot.Running[0].Client, "grafana")
+	a.EqString(snapshot.Running[0].Priority, "interactive")
+	a.EqInt(len(snapshot.Waiting["batch"]), 1)
+	a.EqString(snapshot.Waiting["batch"][0].Owner, "payments")
+	a.EqInt(len(snapshot.Waiting["interactive"]), 0)
+	a.EqInt(snapshot.Waits["interactive"].Admitted, 1)
+	a.EqInt(snapshot.Waits["batch"].Admitted, 0)
+
+	close(release)
+	waitFor(t, done, "the queries")
+	waitFor(t, done, "the queries")
+	snapshot = scheduler.Snapshot()
+	a.EqInt(len(snapshot.Running), 0)
+	a.EqInt(len(snapshot.Waiting["batch"]), 0)
+	a.EqInt(snapshot.Waits["batch"].Admitted, 1)
+	if snapshot.Waits["batch"].MaxSeconds < snapshot.Waits["batch"].MeanSeconds {
+		t.Errorf("the longest wait %f is shorter than the mean %f", snapshot.Waits["batch"].MaxSeconds, snapshot.Waits["batch"].MeanSeconds)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	a := assert.New(t)
+	for _, priority := range []Priority{Batch, Alerting, Interactive} {
+		parsed, err := ParsePriority(priority.String())
+		a.CheckError(err)
+		a.EqInt(int(parsed), int(priority))
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Errorf("expected an error for an unknown priority class")
+	}
+}
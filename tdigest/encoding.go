@@ -0,0 +1,97 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tdigest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The encoding begins with a version byte, so that the format can change.
+const encodingVersion = 1
+
+var errTruncated = errors.New("truncated t-digest")
+
+// Encode compresses the digest, and encodes it for storage: the version,
+// the compression, minimum and maximum, and then the number of centroids
+// followed by the mean and weight of each.
+func (d *Digest) Encode() []byte {
+	centroids := d.Centroids()
+	buffer := make([]byte, 1+3*8+binary.MaxVarintLen64+16*len(centroids))
+	buffer[0] = encodingVersion
+	offset := 1
+	putFloat := func(value float64) {
+		binary.LittleEndian.PutUint64(buffer[offset:], math.Float64bits(value))
+		offset += 8
+	}
+	putFloat(d.compression)
+	putFloat(d.min)
+	putFloat(d.max)
+	offset += binary.PutUvarint(buffer[offset:], uint64(len(centroids)))
+	for _, centroid := range centroids {
+		putFloat(centroid.Mean)
+		putFloat(centroid.Weight)
+	}
+	return buffer[:offset]
+}
+
+// Decode decodes a digest encoded by Encode.
+func Decode(encoded []byte) (*Digest, error) {
+	if len(encoded) == 0 {
+		return nil, errTruncated
+	}
+	if encoded[0] != encodingVersion {
+		return nil, fmt.Errorf("unknown t-digest encoding version %d", encoded[0])
+	}
+	offset := 1
+	getFloat := func() (float64, error) {
+		if len(encoded) < offset+8 {
+			return 0, errTruncated
+		}
+		value := math.Float64frombits(binary.LittleEndian.Uint64(encoded[offset:]))
+		offset += 8
+		return value, nil
+	}
+	header := [3]float64{}
+	for i := range header {
+		value, err := getFloat()
+		if err != nil {
+			return nil, err
+		}
+		header[i] = value
+	}
+	count, n := binary.Uvarint(encoded[offset:])
+	if n <= 0 {
+		return nil, errTruncated
+	}
+	offset += n
+	if uint64(len(encoded)-offset) != 16*count {
+		return nil, fmt.Errorf("t-digest of %d centroids has %d bytes of them", count, len(encoded)-offset)
+	}
+	d := New(header[0])
+	d.min, d.max = header[1], header[2]
+	d.centroids = make([]Centroid, count)
+	for i := range d.centroids {
+		d.centroids[i].Mean, _ = getFloat()
+		d.centroids[i].Weight, _ = getFloat()
+		if !(d.centroids[i].Weight > 0) || (i > 0 && d.centroids[i].Mean < d.centroids[i-1].Mean) {
+			return nil, fmt.Errorf("invalid t-digest centroid %+v", d.centroids[i])
+		}
+		d.count += d.centroids[i].Weight
+	}
+	return d, nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tdigest implements the t-digest, a compact sketch of a set of
+// values which estimates their quantiles. Unlike percentiles computed
+// ahead of time, digests can be merged, so that the percentiles of values
+// from many hosts or over a long time are estimated correctly.
+package tdigest
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultCompression trades the size of a digest for its accuracy; a digest
+// holds at most a few times this many centroids.
+const DefaultCompression = 100
+
+// Centroid summarizes the values near its mean.
+type Centroid struct {
+	Mean   float64
+	Weight float64
+}
+
+// Digest is a t-digest. Values are buffered, and compressed into centroids
+// once enough of them are waiting.
+type Digest struct {
+	compression float64
+	centroids   []Centroid // sorted by mean
+	pending     []Centroid // not yet compressed
+	count       float64    // the total weight, including pending values
+	min         float64
+	max         float64
+}
+
+// New creates an empty digest. A compression which isn't positive is
+// replaced by DefaultCompression.
+func New(compression float64) *Digest {
+	if !(compression > 0) {
+		compression = DefaultCompression
+	}
+	return &Digest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+// Compression returns the compression of the digest.
+func (d *Digest) Compression() float64 {
+	return d.compression
+}
+
+// Count returns the total weight of the values added.
+func (d *Digest) Count() float64 {
+	return d.count
+}
+
+// Add adds a value. NaN values are ignored.
+func (d *Digest) Add(value float64) {
+	d.AddWeighted(value, 1)
+}
+
+// AddWeighted adds a value with the given weight. NaN values and weights
+// which aren't positive are ignored.
+func (d *Digest) AddWeighted(value float64, weight float64) {
+	if math.IsNaN(value) || !(weight > 0) {
+		return
+	}
+	d.pending = append(d.pending, Centroid{Mean: value, Weight: weight})
+	d.count += weight
+	d.min = math.Min(d.min, value)
+	d.max = math.Max(d.max, value)
+	if len(d.pending) > d.pendingLimit() {
+		d.compress()
+	}
+}
+
+// Merge adds the values summarized by the other digest.
+func (d *Digest) Merge(other *Digest) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	d.pending = append(d.pending, other.centroids...)
+	d.pending = append(d.pending, other.pending...)
+	d.count += other.count
+	d.min = math.Min(d.min, other.min)
+	d.max = math.Max(d.max, other.max)
+	if len(d.pending) > d.pendingLimit() {
+		d.compress()
+	}
+}
+
+// Clone returns a copy of the digest, which may be changed independently.
+func (d *Digest) Clone() *Digest {
+	clone := *d
+	clone.centroids = append([]Centroid{}, d.centroids...)
+	clone.pending = append([]Centroid{}, d.pending...)
+	return &clone
+}
+
+// Centroids compresses the digest, and returns its centroids in order.
+func (d *Digest) Centroids() []Centroid {
+	d.compress()
+	return d.centroids
+}
+
+// Compress merges the waiting values into the centroids. A compressed
+// digest isn't changed by reading it, so it can be shared between
+// goroutines.
+func (d *Digest) Compress() {
+	d.compress()
+}
+
+func (d *Digest) pendingLimit() int {
+	return int(5 * d.compression)
+}
+
+// scale is the k1 scale function of the t-digest paper, which keeps
+// centroids small near the extreme quantiles, where accuracy matters most.
+func (d *Digest) scale(q float64) float64 {
+	return d.compression / (2 * math.Pi) * math.Asin(2*math.Min(math.Max(q, 0), 1)-1)
+}
+
+// compress merges the pending values into the centroids.
+func (d *Digest) compress() {
+	if len(d.pending) == 0 {
+		return
+	}
+	all := append(d.pending, d.centroids...)
+	sort.Sort(byMean(all))
+	merged := make([]Centroid, 0, len(d.centroids)+1)
+	current := all[0]
+	cumulative := 0.0
+	for _, next := range all[1:] {
+		left := d.scale(cumulative / d.count)
+		right := d.scale((cumulative + current.Weight + next.Weight) / d.count)
+		if right-left <= 1 {
+			current.Weight += next.Weight
+			current.Mean += (next.Mean - current.Mean) * next.Weight / current.Weight
+			continue
+		}
+		merged = append(merged, current)
+		cumulative += current.Weight
+		current = next
+	}
+	d.centroids = append(merged, current)
+	d.pending = nil
+}
+
+// Quantile estimates the value below which the fraction q of the values
+// lie. It's NaN for an empty digest.
+func (d *Digest) Quantile(q float64) float64 {
+	d.compress()
+	if d.count == 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return d.min
+	}
+	if q >= 1 {
+		return d.max
+	}
+	target := q * d.count
+	// Each centroid's mean is taken to be its value at the middle of its
+	// weight, with the values between interpolated.
+	previousMean, previousPosition := d.min, 0.0
+	cumulative := 0.0
+	for _, centroid := range d.centroids {
+		position := cumulative + centroid.Weight/2
+		if target < position {
+			return interpolate(previousMean, previousPosition, centroid.Mean, position, target)
+		}
+		previousMean, previousPosition = centroid.Mean, position
+		cumulative += centroid.Weight
+	}
+	return interpolate(previousMean, previousPosition, d.max, d.count, target)
+}
+
+// Mean returns the mean of the values. It's NaN for an empty digest.
+func (d *Digest) Mean() float64 {
+	if d.count == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, centroid := range d.centroids {
+		sum += centroid.Mean * centroid.Weight
+	}
+	for _, centroid := range d.pending {
+		sum += centroid.Mean * centroid.Weight
+	}
+	return sum / d.count
+}
+
+func interpolate(fromValue float64, fromPosition float64, toValue float64, toPosition float64, position float64) float64 {
+	if toPosition <= fromPosition {
+		return toValue
+	}
+	return fromValue + (toValue-fromValue)*(position-fromPosition)/(toPosition-fromPosition)
+}
+
+type byMean []Centroid
+
+func (c byMean) Len() int           { return len(c) }
+func (c byMean) Less(i, j int) bool { return c[i].Mean < c[j].Mean }
+func (c byMean) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
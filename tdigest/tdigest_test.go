@@ -0,0 +1,102 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tdigest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestDigest_Quantile(t *testing.T) {
+	a := assert.New(t)
+	empty := New(0)
+	a.Eq(math.IsNaN(empty.Quantile(0.5)), true)
+	a.Eq(math.IsNaN(empty.Mean()), true)
+
+	single := New(0)
+	single.Add(7)
+	a.EqFloat(single.Quantile(0), 7, 0)
+	a.EqFloat(single.Quantile(0.5), 7, 0)
+	a.EqFloat(single.Quantile(1), 7, 0)
+
+	d := New(0)
+	for i := 0; i < 100000; i++ {
+		d.Add(float64(i % 10000))
+	}
+	d.Add(math.NaN())
+	a.EqFloat(d.Count(), 100000, 0)
+	a.EqFloat(d.Quantile(0), 0, 0)
+	a.EqFloat(d.Quantile(1), 9999, 0)
+	a.EqFloat(d.Mean(), 4999.5, 1e-6)
+	for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+		a.Contextf("q=%g", q).EqFloat(d.Quantile(q), q*10000, 10000*0.005)
+	}
+	if len(d.Centroids()) > 5*DefaultCompression {
+		t.Errorf("the digest has %d centroids", len(d.Centroids()))
+	}
+}
+
+func TestDigest_Merge(t *testing.T) {
+	a := assert.New(t)
+	random := rand.New(rand.NewSource(1))
+	whole := New(0)
+	merged := New(0)
+	// Each part holds the values of one host, on a different scale, so that
+	// averaging their percentiles would be wrong.
+	for host := 1; host <= 10; host++ {
+		part := New(0)
+		for i := 0; i < 5000; i++ {
+			value := random.ExpFloat64() * float64(host)
+			part.Add(value)
+			whole.Add(value)
+		}
+		merged.Merge(part)
+	}
+	a.EqFloat(merged.Count(), whole.Count(), 0)
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		expected := whole.Quantile(q)
+		a.Contextf("q=%g", q).EqFloat(merged.Quantile(q), expected, expected*0.02)
+	}
+}
+
+func TestDigest_Encode(t *testing.T) {
+	a := assert.New(t)
+	d := New(50)
+	for i := 0; i < 1000; i++ {
+		d.Add(math.Sqrt(float64(i)))
+	}
+	decoded, err := Decode(d.Encode())
+	a.CheckError(err)
+	a.EqFloat(decoded.Compression(), 50, 0)
+	a.EqFloat(decoded.Count(), 1000, 0)
+	a.Eq(decoded.Centroids(), d.Centroids())
+	for _, q := range []float64{0, 0.25, 0.5, 0.99, 1} {
+		a.EqFloat(decoded.Quantile(q), d.Quantile(q), 0)
+	}
+
+	emptyDecoded, err := Decode(New(0).Encode())
+	a.CheckError(err)
+	a.EqFloat(emptyDecoded.Count(), 0, 0)
+
+	encoded := d.Encode()
+	for _, invalid := range [][]byte{nil, {2}, encoded[:20], encoded[:len(encoded)-1]} {
+		if _, err := Decode(invalid); err == nil {
+			t.Errorf("expected an error decoding %v", invalid)
+		}
+	}
+}
@@ -15,6 +15,7 @@
 package mocks
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -55,7 +56,7 @@ func (fa *FakeMetricMetadataAPI) AddPairWithoutGraphite(tm api.TaggedMetric) {
 	fa.metricTagSets[tm.MetricKey] = append(fa.metricTagSets[tm.MetricKey], tm.TagSet)
 }
 
-func (fa *FakeMetricMetadataAPI) GetAllTags(metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+func (fa *FakeMetricMetadataAPI) GetAllTags(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
 	defer context.Profiler.Record("Mock GetAllTags")()
 	if len(fa.metricTagSets[metricKey]) == 0 {
 		// This matches the behavior of the Cassandra API
@@ -64,7 +65,7 @@ func (fa *FakeMetricMetadataAPI) GetAllTags(metricKey api.MetricKey, context met
 	return fa.metricTagSets[metricKey], nil
 }
 
-func (fa *FakeMetricMetadataAPI) GetAllMetrics(context metadata.Context) ([]api.MetricKey, error) {
+func (fa *FakeMetricMetadataAPI) GetAllMetrics(ctx context.Context, context metadata.Context) ([]api.MetricKey, error) {
 	defer context.Profiler.Record("Mock GetAllMetrics")()
 	array := []api.MetricKey{}
 	for key := range fa.metricTagSets {
@@ -73,7 +74,7 @@ func (fa *FakeMetricMetadataAPI) GetAllMetrics(context metadata.Context) ([]api.
 	return array, nil
 }
 
-func (fa *FakeMetricMetadataAPI) GetMetricsForTag(tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
+func (fa *FakeMetricMetadataAPI) GetMetricsForTag(ctx context.Context, tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
 	defer context.Profiler.Record("Mock GetMetricsForTag")()
 	list := []api.MetricKey{}
 MetricLoop:
@@ -15,12 +15,14 @@
 package mocks
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"time"
 
 	"github.com/square/metrics/api"
 	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/tdigest"
 	"github.com/square/metrics/timeseries"
 )
 
@@ -29,13 +31,13 @@ type FakeComboAPI struct {
 	metrics   map[api.MetricKey][]api.Timeseries
 }
 
-func (fapi FakeComboAPI) AddMetric(metric api.TaggedMetric, context metadata.Context) error {
+func (fapi FakeComboAPI) AddMetric(ctx context.Context, metric api.TaggedMetric, context metadata.Context) error {
 	return fmt.Errorf("cannot add metrics to FakeComboAPI")
 }
-func (fapi FakeComboAPI) AddMetrics(metrics []api.TaggedMetric, context metadata.Context) error {
+func (fapi FakeComboAPI) AddMetrics(ctx context.Context, metrics []api.TaggedMetric, context metadata.Context) error {
 	return fmt.Errorf("cannot add metrics to FakeComboAPI")
 }
-func (fapi FakeComboAPI) GetAllTags(metric api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+func (fapi FakeComboAPI) GetAllTags(ctx context.Context, metric api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
 	list, ok := fapi.metrics[metric]
 	if !ok {
 		return nil, fmt.Errorf("no such metric `%s`", metric)
@@ -46,14 +48,14 @@ func (fapi FakeComboAPI) GetAllTags(metric api.MetricKey, context metadata.Conte
 	}
 	return tagsets, nil
 }
-func (fapi FakeComboAPI) GetAllMetrics(context metadata.Context) ([]api.MetricKey, error) {
+func (fapi FakeComboAPI) GetAllMetrics(ctx context.Context, context metadata.Context) ([]api.MetricKey, error) {
 	metrics := []api.MetricKey{}
 	for metric := range fapi.metrics {
 		metrics = append(metrics, metric)
 	}
 	return metrics, nil
 }
-func (fapi FakeComboAPI) GetMetricsForTag(tagKey string, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
+func (fapi FakeComboAPI) GetMetricsForTag(ctx context.Context, tagKey string, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
 	metrics := []api.MetricKey{}
 	for metric, list := range fapi.metrics {
 		for _, series := range list {
@@ -99,11 +101,23 @@ func (fapi FakeComboAPI) FetchSingleTimeseries(request timeseries.FetchRequest)
 		for i := range result.Values {
 			result.Values[i] = math.NaN()
 		}
+		if series.Samples != nil {
+			result.Samples = make([]int, len(result.Values))
+		}
+		if series.Sketches != nil {
+			result.Sketches = make([]*tdigest.Digest, len(result.Values))
+		}
 		// Iterate over the series, and assign each point in the result.
 		for i := range series.Values {
 			ri := request.Timerange.IndexOfTime(fapi.timerange.TimeOfIndex(i))
 			if ri >= 0 && ri < len(result.Values) {
 				result.Values[ri] = series.Values[i]
+				if series.Samples != nil {
+					result.Samples[ri] = series.Samples[i]
+				}
+				if series.Sketches != nil {
+					result.Sketches[ri] = series.Sketches[i]
+				}
 			}
 		}
 		return result, nil
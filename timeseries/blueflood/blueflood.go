@@ -26,7 +26,9 @@ import (
 	"time"
 
 	"github.com/square/metrics/api"
+	"github.com/square/metrics/endpoints"
 	"github.com/square/metrics/inspect"
+	"github.com/square/metrics/log"
 	"github.com/square/metrics/tasks"
 	"github.com/square/metrics/timeseries"
 	"github.com/square/metrics/util"
@@ -34,11 +36,14 @@ import (
 
 // Blueflood is a timeseries storage API instance.
 type Blueflood struct {
-	config Config
+	config    Config
+	pool      *endpoints.Pool   // nil if there's only the BaseURL
+	endpoints *serviceEndpoints // nil unless endpoints are discovered
 }
 
 //Blueflood implements TimeseriesStorageAPI
 var _ timeseries.StorageAPI = (*Blueflood)(nil)
+var _ timeseries.ScanningStorageAPI = (*Blueflood)(nil)
 
 // TimeSource represents a source of time values.
 // Its zero value will give the current time.
@@ -66,10 +71,15 @@ func (r Resolution) String() string {
 }
 
 type Config struct {
-	BaseURL                 string       `yaml:"base_url"`
-	TenantID                string       `yaml:"tenant_id"`
-	Resolutions             []Resolution `yaml:"resolutions"`           // Resolutions are ordered by priority: best (typically finest) first.
-	MaxSimultaneousRequests int          `yaml:"simultaneous_requests"` // simultaneous requests limits the number of concurrent single-fetches for each multi-fetch
+	BaseURL                 string                `yaml:"base_url"`
+	Discovery               Discovery             `yaml:"discovery"` // if configured, replaces the BaseURL
+	Pool                    endpoints.Config      `yaml:"pool"`      // if configured, replaces the BaseURL; discovered endpoints replace its endpoints
+	TenantID                string                `yaml:"tenant_id"`
+	Resolutions             []Resolution          `yaml:"resolutions"`           // Resolutions are ordered by priority: best (typically finest) first.
+	MaxSimultaneousRequests int                   `yaml:"simultaneous_requests"` // simultaneous requests limits the number of concurrent single-fetches for each multi-fetch
+	Connections             util.HTTPClientConfig `yaml:"connections"`           // tunes the connection pool, unless an HTTPClient is given
+	ScanPatterns            []string              `yaml:"scan_patterns"`         // graphite globs (such as "*.*.*") searched to list the series held, for the metadata indexer
+	IngestURL               string                `yaml:"ingest_url"`            // optional. Where points are written, such as by the canary; Blueflood ingests on another port than it's queried
 
 	GraphiteMetricConverter util.GraphiteConverter
 
@@ -79,23 +89,46 @@ type Config struct {
 
 // NewBlueflood uses the Config to create an instance of Blueflood.
 func NewBlueflood(c Config) timeseries.StorageAPI {
-	if c.HTTPClient == nil {
-		c.HTTPClient = http.DefaultClient
-	}
 	if c.MaxSimultaneousRequests == 0 {
 		c.MaxSimultaneousRequests = 5
 	}
+	if c.HTTPClient == nil {
+		// Keep enough idle connections for at least one multi-fetch to
+		// reuse them all.
+		if c.Connections.MaxIdleConnsPerHost == 0 {
+			c.Connections.MaxIdleConnsPerHost = c.MaxSimultaneousRequests
+		}
+		c.HTTPClient = c.Connections.NewClient()
+	}
 
 	b := &Blueflood{
 		config: c,
 	}
+	if c.Pool.Enabled() || c.Discovery.Enabled() {
+		if c.Pool.HealthCheck.Path == "" {
+			c.Pool.HealthCheck.Path = "/v2.0"
+		}
+		b.pool = endpoints.NewPool(c.Pool, c.HTTPClient)
+		go b.pool.Run()
+	}
+	if c.Discovery.Enabled() {
+		b.endpoints = newServiceEndpoints(c.Discovery, b.pool)
+		if err := b.endpoints.refresh(); err != nil {
+			log.Errorf("Error discovering Blueflood endpoints: %s", err.Error())
+		}
+		go b.endpoints.run()
+	}
 	// TODO: copy internal config structures to prevent modification?
 	return b
 }
 
 // CheckHealthy checks if the blueflood server is available by querying /v2.0
 func (b *Blueflood) CheckHealthy() error {
-	resp, err := b.config.HTTPClient.Get(fmt.Sprintf("%s/v2.0", b.config.BaseURL))
+	baseURL, err := b.baseURL()
+	if err != nil {
+		return err
+	}
+	resp, err := b.config.HTTPClient.Get(fmt.Sprintf("%s/v2.0", baseURL))
 	if err != nil {
 		return err
 	}
@@ -178,6 +211,7 @@ type fetchPlan struct {
 	intervals map[Resolution]api.Interval
 	sampler   sampler
 	timerange api.Timerange
+	header    http.Header // the labels of the request
 }
 
 // createPlan uses the specified request details (which don't depend on the
@@ -200,9 +234,61 @@ func (b *Blueflood) createPlan(request timeseries.RequestDetails) (fetchPlan, er
 		intervals: intervals,
 		sampler:   samplerFunc,
 		timerange: request.Timerange,
+		header:    labelHeader(request.Labels),
 	}, nil
 }
 
+// LabelHeaderPrefix begins the names of the headers which carry the labels
+// of a request, such as "X-Mqe-Label-Dashboard: checkout", so that
+// Blueflood's request logs can attribute slow requests to their queries.
+const LabelHeaderPrefix = "X-Mqe-Label-"
+
+// labelHeader encodes the labels as headers. Labels may come from query
+// directives, so the control characters of their values, such as the line
+// breaks of a multi-line comment, are replaced by spaces, and labels whose
+// names can't be header names are left out.
+func labelHeader(labels map[string]string) http.Header {
+	header := http.Header{}
+	for name, value := range labels {
+		if name == "" || strings.IndexFunc(name, isNotHeaderToken) >= 0 {
+			continue
+		}
+		header.Set(LabelHeaderPrefix+name, strings.Map(func(r rune) rune {
+			if isControl(r) {
+				return ' '
+			}
+			return r
+		}, value))
+	}
+	return header
+}
+
+// isControl reports whether the character can't appear in a header value.
+func isControl(r rune) bool {
+	return (r < ' ' && r != '\t') || r == 0x7f
+}
+
+// isNotHeaderToken reports whether the character can't appear in a header name.
+func isNotHeaderToken(r rune) bool {
+	return r <= ' ' || r >= 0x7f || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", r)
+}
+
+// checkHeader rejects headers which the HTTP client would refuse to send,
+// so that they aren't mistaken for a failing server.
+func checkHeader(header http.Header) error {
+	for name, values := range header {
+		if name == "" || strings.IndexFunc(name, isNotHeaderToken) >= 0 {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		for _, value := range values {
+			if strings.IndexFunc(value, isControl) >= 0 {
+				return fmt.Errorf("invalid value for header %s", name)
+			}
+		}
+	}
+	return nil
+}
+
 // fetchTimeseries uses the provided plan to fetch the timeseries from Blueflood
 // using several HTTP queries. FetchMultipleTimeseries defers to this method,
 // rather than FetchSingleTimeseries, in order to prevent duplicating work on a
@@ -214,15 +300,24 @@ func (b *Blueflood) fetchTimeseries(metric api.TaggedMetric, plan fetchPlan, pro
 		resolution, interval := resolution, interval
 		queue.Do(func() error {
 			defer profiler.RecordWithDescription("Blueflood FetchSingleTimeseries Resolution", fmt.Sprintf("%s at %+v", metric.String(), resolution.Resolution))()
-			// Construct the URL
-			queryURL, err := b.constructURL(metric, interval, plan.sampler, resolution)
-			if err != nil {
-				return err
-			}
-			// Then query it.
-			points, err := b.fetchTimeseriesHTTP(queryURL, ctx)
-			if err != nil {
-				return err
+			var points []metricPoint
+			for attempt := 1; ; attempt++ {
+				// Construct the URL
+				queryURL, err := b.constructURL(metric, interval, plan.sampler, resolution)
+				if err != nil {
+					return err
+				}
+				// Then query it, failing over to another endpoint if the server fails.
+				var failover bool
+				start := time.Now()
+				points, failover, err = b.fetchTimeseriesHTTP(queryURL, plan.header, ctx)
+				recordRequest(profiler, queryURL, start, len(points), err)
+				if err == nil {
+					break
+				}
+				if !failover || attempt >= b.attempts() {
+					return err
+				}
 			}
 			queue.Lock()
 			defer queue.Unlock()
@@ -235,17 +330,30 @@ func (b *Blueflood) fetchTimeseries(metric api.TaggedMetric, plan fetchPlan, pro
 		return api.Timeseries{}, err
 	}
 
-	values := samplePoints(allPoints, plan.timerange, plan.sampler)
+	values, samples := samplePoints(allPoints, plan.timerange, plan.sampler)
 
 	return api.Timeseries{
-		Values: values,
-		TagSet: metric.TagSet,
+		Values:  values,
+		TagSet:  metric.TagSet,
+		Samples: samples,
 	}, nil
 }
 
 // Helper functions
 // ----------------
 
+// recordRequest keeps the request in the profiler, for debug=backend.
+func recordRequest(profiler *inspect.Profiler, queryURL *url.URL, start time.Time, points int, err error) {
+	if !profiler.RecordsBackendRequests() {
+		return
+	}
+	request := inspect.BackendRequest{Backend: "blueflood", Request: queryURL.String(), Start: start, Finish: time.Now(), Rows: points}
+	if err != nil {
+		request.Error = err.Error()
+	}
+	profiler.AddBackendRequest(request)
+}
+
 // constructURL creates the URL to the blueflood's backend to fetch the data from.
 func (b *Blueflood) constructURL(metric api.TaggedMetric, interval api.Interval, sampler sampler, resolution Resolution) (*url.URL, error) {
 	graphiteName, err := b.config.GraphiteMetricConverter.ToGraphiteName(metric)
@@ -253,7 +361,12 @@ func (b *Blueflood) constructURL(metric api.TaggedMetric, interval api.Interval,
 		return nil, timeseries.Error{Metric: metric, Code: timeseries.InvalidSeriesError, Message: "cannot convert to graphite name"}
 	}
 
-	result, err := url.Parse(fmt.Sprintf("%s/v2.0/%s/views/%s", b.config.BaseURL, b.config.TenantID, graphiteName))
+	baseURL, err := b.baseURL()
+	if err != nil {
+		return nil, timeseries.FetchError{Code: 503, Message: err.Error()}
+	}
+
+	result, err := url.Parse(fmt.Sprintf("%s/v2.0/%s/views/%s", baseURL, b.config.TenantID, graphiteName))
 	if err != nil {
 		return nil, timeseries.Error{Metric: metric, Code: timeseries.InvalidSeriesError, Message: fmt.Sprintf("cannot generate URL for tagged metric with graphite name %s", graphiteName)}
 	}
@@ -268,37 +381,91 @@ func (b *Blueflood) constructURL(metric api.TaggedMetric, interval api.Interval,
 	return result, nil
 }
 
+// baseURL chooses the Blueflood server for a request.
+func (b *Blueflood) baseURL() (string, error) {
+	if b.endpoints != nil {
+		return b.endpoints.baseURL()
+	}
+	if b.pool != nil {
+		return b.pool.Next()
+	}
+	return b.config.BaseURL, nil
+}
+
+// Pool returns the pool of Blueflood servers, or nil if there's only the BaseURL.
+func (b *Blueflood) Pool() *endpoints.Pool {
+	return b.pool
+}
+
+// attempts is the number of endpoints tried by each request.
+func (b *Blueflood) attempts() int {
+	if b.pool == nil {
+		return 1
+	}
+	return 2
+}
+
+// report tells the pool, if any, whether the server of the URL is healthy.
+func (b *Blueflood) report(queryURL *url.URL, healthy bool) {
+	if b.pool != nil {
+		b.pool.Report(queryURL.String(), healthy)
+	}
+}
+
 type httpClient interface {
 	// our own client to mock out the standard golang HTTP Client.
 	Get(string) (*http.Response, error)
 	Do(*http.Request) (*http.Response, error)
 }
 
-// fetch fetches from the backend, asynchronously calling performFetch and cancelling on timeout.
-func (b *Blueflood) fetchTimeseriesHTTP(queryURL *url.URL, ctx context.Context) ([]metricPoint, error) {
+// fetchTimeseriesHTTP fetches from the backend, cancelling when the context
+// is done. It reports whether the request failed because of the server, so
+// that it may be retried on another.
+func (b *Blueflood) fetchTimeseriesHTTP(queryURL *url.URL, header http.Header, ctx context.Context) ([]metricPoint, bool, error) {
+	// Requests which can't be made say nothing of the server.
+	if err := checkHeader(header); err != nil {
+		return nil, false, timeseries.FetchError{Code: http.StatusBadRequest, Message: fmt.Sprintf("cannot request %q from Blueflood: %s", queryURL.String(), err.Error())}
+	}
 	request, err := http.NewRequest("GET", queryURL.String(), nil)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	for name, values := range header {
+		request.Header[name] = values
 	}
 	request.Cancel = ctx.Done()
 	response, err := b.config.HTTPClient.Do(request)
 	if err != nil {
-		return nil, timeseries.FetchError{Code: 500, Message: fmt.Sprintf("error fetching from Blueflood at URL %q: %s", queryURL.String(), err.Error())}
+		failed := ctx.Err() == nil // a cancelled request says nothing of the server
+		if failed {
+			b.report(queryURL, false)
+		}
+		return nil, failed, timeseries.FetchError{Code: 500, Message: fmt.Sprintf("error fetching from Blueflood at URL %q: %s", queryURL.String(), err.Error())}
+	}
+	if response.StatusCode/100 == 5 {
+		response.Body.Close()
+		b.report(queryURL, false)
+		return nil, true, timeseries.FetchError{Code: 500, Message: fmt.Sprintf("Blueflood at URL %q responded with status %d", queryURL.String(), response.StatusCode)}
+	}
+	b.report(queryURL, true)
+	if response.StatusCode == http.StatusRequestEntityTooLarge {
+		response.Body.Close()
+		return nil, false, timeseries.FetchError{Code: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("Blueflood at URL %q refused to return so many points", queryURL.String())}
 	}
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return nil, timeseries.FetchError{Code: 500, Message: fmt.Sprintf("error reading from Blueflood response body at URL %q: %s", queryURL.String(), err.Error())}
+		return nil, false, timeseries.FetchError{Code: 500, Message: fmt.Sprintf("error reading from Blueflood response body at URL %q: %s", queryURL.String(), err.Error())}
 	}
 	err = response.Body.Close()
 	if err != nil {
-		return nil, timeseries.FetchError{Code: 500, Message: fmt.Sprintf("error finishing response from Blueflood at URL %q: %s", queryURL.String(), err.Error())}
+		return nil, false, timeseries.FetchError{Code: 500, Message: fmt.Sprintf("error finishing response from Blueflood at URL %q: %s", queryURL.String(), err.Error())}
 	}
 	var parsedJSON queryResponse
 	err = json.Unmarshal(body, &parsedJSON)
 	if err != nil {
-		return nil, timeseries.FetchError{Code: 500, Message: fmt.Sprintf("error unmarshaling JSON from Blueflood at URL %q: %s;\nBody:%s", queryURL.String(), err.Error(), body)}
+		return nil, false, timeseries.FetchError{Code: 500, Message: fmt.Sprintf("error unmarshaling JSON from Blueflood at URL %q: %s;\nBody:%s", queryURL.String(), err.Error(), body)}
 	}
-	return parsedJSON.Values, nil
+	return parsedJSON.Values, false, nil
 }
 
 type queryResponse struct {
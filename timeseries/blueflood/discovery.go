@@ -0,0 +1,132 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blueflood
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/square/metrics/endpoints"
+	"github.com/square/metrics/log"
+)
+
+// Discovery locates Blueflood servers through DNS instead of a fixed
+// BaseURL. In Kubernetes, Service names a headless service, such as
+// "blueflood.monitoring.svc.cluster.local", whose records list the ready
+// pods; a name beginning with an underscore is looked up as an SRV record,
+// such as "_http._tcp.blueflood.monitoring.svc.cluster.local", which also
+// supplies the ports.
+type Discovery struct {
+	Service         string        `yaml:"service"`
+	Port            int           `yaml:"port"`             // the port for addresses found without SRV records
+	Scheme          string        `yaml:"scheme"`           // defaults to http
+	RefreshInterval time.Duration `yaml:"refresh_interval"` // defaults to 30s
+}
+
+// Enabled determines whether discovery has been configured.
+func (d Discovery) Enabled() bool {
+	return d.Service != ""
+}
+
+// serviceEndpoints keeps the base URLs most recently resolved for a
+// Discovery in a pool, which hands them out in turn. SRV records supply the
+// weights of their endpoints.
+type serviceEndpoints struct {
+	discovery  Discovery
+	lookupSRV  func(service, proto, name string) (string, []*net.SRV, error)
+	lookupHost func(host string) ([]string, error)
+	pool       *endpoints.Pool
+}
+
+func newServiceEndpoints(discovery Discovery, pool *endpoints.Pool) *serviceEndpoints {
+	if discovery.Scheme == "" {
+		discovery.Scheme = "http"
+	}
+	if discovery.RefreshInterval == 0 {
+		discovery.RefreshInterval = 30 * time.Second
+	}
+	return &serviceEndpoints{
+		discovery:  discovery,
+		lookupSRV:  net.LookupSRV,
+		lookupHost: net.LookupHost,
+		pool:       pool,
+	}
+}
+
+// resolve looks up the current endpoints for the service.
+func (s *serviceEndpoints) resolve() ([]endpoints.Endpoint, error) {
+	urls := []endpoints.Endpoint{}
+	if strings.HasPrefix(s.discovery.Service, "_") {
+		_, records, err := s.lookupSRV("", "", s.discovery.Service)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			host := strings.TrimSuffix(record.Target, ".")
+			urls = append(urls, endpoints.Endpoint{
+				URL:    fmt.Sprintf("%s://%s", s.discovery.Scheme, net.JoinHostPort(host, strconv.Itoa(int(record.Port)))),
+				Weight: int(record.Weight),
+			})
+		}
+	} else {
+		if s.discovery.Port == 0 {
+			return nil, fmt.Errorf("a port is required to discover Blueflood through the address records of %s", s.discovery.Service)
+		}
+		addresses, err := s.lookupHost(s.discovery.Service)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range addresses {
+			urls = append(urls, endpoints.Endpoint{URL: fmt.Sprintf("%s://%s", s.discovery.Scheme, net.JoinHostPort(address, strconv.Itoa(s.discovery.Port)))})
+		}
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no Blueflood endpoints were found for %s", s.discovery.Service)
+	}
+	return urls, nil
+}
+
+// refresh replaces the endpoints with freshly resolved ones. When resolution
+// fails the previous endpoints are kept, since a DNS outage is not evidence
+// that the servers themselves are gone.
+func (s *serviceEndpoints) refresh() error {
+	urls, err := s.resolve()
+	if err != nil {
+		return err
+	}
+	s.pool.SetEndpoints(urls)
+	return nil
+}
+
+// run refreshes the endpoints periodically, forever.
+func (s *serviceEndpoints) run() {
+	for range time.Tick(s.discovery.RefreshInterval) {
+		if err := s.refresh(); err != nil {
+			log.Errorf("Error discovering Blueflood endpoints: %s", err.Error())
+		}
+	}
+}
+
+// baseURL returns one of the known endpoints, as chosen by the pool.
+func (s *serviceEndpoints) baseURL() (string, error) {
+	url, err := s.pool.Next()
+	if err == endpoints.ErrNoEndpoints {
+		return "", fmt.Errorf("no Blueflood endpoints have been discovered for %s", s.discovery.Service)
+	}
+	return url, err
+}
@@ -0,0 +1,119 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blueflood
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/endpoints"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/util"
+)
+
+func TestServiceEndpoints_Host(t *testing.T) {
+	a := assert.New(t)
+	discovered := newServiceEndpoints(Discovery{Service: "blueflood.monitoring.svc.cluster.local", Port: 19020}, endpoints.NewPool(endpoints.Config{}, nil))
+	addresses := []string{"10.0.0.1", "10.0.0.2"}
+	discovered.lookupHost = func(host string) ([]string, error) {
+		a.EqString(host, "blueflood.monitoring.svc.cluster.local")
+		return addresses, nil
+	}
+
+	_, err := discovered.baseURL()
+	if err == nil {
+		t.Errorf("expected an error before any endpoints are discovered")
+	}
+	a.CheckError(discovered.refresh())
+	seen := map[string]bool{}
+	for i := 0; i < 4; i++ {
+		url, err := discovered.baseURL()
+		a.CheckError(err)
+		seen[url] = true
+	}
+	a.EqInt(len(seen), 2)
+	a.EqBool(seen["http://10.0.0.1:19020"], true)
+	a.EqBool(seen["http://10.0.0.2:19020"], true)
+
+	// Failed lookups keep the previous endpoints.
+	discovered.lookupHost = func(host string) ([]string, error) {
+		return nil, errors.New("no such host")
+	}
+	if discovered.refresh() == nil {
+		t.Errorf("expected the failed lookup to be reported")
+	}
+	_, err = discovered.baseURL()
+	a.CheckError(err)
+}
+
+func TestServiceEndpoints_SRV(t *testing.T) {
+	a := assert.New(t)
+	discovered := newServiceEndpoints(Discovery{Service: "_http._tcp.blueflood.monitoring.svc.cluster.local", Scheme: "https"}, endpoints.NewPool(endpoints.Config{}, nil))
+	discovered.lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		a.EqString(name, "_http._tcp.blueflood.monitoring.svc.cluster.local")
+		return "", []*net.SRV{{Target: "blueflood-0.blueflood.monitoring.svc.cluster.local.", Port: 8080}}, nil
+	}
+	a.CheckError(discovered.refresh())
+	url, err := discovered.baseURL()
+	a.CheckError(err)
+	a.EqString(url, "https://blueflood-0.blueflood.monitoring.svc.cluster.local:8080")
+
+	missingPort := newServiceEndpoints(Discovery{Service: "blueflood"}, endpoints.NewPool(endpoints.Config{}, nil))
+	if missingPort.refresh() == nil {
+		t.Errorf("expected an error for address records without a port")
+	}
+}
+
+func TestBlueflood_PoolFailover(t *testing.T) {
+	a := assert.New(t)
+	nowMillis := int64(739908000000)
+	client := mocks.NewFakeHTTPClient()
+	query := "/v2.0/square/views/some.key.graphite?from=739907880000&resolution=FULL&select=numPoints%2Caverage&to=739907999999"
+	client.SetResponse("https://down.url"+query, mocks.Response{StatusCode: 503})
+	client.SetResponse("https://blueflood.url"+query, mocks.Response{
+		Body:       `{"values": [{"numPoints": 1, "timestamp": 739907880000, "average": 5}]}`,
+		StatusCode: 200,
+	})
+	blueflood := NewBlueflood(Config{
+		Pool:                    endpoints.Config{Endpoints: []endpoints.Endpoint{{URL: "https://down.url"}, {URL: "https://blueflood.url"}}},
+		TenantID:                "square",
+		Resolutions:             []Resolution{resolutionFull},
+		GraphiteMetricConverter: &mocks.FakeGraphiteConverter{MetricMap: map[util.GraphiteMetric]api.TaggedMetric{"some.key.graphite": {MetricKey: "some.key", TagSet: api.TagSet{"tag": "value"}}}},
+		HTTPClient:              client,
+		TimeSource:              TimeSource{GetTime: func() time.Time { return time.Unix(nowMillis/1000, 0) }},
+	})
+	timerange, err := api.NewTimerange(nowMillis-120000, nowMillis, 30000)
+	a.CheckError(err)
+	// Each fetch fails over from the endpoint which is down, until it's
+	// ejected from the pool.
+	for i := 0; i < 5; i++ {
+		result, err := blueflood.FetchSingleTimeseries(timeseries.FetchRequest{
+			Metric: api.TaggedMetric{MetricKey: "some.key", TagSet: api.TagSet{"tag": "value"}},
+			RequestDetails: timeseries.RequestDetails{
+				SampleMethod: timeseries.SampleMean,
+				Timerange:    timerange,
+				Ctx:          context.Background(),
+			},
+		})
+		a.CheckError(err)
+		a.EqFloat(result.Values[0], 5, 0)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blueflood
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/square/metrics/api"
+)
+
+// ingestTTL is how long the points written to Blueflood are kept.
+const ingestTTL = 24 * time.Hour
+
+type ingestPoint struct {
+	CollectionTime int64   `json:"collectionTime"` // milliseconds
+	TTLInSeconds   int     `json:"ttlInSeconds"`
+	MetricValue    float64 `json:"metricValue"`
+	MetricName     string  `json:"metricName"`
+}
+
+// WritePoint writes a point of the metric to the ingestion endpoint, under
+// its graphite name.
+func (b *Blueflood) WritePoint(metric api.TaggedMetric, t time.Time, value float64) error {
+	if b.config.IngestURL == "" {
+		return fmt.Errorf("Blueflood has no ingest_url to write points to")
+	}
+	name, err := b.config.GraphiteMetricConverter.ToGraphiteName(metric)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal([]ingestPoint{{
+		CollectionTime: t.UnixNano() / 1e6,
+		TTLInSeconds:   int(ingestTTL.Seconds()),
+		MetricValue:    value,
+		MetricName:     string(name),
+	}})
+	if err != nil {
+		return err
+	}
+	ingestURL := fmt.Sprintf("%s/v2.0/%s/ingest", b.config.IngestURL, b.config.TenantID)
+	request, err := http.NewRequest("POST", ingestURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := b.config.HTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("error writing to Blueflood at URL %q: %s", ingestURL, err.Error())
+	}
+	response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("Blueflood at URL %q responded with status %d", ingestURL, response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blueflood
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/util"
+)
+
+// ingestRecorder records the requests made through it, answering each with
+// the given status.
+type ingestRecorder struct {
+	status   int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *ingestRecorder) Get(url string) (*http.Response, error) {
+	panic("unexpected GET of " + url)
+}
+
+func (r *ingestRecorder) Do(request *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.requests = append(r.requests, request)
+	r.bodies = append(r.bodies, string(body))
+	return &http.Response{StatusCode: r.status, Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+}
+
+func TestBlueflood_WritePoint(t *testing.T) {
+	a := assert.New(t)
+	metric := api.TaggedMetric{MetricKey: "mqe.canary", TagSet: api.TagSet{"canary": "host1"}}
+	client := &ingestRecorder{status: 200}
+	config := Config{
+		BaseURL:                 "https://blueflood.url",
+		IngestURL:               "https://blueflood.url:19000",
+		TenantID:                "square",
+		GraphiteMetricConverter: &mocks.FakeGraphiteConverter{MetricMap: map[util.GraphiteMetric]api.TaggedMetric{"mqe.canary.host1": metric}},
+		HTTPClient:              client,
+	}
+	blueflood := NewBlueflood(config).(*Blueflood)
+	a.CheckError(blueflood.WritePoint(metric, time.Unix(1500000000, 0), 42))
+	a.EqInt(len(client.requests), 1)
+	a.EqString(client.requests[0].Method, "POST")
+	a.EqString(client.requests[0].URL.String(), "https://blueflood.url:19000/v2.0/square/ingest")
+	a.EqString(client.bodies[0], `[{"collectionTime":1500000000000,"ttlInSeconds":86400,"metricValue":42,"metricName":"mqe.canary.host1"}]`)
+
+	client.status = 500
+	a.EqBool(blueflood.WritePoint(metric, time.Unix(1500000000, 0), 42) != nil, true)
+
+	config.IngestURL = ""
+	a.EqBool(NewBlueflood(config).(*Blueflood).WritePoint(metric, time.Unix(1500000000, 0), 42) != nil, true)
+}
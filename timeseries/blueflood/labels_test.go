@@ -0,0 +1,95 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blueflood
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/util"
+)
+
+// headerRecorder records the headers of the requests made through the client.
+type headerRecorder struct {
+	*mocks.FakeHTTPClient
+	headers []http.Header
+}
+
+func (r *headerRecorder) Do(request *http.Request) (*http.Response, error) {
+	r.headers = append(r.headers, request.Header)
+	return r.FakeHTTPClient.Do(request)
+}
+
+func TestBlueflood_Labels(t *testing.T) {
+	a := assert.New(t)
+	nowMillis := int64(739908000000)
+	client := &headerRecorder{FakeHTTPClient: mocks.NewFakeHTTPClient()}
+	client.SetResponse("https://blueflood.url/v2.0/square/views/some.key.graphite?from=739907880000&resolution=FULL&select=numPoints%2Caverage&to=739907999999", mocks.Response{
+		Body:       `{"values": [{"numPoints": 1, "timestamp": 739907880000, "average": 5}]}`,
+		StatusCode: 200,
+	})
+	blueflood := NewBlueflood(Config{
+		BaseURL:                 "https://blueflood.url",
+		TenantID:                "square",
+		Resolutions:             []Resolution{resolutionFull},
+		GraphiteMetricConverter: &mocks.FakeGraphiteConverter{MetricMap: map[util.GraphiteMetric]api.TaggedMetric{"some.key.graphite": {MetricKey: "some.key", TagSet: api.TagSet{"tag": "value"}}}},
+		HTTPClient:              client,
+		TimeSource:              TimeSource{GetTime: func() time.Time { return time.Unix(nowMillis/1000, 0) }},
+	})
+	timerange, err := api.NewTimerange(nowMillis-120000, nowMillis, 30000)
+	a.CheckError(err)
+	_, err = blueflood.FetchSingleTimeseries(timeseries.FetchRequest{
+		Metric: api.TaggedMetric{MetricKey: "some.key", TagSet: api.TagSet{"tag": "value"}},
+		RequestDetails: timeseries.RequestDetails{
+			SampleMethod: timeseries.SampleMean,
+			Timerange:    timerange,
+			Ctx:          context.Background(),
+			Labels:       map[string]string{"tenant": "payments", "dashboard": "checkout", "owner": "payments\r\noncall", "bad name": "x"},
+		},
+	})
+	a.CheckError(err)
+	a.EqInt(len(client.headers), 1)
+	a.Eq(client.headers[0], http.Header{
+		"X-Mqe-Label-Tenant":    {"payments"},
+		"X-Mqe-Label-Dashboard": {"checkout"},
+		"X-Mqe-Label-Owner":     {"payments  oncall"},
+	})
+}
+
+func TestBlueflood_InvalidHeader(t *testing.T) {
+	a := assert.New(t)
+	client := &headerRecorder{FakeHTTPClient: mocks.NewFakeHTTPClient()}
+	blueflood := NewBlueflood(Config{
+		BaseURL:    "https://blueflood.url",
+		TenantID:   "square",
+		HTTPClient: client,
+	})
+	queryURL, err := url.Parse("https://blueflood.url/v2.0/square/views/some.key.graphite")
+	a.CheckError(err)
+	// A header which can't be sent isn't the server's failure.
+	_, failover, err := blueflood.(*Blueflood).fetchTimeseriesHTTP(queryURL, http.Header{"X-Mqe-Label-Owner": {"line\nbreak"}}, context.Background())
+	if err == nil {
+		t.Fatalf("expected the header to be rejected")
+	}
+	a.EqBool(failover, false)
+	a.EqInt(len(client.headers), 0)
+}
@@ -0,0 +1,76 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blueflood
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/inspect"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/util"
+)
+
+func TestBlueflood_BackendRequests(t *testing.T) {
+	a := assert.New(t)
+	nowMillis := int64(739908000000)
+	queryURL := "https://blueflood.url/v2.0/square/views/some.key.graphite?from=739907880000&resolution=FULL&select=numPoints%2Caverage&to=739907999999"
+	client := mocks.NewFakeHTTPClient()
+	client.SetResponse(queryURL, mocks.Response{
+		Body:       `{"values": [{"numPoints": 1, "timestamp": 739907880000, "average": 5}, {"numPoints": 1, "timestamp": 739907910000, "average": 6}]}`,
+		StatusCode: 200,
+	})
+	blueflood := NewBlueflood(Config{
+		BaseURL:                 "https://blueflood.url",
+		TenantID:                "square",
+		Resolutions:             []Resolution{resolutionFull},
+		GraphiteMetricConverter: &mocks.FakeGraphiteConverter{MetricMap: map[util.GraphiteMetric]api.TaggedMetric{"some.key.graphite": {MetricKey: "some.key", TagSet: api.TagSet{"tag": "value"}}}},
+		HTTPClient:              client,
+		TimeSource:              TimeSource{GetTime: func() time.Time { return time.Unix(nowMillis/1000, 0) }},
+	})
+	timerange, err := api.NewTimerange(nowMillis-120000, nowMillis, 30000)
+	a.CheckError(err)
+	fetch := func(profiler *inspect.Profiler) {
+		_, err := blueflood.FetchSingleTimeseries(timeseries.FetchRequest{
+			Metric: api.TaggedMetric{MetricKey: "some.key", TagSet: api.TagSet{"tag": "value"}},
+			RequestDetails: timeseries.RequestDetails{
+				SampleMethod: timeseries.SampleMean,
+				Timerange:    timerange,
+				Ctx:          context.Background(),
+				Profiler:     profiler,
+			},
+		})
+		a.CheckError(err)
+	}
+
+	// Requests are only kept when asked for.
+	profiler := inspect.New()
+	fetch(profiler)
+	a.EqInt(len(profiler.BackendRequests()), 0)
+
+	profiler.RecordBackendRequests()
+	fetch(profiler)
+	requests := profiler.BackendRequests()
+	a.EqInt(len(requests), 1)
+	a.EqString(requests[0].Backend, "blueflood")
+	a.EqString(requests[0].Request, queryURL)
+	a.EqInt(requests[0].Rows, 2)
+	a.EqString(requests[0].Error, "")
+	a.Eq(requests[0].Finish.Before(requests[0].Start), false)
+}
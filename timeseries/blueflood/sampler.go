@@ -24,13 +24,16 @@ import (
 type sampler struct {
 	fieldName    string                          // Name of field in Blueflood JSON response
 	selectField  func(point metricPoint) float64 // Function for extracting field from metricPoint
-	sampleBucket func([]float64) float64         // Function to sample from the bucket (e.g., min, mean, max)
+	sampleBucket func([]float64, []int) float64  // Function to sample from the bucket (e.g., min, mean, max), given the number of raw samples behind each point
 }
 
-// sampleResult samples the points into a uniform slice of float64s.
-func samplePoints(points []metricPoint, timerange api.Timerange, sampler sampler) []float64 {
+// samplePoints samples the points into a uniform slice of float64s. It also
+// counts the raw samples behind each value, unless Blueflood reported none.
+func samplePoints(points []metricPoint, timerange api.Timerange, sampler sampler) ([]float64, []int) {
 	// A bucket holds a set of points corresponding to one interval in the result.
 	buckets := make([][]float64, timerange.Slots())
+	weights := make([][]int, timerange.Slots())
+	counted := false
 	for _, point := range points {
 		pointValue := sampler.selectField(point)
 		index := (point.Timestamp - timerange.StartMillis()) / timerange.ResolutionMillis()
@@ -38,41 +41,66 @@ func samplePoints(points []metricPoint, timerange api.Timerange, sampler sampler
 			continue
 		}
 		buckets[index] = append(buckets[index], pointValue)
+		weights[index] = append(weights[index], point.Points)
+		counted = counted || point.Points > 0
 	}
 
 	// values will hold the final values to be returned as the series.
 	values := make([]float64, timerange.Slots())
+	var samples []int
+	if counted {
+		samples = make([]int, timerange.Slots())
+	}
 
 	for i, bucket := range buckets {
+		if counted {
+			for _, weight := range weights[i] {
+				samples[i] += weight
+			}
+		}
 		if len(bucket) == 0 {
 			values[i] = math.NaN()
 			continue
 		}
-		values[i] = sampler.sampleBucket(bucket)
+		values[i] = sampler.sampleBucket(bucket, weights[i])
 	}
-	return values
+	return values, samples
 }
 
 var samplerMap = map[timeseries.SampleMethod]sampler{
 	timeseries.SampleMean: {
 		fieldName:   "average",
 		selectField: func(point metricPoint) float64 { return point.Average },
-		sampleBucket: func(bucket []float64) float64 {
+		sampleBucket: func(bucket []float64, weights []int) float64 {
+			// The averages of rollups (such as those of several resolutions
+			// in one bucket) are weighted by the number of raw samples behind
+			// them, if Blueflood reported them all.
+			weighted := true
+			for i, v := range bucket {
+				if !math.IsNaN(v) && weights[i] <= 0 {
+					weighted = false
+				}
+			}
 			value := 0.0
-			count := 0
-			for _, v := range bucket {
-				if !math.IsNaN(v) {
-					value += v
-					count++
+			total := 0.0
+			for i, v := range bucket {
+				if math.IsNaN(v) {
+					continue
+				}
+				weight := 1.0
+				if weighted {
+					weight = float64(weights[i])
 				}
+				value += v * weight
+				total += weight
 			}
-			return value / float64(count)
+			return value / total
 		},
 	},
 	timeseries.SampleMin: {
 		fieldName:   "min",
 		selectField: func(point metricPoint) float64 { return point.Min },
-		sampleBucket: func(bucket []float64) float64 {
+		sampleBucket: func(bucket []float64, _ []int) float64 {
 			smallest := math.NaN()
 			for _, v := range bucket {
 				if math.IsNaN(v) {
@@ -90,7 +118,7 @@ var samplerMap = map[timeseries.SampleMethod]sampler{
 	timeseries.SampleMax: {
 		fieldName:   "max",
 		selectField: func(point metricPoint) float64 { return point.Max },
-		sampleBucket: func(bucket []float64) float64 {
+		sampleBucket: func(bucket []float64, _ []int) float64 {
 			largest := math.NaN()
 			for _, v := range bucket {
 				if math.IsNaN(v) {
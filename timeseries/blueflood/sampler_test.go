@@ -0,0 +1,64 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blueflood
+
+import (
+	"math"
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries"
+)
+
+func TestSamplePoints_WeightedMean(t *testing.T) {
+	a := assert.New(t)
+	timerange, err := api.NewTimerange(0, 2000, 1000)
+	a.CheckError(err)
+	for _, test := range []struct {
+		points  []metricPoint
+		values  []float64
+		samples []int
+	}{
+		{
+			// A rollup of 9 samples and a single raw sample share the first bucket.
+			points: []metricPoint{
+				{Timestamp: 0, Points: 9, Average: 10, Max: 20},
+				{Timestamp: 500, Points: 1, Average: 0, Max: 30},
+				{Timestamp: 1000, Points: 4, Average: 3, Max: 3},
+			},
+			values:  []float64{9, 3, math.NaN()},
+			samples: []int{10, 4, 0},
+		},
+		{
+			// Without counts, the averages are weighted equally.
+			points: []metricPoint{
+				{Timestamp: 0, Average: 10},
+				{Timestamp: 500, Average: 0},
+			},
+			values: []float64{5, math.NaN(), math.NaN()},
+		},
+	} {
+		values, samples := samplePoints(test.points, timerange, samplerMap[timeseries.SampleMean])
+		a.EqFloatArray(values, test.values, 1e-9)
+		a.Eq(samples, test.samples)
+	}
+
+	values, _ := samplePoints([]metricPoint{
+		{Timestamp: 0, Points: 9, Max: 20},
+		{Timestamp: 500, Points: 1, Max: 30},
+	}, timerange, samplerMap[timeseries.SampleMax])
+	a.EqFloat(values[0], 30, 0)
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blueflood
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/log"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/util"
+)
+
+// ScanSeries lists the series matching each of the configured scan patterns
+// in turn, using Blueflood's metric search. Blueflood doesn't paginate its
+// search, so each page holds the series of one pattern, however many there
+// are; the cursor is the index of the next pattern. Names which the
+// conversion rules can't map to tagged metrics are skipped.
+func (b *Blueflood) ScanSeries(request timeseries.ScanRequest) (timeseries.ScanResult, error) {
+	index := 0
+	if request.Cursor != "" {
+		parsed, err := strconv.Atoi(request.Cursor)
+		if err != nil || parsed < 0 {
+			return timeseries.ScanResult{}, fmt.Errorf("invalid scan cursor %q", request.Cursor)
+		}
+		index = parsed
+	}
+	if index >= len(b.config.ScanPatterns) {
+		return timeseries.ScanResult{}, nil
+	}
+
+	names, err := b.searchMetrics(b.config.ScanPatterns[index], request)
+	if err != nil {
+		return timeseries.ScanResult{}, err
+	}
+	result := timeseries.ScanResult{Metrics: make([]api.TaggedMetric, 0, len(names))}
+	for _, name := range names {
+		metric, err := b.config.GraphiteMetricConverter.ToTaggedName(util.GraphiteMetric(name))
+		if err != nil {
+			log.Debugf("Skipping %s while scanning Blueflood: %s", name, err.Error())
+			continue
+		}
+		result.Metrics = append(result.Metrics, metric)
+	}
+	if index+1 < len(b.config.ScanPatterns) {
+		result.Cursor = strconv.Itoa(index + 1)
+	}
+	return result, nil
+}
+
+type searchResult struct {
+	Metric string `json:"metric"`
+}
+
+// searchMetrics returns the graphite names matching the glob.
+func (b *Blueflood) searchMetrics(pattern string, request timeseries.ScanRequest) ([]string, error) {
+	baseURL, err := b.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	searchURL := fmt.Sprintf("%s/v2.0/%s/metrics/search?%s", baseURL, b.config.TenantID, url.Values{"query": {pattern}}.Encode())
+	httpRequest, err := http.NewRequest("GET", searchURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if request.Ctx != nil {
+		httpRequest = httpRequest.WithContext(request.Ctx)
+	}
+	response, err := b.config.HTTPClient.Do(httpRequest)
+	if err != nil {
+		return nil, fmt.Errorf("error searching Blueflood at URL %q: %s", searchURL, err.Error())
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the search of Blueflood at URL %q: %s", searchURL, err.Error())
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Blueflood at URL %q responded with status %d: %s", searchURL, response.StatusCode, body)
+	}
+	var results []searchResult
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("error unmarshaling the search of Blueflood at URL %q: %s", searchURL, err.Error())
+	}
+	names := make([]string, len(results))
+	for i := range results {
+		names[i] = results[i].Metric
+	}
+	return names, nil
+}
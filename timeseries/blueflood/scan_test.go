@@ -0,0 +1,62 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blueflood
+
+import (
+	"testing"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/util"
+)
+
+func TestBlueflood_ScanSeries(t *testing.T) {
+	a := assert.New(t)
+	client := mocks.NewFakeHTTPClient()
+	client.SetResponse("https://blueflood.url/v2.0/square/metrics/search?query=some.%2A.graphite", mocks.Response{
+		Body:       `[{"metric": "some.key.graphite"}, {"metric": "some.unconvertible.graphite"}]`,
+		StatusCode: 200,
+	})
+	client.SetResponse("https://blueflood.url/v2.0/square/metrics/search?query=other.%2A", mocks.Response{
+		Body:       `[{"metric": "other.key"}]`,
+		StatusCode: 200,
+	})
+	blueflood := NewBlueflood(Config{
+		BaseURL:      "https://blueflood.url",
+		TenantID:     "square",
+		ScanPatterns: []string{"some.*.graphite", "other.*"},
+		GraphiteMetricConverter: &mocks.FakeGraphiteConverter{MetricMap: map[util.GraphiteMetric]api.TaggedMetric{
+			"some.key.graphite": {MetricKey: "some.key", TagSet: api.TagSet{"tag": "value"}},
+			"other.key":         {MetricKey: "other", TagSet: api.TagSet{"tag": "key"}},
+		}},
+		HTTPClient: client,
+	}).(timeseries.ScanningStorageAPI)
+
+	first, err := blueflood.ScanSeries(timeseries.ScanRequest{})
+	a.CheckError(err)
+	a.Eq(first.Metrics, []api.TaggedMetric{{MetricKey: "some.key", TagSet: api.TagSet{"tag": "value"}}})
+	a.EqString(first.Cursor, "1")
+
+	second, err := blueflood.ScanSeries(timeseries.ScanRequest{Cursor: first.Cursor})
+	a.CheckError(err)
+	a.Eq(second.Metrics, []api.TaggedMetric{{MetricKey: "other", TagSet: api.TagSet{"tag": "key"}}})
+	a.EqString(second.Cursor, "")
+
+	if _, err := blueflood.ScanSeries(timeseries.ScanRequest{Cursor: "x"}); err == nil {
+		t.Errorf("expected an error for an invalid cursor")
+	}
+}
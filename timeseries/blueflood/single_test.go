@@ -128,8 +128,9 @@ func TestBluefloodHTTPQueriesSingle(t *testing.T) {
 		},
 	}
 	expected := api.Timeseries{
-		Values: []float64{5, 9, -72.13, 6, 4.5},
-		TagSet: api.TagSet{"tag": "value"},
+		Values:  []float64{5, 9, -72.13, 6, 4.5},
+		TagSet:  api.TagSet{"tag": "value"},
+		Samples: []int{1, 2, 1, 1, 1}, // two points share the second bucket
 	}
 	result, err := blueflood.FetchSingleTimeseries(request)
 	if err != nil {
@@ -254,8 +255,10 @@ func TestBluefloodHTTPQueriesMultiResolutionSingle(t *testing.T) {
 	}
 
 	values := make([]float64, 5+24*15+7+1)
+	samples := make([]int, len(values))
 	for i := 0; i < 5+24*15; i++ {
 		values[i] = float64(i-5) * float64(i-5)
+		samples[i] = 1
 	}
 	for i := 0; i < 7+1; i++ {
 		average := 0.0
@@ -263,11 +266,13 @@ func TestBluefloodHTTPQueriesMultiResolutionSingle(t *testing.T) {
 			average += float64(i*12+j) * float64(i*12+j)
 		}
 		values[i+5+24*15] = average / 12.0
+		samples[i+5+24*15] = 12 // the 5 minute rollups in the hour
 	}
 
 	expected := api.Timeseries{
-		Values: values,
-		TagSet: api.TagSet{"tag": "value"},
+		Values:  values,
+		TagSet:  api.TagSet{"tag": "value"},
+		Samples: samples,
 	}
 	result, err := blueflood.FetchSingleTimeseries(request)
 	if err != nil {
@@ -22,6 +22,7 @@ import (
 
 	"github.com/square/metrics/api"
 	"github.com/square/metrics/inspect"
+	"github.com/square/metrics/tdigest"
 )
 
 type StorageAPI interface {
@@ -32,11 +33,61 @@ type StorageAPI interface {
 	CheckHealthy() error
 }
 
+// An Aggregation names a simple aggregation across series which a backend may
+// be able to compute itself.
+type Aggregation string
+
+const (
+	AggregateSum  Aggregation = "sum"  // AggregateSum adds the values of each group at each time.
+	AggregateMean Aggregation = "mean" // AggregateMean averages the values of each group at each time, ignoring NaN.
+)
+
+// AggregatingStorageAPI is implemented by backends which can aggregate series
+// server-side, so that only one series per group is transferred. The result
+// must be the same as fetching the series and applying the corresponding
+// aggregate function with the same grouping.
+type AggregatingStorageAPI interface {
+	StorageAPI
+	// SupportsAggregation reports whether the aggregation can be pushed down.
+	SupportsAggregation(aggregation Aggregation) bool
+	FetchAggregatedTimeseries(request FetchAggregatedRequest) (api.SeriesList, error)
+}
+
+// ScanningStorageAPI is implemented by backends which can list the series
+// they hold, so that the metadata index can be checked against them.
+type ScanningStorageAPI interface {
+	StorageAPI
+	// ScanSeries returns a page of the series held by the backend, starting
+	// from the cursor of the previous page (or "" for the first).
+	ScanSeries(request ScanRequest) (ScanResult, error)
+}
+
+// SketchStorageAPI is implemented by backends which keep t-digests of the
+// raw values of series, so that their percentiles can be merged across
+// series and time. Fetched series carry the digests in their Sketches.
+type SketchStorageAPI interface {
+	StorageAPI
+	// AddSketch merges the digest into the series at the given time.
+	AddSketch(metric api.TaggedMetric, t time.Time, sketch *tdigest.Digest) error
+}
+
+type ScanRequest struct {
+	Cursor string          // where the previous page ended; empty for the first page
+	Limit  int             // the largest number of series wanted; backends may return more
+	Ctx    context.Context // cancels the scan
+}
+
+type ScanResult struct {
+	Metrics []api.TaggedMetric
+	Cursor  string // where the next page starts; empty after the last page
+}
+
 type RequestDetails struct {
 	SampleMethod SampleMethod    // up/downsampling behavior.
 	Timerange    api.Timerange   // time range to fetch data from.
 	Ctx          context.Context // context includes timeout details
 	Profiler     *inspect.Profiler
+	Labels       map[string]string // optional. Attribute the request to a workload (such as a tenant or dashboard) in the backend's logs
 }
 
 type FetchRequest struct {
@@ -49,6 +100,14 @@ type FetchMultipleRequest struct {
 	RequestDetails
 }
 
+type FetchAggregatedRequest struct {
+	Metrics     []api.TaggedMetric
+	Aggregation Aggregation
+	GroupBy     []string // the tags of the group-by (or collapse-by) clause
+	Collapses   bool     // whether GroupBy lists the tags to remove, rather than keep
+	RequestDetails
+}
+
 type ErrorCode int
 
 // FetchError can return a custom error code
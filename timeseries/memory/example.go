@@ -0,0 +1,97 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/square/metrics/api"
+)
+
+// noise is a deterministic pseudo-random value in [-1, 1) for the seed and
+// time, so that repeated fetches of a series agree with each other.
+func noise(seed string, t time.Time) float64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(seed))
+	var buffer [8]byte
+	minute := t.Unix() / 60
+	for i := range buffer {
+		buffer[i] = byte(minute >> (8 * uint(i)))
+	}
+	hash.Write(buffer[:])
+	return float64(hash.Sum64()%2000)/1000 - 1
+}
+
+// daily is a cycle peaking in the middle of the (UTC) day, ranging from 0 to 1.
+func daily(t time.Time) float64 {
+	seconds := float64(t.Unix() % (24 * 60 * 60))
+	return (1 - math.Cos(2*math.Pi*seconds/(24*60*60))) / 2
+}
+
+// AddExampleData fills the store with a small fleet of hosts reporting CPU,
+// memory and request metrics, for demonstrating the engine and its UI.
+func AddExampleData(s *Store) {
+	hosts := []struct {
+		name string
+		dc   string
+		load float64
+	}{
+		{"web1", "east", 1.0},
+		{"web2", "east", 1.2},
+		{"web3", "west", 0.8},
+		{"web4", "west", 1.5},
+	}
+	for _, host := range hosts {
+		host := host
+		tags := func(extra ...string) api.TagSet {
+			tagSet := api.TagSet{"host": host.name, "dc": host.dc, "app": "web"}
+			for i := 0; i+1 < len(extra); i += 2 {
+				tagSet[extra[i]] = extra[i+1]
+			}
+			return tagSet
+		}
+		seed := host.name
+		s.AddGenerated(api.TaggedMetric{MetricKey: "cpu.user", TagSet: tags()}, func(t time.Time) float64 {
+			return math.Min(100, 10+50*host.load*daily(t)+5*noise(seed+"cpu.user", t))
+		})
+		s.AddGenerated(api.TaggedMetric{MetricKey: "cpu.system", TagSet: tags()}, func(t time.Time) float64 {
+			return 5 + 10*host.load*daily(t) + 2*noise(seed+"cpu.system", t)
+		})
+		s.AddGenerated(api.TaggedMetric{MetricKey: "memory.used", TagSet: tags()}, func(t time.Time) float64 {
+			// Memory leaks slowly, until the process is restarted every six hours.
+			sinceRestart := float64(t.Unix()%(6*60*60)) / (6 * 60 * 60)
+			return (2 + 4*sinceRestart*host.load) * (1 << 30)
+		})
+		for _, status := range []string{"200", "500"} {
+			status := status
+			share := 0.98
+			if status == "500" {
+				share = 0.02
+			}
+			s.AddGenerated(api.TaggedMetric{MetricKey: "requests.rate", TagSet: tags("status", status)}, func(t time.Time) float64 {
+				return share * (100 + 400*host.load*daily(t)) * (1 + 0.1*noise(seed+"requests"+status, t))
+			})
+		}
+		s.AddGenerated(api.TaggedMetric{MetricKey: "requests.latency", TagSet: tags()}, func(t time.Time) float64 {
+			value := 20 + 30*host.load*daily(t) + 5*noise(seed+"latency", t)
+			if host.name == "web4" && noise(seed+"spike", t) > 0.95 {
+				value *= 10 // occasional latency spikes
+			}
+			return value
+		})
+	}
+}
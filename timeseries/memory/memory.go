@@ -0,0 +1,391 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memory holds timeseries and their metadata in memory. It serves as
+// both the storage and the metadata backend for trying out the engine without
+// any infrastructure.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/function/builtin/aggregate"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/timeseries"
+	"github.com/square/metrics/util"
+)
+
+// A Generator computes the value of a series at any time. It returns NaN where
+// the series has no data.
+type Generator func(t time.Time) float64
+
+// Store is an in-memory storage and metadata backend. Each series is produced
+// by a generator, so that data is available for any timerange.
+type Store struct {
+	resolution time.Duration // the finest resolution which may be fetched
+	clock      util.Clock    // points after the current time are missing
+
+	mutex  sync.RWMutex
+	series map[api.MetricKey]map[string]storedSeries // metric => serialized tagset => series
+}
+
+type storedSeries struct {
+	tagSet    api.TagSet
+	generator Generator        // nil for series with no generated data
+	sketches  map[int64][]byte // encoded t-digests, by the start (in milliseconds) of their interval at the store's resolution
+}
+
+// hasData reports whether the series is generated or has sketches.
+func (s storedSeries) hasData() bool {
+	return s.generator != nil || len(s.sketches) != 0
+}
+
+var _ timeseries.AggregatingStorageAPI = (*Store)(nil)
+var _ timeseries.ScanningStorageAPI = (*Store)(nil)
+var _ timeseries.SketchStorageAPI = (*Store)(nil)
+var _ metadata.MetricAPI = (*Store)(nil)
+var _ metadata.MetricUpdateAPI = (*Store)(nil)
+
+// NewStore creates an empty store whose finest resolution is the one given.
+func NewStore(resolution time.Duration) *Store {
+	return &Store{
+		resolution: resolution,
+		clock:      util.RealClock{},
+		series:     map[api.MetricKey]map[string]storedSeries{},
+	}
+}
+
+// AddGenerated adds a series whose values are computed by the generator,
+// replacing any existing series for the metric.
+func (s *Store) AddGenerated(metric api.TaggedMetric, generator Generator) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.series[metric.MetricKey] == nil {
+		s.series[metric.MetricKey] = map[string]storedSeries{}
+	}
+	s.series[metric.MetricKey][metric.TagSet.Serialize()] = storedSeries{tagSet: metric.TagSet.Intern(), generator: generator}
+}
+
+// AddSketch merges the digest into the series at the given time, adding the
+// series if it doesn't exist. The digests of each interval of the store's
+// resolution are merged, and kept encoded.
+func (s *Store) AddSketch(metric api.TaggedMetric, t time.Time, sketch *tdigest.Digest) error {
+	resolution := s.resolution.Nanoseconds() / 1e6
+	bucket := t.UnixNano() / 1e6
+	bucket -= bucket % resolution
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.series[metric.MetricKey] == nil {
+		s.series[metric.MetricKey] = map[string]storedSeries{}
+	}
+	key := metric.TagSet.Serialize()
+	series, ok := s.series[metric.MetricKey][key]
+	if !ok {
+		series = storedSeries{tagSet: metric.TagSet.Intern()}
+	}
+	if series.sketches == nil {
+		series.sketches = map[int64][]byte{}
+	}
+	merged := tdigest.New(sketch.Compression())
+	if encoded, ok := series.sketches[bucket]; ok {
+		stored, err := tdigest.Decode(encoded)
+		if err != nil {
+			return err
+		}
+		merged = stored
+	}
+	merged.Merge(sketch)
+	series.sketches[bucket] = merged.Encode()
+	s.series[metric.MetricKey][key] = series
+	return nil
+}
+
+// AddMetric adds the metric with no data, unless it already exists.
+func (s *Store) AddMetric(ctx context.Context, metric api.TaggedMetric, context metadata.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.series[metric.MetricKey] == nil {
+		s.series[metric.MetricKey] = map[string]storedSeries{}
+	}
+	key := metric.TagSet.Serialize()
+	if _, ok := s.series[metric.MetricKey][key]; !ok {
+		s.series[metric.MetricKey][key] = storedSeries{tagSet: metric.TagSet.Intern()}
+	}
+	return nil
+}
+
+// AddMetrics adds each of the metrics.
+func (s *Store) AddMetrics(ctx context.Context, metrics []api.TaggedMetric, context metadata.Context) error {
+	for _, metric := range metrics {
+		if err := s.AddMetric(ctx, metric, context); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAllTags returns the tagsets of the metric.
+func (s *Store) GetAllTags(ctx context.Context, metricKey api.MetricKey, context metadata.Context) ([]api.TagSet, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	byTags, ok := s.series[metricKey]
+	if !ok {
+		return nil, metadata.NewNoSuchMetricError(string(metricKey))
+	}
+	result := make([]api.TagSet, 0, len(byTags))
+	for _, series := range byTags {
+		result = append(result, series.tagSet)
+	}
+	sort.Sort(tagSetsBySerialization(result))
+	return result, nil
+}
+
+// GetAllMetrics returns every metric in the store.
+func (s *Store) GetAllMetrics(ctx context.Context, context metadata.Context) ([]api.MetricKey, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := make([]api.MetricKey, 0, len(s.series))
+	for metric := range s.series {
+		result = append(result, metric)
+	}
+	sort.Sort(api.MetricKeys(result))
+	return result, nil
+}
+
+// GetMetricsForTag returns the metrics having a series with the tag.
+func (s *Store) GetMetricsForTag(ctx context.Context, tagKey, tagValue string, context metadata.Context) ([]api.MetricKey, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := []api.MetricKey{}
+	for metric, byTags := range s.series {
+		for _, series := range byTags {
+			if value, ok := series.tagSet[tagKey]; ok && value == tagValue {
+				result = append(result, metric)
+				break
+			}
+		}
+	}
+	sort.Sort(api.MetricKeys(result))
+	return result, nil
+}
+
+// CheckHealthy always succeeds.
+func (s *Store) CheckHealthy() error {
+	return nil
+}
+
+// ScanSeries lists the series which have data, ordered by metric and then
+// by tags. The cursor is the number of series already listed.
+func (s *Store) ScanSeries(request timeseries.ScanRequest) (timeseries.ScanResult, error) {
+	offset := 0
+	if request.Cursor != "" {
+		parsed, err := strconv.Atoi(request.Cursor)
+		if err != nil || parsed < 0 {
+			return timeseries.ScanResult{}, fmt.Errorf("invalid scan cursor %q", request.Cursor)
+		}
+		offset = parsed
+	}
+	s.mutex.RLock()
+	all := []api.TaggedMetric{}
+	for metric, byTags := range s.series {
+		for _, series := range byTags {
+			if series.hasData() {
+				all = append(all, api.TaggedMetric{MetricKey: metric, TagSet: series.tagSet})
+			}
+		}
+	}
+	s.mutex.RUnlock()
+	sort.Sort(metricsByName(all))
+	if offset > len(all) {
+		offset = len(all)
+	}
+	end := len(all)
+	if request.Limit > 0 && offset+request.Limit < end {
+		end = offset + request.Limit
+	}
+	result := timeseries.ScanResult{Metrics: all[offset:end]}
+	if end < len(all) {
+		result.Cursor = strconv.Itoa(end)
+	}
+	return result, nil
+}
+
+// ChooseResolution picks the requested resolution, unless it is finer than
+// the resolution of the store or the lower bound.
+func (s *Store) ChooseResolution(requested api.Timerange, lowerBound time.Duration) (time.Duration, error) {
+	resolution := requested.Resolution()
+	if resolution < s.resolution {
+		resolution = s.resolution
+	}
+	if resolution < lowerBound {
+		resolution = lowerBound
+	}
+	return resolution, nil
+}
+
+// FetchSingleTimeseries computes the series at each point of the timerange.
+func (s *Store) FetchSingleTimeseries(request timeseries.FetchRequest) (api.Timeseries, error) {
+	defer request.Profiler.RecordWithDescription("Memory FetchSingleTimeseries", request.Metric.String())()
+	s.mutex.RLock()
+	series, ok := s.series[request.Metric.MetricKey][request.Metric.TagSet.Serialize()]
+	s.mutex.RUnlock()
+	if !ok {
+		return api.Timeseries{}, timeseries.Error{
+			Metric:  request.Metric,
+			Code:    timeseries.InvalidSeriesError,
+			Message: "no such series in memory",
+		}
+	}
+	if len(series.sketches) != 0 {
+		return s.fetchSketches(request, series)
+	}
+	now := s.clock.Now()
+	values := make([]float64, request.Timerange.Slots())
+	for i := range values {
+		t := request.Timerange.TimeOfIndex(i)
+		if series.generator == nil || t.After(now) {
+			values[i] = math.NaN()
+			continue
+		}
+		values[i] = series.generator(t)
+	}
+	return api.Timeseries{Values: values, TagSet: request.Metric.TagSet}, nil
+}
+
+// fetchSketches merges the digests of the intervals covered by each point,
+// whose value is the mean of the merged digest.
+func (s *Store) fetchSketches(request timeseries.FetchRequest, series storedSeries) (api.Timeseries, error) {
+	result := api.Timeseries{
+		Values:   make([]float64, request.Timerange.Slots()),
+		TagSet:   request.Metric.TagSet,
+		Samples:  make([]int, request.Timerange.Slots()),
+		Sketches: make([]*tdigest.Digest, request.Timerange.Slots()),
+	}
+	resolution := s.resolution.Nanoseconds() / 1e6
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for i := range result.Values {
+		start := request.Timerange.TimeOfIndex(i).UnixNano() / 1e6
+		end := start + request.Timerange.ResolutionMillis()
+		first := start - start%resolution
+		if first < start {
+			first += resolution
+		}
+		var merged *tdigest.Digest
+		for bucket := first; bucket < end; bucket += resolution {
+			encoded, ok := series.sketches[bucket]
+			if !ok {
+				continue
+			}
+			sketch, err := tdigest.Decode(encoded)
+			if err != nil {
+				return api.Timeseries{}, timeseries.Error{
+					Metric:  request.Metric,
+					Code:    timeseries.InvalidSeriesError,
+					Message: err.Error(),
+				}
+			}
+			if merged == nil {
+				merged = sketch
+				continue
+			}
+			merged.Merge(sketch)
+		}
+		if merged == nil {
+			result.Values[i] = math.NaN()
+			continue
+		}
+		merged.Compress()
+		result.Values[i] = merged.Mean()
+		result.Samples[i] = int(merged.Count())
+		result.Sketches[i] = merged
+	}
+	return result, nil
+}
+
+// FetchMultipleTimeseries fetches each of the requested series.
+func (s *Store) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	requests := request.ToSingle()
+	result := api.SeriesList{Series: make([]api.Timeseries, len(requests))}
+	for i := range requests {
+		series, err := s.FetchSingleTimeseries(requests[i])
+		if err != nil {
+			return api.SeriesList{}, err
+		}
+		result.Series[i] = series
+	}
+	return result, nil
+}
+
+// SupportsAggregation reports that sums and means can be pushed down.
+func (s *Store) SupportsAggregation(aggregation timeseries.Aggregation) bool {
+	return aggregation == timeseries.AggregateSum || aggregation == timeseries.AggregateMean
+}
+
+// FetchAggregatedTimeseries fetches the requested series and aggregates them.
+func (s *Store) FetchAggregatedTimeseries(request timeseries.FetchAggregatedRequest) (api.SeriesList, error) {
+	defer request.Profiler.RecordWithDescription("Memory FetchAggregatedTimeseries", string(request.Aggregation))()
+	aggregator := aggregate.Sum
+	switch request.Aggregation {
+	case timeseries.AggregateSum:
+	case timeseries.AggregateMean:
+		aggregator = aggregate.Mean
+	default:
+		return api.SeriesList{}, timeseries.Error{Code: timeseries.Unsupported, Message: "cannot aggregate by " + string(request.Aggregation)}
+	}
+	list, err := s.FetchMultipleTimeseries(timeseries.FetchMultipleRequest{Metrics: request.Metrics, RequestDetails: request.RequestDetails})
+	if err != nil {
+		return api.SeriesList{}, err
+	}
+	return aggregate.By(list, aggregator, request.GroupBy, request.Collapses), nil
+}
+
+type tagSetsBySerialization []api.TagSet
+
+func (t tagSetsBySerialization) Len() int {
+	return len(t)
+}
+
+func (t tagSetsBySerialization) Less(i, j int) bool {
+	return t[i].Serialize() < t[j].Serialize()
+}
+
+func (t tagSetsBySerialization) Swap(i, j int) {
+	t[i], t[j] = t[j], t[i]
+}
+
+type metricsByName []api.TaggedMetric
+
+func (m metricsByName) Len() int {
+	return len(m)
+}
+
+func (m metricsByName) Less(i, j int) bool {
+	if m[i].MetricKey != m[j].MetricKey {
+		return m[i].MetricKey < m[j].MetricKey
+	}
+	return m[i].TagSet.Serialize() < m[j].TagSet.Serialize()
+}
+
+func (m metricsByName) Swap(i, j int) {
+	m[i], m[j] = m[j], m[i]
+}
@@ -0,0 +1,153 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/metric_metadata"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/testing_support/mocks"
+	"github.com/square/metrics/timeseries"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	a := assert.New(t)
+	store := NewStore(30 * time.Second)
+	store.clock = mocks.NewTestClock(time.Unix(120, 0))
+	store.AddGenerated(api.TaggedMetric{MetricKey: "linear", TagSet: api.TagSet{"host": "a"}}, func(t time.Time) float64 {
+		return float64(t.Unix())
+	})
+	a.CheckError(store.AddMetric(ctx, api.TaggedMetric{MetricKey: "linear", TagSet: api.TagSet{"host": "b"}}, metadata.Context{}))
+	a.CheckError(store.AddMetric(ctx, api.TaggedMetric{MetricKey: "other", TagSet: api.TagSet{"host": "a"}}, metadata.Context{}))
+
+	metrics, err := store.GetAllMetrics(ctx, metadata.Context{})
+	a.CheckError(err)
+	a.Eq(metrics, []api.MetricKey{"linear", "other"})
+	tagSets, err := store.GetAllTags(ctx, "linear", metadata.Context{})
+	a.CheckError(err)
+	a.Eq(tagSets, []api.TagSet{{"host": "a"}, {"host": "b"}})
+	forTag, err := store.GetMetricsForTag(ctx, "host", "b", metadata.Context{})
+	a.CheckError(err)
+	a.Eq(forTag, []api.MetricKey{"linear"})
+	_, err = store.GetAllTags(ctx, "missing", metadata.Context{})
+	if _, ok := err.(metadata.NoSuchMetricError); !ok {
+		a.Errorf("expected a NoSuchMetricError but got %+v", err)
+	}
+
+	timerange, err := api.NewTimerange(0, 180000, 60000)
+	a.CheckError(err)
+	resolution, err := store.ChooseResolution(timerange, 0)
+	a.CheckError(err)
+	a.EqInt(int(resolution/time.Second), 60)
+	resolution, err = store.ChooseResolution(timerange, 5*time.Minute)
+	a.CheckError(err)
+	a.EqInt(int(resolution/time.Second), 300)
+
+	list, err := store.FetchMultipleTimeseries(timeseries.FetchMultipleRequest{
+		Metrics: []api.TaggedMetric{
+			{MetricKey: "linear", TagSet: api.TagSet{"host": "a"}},
+			{MetricKey: "linear", TagSet: api.TagSet{"host": "b"}},
+		},
+		RequestDetails: timeseries.RequestDetails{Timerange: timerange},
+	})
+	a.CheckError(err)
+	nan := math.NaN()
+	a.EqFloatArray(list.Series[0].Values, []float64{0, 60, 120, nan}, 1e-9)
+	a.EqFloatArray(list.Series[1].Values, []float64{nan, nan, nan, nan}, 1e-9)
+
+	_, err = store.FetchSingleTimeseries(timeseries.FetchRequest{
+		Metric:         api.TaggedMetric{MetricKey: "linear", TagSet: api.TagSet{"host": "c"}},
+		RequestDetails: timeseries.RequestDetails{Timerange: timerange},
+	})
+	if err == nil {
+		a.Errorf("expected an error fetching a missing series")
+	}
+}
+
+func TestStore_Sketches(t *testing.T) {
+	a := assert.New(t)
+	store := NewStore(30 * time.Second)
+	metric := api.TaggedMetric{MetricKey: "latency", TagSet: api.TagSet{"host": "a"}}
+	// Two digests in the first interval are merged, and the second interval
+	// has one of its own.
+	for i, values := range [][]float64{{1, 2, 3}, {4, 5}, {100}} {
+		sketch := tdigest.New(0)
+		for _, value := range values {
+			sketch.Add(value)
+		}
+		at := time.Unix(0, 0).Add(time.Duration(i/2) * 30 * time.Second).Add(time.Duration(i) * time.Second)
+		a.CheckError(store.AddSketch(metric, at, sketch))
+	}
+	tagSets, err := store.GetAllTags(context.Background(), "latency", metadata.Context{})
+	a.CheckError(err)
+	a.Eq(tagSets, []api.TagSet{{"host": "a"}})
+
+	fetch := func(resolution int64) api.Timeseries {
+		timerange, err := api.NewTimerange(0, 90000, resolution)
+		a.CheckError(err)
+		series, err := store.FetchSingleTimeseries(timeseries.FetchRequest{
+			Metric:         metric,
+			RequestDetails: timeseries.RequestDetails{Timerange: timerange},
+		})
+		a.CheckError(err)
+		return series
+	}
+	fine := fetch(30000)
+	a.EqFloatArray(fine.Values, []float64{3, 100, math.NaN(), math.NaN()}, 1e-9)
+	a.Eq(fine.Samples, []int{5, 1, 0, 0})
+	a.EqFloat(fine.Sketches[0].Quantile(1), 5, 0)
+	a.Eq(fine.Sketches[2] == nil, true)
+
+	// At a coarser resolution, the digests of the intervals are merged.
+	coarse := fetch(90000)
+	a.EqFloatArray(coarse.Values, []float64{115.0 / 6, math.NaN()}, 1e-9)
+	a.Eq(coarse.Samples, []int{6, 0})
+	a.EqFloat(coarse.Sketches[0].Quantile(1), 100, 0)
+	a.EqFloat(coarse.Sketches[0].Quantile(0), 1, 0)
+}
+
+func TestExampleData(t *testing.T) {
+	a := assert.New(t)
+	store := NewStore(30 * time.Second)
+	AddExampleData(store)
+	metrics, err := store.GetAllMetrics(context.Background(), metadata.Context{})
+	a.CheckError(err)
+	a.Eq(metrics, []api.MetricKey{"cpu.system", "cpu.user", "memory.used", "requests.latency", "requests.rate"})
+
+	now := time.Now()
+	timerange, err := api.NewSnappedTimerange(now.Add(-time.Hour).Unix()*1000, now.Add(-time.Minute).Unix()*1000, 60000)
+	a.CheckError(err)
+	request := timeseries.FetchRequest{
+		Metric:         api.TaggedMetric{MetricKey: "cpu.user", TagSet: api.TagSet{"host": "web1", "dc": "east", "app": "web"}},
+		RequestDetails: timeseries.RequestDetails{Timerange: timerange},
+	}
+	first, err := store.FetchSingleTimeseries(request)
+	a.CheckError(err)
+	second, err := store.FetchSingleTimeseries(request)
+	a.CheckError(err)
+	a.EqFloatArray(first.Values, second.Values, 0)
+	for _, value := range first.Values {
+		if math.IsNaN(value) || value < 0 || value > 100 {
+			a.Errorf("unexpected CPU value %f", value)
+		}
+	}
+}
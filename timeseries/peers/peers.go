@@ -0,0 +1,526 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package peers partitions a fetch cache across the query nodes, so that
+// each series is fetched from the storage by only one node (its owner on a
+// consistent-hash ring) and the others ask that node for it. This reduces
+// duplicate fetches across a fleet without a shared cache such as Redis.
+package peers
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/log"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/timeseries"
+)
+
+// FetchPath is where a node serves the series it owns to its peers.
+const FetchPath = "/peer/fetch"
+
+// Config lists the query nodes sharing the cache.
+type Config struct {
+	Self           string   `yaml:"self"`            // the URL at which the other nodes reach this one, such as http://query-1:9007
+	Peers          []string `yaml:"peers"`           // the URLs of every node, including this one; the cache is off if empty
+	Replicas       int      `yaml:"replicas"`        // the points on the hash ring for each node; 50 if zero
+	TTLSeconds     int      `yaml:"ttl_seconds"`     // how long fetched series are kept; 60 if zero
+	MaxEntries     int      `yaml:"max_entries"`     // the most series kept by each node; 10000 if zero
+	TimeoutSeconds int      `yaml:"timeout_seconds"` // the timeout of requests to peers; 10 if zero
+}
+
+// Stats counts the use of the cache by this node.
+type Stats struct {
+	Entries     int `json:"entries"`      // the series kept by this node
+	Hits        int `json:"hits"`         // fetches of owned series served from the cache
+	Misses      int `json:"misses"`       // fetches of owned series made to the storage
+	PeerFetches int `json:"peer_fetches"` // series requested from their owners
+	PeerErrors  int `json:"peer_errors"`  // requests to peers which failed, so were fetched from the storage instead
+}
+
+// Cache is a StorageAPI which caches the series owned by this node, and asks
+// the other nodes for theirs. The samples and sketches of series are kept and
+// shared along with their values.
+type Cache struct {
+	storage    timeseries.StorageAPI
+	ring       *Ring
+	self       string
+	client     *http.Client
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // of *cachedSeries, the most recently used first
+	stats   Stats
+}
+
+type cachedSeries struct {
+	key    string
+	series api.Timeseries
+	stored time.Time
+}
+
+var _ timeseries.StorageAPI = (*Cache)(nil)
+
+// NewCache wraps the storage, or returns nil if no peers are configured.
+func NewCache(storage timeseries.StorageAPI, config Config) (*Cache, error) {
+	if len(config.Peers) == 0 {
+		return nil, nil
+	}
+	self := strings.TrimSuffix(config.Self, "/")
+	peers := make([]string, len(config.Peers))
+	found := false
+	for i, peer := range config.Peers {
+		peers[i] = strings.TrimSuffix(peer, "/")
+		found = found || peers[i] == self
+	}
+	if !found {
+		return nil, fmt.Errorf("the peers of the fetch cache must include this node (%q)", config.Self)
+	}
+	if config.Replicas <= 0 {
+		config.Replicas = 50
+	}
+	if config.TTLSeconds <= 0 {
+		config.TTLSeconds = 60
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 10000
+	}
+	if config.TimeoutSeconds <= 0 {
+		config.TimeoutSeconds = 10
+	}
+	return &Cache{
+		storage:    storage,
+		ring:       NewRing(config.Replicas, peers...),
+		self:       self,
+		client:     &http.Client{Timeout: time.Duration(config.TimeoutSeconds) * time.Second},
+		ttl:        time.Duration(config.TTLSeconds) * time.Second,
+		maxEntries: config.MaxEntries,
+		now:        time.Now,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}, nil
+}
+
+// Stats describes the use of the cache.
+func (c *Cache) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+// ChooseResolution defers to the underlying StorageAPI.
+func (c *Cache) ChooseResolution(requested api.Timerange, lowerBound time.Duration) (time.Duration, error) {
+	return c.storage.ChooseResolution(requested, lowerBound)
+}
+
+// CheckHealthy defers to the underlying StorageAPI.
+func (c *Cache) CheckHealthy() error {
+	return c.storage.CheckHealthy()
+}
+
+// FetchSingleTimeseries fetches the series from its owner.
+func (c *Cache) FetchSingleTimeseries(request timeseries.FetchRequest) (api.Timeseries, error) {
+	list, err := c.FetchMultipleTimeseries(timeseries.FetchMultipleRequest{
+		Metrics:        []api.TaggedMetric{request.Metric},
+		RequestDetails: request.RequestDetails,
+	})
+	if err != nil {
+		return api.Timeseries{}, err
+	}
+	return list.Series[0], nil
+}
+
+// FetchMultipleTimeseries fetches each series from its owner, making one
+// request to each node. The series are returned in the order requested.
+func (c *Cache) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	byOwner := map[string][]int{}
+	for i, metric := range request.Metrics {
+		owner := c.ring.Owner(cacheKey(metric, request.RequestDetails))
+		byOwner[owner] = append(byOwner[owner], i)
+	}
+	series := make([]api.Timeseries, len(request.Metrics))
+	errs := make(chan error, len(byOwner))
+	for owner, indices := range byOwner {
+		go func(owner string, indices []int) {
+			metrics := make([]api.TaggedMetric, len(indices))
+			for i, index := range indices {
+				metrics[i] = request.Metrics[index]
+			}
+			fetched, err := c.fetchFrom(owner, metrics, request.RequestDetails)
+			if err == nil {
+				for i, index := range indices {
+					series[index] = fetched[i]
+				}
+			}
+			errs <- err
+		}(owner, indices)
+	}
+	var firstErr error
+	for range byOwner {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return api.SeriesList{}, firstErr
+	}
+	return api.SeriesList{Series: series}, nil
+}
+
+// fetchFrom fetches the series from their owner. If the owner can't be
+// reached, they're fetched from the storage instead (and not cached).
+func (c *Cache) fetchFrom(owner string, metrics []api.TaggedMetric, details timeseries.RequestDetails) ([]api.Timeseries, error) {
+	if owner == c.self {
+		return c.fetchOwned(metrics, details)
+	}
+	if details.Profiler != nil {
+		defer details.Profiler.Record("peers.FetchFromPeer")()
+	}
+	c.mutex.Lock()
+	c.stats.PeerFetches += len(metrics)
+	c.mutex.Unlock()
+	series, err := c.fetchFromPeer(owner, metrics, details)
+	if _, unreachable := err.(peerError); !unreachable {
+		return series, err
+	}
+	log.Warningf("Fetching %d series from the storage instead of %s: %s", len(metrics), owner, err.Error())
+	c.mutex.Lock()
+	c.stats.PeerErrors++
+	c.mutex.Unlock()
+	list, err := c.storage.FetchMultipleTimeseries(timeseries.FetchMultipleRequest{Metrics: metrics, RequestDetails: details})
+	if err != nil {
+		return nil, err
+	}
+	return list.Series, nil
+}
+
+// fetchOwned serves the series from the cache, fetching those missing from
+// the storage together.
+func (c *Cache) fetchOwned(metrics []api.TaggedMetric, details timeseries.RequestDetails) ([]api.Timeseries, error) {
+	series := make([]api.Timeseries, len(metrics))
+	keys := make([]string, len(metrics))
+	missing := []int{}
+	for i, metric := range metrics {
+		keys[i] = cacheKey(metric, details)
+		cached, ok := c.get(keys[i])
+		if !ok {
+			missing = append(missing, i)
+			continue
+		}
+		series[i] = cached
+	}
+	if len(missing) == 0 {
+		return series, nil
+	}
+	request := timeseries.FetchMultipleRequest{
+		Metrics:        make([]api.TaggedMetric, len(missing)),
+		RequestDetails: details,
+	}
+	for i, index := range missing {
+		request.Metrics[i] = metrics[index]
+	}
+	fetched, err := c.storage.FetchMultipleTimeseries(request)
+	if err != nil {
+		return nil, err
+	}
+	if len(fetched.Series) != len(missing) {
+		return nil, fmt.Errorf("fetched %d series for %d metrics", len(fetched.Series), len(missing))
+	}
+	for i, index := range missing {
+		series[index] = fetched.Series[i]
+		c.store(keys[index], fetched.Series[i])
+	}
+	return series, nil
+}
+
+// cacheKey identifies a fetched series.
+func cacheKey(metric api.TaggedMetric, details timeseries.RequestDetails) string {
+	return fmt.Sprintf("%s{%s} %d %d %d %d", metric.MetricKey, metric.TagSet.Serialize(),
+		details.Timerange.StartMillis(), details.Timerange.EndMillis(), details.Timerange.ResolutionMillis(), details.SampleMethod)
+}
+
+// get returns a copy of the series cached under the key, unless it has expired.
+func (c *Cache) get(key string) (api.Timeseries, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[key]
+	if ok {
+		entry := element.Value.(*cachedSeries)
+		if c.now().Sub(entry.stored) < c.ttl {
+			c.stats.Hits++
+			c.order.MoveToFront(element)
+			return copySeries(entry.series), true
+		}
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+	c.stats.Misses++
+	return api.Timeseries{}, false
+}
+
+// store caches a copy of the series, dropping the least recently used one if
+// the cache is full.
+func (c *Cache) store(key string, series api.Timeseries) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry := &cachedSeries{key: key, series: copySeries(series), stored: c.now()}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedSeries).key)
+	}
+}
+
+// copySeries copies the values and samples of the series. Its sketches are
+// shared, since they're never changed.
+func copySeries(series api.Timeseries) api.Timeseries {
+	copied := api.Timeseries{
+		TagSet: series.TagSet,
+		Values: append([]float64(nil), series.Values...),
+	}
+	if series.Samples != nil {
+		copied.Samples = append([]int(nil), series.Samples...)
+	}
+	if series.Sketches != nil {
+		copied.Sketches = append([]*tdigest.Digest(nil), series.Sketches...)
+	}
+	return copied
+}
+
+// peerError is returned when a peer can't be reached or fails, as opposed to
+// reporting that its storage failed to fetch the series.
+type peerError struct {
+	message string
+}
+
+func (err peerError) Error() string {
+	return err.message
+}
+
+// fetchRequest asks a node for the series it owns.
+type fetchRequest struct {
+	Metrics      []wireMetric            `json:"metrics"`
+	Start        int64                   `json:"start"`
+	End          int64                   `json:"end"`
+	Resolution   int64                   `json:"resolution"`
+	SampleMethod timeseries.SampleMethod `json:"sample_method"`
+	Labels       map[string]string       `json:"labels,omitempty"`
+}
+
+type wireMetric struct {
+	MetricKey api.MetricKey `json:"metric"`
+	TagSet    api.TagSet    `json:"tagset"`
+}
+
+// fetchResponse holds either the series, or the error fetching them.
+type fetchResponse struct {
+	Series  []wireSeries         `json:"series,omitempty"`
+	Message string               `json:"message,omitempty"`
+	Code    timeseries.ErrorCode `json:"code,omitempty"`   // of a timeseries.Error
+	Metric  *wireMetric          `json:"metric,omitempty"` // of a timeseries.Error
+}
+
+// wireSeries encodes the missing values of a series as null, and each of its
+// sketches with tdigest's encoding (or null, where there is none).
+type wireSeries struct {
+	TagSet   api.TagSet `json:"tagset"`
+	Values   []*float64 `json:"values"`
+	Samples  []int      `json:"samples,omitempty"`
+	Sketches [][]byte   `json:"sketches,omitempty"`
+}
+
+func encodeSeries(series api.Timeseries) wireSeries {
+	values := make([]*float64, len(series.Values))
+	for i := range series.Values {
+		if !math.IsNaN(series.Values[i]) && !math.IsInf(series.Values[i], 0) {
+			values[i] = &series.Values[i]
+		}
+	}
+	wire := wireSeries{TagSet: series.TagSet, Values: values, Samples: series.Samples}
+	if series.Sketches != nil {
+		wire.Sketches = make([][]byte, len(series.Sketches))
+		for i, sketch := range series.Sketches {
+			if sketch != nil {
+				wire.Sketches[i] = sketch.Encode()
+			}
+		}
+	}
+	return wire
+}
+
+func decodeSeries(wire wireSeries) (api.Timeseries, error) {
+	values := make([]float64, len(wire.Values))
+	for i, value := range wire.Values {
+		values[i] = math.NaN()
+		if value != nil {
+			values[i] = *value
+		}
+	}
+	series := api.Timeseries{TagSet: wire.TagSet, Values: values, Samples: wire.Samples}
+	if wire.Sketches != nil {
+		series.Sketches = make([]*tdigest.Digest, len(wire.Sketches))
+		for i, encoded := range wire.Sketches {
+			if encoded == nil {
+				continue
+			}
+			sketch, err := tdigest.Decode(encoded)
+			if err != nil {
+				return api.Timeseries{}, err
+			}
+			series.Sketches[i] = sketch
+		}
+	}
+	return series, nil
+}
+
+func (c *Cache) fetchFromPeer(peer string, metrics []api.TaggedMetric, details timeseries.RequestDetails) ([]api.Timeseries, error) {
+	body := fetchRequest{
+		Metrics:      make([]wireMetric, len(metrics)),
+		Start:        details.Timerange.StartMillis(),
+		End:          details.Timerange.EndMillis(),
+		Resolution:   details.Timerange.ResolutionMillis(),
+		SampleMethod: details.SampleMethod,
+		Labels:       details.Labels,
+	}
+	for i, metric := range metrics {
+		body.Metrics[i] = wireMetric{MetricKey: metric.MetricKey, TagSet: metric.TagSet}
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest("POST", peer+FetchPath, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, peerError{err.Error()}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if details.Ctx != nil {
+		request = request.WithContext(details.Ctx)
+	}
+	response, err := c.client.Do(request)
+	if err != nil {
+		if details.Ctx != nil && details.Ctx.Err() != nil {
+			return nil, details.Ctx.Err()
+		}
+		return nil, peerError{err.Error()}
+	}
+	defer response.Body.Close()
+	var decoded fetchResponse
+	if err := json.NewDecoder(response.Body).Decode(&decoded); err != nil {
+		return nil, peerError{fmt.Sprintf("invalid response from %s (status %d): %s", peer, response.StatusCode, err.Error())}
+	}
+	switch {
+	case response.StatusCode == http.StatusOK && len(decoded.Series) == len(metrics):
+	case response.StatusCode == http.StatusOK:
+		return nil, peerError{fmt.Sprintf("%s returned %d series for %d metrics", peer, len(decoded.Series), len(metrics))}
+	case decoded.Code != 0 && decoded.Metric != nil:
+		return nil, timeseries.Error{
+			Metric:  api.TaggedMetric{MetricKey: decoded.Metric.MetricKey, TagSet: decoded.Metric.TagSet},
+			Code:    decoded.Code,
+			Message: decoded.Message,
+		}
+	case response.StatusCode == http.StatusUnprocessableEntity:
+		return nil, errors.New(decoded.Message)
+	default:
+		return nil, peerError{fmt.Sprintf("%s failed (status %d): %s", peer, response.StatusCode, decoded.Message)}
+	}
+	series := make([]api.Timeseries, len(decoded.Series))
+	for i, wire := range decoded.Series {
+		if series[i], err = decodeSeries(wire); err != nil {
+			return nil, peerError{fmt.Sprintf("invalid sketch from %s: %s", peer, err.Error())}
+		}
+	}
+	return series, nil
+}
+
+// Handler serves the series owned by this node to its peers.
+func (c *Cache) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		respond := func(status int, response fetchResponse) {
+			encoded, err := json.Marshal(response)
+			if err != nil {
+				status = http.StatusInternalServerError
+				encoded = []byte(`{"message": "Failed to encode the series."}`)
+			}
+			writer.WriteHeader(status)
+			writer.Write(encoded)
+		}
+		if request.Method != "POST" {
+			respond(http.StatusMethodNotAllowed, fetchResponse{Message: fmt.Sprintf("unsupported method %s", request.Method)})
+			return
+		}
+		var body fetchRequest
+		encoded, err := io.ReadAll(request.Body)
+		if err == nil {
+			err = json.Unmarshal(encoded, &body)
+		}
+		if err != nil {
+			respond(http.StatusBadRequest, fetchResponse{Message: err.Error()})
+			return
+		}
+		timerange, err := api.NewTimerange(body.Start, body.End, body.Resolution)
+		if err != nil {
+			respond(http.StatusBadRequest, fetchResponse{Message: err.Error()})
+			return
+		}
+		metrics := make([]api.TaggedMetric, len(body.Metrics))
+		for i, metric := range body.Metrics {
+			metrics[i] = api.TaggedMetric{MetricKey: metric.MetricKey, TagSet: metric.TagSet}
+		}
+		// The requester found this node to be the owner, so it doesn't forward them.
+		series, err := c.fetchOwned(metrics, timeseries.RequestDetails{
+			SampleMethod: body.SampleMethod,
+			Timerange:    timerange,
+			Ctx:          request.Context(),
+			Labels:       body.Labels,
+		})
+		if err != nil {
+			response := fetchResponse{Message: err.Error()}
+			if fetchErr, ok := err.(timeseries.Error); ok {
+				response.Message = fetchErr.Message
+				response.Code = fetchErr.Code
+				response.Metric = &wireMetric{MetricKey: fetchErr.Metric.MetricKey, TagSet: fetchErr.Metric.TagSet}
+			}
+			respond(http.StatusUnprocessableEntity, response)
+			return
+		}
+		response := fetchResponse{Series: make([]wireSeries, len(series))}
+		for i, s := range series {
+			response.Series[i] = encodeSeries(s)
+		}
+		respond(http.StatusOK, response)
+	})
+}
@@ -0,0 +1,225 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peers
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/api"
+	"github.com/square/metrics/tdigest"
+	"github.com/square/metrics/testing_support/assert"
+	"github.com/square/metrics/timeseries"
+)
+
+// countingStorage returns [1, NaN, <length of the metric's name>] for every
+// series, with samples and a sketch of the last value, and counts how often
+// each is fetched.
+type countingStorage struct {
+	mutex   sync.Mutex
+	fetches map[api.MetricKey]int
+}
+
+func (s *countingStorage) ChooseResolution(requested api.Timerange, lowerBound time.Duration) (time.Duration, error) {
+	return requested.Resolution(), nil
+}
+
+func (s *countingStorage) FetchSingleTimeseries(request timeseries.FetchRequest) (api.Timeseries, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if request.Metric.MetricKey == "broken" {
+		return api.Timeseries{}, timeseries.Error{Metric: request.Metric, Code: timeseries.FetchIOError, Message: "disk on fire"}
+	}
+	s.fetches[request.Metric.MetricKey]++
+	sketch := tdigest.New(100)
+	sketch.Add(float64(len(request.Metric.MetricKey)))
+	return api.Timeseries{
+		TagSet:   request.Metric.TagSet,
+		Values:   []float64{1, math.NaN(), float64(len(request.Metric.MetricKey))},
+		Samples:  []int{2, 0, 1},
+		Sketches: []*tdigest.Digest{nil, nil, sketch},
+	}, nil
+}
+
+func (s *countingStorage) FetchMultipleTimeseries(request timeseries.FetchMultipleRequest) (api.SeriesList, error) {
+	list := api.SeriesList{}
+	for _, single := range request.ToSingle() {
+		series, err := s.FetchSingleTimeseries(single)
+		if err != nil {
+			return api.SeriesList{}, err
+		}
+		list.Series = append(list.Series, series)
+	}
+	return list, nil
+}
+
+func (s *countingStorage) CheckHealthy() error {
+	return nil
+}
+
+func (s *countingStorage) total() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	total := 0
+	for _, count := range s.fetches {
+		total += count
+	}
+	return total
+}
+
+// newCluster starts a node for each storage, all sharing one ring.
+func newCluster(t *testing.T, storages ...*countingStorage) ([]*Cache, []*httptest.Server) {
+	handlers := make([]http.Handler, len(storages))
+	servers := make([]*httptest.Server, len(storages))
+	urls := make([]string, len(storages))
+	for i := range storages {
+		i := i
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			handlers[i].ServeHTTP(writer, request)
+		}))
+		urls[i] = servers[i].URL
+	}
+	caches := make([]*Cache, len(storages))
+	for i, storage := range storages {
+		cache, err := NewCache(storage, Config{Self: urls[i], Peers: urls})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+		caches[i] = cache
+		handlers[i] = cache.Handler()
+	}
+	return caches, servers
+}
+
+// metricNames returns the names of the metrics fetched by the tests, the i-th
+// of which has length i+1. The ring depends on the servers' ports, so there
+// are enough of them that each node is all but certain to own some.
+func metricNames() []string {
+	names := make([]string, 32)
+	for i := range names {
+		names[i] = strings.Repeat(string(rune('a'+i%26)), i+1)
+	}
+	return names
+}
+
+func TestCacheSharesFetches(t *testing.T) {
+	a := assert.New(t)
+	storages := []*countingStorage{{fetches: map[api.MetricKey]int{}}, {fetches: map[api.MetricKey]int{}}}
+	caches, servers := newCluster(t, storages...)
+	defer servers[0].Close()
+	defer servers[1].Close()
+
+	timerange, err := api.NewTimerange(0, 60000, 30000)
+	a.CheckError(err)
+	request := timeseries.FetchMultipleRequest{RequestDetails: timeseries.RequestDetails{Timerange: timerange, SampleMethod: timeseries.SampleMean}}
+	for _, name := range metricNames() {
+		request.Metrics = append(request.Metrics, api.TaggedMetric{MetricKey: api.MetricKey(name), TagSet: api.TagSet{"host": name}})
+	}
+	for _, cache := range caches {
+		list, err := cache.FetchMultipleTimeseries(request)
+		a.CheckError(err)
+		a.EqInt(len(list.Series), len(request.Metrics))
+		for i, series := range list.Series {
+			a.Eq(series.TagSet, request.Metrics[i].TagSet)
+			a.EqInt(len(series.Values), 3)
+			a.EqFloat(series.Values[0], 1, 0)
+			a.EqBool(math.IsNaN(series.Values[1]), true)
+			a.EqFloat(series.Values[2], float64(i+1), 0)
+			// Samples and sketches survive both the cache and the peers.
+			a.Eq(series.Samples, []int{2, 0, 1})
+			if len(series.Sketches) != 3 || series.Sketches[0] != nil || series.Sketches[2] == nil {
+				t.Fatalf("the sketches of %s weren't kept: %#v", request.Metrics[i].MetricKey, series.Sketches)
+			}
+			a.EqFloat(series.Sketches[2].Quantile(0.5), float64(i+1), 0)
+		}
+	}
+	// Each series was fetched once, by its owner, although both nodes asked for all of them.
+	a.EqInt(storages[0].total()+storages[1].total(), len(request.Metrics))
+	a.EqBool(storages[0].total() > 0 && storages[1].total() > 0, true)
+	// The first node fetched its own series, and asked the second for the rest.
+	stats := caches[0].Stats()
+	a.EqInt(stats.Misses, storages[0].total())
+	a.EqInt(stats.PeerFetches, storages[1].total())
+
+	// A storage error on the owner is reported, rather than retried.
+	broken := request
+	broken.Metrics = []api.TaggedMetric{{MetricKey: "broken"}}
+	for _, cache := range caches {
+		_, err := cache.FetchMultipleTimeseries(broken)
+		fetchErr, ok := err.(timeseries.Error)
+		if !ok {
+			t.Fatalf("expected a timeseries.Error, but got %#v", err)
+		}
+		a.EqString(fetchErr.Message, "disk on fire")
+		a.Eq(fetchErr.Code, timeseries.FetchIOError)
+	}
+}
+
+func TestCacheUnreachablePeer(t *testing.T) {
+	a := assert.New(t)
+	storages := []*countingStorage{{fetches: map[api.MetricKey]int{}}, {fetches: map[api.MetricKey]int{}}}
+	caches, servers := newCluster(t, storages...)
+	defer servers[0].Close()
+	servers[1].Close()
+
+	timerange, err := api.NewTimerange(0, 60000, 30000)
+	a.CheckError(err)
+	request := timeseries.FetchMultipleRequest{RequestDetails: timeseries.RequestDetails{Timerange: timerange}}
+	for _, name := range metricNames() {
+		request.Metrics = append(request.Metrics, api.TaggedMetric{MetricKey: api.MetricKey(name), TagSet: api.TagSet{}})
+	}
+	list, err := caches[0].FetchMultipleTimeseries(request)
+	a.CheckError(err)
+	a.EqInt(len(list.Series), len(request.Metrics))
+	// The series owned by the closed node were fetched directly.
+	a.EqInt(storages[0].total(), len(request.Metrics))
+	a.EqInt(caches[0].Stats().PeerErrors, 1)
+}
+
+func TestCacheExpires(t *testing.T) {
+	a := assert.New(t)
+	storage := &countingStorage{fetches: map[api.MetricKey]int{}}
+	cache, err := NewCache(storage, Config{Self: "http://self/", Peers: []string{"http://self"}, MaxEntries: 1})
+	a.CheckError(err)
+	now := time.Unix(1000, 0)
+	cache.now = func() time.Time { return now }
+	timerange, err := api.NewTimerange(0, 60000, 30000)
+	a.CheckError(err)
+	fetch := func(name string) {
+		_, err := cache.FetchSingleTimeseries(timeseries.FetchRequest{
+			Metric:         api.TaggedMetric{MetricKey: api.MetricKey(name), TagSet: api.TagSet{}},
+			RequestDetails: timeseries.RequestDetails{Timerange: timerange},
+		})
+		a.CheckError(err)
+	}
+	fetch("a")
+	fetch("a")
+	a.EqInt(storage.total(), 1)
+	now = now.Add(time.Minute)
+	fetch("a") // expired
+	a.EqInt(storage.total(), 2)
+	fetch("b") // evicts a
+	fetch("a")
+	a.EqInt(storage.total(), 4)
+	a.Eq(cache.Stats(), Stats{Entries: 1, Hits: 1, Misses: 4})
+
+	_, err = NewCache(storage, Config{Self: "http://elsewhere", Peers: []string{"http://self"}})
+	a.EqBool(err != nil, true)
+}
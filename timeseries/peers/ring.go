@@ -0,0 +1,60 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peers
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// Ring assigns keys to nodes by consistent hashing: each node is placed at
+// several points on a circle of hashes, and a key belongs to the first node
+// found after its own hash. Adding or removing a node only moves the keys
+// next to its points, so the other nodes keep most of what they've cached.
+type Ring struct {
+	points []uint32          // sorted
+	nodes  map[uint32]string // by point
+}
+
+// NewRing places each node at the given number of points on the ring.
+func NewRing(replicas int, nodes ...string) *Ring {
+	ring := &Ring{nodes: map[uint32]string{}}
+	for _, node := range nodes {
+		for i := 0; i < replicas; i++ {
+			point := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + node))
+			if _, ok := ring.nodes[point]; ok {
+				continue // a collision; the node placed first keeps the point
+			}
+			ring.points = append(ring.points, point)
+			ring.nodes[point] = node
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+	return ring
+}
+
+// Owner returns the node owning the key, or "" if the ring is empty.
+func (ring *Ring) Owner(key string) string {
+	if len(ring.points) == 0 {
+		return ""
+	}
+	hash := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(ring.points), func(i int) bool { return ring.points[i] >= hash })
+	if i == len(ring.points) {
+		i = 0
+	}
+	return ring.nodes[ring.points[i]]
+}
@@ -0,0 +1,52 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peers
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	if owner := NewRing(50).Owner("key"); owner != "" {
+		t.Errorf("expected no owner on an empty ring, but got %q", owner)
+	}
+	nodes := []string{"http://a", "http://b", "http://c"}
+	ring := NewRing(50, nodes...)
+	counts := map[string]int{}
+	owners := map[string]string{}
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		owner := ring.Owner(key)
+		if owner != ring.Owner(key) {
+			t.Fatalf("the owner of %s changed", key)
+		}
+		counts[owner]++
+		owners[key] = owner
+	}
+	for _, node := range nodes {
+		// Each node should own roughly a third of the keys.
+		if counts[node] < 600 || counts[node] > 1400 {
+			t.Errorf("node %s owns %d of 3000 keys", node, counts[node])
+		}
+	}
+	// Removing a node only moves the keys it owned.
+	smaller := NewRing(50, "http://a", "http://b")
+	for key, owner := range owners {
+		if owner != "http://c" && smaller.Owner(key) != owner {
+			t.Errorf("key %s moved from %s to %s", key, owner, smaller.Owner(key))
+		}
+	}
+}
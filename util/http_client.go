@@ -0,0 +1,55 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"net/http"
+	"time"
+)
+
+// HTTPClientConfig tunes the connection pool of a client of an HTTP backend.
+// Go's default client keeps only two idle connections to each host, so a
+// deployment making many concurrent requests keeps opening new ones.
+// Fields which are zero keep Go's defaults.
+type HTTPClientConfig struct {
+	MaxIdleConns        int           `yaml:"max_idle_conns"`          // idle connections kept across all hosts
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host"` // idle connections kept to each host
+	MaxConnsPerHost     int           `yaml:"max_conns_per_host"`      // connections to each host, whether active or idle
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout"`       // how long idle connections are kept
+	DisableHTTP2        bool          `yaml:"disable_http2"`           // use HTTP/1.1 even when the server offers HTTP/2
+}
+
+// NewClient creates a client with its own connection pool.
+func (c HTTPClientConfig) NewClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.MaxIdleConns != 0 {
+		transport.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.MaxConnsPerHost != 0 {
+		transport.MaxConnsPerHost = c.MaxConnsPerHost
+	}
+	if c.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP1(true)
+	}
+	return &http.Client{Transport: transport}
+}
@@ -17,13 +17,49 @@ package util
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 var OrdinaryIdentifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z_0-9]*(\.[A-Za-z_][A-Za-z_0-9]*)*$`)
 
+// keywords cannot begin an unquoted identifier in the query language.
+var keywords = map[string]bool{
+	"all":        true,
+	"and":        true,
+	"as":         true,
+	"by":         true,
+	"describe":   true,
+	"group":      true,
+	"collapse":   true,
+	"in":         true,
+	"match":      true,
+	"not":        true,
+	"or":         true,
+	"select":     true,
+	"where":      true,
+	"metrics":    true,
+	"keys":       true,
+	"values":     true,
+	"from":       true,
+	"to":         true,
+	"resolution": true,
+	"sample":     true,
+	"unless":     true,
+}
+
+var identifierEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+var stringEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`", `"`, `\"`)
+
 func EscapeIdentifier(identifier string) string {
-	if !OrdinaryIdentifierRegex.MatchString(identifier) {
-		return fmt.Sprintf("`%s`", identifier)
+	firstSegment := strings.ToLower(strings.SplitN(identifier, ".", 2)[0])
+	if !OrdinaryIdentifierRegex.MatchString(identifier) || keywords[firstSegment] {
+		return fmt.Sprintf("`%s`", identifierEscaper.Replace(identifier))
 	}
 	return identifier
 }
+
+// EscapeString quotes the given value as a string literal which the query
+// parser reads back unchanged.
+func EscapeString(value string) string {
+	return fmt.Sprintf(`"%s"`, stringEscaper.Replace(value))
+}
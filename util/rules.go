@@ -253,6 +253,11 @@ func (rule Rule) GraphitePatternTags() []string {
 	return rule.graphitePatternTags
 }
 
+// Raw returns the rule as it was given, before it was compiled.
+func (rule Rule) Raw() RawRule {
+	return rule.raw
+}
+
 // ToGraphiteName transforms the given tagged metric back to its graphite name,
 // checking against all the rules.
 func (ruleSet RuleSet) ToGraphiteName(taggedMetric api.TaggedMetric) (GraphiteMetric, error) {
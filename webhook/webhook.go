@@ -0,0 +1,222 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook notifies external services of events in the query
+// engine, such as slow queries, by posting to their URLs.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/square/metrics/log"
+)
+
+// The types of events.
+const (
+	SlowQuery          = "slow_query"           // a query ran for longer than the hook's threshold
+	QueryRejected      = "query_rejected"       // a query exceeded a limit, such as the fetch limit or timeout
+	BackendBreakerOpen = "backend_breaker_open" // an endpoint of a backend was ejected from its pool after repeated failures
+)
+
+var eventTypes = map[string]bool{
+	SlowQuery:          true,
+	QueryRejected:      true,
+	BackendBreakerOpen: true,
+}
+
+// An Event is something which happened in the query engine. Fields which
+// don't apply to the type of event are empty.
+type Event struct {
+	Type    string                 `json:"type"`
+	Time    time.Time              `json:"time"`
+	Query   string                 `json:"query,omitempty"`
+	Seconds float64                `json:"seconds,omitempty"` // how long the query ran
+	Message string                 `json:"message,omitempty"`
+	Code    string                 `json:"code,omitempty"` // the error code, for rejected queries
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Config describes a webhook.
+type Config struct {
+	Name             string            `yaml:"name"`
+	URL              string            `yaml:"url"`
+	Events           []string          `yaml:"events"`             // the types of events which are posted
+	Headers          map[string]string `yaml:"headers"`            // sent with each request, such as Authorization
+	Template         string            `yaml:"template"`           // optional. A text/template for the body, given the Event; defaults to its JSON
+	ContentType      string            `yaml:"content_type"`       // optional. Defaults to application/json
+	SlowQuerySeconds float64           `yaml:"slow_query_seconds"` // optional. The threshold for slow_query events; defaults to 10
+	Retries          int               `yaml:"retries"`            // optional. Attempts after the first failure; defaults to 3
+	RetryDelayMillis int               `yaml:"retry_delay_millis"` // optional. Doubles after each attempt; defaults to 1000
+	TimeoutMillis    int               `yaml:"timeout_millis"`     // optional. The timeout of each attempt; defaults to 5000
+}
+
+type hook struct {
+	Config
+	events     map[string]bool
+	template   *template.Template // nil for the default body
+	slowQuery  time.Duration
+	retries    int
+	retryDelay time.Duration
+	client     *http.Client
+}
+
+// Dispatcher posts events to the webhooks which subscribe to them. A nil
+// Dispatcher discards events.
+type Dispatcher struct {
+	hooks []hook
+	sleep func(time.Duration)
+}
+
+// templateFunctions are available to payload templates. `json` encodes a
+// value, so that strings are quoted and escaped.
+var templateFunctions = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+// NewDispatcher checks the configurations of the webhooks. It returns nil
+// if there are none.
+func NewDispatcher(configs []Config) (*Dispatcher, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	dispatcher := &Dispatcher{hooks: make([]hook, len(configs)), sleep: time.Sleep}
+	for i, config := range configs {
+		if config.URL == "" {
+			return nil, fmt.Errorf("webhook %q has no URL", config.Name)
+		}
+		if len(config.Events) == 0 {
+			return nil, fmt.Errorf("webhook %q subscribes to no events", config.Name)
+		}
+		h := hook{
+			Config:     config,
+			events:     map[string]bool{},
+			slowQuery:  10 * time.Second,
+			retries:    3,
+			retryDelay: time.Second,
+			client:     &http.Client{Timeout: 5 * time.Second},
+		}
+		for _, event := range config.Events {
+			if !eventTypes[event] {
+				return nil, fmt.Errorf("webhook %q subscribes to unknown event %q", config.Name, event)
+			}
+			h.events[event] = true
+		}
+		if config.Template != "" {
+			parsed, err := template.New(config.Name).Funcs(templateFunctions).Parse(config.Template)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %q has an invalid template: %s", config.Name, err.Error())
+			}
+			h.template = parsed
+		}
+		if config.ContentType == "" {
+			h.ContentType = "application/json"
+		}
+		if config.SlowQuerySeconds > 0 {
+			h.slowQuery = time.Duration(config.SlowQuerySeconds * float64(time.Second))
+		}
+		if config.Retries > 0 {
+			h.retries = config.Retries
+		}
+		if config.RetryDelayMillis > 0 {
+			h.retryDelay = time.Duration(config.RetryDelayMillis) * time.Millisecond
+		}
+		if config.TimeoutMillis > 0 {
+			h.client.Timeout = time.Duration(config.TimeoutMillis) * time.Millisecond
+		}
+		dispatcher.hooks[i] = h
+	}
+	return dispatcher, nil
+}
+
+// Fire posts the event to each webhook which subscribes to it, in the
+// background. The Time of the event is filled in if it is zero.
+func (d *Dispatcher) Fire(event Event) {
+	if d == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for i := range d.hooks {
+		h := &d.hooks[i]
+		if !h.events[event.Type] {
+			continue
+		}
+		if event.Type == SlowQuery && event.Seconds < h.slowQuery.Seconds() {
+			continue
+		}
+		body, err := h.payload(event)
+		if err != nil {
+			log.Errorf("Webhook %q cannot render its payload for %s: %s", h.Name, event.Type, err.Error())
+			continue
+		}
+		go d.deliver(h, body)
+	}
+}
+
+func (h *hook) payload(event Event) ([]byte, error) {
+	if h.template == nil {
+		return json.Marshal(event)
+	}
+	buffer := bytes.Buffer{}
+	if err := h.template.Execute(&buffer, event); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// deliver posts the body, retrying with exponential backoff.
+func (d *Dispatcher) deliver(h *hook, body []byte) {
+	delay := h.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := h.post(body)
+		if err == nil {
+			return
+		}
+		if attempt == h.retries {
+			log.Errorf("Webhook %q failed after %d attempts: %s", h.Name, attempt+1, err.Error())
+			return
+		}
+		d.sleep(delay)
+		delay *= 2
+	}
+}
+
+func (h *hook) post(body []byte) error {
+	request, err := http.NewRequest("POST", h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", h.ContentType)
+	for name, value := range h.Headers {
+		request.Header.Set(name, value)
+	}
+	response, err := h.client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("status %s", response.Status)
+	}
+	return nil
+}
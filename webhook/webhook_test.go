@@ -0,0 +1,106 @@
+// Copyright 2015 - 2016 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/square/metrics/testing_support/assert"
+)
+
+func TestDispatcher(t *testing.T) {
+	a := assert.New(t)
+	var requests int32
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// Fail the first request, to exercise retries.
+		if atomic.AddInt32(&requests, 1) == 1 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(request.Body)
+		bodies <- request.URL.Path + " " + request.Header.Get("Content-Type") + " " + string(body)
+	}))
+	defer server.Close()
+
+	dispatcher, err := NewDispatcher([]Config{
+		{
+			Name:             "chat",
+			URL:              server.URL + "/chat",
+			Events:           []string{SlowQuery, QueryRejected},
+			Template:         `{"text": {{json (printf "%s took %.0fs" .Query .Seconds)}}}`,
+			SlowQuerySeconds: 30,
+		},
+		{
+			Name:        "pager",
+			URL:         server.URL + "/pager",
+			Events:      []string{QueryRejected},
+			ContentType: "text/plain",
+			Template:    `{{.Code}}: {{.Message}}`,
+		},
+	})
+	a.CheckError(err)
+	dispatcher.sleep = func(time.Duration) {}
+
+	receive := func() string {
+		select {
+		case body := <-bodies:
+			return body
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for a webhook")
+		}
+		return ""
+	}
+
+	dispatcher.Fire(Event{Type: SlowQuery, Query: "select fast", Seconds: 29}) // below the threshold
+	dispatcher.Fire(Event{Type: SlowQuery, Query: "select \"slow\"", Seconds: 45})
+	a.EqString(receive(), `/chat application/json {"text": "select \"slow\" took 45s"}`)
+	a.EqInt(int(atomic.LoadInt32(&requests)), 2) // the first attempt was retried
+
+	dispatcher.Fire(Event{Type: QueryRejected, Code: "fetch_limit", Message: "too many series"})
+	received := map[string]bool{receive(): true, receive(): true}
+	a.Eq(received, map[string]bool{
+		`/chat application/json {"text": " took 0s"}`:    true,
+		`/pager text/plain fetch_limit: too many series`: true,
+	})
+
+	var nothing *Dispatcher
+	nothing.Fire(Event{Type: SlowQuery}) // a nil Dispatcher discards events
+
+	for _, invalid := range []Config{
+		{Name: "no url", Events: []string{SlowQuery}},
+		{Name: "no events", URL: server.URL},
+		{Name: "unknown event", URL: server.URL, Events: []string{"query_finished"}},
+		{Name: "alerts", URL: server.URL, Events: []string{"alert_state_changed"}}, // alert rules aren't evaluated by the server
+		{Name: "bad template", URL: server.URL, Events: []string{SlowQuery}, Template: "{{.Query"},
+	} {
+		if _, err := NewDispatcher([]Config{invalid}); err == nil {
+			t.Errorf("expected an error for webhook %q", invalid.Name)
+		}
+	}
+}
+
+func TestDefaultPayload(t *testing.T) {
+	a := assert.New(t)
+	h := hook{}
+	body, err := h.payload(Event{Type: QueryRejected, Time: time.Date(2016, 10, 1, 0, 0, 0, 0, time.UTC), Query: "select x", Code: "query_timeout"})
+	a.CheckError(err)
+	a.EqString(string(body), `{"type":"query_rejected","time":"2016-10-01T00:00:00Z","query":"select x","code":"query_timeout"}`)
+}